@@ -0,0 +1,346 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module renders an animation of a 3D pipeline by sweeping its Z axis as
+time and writes the frames as an animated GIF or APNG, or streams them into
+ffmpeg to encode a video:
+
+	anim := noisey.NewAnimationExporter(&fbm3d, 256, 256, 60)
+	anim.Gradient = gradient
+	err := anim.WriteGIF(file)
+	err = anim.WriteVideo("", "preview.mp4")
+
+Frame i samples the Source at Z = StartTime + (EndTime-StartTime)*i/Frames,
+so EndTime itself is never reached and a Source that repeats over that time
+loops without a repeated frame. Each frame is shown for Delay hundredths of a
+second and both formats loop forever.
+
+WriteVideo() runs ffmpeg with the frames as raw RGBA on its standard input
+and writes yuv420p video, which most players need, so the frames must have
+an even width and height. WriteRawFrames() writes the same raw stream to any
+io.Writer for other encoders.
+
+The frames are colored with the Gradient, or as grayscale with [-1, 1]
+mapped to [0, 255] if the Gradient has no keys. A GIF has a palette of 256
+colors sampled evenly along the Gradient, which keeps smooth ramps free of
+dithering; an APNG keeps the full colors.
+
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+)
+
+// AnimationExporter contains the parameters for rendering an animation.
+type AnimationExporter struct {
+	Source    NoiseyGet3D     // the pipeline with Z as time
+	Width     int             // the width of the frames in pixels
+	Height    int             // the height of the frames in pixels
+	Bounds    Builder2DBounds // the area of the pipeline to render
+	Frames    int             // the number of frames
+	StartTime float64         // the Z of the first frame
+	EndTime   float64         // the Z the frames sweep towards
+	Delay     int             // the time each frame is shown in hundredths of a second
+	Gradient  ColorGradient   // the colors of the frames
+}
+
+// NewAnimationExporter creates a new exporter for an animation of frames
+// images of width x height pixels over the unit square, sweeping time from
+// 0.0 to 1.0 at 25 frames a second.
+func NewAnimationExporter(src NoiseyGet3D, width int, height int, frames int) (ae AnimationExporter) {
+	ae.Source = src
+	ae.Width = width
+	ae.Height = height
+	ae.Bounds = Builder2DBounds{0.0, 0.0, 1.0, 1.0}
+	ae.Frames = frames
+	ae.StartTime = 0.0
+	ae.EndTime = 1.0
+	ae.Delay = 4
+	return
+}
+
+// String returns a description of the exporter and its source.
+func (ae *AnimationExporter) String() string {
+	return fmt.Sprintf("AnimationExporter{Width: %v, Height: %v, Bounds: %v, Frames: %v, StartTime: %v, EndTime: %v, Delay: %v, Source: %s}",
+		ae.Width, ae.Height, ae.Bounds, ae.Frames, ae.StartTime, ae.EndTime, ae.Delay, describeModule(ae.Source))
+}
+
+// build builds every frame into one volume with a frame for each Z.
+func (ae *AnimationExporter) build() (Builder3D, error) {
+	if ae.Source == nil {
+		return Builder3D{}, fmt.Errorf("Unable to render an animation without a Source.\n")
+	}
+	if ae.Width <= 0 || ae.Height <= 0 || ae.Frames <= 0 {
+		return Builder3D{}, fmt.Errorf("Unable to render an animation of %d frames of %dx%d.\n", ae.Frames, ae.Width, ae.Height)
+	}
+	if ae.Delay < 0 {
+		return Builder3D{}, fmt.Errorf("Unable to render an animation with a Delay of %d.\n", ae.Delay)
+	}
+	builder := NewBuilder3D(ae.Source, ae.Width, ae.Height, ae.Frames)
+	builder.Bounds = Builder3DBounds{ae.Bounds.MinX, ae.Bounds.MinY, ae.StartTime, ae.Bounds.MaxX, ae.Bounds.MaxY, ae.EndTime}
+	err := builder.Build()
+	return builder, err
+}
+
+// color returns the color of a value of a frame.
+func (ae *AnimationExporter) color(v float64) color.NRGBA {
+	if len(ae.Gradient.Keys) == 0 {
+		return color.NRGBA{encodeUnit(v), encodeUnit(v), encodeUnit(v), 255}
+	}
+	return ae.Gradient.Color(v)
+}
+
+// frameImage returns the colored image of frame f of the volume.
+func (ae *AnimationExporter) frameImage(volume *Builder3D, f int) *image.NRGBA {
+	size := ae.Width * ae.Height
+	img := image.NewNRGBA(image.Rect(0, 0, ae.Width, ae.Height))
+	for i, v := range volume.Values[f*size : (f+1)*size] {
+		img.SetNRGBA(i%ae.Width, i/ae.Width, ae.color(v))
+	}
+	return img
+}
+
+// RenderFrames returns the colored image of every frame.
+func (ae *AnimationExporter) RenderFrames() ([]*image.NRGBA, error) {
+	volume, err := ae.build()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]*image.NRGBA, ae.Frames)
+	for f := range frames {
+		frames[f] = ae.frameImage(&volume, f)
+	}
+	return frames, nil
+}
+
+// WriteRawFrames renders the animation and writes every frame to w as raw
+// RGBA pixels, 4 bytes a pixel row by row from the top left, with no header
+// or padding between the frames.
+func (ae *AnimationExporter) WriteRawFrames(w io.Writer) error {
+	volume, err := ae.build()
+	if err != nil {
+		return err
+	}
+	for f := 0; f < ae.Frames; f++ {
+		if _, err = w.Write(ae.frameImage(&volume, f).Pix); err != nil {
+			return fmt.Errorf("Unable to write frame %d of the animation.\n%v\n", f, err)
+		}
+	}
+	return nil
+}
+
+// FrameRate returns the frames per second given by Delay.
+func (ae *AnimationExporter) FrameRate() float64 {
+	if ae.Delay <= 0 {
+		return 100.0
+	}
+	return 100.0 / float64(ae.Delay)
+}
+
+// WriteVideo renders the animation and streams the frames into an ffmpeg
+// process that encodes them into the video file output. The format is picked
+// by ffmpeg from the extension of output, and args are passed to ffmpeg
+// after the input, before output, for options like the codec. If ffmpeg is
+// empty it's looked for in the PATH.
+func (ae *AnimationExporter) WriteVideo(ffmpeg string, output string, args ...string) error {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	if ae.Width%2 != 0 || ae.Height%2 != 0 {
+		return fmt.Errorf("Unable to write a video of %dx%d frames; the width and height must be even.\n", ae.Width, ae.Height)
+	}
+	cmdArgs := []string{"-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", ae.Width, ae.Height),
+		"-framerate", strconv.FormatFloat(ae.FrameRate(), 'g', -1, 64), "-i", "-"}
+	cmdArgs = append(cmdArgs, args...)
+	cmdArgs = append(cmdArgs, "-pix_fmt", "yuv420p", output)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(ffmpeg, cmdArgs...)
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("Unable to open a pipe to ffmpeg.\n%v\n", err)
+	}
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("Unable to start ffmpeg (%s).\n%v\n", ffmpeg, err)
+	}
+	writeErr := ae.WriteRawFrames(stdin)
+	stdin.Close()
+	if err = cmd.Wait(); err != nil {
+		return fmt.Errorf("Unable to encode the video with ffmpeg.\n%v\n%s", err, stderr.String())
+	}
+	return writeErr
+}
+
+// WriteGIF renders the animation and writes it to w as an animated GIF.
+func (ae *AnimationExporter) WriteGIF(w io.Writer) error {
+	volume, err := ae.build()
+	if err != nil {
+		return err
+	}
+
+	// the palette is sampled evenly from lo to hi so each value maps
+	// straight to its index
+	lo, hi := -1.0, 1.0
+	if n := len(ae.Gradient.Keys); n > 0 {
+		lo, hi = ae.Gradient.Keys[0].Position, ae.Gradient.Keys[n-1].Position
+	}
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = ae.color(lerp(lo, hi, float64(i)/255.0))
+	}
+
+	anim := gif.GIF{Image: make([]*image.Paletted, ae.Frames), Delay: make([]int, ae.Frames)}
+	size := ae.Width * ae.Height
+	for f := range anim.Image {
+		img := image.NewPaletted(image.Rect(0, 0, ae.Width, ae.Height), palette)
+		for i, v := range volume.Values[f*size : (f+1)*size] {
+			t := 0.0
+			if hi > lo {
+				t = (v - lo) / (hi - lo)
+			}
+			img.Pix[i] = uint8(math.Max(0.0, math.Min(255.0, math.Floor(t*255.0+0.5))))
+		}
+		anim.Image[f] = img
+		anim.Delay[f] = ae.Delay
+	}
+	if err = gif.EncodeAll(w, &anim); err != nil {
+		return fmt.Errorf("Unable to encode the animation as a GIF.\n%v\n", err)
+	}
+	return nil
+}
+
+// pngChunk is one chunk of a PNG stream.
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// readPNGChunks splits an encoded PNG into its chunks after the signature.
+func readPNGChunks(encoded []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+	b := encoded[8:]
+	for len(b) >= 12 {
+		n := int(binary.BigEndian.Uint32(b[:4]))
+		if len(b) < 12+n {
+			break
+		}
+		chunks = append(chunks, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	if len(chunks) == 0 || chunks[0].kind != "IHDR" {
+		return nil, fmt.Errorf("Unable to read the chunks of an encoded PNG frame.\n")
+	}
+	return chunks, nil
+}
+
+// writePNGChunk writes a chunk with its length and CRC.
+func writePNGChunk(w io.Writer, kind string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rgbaFrame is a frame that claims to have transparency, so that image/png
+// encodes it as RGBA even when every pixel is opaque.
+type rgbaFrame struct {
+	*image.NRGBA
+}
+
+// Opaque returns false for every frame.
+func (rgbaFrame) Opaque() bool {
+	return false
+}
+
+// WriteAPNG renders the animation and writes it to w as an animated PNG.
+func (ae *AnimationExporter) WriteAPNG(w io.Writer) error {
+	frames, err := ae.RenderFrames()
+	if err != nil {
+		return err
+	}
+
+	// every frame is encoded as an RGBA PNG of its own, as the frames of an
+	// APNG share the IHDR of the first, and its image data is moved into the
+	// frame chunks of one stream; the first frame is also the default image
+	const pngSignature = "\x89PNG\r\n\x1a\n"
+	if _, err = io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+	seq := uint32(0)
+	for f, img := range frames {
+		var encoded bytes.Buffer
+		if err = png.Encode(&encoded, rgbaFrame{img}); err != nil {
+			return fmt.Errorf("Unable to encode frame %d of the animation as a PNG.\n%v\n", f, err)
+		}
+		var chunks []pngChunk
+		if chunks, err = readPNGChunks(encoded.Bytes()); err != nil {
+			return err
+		}
+		if f == 0 {
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[:4], uint32(len(frames)))
+			if err = writePNGChunk(w, "IHDR", chunks[0].data); err != nil {
+				return err
+			}
+			if err = writePNGChunk(w, "acTL", actl); err != nil {
+				return err
+			}
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(ae.Width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(ae.Height))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(ae.Delay))
+		binary.BigEndian.PutUint16(fctl[22:], 100)
+		seq++
+		if err = writePNGChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			if c.kind != "IDAT" {
+				continue
+			}
+			if f == 0 {
+				err = writePNGChunk(w, "IDAT", c.data)
+			} else {
+				fdat := make([]byte, 4+len(c.data))
+				binary.BigEndian.PutUint32(fdat, seq)
+				copy(fdat[4:], c.data)
+				seq++
+				err = writePNGChunk(w, "fdAT", fdat)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
@@ -0,0 +1,58 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// TestWriteAPNGMixedAlpha makes sure an animation with both opaque and
+// translucent frames is written with the same RGBA color type for every
+// frame.
+func TestWriteAPNGMixedAlpha(t *testing.T) {
+	// the frames alternate between the ends of the gradient
+	src := NoiseFunc3D(func(x, y, z float64) float64 {
+		if z < 0.5 {
+			return 1.0
+		}
+		return -1.0
+	})
+	ae := NewAnimationExporter(src, 4, 4, 2)
+	ae.Gradient = NewColorGradient(
+		ColorKey{-1.0, color.NRGBA{0, 0, 255, 128}},
+		ColorKey{1.0, color.NRGBA{255, 0, 0, 255}})
+
+	var buf bytes.Buffer
+	if err := ae.WriteAPNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := chunks[0].data[9]; ct != 6 {
+		t.Errorf("the IHDR has the color type %d; expected 6 for RGBA", ct)
+	}
+	frames := 0
+	for _, c := range chunks {
+		if c.kind == "fcTL" {
+			frames++
+		}
+	}
+	if frames != 2 {
+		t.Errorf("wrote %d frames; expected 2", frames)
+	}
+
+	// the first frame is the default image that any PNG decoder reads
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(img.At(0, 0)); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("the first frame starts with %v", c)
+	}
+}
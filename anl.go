@@ -0,0 +1,611 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module imports the expressions of the Accidental Noise Library (ANL)
+as noisey pipelines, so that terrain recipes published for ANL can be used
+without porting them by hand:
+
+	terrain, err := noisey.ParseANL(`
+		base = simplefBm(1, 3, 6, 2.0, 1234)
+		mask = select(0, 1, base, 0.1, 0.05)
+		terrain = mix(base * 0.25, base, mask)
+	`)
+	builder := noisey.NewBuilder2D(terrain, 256, 256)
+
+An expression is made of numbers, the operators + - * / and ^ (power),
+parentheses and calls of the ANL functions, with x, y and radial as the
+coordinates. An instruction list is written as one assignment per line or
+separated by ';', where a name can be used by the lines after it, and the
+value of the last line is the result. Comments run from '#' or '//' to the
+end of the line.
+
+The basis functions become noisey sources seeded with their seed and the
+fractals noisey generators, while the other functions are evaluated as
+ANL does and, like in ANL, can take other expressions as their arguments
+so they vary over the map. The seeds, interpolation types, basis types and
+octave counts have to be numbers.
+
+The functions understood are:
+
+* gradientBasis(interp, seed), simplexBasis(seed) and
+  cellularBasis(f1, f2, f3, f4, d1, d2, d3, d4, distance, seed) with the
+  F1, F2 or F2 - F1 coefficients and euclidean distances
+* simplefBm, simpleRidgedMultifractal and simpleBillow(basis, interp,
+  octaves, frequency, seed), with an optional rotation argument that is
+  ignored
+* add, subtract, multiply, divide, pow, min, max (or minimum, maximum),
+  abs, sqrt, sin, cos, tan, asin, acos and atan
+* bias(src, b), gain(src, g), clamp(src, low, high), sigmoid(src, center,
+  ramp), tiers(src, n) and smoothTiers(src, n)
+* mix or blend(low, high, control) and select(low, high, control,
+  threshold, falloff)
+* scaleDomain, scaleX, scaleY, translateDomain, translateX, translateY and
+  rotateDomain(src, angle) or rotateDomain(src, angle, ax, ay, az) about
+  the Z axis
+
+Maps are 2D, so z is always 0 and the domain functions of Z are ignored.
+The pipelines have the structure of the ANL recipe, but the bases are
+those of noisey: a seed doesn't make the same values as it does in ANL, the
+gradient basis comes out in about the same range and cellular distances
+are in [0, 1].
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// anlValue is the value of an ANL expression: a module, or a number that
+// is kept so that it can be folded and used as a parameter.
+type anlValue struct {
+	module   NoiseyGet2D
+	constant bool
+	number   float64
+}
+
+// anlNumber returns a constant value.
+func anlNumber(v float64) anlValue {
+	return anlValue{module: anlConstant(v), constant: true, number: v}
+}
+
+// anlConstant returns a module of a single value.
+func anlConstant(v float64) NoiseyGet2D {
+	return NoiseFunc2D(func(x, y float64) float64 { return v })
+}
+
+// anlMap returns the value of f applied to the values of the arguments,
+// folded if they are all constants.
+func anlMap(f func(v []float64) float64, args ...anlValue) anlValue {
+	constant := true
+	numbers := make([]float64, len(args))
+	for i, a := range args {
+		constant = constant && a.constant
+		numbers[i] = a.number
+	}
+	if constant {
+		return anlNumber(f(numbers))
+	}
+	return anlValue{module: NoiseFunc2D(func(x, y float64) float64 {
+		v := make([]float64, len(args))
+		for i, a := range args {
+			v[i] = a.module.Get2D(x, y)
+		}
+		return f(v)
+	})}
+}
+
+// anlDomain returns src sampled at a coordinate transformed by f, which
+// also gets the values of the arguments at the coordinate.
+func anlDomain(src anlValue, f func(x, y float64, v []float64) (float64, float64), args ...anlValue) anlValue {
+	if src.constant {
+		return src
+	}
+	return anlValue{module: NoiseFunc2D(func(x, y float64) float64 {
+		v := make([]float64, len(args))
+		for i, a := range args {
+			v[i] = a.module.Get2D(x, y)
+		}
+		return src.module.Get2D(f(x, y, v))
+	})}
+}
+
+// anlBias is the bias function of ANL.
+func anlBias(v float64, b float64) float64 {
+	return math.Pow(v, math.Log(b)/math.Log(0.5))
+}
+
+// anlFunction builds the value of a call from its arguments.
+type anlFunction struct {
+	minArgs int
+	maxArgs int
+	build   func(p *anlParser, args []anlValue) (anlValue, error)
+}
+
+// anlMath returns a function of numbers with the number of arguments.
+func anlMath(n int, f func(v []float64) float64) anlFunction {
+	return anlFunction{n, n, func(p *anlParser, args []anlValue) (anlValue, error) {
+		return anlMap(f, args...), nil
+	}}
+}
+
+// anlFractal returns a fractal function that builds its generator with
+// build from the source of the basis.
+func anlFractal(build func(src NoiseyGet2D, octaves int, frequency float64) NoiseyGet2D) anlFunction {
+	return anlFunction{5, 6, func(p *anlParser, args []anlValue) (anlValue, error) {
+		n, err := p.numbers(args[:5], "basis", "interp", "octaves", "frequency", "seed")
+		if err != nil {
+			return anlValue{}, err
+		}
+		var src NoiseyGet2D
+		switch int(n[0]) {
+		case 1:
+			perlin := NewPerlinGeneratorWithQuality(rand.New(rand.NewSource(int64(n[4]))), anlQuality(n[1]))
+			src = &perlin
+		case 2:
+			simplex := NewOpenSimplexGenerator(rand.New(rand.NewSource(int64(n[4]))))
+			src = &simplex
+		default:
+			return anlValue{}, fmt.Errorf("basis %v isn't one of the gradient (1) or simplex (2) bases", n[0])
+		}
+		return anlValue{module: build(src, int(n[2]), n[3])}, nil
+	}}
+}
+
+// anlQuality returns the quality of an ANL interpolation type.
+func anlQuality(interp float64) NoiseQuality {
+	switch int(interp) {
+	case 2:
+		return QualityStandard
+	case 3:
+		return QualityBest
+	}
+	return QualityFast
+}
+
+// anlFunctions are the functions understood by ParseANL().
+var anlFunctions map[string]anlFunction
+
+func init() {
+	unary := func(f func(float64) float64) anlFunction {
+		return anlMath(1, func(v []float64) float64 { return f(v[0]) })
+	}
+	add := anlMath(2, func(v []float64) float64 { return v[0] + v[1] })
+	subtract := anlMath(2, func(v []float64) float64 { return v[0] - v[1] })
+	multiply := anlMath(2, func(v []float64) float64 { return v[0] * v[1] })
+	divide := anlMath(2, func(v []float64) float64 { return v[0] / v[1] })
+	pow := anlMath(2, func(v []float64) float64 { return math.Pow(v[0], v[1]) })
+	minimum := anlMath(2, func(v []float64) float64 { return math.Min(v[0], v[1]) })
+	maximum := anlMath(2, func(v []float64) float64 { return math.Max(v[0], v[1]) })
+	mix := anlMath(3, func(v []float64) float64 { return lerp(v[0], v[1], v[2]) })
+	tiers := func(smooth bool) anlFunction {
+		return anlMath(2, func(v []float64) float64 {
+			steps := math.Floor(v[1])
+			if smooth {
+				steps--
+			}
+			bottom := math.Floor(v[0] * steps)
+			t := v[0]*steps - bottom
+			u := 0.0
+			if smooth {
+				u = QualityBest.sCurve(t)
+			}
+			return (bottom + u) / steps
+		})
+	}
+	scale := func(sx, sy bool) anlFunction {
+		return anlFunction{2, 2, func(p *anlParser, args []anlValue) (anlValue, error) {
+			return anlDomain(args[0], func(x, y float64, v []float64) (float64, float64) {
+				if sx {
+					x *= v[0]
+				}
+				if sy {
+					y *= v[0]
+				}
+				return x, y
+			}, args[1]), nil
+		}}
+	}
+	translate := func(tx, ty bool) anlFunction {
+		return anlFunction{2, 2, func(p *anlParser, args []anlValue) (anlValue, error) {
+			return anlDomain(args[0], func(x, y float64, v []float64) (float64, float64) {
+				if tx {
+					x += v[0]
+				}
+				if ty {
+					y += v[0]
+				}
+				return x, y
+			}, args[1]), nil
+		}}
+	}
+
+	anlFunctions = map[string]anlFunction{
+		"add": add, "subtract": subtract, "multiply": multiply, "divide": divide, "pow": pow,
+		"min": minimum, "minimum": minimum, "max": maximum, "maximum": maximum,
+		"abs": unary(math.Abs), "sqrt": unary(math.Sqrt),
+		"sin": unary(math.Sin), "cos": unary(math.Cos), "tan": unary(math.Tan),
+		"asin": unary(math.Asin), "acos": unary(math.Acos), "atan": unary(math.Atan),
+		"bias": anlMath(2, func(v []float64) float64 { return anlBias(v[0], v[1]) }),
+		"gain": anlMath(2, func(v []float64) float64 {
+			if v[0] < 0.5 {
+				return anlBias(v[0]*2.0, 1.0-v[1]) * 0.5
+			}
+			return 1.0 - anlBias(2.0-v[0]*2.0, 1.0-v[1])*0.5
+		}),
+		"clamp": anlMath(3, func(v []float64) float64 { return math.Max(v[1], math.Min(v[2], v[0])) }),
+		"sigmoid": anlMath(3, func(v []float64) float64 {
+			return 1.0 / (1.0 + math.Exp(-v[2]*(v[0]-v[1])))
+		}),
+		"tiers": tiers(false), "smoothTiers": tiers(true),
+		"mix": mix, "blend": mix,
+		"select": anlMath(5, func(v []float64) float64 {
+			low, high, control, threshold, falloff := v[0], v[1], v[2], v[3], v[4]
+			if falloff > 0.0 {
+				switch {
+				case control < threshold-falloff:
+					return low
+				case control > threshold+falloff:
+					return high
+				}
+				t := (control - (threshold - falloff)) / (2.0 * falloff)
+				return lerp(low, high, QualityBest.sCurve(t))
+			}
+			if control < threshold {
+				return low
+			}
+			return high
+		}),
+		"scaleDomain": scale(true, true), "scaleX": scale(true, false), "scaleY": scale(false, true),
+		"translateDomain": translate(true, true), "translateX": translate(true, false), "translateY": translate(false, true),
+		"rotateDomain": {2, 5, func(p *anlParser, args []anlValue) (anlValue, error) {
+			if len(args) != 2 && len(args) != 5 {
+				return anlValue{}, fmt.Errorf("it takes 2 or 5 arguments, not %d", len(args))
+			}
+			angle := args[1]
+			if len(args) == 5 {
+				axis, err := p.numbers(args[2:], "ax", "ay", "az")
+				if err != nil {
+					return anlValue{}, err
+				}
+				if axis[0] != 0.0 || axis[1] != 0.0 || axis[2] == 0.0 {
+					return anlValue{}, fmt.Errorf("a 2D map can only be rotated about the Z axis")
+				}
+				if axis[2] < 0.0 {
+					angle = anlMap(func(v []float64) float64 { return -v[0] }, angle)
+				}
+			}
+			return anlDomain(args[0], func(x, y float64, v []float64) (float64, float64) {
+				sin, cos := math.Sincos(v[0])
+				return x*cos - y*sin, x*sin + y*cos
+			}, angle), nil
+		}},
+		"scaleZ":     {2, 2, func(p *anlParser, args []anlValue) (anlValue, error) { return args[0], nil }},
+		"translateZ": {2, 2, func(p *anlParser, args []anlValue) (anlValue, error) { return args[0], nil }},
+
+		"gradientBasis": {2, 2, func(p *anlParser, args []anlValue) (anlValue, error) {
+			n, err := p.numbers(args, "interp", "seed")
+			if err != nil {
+				return anlValue{}, err
+			}
+			perlin := NewPerlinGeneratorWithQuality(rand.New(rand.NewSource(int64(n[1]))), anlQuality(n[0]))
+			return anlValue{module: &perlin}, nil
+		}},
+		"simplexBasis": {1, 1, func(p *anlParser, args []anlValue) (anlValue, error) {
+			n, err := p.numbers(args, "seed")
+			if err != nil {
+				return anlValue{}, err
+			}
+			simplex := NewOpenSimplexGenerator(rand.New(rand.NewSource(int64(n[0]))))
+			return anlValue{module: &simplex}, nil
+		}},
+		"cellularBasis": {10, 10, func(p *anlParser, args []anlValue) (anlValue, error) {
+			n, err := p.numbers(args, "f1", "f2", "f3", "f4", "d1", "d2", "d3", "d4", "distance", "seed")
+			if err != nil {
+				return anlValue{}, err
+			}
+			if n[2] != 0.0 || n[3] != 0.0 || n[8] != 0.0 {
+				return anlValue{}, fmt.Errorf("only the F1 and F2 coefficients of euclidean distances are supported")
+			}
+			cells := NewWorleyGenerator(rand.New(rand.NewSource(int64(n[9]))))
+			switch {
+			case n[0] == 1.0 && n[1] == 0.0:
+				cells.Return = WorleyF1
+			case n[0] == 0.0 && n[1] == 1.0:
+				cells.Return = WorleyF2
+			case n[0] == -1.0 && n[1] == 1.0:
+				cells.Return = WorleyF2MinusF1
+			default:
+				return anlValue{}, fmt.Errorf("only the coefficients of F1, F2 or F2 - F1 are supported")
+			}
+			// the distances are stretched to [-1, 1] by noisey
+			return anlValue{module: NoiseFunc2D(func(x, y float64) float64 {
+				return (cells.Get2D(x, y) + 1.0) * 0.5
+			})}, nil
+		}},
+		"simplefBm": anlFractal(func(src NoiseyGet2D, octaves int, frequency float64) NoiseyGet2D {
+			fbm := NewFBMGenerator2D(src, octaves, 0.5, 2.0, frequency)
+			return &fbm
+		}),
+		"simpleRidgedMultifractal": anlFractal(func(src NoiseyGet2D, octaves int, frequency float64) NoiseyGet2D {
+			ridged := NewRidgedGenerator2D(src, octaves, 2.0, frequency, 1.0, 2.0, 1.0)
+			return &ridged
+		}),
+		"simpleBillow": anlFractal(func(src NoiseyGet2D, octaves int, frequency float64) NoiseyGet2D {
+			billow := NewBillow2D(src, octaves, 0.5, 2.0, frequency)
+			return &billow
+		}),
+	}
+}
+
+// anlCoordinates are the names of the coordinates of an expression.
+var anlCoordinates = map[string]anlValue{
+	"x":      {module: NoiseFunc2D(func(x, y float64) float64 { return x })},
+	"y":      {module: NoiseFunc2D(func(x, y float64) float64 { return y })},
+	"z":      anlNumber(0.0),
+	"radial": {module: NoiseFunc2D(func(x, y float64) float64 { return math.Sqrt(x*x + y*y) })},
+}
+
+// anlToken is a token of an ANL expression.
+type anlToken struct {
+	kind  rune // 'n' for a number, 'i' for a name, ';' for a separator or the operator
+	text  string
+	pos   int
+	value float64
+}
+
+// anlParser parses an ANL expression.
+type anlParser struct {
+	tokens []anlToken
+	next   int
+	names  map[string]anlValue
+}
+
+// anlTokens splits the source of an expression into tokens. Lines only
+// separate statements outside of parentheses.
+func anlTokens(src string) ([]anlToken, error) {
+	var tokens []anlToken
+	depth := 0
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case (c == '\n' && depth == 0) || c == ';':
+			tokens = append(tokens, anlToken{kind: ';', text: string(c), pos: i})
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.' ||
+				((src[j] == 'e' || src[j] == 'E') && j+1 < len(src)) ||
+				((src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q at %d isn't a number", src[i:j], i)
+			}
+			tokens = append(tokens, anlToken{kind: 'n', text: src[i:j], pos: i, value: v})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, anlToken{kind: 'i', text: src[i:j], pos: i})
+			i = j
+		case strings.ContainsRune("+-*/^(),=", c):
+			if c == '(' {
+				depth++
+			} else if c == ')' && depth > 0 {
+				depth--
+			}
+			tokens = append(tokens, anlToken{kind: c, text: string(c), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+	}
+	return append(tokens, anlToken{kind: ';', text: "the end", pos: len(src)}), nil
+}
+
+// peek returns the next token without taking it.
+func (p *anlParser) peek() anlToken {
+	return p.tokens[p.next]
+}
+
+// take returns the next token and moves past it. The end is never passed.
+func (p *anlParser) take() anlToken {
+	t := p.tokens[p.next]
+	if p.next < len(p.tokens)-1 {
+		p.next++
+	}
+	return t
+}
+
+// expect takes the next token if it's of the kind.
+func (p *anlParser) expect(kind rune) error {
+	if t := p.take(); t.kind != kind {
+		return fmt.Errorf("expected %q but found %q at %d", kind, t.text, t.pos)
+	}
+	return nil
+}
+
+// numbers returns the values of the arguments, which have to be numbers.
+func (p *anlParser) numbers(args []anlValue, names ...string) ([]float64, error) {
+	n := make([]float64, len(args))
+	for i, a := range args {
+		if !a.constant {
+			return nil, fmt.Errorf("the %s has to be a number", names[i])
+		}
+		n[i] = a.number
+	}
+	return n, nil
+}
+
+// program parses the statements and returns the value of the last one.
+func (p *anlParser) program() (anlValue, error) {
+	var result anlValue
+	found := false
+	for p.next < len(p.tokens)-1 {
+		if p.peek().kind == ';' {
+			p.take()
+			continue
+		}
+		var name string
+		if p.peek().kind == 'i' && p.tokens[p.next+1].kind == '=' {
+			name = p.take().text
+			p.take()
+		}
+		v, err := p.expression()
+		if err != nil {
+			return anlValue{}, err
+		}
+		if t := p.peek(); t.kind != ';' {
+			return anlValue{}, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+		}
+		if name != "" {
+			p.names[name] = v
+		}
+		result, found = v, true
+	}
+	if !found {
+		return anlValue{}, fmt.Errorf("there is no expression")
+	}
+	return result, nil
+}
+
+// expression parses a sum.
+func (p *anlParser) expression() (anlValue, error) {
+	v, err := p.term()
+	for err == nil && (p.peek().kind == '+' || p.peek().kind == '-') {
+		op := p.take().kind
+		var r anlValue
+		if r, err = p.term(); err == nil {
+			if op == '+' {
+				v = anlMap(func(v []float64) float64 { return v[0] + v[1] }, v, r)
+			} else {
+				v = anlMap(func(v []float64) float64 { return v[0] - v[1] }, v, r)
+			}
+		}
+	}
+	return v, err
+}
+
+// term parses a product.
+func (p *anlParser) term() (anlValue, error) {
+	v, err := p.unary()
+	for err == nil && (p.peek().kind == '*' || p.peek().kind == '/') {
+		op := p.take().kind
+		var r anlValue
+		if r, err = p.unary(); err == nil {
+			if op == '*' {
+				v = anlMap(func(v []float64) float64 { return v[0] * v[1] }, v, r)
+			} else {
+				v = anlMap(func(v []float64) float64 { return v[0] / v[1] }, v, r)
+			}
+		}
+	}
+	return v, err
+}
+
+// unary parses a negation or a power.
+func (p *anlParser) unary() (anlValue, error) {
+	if p.peek().kind == '-' {
+		p.take()
+		v, err := p.unary()
+		return anlMap(func(v []float64) float64 { return -v[0] }, v), err
+	}
+	v, err := p.primary()
+	if err == nil && p.peek().kind == '^' {
+		p.take()
+		var e anlValue
+		if e, err = p.unary(); err == nil {
+			v = anlMap(func(v []float64) float64 { return math.Pow(v[0], v[1]) }, v, e)
+		}
+	}
+	return v, err
+}
+
+// primary parses a number, a name, a call or an expression in parentheses.
+func (p *anlParser) primary() (anlValue, error) {
+	t := p.take()
+	switch t.kind {
+	case 'n':
+		return anlNumber(t.value), nil
+	case '(':
+		v, err := p.expression()
+		if err != nil {
+			return anlValue{}, err
+		}
+		return v, p.expect(')')
+	case 'i':
+		if p.peek().kind != '(' {
+			if v, ok := p.names[t.text]; ok {
+				return v, nil
+			}
+			if v, ok := anlCoordinates[t.text]; ok {
+				return v, nil
+			}
+			return anlValue{}, fmt.Errorf("%q at %d isn't defined", t.text, t.pos)
+		}
+		p.take()
+		var args []anlValue
+		for p.peek().kind != ')' {
+			if len(args) > 0 {
+				if err := p.expect(','); err != nil {
+					return anlValue{}, err
+				}
+			}
+			a, err := p.expression()
+			if err != nil {
+				return anlValue{}, err
+			}
+			args = append(args, a)
+		}
+		p.take()
+		if v, ok := anlCoordinates[t.text]; ok && len(args) == 0 {
+			return v, nil
+		}
+		f, ok := anlFunctions[t.text]
+		if !ok {
+			return anlValue{}, fmt.Errorf("the function %s at %d isn't supported", t.text, t.pos)
+		}
+		if len(args) < f.minArgs || len(args) > f.maxArgs {
+			return anlValue{}, fmt.Errorf("%s at %d takes %d to %d arguments, not %d", t.text, t.pos, f.minArgs, f.maxArgs, len(args))
+		}
+		v, err := f.build(p, args)
+		if err != nil {
+			return anlValue{}, fmt.Errorf("%s at %d: %v", t.text, t.pos, err)
+		}
+		return v, nil
+	}
+	return anlValue{}, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// ParseANL parses an ANL expression or instruction list and returns the
+// pipeline of its result.
+func ParseANL(src string) (NoiseyGet2D, error) {
+	tokens, err := anlTokens(src)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the ANL expression: %v.\n", err)
+	}
+	p := anlParser{tokens: tokens, names: make(map[string]anlValue)}
+	v, err := p.program()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the ANL expression: %v.\n", err)
+	}
+	return v.module, nil
+}
@@ -0,0 +1,204 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module bakes several pipelines into the sprites of one texture atlas and
+writes a JSON manifest of where each sprite is:
+
+	atlas := noisey.NewAtlasBaker(128, 128)
+	atlas.Gradient = gradient
+	err := atlas.Add("marble", marble)
+	err = atlas.AddVariations("fbm", 4, func(i int) (noisey.NoiseyGet2D, error) {
+		fbm := noisey.NewFBMGenerator2D(&perlin, 2+i, 0.5, 2.0, 4.0)
+		return &fbm, nil
+	})
+	sheet, err := atlas.Bake()
+	err = sheet.WritePNG(imageFile)
+	manifest, err := sheet.SaveManifest("textures.png")
+
+Every sprite is Width x Height pixels built over the same Bounds. The sprites
+are placed in the order they were added, row by row from the top left, with
+Padding pixels between them and around the edge of the atlas. Columns sets
+how many sprites go in each row; if it's 0 the atlas is made as close to
+square as it can be.
+
+The sprites are colored with the Gradient, or as grayscale with [-1, 1]
+mapped to [0, 255] if the Gradient has no keys.
+
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// AtlasRegion is the place of one sprite in an atlas image, in pixels from
+// the top left.
+type AtlasRegion struct {
+	Name   string
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// AtlasManifest is the JSON manifest written by Atlas.SaveManifest().
+type AtlasManifest struct {
+	Image   string // the file name of the atlas image
+	Width   int    // the width of the atlas image
+	Height  int    // the height of the atlas image
+	Sprites []AtlasRegion
+}
+
+// Atlas is the output of AtlasBaker.Bake().
+type Atlas struct {
+	Image   *image.NRGBA
+	Regions []AtlasRegion
+}
+
+// atlasSprite is a named pipeline added to an AtlasBaker.
+type atlasSprite struct {
+	name   string
+	source NoiseyGet2D
+}
+
+// AtlasBaker contains the parameters for baking pipelines into an atlas.
+type AtlasBaker struct {
+	Width    int             // the width of each sprite in pixels
+	Height   int             // the height of each sprite in pixels
+	Bounds   Builder2DBounds // the area of each pipeline to bake
+	Columns  int             // the number of sprites in each row, or 0 for a square atlas
+	Padding  int             // the pixels between the sprites and around the edge
+	Gradient ColorGradient   // the colors of the sprites
+	sprites  []atlasSprite
+}
+
+// NewAtlasBaker creates a new baker for sprites of width x height pixels
+// over the unit square.
+func NewAtlasBaker(width int, height int) (ab AtlasBaker) {
+	ab.Width = width
+	ab.Height = height
+	ab.Bounds = Builder2DBounds{0.0, 0.0, 1.0, 1.0}
+	return
+}
+
+// String returns a description of the baker and the names of its sprites.
+func (ab *AtlasBaker) String() string {
+	names := make([]string, len(ab.sprites))
+	for i, s := range ab.sprites {
+		names[i] = s.name
+	}
+	return fmt.Sprintf("AtlasBaker{Width: %v, Height: %v, Bounds: %v, Columns: %v, Padding: %v, Sprites: %v}",
+		ab.Width, ab.Height, ab.Bounds, ab.Columns, ab.Padding, names)
+}
+
+// Add adds a pipeline to the atlas as a sprite. The names of the sprites
+// must be unique.
+func (ab *AtlasBaker) Add(name string, src NoiseyGet2D) error {
+	if src == nil {
+		return fmt.Errorf("Unable to add the sprite %s to the atlas without a source.\n", name)
+	}
+	for _, s := range ab.sprites {
+		if s.name == name {
+			return fmt.Errorf("Unable to add the sprite %s to the atlas; the name is already used.\n", name)
+		}
+	}
+	ab.sprites = append(ab.sprites, atlasSprite{name, src})
+	return nil
+}
+
+// AddVariations adds count sprites named prefix_0, prefix_1 and so on, with
+// the pipeline for each made by variation.
+func (ab *AtlasBaker) AddVariations(prefix string, count int, variation func(i int) (NoiseyGet2D, error)) error {
+	for i := 0; i < count; i++ {
+		src, err := variation(i)
+		if err != nil {
+			return err
+		}
+		if err = ab.Add(fmt.Sprintf("%s_%d", prefix, i), src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bake builds every sprite and places them into one atlas image.
+func (ab *AtlasBaker) Bake() (atlas Atlas, err error) {
+	if len(ab.sprites) == 0 {
+		return atlas, fmt.Errorf("Unable to bake an atlas without any sprites.\n")
+	}
+	if ab.Width <= 0 || ab.Height <= 0 || ab.Padding < 0 || ab.Columns < 0 {
+		return atlas, fmt.Errorf("Unable to bake an atlas of %dx%d sprites with %d columns and a padding of %d.\n", ab.Width, ab.Height, ab.Columns, ab.Padding)
+	}
+
+	cols := ab.Columns
+	if cols == 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(len(ab.sprites)))))
+	}
+	cols = minInt(cols, len(ab.sprites))
+	rows := (len(ab.sprites) + cols - 1) / cols
+	pad := ab.Padding
+	atlas.Image = image.NewNRGBA(image.Rect(0, 0, cols*(ab.Width+pad)+pad, rows*(ab.Height+pad)+pad))
+
+	builder := NewBuilder2D(nil, ab.Width, ab.Height)
+	builder.Bounds = ab.Bounds
+	for i, s := range ab.sprites {
+		builder.Source = s.source
+		if err = builder.Build(); err != nil {
+			return atlas, err
+		}
+		region := AtlasRegion{s.name, pad + (i%cols)*(ab.Width+pad), pad + (i/cols)*(ab.Height+pad), ab.Width, ab.Height}
+		for y := 0; y < ab.Height; y++ {
+			for x := 0; x < ab.Width; x++ {
+				v := builder.Values[y*ab.Width+x]
+				c := color.NRGBA{encodeUnit(v), encodeUnit(v), encodeUnit(v), 255}
+				if len(ab.Gradient.Keys) > 0 {
+					c = ab.Gradient.Color(v)
+				}
+				atlas.Image.SetNRGBA(region.X+x, region.Y+y, c)
+			}
+		}
+		atlas.Regions = append(atlas.Regions, region)
+	}
+	return atlas, nil
+}
+
+// Find returns the region of the named sprite and true, or false if the
+// atlas has no sprite with the name.
+func (atlas *Atlas) Find(name string) (AtlasRegion, bool) {
+	for _, r := range atlas.Regions {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return AtlasRegion{}, false
+}
+
+// WritePNG writes the atlas image to w as a PNG.
+func (atlas *Atlas) WritePNG(w io.Writer) error {
+	return png.Encode(w, atlas.Image)
+}
+
+// SaveManifest marshals the manifest of the atlas into a JSON byte array
+// that is indented nicely. imageName is the file name the image is saved as.
+func (atlas *Atlas) SaveManifest(imageName string) ([]byte, error) {
+	bounds := atlas.Image.Bounds()
+	manifest := AtlasManifest{imageName, bounds.Dx(), bounds.Dy(), atlas.Regions}
+	rawBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode the atlas manifest into JSON.\n%v\n", err)
+	}
+
+	var b bytes.Buffer
+	json.Indent(&b, rawBytes, "", "\t")
+	return b.Bytes(), nil
+}
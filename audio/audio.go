@@ -0,0 +1,317 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package audio renders 1D coherent noise as PCM sound, which makes good beds
+of wind, rumble, surf and engine drones:
+
+	perlin := noisey.NewPerlinSeeded(1)
+	fbm := noisey.NewFBMGenerator1D(&perlin, 6, 0.6, 2.0, 1.0)
+
+	wind := audio.NewStream(&fbm, 44100)
+	wind.Frequency = 80.0
+	wind.Duration = 10.0
+	wind.Envelope = audio.ADSR{Attack: 2.0, Decay: 1.0, Sustain: 0.7, Release: 3.0, Length: 10.0}
+	err := audio.WriteWAV(file, wind)
+
+Sample i of a Stream is the noise at i / SampleRate * Frequency, so
+Frequency is about how many features of a noise with a frequency of 1 go by
+in a second: tens for a slow rumble, thousands for a hiss. The value is
+multiplied by Gain and the envelope and clipped to [-1, 1].
+
+A Stream is an io.Reader of interleaved little endian PCM samples as 16 bit
+integers or 32 bit floats, so it can be piped to players and encoders, and
+ReadSamples() gives the samples as float64 to mix them in Go. The samples
+only depend on the settings of the stream, so the same settings always
+make the same sound.
+*/
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tbogdala/noisey"
+)
+
+// Format is how the samples of a Stream are written as bytes.
+type Format int
+
+const (
+	PCM16   Format = iota // signed 16 bit integers
+	Float32               // 32 bit floats in [-1, 1]
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case PCM16:
+		return "PCM16"
+	case Float32:
+		return "Float32"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// size returns the number of bytes of a sample in the format.
+func (f Format) size() int {
+	if f == Float32 {
+		return 4
+	}
+	return 2
+}
+
+// Envelope shapes the amplitude of a sound over time.
+type Envelope interface {
+	// Amplitude returns the amplitude at t seconds, usually in [0, 1].
+	Amplitude(t float64) float64
+}
+
+// EnvelopeFunc is a function that can be used as an Envelope.
+type EnvelopeFunc func(t float64) float64
+
+// Amplitude returns the amplitude by calling the function.
+func (f EnvelopeFunc) Amplitude(t float64) float64 {
+	return f(t)
+}
+
+// ADSR is an attack, decay, sustain and release envelope. The amplitude
+// rises from 0 to 1 over Attack seconds, falls to Sustain over Decay
+// seconds, stays there and then falls to 0 over the last Release seconds
+// of Length. A Length of 0 sustains forever.
+type ADSR struct {
+	Attack  float64 // the seconds to rise to full amplitude
+	Decay   float64 // the seconds to fall from full amplitude to Sustain
+	Sustain float64 // the amplitude held between the decay and the release
+	Release float64 // the seconds to fall to silence at the end of Length
+	Length  float64 // the seconds of the whole sound, or 0 for no end
+}
+
+// Amplitude returns the amplitude of the envelope at t seconds.
+func (e ADSR) Amplitude(t float64) float64 {
+	if t < 0.0 {
+		return 0.0
+	}
+	var a float64
+	switch {
+	case t < e.Attack:
+		a = t / e.Attack
+	case t < e.Attack+e.Decay:
+		a = 1.0 - (1.0-e.Sustain)*(t-e.Attack)/e.Decay
+	default:
+		a = e.Sustain
+	}
+	if e.Length > 0.0 {
+		left := e.Length - t
+		if left <= 0.0 {
+			return 0.0
+		}
+		if left < e.Release {
+			a *= left / e.Release
+		}
+	}
+	return a
+}
+
+// channelOffset is how far apart the channels of a stream sample the
+// noise, so that they don't sound the same.
+const channelOffset = 7919.0
+
+// Stream renders a 1D noise source as a stream of PCM samples.
+type Stream struct {
+	// Source is the noise that is played
+	Source noisey.NoiseyGet1D
+
+	// SampleRate is the number of samples a second of each channel
+	SampleRate int
+
+	// Channels is the number of interleaved channels, each sampling a
+	// different stretch of the noise
+	Channels int
+
+	// Frequency is how far the noise is walked in a second
+	Frequency float64
+
+	// Offset is where in the noise the stream starts
+	Offset float64
+
+	// Gain multiplies the noise before the envelope
+	Gain float64
+
+	// Envelope shapes the amplitude over time; nil keeps it at Gain
+	Envelope Envelope
+
+	// Duration is the seconds of the stream, or 0 for no end
+	Duration float64
+
+	// Format is how Read() writes the samples
+	Format Format
+
+	frame   int64 // the next frame to read
+	channel int   // the next channel of the frame to read
+	buf     [4]byte
+	pending []byte // the bytes of the last sample that haven't been read
+}
+
+// NewStream creates a new endless mono stream of src at the sample rate,
+// walking one unit of noise a second at full gain as 16 bit samples.
+func NewStream(src noisey.NoiseyGet1D, sampleRate int) (s *Stream) {
+	s = new(Stream)
+	s.Source = src
+	s.SampleRate = sampleRate
+	s.Channels = 1
+	s.Frequency = 1.0
+	s.Gain = 1.0
+	s.Format = PCM16
+	return s
+}
+
+// Frames returns the number of frames of the stream, one sample of every
+// channel each, or -1 if it doesn't end.
+func (s *Stream) Frames() int64 {
+	if s.Duration <= 0.0 {
+		return -1
+	}
+	return int64(math.Ceil(s.Duration * float64(s.SampleRate)))
+}
+
+// Sample returns the value of a channel at a frame in [-1, 1].
+func (s *Stream) Sample(frame int64, channel int) float64 {
+	t := float64(frame) / float64(s.SampleRate)
+	v := s.Source.Get1D(s.Offset+t*s.Frequency+float64(channel)*channelOffset) * s.Gain
+	if s.Envelope != nil {
+		v *= s.Envelope.Amplitude(t)
+	}
+	return math.Max(-1.0, math.Min(1.0, v))
+}
+
+// check returns an error if the stream can't be read.
+func (s *Stream) check() error {
+	if s.Source == nil {
+		return fmt.Errorf("Unable to play noise without a Source.\n")
+	}
+	if s.SampleRate <= 0 || s.Channels <= 0 {
+		return fmt.Errorf("Unable to play noise at %d samples a second in %d channels.\n", s.SampleRate, s.Channels)
+	}
+	return nil
+}
+
+// next returns the next sample and false when the stream has ended.
+func (s *Stream) next() (float64, bool) {
+	if frames := s.Frames(); frames >= 0 && s.frame >= frames {
+		return 0.0, false
+	}
+	v := s.Sample(s.frame, s.channel)
+	s.channel++
+	if s.channel >= s.Channels {
+		s.channel = 0
+		s.frame++
+	}
+	return v, true
+}
+
+// ReadSamples fills samples with the next interleaved samples and returns
+// how many it read. It returns io.EOF when the stream has ended. Bytes of
+// a sample that a Read() split are dropped.
+func (s *Stream) ReadSamples(samples []float64) (n int, err error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	s.pending = nil
+	for n < len(samples) {
+		v, ok := s.next()
+		if !ok {
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		}
+		samples[n] = v
+		n++
+	}
+	return n, nil
+}
+
+// encode writes a sample into the buffer in the format and returns its bytes.
+func (s *Stream) encode(v float64) []byte {
+	if s.Format == Float32 {
+		binary.LittleEndian.PutUint32(s.buf[:], math.Float32bits(float32(v)))
+		return s.buf[:4]
+	}
+	binary.LittleEndian.PutUint16(s.buf[:], uint16(int16(math.Floor(v*32767.0+0.5))))
+	return s.buf[:2]
+}
+
+// Read fills p with the next interleaved samples in the format. A sample
+// can be split between reads. It returns io.EOF when the stream has ended.
+func (s *Stream) Read(p []byte) (n int, err error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			v, ok := s.next()
+			if !ok {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				return n, nil
+			}
+			s.pending = s.encode(v)
+		}
+		c := copy(p[n:], s.pending)
+		s.pending = s.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Rewind starts the stream from its first sample again.
+func (s *Stream) Rewind() {
+	s.frame, s.channel, s.pending = 0, 0, nil
+}
+
+// WriteWAV writes the whole stream from its first sample as a WAV file. The
+// stream needs a Duration.
+func WriteWAV(w io.Writer, s *Stream) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	frames := s.Frames()
+	if frames < 0 {
+		return fmt.Errorf("Unable to write a WAV file of a stream without a Duration.\n")
+	}
+	size := s.Format.size()
+	dataSize := frames * int64(s.Channels) * int64(size)
+	if dataSize > math.MaxUint32-36 {
+		return fmt.Errorf("Unable to write a WAV file of %d bytes of samples.\n", dataSize)
+	}
+	formatTag := uint16(1)
+	if s.Format == Float32 {
+		formatTag = 3
+	}
+
+	le := binary.LittleEndian
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	le.PutUint32(header[4:], uint32(36+dataSize))
+	copy(header[8:], "WAVEfmt ")
+	le.PutUint32(header[16:], 16)
+	le.PutUint16(header[20:], formatTag)
+	le.PutUint16(header[22:], uint16(s.Channels))
+	le.PutUint32(header[24:], uint32(s.SampleRate))
+	le.PutUint32(header[28:], uint32(s.SampleRate*s.Channels*size))
+	le.PutUint16(header[32:], uint16(s.Channels*size))
+	le.PutUint16(header[34:], uint16(8*size))
+	copy(header[36:], "data")
+	le.PutUint32(header[40:], uint32(dataSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	s.Rewind()
+	_, err := io.CopyBuffer(w, s, make([]byte, 32*1024))
+	return err
+}
@@ -0,0 +1,115 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module performs billowy noise which is fractal Brownian motion where the
+absolute value of each octave is taken, so the noise folds into rounded lumps
+like clouds or rocks.
+
+Reference material:
+* Libnoise's billow module: http://libnoise.sourceforge.net/docs/classnoise_1_1module_1_1Billow.html
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Billow2D takes noise and makes billowy fractal values.
+type Billow2D struct {
+	NoiseMaker  NoiseyGet2D // the interface Billow2D uses gets noise values
+	Octaves     int         // the number of octaves to calculate on each Get()
+	Persistence float64     // a multiplier that determines how quickly the amplitudes diminish for each successive octave
+	Lacunarity  float64     // a multiplier that determines how quickly the frequency increases for each successive octave
+	Frequency   float64     // the number of cycles per unit length
+}
+
+// NewBillow2D creates a new billow generator state. A 'default' billow would
+// have 6 octaves, 0.5 persistence, 2.0 lacunarity and 1.0 frequency.
+func NewBillow2D(noise NoiseyGet2D, octaves int, persistence float64, lacunarity float64, frequency float64) (billow Billow2D) {
+	billow.NoiseMaker = noise
+	billow.Octaves = octaves
+	billow.Persistence = persistence
+	billow.Lacunarity = lacunarity
+	billow.Frequency = frequency
+	return
+}
+
+// Clone2D returns a deep copy of the generator and its NoiseMaker.
+func (billow *Billow2D) Clone2D() NoiseyGet2D {
+	c := *billow
+	c.NoiseMaker = DeepCopy2D(billow.NoiseMaker)
+	return &c
+}
+
+// SetOctaves validates and changes the number of octaves.
+func (billow *Billow2D) SetOctaves(octaves int) error {
+	if err := validateParam(GenBillow2D, "Octaves", float64(octaves)); err != nil {
+		return err
+	}
+	billow.Octaves = octaves
+	return nil
+}
+
+// SetPersistence validates and changes the persistence.
+func (billow *Billow2D) SetPersistence(persistence float64) error {
+	if err := validateParam(GenBillow2D, "Persistence", persistence); err != nil {
+		return err
+	}
+	billow.Persistence = persistence
+	return nil
+}
+
+// SetLacunarity validates and changes the lacunarity.
+func (billow *Billow2D) SetLacunarity(lacunarity float64) error {
+	if err := validateParam(GenBillow2D, "Lacunarity", lacunarity); err != nil {
+		return err
+	}
+	billow.Lacunarity = lacunarity
+	return nil
+}
+
+// SetFrequency validates and changes the frequency.
+func (billow *Billow2D) SetFrequency(frequency float64) error {
+	if err := validateParam(GenBillow2D, "Frequency", frequency); err != nil {
+		return err
+	}
+	billow.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the generator and its NoiseMaker.
+func (billow *Billow2D) String() string {
+	return fmt.Sprintf("Billow2D{Octaves: %v, Persistence: %v, Lacunarity: %v, Frequency: %v, NoiseMaker: %s}",
+		billow.Octaves, billow.Persistence, billow.Lacunarity, billow.Frequency, describeModule(billow.NoiseMaker))
+}
+
+// Get2D calculates the noise value over the number of Octaves. Each octave
+// contributes 2*|n|-1 so a single octave stays in the range [-1, 1].
+func (billow *Billow2D) Get2D(x float64, y float64) (v float64) {
+	curPersistence := 1.0
+
+	x *= billow.Frequency
+	y *= billow.Frequency
+
+	for o := 0; o < billow.Octaves; o++ {
+		signal := 2.0*math.Abs(billow.NoiseMaker.Get2D(x, y)) - 1.0
+		v += signal * curPersistence
+
+		x *= billow.Lacunarity
+		y *= billow.Lacunarity
+		curPersistence *= billow.Persistence
+	}
+
+	return
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (billow *Billow2D) Range2D() (min float64, max float64) {
+	lo, hi := absRange(ValueRange2D(billow.NoiseMaker))
+	return octavesRange(2.0*lo-1.0, 2.0*hi-1.0, billow.Octaves, billow.Persistence)
+}
@@ -0,0 +1,175 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module classifies biomes from elevation, moisture and temperature using
+a Whittaker style lookup table. Each channel can be any NoiseyGet2D, so both
+generators and built NoiseMaps can be used.
+
+	classifier := noisey.NewBiomeClassifier(&elevation, &moisture, &temperature, noisey.DefaultBiomes())
+	biomes, err := classifier.Build(256, 256, noisey.Builder2DBounds{0.0, 0.0, 4.0, 4.0})
+	name := classifier.Biomes[biomes.Get(10, 20)].Name
+
+The classifier is also a NoiseyGet2D that returns the biome index as a
+float64 so that it can be used with other modules like Select2D.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Biome is an entry in the lookup table of a BiomeClassifier. A biome matches
+// when all three channels are within its [Min, Max) ranges.
+type Biome struct {
+	Name           string
+	MinElevation   float64
+	MaxElevation   float64
+	MinMoisture    float64
+	MaxMoisture    float64
+	MinTemperature float64
+	MaxTemperature float64
+}
+
+// Matches returns true if the channel values are inside the ranges of the biome.
+func (b *Biome) Matches(elevation float64, moisture float64, temperature float64) bool {
+	return elevation >= b.MinElevation && elevation < b.MaxElevation &&
+		moisture >= b.MinMoisture && moisture < b.MaxMoisture &&
+		temperature >= b.MinTemperature && temperature < b.MaxTemperature
+}
+
+// DefaultBiomes returns a Whittaker style table of biomes for channels with
+// values in the range [-1, 1] where an elevation of 0.0 is sea level.
+func DefaultBiomes() []Biome {
+	inf := math.Inf(1)
+	return []Biome{
+		{"ocean", -inf, 0.0, -inf, inf, -inf, inf},
+		{"beach", 0.0, 0.05, -inf, inf, -0.5, inf},
+		{"snow", 0.75, inf, -inf, inf, -inf, inf},
+		{"tundra", 0.0, inf, -inf, inf, -inf, -0.5},
+		{"taiga", 0.0, inf, 0.0, inf, -0.5, -0.1},
+		{"desert", 0.0, inf, -inf, -0.4, 0.3, inf},
+		{"rainforest", 0.0, inf, 0.4, inf, 0.3, inf},
+		{"grassland", 0.0, inf, -inf, 0.0, -inf, inf},
+		{"forest", 0.0, inf, 0.0, inf, -inf, inf},
+	}
+}
+
+// BiomeClassifier picks a biome for each coordinate from the values of the
+// elevation, moisture and temperature channels.
+type BiomeClassifier struct {
+	Elevation   NoiseyGet2D // the elevation channel
+	Moisture    NoiseyGet2D // the moisture channel
+	Temperature NoiseyGet2D // the temperature channel
+	Biomes      []Biome     // the lookup table; the first matching biome is used
+	Default     int         // the index used when no biome matches
+}
+
+// NewBiomeClassifier creates a new classifier for the channels using the
+// lookup table biomes. Default is set to 0.
+func NewBiomeClassifier(elevation NoiseyGet2D, moisture NoiseyGet2D, temperature NoiseyGet2D, biomes []Biome) (bc BiomeClassifier) {
+	bc.Elevation = elevation
+	bc.Moisture = moisture
+	bc.Temperature = temperature
+	bc.Biomes = biomes
+	return
+}
+
+// Classify returns the index of the first biome in the lookup table that
+// matches the channel values or Default if none do.
+func (bc *BiomeClassifier) Classify(elevation float64, moisture float64, temperature float64) int {
+	for i := range bc.Biomes {
+		if bc.Biomes[i].Matches(elevation, moisture, temperature) {
+			return i
+		}
+	}
+	return bc.Default
+}
+
+// Get2D returns the index of the biome at the coordinate as a float64.
+func (bc *BiomeClassifier) Get2D(x float64, y float64) float64 {
+	return float64(bc.Classify(bc.Elevation.Get2D(x, y), bc.Moisture.Get2D(x, y), bc.Temperature.Get2D(x, y)))
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are the indexes
+// of the biomes and Default.
+func (bc *BiomeClassifier) Range2D() (min float64, max float64) {
+	min, max = float64(bc.Default), float64(bc.Default)
+	if len(bc.Biomes) > 0 {
+		min, max = unionRange(min, max, 0.0, float64(len(bc.Biomes)-1))
+	}
+	return
+}
+
+// Clone2D returns a deep copy of the classifier and its channels.
+func (bc *BiomeClassifier) Clone2D() NoiseyGet2D {
+	c := *bc
+	c.Elevation = DeepCopy2D(bc.Elevation)
+	c.Moisture = DeepCopy2D(bc.Moisture)
+	c.Temperature = DeepCopy2D(bc.Temperature)
+	c.Biomes = make([]Biome, len(bc.Biomes))
+	copy(c.Biomes, bc.Biomes)
+	return &c
+}
+
+// String returns a description of the classifier and its channels.
+func (bc *BiomeClassifier) String() string {
+	return fmt.Sprintf("BiomeClassifier{Biomes: %d, Default: %v, Elevation: %s, Moisture: %s, Temperature: %s}",
+		len(bc.Biomes), bc.Default, describeModule(bc.Elevation), describeModule(bc.Moisture), describeModule(bc.Temperature))
+}
+
+// BiomeMap is a grid of biome indexes built by BiomeClassifier.Build().
+type BiomeMap struct {
+	Width   int
+	Height  int
+	Bounds  Builder2DBounds
+	Indexes []int
+}
+
+// Get returns the biome index at the grid point (x, y). Coordinates outside
+// of the grid are clamped to the nearest edge.
+func (bm *BiomeMap) Get(x int, y int) int {
+	if x < 0 {
+		x = 0
+	} else if x >= bm.Width {
+		x = bm.Width - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= bm.Height {
+		y = bm.Height - 1
+	}
+	i := y*bm.Width + x
+	if x < 0 || y < 0 || i >= len(bm.Indexes) {
+		return 0
+	}
+	return bm.Indexes[i]
+}
+
+// Build classifies every grid point of a width x height map covering bounds.
+// The sample coordinates are the same ones used by Builder2D so the channels
+// can be NoiseMaps built with the same size and bounds.
+func (bc *BiomeClassifier) Build(width int, height int, bounds Builder2DBounds) (bm BiomeMap, err error) {
+	if bc.Elevation == nil || bc.Moisture == nil || bc.Temperature == nil {
+		return bm, fmt.Errorf("Unable to classify biomes without all three channels.\n")
+	}
+
+	builder := NewBuilder2D(bc, width, height)
+	builder.Bounds = bounds
+	if err = builder.Build(); err != nil {
+		return bm, err
+	}
+
+	bm.Width = width
+	bm.Height = height
+	bm.Bounds = bounds
+	bm.Indexes = make([]int, len(builder.Values))
+	for i, v := range builder.Values {
+		bm.Indexes[i] = int(v)
+	}
+	return bm, nil
+}
@@ -0,0 +1,147 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains BrownianNoise1D, a 1D source of red noise: a random
+walk that adds a white step every unit, so its power falls off as 1/f^2.
+It wanders smoothly and doesn't come back to where it started the way
+Perlin noise does, which suits slowly drifting values like camera shake,
+wind strength or the mood of a character:
+
+	walk := noisey.NewBrownianNoise1D(rng, 0.05)
+	shake := walk.Get1D(seconds * 30.0)
+
+A free random walk drifts away without bound, so the walk is clamped by
+reflecting it off Min and Max: it bounces back when it reaches either of
+them and stays in [Min, Max] while wandering like a free walk in between.
+Max <= Min turns the clamping off.
+
+The walk is built with the midpoint displacement of a Brownian bridge over
+2^32 units on either side of 0, where it starts at 0, with the random
+values picked by hashing their position. So every coordinate can be read
+in any order without stepping through the ones before it, and the walk is
+the same every time. It repeats every 2^33 units. Integer coordinates are
+the steps of the walk and the coordinates in between are blended with a
+cubic spline, so it has no corners at the steps.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// brownianSpan is the number of steps of the walk on either side of 0.
+const brownianSpan = int64(1) << 32
+
+// BrownianNoise1D stores the state for generating 1D Brownian noise.
+type BrownianNoise1D struct {
+	Seed uint64  // the hash seed picking the random steps
+	Step float64 // the standard deviation of a step of one unit
+	Min  float64 // the lowest value the walk reflects off
+	Max  float64 // the highest value the walk reflects off
+}
+
+// NewBrownianNoise1D creates a new Brownian noise source whose steps of
+// one unit have the standard deviation step, reflecting off -1 and 1. The
+// seed is taken from rng.
+func NewBrownianNoise1D(rng RandomSource, step float64) (bn BrownianNoise1D) {
+	hi := uint64(rng.Float64() * (1 << 32))
+	lo := uint64(rng.Float64() * (1 << 32))
+	bn.Seed = hi<<32 | lo
+	bn.Step = step
+	bn.Min = -1.0
+	bn.Max = 1.0
+	return
+}
+
+// String returns a description of the source.
+func (bn *BrownianNoise1D) String() string {
+	return fmt.Sprintf("BrownianNoise1D{Step: %v, Min: %v, Max: %v}", bn.Step, bn.Min, bn.Max)
+}
+
+// gauss returns a normally distributed random value for a point of one
+// side of the walk.
+func (bn *BrownianNoise1D) gauss(side uint64, point int64) float64 {
+	state := bn.Seed ^ side*0xd1b54a32d192ed03
+	state += uint64(point) * 0xaef17502108ef2d9
+	h := splitMix64(&state)
+	u1 := (float64(h>>32) + 1.0) / (1 << 32)
+	u2 := float64(h&0xffffffff) / (1 << 32)
+	return math.Sqrt(-2.0*math.Log(u1)) * math.Cos(2.0*math.Pi*u2)
+}
+
+// walk returns the free walk at step n in units of Step.
+func (bn *BrownianNoise1D) walk(n int64) float64 {
+	// the sides are built separately so that 0 is a point of both
+	side := uint64(0)
+	n %= 2 * brownianSpan
+	if n > brownianSpan {
+		n -= 2 * brownianSpan
+	} else if n < -brownianSpan {
+		n += 2 * brownianSpan
+	}
+	if n < 0 {
+		n, side = -n, 1
+	}
+
+	// point 0 is never a midpoint, so it keys the end of the span
+	lo, hi := int64(0), brownianSpan
+	vlo, vhi := 0.0, bn.gauss(side, 0)*math.Sqrt(float64(brownianSpan))
+	for hi-lo > 1 {
+		// the middle of a bridge of length L varies by sqrt(L)/2 around
+		// the mean of its ends
+		mid := lo + (hi-lo)/2
+		vmid := (vlo+vhi)*0.5 + bn.gauss(side, mid)*math.Sqrt(float64(hi-lo))*0.5
+		if n < mid {
+			hi, vhi = mid, vmid
+		} else {
+			lo, vlo = mid, vmid
+		}
+	}
+	if n == lo {
+		return vlo
+	}
+	return vhi
+}
+
+// reflectRange folds v into [min, max] as if it bounced off both ends.
+func reflectRange(v float64, min float64, max float64) float64 {
+	r := max - min
+	u := math.Mod(v-min, 2.0*r)
+	if u < 0.0 {
+		u += 2.0 * r
+	}
+	if u > r {
+		u = 2.0*r - u
+	}
+	return min + u
+}
+
+// Get1D calculates the Brownian noise at a given 1D coordinate
+func (bn *BrownianNoise1D) Get1D(x float64) float64 {
+	floored := math.Floor(x)
+	n := int64(floored)
+	t := x - floored
+	v := bn.walk(n)
+	if t != 0.0 {
+		v = cubicInterp(bn.walk(n-1), v, bn.walk(n+1), bn.walk(n+2), t)
+	}
+	v *= bn.Step
+	if bn.Max > bn.Min {
+		v = reflectRange(v, bn.Min, bn.Max)
+	}
+	return v
+}
+
+// Range1D returns the bounds of the values of Get1D(). A walk that doesn't
+// reflect off a range is unbounded.
+func (bn *BrownianNoise1D) Range1D() (min float64, max float64) {
+	if bn.Max > bn.Min {
+		return bn.Min, bn.Max
+	}
+	return unboundedRange()
+}
@@ -5,7 +5,10 @@ See the LICENSE file for more details. */
 
 /* This module contains code to easily build 'maps' of random noise. */
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // Builder2DBounds is a simple rectangle type.
 type Builder2DBounds struct {
@@ -32,7 +35,18 @@ func NewBuilder2D(s NoiseyGet2D, width int, height int) (b Builder2D) {
 
 // Build gets noise from Source for each spot in the data array. These steps
 // are real numbers so that Bounds does not have to match Width/Height.
-func (b *Builder2D) Build() {
+// An error is returned if there is no Source or Values is the wrong size.
+func (b *Builder2D) Build() error {
+	if b.Source == nil {
+		return fmt.Errorf("Unable to build the map without a Source.\n")
+	}
+	if b.Width < 0 || b.Height < 0 {
+		return fmt.Errorf("Unable to build a map with a negative size (%dx%d).\n", b.Width, b.Height)
+	}
+	if len(b.Values) != b.Width*b.Height {
+		return fmt.Errorf("Unable to build the map: Values has %d elements but %dx%d were expected.\n", len(b.Values), b.Width, b.Height)
+	}
+
 	// setup the initial parameters controlling how the noise is sampled
 	xExtent := b.Bounds.MaxX - b.Bounds.MinX
 	yExtent := b.Bounds.MaxY - b.Bounds.MinY
@@ -50,6 +64,8 @@ func (b *Builder2D) Build() {
 		}
 		yCur += yDelta
 	}
+
+	return nil
 }
 
 // GetMinMax returns the lowest and the highest Values
@@ -58,6 +74,9 @@ func (b *Builder2D) GetMinMax() (min float64, max float64) {
 	var high float64 = math.SmallestNonzeroFloat64
 
 	totalIndex := b.Width * b.Height
+	if totalIndex > len(b.Values) {
+		totalIndex = len(b.Values)
+	}
 	for i := 0; i < totalIndex; i++ {
 		v := b.Values[i]
 		if v < low {
@@ -70,3 +89,69 @@ func (b *Builder2D) GetMinMax() (min float64, max float64) {
 
 	return low, high
 }
+
+// Builder3DBounds is a simple box type.
+type Builder3DBounds struct {
+	MinX, MinY, MinZ, MaxX, MaxY, MaxZ float64
+}
+
+// Builder3D contains the parameters and data for the 3D noise 'volume' generated with Build().
+// Values are stored with X changing fastest, then Y and then Z.
+type Builder3D struct {
+	Source NoiseyGet3D
+	Width  int
+	Height int
+	Depth  int
+	Bounds Builder3DBounds
+	Values []float64
+}
+
+// NewBuilder3D creates a new 3D noise 'volume' builder of the given size
+func NewBuilder3D(s NoiseyGet3D, width int, height int, depth int) (b Builder3D) {
+	b.Source = s
+	b.Width = width
+	b.Height = height
+	b.Depth = depth
+	b.Values = make([]float64, width*height*depth)
+	return
+}
+
+// Build gets noise from Source for each spot in the data array the same way
+// Builder2D.Build() does. An error is returned if there is no Source or Values
+// is the wrong size.
+func (b *Builder3D) Build() error {
+	if b.Source == nil {
+		return fmt.Errorf("Unable to build the volume without a Source.\n")
+	}
+	if b.Width < 0 || b.Height < 0 || b.Depth < 0 {
+		return fmt.Errorf("Unable to build a volume with a negative size (%dx%dx%d).\n", b.Width, b.Height, b.Depth)
+	}
+	if len(b.Values) != b.Width*b.Height*b.Depth {
+		return fmt.Errorf("Unable to build the volume: Values has %d elements but %dx%dx%d were expected.\n", len(b.Values), b.Width, b.Height, b.Depth)
+	}
+
+	xDelta := (b.Bounds.MaxX - b.Bounds.MinX) / float64(b.Width)
+	yDelta := (b.Bounds.MaxY - b.Bounds.MinY) / float64(b.Height)
+	zDelta := (b.Bounds.MaxZ - b.Bounds.MinZ) / float64(b.Depth)
+	for z := 0; z < b.Depth; z++ {
+		zCur := b.Bounds.MinZ + float64(z)*zDelta
+		for y := 0; y < b.Height; y++ {
+			yCur := b.Bounds.MinY + float64(y)*yDelta
+			for x := 0; x < b.Width; x++ {
+				xCur := b.Bounds.MinX + float64(x)*xDelta
+				b.Values[(z*b.Height+y)*b.Width+x] = b.Source.Get3D(xCur, yCur, zCur)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Get returns the value at the grid point (x, y, z) or 0.0 if it's outside of the volume.
+func (b *Builder3D) Get(x int, y int, z int) float64 {
+	i := (z*b.Height+y)*b.Width + x
+	if x < 0 || x >= b.Width || y < 0 || y >= b.Height || z < 0 || z >= b.Depth || i >= len(b.Values) {
+		return 0.0
+	}
+	return b.Values[i]
+}
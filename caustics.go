@@ -0,0 +1,180 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes the moving network of bright lines that sunlight focused by
+waves casts on the bottom of shallow water. Each layer is a pattern of thin
+lines along the edges of Worley cells, and the layers are rotated, scaled and
+laid over each other so the lines cross and the crossings become hot spots:
+
+	cells := noisey.NewWorleyGenerator(rng)
+	caustics := noisey.NewCaustics2D(&cells, 0.25, 0.08)
+	caustics.Warp, caustics.Distortion = &fbm, 0.3
+	anim := noisey.NewAnimationExporter(&caustics, 256, 256, 50)
+
+The cells are 3D Worley cells and the Z axis moves through them with time at
+Speed cells per unit of time, so the lines shift, merge and split the way
+real caustics do. Get3D() takes the time as Z for animations and Get2D()
+uses Time.
+
+The Warp generator bends the straight cell edges into the rounded curves of
+caustics. It's sampled at the coordinates in cells drifting with time and
+displaces them by up to Distortion cells.
+
+The result is 1.0 on the lines and at their crossings and falls to -1.0 at
+Width cells from every line. CellSize and Width work like they do for
+Crackle2D.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// causticsRotation is the angle each layer is turned from the last, the
+// golden angle so that no two layers line up.
+const causticsRotation = 2.399963229728653
+
+// Caustics2D is a module that returns an animated water caustics pattern.
+type Caustics2D struct {
+	Cells      *WorleyGenerator // the cells the lines run between
+	Warp       NoiseyGet2D      // the optional noise that bends the lines
+	CellSize   float64          // the world size of a cell of the first layer
+	Width      float64          // the width of the lines in cells
+	Layers     int              // the number of line patterns laid over each other
+	Speed      float64          // how many cells the pattern moves through per unit of time
+	Distortion float64          // how far in cells Warp displaces the coordinates
+	Time       float64          // the time used by Get2D()
+}
+
+// NewCaustics2D creates a new caustics module of two layers without a Warp.
+func NewCaustics2D(cells *WorleyGenerator, cellSize float64, width float64) (caustics Caustics2D) {
+	caustics.Cells = cells
+	caustics.CellSize = cellSize
+	caustics.Width = width
+	caustics.Layers = 2
+	caustics.Speed = 1.0
+	return
+}
+
+// Clone2D returns a deep copy of the module, its cells and its Warp.
+func (caustics *Caustics2D) Clone2D() NoiseyGet2D {
+	c := *caustics
+	if caustics.Cells != nil {
+		c.Cells = caustics.Cells.Clone()
+	}
+	c.Warp = DeepCopy2D(caustics.Warp)
+	return &c
+}
+
+// Clone3D returns a deep copy of the module as a NoiseyGet3D.
+func (caustics *Caustics2D) Clone3D() NoiseyGet3D {
+	return caustics.Clone2D().(*Caustics2D)
+}
+
+// SetCellSize validates and changes the cell size.
+func (caustics *Caustics2D) SetCellSize(cellSize float64) error {
+	if err := validateParam(GenCaustics2D, "CellSize", cellSize); err != nil {
+		return err
+	}
+	caustics.CellSize = cellSize
+	return nil
+}
+
+// SetWidth validates and changes the line width.
+func (caustics *Caustics2D) SetWidth(width float64) error {
+	if err := validateParam(GenCaustics2D, "Width", width); err != nil {
+		return err
+	}
+	caustics.Width = width
+	return nil
+}
+
+// SetLayers validates and changes the number of layers.
+func (caustics *Caustics2D) SetLayers(layers int) error {
+	if err := validateParam(GenCaustics2D, "Layers", float64(layers)); err != nil {
+		return err
+	}
+	caustics.Layers = layers
+	return nil
+}
+
+// SetSpeed validates and changes the speed.
+func (caustics *Caustics2D) SetSpeed(speed float64) error {
+	if err := validateParam(GenCaustics2D, "Speed", speed); err != nil {
+		return err
+	}
+	caustics.Speed = speed
+	return nil
+}
+
+// SetDistortion validates and changes the distortion.
+func (caustics *Caustics2D) SetDistortion(distortion float64) error {
+	if err := validateParam(GenCaustics2D, "Distortion", distortion); err != nil {
+		return err
+	}
+	caustics.Distortion = distortion
+	return nil
+}
+
+// String returns a description of the module, its cells and its Warp.
+func (caustics *Caustics2D) String() string {
+	var cells interface{}
+	if caustics.Cells != nil {
+		cells = caustics.Cells
+	}
+	return fmt.Sprintf("Caustics2D{CellSize: %v, Width: %v, Layers: %v, Speed: %v, Distortion: %v, Time: %v, Cells: %s, Warp: %s}",
+		caustics.CellSize, caustics.Width, caustics.Layers, caustics.Speed, caustics.Distortion, caustics.Time,
+		describeModule(cells), describeModule(caustics.Warp))
+}
+
+// Get2D calculates the caustics value at the coordinate at Time.
+func (caustics *Caustics2D) Get2D(x float64, y float64) float64 {
+	return caustics.Get3D(x, y, caustics.Time)
+}
+
+// Get3D calculates the caustics value at the coordinate (x, y) at the time z.
+func (caustics *Caustics2D) Get3D(x float64, y float64, z float64) float64 {
+	if caustics.CellSize <= 0.0 || caustics.Width <= 0.0 {
+		return -1.0
+	}
+	x /= caustics.CellSize
+	y /= caustics.CellSize
+	t := z * caustics.Speed
+
+	light := 0.0
+	for l := 0; l < caustics.Layers; l++ {
+		// each layer is turned, a little smaller and in its own part of the cells
+		sin, cos := math.Sincos(float64(l) * causticsRotation)
+		scale := 1.0 + 0.3*float64(l)
+		offset := float64(l) * turbulenceOffset
+		lx := (x*cos - y*sin) * scale
+		ly := (x*sin + y*cos) * scale
+		if caustics.Warp != nil && caustics.Distortion != 0.0 {
+			dx := caustics.Warp.Get2D(lx+t*0.5+offset, ly) * caustics.Distortion
+			dy := caustics.Warp.Get2D(lx+turbulenceOffset, ly-t*0.5+offset) * caustics.Distortion
+			lx, ly = lx+dx, ly+dy
+		}
+
+		f1, f2 := caustics.Cells.distances3D(lx, ly, t+offset)
+		if d := math.Sqrt(f2) - math.Sqrt(f1); d < caustics.Width {
+			s := 1.0 - d/caustics.Width
+			light += s * s * (3.0 - 2.0*s)
+		}
+	}
+	return math.Min(1.0, light)*2.0 - 1.0
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (caustics *Caustics2D) Range2D() (min float64, max float64) {
+	return -1.0, 1.0
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (caustics *Caustics2D) Range3D() (min float64, max float64) {
+	return -1.0, 1.0
+}
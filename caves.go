@@ -0,0 +1,174 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module generates 3D cave systems for voxel terrain. The Y axis points up.
+Two kinds of caves are combined:
+
+* Spaghetti caves are long winding tunnels found where two independent noise
+  sources are both close to zero. Each source is zero on a surface and the
+  two surfaces cross along a curve, so the tunnels follow those curves.
+
+* Cheese caves are large open caverns around the feature points of Worley
+  noise, like the holes in a block of cheese.
+
+Caves3D is a NoiseyGet3D that returns a value greater than 0.0 inside of a
+cave. It can also bake chunks of voxels into masks:
+
+	a, b := noisey.NewOpenSimplexSeeded(1), noisey.NewOpenSimplexSeeded(2)
+	worley := noisey.NewWorleySeeded(3)
+	caves := noisey.NewCaves3D(&a, &b, &worley)
+	caves.TopY = 60.0
+	mask := caves.BakeChunk(0.0, 0.0, 0.0, 16, 128, 16, 1.0)
+
+Caves fade out over FadeDistance as they get close to TopY so that they
+don't open up into the sky everywhere, and fade out towards BottomY too.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Caves3D is a module that returns a value greater than 0.0 inside of caves.
+type Caves3D struct {
+	SpaghettiA NoiseyGet3D // the first source whose zero surface the tunnels follow
+	SpaghettiB NoiseyGet3D // the second source whose zero surface the tunnels follow
+	Cheese     NoiseyGet3D // the Worley F1 source that caverns are made around; nil disables caverns
+
+	SpaghettiFrequency float64 // the frequency the spaghetti sources are sampled at
+	SpaghettiThickness float64 // how close to zero both spaghetti sources must be; larger makes wider tunnels
+	CheeseFrequency    float64 // the frequency the cheese source is sampled at
+	CheeseThreshold    float64 // the cheese source must be below this to make a cavern; larger makes bigger caverns
+
+	TopY         float64 // caves fade out above this height
+	BottomY      float64 // caves fade out below this height
+	FadeDistance float64 // the distance in Y over which caves fade out at TopY and BottomY
+}
+
+// NewCaves3D creates a new cave module with parameters for a voxel size of 1.0.
+func NewCaves3D(spaghettiA NoiseyGet3D, spaghettiB NoiseyGet3D, cheese NoiseyGet3D) (caves Caves3D) {
+	caves.SpaghettiA = spaghettiA
+	caves.SpaghettiB = spaghettiB
+	caves.Cheese = cheese
+	caves.SpaghettiFrequency = 0.02
+	caves.SpaghettiThickness = 0.08
+	caves.CheeseFrequency = 0.03
+	caves.CheeseThreshold = -0.75
+	caves.TopY = 64.0
+	caves.BottomY = 0.0
+	caves.FadeDistance = 8.0
+	return
+}
+
+// Clone3D returns a deep copy of the module and its sources.
+func (caves *Caves3D) Clone3D() NoiseyGet3D {
+	c := *caves
+	c.SpaghettiA = DeepCopy3D(caves.SpaghettiA)
+	c.SpaghettiB = DeepCopy3D(caves.SpaghettiB)
+	if caves.Cheese != nil {
+		c.Cheese = DeepCopy3D(caves.Cheese)
+	}
+	return &c
+}
+
+// String returns a description of the module and its sources.
+func (caves *Caves3D) String() string {
+	return fmt.Sprintf("Caves3D{SpaghettiFrequency: %v, SpaghettiThickness: %v, CheeseFrequency: %v, CheeseThreshold: %v, TopY: %v, BottomY: %v, FadeDistance: %v, SpaghettiA: %s, SpaghettiB: %s, Cheese: %s}",
+		caves.SpaghettiFrequency, caves.SpaghettiThickness, caves.CheeseFrequency, caves.CheeseThreshold, caves.TopY, caves.BottomY, caves.FadeDistance,
+		describeModule(caves.SpaghettiA), describeModule(caves.SpaghettiB), describeModule(caves.Cheese))
+}
+
+// fade returns how strongly caves are carved at the height y in [0, 1].
+func (caves *Caves3D) fade(y float64) float64 {
+	if y >= caves.TopY || y <= caves.BottomY {
+		return 0.0
+	}
+	if caves.FadeDistance <= 0.0 {
+		return 1.0
+	}
+	return math.Min(1.0, math.Min(caves.TopY-y, y-caves.BottomY)/caves.FadeDistance)
+}
+
+// Get3D returns a value greater than 0.0 inside of a cave. The value is 1.0
+// on the center line of a tunnel or at the feature point of a cavern.
+func (caves *Caves3D) Get3D(x float64, y float64, z float64) float64 {
+	f := caves.fade(y)
+	if f <= 0.0 {
+		return -1.0
+	}
+
+	v := -1.0
+	if caves.SpaghettiA != nil && caves.SpaghettiB != nil && caves.SpaghettiThickness > 0.0 {
+		sf := caves.SpaghettiFrequency
+		a := math.Abs(caves.SpaghettiA.Get3D(x*sf, y*sf, z*sf))
+		b := math.Abs(caves.SpaghettiB.Get3D(x*sf, y*sf, z*sf))
+		v = 1.0 - math.Max(a, b)/caves.SpaghettiThickness
+	}
+	if caves.Cheese != nil {
+		cf := caves.CheeseFrequency
+		c := caves.Cheese.Get3D(x*cf, y*cf, z*cf)
+		if extent := caves.CheeseThreshold + 1.0; extent > 0.0 {
+			v = math.Max(v, (caves.CheeseThreshold-c)/extent)
+		}
+	}
+
+	// fading lowers the value so the caves shrink and close up
+	if v > 0.0 {
+		v = v + f - 1.0
+	}
+	return v
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (caves *Caves3D) Range3D() (min float64, max float64) {
+	min, max = -1.0, -1.0
+	if caves.SpaghettiA != nil && caves.SpaghettiB != nil && caves.SpaghettiThickness > 0.0 {
+		alo, ahi := absRange(ValueRange3D(caves.SpaghettiA))
+		blo, bhi := absRange(ValueRange3D(caves.SpaghettiB))
+		min = 1.0 - math.Max(ahi, bhi)/caves.SpaghettiThickness
+		max = 1.0 - math.Max(alo, blo)/caves.SpaghettiThickness
+	}
+	if caves.Cheese != nil {
+		if extent := caves.CheeseThreshold + 1.0; extent > 0.0 {
+			lo, hi := ValueRange3D(caves.Cheese)
+			min = math.Max(min, (caves.CheeseThreshold-hi)/extent)
+			max = math.Max(max, (caves.CheeseThreshold-lo)/extent)
+		}
+	}
+
+	// fading only lowers the positive values and never below the -1.0 of
+	// the faded out heights
+	return math.Min(min, -1.0), max
+}
+
+// IsCave returns true if the coordinate is inside of a cave.
+func (caves *Caves3D) IsCave(x float64, y float64, z float64) bool {
+	return caves.Get3D(x, y, z) > 0.0
+}
+
+// BakeChunk returns a mask of the voxels in a chunk that are inside of caves.
+// The chunk starts at the origin and has sizeX x sizeY x sizeZ voxels that
+// each measure voxelSize; each voxel is sampled at its minimum corner. The
+// mask is stored with X changing fastest, then Y and then Z like Builder3D.
+func (caves *Caves3D) BakeChunk(originX float64, originY float64, originZ float64, sizeX int, sizeY int, sizeZ int, voxelSize float64) []bool {
+	if sizeX <= 0 || sizeY <= 0 || sizeZ <= 0 {
+		return nil
+	}
+	mask := make([]bool, sizeX*sizeY*sizeZ)
+	for z := 0; z < sizeZ; z++ {
+		wz := originZ + float64(z)*voxelSize
+		for y := 0; y < sizeY; y++ {
+			wy := originY + float64(y)*voxelSize
+			for x := 0; x < sizeX; x++ {
+				wx := originX + float64(x)*voxelSize
+				mask[(z*sizeY+y)*sizeX+x] = caves.IsCave(wx, wy, wz)
+			}
+		}
+	}
+	return mask
+}
@@ -0,0 +1,181 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains tools for keeping the borders of chunks built by a
+ChunkManager continuous.
+
+Chunks sampled from the same pipeline always line up, but post-processing
+like erosion looks at the neighbours of each value and sees the edge of the
+chunk instead of the neighbouring chunk. ProcessChunk() builds the chunk with
+a margin of extra values on every side, processes it and crops the margin off
+again. If the processing only moves information across at most margin grid
+points, the result is exactly the same as processing one large map. Each
+iteration of ThermalErosion reaches 2 grid points, so a margin of twice the
+iterations is enough:
+
+	te := noisey.NewThermalErosion(8, 0.01, 0.5)
+	nm, err := chunks.ProcessChunk(coord, 16, te.Erode)
+
+Processing that reaches further, like HydraulicErosion, can still leave small
+differences. The padded chunks of neighbours overlap by 2*margin values, so
+ChunkSeamError() measures how different two processed padded chunks are
+where they overlap and StitchChunks() blends them into each other so that
+the overlap becomes identical in both.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChunkWithMargin builds the chunk with margin extra grid points on every side
+// so the map has ChunkSize+2*margin values along each side.
+func (cm *ChunkManager) ChunkWithMargin(c ChunkCoord, margin int) (NoiseMap, error) {
+	if cm.Source == nil {
+		return NoiseMap{}, fmt.Errorf("Unable to build chunk %v without a Source.\n", c)
+	}
+	if cm.ChunkSize <= 0 || margin < 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to build chunk %v with a chunk size of %d and a margin of %d.\n", c, cm.ChunkSize, margin)
+	}
+
+	n := cm.ChunkSize + 2*margin
+	x0, y0 := c.X*cm.ChunkSize-margin, c.Y*cm.ChunkSize-margin
+	cs := cm.CellSize
+	bounds := Builder2DBounds{float64(x0) * cs, float64(y0) * cs, float64(x0+n) * cs, float64(y0+n) * cs}
+	nm := NewNoiseMap(n, n, bounds)
+	for y := 0; y < n; y++ {
+		wy := float64(y0+y) * cs
+		for x := 0; x < n; x++ {
+			wx := float64(x0+x) * cs
+			nm.Values[y*n+x] = cm.Source.Get2D(wx, wy)
+		}
+	}
+	return nm, nil
+}
+
+// CropChunk returns a copy of the map with margin values removed from every side.
+func CropChunk(nm *NoiseMap, margin int) (NoiseMap, error) {
+	w, h := nm.Width-2*margin, nm.Height-2*margin
+	if margin < 0 || w <= 0 || h <= 0 || len(nm.Values) != nm.Width*nm.Height {
+		return NoiseMap{}, fmt.Errorf("Unable to crop a margin of %d from a map of size %dx%d with %d values.\n", margin, nm.Width, nm.Height, len(nm.Values))
+	}
+
+	dx := (nm.Bounds.MaxX - nm.Bounds.MinX) / float64(nm.Width)
+	dy := (nm.Bounds.MaxY - nm.Bounds.MinY) / float64(nm.Height)
+	m := float64(margin)
+	bounds := Builder2DBounds{nm.Bounds.MinX + m*dx, nm.Bounds.MinY + m*dy, nm.Bounds.MaxX - m*dx, nm.Bounds.MaxY - m*dy}
+	cropped := NewNoiseMap(w, h, bounds)
+	cropped.Interpolation = nm.Interpolation
+	for y := 0; y < h; y++ {
+		copy(cropped.Values[y*w:(y+1)*w], nm.Values[(y+margin)*nm.Width+margin:])
+	}
+	return cropped, nil
+}
+
+// ProcessChunk builds the chunk with a margin, runs process on it and crops
+// the margin off of the result. The chunk is not cached.
+func (cm *ChunkManager) ProcessChunk(c ChunkCoord, margin int, process func(*NoiseMap) (NoiseMap, error)) (NoiseMap, error) {
+	padded, err := cm.ChunkWithMargin(c, margin)
+	if err != nil {
+		return NoiseMap{}, err
+	}
+	processed, err := process(&padded)
+	if err != nil {
+		return NoiseMap{}, err
+	}
+	if processed.Width != padded.Width || processed.Height != padded.Height {
+		return NoiseMap{}, fmt.Errorf("Unable to crop chunk %v: processing changed its size from %dx%d to %dx%d.\n", c, padded.Width, padded.Height, processed.Width, processed.Height)
+	}
+	return CropChunk(&processed, margin)
+}
+
+// chunkOverlap returns the grid offset of b inside of a for neighbouring
+// chunks of the same size with b at (dx, dy) chunks from a, where each of dx
+// and dy must be -1, 0 or 1, along with the size of the overlap.
+func chunkOverlap(a *NoiseMap, b *NoiseMap, dx int, dy int, overlap int) (ox int, oy int, w int, h int, err error) {
+	if a.Width != b.Width || a.Height != b.Height || len(a.Values) != a.Width*a.Height || len(b.Values) != b.Width*b.Height {
+		return 0, 0, 0, 0, fmt.Errorf("Unable to compare chunks of size %dx%d and %dx%d.\n", a.Width, a.Height, b.Width, b.Height)
+	}
+	if dx < -1 || dx > 1 || dy < -1 || dy > 1 || (dx == 0 && dy == 0) {
+		return 0, 0, 0, 0, fmt.Errorf("Unable to compare chunks that are not neighbours (%d, %d).\n", dx, dy)
+	}
+	if overlap <= 0 || overlap > a.Width || overlap > a.Height {
+		return 0, 0, 0, 0, fmt.Errorf("Unable to compare chunks of size %dx%d that overlap by %d.\n", a.Width, a.Height, overlap)
+	}
+
+	// b starts this many grid points after a along each axis
+	ox, w = dx*(a.Width-overlap), a.Width
+	if dx != 0 {
+		w = overlap
+	}
+	oy, h = dy*(a.Height-overlap), a.Height
+	if dy != 0 {
+		h = overlap
+	}
+	return ox, oy, w, h, nil
+}
+
+// ChunkSeamError returns the largest difference between two padded chunks
+// where they overlap. Chunk b is at (dx, dy) chunks from a and the chunks
+// overlap by overlap grid points, which is 2*margin for ChunkWithMargin().
+func ChunkSeamError(a *NoiseMap, b *NoiseMap, dx int, dy int, overlap int) (float64, error) {
+	ox, oy, w, h, err := chunkOverlap(a, b, dx, dy, overlap)
+	if err != nil {
+		return 0.0, err
+	}
+
+	var maxDiff float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ax, ay := maxInt(ox, 0)+x, maxInt(oy, 0)+y
+			d := math.Abs(a.Values[ay*a.Width+ax] - b.Values[(ay-oy)*b.Width+ax-ox])
+			if d > maxDiff || math.IsNaN(d) {
+				maxDiff = d
+			}
+		}
+	}
+	return maxDiff, nil
+}
+
+// StitchChunks blends two padded chunks where they overlap so that the
+// overlap is identical in both. Across the overlap the blend moves smoothly
+// from the values of a to the values of b.
+func StitchChunks(a *NoiseMap, b *NoiseMap, dx int, dy int, overlap int) error {
+	ox, oy, w, h, err := chunkOverlap(a, b, dx, dy, overlap)
+	if err != nil {
+		return err
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// how far across the overlap from a to b the value is
+			t := 0.0
+			if dx != 0 {
+				t = blendWeight(x, w, dx)
+			}
+			if dy != 0 {
+				t = math.Max(t, blendWeight(y, h, dy))
+			}
+			ax, ay := maxInt(ox, 0)+x, maxInt(oy, 0)+y
+			ai, bi := ay*a.Width+ax, (ay-oy)*b.Width+ax-ox
+			v := lerp(a.Values[ai], b.Values[bi], t)
+			a.Values[ai], b.Values[bi] = v, v
+		}
+	}
+	return nil
+}
+
+// blendWeight returns the weight of the neighbouring chunk at grid point i of
+// an overlap of size n when the neighbour is in the direction d.
+func blendWeight(i int, n int, d int) float64 {
+	t := (float64(i) + 0.5) / float64(n)
+	if d < 0 {
+		t = 1.0 - t
+	}
+	return t
+}
@@ -0,0 +1,226 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the ChunkManager which builds an infinite 2D world as
+square chunks of noise keyed by their chunk coordinates. World grid point
+(i, j) is always sampled at (i*CellSize, j*CellSize), so a chunk contains
+exactly the same values no matter when, in what order or on which thread it
+is built, and neighbouring chunks line up with each other.
+
+	fbm := noisey.NewFBMGenerator2D(&perlin, 6, 0.5, 2.0, 1.0)
+	chunks := noisey.NewChunkManager(&fbm, 64, 1.0/64.0, 256)
+	nm, err := chunks.Chunk(noisey.ChunkCoord{-3, 7})
+
+Recently used chunks are kept in a least recently used cache of Capacity
+chunks. Chunks() builds all of the chunks it's asked for at once using up to
+Workers goroutines, and the same chunk is never built twice at the same time.
+The Source is shared between the goroutines so it must be safe to sample from
+several goroutines at once, which all of the modules in this package are.
+
+*/
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// ChunkCoord is the coordinate of a chunk in units of chunks.
+type ChunkCoord struct {
+	X, Y int
+}
+
+// chunkCall is a chunk that is being built so that other callers can wait for it.
+type chunkCall struct {
+	done chan struct{}
+	nm   *NoiseMap
+}
+
+// ChunkManager builds and caches the chunks of an infinite 2D world.
+type ChunkManager struct {
+	Source    NoiseyGet2D // the pipeline the chunks are sampled from
+	ChunkSize int         // the number of grid points along each side of a chunk
+	CellSize  float64     // the distance in world coordinates between grid points
+	Capacity  int         // the number of chunks kept in the cache; 0 disables caching
+	Workers   int         // the number of goroutines Chunks() builds with
+
+	lock    sync.Mutex
+	cache   map[ChunkCoord]*list.Element
+	lru     *list.List // the cached chunks with the most recently used at the front
+	pending map[ChunkCoord]*chunkCall
+}
+
+// chunkEntry is an element of the LRU list.
+type chunkEntry struct {
+	coord ChunkCoord
+	nm    *NoiseMap
+}
+
+// NewChunkManager creates a new chunk manager that builds chunks with
+// chunkSize x chunkSize values using one worker for each CPU.
+func NewChunkManager(src NoiseyGet2D, chunkSize int, cellSize float64, capacity int) (cm ChunkManager) {
+	cm.Source = src
+	cm.ChunkSize = chunkSize
+	cm.CellSize = cellSize
+	cm.Capacity = capacity
+	cm.Workers = runtime.NumCPU()
+	return
+}
+
+// ChunkAt returns the coordinate of the chunk containing the world coordinate.
+func (cm *ChunkManager) ChunkAt(x float64, y float64) ChunkCoord {
+	size := float64(cm.ChunkSize) * cm.CellSize
+	return ChunkCoord{int(math.Floor(x / size)), int(math.Floor(y / size))}
+}
+
+// ChunkBounds returns the world rectangle the chunk covers.
+func (cm *ChunkManager) ChunkBounds(c ChunkCoord) Builder2DBounds {
+	size := float64(cm.ChunkSize) * cm.CellSize
+	return Builder2DBounds{float64(c.X) * size, float64(c.Y) * size, float64(c.X+1) * size, float64(c.Y+1) * size}
+}
+
+// Len returns the number of chunks in the cache.
+func (cm *ChunkManager) Len() int {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	if cm.lru == nil {
+		return 0
+	}
+	return cm.lru.Len()
+}
+
+// Evict removes a chunk from the cache.
+func (cm *ChunkManager) Evict(c ChunkCoord) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	if e, ok := cm.cache[c]; ok {
+		cm.lru.Remove(e)
+		delete(cm.cache, c)
+	}
+}
+
+// Clear removes all of the chunks from the cache.
+func (cm *ChunkManager) Clear() {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	cm.cache = nil
+	cm.lru = nil
+}
+
+// Chunk returns the chunk at the coordinate, building it if it's not in the
+// cache. The returned map is shared with the cache and must not be modified;
+// Clone() it first if it needs to be changed.
+func (cm *ChunkManager) Chunk(c ChunkCoord) (*NoiseMap, error) {
+	if cm.Source == nil {
+		return nil, fmt.Errorf("Unable to build chunk %v without a Source.\n", c)
+	}
+	if cm.ChunkSize <= 0 {
+		return nil, fmt.Errorf("Unable to build chunk %v with a chunk size of %d.\n", c, cm.ChunkSize)
+	}
+
+	cm.lock.Lock()
+	if e, ok := cm.cache[c]; ok {
+		cm.lru.MoveToFront(e)
+		cm.lock.Unlock()
+		return e.Value.(*chunkEntry).nm, nil
+	}
+	if call, ok := cm.pending[c]; ok {
+		cm.lock.Unlock()
+		<-call.done
+		return call.nm, nil
+	}
+	call := &chunkCall{done: make(chan struct{})}
+	if cm.pending == nil {
+		cm.pending = make(map[ChunkCoord]*chunkCall)
+	}
+	cm.pending[c] = call
+	cm.lock.Unlock()
+
+	nm := cm.build(c)
+	call.nm = &nm
+
+	cm.lock.Lock()
+	delete(cm.pending, c)
+	cm.store(c, call.nm)
+	cm.lock.Unlock()
+	close(call.done)
+	return call.nm, nil
+}
+
+// Chunks returns the chunks at the coordinates in the same order, building
+// the ones that aren't in the cache concurrently.
+func (cm *ChunkManager) Chunks(coords []ChunkCoord) ([]*NoiseMap, error) {
+	maps := make([]*NoiseMap, len(coords))
+	errs := make([]error, len(coords))
+	workers := cm.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				maps[i], errs[i] = cm.Chunk(coords[i])
+			}
+		}()
+	}
+	for i := range coords {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return maps, err
+		}
+	}
+	return maps, nil
+}
+
+// build samples the chunk from the Source.
+func (cm *ChunkManager) build(c ChunkCoord) NoiseMap {
+	n := cm.ChunkSize
+	nm := NewNoiseMap(n, n, cm.ChunkBounds(c))
+	for y := 0; y < n; y++ {
+		wy := float64(c.Y*n+y) * cm.CellSize
+		for x := 0; x < n; x++ {
+			wx := float64(c.X*n+x) * cm.CellSize
+			nm.Values[y*n+x] = cm.Source.Get2D(wx, wy)
+		}
+	}
+	return nm
+}
+
+// store adds a chunk to the cache and drops the least recently used chunks
+// beyond Capacity. The lock must be held.
+func (cm *ChunkManager) store(c ChunkCoord, nm *NoiseMap) {
+	if cm.Capacity <= 0 {
+		return
+	}
+	if cm.cache == nil {
+		cm.cache = make(map[ChunkCoord]*list.Element)
+		cm.lru = list.New()
+	}
+	if e, ok := cm.cache[c]; ok {
+		e.Value.(*chunkEntry).nm = nm
+		cm.lru.MoveToFront(e)
+	} else {
+		cm.cache[c] = cm.lru.PushFront(&chunkEntry{c, nm})
+	}
+	for cm.lru.Len() > cm.Capacity {
+		oldest := cm.lru.Back()
+		cm.lru.Remove(oldest)
+		delete(cm.cache, oldest.Value.(*chunkEntry).coord)
+	}
+}
@@ -0,0 +1,121 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"math"
+	"testing"
+)
+
+var chunkNeighbours = []ChunkCoord{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+
+func newTestChunkManager() ChunkManager {
+	perlin := NewPerlinSeeded(42)
+	fbm := NewFBMGenerator2D(&perlin, 4, 0.5, 2.0, 1.0)
+	return NewChunkManager(&fbm, 16, 1.0/16.0, 16)
+}
+
+// TestChunksMatchSource makes sure chunks contain the values of the source at
+// their world grid points no matter which order they are built in.
+func TestChunksMatchSource(t *testing.T) {
+	cm := newTestChunkManager()
+	coords := []ChunkCoord{{-2, 3}, {0, 0}, {5, -1}, {-2, 3}}
+	maps, err := cm.Chunks(coords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range coords {
+		for y := 0; y < cm.ChunkSize; y++ {
+			for x := 0; x < cm.ChunkSize; x++ {
+				wx := float64(c.X*cm.ChunkSize+x) * cm.CellSize
+				wy := float64(c.Y*cm.ChunkSize+y) * cm.CellSize
+				if v, e := maps[i].Get(x, y), cm.Source.Get2D(wx, wy); v != e {
+					t.Fatalf("chunk %v value (%d, %d) is %v; expected %v", c, x, y, v, e)
+				}
+			}
+		}
+	}
+	if maps[0] != maps[3] {
+		t.Errorf("the same chunk was built twice instead of being cached")
+	}
+}
+
+// TestChunkMarginsOverlap makes sure padded neighbouring chunks are identical where they overlap.
+func TestChunkMarginsOverlap(t *testing.T) {
+	cm := newTestChunkManager()
+	margin := 3
+	center, err := cm.ChunkWithMargin(ChunkCoord{1, -1}, margin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range chunkNeighbours {
+		nb, err := cm.ChunkWithMargin(ChunkCoord{1 + d.X, -1 + d.Y}, margin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff, err := ChunkSeamError(&center, &nb, d.X, d.Y, 2*margin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff != 0.0 {
+			t.Errorf("chunks at offset %v differ by %v where they overlap", d, diff)
+		}
+	}
+}
+
+// TestProcessChunkMatchesLargeMap makes sure eroding chunks with a margin
+// gives the same values as eroding one large map.
+func TestProcessChunkMatchesLargeMap(t *testing.T) {
+	cm := newTestChunkManager()
+	te := NewThermalErosion(4, 0.01, 0.5)
+	margin := 2 * te.Iterations
+
+	// a large map covering chunks (0, 0) to (1, 1) with a margin around them
+	n := cm.ChunkSize
+	large := NewNoiseMap(2*n+2*margin, 2*n+2*margin, Builder2DBounds{})
+	for y := 0; y < large.Height; y++ {
+		for x := 0; x < large.Width; x++ {
+			large.Set(x, y, cm.Source.Get2D(float64(x-margin)*cm.CellSize, float64(y-margin)*cm.CellSize))
+		}
+	}
+	eroded, err := te.Erode(&large)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for cy := 0; cy < 2; cy++ {
+		for cx := 0; cx < 2; cx++ {
+			chunk, err := cm.ProcessChunk(ChunkCoord{cx, cy}, margin, te.Erode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < n; y++ {
+				for x := 0; x < n; x++ {
+					if v, e := chunk.Get(x, y), eroded.Get(cx*n+x+margin, cy*n+y+margin); v != e {
+						t.Fatalf("chunk (%d, %d) value (%d, %d) is %v; expected %v", cx, cy, x, y, v, e)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestStitchChunks makes sure stitching makes the overlap of two different chunks identical.
+func TestStitchChunks(t *testing.T) {
+	cm := newTestChunkManager()
+	margin := 4
+	for _, d := range chunkNeighbours {
+		a, _ := cm.ChunkWithMargin(ChunkCoord{0, 0}, margin)
+		b, _ := cm.ChunkWithMargin(ChunkCoord{d.X, d.Y}, margin)
+		for i := range b.Values {
+			b.Values[i] += 0.25 * math.Sin(float64(i))
+		}
+		if err := StitchChunks(&a, &b, d.X, d.Y, 2*margin); err != nil {
+			t.Fatal(err)
+		}
+		if diff, _ := ChunkSeamError(&a, &b, d.X, d.Y, 2*margin); diff != 0.0 {
+			t.Errorf("stitched chunks at offset %v still differ by %v", d, diff)
+		}
+	}
+}
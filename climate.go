@@ -0,0 +1,158 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module generates temperature and precipitation maps for a heightmap.
+Both are in the range [-1, 1] with an elevation of 0.0 as sea level, the same
+as DefaultBiomes(), so they can be given straight to a BiomeClassifier:
+
+	climate := noisey.NewClimate(&tempNoise, &rainNoise)
+	climate.EquatorY, climate.PoleDistance = 0.0, 512.0
+	result, err := climate.Generate(&heightmap)
+	classifier := noisey.NewBiomeClassifier(&heightmap, &result.Precipitation, &result.Temperature, noisey.DefaultBiomes())
+
+Temperature falls from EquatorTemperature to PoleTemperature with the cosine
+of the latitude, drops by LapseRate for every unit of elevation above sea
+level and is varied by the temperature noise. The latitude is measured along
+the world Y axis: EquatorY is the equator and the poles are PoleDistance
+away from it on either side.
+
+Precipitation starts from the precipitation noise. Air moving with the
+prevailing Wind rises and rains on slopes facing the wind, which adds
+Orographic times the climb of the terrain, and mountains between a point and
+the wind take the moisture away so the far side is left dry. The rain shadow
+looks upwind for ShadowDistance world units and dries a point by how far the
+highest terrain there rises above it, fading with the distance to it.
+
+All noise sources are sampled at the world coordinates of the heightmap.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Climate contains the parameters for generating climate maps.
+type Climate struct {
+	TemperatureNoise   NoiseyGet2D // the noise that varies the temperature; nil for none
+	PrecipitationNoise NoiseyGet2D // the noise precipitation starts from; nil for an even 0.5
+
+	EquatorY           float64 // the world Y of the equator
+	PoleDistance       float64 // the world distance from the equator to the poles
+	EquatorTemperature float64 // the temperature at sea level on the equator
+	PoleTemperature    float64 // the temperature at sea level at the poles
+	LapseRate          float64 // how much the temperature drops per unit of elevation above sea level
+	TemperatureAmount  float64 // what the temperature noise is scaled by
+
+	Wind             Vec2f   // the direction of the prevailing wind
+	Orographic       float64 // how much rain a climb of one unit of elevation per world unit adds
+	ShadowDistance   float64 // how far upwind mountains cast a rain shadow in world units
+	ShadowStrength   float64 // how strongly a unit of elevation upwind dries a point
+	PrecipitationMin float64 // the precipitation of the driest point [-1, 1]
+}
+
+// ClimateResult is the output of Climate.Generate().
+type ClimateResult struct {
+	Temperature   NoiseMap // the temperature in [-1, 1]
+	Precipitation NoiseMap // the precipitation in [-1, 1]
+}
+
+// NewClimate creates a new climate generator with a west wind and parameters
+// for a heightmap in [-1, 1] with a cell size of about 1.0.
+func NewClimate(temperatureNoise NoiseyGet2D, precipitationNoise NoiseyGet2D) (c Climate) {
+	c.TemperatureNoise = temperatureNoise
+	c.PrecipitationNoise = precipitationNoise
+	c.EquatorY = 0.0
+	c.PoleDistance = 256.0
+	c.EquatorTemperature = 1.0
+	c.PoleTemperature = -1.0
+	c.LapseRate = 1.5
+	c.TemperatureAmount = 0.2
+	c.Wind = Vec2f{1.0, 0.0}
+	c.Orographic = 20.0
+	c.ShadowDistance = 64.0
+	c.ShadowStrength = 3.0
+	c.PrecipitationMin = -1.0
+	return
+}
+
+// String returns a description of the generator and its noise sources.
+func (c *Climate) String() string {
+	return fmt.Sprintf("Climate{EquatorY: %v, PoleDistance: %v, EquatorTemperature: %v, PoleTemperature: %v, LapseRate: %v, TemperatureAmount: %v, Wind: %v, Orographic: %v, ShadowDistance: %v, ShadowStrength: %v, PrecipitationMin: %v, TemperatureNoise: %s, PrecipitationNoise: %s}",
+		c.EquatorY, c.PoleDistance, c.EquatorTemperature, c.PoleTemperature, c.LapseRate, c.TemperatureAmount, c.Wind, c.Orographic,
+		c.ShadowDistance, c.ShadowStrength, c.PrecipitationMin, describeModule(c.TemperatureNoise), describeModule(c.PrecipitationNoise))
+}
+
+// Latitude returns the latitude in degrees [-90, 90] at the world Y.
+func (c *Climate) Latitude(y float64) float64 {
+	if c.PoleDistance == 0.0 {
+		return 0.0
+	}
+	return math.Max(-90.0, math.Min(90.0, (y-c.EquatorY)/c.PoleDistance*90.0))
+}
+
+// Generate creates the climate maps for the heightmap.
+func (c *Climate) Generate(heightmap *NoiseMap) (result ClimateResult, err error) {
+	w, h := heightmap.Width, heightmap.Height
+	if w <= 0 || h <= 0 || len(heightmap.Values) != w*h {
+		return result, fmt.Errorf("Unable to generate a climate for a heightmap of size %dx%d with %d values.\n", w, h, len(heightmap.Values))
+	}
+	b := heightmap.Bounds
+	dx, dy := (b.MaxX-b.MinX)/float64(w), (b.MaxY-b.MinY)/float64(h)
+
+	// march upwind in steps of about one grid cell
+	var upwind Vec2f
+	step := math.Min(math.Abs(dx), math.Abs(dy))
+	steps := 0
+	if l := c.Wind.Length(); l > 0.0 && step > 0.0 {
+		upwind = c.Wind.Scale(-step / l)
+		steps = int(c.ShadowDistance / step)
+	}
+
+	result.Temperature = NewNoiseMap(w, h, b)
+	result.Precipitation = NewNoiseMap(w, h, b)
+	for y := 0; y < h; y++ {
+		wy := b.MinY + float64(y)*dy
+		lat := c.Latitude(wy) * math.Pi / 180.0
+		for x := 0; x < w; x++ {
+			wx := b.MinX + float64(x)*dx
+			i := y*w + x
+			elevation := heightmap.Values[i]
+			ground := math.Max(0.0, elevation)
+
+			t := c.PoleTemperature + (c.EquatorTemperature-c.PoleTemperature)*math.Cos(lat)
+			t -= c.LapseRate * ground
+			if c.TemperatureNoise != nil {
+				t += c.TemperatureAmount * c.TemperatureNoise.Get2D(wx, wy)
+			}
+			result.Temperature.Values[i] = math.Max(-1.0, math.Min(1.0, t))
+
+			// wetness in [0, 1] before it's mapped to the output range
+			wet := 0.5
+			if c.PrecipitationNoise != nil {
+				wet = (c.PrecipitationNoise.Get2D(wx, wy) + 1.0) * 0.5
+			}
+			if steps > 0 {
+				p := Vec2f{wx, wy}
+				climb := ground - math.Max(0.0, heightmap.Get2D(p.X+upwind.X, p.Y+upwind.Y))
+				wet += c.Orographic * math.Max(0.0, climb) / step
+
+				shadow := 0.0
+				for s := 1; s <= steps; s++ {
+					q := p.Add(upwind.Scale(float64(s)))
+					rise := math.Max(0.0, heightmap.Get2D(q.X, q.Y)) - ground
+					fade := 1.0 - float64(s)/float64(steps+1)
+					shadow = math.Max(shadow, rise*fade)
+				}
+				wet *= math.Exp(-c.ShadowStrength * shadow)
+			}
+			wet = math.Max(0.0, math.Min(1.0, wet))
+			result.Precipitation.Values[i] = lerp(c.PrecipitationMin, 1.0, wet)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the interfaces and functions used to make deep copies
+of a pipeline of noise modules so that the copy can be modified without
+affecting the original.
+
+The RandomSource used to construct a source is shared between the original
+and the copy since it is only used during construction and an arbitrary
+random number generator cannot be copied safely.
+
+*/
+
+// NoiseyClone2D is an interface for modules that can make a deep copy of themselves.
+type NoiseyClone2D interface {
+	Clone2D() NoiseyGet2D
+}
+
+// NoiseyClone3D is an interface for modules that can make a deep copy of themselves.
+type NoiseyClone3D interface {
+	Clone3D() NoiseyGet3D
+}
+
+// DeepCopy2D returns a deep copy of the module s and all of the modules it
+// uses. If s does not implement NoiseyClone2D then s itself is returned
+// and will be shared with the original pipeline.
+func DeepCopy2D(s NoiseyGet2D) NoiseyGet2D {
+	if s == nil {
+		return nil
+	}
+	if c, ok := s.(NoiseyClone2D); ok {
+		return c.Clone2D()
+	}
+	return s
+}
+
+// DeepCopy3D returns a deep copy of the module s and all of the modules it
+// uses. If s does not implement NoiseyClone3D then s itself is returned
+// and will be shared with the original pipeline.
+func DeepCopy3D(s NoiseyGet3D) NoiseyGet3D {
+	if s == nil {
+		return nil
+	}
+	if c, ok := s.(NoiseyClone3D); ok {
+		return c.Clone3D()
+	}
+	return s
+}
+
+func copyInts(src []int) []int {
+	if src == nil {
+		return nil
+	}
+	dst := make([]int, len(src))
+	copy(dst, src)
+	return dst
+}
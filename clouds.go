@@ -0,0 +1,195 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes the density of volumetric clouds for ray marched rendering,
+following the recipe Guerrilla Games used for the skies of Horizon Zero Dawn.
+The Y axis points up and the 2D weather maps are sampled at (x, z).
+
+	clouds := noisey.NewClouds3D(&coverage, &shape, 1500.0, 4000.0)
+	clouds.Detail = &detail
+	density := clouds.Get3D(x, y, z)
+	texture, err := clouds.Bake(128, 32, 128, bounds)
+
+The density at a point is built up in steps, with every noise in [-1, 1]
+mapped to [0, 1] first:
+
+* The height in the cloud layer from MinY to MaxY picks a density profile
+  that depends on the cloud type: 0.0 makes thin flat stratus, 0.5 puffy
+  cumulus and 1.0 towering cumulonimbus. The type comes from the Type map, or
+  CloudType if there is no Type.
+* The Shape noise is scaled by the profile and then remapped so that only the
+  parts of it above 1-coverage remain, where the coverage comes from the
+  Coverage map. A coverage of 0.0 leaves a clear sky.
+* The optional Detail noise erodes the edges of the clouds, by up to
+  DetailStrength. Its effect is inverted near the base of the layer, which
+  makes wispy bottoms and billowy tops.
+
+The result is 0.0 outside the clouds and up to Density inside of them. A
+Perlin-Worley mix makes a good Shape and a few octaves of inverted Worley
+noise at a higher frequency a good Detail.
+
+Reference material:
+* Schneider, "The Real-time Volumetric Cloudscapes of Horizon Zero Dawn", 2015
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// cloudProfiles are the heights in the layer where the density of stratus,
+// cumulus and cumulonimbus clouds starts to rise, reaches its full value,
+// starts to fall and reaches 0.0 again.
+var cloudProfiles = [3][4]float64{
+	{0.0, 0.1, 0.2, 0.3},
+	{0.0, 0.2, 0.45, 0.7},
+	{0.0, 0.1, 0.75, 1.0},
+}
+
+// Clouds3D is a module that returns the density of volumetric clouds.
+type Clouds3D struct {
+	Coverage       NoiseyGet2D // the weather map of how much of the sky is covered at (x, z)
+	Type           NoiseyGet2D // the optional weather map of the cloud type at (x, z)
+	Shape          NoiseyGet3D // the low frequency noise of the shapes of the clouds
+	Detail         NoiseyGet3D // the optional high frequency noise that erodes the edges
+	MinY           float64     // the world Y of the base of the cloud layer
+	MaxY           float64     // the world Y of the top of the cloud layer
+	CloudType      float64     // the cloud type [0, 1] used if there is no Type map
+	DetailStrength float64     // how much Detail erodes the clouds [0, 1]
+	Density        float64     // the density of the thickest clouds
+}
+
+// NewClouds3D creates a new cloud module for cumulus clouds without Detail.
+func NewClouds3D(coverage NoiseyGet2D, shape NoiseyGet3D, minY float64, maxY float64) (c Clouds3D) {
+	c.Coverage = coverage
+	c.Shape = shape
+	c.MinY = minY
+	c.MaxY = maxY
+	c.CloudType = 0.5
+	c.DetailStrength = 0.35
+	c.Density = 1.0
+	return
+}
+
+// Clone3D returns a deep copy of the module and its sources.
+func (c *Clouds3D) Clone3D() NoiseyGet3D {
+	clone := *c
+	clone.Coverage = DeepCopy2D(c.Coverage)
+	clone.Type = DeepCopy2D(c.Type)
+	clone.Shape = DeepCopy3D(c.Shape)
+	clone.Detail = DeepCopy3D(c.Detail)
+	return &clone
+}
+
+// String returns a description of the module and its sources.
+func (c *Clouds3D) String() string {
+	return fmt.Sprintf("Clouds3D{MinY: %v, MaxY: %v, CloudType: %v, DetailStrength: %v, Density: %v, Coverage: %s, Type: %s, Shape: %s, Detail: %s}",
+		c.MinY, c.MaxY, c.CloudType, c.DetailStrength, c.Density, describeModule(c.Coverage), describeModule(c.Type),
+		describeModule(c.Shape), describeModule(c.Detail))
+}
+
+// unitNoise maps a noise value in [-1, 1] to [0, 1].
+func unitNoise(v float64) float64 {
+	return math.Max(0.0, math.Min(1.0, (v+1.0)*0.5))
+}
+
+// remap maps v from [lo, hi] to [newLo, newHi] without clamping.
+func remap(v float64, lo float64, hi float64, newLo float64, newHi float64) float64 {
+	if hi == lo {
+		return newLo
+	}
+	return newLo + (v-lo)/(hi-lo)*(newHi-newLo)
+}
+
+// smoothstep returns 0.0 below lo, 1.0 above hi and a smooth curve between.
+func smoothstep(lo float64, hi float64, v float64) float64 {
+	if v <= lo {
+		return 0.0
+	}
+	if v >= hi {
+		return 1.0
+	}
+	t := (v - lo) / (hi - lo)
+	return t * t * (3.0 - 2.0*t)
+}
+
+// cloudProfile returns the density profile [0, 1] of the cloud type at the
+// height h [0, 1] in the layer, blending between the profiles of the types.
+func cloudProfile(h float64, cloudType float64) float64 {
+	t := math.Max(0.0, math.Min(1.0, cloudType)) * 2.0
+	i := minInt(int(t), 1)
+	f := t - float64(i)
+	var p [4]float64
+	for k := range p {
+		p[k] = lerp(cloudProfiles[i][k], cloudProfiles[i+1][k], f)
+	}
+	return smoothstep(p[0], p[1], h) * (1.0 - smoothstep(p[2], p[3], h))
+}
+
+// Get3D calculates the cloud density at the coordinate.
+func (c *Clouds3D) Get3D(x float64, y float64, z float64) float64 {
+	if c.MaxY <= c.MinY {
+		return 0.0
+	}
+	h := (y - c.MinY) / (c.MaxY - c.MinY)
+	if h <= 0.0 || h >= 1.0 {
+		return 0.0
+	}
+	coverage := unitNoise(c.Coverage.Get2D(x, z))
+	if coverage <= 0.0 {
+		return 0.0
+	}
+	cloudType := c.CloudType
+	if c.Type != nil {
+		cloudType = unitNoise(c.Type.Get2D(x, z))
+	}
+	profile := cloudProfile(h, cloudType)
+	if profile <= 0.0 {
+		return 0.0
+	}
+
+	base := unitNoise(c.Shape.Get3D(x, y, z)) * profile
+	base = remap(base, 1.0-coverage, 1.0, 0.0, 1.0) * coverage
+	if base <= 0.0 {
+		return 0.0
+	}
+	if c.Detail != nil && c.DetailStrength > 0.0 {
+		detail := unitNoise(c.Detail.Get3D(x, y, z))
+		erosion := lerp(detail, 1.0-detail, math.Min(1.0, h*10.0))
+		base = remap(base, erosion*c.DetailStrength, 1.0, 0.0, 1.0)
+	}
+	return math.Max(0.0, math.Min(1.0, base)) * c.Density
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (c *Clouds3D) Range3D() (min float64, max float64) {
+	return scaleRange(0.0, 1.0, c.Density)
+}
+
+// Bake builds the density into a width x height x depth volume over bounds
+// and returns it as bytes with 0 to Density mapped to [0, 255], with X
+// changing fastest, then Y and then Z like Builder3D. The bytes can be used
+// directly as a single channel 3D texture.
+func (c *Clouds3D) Bake(width int, height int, depth int, bounds Builder3DBounds) ([]uint8, error) {
+	if c.Coverage == nil || c.Shape == nil {
+		return nil, fmt.Errorf("Unable to bake clouds without a Coverage map and a Shape.\n")
+	}
+	if c.Density <= 0.0 {
+		return nil, fmt.Errorf("Unable to bake clouds with a Density of %v.\n", c.Density)
+	}
+	builder := NewBuilder3D(c, width, height, depth)
+	builder.Bounds = bounds
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	texture := make([]uint8, len(builder.Values))
+	for i, v := range builder.Values {
+		texture[i] = uint8(math.Floor(math.Max(0.0, math.Min(1.0, v/c.Density))*255.0 + 0.5))
+	}
+	return texture, nil
+}
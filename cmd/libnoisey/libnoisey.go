@@ -0,0 +1,167 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Libnoisey is a C API for the noise pipelines of NoiseJSON configurations,
+built as a shared library so that tools in Python, C#, C++ and other
+languages sample exactly the same noise as Go programs using noisey:
+
+	go build -buildmode=c-shared -o libnoisey.so github.com/tbogdala/noisey/cmd/libnoisey
+
+The build writes libnoisey.h next to the library with these functions:
+
+	// builds the pipeline of the configuration and returns its handle, or
+	// 0 with *err set to a message that has to be freed
+	long long noisey_create(char* json, char** err);
+
+	// fills out with width*height values of the generator over the bounds,
+	// row by row like a noisey.NoiseMap; the generator is the last one of
+	// the configuration if it is NULL or empty. It returns 0, or -1 with
+	// *err set to a message that has to be freed.
+	int noisey_fill(long long pipeline, char* generator, double minX, double minY,
+		double maxX, double maxY, int width, int height, double* out, char** err);
+
+	// frees the pipeline
+	void noisey_destroy(long long pipeline);
+
+	// frees an error message
+	void noisey_free_error(char* err);
+
+err may be NULL if the message isn't wanted. The random sources are seeded
+with math/rand like the noisey command and the servers do, so a
+configuration gives the same values everywhere. The functions can be called
+from several threads at once.
+
+From Python it can be used with ctypes:
+
+	lib = ctypes.CDLL("./libnoisey.so")
+	lib.noisey_create.restype = ctypes.c_longlong
+	pipeline = lib.noisey_create(config.encode(), None)
+	out = (ctypes.c_double * (256 * 256))()
+	lib.noisey_fill(ctypes.c_longlong(pipeline), None, ctypes.c_double(0), ctypes.c_double(0),
+		ctypes.c_double(1), ctypes.c_double(1), 256, 256, out, None)
+	lib.noisey_destroy(ctypes.c_longlong(pipeline))
+*/
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"unsafe"
+
+	"github.com/tbogdala/noisey"
+)
+
+// pipelines are the built configurations keyed by their handles.
+var pipelines = struct {
+	sync.RWMutex
+	next    int64
+	configs map[int64]*noisey.NoiseJSON
+}{configs: make(map[int64]*noisey.NoiseJSON)}
+
+// setError stores the message of err in *out if out isn't NULL.
+func setError(out **C.char, err error) {
+	if out != nil {
+		*out = C.CString(err.Error())
+	}
+}
+
+// create builds the sources and generators of a configuration.
+func create(config []byte) (*noisey.NoiseJSON, error) {
+	cfg, err := noisey.LoadNoiseJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.BuildSources(func(s int64) noisey.RandomSource {
+		return rand.New(rand.NewSource(s))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.BuildGenerators(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+//export noisey_create
+func noisey_create(json *C.char, err **C.char) C.longlong {
+	if json == nil {
+		setError(err, fmt.Errorf("Unable to create a pipeline without a configuration.\n"))
+		return 0
+	}
+	cfg, e := create([]byte(C.GoString(json)))
+	if e != nil {
+		setError(err, e)
+		return 0
+	}
+	pipelines.Lock()
+	pipelines.next++
+	handle := pipelines.next
+	pipelines.configs[handle] = cfg
+	pipelines.Unlock()
+	return C.longlong(handle)
+}
+
+// fill builds the generator of a pipeline into values.
+func fill(handle int64, name string, bounds noisey.Builder2DBounds, width int, height int, values []float64) error {
+	pipelines.RLock()
+	cfg, ok := pipelines.configs[handle]
+	pipelines.RUnlock()
+	if !ok {
+		return fmt.Errorf("Unable to find the pipeline %d.\n", handle)
+	}
+	if name == "" && len(cfg.Generators) > 0 {
+		name = cfg.Generators[len(cfg.Generators)-1].Name
+	}
+	gen := cfg.GetGenerator(name)
+	if gen == nil {
+		return fmt.Errorf("Unable to find the generator %q in the pipeline.\n", name)
+	}
+	builder := noisey.NewBuilder2D(gen, 0, 0)
+	builder.Width, builder.Height = width, height
+	builder.Values = values
+	builder.Bounds = bounds
+	return builder.Build()
+}
+
+//export noisey_fill
+func noisey_fill(pipeline C.longlong, generator *C.char, minX, minY, maxX, maxY C.double, width, height C.int, out *C.double, err **C.char) C.int {
+	w, h := int(width), int(height)
+	if w <= 0 || h <= 0 || out == nil {
+		setError(err, fmt.Errorf("Unable to fill a buffer of %dx%d values.\n", w, h))
+		return -1
+	}
+	var name string
+	if generator != nil {
+		name = C.GoString(generator)
+	}
+	values := unsafe.Slice((*float64)(unsafe.Pointer(out)), w*h)
+	bounds := noisey.Builder2DBounds{MinX: float64(minX), MinY: float64(minY), MaxX: float64(maxX), MaxY: float64(maxY)}
+	if e := fill(int64(pipeline), name, bounds, w, h, values); e != nil {
+		setError(err, e)
+		return -1
+	}
+	return 0
+}
+
+//export noisey_destroy
+func noisey_destroy(pipeline C.longlong) {
+	pipelines.Lock()
+	delete(pipelines.configs, int64(pipeline))
+	pipelines.Unlock()
+}
+
+//export noisey_free_error
+func noisey_free_error(err *C.char) {
+	C.free(unsafe.Pointer(err))
+}
+
+// main is required by -buildmode=c-shared and is never run.
+func main() {}
@@ -0,0 +1,155 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the bench command, which measures how expensive the
+generators of a configuration are to sample:
+
+	noisey bench -config terrain.yaml -samples 1000000 -regions 8 -size 512x512
+
+Every generator is sampled at the same -samples points scattered over
+-bounds, and the time of a sample is reported both including the
+generators it reads from and by itself, which is its time less the time of
+the generators listed as its inputs. The self time is an estimate, since a
+module may sample its inputs more or less than once a sample.
+
+The total is the time to build -regions maps of -size grid points with the
+generator picked by -generator, the last one of the configuration by
+default, next to each other along X starting at -bounds.
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tbogdala/noisey"
+)
+
+// benchSink keeps the compiler from dropping the samples that are timed.
+var benchSink float64
+
+// benchTime returns the nanoseconds a sample of the generator takes over
+// the points, running them at least once and for at least minTime.
+func benchTime(gen noisey.NoiseyGet2D, xs []float64, ys []float64, minTime time.Duration) float64 {
+	runs := 0
+	start := time.Now()
+	for {
+		for i := range xs {
+			benchSink += gen.Get2D(xs[i], ys[i])
+		}
+		runs++
+		if elapsed := time.Since(start); elapsed >= minTime {
+			return float64(elapsed.Nanoseconds()) / float64(runs*len(xs))
+		}
+	}
+}
+
+// perSecond formats the rate of something that takes ns nanoseconds each.
+func perSecond(ns float64) string {
+	if ns <= 0.0 {
+		return "-"
+	}
+	rate := 1e9 / ns
+	switch {
+	case rate >= 1e6:
+		return fmt.Sprintf("%.2fM", rate/1e6)
+	case rate >= 1e3:
+		return fmt.Sprintf("%.2fk", rate/1e3)
+	}
+	return fmt.Sprintf("%.2f", rate)
+}
+
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to build the regions with; the last one of the configuration if empty")
+	samples := flags.Int("samples", 100000, "the number of scattered points each generator is sampled at")
+	regions := flags.Int("regions", 4, "the number of maps built with the generator for the total")
+	size := flags.String("size", "256x256", "the size of the regions in grid points as WIDTHxHEIGHT")
+	bounds := flags.String("bounds", "0,0,1,1", "the rectangle the points are scattered over and of the first region as MINX,MINY,MAXX,MAXY")
+	minTime := flags.Duration("time", 200*time.Millisecond, "the least time every generator is sampled for")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	if *samples <= 0 || *regions < 0 {
+		return fmt.Errorf("-samples must be positive and -regions can't be negative")
+	}
+	w, h, err := parseSize(*size)
+	if err != nil {
+		return err
+	}
+	b, err := parseBounds(*bounds)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*config)
+	if err != nil {
+		return err
+	}
+	total, err := generator(cfg, *name)
+	if err != nil {
+		return err
+	}
+
+	// the points are made up front so their random numbers aren't timed
+	rng := rand.New(rand.NewSource(1))
+	xs := make([]float64, *samples)
+	ys := make([]float64, *samples)
+	for i := range xs {
+		xs[i] = b.MinX + rng.Float64()*(b.MaxX-b.MinX)
+		ys[i] = b.MinY + rng.Float64()*(b.MaxY-b.MinY)
+	}
+
+	times := make(map[string]float64)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "generator\ttype\tns/sample\tsamples/s\tself ns/sample\tself samples/s\t\n")
+	for _, gj := range cfg.Generators {
+		gen := cfg.GetGenerator(gj.Name)
+		if gen == nil {
+			continue
+		}
+		t := benchTime(gen, xs, ys, *minTime)
+		times[gj.Name] = t
+		self := t
+		for _, input := range gj.Generators {
+			self -= times[input]
+		}
+		if self < 0.0 {
+			self = 0.0
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.1f\t%s\t%.1f\t%s\t\n", gj.Name, gj.GeneratorType, t, perSecond(t), self, perSecond(self))
+	}
+	tw.Flush()
+
+	if *regions == 0 {
+		return nil
+	}
+	builder := noisey.NewBuilder2D(total, w, h)
+	extent := b.MaxX - b.MinX
+	start := time.Now()
+	for i := 0; i < *regions; i++ {
+		builder.Bounds = b
+		builder.Bounds.MinX += float64(i) * extent
+		builder.Bounds.MaxX += float64(i) * extent
+		if err := builder.Build(); err != nil {
+			return err
+		}
+	}
+	elapsed := time.Since(start)
+	perRegion := float64(elapsed.Nanoseconds()) / float64(*regions)
+	perSample := perRegion / float64(w*h)
+	fmt.Printf("\ntotal: %d regions of %dx%d in %v: %v a region (%s regions/s), %.1f ns a sample (%s samples/s)\n",
+		*regions, w, h, elapsed, time.Duration(perRegion), perSecond(perRegion), perSample, perSecond(perSample))
+	return nil
+}
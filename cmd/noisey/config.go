@@ -0,0 +1,286 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file loads configuration files. Every format is parsed into a tree of
+nodes that keeps the order of the keys of maps and the comments, which is
+turned into JSON for noisey.LoadNoiseJSON() or written in another format.
+
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// nodeKind is the kind of value a node holds.
+type nodeKind int
+
+const (
+	nodeNull nodeKind = iota
+	nodeBool
+	nodeNumber
+	nodeString
+	nodeList
+	nodeMap
+)
+
+// node is a value of a configuration file.
+type node struct {
+	kind     nodeKind
+	scalar   string   // the text of a bool, number or string
+	keys     []string // the keys of a map in order
+	values   []*node  // the values of a map or the items of a list
+	comments []string // the comments above the key or item of the value
+}
+
+// get returns the value of the key of a map or nil.
+func (n *node) get(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// set adds the key to a map or replaces its value.
+func (n *node) set(key string, v *node) {
+	for i, k := range n.keys {
+		if k == key {
+			n.values[i] = v
+			return
+		}
+	}
+	n.keys = append(n.keys, key)
+	n.values = append(n.values, v)
+}
+
+// parseJSON parses JSON into a node tree.
+func parseJSON(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the JSON: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unable to parse the JSON: unexpected data after the top level value")
+	}
+	return n, nil
+}
+
+func parseJSONValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			n := &node{kind: nodeList}
+			for dec.More() {
+				item, err := parseJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.values = append(n.values, item)
+			}
+			_, err = dec.Token()
+			return n, err
+		}
+		n := &node{kind: nodeMap}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.set(key.(string), v)
+		}
+		_, err = dec.Token()
+		return n, err
+	case bool:
+		return &node{kind: nodeBool, scalar: strconv.FormatBool(t)}, nil
+	case json.Number:
+		return &node{kind: nodeNumber, scalar: t.String()}, nil
+	case string:
+		return &node{kind: nodeString, scalar: t}, nil
+	}
+	return &node{kind: nodeNull}, nil
+}
+
+// jsonNumber returns the number as JSON, rewriting the forms JSON doesn't
+// allow like "+1" and ".5".
+func jsonNumber(s string) string {
+	var v json.Number
+	if json.Unmarshal([]byte(s), &v) == nil {
+		return s
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// writeJSON writes the tree as indented JSON.
+func (n *node) writeJSON(b *bytes.Buffer, indent string) {
+	switch n.kind {
+	case nodeBool:
+		b.WriteString(n.scalar)
+	case nodeNumber:
+		b.WriteString(jsonNumber(n.scalar))
+	case nodeString:
+		s, _ := json.Marshal(n.scalar)
+		b.Write(s)
+	case nodeList, nodeMap:
+		open, end := "[", "]"
+		if n.kind == nodeMap {
+			open, end = "{", "}"
+		}
+		b.WriteString(open)
+		if len(n.values) == 0 {
+			b.WriteString(end)
+			return
+		}
+		for i, v := range n.values {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n" + indent + "\t")
+			if n.kind == nodeMap {
+				k, _ := json.Marshal(n.keys[i])
+				b.Write(k)
+				b.WriteString(": ")
+			}
+			v.writeJSON(b, indent+"\t")
+		}
+		b.WriteString("\n" + indent + end)
+	default:
+		b.WriteString("null")
+	}
+}
+
+// writeComments writes the comments on lines of their own at the indent,
+// with # in front like both YAML and TOML have them.
+func writeComments(b *bytes.Buffer, comments []string, indent string) {
+	for _, c := range comments {
+		if c == "" {
+			b.WriteString(indent + "#\n")
+		} else {
+			b.WriteString(indent + "# " + c + "\n")
+		}
+	}
+}
+
+// JSON returns the tree as indented JSON, which has no comments.
+func (n *node) JSON() []byte {
+	var b bytes.Buffer
+	n.writeJSON(&b, "")
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// configFormat returns the format of a configuration file by its extension.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return "json"
+}
+
+// parseConfig parses the data of a configuration in the format.
+func parseConfig(data []byte, format string) (*node, error) {
+	switch format {
+	case "json":
+		return parseJSON(data)
+	case "yaml":
+		return parseYAML(data)
+	case "toml":
+		return parseTOML(data)
+	}
+	return nil, fmt.Errorf("unknown configuration format %q", format)
+}
+
+// formatConfig writes the tree of a configuration in the format.
+func formatConfig(tree *node, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return tree.JSON(), nil
+	case "yaml":
+		return tree.YAML(), nil
+	case "toml":
+		return tree.TOML()
+	}
+	return nil, fmt.Errorf("unknown configuration format %q", format)
+}
+
+// loadConfig reads a configuration file and builds its sources and generators.
+func loadConfig(path string) (*noisey.NoiseJSON, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := parseConfig(data, configFormat(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	cfg, err := buildConfig(tree)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// buildConfig builds the sources and generators of a configuration tree.
+func buildConfig(tree *node) (*noisey.NoiseJSON, error) {
+	cfg, err := noisey.LoadNoiseJSON(tree.JSON())
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.BuildSources(func(s int64) noisey.RandomSource {
+		return rand.New(rand.NewSource(s))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.BuildGenerators(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// generator returns the named generator of the configuration, or the last
+// one if the name is empty.
+func generator(cfg *noisey.NoiseJSON, name string) (noisey.NoiseyGet2D, error) {
+	if name == "" {
+		if len(cfg.Generators) == 0 {
+			return nil, fmt.Errorf("the configuration has no generators")
+		}
+		name = cfg.Generators[len(cfg.Generators)-1].Name
+	}
+	g := cfg.GetGenerator(name)
+	if g == nil {
+		return nil, fmt.Errorf("the configuration has no generator named %q", name)
+	}
+	return g, nil
+}
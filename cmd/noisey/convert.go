@@ -0,0 +1,113 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the convert command, which writes a configuration in
+another format:
+
+	noisey convert -config terrain.json -o terrain.yaml
+	noisey convert -config terrain.yaml -to toml > terrain.toml
+
+The formats are picked by the extensions of the files unless -from or -to
+are given. Every key is kept in its order, and so are the comments of YAML
+and TOML when writing either of them. The converted text is read back and
+compared with the configuration before it's written, so that a conversion
+never loses a value without saying so.
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// sameValues returns true if the trees hold the same values, where a key
+// of a map that is missing is the same as a null.
+func sameValues(a *node, b *node) bool {
+	if a.kind != b.kind {
+		return false
+	}
+	switch a.kind {
+	case nodeBool, nodeString:
+		return a.scalar == b.scalar
+	case nodeNumber:
+		return jsonNumber(a.scalar) == jsonNumber(b.scalar)
+	case nodeList:
+		if len(a.values) != len(b.values) {
+			return false
+		}
+		for i := range a.values {
+			if !sameValues(a.values[i], b.values[i]) {
+				return false
+			}
+		}
+	case nodeMap:
+		null := &node{kind: nodeNull}
+		for _, pair := range [][2]*node{{a, b}, {b, a}} {
+			for i, k := range pair[0].keys {
+				other := pair[1].get(k)
+				if other == nil {
+					other = null
+				}
+				if !sameValues(pair[0].values[i], other) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func runConvert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	config := flags.String("config", "", "the configuration file to convert")
+	output := flags.String("o", "", "the file to write; the standard output if empty")
+	from := flags.String("from", "", "the format of the configuration: json, yaml or toml; picked by its extension if empty")
+	to := flags.String("to", "", "the format to write: json, yaml or toml; picked by the extension of -o if empty")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	if *from == "" {
+		*from = configFormat(*config)
+	}
+	if *to == "" {
+		if *output == "" {
+			return fmt.Errorf("-to is required when writing to the standard output")
+		}
+		*to = configFormat(*output)
+	}
+
+	data, err := ioutil.ReadFile(*config)
+	if err != nil {
+		return err
+	}
+	tree, err := parseConfig(data, *from)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *config, err)
+	}
+	converted, err := formatConfig(tree, *to)
+	if err != nil {
+		return fmt.Errorf("unable to write %s as %s: %v", *config, *to, err)
+	}
+	back, err := parseConfig(converted, *to)
+	if err != nil {
+		return fmt.Errorf("the %s of %s doesn't read back: %v", *to, *config, err)
+	}
+	if !sameValues(tree, back) {
+		return fmt.Errorf("the %s of %s doesn't read back with the same values", *to, *config)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(converted)
+		return err
+	}
+	return ioutil.WriteFile(*output, converted, 0644)
+}
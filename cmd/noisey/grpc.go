@@ -0,0 +1,99 @@
+//go:build grpc
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the grpc command, which runs the Noisey gRPC service
+of the server package so that clients in other languages can load
+pipelines and sample them:
+
+	go install -tags grpc github.com/tbogdala/noisey/cmd/noisey
+	noisey grpc -addr :50051 -config terrain.yaml
+
+Configurations given with -config are loaded before serving and their ids
+are printed, so clients can sample them without loading them first. With
+-metrics the regions and the evaluation times of the pipelines are served
+in the Prometheus text format at /metrics and as expvar variables at
+/debug/vars of another HTTP address.
+
+*/
+
+import (
+	"expvar"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+
+	"github.com/tbogdala/noisey/server"
+)
+
+func init() {
+	commands["grpc"] = command{"serve the pipelines of configurations over gRPC", runGRPC}
+}
+
+// configList is a flag that can be given more than once.
+type configList []string
+
+func (c *configList) String() string {
+	return fmt.Sprint(*c)
+}
+
+func (c *configList) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+func runGRPC(args []string) error {
+	flags := flag.NewFlagSet("grpc", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:50051", "the address to listen on")
+	var configs configList
+	flags.Var(&configs, "config", "a JSON, YAML or TOML configuration to load first; may be given more than once")
+	metrics := flags.String("metrics", "", "the HTTP address to serve Prometheus metrics at /metrics and expvar at /debug/vars; none if empty")
+	flags.Parse(args)
+
+	svc := server.NewService()
+	if *metrics != "" {
+		svc.Metrics = server.NewMetrics()
+		svc.Metrics.Publish("noisey")
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", svc.Metrics)
+		mux.Handle("/debug/vars", expvar.Handler())
+		go func() {
+			log.Fatal(http.ListenAndServe(*metrics, mux))
+		}()
+		log.Printf("serving metrics at http://%s/metrics", *metrics)
+	}
+	for _, path := range configs {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tree, err := parseConfig(data, configFormat(path))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		id, generators, err := svc.Load(tree.JSON())
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		log.Printf("loaded %s as %s with the generators %v", path, id, generators)
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	server.Register(s, svc)
+	log.Printf("serving gRPC at %s", lis.Addr())
+	return s.Serve(lis)
+}
@@ -0,0 +1,14 @@
+//go:build !grpc
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+import "fmt"
+
+func init() {
+	commands["grpc"] = command{"serve the pipelines of configurations over gRPC", func(args []string) error {
+		return fmt.Errorf("this build has no gRPC server; build with -tags grpc")
+	}}
+}
@@ -0,0 +1,83 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+The noisey command builds and renders the noise pipelines described by
+NoiseJSON configuration files, so that they can be designed without
+writing Go:
+
+	noisey render -config terrain.json -size 1024x1024 -bounds 0,0,4,4 -o terrain.png
+
+Configurations can be written in JSON, YAML or TOML, which is picked by the
+extension of the file. The commands are:
+
+	bench    measure the throughput of every generator and of building regions
+	convert  write a configuration in another format, keeping its comments
+	grpc     serve the pipelines of configurations to other languages over gRPC
+	regress  render the scenes of a configuration and compare them to reference images
+	render   build a generator of a configuration and write it as PNG, RAW, TER or Parquet
+	preview  show a generator in a window that updates when the file changes
+	serve    serve a generator as slippy map tiles for a Leaflet page over HTTP
+	show     build a generator and print it to the terminal in color
+	tweak    change the parameters of a configuration with sliders and save them
+
+The preview needs GLFW3 and is only included when built with the preview tag,
+the tweak window needs Fyne and is only included with the tweak tag, and the
+gRPC server needs google.golang.org/grpc and the generated server/noiseypb
+package and is only included with the grpc tag.
+
+Run `noisey <command> -h` for the flags of a command.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is a subcommand of the tool that is run with its arguments.
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+// commands are the subcommands of the tool keyed by name.
+var commands = map[string]command{
+	"bench":   {"measure how many samples a second the generators of a configuration take", runBench},
+	"convert": {"write a configuration as JSON, YAML or TOML", runConvert},
+	"regress": {"render the scenes of a configuration and compare them to reference images", runRegress},
+	"render":  {"build a generator of a configuration and write it as PNG, RAW, TER or Parquet", runRender},
+	"serve":   {"serve a generator of a configuration as slippy map tiles over HTTP", runServe},
+	"show":    {"build a generator of a configuration and print it to the terminal in color", runShow},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: noisey <command> [flags]\n\ncommands:\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "noisey: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "noisey %s: %s\n", os.Args[1], strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,262 @@
+//go:build preview
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the preview command, which shows the rendered map of a
+generator in a window and renders it again whenever the configuration file
+is saved, for quick iteration on a pipeline.
+
+It needs the GLFW3 library and the Go wrappers used by the examples, so it's
+only built with the preview tag:
+
+	go get github.com/go-gl/gl/v3.3-core/gl
+	go get github.com/go-gl/glfw/v3.1/glfw
+	go install -tags preview github.com/tbogdala/noisey/cmd/noisey
+
+Hit `esc` to quit and `r` to render again without saving.
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	glfw "github.com/go-gl/glfw/v3.1/glfw"
+
+	"github.com/tbogdala/noisey/interop/gltex"
+)
+
+func init() {
+	// GLFW has to be called from the main thread
+	runtime.LockOSThread()
+	commands["preview"] = command{"show a generator of a configuration in a window that updates when the file changes", runPreview}
+}
+
+// previewVertexShader draws a triangle covering the window without any
+// vertex buffers, with row 0 of the map at the top.
+const previewVertexShader = `#version 330
+out vec2 uv;
+void main() {
+	vec2 p = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+	uv = vec2(p.x * 0.5, 1.0 - p.y * 0.5);
+	gl_Position = vec4(p * 2.0 - 1.0, 0.0, 1.0);
+}
+`
+
+// previewFragmentShader shows the map texture.
+const previewFragmentShader = `#version 330
+uniform sampler2D tex;
+in vec2 uv;
+out vec4 color;
+void main() {
+	color = texture(tex, uv);
+}
+`
+
+// compileShader compiles the GLSL source as a shader of the kind.
+func compileShader(kind uint32, src string) (uint32, error) {
+	shader := gl.CreateShader(kind)
+	csrc, free := gl.Strs(src + "\x00")
+	gl.ShaderSource(shader, 1, csrc, nil)
+	gl.CompileShader(shader)
+	free()
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("failed to compile a shader: %s", log)
+	}
+	return shader, nil
+}
+
+// previewProgram links the shaders of the preview.
+func previewProgram() (uint32, error) {
+	vs, err := compileShader(gl.VERTEX_SHADER, previewVertexShader)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(vs)
+	fs, err := compileShader(gl.FRAGMENT_SHADER, previewFragmentShader)
+	if err != nil {
+		return 0, err
+	}
+	defer gl.DeleteShader(fs)
+
+	prog := gl.CreateProgram()
+	gl.AttachShader(prog, vs)
+	gl.AttachShader(prog, fs)
+	gl.LinkProgram(prog)
+	var status int32
+	gl.GetProgramiv(prog, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(prog, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(prog, logLength, nil, gl.Str(log))
+		return 0, fmt.Errorf("failed to link the shaders: %s", log)
+	}
+	return prog, nil
+}
+
+// previewImage renders the generator and colors it by the gradient, or in
+// grayscale if the gradient is empty.
+func previewImage(config string, name string, size string, bounds string, gradient string, normalize bool) (*image.NRGBA, error) {
+	cfg, nm, err := renderMap(config, name, size, bounds)
+	if err != nil {
+		return nil, err
+	}
+	if normalize {
+		nm.Normalize(-1.0, 1.0)
+	}
+	cg, err := colorGradient(cfg, gradient)
+	if err != nil {
+		return nil, err
+	}
+	return cg.Image(&nm), nil
+}
+
+// modTime returns the modification time of the file, or the zero time if
+// it can't be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func runPreview(args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to show; the last one of the configuration if empty")
+	size := flags.String("size", "512x512", "the size of the window and the map as WIDTHxHEIGHT")
+	bounds := flags.String("bounds", "0,0,1,1", "the rectangle to render as MINX,MINY,MAXX,MAXY")
+	gradient := flags.String("gradient", "", "the gradient of the configuration to color the map with")
+	normalize := flags.Bool("normalize", false, "stretch the values to fill [-1, 1]")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	w, h, err := parseSize(*size)
+	if err != nil {
+		return err
+	}
+
+	if err := glfw.Init(); err != nil {
+		return fmt.Errorf("unable to initialize GLFW: %v", err)
+	}
+	defer glfw.Terminate()
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	window, err := glfw.CreateWindow(w, h, "noisey preview: "+*config, nil, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create the window: %v", err)
+	}
+	window.MakeContextCurrent()
+	glfw.SwapInterval(1)
+	if err := gl.Init(); err != nil {
+		return fmt.Errorf("unable to initialize OpenGL: %v", err)
+	}
+
+	prog, err := previewProgram()
+	if err != nil {
+		return err
+	}
+	var vao, tex uint32
+	gl.GenVertexArrays(1, &vao)
+	texParams := gltex.Params{MinFilter: gl.LINEAR, MagFilter: gl.NEAREST, WrapS: gl.CLAMP_TO_EDGE, WrapT: gl.CLAMP_TO_EDGE}
+
+	// the maps are rendered off the main thread so the window stays
+	// responsive, and uploaded to the texture when they are done
+	type result struct {
+		img *image.NRGBA
+		err error
+	}
+	results := make(chan result, 1)
+	rendering := false
+	reload := true
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeyR:
+			reload = true
+		}
+	})
+
+	var lastMod time.Time
+	lastCheck := time.Time{}
+	for !window.ShouldClose() {
+		if now := time.Now(); now.Sub(lastCheck) > 250*time.Millisecond {
+			lastCheck = now
+			if mod := modTime(*config); !mod.Equal(lastMod) {
+				lastMod = mod
+				reload = true
+			}
+		}
+		if reload && !rendering {
+			reload, rendering = false, true
+			fmt.Printf("rendering %s ...\n", *config)
+			go func() {
+				img, err := previewImage(*config, *name, *size, *bounds, *gradient, *normalize)
+				results <- result{img, err}
+			}()
+		}
+		select {
+		case r := <-results:
+			rendering = false
+			if r.err != nil {
+				fmt.Fprintf(os.Stderr, "noisey preview: %s\n", strings.TrimSpace(r.err.Error()))
+				break
+			}
+			// the texture is made with the first map and reused after
+			if tex == 0 {
+				tex, err = gltex.NewRGBA(r.img, texParams)
+			} else {
+				err = gltex.UpdateRGBA(tex, r.img)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "noisey preview: %s\n", strings.TrimSpace(err.Error()))
+			}
+		default:
+		}
+
+		fw, fh := window.GetFramebufferSize()
+		gl.Viewport(0, 0, int32(fw), int32(fh))
+		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		gl.UseProgram(prog)
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, tex)
+		gl.Uniform1i(gl.GetUniformLocation(prog, gl.Str("tex\x00")), 0)
+		gl.BindVertexArray(vao)
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !preview
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+import "fmt"
+
+func init() {
+	commands["preview"] = command{"show a generator of a configuration in a window that updates when the file changes", func(args []string) error {
+		return fmt.Errorf("this build has no preview window; install GLFW3, go-gl/gl and go-gl/glfw and build with -tags preview")
+	}}
+}
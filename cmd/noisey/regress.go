@@ -0,0 +1,127 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the regress command, which renders the Scenes of a
+configuration and compares them to the PNGs of the same names in -refs, for
+catching visual regressions of pipelines in CI:
+
+	noisey regress -config terrain.json -refs testdata/scenes -diffs out
+
+The diff image of every scene that differs is written to -diffs as
+NAME.diff.png and the command fails if any scene differs. -update writes the
+renders to -refs instead, after a deliberate change to how they look.
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tbogdala/noisey"
+)
+
+// savePNG writes an image to a PNG file, making its directory if needed.
+func savePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// compareReference compares an image to the reference PNG at path.
+func compareReference(path string, img image.Image, tol noisey.VisualTolerance) (*noisey.ImageDiff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no reference image; run with -update to make one")
+	}
+	defer f.Close()
+	ref, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return noisey.CompareImages(ref, img, tol)
+}
+
+func runRegress(args []string) error {
+	flags := flag.NewFlagSet("regress", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file with the Scenes")
+	refs := flags.String("refs", "", "the directory of the reference PNGs")
+	diffs := flags.String("diffs", "", "the directory to write the diff images to; none are written if empty")
+	update := flags.Bool("update", false, "write the renders as the new references")
+	delta := flags.Float64("delta", noisey.DefaultVisualTolerance.PixelDelta, "the largest color difference of a pixel that counts as the same")
+	fraction := flags.Float64("fraction", noisey.DefaultVisualTolerance.MaxFraction, "the largest share of the pixels of a scene that may differ")
+	flags.Parse(args)
+
+	if *config == "" || *refs == "" {
+		flags.Usage()
+		return fmt.Errorf("both -config and -refs are required")
+	}
+	cfg, err := loadConfig(*config)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Scenes) == 0 {
+		return fmt.Errorf("%s has no scenes", *config)
+	}
+	names := make([]string, 0, len(cfg.Scenes))
+	for name := range cfg.Scenes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tol := noisey.VisualTolerance{PixelDelta: *delta, MaxFraction: *fraction}
+	failed := 0
+	for _, name := range names {
+		img, err := cfg.RenderScene(name)
+		if err != nil {
+			return err
+		}
+		ref := filepath.Join(*refs, name+".png")
+		if *update {
+			if err := savePNG(ref, img); err != nil {
+				return err
+			}
+			fmt.Printf("%-24s updated\n", name)
+			continue
+		}
+
+		diff, err := compareReference(ref, img, tol)
+		if err != nil {
+			fmt.Printf("%-24s FAIL %v\n", name, err)
+			failed++
+			continue
+		}
+		if !diff.Failed {
+			fmt.Printf("%-24s ok   %.4f%% of the pixels differ, by up to %.2f\n", name, diff.Fraction*100.0, diff.MaxDelta)
+			continue
+		}
+		failed++
+		fmt.Printf("%-24s FAIL %.4f%% of the pixels differ, by up to %.2f\n", name, diff.Fraction*100.0, diff.MaxDelta)
+		if *diffs != "" {
+			if err := savePNG(filepath.Join(*diffs, name+".diff.png"), diff.Image); err != nil {
+				return err
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenes differ from their references", failed, len(names))
+	}
+	return nil
+}
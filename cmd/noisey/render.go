@@ -0,0 +1,175 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the render command, which builds a generator of a
+configuration over a rectangle and writes the map as:
+
+* png: a 16 bit grayscale PNG, or a color PNG with -gradient
+* raw: unsigned 16 bit little endian heights as used by Unity's R16
+* ter: a Terragen terrain of -height meters high with -spacing meters
+  between the grid points
+* parquet: a gzip compressed Parquet file with the x, y and value of every
+  grid point, for analyzing the values in Spark or pandas
+
+The format is picked by -format or else by the extension of -o. Values are
+expected in [-1, 1]; -normalize stretches the map to fill that range first.
+
+*/
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// parseSize parses a size like "512x256", or "512" for a square.
+func parseSize(s string) (int, int, error) {
+	parts := strings.Split(strings.ToLower(s), "x")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("bad size %q; expected WIDTHxHEIGHT", s)
+	}
+	w, errW := strconv.Atoi(parts[0])
+	h, errH := strconv.Atoi(parts[1])
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("bad size %q; expected WIDTHxHEIGHT", s)
+	}
+	return w, h, nil
+}
+
+// parseBounds parses bounds like "0,0,1,1" in the order MinX, MinY, MaxX, MaxY.
+func parseBounds(s string) (noisey.Builder2DBounds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return noisey.Builder2DBounds{}, fmt.Errorf("bad bounds %q; expected MINX,MINY,MAXX,MAXY", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return noisey.Builder2DBounds{}, fmt.Errorf("bad bounds %q; expected MINX,MINY,MAXX,MAXY", s)
+		}
+		v[i] = f
+	}
+	return noisey.Builder2DBounds{MinX: v[0], MinY: v[1], MaxX: v[2], MaxY: v[3]}, nil
+}
+
+// outputFormat returns the format named by format or else by the extension of path.
+func outputFormat(format string, path string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "png", "ter", "parquet":
+		return format, nil
+	case "raw", "r16":
+		return "raw", nil
+	}
+	return "", fmt.Errorf("unknown output format %q; expected png, raw, ter or parquet", format)
+}
+
+// renderMap builds the generator of the configuration file into a map.
+func renderMap(config string, name string, size string, bounds string) (*noisey.NoiseJSON, noisey.NoiseMap, error) {
+	w, h, err := parseSize(size)
+	if err != nil {
+		return nil, noisey.NoiseMap{}, err
+	}
+	b, err := parseBounds(bounds)
+	if err != nil {
+		return nil, noisey.NoiseMap{}, err
+	}
+	cfg, err := loadConfig(config)
+	if err != nil {
+		return nil, noisey.NoiseMap{}, err
+	}
+	gen, err := generator(cfg, name)
+	if err != nil {
+		return nil, noisey.NoiseMap{}, err
+	}
+	builder := noisey.NewBuilder2D(gen, w, h)
+	builder.Bounds = b
+	if err := builder.Build(); err != nil {
+		return nil, noisey.NoiseMap{}, err
+	}
+	return cfg, builder.GetNoiseMap(), nil
+}
+
+// colorGradient returns the named gradient of the configuration, or a
+// gradient from black at -1.0 to white at 1.0 if the name is empty.
+func colorGradient(cfg *noisey.NoiseJSON, name string) (noisey.ColorGradient, error) {
+	if name != "" {
+		return cfg.GetGradient(name)
+	}
+	cg := noisey.NewColorGradient()
+	cg.AddKey(-1.0, color.NRGBA{0, 0, 0, 255})
+	cg.AddKey(1.0, color.NRGBA{255, 255, 255, 255})
+	return cg, nil
+}
+
+func runRender(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to render; the last one of the configuration if empty")
+	size := flags.String("size", "512x512", "the size of the output in grid points as WIDTHxHEIGHT")
+	bounds := flags.String("bounds", "0,0,1,1", "the rectangle to render as MINX,MINY,MAXX,MAXY")
+	output := flags.String("o", "", "the file to write")
+	format := flags.String("format", "", "the output format: png, raw, ter or parquet; picked by the extension of -o if empty")
+	gradient := flags.String("gradient", "", "the gradient of the configuration to color a png with")
+	normalize := flags.Bool("normalize", false, "stretch the values to fill [-1, 1] before writing")
+	height := flags.Float64("height", 1000.0, "the meters a value of 1.0 is high in a ter")
+	spacing := flags.Float64("spacing", 30.0, "the meters between grid points in a ter")
+	flags.Parse(args)
+
+	if *config == "" || *output == "" {
+		flags.Usage()
+		return fmt.Errorf("both -config and -o are required")
+	}
+	out, err := outputFormat(*format, *output)
+	if err != nil {
+		return err
+	}
+	cfg, nm, err := renderMap(*config, *name, *size, *bounds)
+	if err != nil {
+		return err
+	}
+	if *normalize {
+		nm.Normalize(-1.0, 1.0)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	switch {
+	case out == "png" && *gradient != "":
+		var cg noisey.ColorGradient
+		if cg, err = cfg.GetGradient(*gradient); err == nil {
+			err = cg.WritePNG(f, &nm)
+		}
+	case out == "png":
+		err = noisey.WritePNG16(f, &nm)
+	case out == "raw":
+		err = noisey.WriteRAW16(f, &nm, binary.LittleEndian)
+	case out == "ter":
+		err = noisey.WriteTerragen(f, &nm, *height, *spacing)
+	case out == "parquet":
+		err = noisey.WriteParquet(f, &nm, nil, noisey.ParquetOptions{Gzip: true})
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
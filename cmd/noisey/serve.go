@@ -0,0 +1,429 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the serve command, an HTTP server of slippy map tiles
+rendered on demand from a generator, for panning around an infinite world
+in a browser:
+
+	noisey serve -config terrain.yaml -gradient terrain -world 8 -addr :8080
+
+The page at / shows the tiles with Leaflet and the tiles are at
+/{z}/{x}/{y}.png. The tile (0, 0) of zoom 0 covers the world rectangle from
+(0, 0) to (world, world) and every zoom level halves the world size of a
+tile, so zooming in samples the generator over smaller areas and shows its
+higher frequencies. Tile coordinates may be negative, so the world goes on
+in every direction.
+
+Rendered tiles are kept in a least recently used cache of -cache tiles. The
+configuration file is checked for changes at most once a second, and when
+it has been saved it is loaded again and the cache is cleared, so reloading
+the page shows the new parameters.
+
+Map clients that would rather not poll for tiles can connect a WebSocket to
+/chunks, subscribe to regions of chunks of -chunk by -chunk grid points
+-cell apart and have the raw values pushed to them as described by
+server.ChunkStream. A stream keeps the pipeline it connected with until the
+client connects again.
+
+With -store the tiles are also kept in a shared TileCache, so they are
+rendered once for every server of a deployment and survive restarts:
+
+	noisey serve -config terrain.yaml -store s3://tiles-bucket/terrain
+	noisey serve -config terrain.yaml -store gs://tiles-bucket/terrain
+	noisey serve -config terrain.yaml -store /var/cache/noisey
+
+S3 is signed with AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and, if it is
+set, AWS_SESSION_TOKEN for the region in AWS_REGION, and Google Cloud
+Storage with the HMAC keys in GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY.
+-store-endpoint points s3:// at another service with the S3 API, like
+MinIO. The keys start with a hash of the configuration file and of the
+settings that change the tiles, so a changed configuration gets new tiles;
+the old ones are left for the bucket's lifecycle rules to expire.
+
+With -metrics the server counts the rendered tiles and the hits and misses
+of the cache and times the rendering, in the Prometheus text format at
+/metrics and as expvar variables at /debug/vars.
+
+*/
+
+import (
+	"bytes"
+	"container/list"
+	"expvar"
+	"flag"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tbogdala/noisey"
+	"github.com/tbogdala/noisey/server"
+)
+
+// tileKey is the zoom level and coordinate of a tile.
+type tileKey struct {
+	z, x, y int
+}
+
+// tileEntry is a cached tile in the LRU list.
+type tileEntry struct {
+	key tileKey
+	png []byte
+}
+
+// tileServer renders and caches the tiles of a configuration.
+type tileServer struct {
+	config   string
+	name     string
+	gradient string
+	world    float64
+	tileSize int
+	maxZoom  int
+	capacity int
+	chunk    int
+	cell     float64
+	metrics  *server.Metrics  // nil without -metrics
+	store    server.TileCache // nil without -store
+
+	lock      sync.Mutex
+	gen       noisey.NoiseyGet2D
+	genName   string // the name of gen for the metrics
+	version   string // the version of the keys of the store
+	cg        noisey.ColorGradient
+	modTime   time.Time
+	lastCheck time.Time
+	cache     map[tileKey]*list.Element
+	lru       *list.List // the cached tiles with the most recently used at the front
+	stream    *server.ChunkStream
+}
+
+// reload loads the configuration again if the file changed since it was
+// last loaded and clears the cache. It's called with the lock held.
+func (ts *tileServer) reload() error {
+	now := time.Now()
+	if ts.gen != nil && now.Sub(ts.lastCheck) < time.Second {
+		return nil
+	}
+	ts.lastCheck = now
+	info, err := os.Stat(ts.config)
+	if err != nil {
+		return err
+	}
+	if ts.gen != nil && info.ModTime().Equal(ts.modTime) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(ts.config)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(ts.config)
+	if err != nil {
+		return err
+	}
+	gen, err := generator(cfg, ts.name)
+	if err != nil {
+		return err
+	}
+	cg, err := colorGradient(cfg, ts.gradient)
+	if err != nil {
+		return err
+	}
+	if ts.gen != nil {
+		log.Printf("%s changed; clearing %d cached tiles", ts.config, ts.lru.Len())
+	}
+	ts.gen, ts.cg, ts.modTime = gen, cg, info.ModTime()
+	ts.genName = ts.name
+	if ts.genName == "" {
+		ts.genName = cfg.Generators[len(cfg.Generators)-1].Name
+	}
+	ts.version = server.TileCacheVersion(data, ts.genName, ts.gradient,
+		strconv.FormatFloat(ts.world, 'g', -1, 64), strconv.Itoa(ts.tileSize))
+	ts.cache = make(map[tileKey]*list.Element)
+	ts.lru = list.New()
+	cm := noisey.NewChunkManager(gen, ts.chunk, ts.cell, ts.capacity)
+	ts.stream = server.NewChunkStream(&cm)
+	return nil
+}
+
+// remember adds a tile to the cache if the configuration didn't change
+// since modTime.
+func (ts *tileServer) remember(key tileKey, data []byte, modTime time.Time) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	if ts.capacity > 0 && ts.modTime.Equal(modTime) {
+		if _, ok := ts.cache[key]; !ok {
+			ts.cache[key] = ts.lru.PushFront(&tileEntry{key, data})
+			for ts.lru.Len() > ts.capacity {
+				oldest := ts.lru.Back()
+				ts.lru.Remove(oldest)
+				delete(ts.cache, oldest.Value.(*tileEntry).key)
+			}
+		}
+	}
+}
+
+// tile returns the PNG of the tile from the caches or renders it.
+func (ts *tileServer) tile(key tileKey) ([]byte, error) {
+	ts.lock.Lock()
+	if err := ts.reload(); err != nil {
+		ts.lock.Unlock()
+		return nil, err
+	}
+	if e, ok := ts.cache[key]; ok {
+		ts.lru.MoveToFront(e)
+		data := e.Value.(*tileEntry).png
+		ts.lock.Unlock()
+		if ts.metrics != nil {
+			ts.metrics.CacheHit()
+		}
+		return data, nil
+	}
+	gen, genName, cg, modTime, version := ts.gen, ts.genName, ts.cg, ts.modTime, ts.version
+	ts.lock.Unlock()
+
+	// a store that fails only costs rendering the tile
+	storeKey := server.TileCacheKey(version, key.z, key.x, key.y, ".png")
+	if ts.store != nil {
+		data, ok, err := ts.store.Get(storeKey)
+		if err != nil {
+			log.Printf("tile %d/%d/%d: %s", key.z, key.x, key.y, strings.TrimSpace(err.Error()))
+		} else if ok {
+			if ts.metrics != nil {
+				ts.metrics.CacheHit()
+			}
+			ts.remember(key, data, modTime)
+			return data, nil
+		}
+	}
+	if ts.metrics != nil && (ts.capacity > 0 || ts.store != nil) {
+		ts.metrics.CacheMiss()
+	}
+
+	// the generators are safe to sample from several requests at once
+	start := time.Now()
+	size := ts.world / math.Pow(2.0, float64(key.z))
+	builder := noisey.NewBuilder2D(gen, ts.tileSize, ts.tileSize)
+	builder.Bounds = noisey.Builder2DBounds{
+		MinX: float64(key.x) * size,
+		MinY: float64(key.y) * size,
+		MaxX: float64(key.x+1) * size,
+		MaxY: float64(key.y+1) * size,
+	}
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	if ts.metrics != nil {
+		ts.metrics.Observe(ts.config, genName, time.Since(start))
+		ts.metrics.TileRendered()
+	}
+	nm := builder.GetNoiseMap()
+	var b bytes.Buffer
+	if err := png.Encode(&b, cg.Image(&nm)); err != nil {
+		return nil, err
+	}
+
+	if ts.store != nil {
+		if err := ts.store.Put(storeKey, b.Bytes()); err != nil {
+			log.Printf("tile %d/%d/%d: %s", key.z, key.x, key.y, strings.TrimSpace(err.Error()))
+		}
+	}
+	ts.remember(key, b.Bytes(), modTime)
+	return b.Bytes(), nil
+}
+
+// newTileStore returns the TileCache of a -store: s3://bucket/prefix,
+// gs://bucket/prefix or a directory.
+func newTileStore(spec string, endpoint string) (server.TileCache, error) {
+	bucketPrefix := func(rest string) (string, string) {
+		parts := strings.SplitN(rest, "/", 2)
+		prefix := ""
+		if len(parts) == 2 && strings.Trim(parts[1], "/") != "" {
+			prefix = strings.Trim(parts[1], "/") + "/"
+		}
+		return parts[0], prefix
+	}
+	switch {
+	case strings.HasPrefix(spec, "s3://"):
+		bucket, prefix := bucketPrefix(strings.TrimPrefix(spec, "s3://"))
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		sc := server.NewS3TileCache(region, bucket, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+		sc.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		sc.Prefix = prefix
+		if endpoint != "" {
+			sc.Endpoint = endpoint
+		}
+		if bucket == "" || sc.AccessKey == "" || sc.SecretKey == "" {
+			return nil, fmt.Errorf("-store %s needs a bucket, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", spec)
+		}
+		return sc, nil
+	case strings.HasPrefix(spec, "gs://"):
+		bucket, prefix := bucketPrefix(strings.TrimPrefix(spec, "gs://"))
+		sc := server.NewGCSTileCache(bucket, os.Getenv("GCS_ACCESS_KEY_ID"), os.Getenv("GCS_SECRET_ACCESS_KEY"))
+		sc.Prefix = prefix
+		if endpoint != "" {
+			sc.Endpoint = endpoint
+		}
+		if bucket == "" || sc.AccessKey == "" || sc.SecretKey == "" {
+			return nil, fmt.Errorf("-store %s needs a bucket, GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY", spec)
+		}
+		return sc, nil
+	case strings.Contains(spec, "://"):
+		return nil, fmt.Errorf("unknown -store %q; expected s3://, gs:// or a directory", spec)
+	}
+	return server.NewDirTileCache(spec), nil
+}
+
+// parseTile parses a path like /3/-1/2.png into a tile.
+func parseTile(path string) (tileKey, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ".png") {
+		return tileKey{}, false
+	}
+	var v [3]int
+	for i, part := range []string{parts[0], parts[1], strings.TrimSuffix(parts[2], ".png")} {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return tileKey{}, false
+		}
+		v[i] = n
+	}
+	return tileKey{v[0], v[1], v[2]}, true
+}
+
+// tilePage is the Leaflet page that shows the tiles.
+const tilePage = `<!DOCTYPE html>
+<html>
+<head>
+<title>noisey: %s</title>
+<meta charset="utf-8">
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%%; margin: 0; background: #000; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var map = L.map('map', {crs: L.CRS.Simple, minZoom: 0, maxZoom: %d}).setView([0, 0], 0);
+L.tileLayer('/{z}/{x}/{y}.png', {tileSize: %d, minZoom: 0, maxZoom: %d}).addTo(map);
+</script>
+</body>
+</html>
+`
+
+// ServeHTTP serves the page, the tiles, the chunk streams and the metrics.
+func (ts *tileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ts.metrics != nil {
+		switch r.URL.Path {
+		case "/metrics":
+			ts.metrics.ServeHTTP(w, r)
+			return
+		case "/debug/vars":
+			expvar.Handler().ServeHTTP(w, r)
+			return
+		}
+	}
+	if r.URL.Path == "/chunks" {
+		ts.lock.Lock()
+		err := ts.reload()
+		stream := ts.stream
+		ts.lock.Unlock()
+		if err != nil {
+			http.Error(w, strings.TrimSpace(err.Error()), http.StatusInternalServerError)
+			return
+		}
+		stream.ServeHTTP(w, r)
+		return
+	}
+	if r.URL.Path == "/" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, tilePage, ts.config, ts.maxZoom, ts.tileSize, ts.maxZoom)
+		return
+	}
+	key, ok := parseTile(r.URL.Path)
+	if !ok || key.z < 0 || key.z > ts.maxZoom {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := ts.tile(key)
+	if err != nil {
+		log.Printf("tile %d/%d/%d: %s", key.z, key.x, key.y, strings.TrimSpace(err.Error()))
+		http.Error(w, strings.TrimSpace(err.Error()), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to serve; the last one of the configuration if empty")
+	gradient := flags.String("gradient", "", "the gradient of the configuration to color the tiles with")
+	addr := flags.String("addr", "localhost:8080", "the address to listen on")
+	world := flags.Float64("world", 1.0, "the world size of a tile at zoom level 0")
+	tileSize := flags.Int("tile", 256, "the size of the tiles in pixels")
+	maxZoom := flags.Int("maxzoom", 20, "the highest zoom level served")
+	capacity := flags.Int("cache", 1024, "the number of tiles and of streamed chunks kept in memory; 0 disables the caches")
+	chunk := flags.Int("chunk", 64, "the number of grid points along each side of a streamed chunk")
+	cell := flags.Float64("cell", 0.0, "the world distance between the grid points of streamed chunks; -world divided by -tile if 0")
+	metrics := flags.Bool("metrics", false, "serve Prometheus metrics at /metrics and expvar at /debug/vars")
+	store := flags.String("store", "", "keep the tiles in s3://bucket/prefix, gs://bucket/prefix or a directory as well")
+	storeEndpoint := flags.String("store-endpoint", "", "the URL of the service with the S3 API of the -store")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	if *world <= 0.0 || *tileSize <= 0 || *chunk <= 0 || *maxZoom < 0 || *cell < 0.0 {
+		return fmt.Errorf("-world, -tile and -chunk must be positive and -maxzoom and -cell can't be negative")
+	}
+	if *cell == 0.0 {
+		*cell = *world / float64(*tileSize)
+	}
+	ts := &tileServer{
+		config:   *config,
+		name:     *name,
+		gradient: *gradient,
+		world:    *world,
+		tileSize: *tileSize,
+		maxZoom:  *maxZoom,
+		capacity: *capacity,
+		chunk:    *chunk,
+		cell:     *cell,
+	}
+	var err error
+	if *store != "" {
+		if ts.store, err = newTileStore(*store, *storeEndpoint); err != nil {
+			return err
+		}
+	}
+	if *metrics {
+		ts.metrics = server.NewMetrics()
+		ts.metrics.Publish("noisey")
+	}
+	ts.lock.Lock()
+	err = ts.reload()
+	ts.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	log.Printf("serving %s at http://%s/", *config, *addr)
+	return http.ListenAndServe(*addr, ts)
+}
@@ -0,0 +1,76 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the show command, which builds a generator of a
+configuration and prints it to the terminal with colored half block
+characters, for a look at it over ssh or in the output of a CI job.
+
+The colors are 24 bit if COLORTERM says the terminal has them and the 256
+colors of xterm otherwise, unless -colors picks one.
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tbogdala/noisey"
+)
+
+// terminalColors returns the colors named by name, or the colors the
+// terminal says it has through COLORTERM if name is empty.
+func terminalColors(name string) (noisey.TerminalColors, error) {
+	if name == "" {
+		switch os.Getenv("COLORTERM") {
+		case "truecolor", "24bit":
+			return noisey.TerminalTrueColor, nil
+		}
+		return noisey.Terminal256, nil
+	}
+	switch name {
+	case "256":
+		return noisey.Terminal256, nil
+	case "truecolor", "24bit":
+		return noisey.TerminalTrueColor, nil
+	}
+	return 0, fmt.Errorf("unknown colors %q; expected 256 or truecolor", name)
+}
+
+func runShow(args []string) error {
+	flags := flag.NewFlagSet("show", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to show; the last one of the configuration if empty")
+	size := flags.String("size", "256x256", "the size of the map to build in grid points as WIDTHxHEIGHT")
+	bounds := flags.String("bounds", "0,0,1,1", "the rectangle to show as MINX,MINY,MAXX,MAXY")
+	columns := flags.Int("columns", 80, "the width of the preview in characters")
+	colors := flags.String("colors", "", "the colors to use: 256 or truecolor; picked by COLORTERM if empty")
+	gradient := flags.String("gradient", "", "the gradient of the configuration to color the map with")
+	normalize := flags.Bool("normalize", false, "stretch the values to fill [-1, 1] before showing them")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	tc, err := terminalColors(*colors)
+	if err != nil {
+		return err
+	}
+	cfg, nm, err := renderMap(*config, *name, *size, *bounds)
+	if err != nil {
+		return err
+	}
+	if *normalize {
+		nm.Normalize(-1.0, 1.0)
+	}
+	cg, err := colorGradient(cfg, *gradient)
+	if err != nil {
+		return err
+	}
+	return noisey.WriteTerminal(os.Stdout, &nm, noisey.TerminalOptions{Columns: *columns, Colors: tc, Gradient: &cg})
+}
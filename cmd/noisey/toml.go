@@ -0,0 +1,633 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file parses and writes TOML: tables, arrays of tables, dotted keys,
+inline tables, arrays, basic, literal and multi-line strings, integers,
+floats and booleans. Dates and times aren't supported since configurations
+don't have any.
+
+Comments are kept with the value of the key or table on the line they are
+on or on the lines above it, like in YAML. TOML has no null, so keys with
+null values are left out when writing, which loads the same.
+
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser walks the text of a TOML document.
+type tomlParser struct {
+	data     string
+	pos      int
+	line     int
+	comments []string // the comments read since the last key or table
+}
+
+// errorf returns an error at the current line.
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// comment reads the comment at the position, if there is one, up to the
+// end of the line.
+func (p *tomlParser) comment() {
+	if p.peek() != '#' {
+		return
+	}
+	end := strings.IndexByte(p.data[p.pos:], '\n')
+	if end < 0 {
+		end = len(p.data) - p.pos
+	}
+	p.comments = append(p.comments, commentText(p.data[p.pos:p.pos+end]))
+	p.pos += end
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.comment()
+		default:
+			return
+		}
+	}
+}
+
+// endLine reads the rest of a line after a key and value or a table, which
+// may only have a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	p.comment()
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	if !p.eof() && p.peek() != '\n' {
+		return p.errorf("unexpected %q after the value", p.peek())
+	}
+	return nil
+}
+
+// takeComments returns the comments read so far and forgets them.
+func (p *tomlParser) takeComments() []string {
+	c := p.comments
+	p.comments = nil
+	return c
+}
+
+// isBareKey returns true if the character can be part of a bare key.
+func isBareKey(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// key parses a key of parts separated by dots.
+func (p *tomlParser) key() ([]string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		var part string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			n, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			part = n.scalar
+		case isBareKey(c):
+			start := p.pos
+			for !p.eof() && isBareKey(p.peek()) {
+				p.pos++
+			}
+			part = p.data[start:p.pos]
+		default:
+			return nil, p.errorf("expected a key")
+		}
+		parts = append(parts, part)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return parts, nil
+		}
+		p.pos++
+	}
+}
+
+// str parses a basic, literal or multi-line string.
+func (p *tomlParser) str() (*node, error) {
+	quote := p.data[p.pos : p.pos+1]
+	multi := strings.HasPrefix(p.data[p.pos:], strings.Repeat(quote, 3))
+	if multi {
+		quote = strings.Repeat(quote, 3)
+	}
+	p.pos += len(quote)
+	if multi {
+		// a newline right after the opening quotes isn't part of the string
+		if strings.HasPrefix(p.data[p.pos:], "\r\n") {
+			p.pos += 2
+			p.line++
+		} else if p.peek() == '\n' {
+			p.pos++
+			p.line++
+		}
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return nil, p.errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.data[p.pos:], quote) {
+			// a multi-line string may end with up to two more quotes that
+			// are part of it
+			extra := 0
+			for multi && extra < 2 && strings.HasPrefix(p.data[p.pos+extra+1:], quote) {
+				extra++
+			}
+			b.WriteString(p.data[p.pos : p.pos+extra])
+			p.pos += extra + len(quote)
+			return &node{kind: nodeString, scalar: b.String()}, nil
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == '\n':
+			if !multi {
+				return nil, p.errorf("unterminated string")
+			}
+			p.line++
+			b.WriteByte(c)
+			p.pos++
+		case c == '\\' && quote[0] == '"':
+			if err := p.escape(&b, multi); err != nil {
+				return nil, err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// escape parses an escape sequence of a basic string.
+func (p *tomlParser) escape(b *strings.Builder, multi bool) error {
+	p.pos++
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.data) {
+			return p.errorf("bad unicode escape")
+		}
+		r, err := strconv.ParseUint(p.data[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("bad unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += size
+	default:
+		// a backslash at the end of a line of a multi-line string drops the
+		// newline and the whitespace after it
+		rest := strings.TrimLeft(p.data[p.pos-1:], " \t\r")
+		if !multi || !strings.HasPrefix(rest, "\n") {
+			return p.errorf("bad escape \\%c", c)
+		}
+		p.pos--
+		for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+			if p.peek() == '\n' {
+				p.line++
+			}
+			p.pos++
+		}
+	}
+	return nil
+}
+
+// value parses a value after the = of a key or in an array.
+func (p *tomlParser) value() (*node, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		p.pos++
+		n := &node{kind: nodeList}
+		for {
+			// comments inside arrays are dropped
+			p.skipBlank()
+			p.comments = nil
+			if p.peek() == ']' {
+				p.pos++
+				return n, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, v)
+			p.skipBlank()
+			p.comments = nil
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ']':
+			default:
+				return nil, p.errorf("expected a comma or the end of the array")
+			}
+		}
+	case c == '{':
+		p.pos++
+		n := &node{kind: nodeMap}
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return n, nil
+		}
+		for {
+			if _, err := p.keyValue(n); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case '}':
+				p.pos++
+				return n, nil
+			default:
+				return nil, p.errorf("expected a comma or the end of the inline table")
+			}
+		}
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(",]}# \t\r\n", rune(p.peek())) {
+		p.pos++
+	}
+	return tomlScalar(p.data[start:p.pos], p.line)
+}
+
+// tomlScalar parses a boolean or a number.
+func tomlScalar(s string, line int) (*node, error) {
+	switch s {
+	case "true", "false":
+		return &node{kind: nodeBool, scalar: s}, nil
+	case "":
+		return nil, fmt.Errorf("line %d: expected a value", line)
+	}
+	clean := strings.Replace(s, "_", "", -1)
+	base := 10
+	switch {
+	case strings.HasPrefix(clean, "0x"):
+		base = 16
+	case strings.HasPrefix(clean, "0o"):
+		base = 8
+	case strings.HasPrefix(clean, "0b"):
+		base = 2
+	}
+	if base != 10 {
+		i, err := strconv.ParseInt(clean[2:], base, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad number %s", line, s)
+		}
+		return &node{kind: nodeNumber, scalar: strconv.FormatInt(i, 10)}, nil
+	}
+	if i, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		return &node{kind: nodeNumber, scalar: strconv.FormatInt(i, 10)}, nil
+	}
+	if _, err := strconv.ParseFloat(clean, 64); err == nil && !strings.ContainsAny(clean, "xXpPnNiI") {
+		return &node{kind: nodeNumber, scalar: jsonNumber(clean)}, nil
+	}
+	if strings.ContainsAny(s, ":") || (len(s) >= 10 && s[4] == '-') {
+		return nil, fmt.Errorf("line %d: dates and times aren't supported", line)
+	}
+	return nil, fmt.Errorf("line %d: bad value %s", line, s)
+}
+
+// keyValue parses a key = value, adds it to the table and returns the value.
+func (p *tomlParser) keyValue(table *node) (*node, error) {
+	path, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '=' {
+		return nil, p.errorf("expected = after the key")
+	}
+	p.pos++
+	p.skipSpace()
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range path[:len(path)-1] {
+		next := table.get(k)
+		if next == nil {
+			next = &node{kind: nodeMap}
+			table.set(k, next)
+		} else if next.kind != nodeMap {
+			return nil, p.errorf("key %s is already a value", k)
+		}
+		table = next
+	}
+	last := path[len(path)-1]
+	if table.get(last) != nil {
+		return nil, p.errorf("key %s is defined twice", last)
+	}
+	table.set(last, v)
+	return v, nil
+}
+
+// table returns the table of a [table] or a new table of an [[array]],
+// making the tables on the way there.
+func (p *tomlParser) table(root *node, path []string, array bool) (*node, error) {
+	t := root
+	for i, k := range path {
+		v := t.get(k)
+		last := i == len(path)-1
+		if last && array {
+			if v == nil {
+				v = &node{kind: nodeList}
+				t.set(k, v)
+			} else if v.kind != nodeList {
+				return nil, p.errorf("key %s isn't an array of tables", k)
+			}
+			el := &node{kind: nodeMap}
+			v.values = append(v.values, el)
+			return el, nil
+		}
+		if v == nil {
+			v = &node{kind: nodeMap}
+			t.set(k, v)
+		}
+		// a table in an array of tables goes in its last table
+		if v.kind == nodeList && len(v.values) > 0 && !last {
+			v = v.values[len(v.values)-1]
+		}
+		if v.kind != nodeMap {
+			return nil, p.errorf("key %s is already a value", k)
+		}
+		t = v
+	}
+	return t, nil
+}
+
+// parseTOML parses TOML into a node tree.
+func parseTOML(data []byte) (*node, error) {
+	p := tomlParser{data: string(data), line: 1}
+	root := &node{kind: nodeMap}
+	current := root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+		comments := p.takeComments()
+		if p.peek() == '[' {
+			p.pos++
+			array := p.peek() == '['
+			if array {
+				p.pos++
+			}
+			path, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			end := "]"
+			if array {
+				end = "]]"
+			}
+			if !strings.HasPrefix(p.data[p.pos:], end) {
+				return nil, p.errorf("expected %s after the table name", end)
+			}
+			p.pos += len(end)
+			if current, err = p.table(root, path, array); err != nil {
+				return nil, err
+			}
+			if err := p.endLine(); err != nil {
+				return nil, err
+			}
+			current.comments = append(current.comments, comments...)
+			current.comments = append(current.comments, p.takeComments()...)
+			continue
+		}
+		v, err := p.keyValue(current)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+		v.comments = append(comments, p.takeComments()...)
+	}
+}
+
+// tomlKey returns the key bare if it can be, or else quoted.
+func tomlKey(k string) string {
+	if k == "" {
+		return tomlString(k)
+	}
+	for i := 0; i < len(k); i++ {
+		if !isBareKey(k[i]) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// tomlString returns the string as a basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString("\\\"")
+		case '\\':
+			b.WriteString("\\\\")
+		case '\n':
+			b.WriteString("\\n")
+		case '\t':
+			b.WriteString("\\t")
+		case '\r':
+			b.WriteString("\\r")
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, "\\u%04x", r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tomlInline returns a value on one line.
+func tomlInline(n *node) (string, error) {
+	switch n.kind {
+	case nodeBool:
+		return n.scalar, nil
+	case nodeNumber:
+		return jsonNumber(n.scalar), nil
+	case nodeString:
+		return tomlString(n.scalar), nil
+	case nodeList:
+		parts := make([]string, len(n.values))
+		for i, v := range n.values {
+			s, err := tomlInline(v)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case nodeMap:
+		var parts []string
+		for i, v := range n.values {
+			if v.kind == nodeNull {
+				continue
+			}
+			s, err := tomlInline(v)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(n.keys[i])+" = "+s)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("TOML has no null to write in an array")
+}
+
+// isTableArray returns true if the value is written as an array of tables.
+func isTableArray(n *node) bool {
+	if n.kind != nodeList || len(n.values) == 0 {
+		return false
+	}
+	for _, v := range n.values {
+		if v.kind != nodeMap {
+			return false
+		}
+	}
+	return true
+}
+
+// hasValues returns true if the table has keys that aren't tables.
+func (n *node) hasValues() bool {
+	for _, v := range n.values {
+		if v.kind != nodeNull && v.kind != nodeMap && !isTableArray(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTOMLTable writes the keys of a table, with the values first and
+// then the tables and arrays of tables in it, since a value after a table
+// would be in that table.
+func (n *node) writeTOMLTable(b *bytes.Buffer, path string) error {
+	for i, v := range n.values {
+		if v.kind == nodeNull || v.kind == nodeMap || isTableArray(v) {
+			continue
+		}
+		s, err := tomlInline(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path+tomlKey(n.keys[i]), err)
+		}
+		writeComments(b, v.comments, "")
+		b.WriteString(tomlKey(n.keys[i]) + " = " + s + "\n")
+	}
+	for i, v := range n.values {
+		name := path + tomlKey(n.keys[i])
+		switch {
+		case v.kind == nodeMap:
+			// a table only holding tables doesn't need a header of its own
+			if v.hasValues() || len(v.values) == 0 || len(v.comments) > 0 {
+				if b.Len() > 0 {
+					b.WriteString("\n")
+				}
+				writeComments(b, v.comments, "")
+				b.WriteString("[" + name + "]\n")
+			}
+			if err := v.writeTOMLTable(b, name+"."); err != nil {
+				return err
+			}
+		case isTableArray(v):
+			for j, el := range v.values {
+				if b.Len() > 0 {
+					b.WriteString("\n")
+				}
+				if j == 0 {
+					writeComments(b, v.comments, "")
+				}
+				writeComments(b, el.comments, "")
+				b.WriteString("[[" + name + "]]\n")
+				if err := el.writeTOMLTable(b, name+"."); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TOML returns the tree as TOML, which has to be a map at the top.
+func (n *node) TOML() ([]byte, error) {
+	if n.kind != nodeMap {
+		return nil, fmt.Errorf("TOML can only hold a map at the top level")
+	}
+	var b bytes.Buffer
+	if err := n.writeTOMLTable(&b, ""); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
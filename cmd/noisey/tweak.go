@@ -0,0 +1,248 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file holds the configuration side of the tweak command: it finds the
+numeric parameters of every source and generator of a configuration tree by
+their descriptions in the registry of noisey, changes them in the tree and
+writes the tree back to the file with its comments. The window itself is in
+tweak_gui.go.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// tweakParam is a numeric parameter of a source or generator of a
+// configuration tree.
+type tweakParam struct {
+	module string           // the name of the source or generator
+	info   noisey.ParamInfo // the description of the parameter
+	owner  *node            // the map of the source or generator
+	min    float64          // the lowest value of the slider
+	max    float64          // the highest value of the slider
+	step   float64          // the step of the slider
+}
+
+// lookupFold returns the key of a map that is name ignoring case, like
+// encoding/json matches fields, or name itself if there is none.
+func (n *node) lookupFold(name string) (string, *node) {
+	for i, k := range n.keys {
+		if strings.EqualFold(k, name) {
+			return k, n.values[i]
+		}
+	}
+	return name, nil
+}
+
+// value returns the value of the parameter in the tree, or its default if
+// the tree doesn't have it.
+func (p *tweakParam) value() float64 {
+	_, v := p.owner.lookupFold(p.info.Name)
+	if v == nil || v.kind != nodeNumber {
+		return p.info.Default
+	}
+	f, err := strconv.ParseFloat(v.scalar, 64)
+	if err != nil {
+		return p.info.Default
+	}
+	return f
+}
+
+// format returns the text of a value of the parameter, rounded to the step
+// of its slider.
+func (p *tweakParam) format(v float64) string {
+	if p.info.Type == "int" {
+		return strconv.FormatInt(int64(math.Floor(v+0.5)), 10)
+	}
+	decimals := 0
+	if p.step > 0.0 {
+		decimals = int(math.Max(0.0, math.Ceil(-math.Log10(p.step))))
+	}
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// set changes the parameter in the tree, keeping the comments of the value
+// it replaces. The value is clamped to the valid range of the parameter.
+func (p *tweakParam) set(v float64) {
+	v = math.Max(p.info.Min, math.Min(p.info.Max, v))
+	key, old := p.owner.lookupFold(p.info.Name)
+	n := &node{kind: nodeNumber, scalar: p.format(v)}
+	if old != nil {
+		n.comments = old.comments
+	}
+	p.owner.set(key, n)
+}
+
+// sliderRange sets the range and step of the slider of the parameter. A
+// parameter without a finite bound gets a range of four times the size of
+// its value or default, at least 1, past the bound it has.
+func (p *tweakParam) sliderRange() {
+	v := p.value()
+	span := 4.0 * math.Max(1.0, math.Max(math.Abs(v), math.Abs(p.info.Default)))
+	lo, hi := p.info.Min, p.info.Max
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		lo, hi = -span, span
+	case math.IsInf(lo, -1):
+		lo = hi - span
+	case math.IsInf(hi, 1):
+		hi = lo + span
+	}
+	// values outside of the range are kept reachable
+	p.min, p.max = math.Min(lo, v), math.Max(hi, v)
+	if p.info.Type == "int" {
+		p.step = 1.0
+	} else {
+		p.step = math.Pow(10.0, math.Floor(math.Log10((p.max-p.min)/1000.0)))
+	}
+}
+
+// moduleParams returns the parameters of a source or generator map. Types
+// that aren't in the registry have no parameters.
+func moduleParams(name string, module *node, typeKey string) []*tweakParam {
+	if module == nil || module.kind != nodeMap {
+		return nil
+	}
+	_, t := module.lookupFold(typeKey)
+	if t == nil || t.kind != nodeString {
+		return nil
+	}
+	info, ok := noisey.LookupModule(t.scalar)
+	if !ok {
+		return nil
+	}
+	var params []*tweakParam
+	for _, pi := range info.Params {
+		p := &tweakParam{module: name, info: pi, owner: module}
+		p.sliderRange()
+		params = append(params, p)
+	}
+	return params
+}
+
+// tweakParams returns the parameters of the sources and then of the
+// generators of a configuration tree, in the order of the file.
+func tweakParams(tree *node) []*tweakParam {
+	var params []*tweakParam
+	if tree == nil || tree.kind != nodeMap {
+		return nil
+	}
+	if _, sources := tree.lookupFold("Sources"); sources != nil && sources.kind == nodeMap {
+		for i, name := range sources.keys {
+			params = append(params, moduleParams(name, sources.values[i], "SourceType")...)
+		}
+	}
+	if _, gens := tree.lookupFold("Generators"); gens != nil && gens.kind == nodeList {
+		for i, g := range gens.values {
+			name := fmt.Sprintf("generator %d", i+1)
+			if g.kind == nodeMap {
+				if _, n := g.lookupFold("Name"); n != nil && n.kind == nodeString {
+					name = n.scalar
+				}
+			}
+			params = append(params, moduleParams(name, g, "GeneratorType")...)
+		}
+	}
+	return params
+}
+
+// tweakSession is a configuration file being tweaked.
+type tweakSession struct {
+	path   string
+	format string
+	tree   *node
+	params []*tweakParam
+}
+
+// openTweakSession reads a configuration file and finds its parameters.
+func openTweakSession(path string) (*tweakSession, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ts := &tweakSession{path: path, format: configFormat(path)}
+	ts.tree, err = parseConfig(data, ts.format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if _, err := buildConfig(ts.tree); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	ts.params = tweakParams(ts.tree)
+	return ts, nil
+}
+
+// save writes the tree back to the file in its format.
+func (ts *tweakSession) save() error {
+	data, err := formatConfig(ts.tree, ts.format)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ts.path, data, 0644)
+}
+
+// names returns the names of the generators and the gradients of the tree.
+func (ts *tweakSession) names() (generators []string, gradients []string) {
+	if _, gens := ts.tree.lookupFold("Generators"); gens != nil && gens.kind == nodeList {
+		for _, g := range gens.values {
+			if g.kind != nodeMap {
+				continue
+			}
+			if _, n := g.lookupFold("Name"); n != nil && n.kind == nodeString {
+				generators = append(generators, n.scalar)
+			}
+		}
+	}
+	if _, grads := ts.tree.lookupFold("Gradients"); grads != nil && grads.kind == nodeMap {
+		gradients = append(gradients, grads.keys...)
+	}
+	return generators, gradients
+}
+
+// tweakImage builds a generator of the JSON of a configuration tree over
+// the bounds and colors it with the gradient, or in grayscale if the
+// gradient is empty.
+func tweakImage(data []byte, name string, w int, h int, bounds noisey.Builder2DBounds, gradient string) (*image.NRGBA, error) {
+	tree, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := buildConfig(tree)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := generator(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	builder := noisey.NewBuilder2D(gen, w, h)
+	builder.Bounds = bounds
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	cg, err := colorGradient(cfg, gradient)
+	if err != nil {
+		return nil, err
+	}
+	nm := builder.GetNoiseMap()
+	return cg.Image(&nm), nil
+}
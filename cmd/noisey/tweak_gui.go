@@ -0,0 +1,234 @@
+//go:build tweak
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file implements the tweak command, which opens a configuration in a
+window with a slider for every numeric parameter of its sources and
+generators, as described by the registry of noisey. The map of a generator
+is rendered again whenever a parameter changes, and Save writes the
+parameters back to the configuration file, keeping its comments.
+
+It needs the Fyne toolkit, so it's only built with the tweak tag:
+
+	go get fyne.io/fyne/v2
+	go install -tags tweak github.com/tbogdala/noisey/cmd/noisey
+
+*/
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"runtime"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+func init() {
+	// the windows have to be driven from the main thread
+	runtime.LockOSThread()
+	commands["tweak"] = command{"change the parameters of a configuration with sliders and see the result as they move", runTweak}
+}
+
+// tweakRender is a request to render the JSON of a configuration tree.
+type tweakRender struct {
+	data      []byte
+	generator string
+	gradient  string
+}
+
+// paramRow returns the row of a parameter: its name, a slider and an entry
+// for typing a value, which call changed with the value that was set.
+func paramRow(p *tweakParam, changed func()) fyne.CanvasObject {
+	label := widget.NewLabel(p.info.Name)
+	entry := widget.NewEntry()
+	entry.SetText(p.format(p.value()))
+	slider := widget.NewSlider(p.min, p.max)
+	slider.Step = p.step
+	slider.Value = p.value()
+
+	slider.OnChanged = func(v float64) {
+		p.set(v)
+		entry.SetText(p.format(p.value()))
+		changed()
+	}
+	entry.OnSubmitted = func(s string) {
+		var v float64
+		if _, err := fmt.Sscan(strings.TrimSpace(s), &v); err != nil {
+			entry.SetText(p.format(p.value()))
+			return
+		}
+		p.set(v)
+		slider.Value = p.value()
+		slider.Refresh()
+		entry.SetText(p.format(p.value()))
+		changed()
+	}
+
+	value := container.NewGridWrap(fyne.NewSize(90, entry.MinSize().Height), entry)
+	row := container.NewBorder(nil, nil, label, value, slider)
+	if p.info.Description == "" {
+		return row
+	}
+	help := widget.NewLabel(p.info.Description)
+	help.Wrapping = fyne.TextWrapWord
+	help.TextStyle = fyne.TextStyle{Italic: true}
+	return container.NewVBox(row, help)
+}
+
+func runTweak(args []string) error {
+	flags := flag.NewFlagSet("tweak", flag.ExitOnError)
+	config := flags.String("config", "", "the JSON, YAML or TOML configuration file")
+	name := flags.String("generator", "", "the generator to show; the last one of the configuration if empty")
+	size := flags.String("size", "384x384", "the size of the rendered map as WIDTHxHEIGHT")
+	bounds := flags.String("bounds", "0,0,1,1", "the rectangle to render as MINX,MINY,MAXX,MAXY")
+	gradient := flags.String("gradient", "", "the gradient of the configuration to color the map with")
+	flags.Parse(args)
+
+	if *config == "" {
+		flags.Usage()
+		return fmt.Errorf("-config is required")
+	}
+	w, h, err := parseSize(*size)
+	if err != nil {
+		return err
+	}
+	b, err := parseBounds(*bounds)
+	if err != nil {
+		return err
+	}
+	ts, err := openTweakSession(*config)
+	if err != nil {
+		return err
+	}
+	generators, gradients := ts.names()
+	if len(generators) == 0 {
+		return fmt.Errorf("the configuration has no generators")
+	}
+	if *name == "" {
+		*name = generators[len(generators)-1]
+	}
+
+	a := app.New()
+	window := a.NewWindow("noisey tweak: " + *config)
+
+	preview := canvas.NewImageFromImage(image.NewNRGBA(image.Rect(0, 0, w, h)))
+	preview.FillMode = canvas.ImageFillContain
+	preview.ScaleMode = canvas.ImageScalePixels
+	preview.SetMinSize(fyne.NewSize(float32(w), float32(h)))
+	status := widget.NewLabel("")
+
+	// the maps are rendered off the main thread so the sliders stay
+	// responsive; only the latest request is rendered
+	renders := make(chan tweakRender, 1)
+	go func() {
+		for r := range renders {
+			start := time.Now()
+			img, err := tweakImage(r.data, r.generator, w, h, b, r.gradient)
+			took := time.Since(start)
+			fyne.Do(func() {
+				if err != nil {
+					status.SetText(strings.TrimSpace(err.Error()))
+					return
+				}
+				preview.Image = img
+				preview.Refresh()
+				status.SetText(fmt.Sprintf("rendered %s in %v", r.generator, took.Round(time.Millisecond)))
+			})
+		}
+	}()
+	render := func() {
+		r := tweakRender{ts.tree.JSON(), *name, *gradient}
+		select {
+		case <-renders:
+		default:
+		}
+		renders <- r
+	}
+	saved := true
+	dirty := func() {
+		saved = false
+		window.SetTitle("noisey tweak: " + *config + " *")
+		render()
+	}
+	window.SetCloseIntercept(func() {
+		if saved {
+			window.Close()
+			return
+		}
+		dialog.ShowConfirm("Unsaved changes", "Quit without saving the parameters?", func(quit bool) {
+			if quit {
+				window.Close()
+			}
+		}, window)
+	})
+
+	genSelect := widget.NewSelect(generators, func(s string) {
+		*name = s
+		render()
+	})
+	genSelect.Selected = *name
+	gradSelect := widget.NewSelect(append([]string{"(grayscale)"}, gradients...), func(s string) {
+		if s == "(grayscale)" {
+			s = ""
+		}
+		*gradient = s
+		render()
+	})
+	gradSelect.Selected = *gradient
+	if *gradient == "" {
+		gradSelect.Selected = "(grayscale)"
+	}
+	save := widget.NewButton("Save", func() {
+		if err := ts.save(); err != nil {
+			status.SetText(strings.TrimSpace(err.Error()))
+			return
+		}
+		saved = true
+		window.SetTitle("noisey tweak: " + *config)
+		status.SetText("saved " + *config)
+	})
+
+	params := container.NewVBox()
+	module := ""
+	for _, p := range ts.params {
+		if p.module != module {
+			module = p.module
+			params.Add(widget.NewLabelWithStyle(module, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		params.Add(paramRow(p, dirty))
+	}
+	if len(ts.params) == 0 {
+		params.Add(widget.NewLabel("the configuration has no parameters to tweak"))
+	}
+
+	controls := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Generator", genSelect),
+			widget.NewFormItem("Gradient", gradSelect),
+		),
+		save,
+	)
+	right := container.NewBorder(controls, status, nil, nil, preview)
+	split := container.NewHSplit(container.NewVScroll(params), right)
+	split.Offset = 0.45
+	window.SetContent(split)
+	window.Resize(fyne.NewSize(float32(w)*2.2, float32(h)+160))
+
+	render()
+	window.ShowAndRun()
+	close(renders)
+	return nil
+}
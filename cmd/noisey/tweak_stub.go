@@ -0,0 +1,14 @@
+//go:build !tweak
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+import "fmt"
+
+func init() {
+	commands["tweak"] = command{"change the parameters of a configuration with sliders and see the result as they move", func(args []string) error {
+		return fmt.Errorf("this build has no tweak window; install fyne.io/fyne/v2 and build with -tags tweak")
+	}}
+}
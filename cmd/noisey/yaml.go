@@ -0,0 +1,421 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package main
+
+/*
+
+This file parses and writes the part of YAML that configurations need:
+block maps and lists nested by indentation, flow lists and maps of scalars
+on one line, quoted and plain scalars and comments. Anchors, tags and
+multi-line strings aren't supported.
+
+Comments are kept with the value of the key or list item on the line they
+are on or on the lines above it, and written out above it again.
+
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of YAML without its indentation and comment.
+type yamlLine struct {
+	number   int
+	indent   int
+	text     string
+	comments []string // the comments of the line and of the lines above it
+}
+
+// splitYAMLComment splits the line into the text and the comment, which
+// starts at a # that begins the line or follows a space outside of quotes.
+func splitYAMLComment(s string) (string, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// commentText returns the text of a comment without the # and the space after it.
+func commentText(comment string) string {
+	return strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(comment, "#"), " "), " \t\r")
+}
+
+// parseYAML parses YAML into a node tree.
+func parseYAML(data []byte) (*node, error) {
+	var lines []yamlLine
+	var comments []string
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw, comment := splitYAMLComment(strings.TrimRight(raw, "\r"))
+		if comment != "" {
+			comments = append(comments, commentText(comment))
+		}
+		raw = strings.TrimRight(raw, " \t")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: YAML can't be indented with tabs", i+1)
+		}
+		lines = append(lines, yamlLine{i + 1, len(raw) - len(text), text, comments})
+		comments = nil
+	}
+	if len(lines) == 0 {
+		return &node{kind: nodeMap}, nil
+	}
+	p := yamlParser{lines: lines}
+	n, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return n, nil
+}
+
+// yamlParser walks the lines of a YAML document.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// isListItem returns true if the text starts a list item.
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the map or list whose lines are at the indent.
+func (p *yamlParser) block(indent int) (*node, error) {
+	if isListItem(p.lines[p.pos].text) {
+		return p.list(indent)
+	}
+	return p.mapping(indent)
+}
+
+// nested parses the value that follows a key or a list item without one on
+// its own line, which is more indented than parent or a list at the indent
+// of a key.
+func (p *yamlParser) nested(parent int, key bool) (*node, error) {
+	if p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		if next.indent > parent || (key && next.indent == parent && isListItem(next.text)) {
+			return p.block(next.indent)
+		}
+	}
+	return &node{kind: nodeNull}, nil
+}
+
+func (p *yamlParser) list(indent int) (*node, error) {
+	n := &node{kind: nodeList}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isListItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		var item *node
+		var err error
+		switch {
+		case rest == "":
+			p.pos++
+			item, err = p.nested(indent, false)
+		case yamlKey(rest) >= 0:
+			// a map that starts on the line of the item continues at the
+			// indent of its first key
+			p.lines[p.pos] = yamlLine{line.number, indent + len(line.text) - len(rest), rest, nil}
+			item, err = p.mapping(p.lines[p.pos].indent)
+		default:
+			p.pos++
+			item, err = parseYAMLScalar(rest, line.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		item.comments = line.comments
+		n.values = append(n.values, item)
+	}
+	return n, nil
+}
+
+func (p *yamlParser) mapping(indent int) (*node, error) {
+	n := &node{kind: nodeMap}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if isListItem(line.text) {
+			break
+		}
+		colon := yamlKey(line.text)
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: expected a key followed by a colon", line.number)
+		}
+		key, err := parseYAMLKey(strings.TrimSpace(line.text[:colon]), line.number)
+		if err != nil {
+			return nil, err
+		}
+		rest := strings.TrimSpace(line.text[colon+1:])
+		p.pos++
+		var v *node
+		if rest == "" {
+			v, err = p.nested(indent, true)
+		} else {
+			v, err = parseYAMLScalar(rest, line.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.comments = line.comments
+		n.set(key, v)
+	}
+	return n, nil
+}
+
+// yamlKey returns the index of the colon that ends the key at the start of
+// the text, or -1 if the text doesn't start with a key.
+func yamlKey(text string) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == '[' || c == '{':
+			if i == 0 {
+				return -1
+			}
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// parseYAMLKey parses a key, which is the text of a plain scalar even if
+// it reads as a number, bool or null.
+func parseYAMLKey(s string, line int) (string, error) {
+	if !strings.HasPrefix(s, "\"") && !strings.HasPrefix(s, "'") {
+		return s, nil
+	}
+	key, err := parseYAMLScalar(s, line)
+	if err != nil {
+		return "", err
+	}
+	return key.scalar, nil
+}
+
+// splitFlow splits the inside of a flow list or map at its top level commas.
+func splitFlow(s string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// parseYAMLScalar parses a scalar or a flow list or map on one line.
+func parseYAMLScalar(s string, line int) (*node, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow list", line)
+		}
+		n := &node{kind: nodeList}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			item, err := parseYAMLScalar(part, line)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, item)
+		}
+		return n, nil
+	case strings.HasPrefix(s, "{"):
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("line %d: unterminated flow map", line)
+		}
+		n := &node{kind: nodeMap}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			colon := yamlKey(part)
+			if colon < 0 {
+				return nil, fmt.Errorf("line %d: expected a key followed by a colon in %q", line, part)
+			}
+			key, err := parseYAMLKey(strings.TrimSpace(part[:colon]), line)
+			if err != nil {
+				return nil, err
+			}
+			v, err := parseYAMLScalar(strings.TrimSpace(part[colon+1:]), line)
+			if err != nil {
+				return nil, err
+			}
+			n.set(key, v)
+		}
+		return n, nil
+	case strings.HasPrefix(s, "\""):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad quoted string %s", line, s)
+		}
+		return &node{kind: nodeString, scalar: v}, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("line %d: bad quoted string %s", line, s)
+		}
+		return &node{kind: nodeString, scalar: strings.Replace(s[1:len(s)-1], "''", "'", -1)}, nil
+	}
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return &node{kind: nodeNull}, nil
+	case "true", "True", "TRUE":
+		return &node{kind: nodeBool, scalar: "true"}, nil
+	case "false", "False", "FALSE":
+		return &node{kind: nodeBool, scalar: "false"}, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN") {
+		return &node{kind: nodeNumber, scalar: s}, nil
+	}
+	return &node{kind: nodeString, scalar: s}, nil
+}
+
+// yamlString returns the string as a plain scalar if it reads back as the
+// same string, or else quoted. Plain scalars in flow lists can't have the
+// characters that separate their items either.
+func yamlString(s string, flow bool) string {
+	plain := s != "" && s == strings.TrimSpace(s) &&
+		!strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") &&
+		!strings.Contains(s, ": ") && !strings.Contains(s, " #") &&
+		!strings.HasSuffix(s, ":") && !strings.ContainsAny(s, "\n\r\t")
+	if plain && flow {
+		plain = !strings.ContainsAny(s, ",[]{}")
+	}
+	if plain {
+		if n, err := parseYAMLScalar(s, 0); err != nil || n.kind != nodeString || n.scalar != s {
+			plain = false
+		}
+	}
+	if plain {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlScalar returns a value that isn't a non-empty map or list as YAML.
+func yamlScalar(n *node, flow bool) string {
+	switch n.kind {
+	case nodeBool, nodeNumber:
+		return n.scalar
+	case nodeString:
+		return yamlString(n.scalar, flow)
+	case nodeList:
+		parts := make([]string, len(n.values))
+		for i, v := range n.values {
+			parts[i] = yamlScalar(v, true)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case nodeMap:
+		return "{}"
+	}
+	return "null"
+}
+
+// isBlock returns true if the value is written on lines of its own, which
+// are the maps and lists that aren't empty, but not the lists of scalars
+// without comments that are written as flow lists.
+func (n *node) isBlock() bool {
+	if n.kind == nodeMap {
+		return len(n.values) > 0
+	}
+	if n.kind != nodeList {
+		return false
+	}
+	for _, v := range n.values {
+		if len(v.comments) > 0 || v.isBlock() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeYAMLBlock writes a non-empty map or list with its lines at the
+// indent. If inline is true the first line continues the current one, as
+// the first key of a map that is a list item follows the dash, and its
+// comments have already been written.
+func (n *node) writeYAMLBlock(b *bytes.Buffer, indent string, inline bool) {
+	for i, v := range n.values {
+		prefix := indent
+		if i == 0 && inline {
+			prefix = ""
+		} else {
+			writeComments(b, v.comments, indent)
+		}
+		if n.kind == nodeMap {
+			b.WriteString(prefix + yamlString(n.keys[i], false) + ":")
+		} else {
+			if v.kind == nodeMap && len(v.values) > 0 {
+				writeComments(b, v.values[0].comments, indent+"  ")
+			}
+			b.WriteString(prefix + "-")
+		}
+		switch {
+		case v.kind == nodeMap && v.isBlock() && n.kind == nodeList:
+			b.WriteString(" ")
+			v.writeYAMLBlock(b, indent+"  ", true)
+		case v.isBlock():
+			b.WriteString("\n")
+			v.writeYAMLBlock(b, indent+"  ", false)
+		default:
+			b.WriteString(" " + yamlScalar(v, false) + "\n")
+		}
+	}
+}
+
+// YAML returns the tree as YAML.
+func (n *node) YAML() []byte {
+	var b bytes.Buffer
+	if n.isBlock() {
+		n.writeYAMLBlock(&b, "", false)
+	} else {
+		b.WriteString(yamlScalar(n, false) + "\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,427 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module compiles a pipeline of modules into a Program2D: a flat list of
+operations over a stack of values, which evaluates the whole pipeline in
+one loop instead of calling through an interface and following a pointer
+for every module of every octave:
+
+	terrain := noiseBank.GetGenerator("terrain")
+	program, err := noisey.Compile2D(terrain)
+	builder := noisey.NewBuilder2D(program, 512, 512)
+
+FBMGenerator2D, RidgedGenerator2D, Billow2D, Select2D, Scale2D and
+Turbulence2D are compiled into operations, including their octaves and the
+modules they use. Every other module, like the sources, is called as a
+leaf of the program. Select2D jumps over the source it doesn't need, so a
+program only samples what the pipeline would.
+
+The operations do the same floating point arithmetic in the same order as
+the modules, so a program returns exactly the values of its pipeline. It
+takes a snapshot of the parameters, so a pipeline that is changed after it
+was compiled has to be compiled again; the leaves are shared with it. A
+Program2D can be used by many goroutines at once.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// opCode is the kind of an operation of a Program2D.
+type opCode uint8
+
+const (
+	opCall       opCode = iota // push the leaf at the point
+	opZero                     // push 0
+	opOne                      // push 1
+	opTransform                // save the point and change it to (x*a, y*a) + (b, b)
+	opLacunarity               // multiply the point by a
+	opRestore                  // restore the point saved last
+	opDisplace                 // pop dy and dx, save the point and move it by (dx*a, dy*a)
+	opFBM                      // pop n and add n*a to the top
+	opFBMLeaf                  // add the leaf times a to the top and multiply the point by b
+	opBillow                   // pop n and add (2|n|-1)*a to the top
+	opBillowLeaf               // add (2|leaf|-1)*a to the top and multiply the point by b
+	opRidged                   // pop n and add the ridged signal of n times a to the sum below the weight on top
+	opRidgedLeaf               // add the ridged signal of the leaf like opRidged and multiply the point by d
+	opRidgedEnd                // pop the weight and shift the sum
+	opScale                    // scale the top by a, add b and clamp it to [c, d]
+	opSelect                   // pop the control and pick a source, jumping or pushing the blend
+	opBlend                    // pop b, a, the direction and t and push the blend of a and b
+	opJump                     // continue at the jump
+)
+
+// op is an operation of a Program2D.
+type op struct {
+	code       opCode
+	leaf       NoiseyGet2D
+	a, b, c, d float64
+	jump       int // where opJump continues, or where opSelect continues for SourceA
+	blend      int // where opSelect continues to blend the sources
+}
+
+// Program2D is a pipeline compiled into a flat list of operations.
+type Program2D struct {
+	ops    []op
+	values int // the largest number of values on the stack
+	points int // the largest number of saved points
+
+	min, max float64 // the bounds of the values of the pipeline when it was compiled
+}
+
+// programStack is the size of the stacks a program can use without
+// allocating them.
+const programStack = 16
+
+// compiler builds the operations of a program.
+type compiler struct {
+	p      *Program2D
+	values int
+	points int
+}
+
+// emit appends an operation and returns its index.
+func (c *compiler) emit(o op) int {
+	c.p.ops = append(c.p.ops, o)
+	return len(c.p.ops) - 1
+}
+
+// push records that n values were pushed.
+func (c *compiler) push(n int) {
+	c.values += n
+	if c.values > c.p.values {
+		c.p.values = c.values
+	}
+}
+
+// save records that a point was saved.
+func (c *compiler) save() {
+	c.points++
+	if c.points > c.p.points {
+		c.p.points = c.points
+	}
+}
+
+// module emits the operations that push the value of m.
+func (c *compiler) module(m NoiseyGet2D) error {
+	switch m := m.(type) {
+	case nil:
+		return fmt.Errorf("Unable to compile a pipeline with a missing module.\n")
+	case *FBMGenerator2D:
+		return c.fractal(m.NoiseMaker, m.Octaves, m.Persistence, m.Lacunarity, m.Frequency, opFBM)
+	case *Billow2D:
+		return c.fractal(m.NoiseMaker, m.Octaves, m.Persistence, m.Lacunarity, m.Frequency, opBillow)
+	case *RidgedGenerator2D:
+		c.emit(op{code: opTransform, a: m.Frequency})
+		c.save()
+		c.emit(op{code: opZero})
+		c.emit(op{code: opOne})
+		c.push(2)
+		spectralWeight := 1.0
+		spectralStep := math.Pow(m.Lacunarity, -m.Exponent)
+		for o := 0; o < m.Octaves; o++ {
+			if leaf, ok := c.leaf(m.NoiseMaker); ok {
+				c.emit(op{code: opRidgedLeaf, leaf: leaf, a: spectralWeight, b: m.Offset, c: m.Gain, d: m.Lacunarity})
+				spectralWeight *= spectralStep
+				continue
+			}
+			if err := c.module(m.NoiseMaker); err != nil {
+				return err
+			}
+			c.emit(op{code: opRidged, a: spectralWeight, b: m.Offset, c: m.Gain})
+			c.values--
+			c.emit(op{code: opLacunarity, a: m.Lacunarity})
+			spectralWeight *= spectralStep
+		}
+		c.emit(op{code: opRidgedEnd})
+		c.values--
+		c.emit(op{code: opRestore})
+		c.points--
+	case *Scale2D:
+		if err := c.module(m.Source); err != nil {
+			return err
+		}
+		c.emit(op{code: opScale, a: m.Scale, b: m.Bias, c: m.Min, d: m.Max})
+	case *Turbulence2D:
+		c.emit(op{code: opTransform, a: m.Frequency})
+		c.save()
+		if err := c.module(m.XDistort); err != nil {
+			return err
+		}
+		c.emit(op{code: opRestore})
+		c.emit(op{code: opTransform, a: m.Frequency, b: turbulenceOffset})
+		if err := c.module(m.YDistort); err != nil {
+			return err
+		}
+		c.emit(op{code: opRestore})
+		c.emit(op{code: opDisplace, a: m.Power})
+		c.values -= 2
+		if err := c.module(m.Source); err != nil {
+			return err
+		}
+		c.emit(op{code: opRestore})
+		c.points--
+	case *Select2D:
+		return c.selector(m)
+	default:
+		c.emit(op{code: opCall, leaf: m})
+		c.push(1)
+	}
+	return nil
+}
+
+// leaf returns m and true if it is called as a leaf of the program.
+func (c *compiler) leaf(m NoiseyGet2D) (NoiseyGet2D, bool) {
+	switch m.(type) {
+	case nil, *FBMGenerator2D, *Billow2D, *RidgedGenerator2D, *Scale2D, *Turbulence2D, *Select2D:
+		return nil, false
+	}
+	return m, true
+}
+
+// fractal emits the octaves of fBm or billow noise.
+func (c *compiler) fractal(noise NoiseyGet2D, octaves int, persistence float64, lacunarity float64, frequency float64, code opCode) error {
+	c.emit(op{code: opTransform, a: frequency})
+	c.save()
+	c.emit(op{code: opZero})
+	c.push(1)
+	curPersistence := 1.0
+	for o := 0; o < octaves; o++ {
+		// an octave of a leaf is a single operation
+		if leaf, ok := c.leaf(noise); ok {
+			if code == opFBM {
+				c.emit(op{code: opFBMLeaf, leaf: leaf, a: curPersistence, b: lacunarity})
+			} else {
+				c.emit(op{code: opBillowLeaf, leaf: leaf, a: curPersistence, b: lacunarity})
+			}
+			curPersistence *= persistence
+			continue
+		}
+		if err := c.module(noise); err != nil {
+			return err
+		}
+		c.emit(op{code: code, a: curPersistence})
+		c.values--
+		c.emit(op{code: opLacunarity, a: lacunarity})
+		curPersistence *= persistence
+	}
+	c.emit(op{code: opRestore})
+	c.points--
+	return nil
+}
+
+// selector emits the control of a Select2D followed by the code of
+// SourceB, the code of SourceA and the code that blends them, with
+// opSelect jumping to the one it needs.
+func (c *compiler) selector(m *Select2D) error {
+	if err := c.module(m.Control); err != nil {
+		return err
+	}
+	sel := c.emit(op{code: opSelect, a: m.LowerBound, b: m.UpperBound, c: m.EdgeFalloff})
+	c.values--
+	base := c.values
+
+	var ends []int
+	if err := c.module(m.SourceB); err != nil {
+		return err
+	}
+	ends = append(ends, c.emit(op{code: opJump}))
+
+	c.values = base
+	c.p.ops[sel].jump = len(c.p.ops)
+	if err := c.module(m.SourceA); err != nil {
+		return err
+	}
+	ends = append(ends, c.emit(op{code: opJump}))
+
+	c.values = base
+	c.p.ops[sel].blend = len(c.p.ops)
+	c.push(2)
+	if err := c.module(m.SourceA); err != nil {
+		return err
+	}
+	if err := c.module(m.SourceB); err != nil {
+		return err
+	}
+	c.emit(op{code: opBlend})
+	c.values = base + 1
+
+	for _, e := range ends {
+		c.p.ops[e].jump = len(c.p.ops)
+	}
+	return nil
+}
+
+// Compile2D compiles the pipeline of m into a program returning the same
+// values.
+func Compile2D(m NoiseyGet2D) (*Program2D, error) {
+	c := compiler{p: new(Program2D)}
+	if err := c.module(m); err != nil {
+		return nil, err
+	}
+	c.p.min, c.p.max = ValueRange2D(m)
+	return c.p, nil
+}
+
+// String returns a description of the program.
+func (p *Program2D) String() string {
+	return fmt.Sprintf("Program2D{Ops: %d, Stack: %d}", len(p.ops), p.values)
+}
+
+// Range2D returns the bounds of the values of the pipeline as it was compiled.
+func (p *Program2D) Range2D() (min float64, max float64) {
+	return p.min, p.max
+}
+
+// Get2D calculates the value of the compiled pipeline at a 2D coordinate.
+func (p *Program2D) Get2D(x float64, y float64) float64 {
+	var valueArray [programStack]float64
+	var pointArray [programStack][2]float64
+	values, points := valueArray[:], pointArray[:]
+	if p.values > programStack {
+		values = make([]float64, p.values)
+	}
+	if p.points > programStack {
+		points = make([][2]float64, p.points)
+	}
+	top, saved := -1, -1
+
+	ops := p.ops
+	for pc := 0; pc < len(ops); pc++ {
+		o := &ops[pc]
+		switch o.code {
+		case opCall:
+			top++
+			values[top] = o.leaf.Get2D(x, y)
+		case opZero:
+			top++
+			values[top] = 0.0
+		case opOne:
+			top++
+			values[top] = 1.0
+		case opTransform:
+			saved++
+			points[saved] = [2]float64{x, y}
+			x *= o.a
+			y *= o.a
+			if o.b != 0.0 {
+				x += o.b
+				y += o.b
+			}
+		case opLacunarity:
+			x *= o.a
+			y *= o.a
+		case opRestore:
+			x, y = points[saved][0], points[saved][1]
+			saved--
+		case opDisplace:
+			dx := values[top-1] * o.a
+			dy := values[top] * o.a
+			top -= 2
+			saved++
+			points[saved] = [2]float64{x, y}
+			x += dx
+			y += dy
+		case opFBM:
+			values[top-1] += values[top] * o.a
+			top--
+		case opFBMLeaf:
+			values[top] += o.leaf.Get2D(x, y) * o.a
+			x *= o.b
+			y *= o.b
+		case opBillowLeaf:
+			values[top] += (2.0*math.Abs(o.leaf.Get2D(x, y)) - 1.0) * o.a
+			x *= o.b
+			y *= o.b
+		case opBillow:
+			values[top-1] += (2.0*math.Abs(values[top]) - 1.0) * o.a
+			top--
+		case opRidged:
+			signal := o.b - math.Abs(values[top])
+			top--
+			signal *= signal
+			signal *= values[top]
+			weight := signal * o.c
+			if weight > 1.0 {
+				weight = 1.0
+			} else if weight < 0.0 {
+				weight = 0.0
+			}
+			values[top] = weight
+			values[top-1] += signal * o.a
+		case opRidgedLeaf:
+			signal := o.b - math.Abs(o.leaf.Get2D(x, y))
+			signal *= signal
+			signal *= values[top]
+			weight := signal * o.c
+			if weight > 1.0 {
+				weight = 1.0
+			} else if weight < 0.0 {
+				weight = 0.0
+			}
+			values[top] = weight
+			values[top-1] += signal * o.a
+			x *= o.d
+			y *= o.d
+		case opRidgedEnd:
+			top--
+			values[top] = values[top]*1.25 - 1.0
+		case opScale:
+			v := values[top]
+			v *= o.a
+			v += o.b
+			v = math.Max(o.c, v)
+			values[top] = math.Min(o.d, v)
+		case opSelect:
+			control := values[top]
+			top--
+			lower, upper, falloff := o.a, o.b, o.c
+			if falloff <= 0.0 {
+				if !(lower < control && control < upper) {
+					pc = o.jump - 1
+				}
+				continue
+			}
+			switch {
+			case control < lower-falloff:
+				pc = o.jump - 1
+			case control < lower+falloff:
+				l, u := lower-falloff, lower+falloff
+				values[top+1] = calcCubicSCurve((control - l) / (u - l))
+				values[top+2] = 0.0
+				top += 2
+				pc = o.blend - 1
+			case control < upper-falloff:
+			case control < upper+falloff:
+				l, u := upper-falloff, upper+falloff
+				values[top+1] = calcCubicSCurve((control - l) / (u - l))
+				values[top+2] = 1.0
+				top += 2
+				pc = o.blend - 1
+			default:
+				pc = o.jump - 1
+			}
+		case opBlend:
+			a, b := values[top-1], values[top]
+			t, reverse := values[top-3], values[top-2]
+			top -= 3
+			if reverse != 0.0 {
+				values[top] = lerp(b, a, t)
+			} else {
+				values[top] = lerp(a, b, t)
+			}
+		case opJump:
+			pc = o.jump - 1
+		}
+	}
+	if top < 0 {
+		return 0.0
+	}
+	return values[top]
+}
@@ -0,0 +1,58 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestCompile2DExact makes sure that compiled pipelines return exactly the
+// values of the modules they were compiled from.
+func TestCompile2DExact(t *testing.T) {
+	perlin := NewPerlinGenerator(rand.New(rand.NewSource(1)))
+	simplex := NewOpenSimplexGenerator(rand.New(rand.NewSource(2)))
+	ridgedSource := NewPerlinGeneratorWithQuality(rand.New(rand.NewSource(3)), QualityBest)
+
+	fbm := NewFBMGenerator2D(&perlin, 5, 0.5, 2.0, 1.3)
+	billow := NewBillow2D(&simplex, 4, 0.6, 2.1, 0.9)
+	ridged := NewRidgedGenerator2D(&ridgedSource, 6, 2.0, 1.1, 1.0, 2.0, 1.0)
+	scaled := NewScale2D(&billow, 0.75, 0.1, -0.8, 0.8)
+	fbmOfScaled := NewFBMGenerator2D(&scaled, 3, 0.5, 2.0, 0.7)
+	turbulence := NewTurbulence2D(&ridged, &fbm, &billow, 2.0, 0.25)
+	hard := NewSelect2D(&fbmOfScaled, &turbulence, &fbm, -0.2, 0.3, 0.0)
+	soft := NewSelect2D(&hard, &ridged, &simplex, -0.1, 0.4, 0.15)
+	nested := NewTurbulence2D(&soft, &perlin, &scaled, 1.5, 0.1)
+
+	pipelines := []struct {
+		name   string
+		module NoiseyGet2D
+	}{
+		{"fbm", &fbm},
+		{"billow", &billow},
+		{"ridged", &ridged},
+		{"scale", &scaled},
+		{"fbm of scale", &fbmOfScaled},
+		{"turbulence", &turbulence},
+		{"select", &hard},
+		{"select with falloff", &soft},
+		{"nested", &nested},
+	}
+	for _, p := range pipelines {
+		program, err := Compile2D(p.module)
+		if err != nil {
+			t.Fatalf("%s: %v", p.name, err)
+		}
+		for y := 0; y < 40; y++ {
+			for x := 0; x < 40; x++ {
+				fx, fy := float64(x)*0.173-3.1, float64(y)*0.219-4.3
+				want, got := p.module.Get2D(fx, fy), program.Get2D(fx, fy)
+				if math.Float64bits(want) != math.Float64bits(got) {
+					t.Fatalf("%s: Get2D(%v, %v) returned %v; the pipeline returned %v", p.name, fx, fy, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module composites baked NoiseMaps as layers with the blend modes of an
+image editor, so terrain can be assembled from separate maps:
+
+	terrain, err := noisey.Composite(&base, []noisey.MapLayer{
+		{Map: &mountains, Mode: noisey.BlendMax, Opacity: 1.0, Mask: &ranges},
+		{Map: &detail, Mode: noisey.BlendOverlay, Opacity: 0.3},
+	})
+
+Layers are blended onto the base in order. Every layer is first combined
+with the map below it by its Mode, and the result is then faded in by
+Opacity times the layer's Mask at each grid point; a layer without a Mask is
+faded in evenly. Masks are clamped to [0, 1].
+
+Normal, Add, Min and Max work on values of any range, but Multiply, Screen
+and Overlay expect values in [0, 1] as they do in image editors, so maps of
+noise in [-1, 1] should be Normalize()d to [0, 1] first.
+
+All of the maps have to have the same size.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// BlendMode selects how a layer is combined with the map below it.
+type BlendMode int
+
+const (
+	BlendNormal   BlendMode = iota // the layer replaces the map
+	BlendMultiply                  // the product of the two, which darkens
+	BlendScreen                    // the inverse of the product of the inverses, which lightens
+	BlendOverlay                   // Multiply where the map is below 0.5 and Screen above, which adds contrast
+	BlendAdd                       // the sum of the two
+	BlendMin                       // the lower of the two
+	BlendMax                       // the higher of the two
+)
+
+// blendModeNames are the names of the blend modes.
+var blendModeNames = []string{"BlendNormal", "BlendMultiply", "BlendScreen", "BlendOverlay", "BlendAdd", "BlendMin", "BlendMax"}
+
+// String returns the name of the blend mode.
+func (m BlendMode) String() string {
+	if m >= 0 && int(m) < len(blendModeNames) {
+		return blendModeNames[m]
+	}
+	return fmt.Sprintf("BlendMode(%d)", int(m))
+}
+
+// blend combines the value a of the map below with the value b of the layer.
+func (m BlendMode) blend(a float64, b float64) float64 {
+	switch m {
+	case BlendMultiply:
+		return a * b
+	case BlendScreen:
+		return 1.0 - (1.0-a)*(1.0-b)
+	case BlendOverlay:
+		if a < 0.5 {
+			return 2.0 * a * b
+		}
+		return 1.0 - 2.0*(1.0-a)*(1.0-b)
+	case BlendAdd:
+		return a + b
+	case BlendMin:
+		return math.Min(a, b)
+	case BlendMax:
+		return math.Max(a, b)
+	}
+	return b
+}
+
+// MapLayer is one layer of a Composite.
+type MapLayer struct {
+	Map     *NoiseMap // the values of the layer
+	Mode    BlendMode // how the layer is combined with the maps below it
+	Opacity float64   // how much of the layer is faded in [0, 1]
+	Mask    *NoiseMap // the optional map of where the layer is faded in [0, 1]
+}
+
+// String returns a description of the layer.
+func (l *MapLayer) String() string {
+	var m, mask interface{}
+	if l.Map != nil {
+		m = l.Map
+	}
+	if l.Mask != nil {
+		mask = l.Mask
+	}
+	return fmt.Sprintf("MapLayer{Mode: %v, Opacity: %v, Map: %s, Mask: %s}", l.Mode, l.Opacity, describeModule(m), describeModule(mask))
+}
+
+// Blend blends the layer onto the map in place.
+func (nm *NoiseMap) Blend(layer MapLayer) error {
+	if layer.Map == nil {
+		return fmt.Errorf("Unable to blend a layer without a Map.\n")
+	}
+	if err := nm.checkSameSize(layer.Map); err != nil {
+		return err
+	}
+	if layer.Mask != nil {
+		if err := nm.checkSameSize(layer.Mask); err != nil {
+			return err
+		}
+	}
+	for i, b := range layer.Map.Values {
+		t := layer.Opacity
+		if layer.Mask != nil {
+			t *= math.Max(0.0, math.Min(1.0, layer.Mask.Values[i]))
+		}
+		if t == 0.0 {
+			continue
+		}
+		a := nm.Values[i]
+		nm.Values[i] = lerp(a, layer.Mode.blend(a, b), t)
+	}
+	return nil
+}
+
+// Composite returns a new map of the layers blended onto a copy of base in order.
+func Composite(base *NoiseMap, layers []MapLayer) (NoiseMap, error) {
+	nm := base.Clone()
+	for i, layer := range layers {
+		if err := nm.Blend(layer); err != nil {
+			return NoiseMap{}, fmt.Errorf("Unable to composite layer %d: %v", i, err)
+		}
+	}
+	return nm, nil
+}
@@ -0,0 +1,162 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module extracts iso-contours from a NoiseMap with marching squares, for
+example to get vector coastlines at sea level:
+
+	coastlines := heights.Contours(0.0)
+	for _, c := range coastlines {
+		// c.Points is a closed polyline in the coordinates of heights.Bounds
+	}
+
+The area outside of the map is treated as being below every level so that
+all contours are closed; contours that reach the edge of the map follow it.
+Each contour is wound so that the values at or above its level are on the
+right hand side when the Y axis points down, which is clockwise around
+islands and counter-clockwise around lakes.
+
+*/
+
+import "math"
+
+// Contour is a closed polyline at an iso level of a NoiseMap. The last
+// point connects back to the first one.
+type Contour struct {
+	Level  float64
+	Points []Vec2f
+}
+
+// contourSegment is a directed line segment between two cell edges.
+type contourSegment struct {
+	from, to int
+}
+
+// Contours returns the closed contours of the map at each of the levels.
+func (nm *NoiseMap) Contours(levels ...float64) []Contour {
+	var contours []Contour
+	if nm.Width < 1 || nm.Height < 1 || len(nm.Values) < nm.Width*nm.Height {
+		return contours
+	}
+	for _, level := range levels {
+		contours = append(contours, nm.contoursAt(level)...)
+	}
+	return contours
+}
+
+func (nm *NoiseMap) contoursAt(level float64) []Contour {
+	// the grid is padded by one vertex on each side that is below every level
+	pw, ph := nm.Width+2, nm.Height+2
+	value := func(vx, vy int) float64 {
+		x, y := vx-1, vy-1
+		if x < 0 || y < 0 || x >= nm.Width || y >= nm.Height {
+			return math.Inf(-1)
+		}
+		return nm.Values[y*nm.Width+x]
+	}
+
+	// each edge is keyed by the vertex it starts at and whether it's horizontal or vertical
+	hEdge := func(vx, vy int) int { return (vy*pw + vx) * 2 }
+	vEdge := func(vx, vy int) int { return (vy*pw+vx)*2 + 1 }
+
+	// points holds the position of the crossing on each edge in grid coordinates
+	points := make(map[int]Vec2f)
+	crossing := func(key, ax, ay, bx, by int) {
+		if _, ok := points[key]; ok {
+			return
+		}
+		a, b := value(ax, ay), value(bx, by)
+		t := (level - a) / (b - a)
+		if math.IsNaN(t) {
+			t = 0.0
+		}
+		t = math.Max(0.0, math.Min(1.0, t))
+		if math.IsInf(a, -1) {
+			t = 1.0
+		}
+		points[key] = Vec2f{float64(ax-1) + float64(bx-ax)*t, float64(ay-1) + float64(by-ay)*t}
+	}
+
+	next := make(map[int]int)
+	var starts []int
+	for cy := 0; cy < ph-1; cy++ {
+		for cx := 0; cx < pw-1; cx++ {
+			// the corners and edges of the cell in clockwise order starting at the top left
+			corners := [4]float64{value(cx, cy), value(cx+1, cy), value(cx+1, cy+1), value(cx, cy+1)}
+			edges := [4]int{hEdge(cx, cy), vEdge(cx+1, cy), hEdge(cx, cy+1), vEdge(cx, cy)}
+			var above [4]bool
+			count := 0
+			for i, c := range corners {
+				above[i] = c >= level
+				if above[i] {
+					count++
+				}
+			}
+			if count == 0 || count == 4 {
+				continue
+			}
+
+			crossing(edges[0], cx, cy, cx+1, cy)
+			crossing(edges[1], cx+1, cy, cx+1, cy+1)
+			crossing(edges[2], cx, cy+1, cx+1, cy+1)
+			crossing(edges[3], cx, cy, cx, cy+1)
+
+			// an edge is an exit if it goes from above to below clockwise and
+			// each segment runs from an exit to an entry
+			var segs []contourSegment
+			if count == 2 && above[0] == above[2] {
+				// a saddle is resolved by the average value at the center of the cell
+				center := (corners[0] + corners[1] + corners[2] + corners[3]) * 0.25
+				for i := 0; i < 4; i++ {
+					prev := (i + 3) % 4
+					if above[i] == (center < level) {
+						// cut off corner i
+						if above[i] {
+							segs = append(segs, contourSegment{edges[i], edges[prev]})
+						} else {
+							segs = append(segs, contourSegment{edges[prev], edges[i]})
+						}
+					}
+				}
+			} else {
+				var exit, entry int
+				for i := 0; i < 4; i++ {
+					j := (i + 1) % 4
+					if above[i] && !above[j] {
+						exit = edges[i]
+					} else if !above[i] && above[j] {
+						entry = edges[i]
+					}
+				}
+				segs = append(segs, contourSegment{exit, entry})
+			}
+
+			for _, s := range segs {
+				next[s.from] = s.to
+				starts = append(starts, s.from)
+			}
+		}
+	}
+
+	// chain the segments into closed polylines
+	xScale := (nm.Bounds.MaxX - nm.Bounds.MinX) / float64(nm.Width)
+	yScale := (nm.Bounds.MaxY - nm.Bounds.MinY) / float64(nm.Height)
+	var contours []Contour
+	used := make(map[int]bool)
+	for _, start := range starts {
+		if used[start] {
+			continue
+		}
+		c := Contour{Level: level}
+		for e := start; !used[e]; e = next[e] {
+			used[e] = true
+			p := points[e]
+			c.Points = append(c.Points, Vec2f{nm.Bounds.MinX + p.X*xScale, nm.Bounds.MinY + p.Y*yScale})
+		}
+		contours = append(contours, c)
+	}
+	return contours
+}
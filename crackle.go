@@ -0,0 +1,126 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes crack patterns like dried mud, crazed ceramic glaze or the
+crust of cooling lava from the edges between Worley cells. The difference of
+the distances to the two closest feature points, F2-F1, is 0.0 on the edges
+between cells and grows towards their centers, so thresholding it leaves thin
+lines along the edges:
+
+	cells := noisey.NewWorleyGenerator(rng)
+	crackle := noisey.NewCrackle2D(&cells, 0.5, 0.05)
+	crackle.Warp, crackle.Jaggedness = &fbm, 0.1
+
+The result is 1.0 in the middle of a crack and falls smoothly to -1.0 at
+Width from the edge, where the plates between the cracks are. Width and
+Jaggedness are measured in cells. F2-F1 grows about twice as fast as the
+distance from the edge, so Width is about the full width of a crack.
+
+The Warp generator displaces the coordinates before the cells are found,
+which turns the straight edges of the cells into jagged cracks. It's sampled
+at the coordinates in cells, so its frequency sets how many kinks each edge
+gets.
+
+The Return of the Worley generator is ignored; F2-F1 is always used.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Crackle2D is a module that returns cracks along the edges of Worley cells.
+type Crackle2D struct {
+	Cells      *WorleyGenerator // the cells the cracks run between
+	Warp       NoiseyGet2D      // the optional noise that makes the cracks jagged
+	CellSize   float64          // the world size of a cell
+	Width      float64          // the width of the cracks in cells
+	Jaggedness float64          // how far in cells Warp displaces the coordinates
+}
+
+// NewCrackle2D creates a new crack pattern module without a Warp.
+func NewCrackle2D(cells *WorleyGenerator, cellSize float64, width float64) (crackle Crackle2D) {
+	crackle.Cells = cells
+	crackle.CellSize = cellSize
+	crackle.Width = width
+	return
+}
+
+// Clone2D returns a deep copy of the module, its cells and its Warp.
+func (crackle *Crackle2D) Clone2D() NoiseyGet2D {
+	c := *crackle
+	if crackle.Cells != nil {
+		c.Cells = crackle.Cells.Clone()
+	}
+	c.Warp = DeepCopy2D(crackle.Warp)
+	return &c
+}
+
+// SetCellSize validates and changes the cell size.
+func (crackle *Crackle2D) SetCellSize(cellSize float64) error {
+	if err := validateParam(GenCrackle2D, "CellSize", cellSize); err != nil {
+		return err
+	}
+	crackle.CellSize = cellSize
+	return nil
+}
+
+// SetWidth validates and changes the crack width.
+func (crackle *Crackle2D) SetWidth(width float64) error {
+	if err := validateParam(GenCrackle2D, "Width", width); err != nil {
+		return err
+	}
+	crackle.Width = width
+	return nil
+}
+
+// SetJaggedness validates and changes the jaggedness.
+func (crackle *Crackle2D) SetJaggedness(jaggedness float64) error {
+	if err := validateParam(GenCrackle2D, "Jaggedness", jaggedness); err != nil {
+		return err
+	}
+	crackle.Jaggedness = jaggedness
+	return nil
+}
+
+// String returns a description of the module, its cells and its Warp.
+func (crackle *Crackle2D) String() string {
+	var cells interface{}
+	if crackle.Cells != nil {
+		cells = crackle.Cells
+	}
+	return fmt.Sprintf("Crackle2D{CellSize: %v, Width: %v, Jaggedness: %v, Cells: %s, Warp: %s}",
+		crackle.CellSize, crackle.Width, crackle.Jaggedness, describeModule(cells), describeModule(crackle.Warp))
+}
+
+// Get2D calculates the crack value at the coordinate.
+func (crackle *Crackle2D) Get2D(x float64, y float64) float64 {
+	if crackle.CellSize <= 0.0 {
+		return -1.0
+	}
+	x /= crackle.CellSize
+	y /= crackle.CellSize
+	if crackle.Warp != nil && crackle.Jaggedness != 0.0 {
+		dx := crackle.Warp.Get2D(x, y) * crackle.Jaggedness
+		dy := crackle.Warp.Get2D(x+turbulenceOffset, y+turbulenceOffset) * crackle.Jaggedness
+		x, y = x+dx, y+dy
+	}
+
+	f1, f2 := crackle.Cells.distances2D(x, y)
+	d := math.Sqrt(f2) - math.Sqrt(f1)
+	if crackle.Width <= 0.0 || d >= crackle.Width {
+		return -1.0
+	}
+	t := 1.0 - d/crackle.Width
+	return t*t*(3.0-2.0*t)*2.0 - 1.0
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (crackle *Crackle2D) Range2D() (min float64, max float64) {
+	return -1.0, 1.0
+}
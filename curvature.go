@@ -0,0 +1,147 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module measures how a heightmap bends, for texturing that gathers dirt
+in crevices and wears away ridges:
+
+	curvature, err := noisey.CurvatureMap(&heightmap, 200.0, noisey.CurvatureMean)
+	cavity, err := noisey.CavityMap(&heightmap, 4)
+
+CurvatureMap fits the 3x3 neighborhood of every grid point with the
+derivatives of Zevenbergen and Thorne, with the heights multiplied by
+heightScale like SlopeMap, and returns one of three curvatures in units of
+1/world units:
+
+* CurvatureMean is the average bending in all directions.
+* CurvatureProfile is the bending in the direction of the slope, which
+  speeds up or slows down water flowing downhill.
+* CurvaturePlan is the bending across the slope, which spreads or gathers
+  water flowing downhill.
+
+All of them are positive where the ground is convex, like ridges and peaks,
+and negative where it's concave, like valleys and crevices. Profile and plan
+curvature are 0.0 on flat ground where there is no downhill direction.
+
+CavityMap is a cheaper approximation that's good enough for texturing: the
+height of every grid point above the average height of the square of grid
+points radius away around it, in the units of the heightmap. It's positive
+on convexities and negative in cavities, and the radius sets the size of the
+features it finds. AutoLevels() turns either map into a mask.
+
+Reference material:
+* Zevenbergen and Thorne, "Quantitative analysis of land surface topography", 1987
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// CurvatureKind selects which curvature a CurvatureMap measures.
+type CurvatureKind int
+
+const (
+	CurvatureMean    CurvatureKind = iota // the average bending in all directions
+	CurvatureProfile                      // the bending along the slope
+	CurvaturePlan                         // the bending across the slope
+)
+
+// String returns the name of the curvature.
+func (k CurvatureKind) String() string {
+	switch k {
+	case CurvatureMean:
+		return "CurvatureMean"
+	case CurvatureProfile:
+		return "CurvatureProfile"
+	case CurvaturePlan:
+		return "CurvaturePlan"
+	}
+	return fmt.Sprintf("CurvatureKind(%d)", int(k))
+}
+
+// flatSlope is the squared slope below which the ground is taken as flat.
+const flatSlope = 1e-12
+
+// CurvatureMap returns a map of the curvature of the heightmap at each grid point.
+func CurvatureMap(heightmap *NoiseMap, heightScale float64, kind CurvatureKind) (NoiseMap, error) {
+	if err := checkHeightmap(heightmap, "curvature"); err != nil {
+		return NoiseMap{}, err
+	}
+	b := heightmap.Bounds
+	dx := (b.MaxX - b.MinX) / float64(heightmap.Width)
+	dy := (b.MaxY - b.MinY) / float64(heightmap.Height)
+	if dx <= 0.0 || dy <= 0.0 {
+		return NoiseMap{}, fmt.Errorf("Unable to make a curvature map for a heightmap over the Bounds %v.\n", b)
+	}
+
+	curvature := NewNoiseMap(heightmap.Width, heightmap.Height, b)
+	for y := 0; y < heightmap.Height; y++ {
+		for x := 0; x < heightmap.Width; x++ {
+			// the neighbors are clamped at the edges
+			z := func(ox int, oy int) float64 { return heightmap.Get(x+ox, y+oy) * heightScale }
+			c := z(0, 0)
+			p := (z(1, 0) - z(-1, 0)) / (2.0 * dx)
+			q := (z(0, 1) - z(0, -1)) / (2.0 * dy)
+			r := (z(1, 0) - 2.0*c + z(-1, 0)) / (dx * dx)
+			t := (z(0, 1) - 2.0*c + z(0, -1)) / (dy * dy)
+			s := (z(1, 1) - z(-1, 1) - z(1, -1) + z(-1, -1)) / (4.0 * dx * dy)
+
+			// below flatSlope the direction of the slope is only rounding error
+			g := p*p + q*q
+			v := 0.0
+			switch kind {
+			case CurvatureProfile:
+				if g > flatSlope {
+					v = -(p*p*r + 2.0*p*q*s + q*q*t) / (g * math.Pow(1.0+g, 1.5))
+				}
+			case CurvaturePlan:
+				if g > flatSlope {
+					v = -(q*q*r - 2.0*p*q*s + p*p*t) / math.Pow(g, 1.5)
+				}
+			default:
+				v = -((1.0+q*q)*r - 2.0*p*q*s + (1.0+p*p)*t) / (2.0 * math.Pow(1.0+g, 1.5))
+			}
+			curvature.Values[y*heightmap.Width+x] = v
+		}
+	}
+	return curvature, nil
+}
+
+// CavityMap returns a map of how far each grid point of the heightmap is
+// above the average of the grid points within radius of it.
+func CavityMap(heightmap *NoiseMap, radius int) (NoiseMap, error) {
+	if err := checkHeightmap(heightmap, "cavity"); err != nil {
+		return NoiseMap{}, err
+	}
+	if radius <= 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to make a cavity map with a radius of %d.\n", radius)
+	}
+
+	// a summed area table makes every average cost the same for any radius
+	w, h := heightmap.Width, heightmap.Height
+	sums := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		row := 0.0
+		for x := 0; x < w; x++ {
+			row += heightmap.Values[y*w+x]
+			sums[(y+1)*(w+1)+x+1] = sums[y*(w+1)+x+1] + row
+		}
+	}
+
+	cavity := NewNoiseMap(w, h, heightmap.Bounds)
+	for y := 0; y < h; y++ {
+		y0, y1 := maxInt(0, y-radius), minInt(h, y+radius+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := maxInt(0, x-radius), minInt(w, x+radius+1)
+			sum := sums[y1*(w+1)+x1] - sums[y0*(w+1)+x1] - sums[y1*(w+1)+x0] + sums[y0*(w+1)+x0]
+			mean := sum / float64((x1-x0)*(y1-y0))
+			cavity.Values[y*w+x] = heightmap.Values[y*w+x] - mean
+		}
+	}
+	return cavity, nil
+}
@@ -0,0 +1,93 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module combines a 2D surface heightmap with 3D density noise to make
+voxel terrain that can have overhangs, arches and floating islands. The Y
+axis points up and the surface is sampled at (x, z).
+
+The density at a point is the 3D noise scaled by Amplitude plus a gradient
+that is positive below the surface and negative above it:
+
+	density = Amplitude*Density(x, y, z) + Squash*(surfaceY - y)
+	surfaceY = BaseHeight + HeightScale*Surface(x, z)
+
+Points with a density greater than 0.0 are solid. The gradient is measured in
+world units from the surface height instead of from a fixed level, so the
+terrain follows the surface everywhere, and Squash sets how far from the
+surface the 3D noise can still carve or add material: Amplitude/Squash world
+units. Large values make terrain that looks like the heightmap while small
+values make wild overhangs.
+
+	density := noisey.NewDensityTerrain3D(&surface, &noise3d, 64.0, 32.0, 1.0, 8.0)
+	if density.Solid(x, y, z) {
+		// place a block
+	}
+
+*/
+
+import "fmt"
+
+// DensityTerrain3D is a module that returns the density of voxel terrain
+// where values greater than 0.0 are solid.
+type DensityTerrain3D struct {
+	Surface     NoiseyGet2D // the surface height sampled at (x, z)
+	Density     NoiseyGet3D // the 3D noise that carves and adds material around the surface
+	BaseHeight  float64     // the world Y of a surface value of 0.0
+	HeightScale float64     // the world units in Y per unit of surface value
+	Squash      float64     // how quickly the density grows with the distance from the surface
+	Amplitude   float64     // what the 3D noise is scaled by
+}
+
+// NewDensityTerrain3D creates a new density terrain module.
+func NewDensityTerrain3D(surface NoiseyGet2D, density NoiseyGet3D, baseHeight float64, heightScale float64, squash float64, amplitude float64) (dt DensityTerrain3D) {
+	dt.Surface = surface
+	dt.Density = density
+	dt.BaseHeight = baseHeight
+	dt.HeightScale = heightScale
+	dt.Squash = squash
+	dt.Amplitude = amplitude
+	return
+}
+
+// Clone3D returns a deep copy of the module and its sources.
+func (dt *DensityTerrain3D) Clone3D() NoiseyGet3D {
+	c := *dt
+	c.Surface = DeepCopy2D(dt.Surface)
+	c.Density = DeepCopy3D(dt.Density)
+	return &c
+}
+
+// String returns a description of the module and its sources.
+func (dt *DensityTerrain3D) String() string {
+	return fmt.Sprintf("DensityTerrain3D{BaseHeight: %v, HeightScale: %v, Squash: %v, Amplitude: %v, Surface: %s, Density: %s}",
+		dt.BaseHeight, dt.HeightScale, dt.Squash, dt.Amplitude, describeModule(dt.Surface), describeModule(dt.Density))
+}
+
+// SurfaceHeight returns the world Y of the surface at (x, z).
+func (dt *DensityTerrain3D) SurfaceHeight(x float64, z float64) float64 {
+	return dt.BaseHeight + dt.HeightScale*dt.Surface.Get2D(x, z)
+}
+
+// Get3D calculates the density at the coordinate.
+func (dt *DensityTerrain3D) Get3D(x float64, y float64, z float64) float64 {
+	return dt.Amplitude*dt.Density.Get3D(x, y, z) + dt.Squash*(dt.SurfaceHeight(x, z)-y)
+}
+
+// Range3D returns the bounds of the values of Get3D(). Unless Squash is 0.0
+// the values grow without bound away from the surface.
+func (dt *DensityTerrain3D) Range3D() (min float64, max float64) {
+	if dt.Squash != 0.0 {
+		return unboundedRange()
+	}
+	min, max = ValueRange3D(dt.Density)
+	return scaleRange(min, max, dt.Amplitude)
+}
+
+// Solid returns true if the density at the coordinate is greater than 0.0.
+func (dt *DensityTerrain3D) Solid(x float64, y float64, z float64) bool {
+	return dt.Get3D(x, y, z) > 0.0
+}
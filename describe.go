@@ -0,0 +1,215 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the helpers used by the String() methods of the noise
+modules and the Describe() function that dumps a whole pipeline as an
+indented tree.
+
+Every module formats itself as its type name followed by its parameters and
+the modules it uses in braces, for example:
+
+	FBMGenerator2D{Octaves: 5, Persistence: 0.25, Lacunarity: 2, Frequency: 1, NoiseMaker: PerlinGenerator{}}
+
+Describe() doesn't parse those strings but walks the exported fields of the
+modules, so parameters whose values contain braces or commas, like bounds or
+quoted names, stay on their own lines. Modules without exported fields are
+written with their String().
+
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// describeModule returns the String() of a module if it implements
+// fmt.Stringer; otherwise just the type name is returned.
+func describeModule(m interface{}) string {
+	if m == nil {
+		return "nil"
+	}
+	if s, ok := m.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
+
+// field is a parameter of a module, or a module that it uses, under the
+// name of the field it is kept in.
+type field struct {
+	name   string
+	value  string      // the parameter, if module is nil
+	module interface{} // the module that is used
+}
+
+// isModule returns true if v is a noise module of any dimension.
+func isModule(v interface{}) bool {
+	switch v.(type) {
+	case NoiseyGet1D, NoiseyGet2D, NoiseyGet3D, NoiseyGet4D:
+		return true
+	}
+	return false
+}
+
+// moduleTypes are the interfaces of the modules, for the fields that may hold one.
+var moduleTypes = []reflect.Type{
+	reflect.TypeOf((*NoiseyGet1D)(nil)).Elem(),
+	reflect.TypeOf((*NoiseyGet2D)(nil)).Elem(),
+	reflect.TypeOf((*NoiseyGet3D)(nil)).Elem(),
+	reflect.TypeOf((*NoiseyGet4D)(nil)).Elem(),
+}
+
+// holdsModule returns true if a field of type t holds modules.
+func holdsModule(t reflect.Type) bool {
+	for _, m := range moduleTypes {
+		if t.Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameModule returns true if a and b are the same module.
+func sameModule(a interface{}, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
+
+// structFields appends the exported fields of a module, including those of
+// the structs it embeds. Modules kept in slices are named by their index.
+// Long slices, like permutation tables, are only counted, and the fields
+// that aren't values, like the random sources, are left out.
+func structFields(v reflect.Value, fields []field) []field {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			fields = structFields(fv, fields)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.Interface, reflect.Ptr:
+			switch {
+			case !fv.IsNil() && isModule(fv.Interface()):
+				fields = append(fields, field{name: f.Name, module: fv.Interface()})
+			case fv.IsNil() && holdsModule(f.Type):
+				fields = append(fields, field{name: f.Name, value: "nil"})
+			}
+		case reflect.Slice, reflect.Array:
+			if holdsModule(f.Type.Elem()) {
+				for j := 0; j < fv.Len(); j++ {
+					if e := fv.Index(j); !(e.Kind() == reflect.Interface && e.IsNil()) {
+						fields = append(fields, field{name: fmt.Sprintf("%s[%d]", f.Name, j), module: e.Interface()})
+					}
+				}
+			} else if fv.Len() > 16 {
+				fields = append(fields, field{name: f.Name, value: fmt.Sprintf("[%d values]", fv.Len())})
+			} else {
+				fields = append(fields, field{name: f.Name, value: fmt.Sprint(fv.Interface())})
+			}
+		case reflect.Map, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		default:
+			fields = append(fields, field{name: f.Name, value: fmt.Sprint(fv.Interface())})
+		}
+	}
+	return fields
+}
+
+// children returns the parameters of a module and the modules it uses, or
+// false if it isn't a struct with exported fields and can only be described
+// by its String(). The module held by a Handle2D or Handle3D is its Source,
+// and a module kept in more than one field, like the 2D and 3D sources of a
+// NaNCheck3D, is only returned once.
+func children(m interface{}) ([]field, bool) {
+	var held interface{}
+	switch h := m.(type) {
+	case *Handle2D:
+		held = h.Load()
+	case *Handle3D:
+		held = h.Load()
+	default:
+		return moduleFields(m)
+	}
+	if held == nil {
+		return []field{{name: "Source", value: "nil"}}, true
+	}
+	return []field{{name: "Source", module: held}}, true
+}
+
+// moduleFields returns the fields of a module for children().
+func moduleFields(m interface{}) ([]field, bool) {
+
+	v := reflect.ValueOf(m)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	var unique []field
+	for _, f := range structFields(v, nil) {
+		dup := false
+		for _, u := range unique {
+			if f.module != nil && u.module != nil && sameModule(f.module, u.module) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			unique = append(unique, f)
+		}
+	}
+	return unique, len(unique) > 0
+}
+
+// Describe returns a multi-line tree dump of a module and all of the modules
+// it uses with one parameter per line. The modules are walked through their
+// fields, so the values of the parameters can be anything.
+func Describe(module interface{}) string {
+	var b bytes.Buffer
+	describeTree(&b, module, 0, nil)
+	return b.String()
+}
+
+// describeTree writes a module and the modules it uses at a depth of
+// indentation. A module that comes up again inside itself, which can happen
+// through handles, is written as {...}.
+func describeTree(b *bytes.Buffer, m interface{}, depth int, path []interface{}) {
+	fields, ok := children(m)
+	if !ok {
+		b.WriteString(describeModule(m))
+		return
+	}
+	name := reflect.Indirect(reflect.ValueOf(m)).Type().Name()
+	for _, p := range path {
+		if sameModule(p, m) {
+			b.WriteString(name + "{...}")
+			return
+		}
+	}
+	path = append(path, m)
+
+	b.WriteString(name + "{")
+	indent := "\n" + strings.Repeat("  ", depth+1)
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(indent + f.name + ": ")
+		if f.module == nil {
+			b.WriteString(f.value)
+		} else {
+			describeTree(b, f.module, depth+1, path)
+		}
+	}
+	b.WriteString("\n" + strings.Repeat("  ", depth) + "}")
+}
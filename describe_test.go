@@ -0,0 +1,37 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDescribe makes sure that the tree of a pipeline has one line per
+// parameter even when the values contain commas and braces.
+func TestDescribe(t *testing.T) {
+	perlin := NewPerlinSeeded(1)
+	fbm := NewFBMGenerator2D(&perlin, 2, 0.5, 2.0, 1.0)
+	nm := NewNoiseMap(4, 4, Builder2DBounds{MinX: 0, MinY: 0, MaxX: 1, MaxY: 1})
+	sel := NewSelect2D(&fbm, &nm, nil, -0.5, 0.5, 0.0)
+	checked := CheckNaN2D(`generator "a, {b}"`, &sel, nil)
+	handle := NewHandle2D(checked)
+
+	lines := strings.Split(Describe(handle), "\n")
+	want := map[string]string{
+		`    Name: generator "a, {b}",`: "the name",
+		`        Bounds: {0 0 1 1},`:    "the bounds of the map",
+		`      Control: nil,`:           "the missing control",
+		`        Octaves: 2,`:           "the octaves of the FBM",
+	}
+	for _, l := range lines {
+		delete(want, l)
+	}
+	for _, what := range want {
+		t.Errorf("%s is missing from:\n%s", what, strings.Join(lines, "\n"))
+	}
+	if lines[0] != "Handle2D{" || lines[len(lines)-1] != "}" {
+		t.Errorf("got the tree:\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,252 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module performs particle based hydraulic erosion on a built heightmap.
+Droplets of water are dropped at random points on the map and roll downhill,
+eroding material where they speed up and depositing it where they slow down
+or fill up with sediment.
+
+	builder := noisey.NewBuilder2D(&fbm, 256, 256)
+	builder.Bounds = noisey.Builder2DBounds{0.0, 0.0, 4.0, 4.0}
+	builder.Build()
+
+	heights := builder.GetNoiseMap()
+	erosion := noisey.NewHydraulicErosion(rand.New(rand.NewSource(1)))
+	erosion.TrackWetness = true
+	result, err := erosion.Erode(&heights)
+
+Reference material:
+* Hans Theobald Beyer's "Implementation of a method for hydraulic erosion": https://www.firespark.de/resources/downloads/implementation%20of%20a%20methode%20for%20hydraulic%20erosion.pdf
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// HydraulicErosion contains the parameters for particle based hydraulic erosion.
+type HydraulicErosion struct {
+	Rng                 RandomSource // the random number generator used to place droplets
+	Droplets            int          // the number of droplets to simulate
+	MaxLifetime         int          // the maximum number of steps a droplet takes
+	Inertia             float64      // how much a droplet keeps its direction instead of following the slope [0, 1]
+	SedimentCapacity    float64      // a multiplier for how much sediment a droplet can carry
+	MinSedimentCapacity float64      // the lowest capacity a droplet can have so that flat areas still erode
+	ErodeSpeed          float64      // the fraction of free capacity that is eroded on each step [0, 1]
+	DepositSpeed        float64      // the fraction of surplus sediment that is deposited on each step [0, 1]
+	EvaporateSpeed      float64      // the fraction of water that evaporates on each step [0, 1]
+	Gravity             float64      // how quickly droplets accelerate downhill
+	Radius              int          // the radius in grid points that erosion is spread over
+	InitialWater        float64      // the amount of water a droplet starts with
+	InitialSpeed        float64      // the speed a droplet starts with
+	TrackSediment       bool         // if true, Erode() returns a map of the deposited sediment
+	TrackWetness        bool         // if true, Erode() returns a map of where water flowed
+}
+
+// ErosionResult contains the maps produced by HydraulicErosion.Erode().
+type ErosionResult struct {
+	// Heightmap is the eroded heightmap
+	Heightmap NoiseMap
+
+	// Sediment is the amount of sediment deposited at each grid point; nil
+	// unless HydraulicErosion.TrackSediment was set
+	Sediment *NoiseMap
+
+	// Wetness is the total amount of water that passed over each grid point;
+	// nil unless HydraulicErosion.TrackWetness was set
+	Wetness *NoiseMap
+}
+
+// NewHydraulicErosion creates a new erosion state with parameters that work
+// well for a 256x256 heightmap with values in the range [-1, 1]. Droplets
+// should be scaled with the number of grid points for other sizes.
+func NewHydraulicErosion(rng RandomSource) (he HydraulicErosion) {
+	he.Rng = rng
+	he.Droplets = 50000
+	he.MaxLifetime = 30
+	he.Inertia = 0.05
+	he.SedimentCapacity = 4.0
+	he.MinSedimentCapacity = 0.01
+	he.ErodeSpeed = 0.3
+	he.DepositSpeed = 0.3
+	he.EvaporateSpeed = 0.01
+	he.Gravity = 4.0
+	he.Radius = 3
+	he.InitialWater = 1.0
+	he.InitialSpeed = 1.0
+	return
+}
+
+// String returns a description of the erosion parameters.
+func (he *HydraulicErosion) String() string {
+	return fmt.Sprintf("HydraulicErosion{Droplets: %v, MaxLifetime: %v, Inertia: %v, SedimentCapacity: %v, MinSedimentCapacity: %v, ErodeSpeed: %v, DepositSpeed: %v, EvaporateSpeed: %v, Gravity: %v, Radius: %v}",
+		he.Droplets, he.MaxLifetime, he.Inertia, he.SedimentCapacity, he.MinSedimentCapacity, he.ErodeSpeed, he.DepositSpeed, he.EvaporateSpeed, he.Gravity, he.Radius)
+}
+
+// erosionBrush is an offset from a grid point and the share of erosion it receives.
+type erosionBrush struct {
+	dx, dy int
+	weight float64
+}
+
+// newErosionBrush returns the points within radius of the center weighted by
+// how close they are to it.
+func newErosionBrush(radius int) []erosionBrush {
+	if radius < 1 {
+		return []erosionBrush{{0, 0, 1.0}}
+	}
+	var brush []erosionBrush
+	r := float64(radius)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			d := math.Sqrt(float64(dx*dx + dy*dy))
+			if d < r {
+				brush = append(brush, erosionBrush{dx, dy, r - d})
+			}
+		}
+	}
+	return brush
+}
+
+// heightAndGradient returns the bilinear interpolated height and gradient
+// of the map at the fractional grid coordinate (x, y).
+func heightAndGradient(nm *NoiseMap, x float64, y float64) (h float64, gx float64, gy float64) {
+	ix := int(x)
+	iy := int(y)
+	u := x - float64(ix)
+	v := y - float64(iy)
+
+	i := iy*nm.Width + ix
+	nw := nm.Values[i]
+	ne := nm.Values[i+1]
+	sw := nm.Values[i+nm.Width]
+	se := nm.Values[i+nm.Width+1]
+
+	gx = (ne-nw)*(1-v) + (se-sw)*v
+	gy = (sw-nw)*(1-u) + (se-ne)*u
+	h = nw*(1-u)*(1-v) + ne*u*(1-v) + sw*(1-u)*v + se*u*v
+	return
+}
+
+// Erode runs the simulation over a copy of heightmap and returns the eroded
+// copy along with any of the optional maps that were enabled. Heights that
+// are NaN or infinite are rejected with an error.
+func (he *HydraulicErosion) Erode(heightmap *NoiseMap) (result ErosionResult, err error) {
+	if he.Rng == nil {
+		return result, fmt.Errorf("Unable to erode the heightmap without a random source.\n")
+	}
+	if heightmap.Width < 2 || heightmap.Height < 2 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return result, fmt.Errorf("Unable to erode a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+	for i, v := range heightmap.Values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return result, fmt.Errorf("Unable to erode a heightmap with the height %v at (%d, %d).\n", v, i%heightmap.Width, i/heightmap.Width)
+		}
+	}
+
+	result.Heightmap = heightmap.Clone()
+	hm := &result.Heightmap
+	if he.TrackSediment {
+		sediment := NewNoiseMap(hm.Width, hm.Height, hm.Bounds)
+		result.Sediment = &sediment
+	}
+	if he.TrackWetness {
+		wetness := NewNoiseMap(hm.Width, hm.Height, hm.Bounds)
+		result.Wetness = &wetness
+	}
+
+	brush := newErosionBrush(he.Radius)
+	maxX := float64(hm.Width - 1)
+	maxY := float64(hm.Height - 1)
+
+	for d := 0; d < he.Droplets; d++ {
+		x := he.Rng.Float64() * maxX
+		y := he.Rng.Float64() * maxY
+		dirX, dirY := 0.0, 0.0
+		speed := he.InitialSpeed
+		water := he.InitialWater
+		sediment := 0.0
+
+		for step := 0; step < he.MaxLifetime; step++ {
+			ix := int(x)
+			iy := int(y)
+			u := x - float64(ix)
+			v := y - float64(iy)
+			if result.Wetness != nil {
+				result.Wetness.Values[iy*hm.Width+ix] += water
+			}
+
+			// steer the droplet down the slope, keeping some of its old direction
+			h, gx, gy := heightAndGradient(hm, x, y)
+			dirX = dirX*he.Inertia - gx*(1-he.Inertia)
+			dirY = dirY*he.Inertia - gy*(1-he.Inertia)
+			l := math.Sqrt(dirX*dirX + dirY*dirY)
+			if l == 0.0 {
+				break
+			}
+			dirX /= l
+			dirY /= l
+			x += dirX
+			y += dirY
+			if x < 0 || x >= maxX || y < 0 || y >= maxY {
+				break
+			}
+
+			newH, _, _ := heightAndGradient(hm, x, y)
+			deltaH := newH - h
+			capacity := math.Max(-deltaH*speed*water*he.SedimentCapacity, he.MinSedimentCapacity)
+
+			if sediment > capacity || deltaH > 0 {
+				// fill the pit the droplet climbed out of or drop the surplus
+				var amount float64
+				if deltaH > 0 {
+					amount = math.Min(deltaH, sediment)
+				} else {
+					amount = (sediment - capacity) * he.DepositSpeed
+				}
+				sediment -= amount
+
+				i := iy*hm.Width + ix
+				weights := [4]float64{(1 - u) * (1 - v), u * (1 - v), (1 - u) * v, u * v}
+				cells := [4]int{i, i + 1, i + hm.Width, i + hm.Width + 1}
+				for c, w := range weights {
+					hm.Values[cells[c]] += amount * w
+					if result.Sediment != nil {
+						result.Sediment.Values[cells[c]] += amount * w
+					}
+				}
+			} else {
+				// erode no more than the height difference and never below the
+				// new position of the droplet so that no pits are dug
+				amount := math.Min((capacity-sediment)*he.ErodeSpeed, -deltaH)
+
+				total := 0.0
+				for _, b := range brush {
+					bx, by := ix+b.dx, iy+b.dy
+					if bx >= 0 && bx < hm.Width && by >= 0 && by < hm.Height {
+						total += b.weight
+					}
+				}
+				for _, b := range brush {
+					bx, by := ix+b.dx, iy+b.dy
+					if bx >= 0 && bx < hm.Width && by >= 0 && by < hm.Height {
+						c := by*hm.Width + bx
+						e := math.Min(amount*b.weight/total, math.Max(0.0, hm.Values[c]-newH))
+						hm.Values[c] -= e
+						sediment += e
+					}
+				}
+			}
+
+			speed = math.Sqrt(math.Max(0.0, speed*speed-deltaH*he.Gravity))
+			water *= 1 - he.EvaporateSpeed
+		}
+	}
+
+	return result, nil
+}
@@ -32,6 +32,7 @@ import (
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
 	"github.com/tbogdala/noisey"
+	"github.com/tbogdala/noisey/interop/gltex"
 	"math"
 	"math/rand"
 )
@@ -47,22 +48,6 @@ func keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action,
 	}
 }
 
-// createTextureFromRGB makes an OpenGL texture and buffers the RGB data into it
-func createTextureFromRGB(rgb []byte, imageSize int32) (tex uint32) {
-	gl.GenTextures(1, &tex)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, tex)
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, imageSize, imageSize, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(rgb))
-
-	return tex
-}
-
 func generateNoiseImage(imageSize int, r noisey.RandomSource) []byte {
 	// create a new perlin noise generator
 	noiseGen := noisey.NewPerlinGenerator(r)
@@ -156,7 +141,10 @@ func main() {
 	// generate the noise and make a image
 	r := rand.New(rand.NewSource(int64(1)))
 	randomPixels := generateNoiseImage(512, r)
-	noiseTex := createTextureFromRGB(randomPixels, 512)
+	noiseTex, err := gltex.NewRGB(randomPixels, 512, 512, gltex.DefaultParams)
+	if err != nil {
+		panic("Failed to create the noise texture! " + err.Error())
+	}
 	plane.Tex0 = noiseTex
 
 	app.RenderLoop()
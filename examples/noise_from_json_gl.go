@@ -35,6 +35,7 @@ import (
 	glfw "github.com/go-gl/glfw/v3.1/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
 	"github.com/tbogdala/noisey"
+	"github.com/tbogdala/noisey/interop/gltex"
 	"io/ioutil"
 	"math"
 	"math/rand"
@@ -59,8 +60,7 @@ func keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action,
 		fmt.Println("Reloading noise bank from JSON file...")
 		loadJSONFile()
 		randomPixels := generateNoiseImage(imageSize)
-		gl.BindTexture(gl.TEXTURE_2D, noiseTex)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, imageSize, imageSize, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(randomPixels))
+		gltex.UpdateRGB(noiseTex, randomPixels, imageSize, imageSize)
 	}
 	if key == glfw.KeyC && action == glfw.Press {
 		colorizeEnabled = !colorizeEnabled
@@ -70,28 +70,11 @@ func keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action,
 			fmt.Println("Displaying noise as a grayscale image ...")
 		}
 		randomPixels := generateNoiseImage(imageSize)
-		gl.BindTexture(gl.TEXTURE_2D, noiseTex)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, imageSize, imageSize, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(randomPixels))
+		gltex.UpdateRGB(noiseTex, randomPixels, imageSize, imageSize)
 
 	}
 }
 
-// createTextureFromRGB makes an OpenGL texture and buffers the RGB data into it
-func createTextureFromRGB(rgb []byte, imageSize int32) (tex uint32) {
-	gl.GenTextures(1, &tex)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, tex)
-
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, imageSize, imageSize, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(rgb))
-
-	return tex
-}
-
 func generateNoiseImage(imageSize int32) []byte {
 	// create the fractal Brownian motion generator based on perlin
 	fbmPerlin := noiseBank.GetGenerator("basic")
@@ -216,7 +199,10 @@ func main() {
 
 	// generate the noise and make a image
 	randomPixels := generateNoiseImage(imageSize)
-	noiseTex = createTextureFromRGB(randomPixels, imageSize)
+	noiseTex, err = gltex.NewRGB(randomPixels, imageSize, imageSize, gltex.DefaultParams)
+	if err != nil {
+		panic("Failed to create the noise texture! " + err.Error())
+	}
 	plane.Tex0 = noiseTex
 
 	app.RenderLoop()
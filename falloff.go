@@ -0,0 +1,150 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes falloff masks that are 1.0 at a center point and fall off
+to 0.0 at a radius from it. Multiplying terrain by a falloff mask forces the
+terrain down towards sea level at the edges, which is the usual way to make
+islands.
+
+	falloff := noisey.NewFalloff2D(noisey.FalloffRadial, 0.0, 0.0, 1.0, 2.0)
+	island := noisey.NoiseFunc2D(func(x, y float64) float64 {
+		return fbm.Get2D(x, y)*falloff.Get2D(x, y)
+	})
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// FalloffShape determines how the distance from the center of a Falloff2D is measured.
+type FalloffShape int
+
+const (
+	// FalloffRadial makes round masks using the euclidean distance.
+	FalloffRadial FalloffShape = iota
+
+	// FalloffSquare makes square masks using the largest of the axis distances.
+	FalloffSquare
+
+	// FalloffDiamond makes diamond masks using the sum of the axis distances.
+	FalloffDiamond
+
+	// FalloffCustom uses the Falloff2D.Distance function. It's accepted in a
+	// NoiseJSON configuration, but the function can only be set in code, on
+	// the built module; until it is the mask is 0.0 everywhere.
+	FalloffCustom
+)
+
+// String returns the name of the shape.
+func (s FalloffShape) String() string {
+	switch s {
+	case FalloffRadial:
+		return "FalloffRadial"
+	case FalloffSquare:
+		return "FalloffSquare"
+	case FalloffDiamond:
+		return "FalloffDiamond"
+	case FalloffCustom:
+		return "FalloffCustom"
+	}
+	return fmt.Sprintf("FalloffShape(%d)", int(s))
+}
+
+// Falloff2D is a module that returns 1.0 at its center falling off to 0.0
+// at Radius from the center and beyond.
+type Falloff2D struct {
+	Shape    FalloffShape // how the distance from the center is measured
+	CenterX  float64      // the X coordinate of the center
+	CenterY  float64      // the Y coordinate of the center
+	Radius   float64      // the distance from the center where the mask reaches 0.0
+	Exponent float64      // the shape of the falloff; 1.0 is linear and higher values keep more of the center at 1.0
+
+	// Distance measures the distance of the offset (dx, dy) from the center
+	// when Shape is FalloffCustom
+	Distance func(dx, dy float64) float64
+}
+
+// NewFalloff2D creates a new falloff mask module.
+func NewFalloff2D(shape FalloffShape, centerX float64, centerY float64, radius float64, exponent float64) (falloff Falloff2D) {
+	falloff.Shape = shape
+	falloff.CenterX = centerX
+	falloff.CenterY = centerY
+	falloff.Radius = radius
+	falloff.Exponent = exponent
+	return
+}
+
+// Clone2D returns a copy of the module.
+func (falloff *Falloff2D) Clone2D() NoiseyGet2D {
+	c := *falloff
+	return &c
+}
+
+// SetRadius validates and changes the radius.
+func (falloff *Falloff2D) SetRadius(radius float64) error {
+	if err := validateParam(GenFalloff2D, "Radius", radius); err != nil {
+		return err
+	}
+	falloff.Radius = radius
+	return nil
+}
+
+// SetExponent validates and changes the exponent.
+func (falloff *Falloff2D) SetExponent(exponent float64) error {
+	if err := validateParam(GenFalloff2D, "Exponent", exponent); err != nil {
+		return err
+	}
+	falloff.Exponent = exponent
+	return nil
+}
+
+// String returns a description of the module.
+func (falloff *Falloff2D) String() string {
+	return fmt.Sprintf("Falloff2D{Shape: %v, CenterX: %v, CenterY: %v, Radius: %v, Exponent: %v}",
+		falloff.Shape, falloff.CenterX, falloff.CenterY, falloff.Radius, falloff.Exponent)
+}
+
+// Get2D calculates the mask value at the coordinate.
+func (falloff *Falloff2D) Get2D(x float64, y float64) float64 {
+	if falloff.Radius <= 0.0 {
+		return 0.0
+	}
+
+	dx := x - falloff.CenterX
+	dy := y - falloff.CenterY
+	var d float64
+	switch falloff.Shape {
+	case FalloffSquare:
+		d = math.Max(math.Abs(dx), math.Abs(dy))
+	case FalloffDiamond:
+		d = math.Abs(dx) + math.Abs(dy)
+	case FalloffCustom:
+		if falloff.Distance == nil {
+			return 0.0
+		}
+		d = falloff.Distance(dx, dy)
+	default:
+		d = math.Sqrt(dx*dx + dy*dy)
+	}
+
+	d /= falloff.Radius
+	if d >= 1.0 {
+		return 0.0
+	}
+	return 1.0 - math.Pow(math.Max(d, 0.0), falloff.Exponent)
+}
+
+// Range2D returns the bounds of the values of Get2D(). A negative Exponent
+// grows the mask without bound towards the center.
+func (falloff *Falloff2D) Range2D() (min float64, max float64) {
+	if falloff.Exponent < 0.0 {
+		return math.Inf(-1), 1.0
+	}
+	return 0.0, 1.0
+}
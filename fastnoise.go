@@ -0,0 +1,379 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module builds pipelines from the settings of FastNoiseLite, so a
+server using noisey can follow a client that uses FastNoiseLite:
+
+	settings := noisey.DefaultFastNoiseLite()
+	settings.Seed = 42
+	settings.FractalType = noisey.FNLFractalFBm
+	settings.Octaves = 5
+	settings.DomainWarpAmp = 30.0
+	terrain, err := noisey.NewFastNoiseLite(settings)
+	v := terrain.Get2D(x, y) // the coordinates FastNoiseLite's GetNoise() takes
+
+The fields and their defaults are those of the FastNoiseLite class, and the
+fractals are FastNoiseLite2D generators summing the octaves the way
+FastNoiseLite does: every octave has its own seed, counting up from Seed,
+the weights are scaled by the fractal bounding so the sum stays in [-1, 1],
+and WeightedStrength and PingPongStrength work the same.
+
+The bases are noisey's, so the outputs have the same frequency, range and
+character as FastNoiseLite's but don't match value for value:
+
+* OpenSimplex2 and OpenSimplex2S use OpenSimplexGenerator and Perlin uses
+  PerlinGenerator with QualityBest, the quintic curve of FastNoiseLite.
+* Cellular uses WorleyGenerator with CellularJitter. It returns Distance,
+  Distance2 and Distance2Sub with euclidean distances and doesn't square
+  them for EuclideanSq.
+* Value and ValueCubic noise and the other cellular settings return an
+  error, as noisey doesn't have them.
+* A DomainWarpAmp other than 0 wraps the noise in a Turbulence2D of
+  OpenSimplexGenerator sampled at Frequency and scaled by the fractal
+  bounding like FastNoiseLite's DomainWarp(). The warp fractal types sum
+  Octaves of the warp with Gain and Lacunarity, where the progressive one
+  is treated as independent, and leave the noise itself without a fractal.
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// FNLNoiseType is the NoiseType of FastNoiseLite.
+type FNLNoiseType int
+
+const (
+	FNLOpenSimplex2  FNLNoiseType = iota // OpenSimplex2 noise
+	FNLOpenSimplex2S                     // the smoother OpenSimplex2S noise
+	FNLCellular                          // cellular noise
+	FNLPerlin                            // Perlin noise
+	FNLValueCubic                        // value noise with cubic interpolation, which noisey doesn't have
+	FNLValue                             // value noise, which noisey doesn't have
+)
+
+// fnlNoiseTypeNames are the names of the values.
+var fnlNoiseTypeNames = []string{"OpenSimplex2", "OpenSimplex2S", "Cellular", "Perlin", "ValueCubic", "Value"}
+
+// String returns the name of the value.
+func (t FNLNoiseType) String() string {
+	if t >= 0 && int(t) < len(fnlNoiseTypeNames) {
+		return fnlNoiseTypeNames[t]
+	}
+	return fmt.Sprintf("FNLNoiseType(%d)", int(t))
+}
+
+// FNLFractalType is the FractalType of FastNoiseLite.
+type FNLFractalType int
+
+const (
+	FNLFractalNone                  FNLFractalType = iota // a single octave
+	FNLFractalFBm                                         // fractal Brownian motion
+	FNLFractalRidged                                      // ridged multifractal
+	FNLFractalPingPong                                    // octaves folded back and forth by PingPongStrength
+	FNLFractalDomainWarpProgressive                       // a fractal domain warp warping the warped coordinates
+	FNLFractalDomainWarpIndependent                       // a fractal domain warp of the original coordinates
+)
+
+// fnlFractalTypeNames are the names of the values.
+var fnlFractalTypeNames = []string{"None", "FBm", "Ridged", "PingPong", "DomainWarpProgressive", "DomainWarpIndependent"}
+
+// String returns the name of the value.
+func (t FNLFractalType) String() string {
+	if t >= 0 && int(t) < len(fnlFractalTypeNames) {
+		return fnlFractalTypeNames[t]
+	}
+	return fmt.Sprintf("FNLFractalType(%d)", int(t))
+}
+
+// FNLCellularDistance is the CellularDistanceFunction of FastNoiseLite.
+type FNLCellularDistance int
+
+const (
+	FNLDistanceEuclidean   FNLCellularDistance = iota // the euclidean distance
+	FNLDistanceEuclideanSq                            // the squared euclidean distance
+	FNLDistanceManhattan                              // the manhattan distance, which noisey doesn't have
+	FNLDistanceHybrid                                 // a blend of both, which noisey doesn't have
+)
+
+// fnlCellularDistanceNames are the names of the values.
+var fnlCellularDistanceNames = []string{"Euclidean", "EuclideanSq", "Manhattan", "Hybrid"}
+
+// String returns the name of the value.
+func (t FNLCellularDistance) String() string {
+	if t >= 0 && int(t) < len(fnlCellularDistanceNames) {
+		return fnlCellularDistanceNames[t]
+	}
+	return fmt.Sprintf("FNLCellularDistance(%d)", int(t))
+}
+
+// FNLCellularReturn is the CellularReturnType of FastNoiseLite.
+type FNLCellularReturn int
+
+const (
+	FNLReturnCellValue    FNLCellularReturn = iota // a random value per cell, which noisey doesn't have
+	FNLReturnDistance                              // the distance to the closest point
+	FNLReturnDistance2                             // the distance to the second closest point
+	FNLReturnDistance2Add                          // the sum of both, which noisey doesn't have
+	FNLReturnDistance2Sub                          // Distance2 - Distance
+	FNLReturnDistance2Mul                          // the product of both, which noisey doesn't have
+	FNLReturnDistance2Div                          // Distance / Distance2, which noisey doesn't have
+)
+
+// fnlCellularReturnNames are the names of the values.
+var fnlCellularReturnNames = []string{"CellValue", "Distance", "Distance2", "Distance2Add", "Distance2Sub", "Distance2Mul", "Distance2Div"}
+
+// String returns the name of the value.
+func (t FNLCellularReturn) String() string {
+	if t >= 0 && int(t) < len(fnlCellularReturnNames) {
+		return fnlCellularReturnNames[t]
+	}
+	return fmt.Sprintf("FNLCellularReturn(%d)", int(t))
+}
+
+// FastNoiseLiteSettings holds the settings of a FastNoiseLite object.
+type FastNoiseLiteSettings struct {
+	Seed      int          // the seed of the first octave
+	Frequency float64      // what the coordinates are multiplied by
+	NoiseType FNLNoiseType // the basis of every octave
+
+	FractalType      FNLFractalType // how the octaves are summed
+	Octaves          int            // the number of octaves
+	Lacunarity       float64        // the frequency multiplier between octaves
+	Gain             float64        // the amplitude multiplier between octaves
+	WeightedStrength float64        // how much an octave is weighted by the one before it [0, 1]
+	PingPongStrength float64        // the strength of the ping pong fractal
+
+	CellularDistance FNLCellularDistance // the distance function of cellular noise
+	CellularReturn   FNLCellularReturn   // what cellular noise returns
+	CellularJitter   float64             // how far the cellular points move from the centers of their cells
+
+	DomainWarpAmp float64 // the largest displacement of the domain warp, or 0 for none
+}
+
+// DefaultFastNoiseLite returns the settings of a new FastNoiseLite object.
+func DefaultFastNoiseLite() (s FastNoiseLiteSettings) {
+	s.Seed = 1337
+	s.Frequency = 0.01
+	s.NoiseType = FNLOpenSimplex2
+	s.FractalType = FNLFractalNone
+	s.Octaves = 3
+	s.Lacunarity = 2.0
+	s.Gain = 0.5
+	s.WeightedStrength = 0.0
+	s.PingPongStrength = 2.0
+	s.CellularDistance = FNLDistanceEuclideanSq
+	s.CellularReturn = FNLReturnDistance
+	s.CellularJitter = 1.0
+	s.DomainWarpAmp = 0.0
+	return
+}
+
+// fractalBounding returns what the first octave is weighted by so that
+// the octaves sum to at most 1.
+func (s *FastNoiseLiteSettings) fractalBounding() float64 {
+	gain := math.Abs(s.Gain)
+	amp, sum := gain, 1.0
+	for i := 1; i < s.Octaves; i++ {
+		sum += amp
+		amp *= gain
+	}
+	return 1.0 / sum
+}
+
+// basis returns the noisey source of the noise type with a seed.
+func (s *FastNoiseLiteSettings) basis(seed int) (NoiseyGet2D, error) {
+	rng := rand.New(rand.NewSource(int64(seed)))
+	switch s.NoiseType {
+	case FNLOpenSimplex2, FNLOpenSimplex2S:
+		simplex := NewOpenSimplexGenerator(rng)
+		return &simplex, nil
+	case FNLPerlin:
+		perlin := NewPerlinGeneratorWithQuality(rng, QualityBest)
+		return &perlin, nil
+	case FNLCellular:
+		if s.CellularDistance != FNLDistanceEuclidean && s.CellularDistance != FNLDistanceEuclideanSq {
+			return nil, fmt.Errorf("Unable to make cellular noise with the distance function %v; only the euclidean ones are supported.\n", s.CellularDistance)
+		}
+		cells := NewWorleyGenerator(rng)
+		cells.Jitter = s.CellularJitter
+		switch s.CellularReturn {
+		case FNLReturnDistance:
+			cells.Return = WorleyF1
+		case FNLReturnDistance2:
+			cells.Return = WorleyF2
+		case FNLReturnDistance2Sub:
+			cells.Return = WorleyF2MinusF1
+		default:
+			return nil, fmt.Errorf("Unable to make cellular noise returning %v; only Distance, Distance2 and Distance2Sub are supported.\n", s.CellularReturn)
+		}
+		return &cells, nil
+	}
+	return nil, fmt.Errorf("Unable to make FastNoiseLite noise of type %v; noisey has no value noise.\n", s.NoiseType)
+}
+
+// FastNoiseLite2D sums octaves of noise the way the fractals of
+// FastNoiseLite do.
+type FastNoiseLite2D struct {
+	Octaves          []NoiseyGet2D  // the sources of the octaves, each with its own seed
+	FractalType      FNLFractalType // how the octaves are summed
+	Frequency        float64        // what the coordinates are multiplied by
+	Lacunarity       float64        // the frequency multiplier between octaves
+	Gain             float64        // the amplitude multiplier between octaves
+	WeightedStrength float64        // how much an octave is weighted by the one before it
+	PingPongStrength float64        // the strength of the ping pong fractal
+	Bounding         float64        // the weight of the first octave
+}
+
+// Clone2D returns a deep copy of the module and its sources.
+func (fnl *FastNoiseLite2D) Clone2D() NoiseyGet2D {
+	c := *fnl
+	c.Octaves = make([]NoiseyGet2D, len(fnl.Octaves))
+	for i, o := range fnl.Octaves {
+		c.Octaves[i] = DeepCopy2D(o)
+	}
+	return &c
+}
+
+// String returns a description of the module.
+func (fnl *FastNoiseLite2D) String() string {
+	return fmt.Sprintf("FastNoiseLite2D{FractalType: %v, Octaves: %d, Frequency: %v, Lacunarity: %v, Gain: %v, WeightedStrength: %v, PingPongStrength: %v}",
+		fnl.FractalType, len(fnl.Octaves), fnl.Frequency, fnl.Lacunarity, fnl.Gain, fnl.WeightedStrength, fnl.PingPongStrength)
+}
+
+// fnlPingPong folds t back and forth between 0 and 1.
+func fnlPingPong(t float64) float64 {
+	t -= math.Trunc(t*0.5) * 2.0
+	if t < 1.0 {
+		return t
+	}
+	return 2.0 - t
+}
+
+// Get2D calculates the noise value at a given 2D coordinate.
+func (fnl *FastNoiseLite2D) Get2D(x float64, y float64) (v float64) {
+	x *= fnl.Frequency
+	y *= fnl.Frequency
+	if len(fnl.Octaves) == 0 {
+		return 0.0
+	}
+	if fnl.FractalType != FNLFractalFBm && fnl.FractalType != FNLFractalRidged && fnl.FractalType != FNLFractalPingPong {
+		return fnl.Octaves[0].Get2D(x, y)
+	}
+
+	amp := fnl.Bounding
+	for _, octave := range fnl.Octaves {
+		noise := octave.Get2D(x, y)
+		switch fnl.FractalType {
+		case FNLFractalFBm:
+			v += noise * amp
+			amp *= lerp(1.0, math.Min(noise+1.0, 2.0)*0.5, fnl.WeightedStrength)
+		case FNLFractalRidged:
+			noise = math.Abs(noise)
+			v += (noise*-2.0 + 1.0) * amp
+			amp *= lerp(1.0, 1.0-noise, fnl.WeightedStrength)
+		case FNLFractalPingPong:
+			noise = fnlPingPong((noise + 1.0) * fnl.PingPongStrength)
+			v += (noise - 0.5) * 2.0 * amp
+			amp *= lerp(1.0, noise, fnl.WeightedStrength)
+		}
+		x *= fnl.Lacunarity
+		y *= fnl.Lacunarity
+		amp *= fnl.Gain
+	}
+	return v
+}
+
+// Range2D returns the bounds of the values of Get2D(). The amplitude of each
+// octave is bounded along with the sum, from the bounds of the octave values
+// that weight it.
+func (fnl *FastNoiseLite2D) Range2D() (min float64, max float64) {
+	if len(fnl.Octaves) == 0 {
+		return 0.0, 0.0
+	}
+	if fnl.FractalType != FNLFractalFBm && fnl.FractalType != FNLFractalRidged && fnl.FractalType != FNLFractalPingPong {
+		return ValueRange2D(fnl.Octaves[0])
+	}
+
+	amin, amax := fnl.Bounding, fnl.Bounding
+	for _, octave := range fnl.Octaves {
+		lo, hi := ValueRange2D(octave)
+		var slo, shi, wlo, whi float64
+		switch fnl.FractalType {
+		case FNLFractalFBm:
+			slo, shi = lo, hi
+			wlo, whi = math.Min(lo+1.0, 2.0)*0.5, math.Min(hi+1.0, 2.0)*0.5
+		case FNLFractalRidged:
+			lo, hi = absRange(lo, hi)
+			slo, shi = 1.0-2.0*hi, 1.0-2.0*lo
+			wlo, whi = 1.0-hi, 1.0-lo
+		case FNLFractalPingPong:
+			// fnlPingPong folds the values of 0 and up into [0, 1] but
+			// passes those down to -2 through
+			tlo, _ := scaleRange(lo+1.0, hi+1.0, fnl.PingPongStrength)
+			wlo, whi = 0.0, 1.0
+			if tlo < 0.0 {
+				wlo = -2.0
+			}
+			slo, shi = (wlo-0.5)*2.0, (whi-0.5)*2.0
+		}
+		omin, omax := mulRange(slo, shi, amin, amax)
+		min, max = addRange(min, max, omin, omax)
+
+		// the amplitude is scaled by lerp(1, w, WeightedStrength) and Gain
+		flo, fhi := scaleRange(wlo-1.0, whi-1.0, fnl.WeightedStrength)
+		amin, amax = mulRange(amin, amax, flo+1.0, fhi+1.0)
+		amin, amax = scaleRange(amin, amax, fnl.Gain)
+	}
+	return
+}
+
+// NewFastNoiseLite builds the pipeline of the settings, which samples the
+// coordinates FastNoiseLite's GetNoise() takes.
+func NewFastNoiseLite(s FastNoiseLiteSettings) (NoiseyGet2D, error) {
+	warpFractal := s.FractalType == FNLFractalDomainWarpProgressive || s.FractalType == FNLFractalDomainWarpIndependent
+	octaves := 1
+	if s.FractalType == FNLFractalFBm || s.FractalType == FNLFractalRidged || s.FractalType == FNLFractalPingPong {
+		octaves = s.Octaves
+	}
+	if octaves < 1 {
+		return nil, fmt.Errorf("Unable to make FastNoiseLite noise of %d octaves.\n", octaves)
+	}
+
+	fnl := new(FastNoiseLite2D)
+	fnl.FractalType = s.FractalType
+	fnl.Frequency = s.Frequency
+	fnl.Lacunarity = s.Lacunarity
+	fnl.Gain = s.Gain
+	fnl.WeightedStrength = s.WeightedStrength
+	fnl.PingPongStrength = s.PingPongStrength
+	fnl.Bounding = s.fractalBounding()
+	for i := 0; i < octaves; i++ {
+		src, err := s.basis(s.Seed + i)
+		if err != nil {
+			return nil, err
+		}
+		fnl.Octaves = append(fnl.Octaves, src)
+	}
+	if s.DomainWarpAmp == 0.0 {
+		return fnl, nil
+	}
+
+	simplex := NewOpenSimplexGenerator(rand.New(rand.NewSource(int64(s.Seed))))
+	var warp NoiseyGet2D = &simplex
+	if warpFractal {
+		if s.Octaves < 1 {
+			return nil, fmt.Errorf("Unable to make a FastNoiseLite domain warp of %d octaves.\n", s.Octaves)
+		}
+		fbm := NewFBMGenerator2D(&simplex, s.Octaves, s.Gain, s.Lacunarity, 1.0)
+		warp = &fbm
+	}
+	turb := NewTurbulence2D(fnl, warp, warp, s.Frequency, s.DomainWarpAmp*fnl.Bounding)
+	return &turb, nil
+}
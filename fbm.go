@@ -14,6 +14,94 @@ Reference material:
 
 */
 
+import "fmt"
+
+// FBMGenerator1D takes noise and makes fractal Brownian motion values.
+type FBMGenerator1D struct {
+	NoiseMaker  NoiseyGet1D // the interface FBMGenerator1D uses gets noise values
+	Octaves     int         // the number of octaves to calculate on each Get()
+	Persistence float64     // a multiplier that determines how quickly the amplitudes diminish for each successive octave
+	Lacunarity  float64     // a multiplier that determines how quickly the frequency increases for each successive octave
+	Frequency   float64     // the number of cycles per unit length
+}
+
+// NewFBMGenerator1D creates a new fractal Brownian motion generator state. A 'default' fBm
+// would have 1 octave, 0.5 persistence, 2.0 lacunarity and 1.0 frequency.
+func NewFBMGenerator1D(noise NoiseyGet1D, octaves int, persistence float64, lacunarity float64, frequency float64) (fbm FBMGenerator1D) {
+	fbm.NoiseMaker = noise
+	fbm.Octaves = octaves
+	fbm.Persistence = persistence
+	fbm.Lacunarity = lacunarity
+	fbm.Frequency = frequency
+	return
+}
+
+// SetOctaves validates and changes the number of octaves.
+func (fbm *FBMGenerator1D) SetOctaves(octaves int) error {
+	if err := validateParamIn(fbmParams, "Octaves", float64(octaves)); err != nil {
+		return err
+	}
+	fbm.Octaves = octaves
+	return nil
+}
+
+// SetPersistence validates and changes the persistence.
+func (fbm *FBMGenerator1D) SetPersistence(persistence float64) error {
+	if err := validateParamIn(fbmParams, "Persistence", persistence); err != nil {
+		return err
+	}
+	fbm.Persistence = persistence
+	return nil
+}
+
+// SetLacunarity validates and changes the lacunarity.
+func (fbm *FBMGenerator1D) SetLacunarity(lacunarity float64) error {
+	if err := validateParamIn(fbmParams, "Lacunarity", lacunarity); err != nil {
+		return err
+	}
+	fbm.Lacunarity = lacunarity
+	return nil
+}
+
+// SetFrequency validates and changes the frequency.
+func (fbm *FBMGenerator1D) SetFrequency(frequency float64) error {
+	if err := validateParamIn(fbmParams, "Frequency", frequency); err != nil {
+		return err
+	}
+	fbm.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the generator and its NoiseMaker.
+func (fbm *FBMGenerator1D) String() string {
+	return fmt.Sprintf("FBMGenerator1D{Octaves: %v, Persistence: %v, Lacunarity: %v, Frequency: %v, NoiseMaker: %s}",
+		fbm.Octaves, fbm.Persistence, fbm.Lacunarity, fbm.Frequency, describeModule(fbm.NoiseMaker))
+}
+
+// Get1D calculates the noise value over the number of Octaves and other parameters
+// that scale the coordinate over each octave.
+func (fbm *FBMGenerator1D) Get1D(x float64) (v float64) {
+	curPersistence := 1.0
+
+	x *= fbm.Frequency
+
+	for o := 0; o < fbm.Octaves; o++ {
+		signal := fbm.NoiseMaker.Get1D(x)
+		v += signal * curPersistence
+
+		x *= fbm.Lacunarity
+		curPersistence *= fbm.Persistence
+	}
+
+	return v
+}
+
+// Range1D returns the bounds of the values of Get1D().
+func (fbm *FBMGenerator1D) Range1D() (min float64, max float64) {
+	lo, hi := ValueRange1D(fbm.NoiseMaker)
+	return octavesRange(lo, hi, fbm.Octaves, fbm.Persistence)
+}
+
 // FBMGenerator2D takes noise and makes fractal Brownian motion values.
 type FBMGenerator2D struct {
 	NoiseMaker  NoiseyGet2D // the interface FBMGenerator2D uses gets noise values
@@ -34,6 +122,55 @@ func NewFBMGenerator2D(noise NoiseyGet2D, octaves int, persistence float64, lacu
 	return
 }
 
+// Clone2D returns a deep copy of the generator and its NoiseMaker.
+func (fbm *FBMGenerator2D) Clone2D() NoiseyGet2D {
+	c := *fbm
+	c.NoiseMaker = DeepCopy2D(fbm.NoiseMaker)
+	return &c
+}
+
+// SetOctaves validates and changes the number of octaves.
+func (fbm *FBMGenerator2D) SetOctaves(octaves int) error {
+	if err := validateParamIn(fbmParams, "Octaves", float64(octaves)); err != nil {
+		return err
+	}
+	fbm.Octaves = octaves
+	return nil
+}
+
+// SetPersistence validates and changes the persistence.
+func (fbm *FBMGenerator2D) SetPersistence(persistence float64) error {
+	if err := validateParamIn(fbmParams, "Persistence", persistence); err != nil {
+		return err
+	}
+	fbm.Persistence = persistence
+	return nil
+}
+
+// SetLacunarity validates and changes the lacunarity.
+func (fbm *FBMGenerator2D) SetLacunarity(lacunarity float64) error {
+	if err := validateParamIn(fbmParams, "Lacunarity", lacunarity); err != nil {
+		return err
+	}
+	fbm.Lacunarity = lacunarity
+	return nil
+}
+
+// SetFrequency validates and changes the frequency.
+func (fbm *FBMGenerator2D) SetFrequency(frequency float64) error {
+	if err := validateParamIn(fbmParams, "Frequency", frequency); err != nil {
+		return err
+	}
+	fbm.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the generator and its NoiseMaker.
+func (fbm *FBMGenerator2D) String() string {
+	return fmt.Sprintf("FBMGenerator2D{Octaves: %v, Persistence: %v, Lacunarity: %v, Frequency: %v, NoiseMaker: %s}",
+		fbm.Octaves, fbm.Persistence, fbm.Lacunarity, fbm.Frequency, describeModule(fbm.NoiseMaker))
+}
+
 // Get2D calculates the noise value over the number of Octaves and other parameters
 // that scale the coordinates over each octave.
 func (fbm *FBMGenerator2D) Get2D(x float64, y float64) (v float64) {
@@ -54,6 +191,12 @@ func (fbm *FBMGenerator2D) Get2D(x float64, y float64) (v float64) {
 	return
 }
 
+// Range2D returns the bounds of the values of Get2D().
+func (fbm *FBMGenerator2D) Range2D() (min float64, max float64) {
+	lo, hi := ValueRange2D(fbm.NoiseMaker)
+	return octavesRange(lo, hi, fbm.Octaves, fbm.Persistence)
+}
+
 // FBMGenerator3D takes noise and makes fractal Brownian motion values.
 type FBMGenerator3D struct {
 	NoiseMaker  NoiseyGet3D // the interface FBMGenerator3D uses gets noise values
@@ -74,6 +217,55 @@ func NewFBMGenerator3D(noise NoiseyGet3D, octaves int, persistence float64, lacu
 	return
 }
 
+// Clone3D returns a deep copy of the generator and its NoiseMaker.
+func (fbm *FBMGenerator3D) Clone3D() NoiseyGet3D {
+	c := *fbm
+	c.NoiseMaker = DeepCopy3D(fbm.NoiseMaker)
+	return &c
+}
+
+// SetOctaves validates and changes the number of octaves.
+func (fbm *FBMGenerator3D) SetOctaves(octaves int) error {
+	if err := validateParamIn(fbmParams, "Octaves", float64(octaves)); err != nil {
+		return err
+	}
+	fbm.Octaves = octaves
+	return nil
+}
+
+// SetPersistence validates and changes the persistence.
+func (fbm *FBMGenerator3D) SetPersistence(persistence float64) error {
+	if err := validateParamIn(fbmParams, "Persistence", persistence); err != nil {
+		return err
+	}
+	fbm.Persistence = persistence
+	return nil
+}
+
+// SetLacunarity validates and changes the lacunarity.
+func (fbm *FBMGenerator3D) SetLacunarity(lacunarity float64) error {
+	if err := validateParamIn(fbmParams, "Lacunarity", lacunarity); err != nil {
+		return err
+	}
+	fbm.Lacunarity = lacunarity
+	return nil
+}
+
+// SetFrequency validates and changes the frequency.
+func (fbm *FBMGenerator3D) SetFrequency(frequency float64) error {
+	if err := validateParamIn(fbmParams, "Frequency", frequency); err != nil {
+		return err
+	}
+	fbm.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the generator and its NoiseMaker.
+func (fbm *FBMGenerator3D) String() string {
+	return fmt.Sprintf("FBMGenerator3D{Octaves: %v, Persistence: %v, Lacunarity: %v, Frequency: %v, NoiseMaker: %s}",
+		fbm.Octaves, fbm.Persistence, fbm.Lacunarity, fbm.Frequency, describeModule(fbm.NoiseMaker))
+}
+
 // Get3D calculates the noise value over the number of Octaves and other parameters
 // that scale the coordinates over each octave.
 func (fbm *FBMGenerator3D) Get3D(x float64, y float64, z float64) (v float64) {
@@ -95,3 +287,21 @@ func (fbm *FBMGenerator3D) Get3D(x float64, y float64, z float64) (v float64) {
 
 	return v
 }
+
+// Range3D returns the bounds of the values of Get3D().
+func (fbm *FBMGenerator3D) Range3D() (min float64, max float64) {
+	lo, hi := ValueRange3D(fbm.NoiseMaker)
+	return octavesRange(lo, hi, fbm.Octaves, fbm.Persistence)
+}
+
+// octavesRange returns the bounds of the sum of octaves with values in
+// [lo, hi] that are scaled by persistence to the power of the octave.
+func octavesRange(lo float64, hi float64, octaves int, persistence float64) (min float64, max float64) {
+	amplitude := 1.0
+	for o := 0; o < octaves; o++ {
+		omin, omax := scaleRange(lo, hi, amplitude)
+		min, max = addRange(min, max, omin, omax)
+		amplitude *= persistence
+	}
+	return
+}
@@ -0,0 +1,139 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the fast Fourier transforms used to shape and analyse
+the spectrum of maps. Power of two sizes use an iterative radix-2 transform
+and other sizes use Bluestein's algorithm on top of it, so maps of any size
+can be transformed in O(n log n).
+
+The forward transform isn't scaled and the inverse one divides by n, so an
+inverse of a forward transform gives the input back.
+
+*/
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// fft transforms data in place, or does the inverse transform.
+func fft(data []complex128, inverse bool) {
+	n := len(data)
+	if n <= 1 {
+		return
+	}
+	if n&(n-1) == 0 {
+		fftRadix2(data, inverse)
+	} else {
+		fftBluestein(data, inverse)
+	}
+	if inverse {
+		scale := complex(1.0/float64(n), 0.0)
+		for i := range data {
+			data[i] *= scale
+		}
+	}
+}
+
+// fftRadix2 transforms data of a power of two length in place without
+// scaling the inverse.
+func fftRadix2(data []complex128, inverse bool) {
+	n := len(data)
+	shift := uint(64 - bits.TrailingZeros(uint(n)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		step := cmplx.Rect(1.0, sign*2.0*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1.0, 0.0)
+			for k := 0; k < half; k++ {
+				a, b := data[start+k], data[start+k+half]*w
+				data[start+k], data[start+k+half] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// fftBluestein transforms data of any length in place without scaling the
+// inverse, as a convolution of power of two length.
+func fftBluestein(data []complex128, inverse bool) {
+	n := len(data)
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+
+	// the chirp, with k*k taken modulo 2n to keep the angles precise
+	chirp := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		kk := (int64(k) * int64(k)) % int64(2*n)
+		chirp[k] = cmplx.Rect(1.0, sign*math.Pi*float64(kk)/float64(n))
+	}
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = data[k] * chirp[k]
+	}
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = b[k]
+	}
+
+	fftRadix2(a, false)
+	fftRadix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fftRadix2(a, true)
+	scale := complex(1.0/float64(m), 0.0)
+	for k := 0; k < n; k++ {
+		data[k] = a[k] * scale * chirp[k]
+	}
+}
+
+// fft2D transforms a width x height grid in rows in place, or does the
+// inverse transform.
+func fft2D(data []complex128, width int, height int, inverse bool) {
+	for y := 0; y < height; y++ {
+		fft(data[y*width:(y+1)*width], inverse)
+	}
+	column := make([]complex128, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			column[y] = data[y*width+x]
+		}
+		fft(column, inverse)
+		for y := 0; y < height; y++ {
+			data[y*width+x] = column[y]
+		}
+	}
+}
+
+// fftFrequency returns the signed frequency of bin i of n in cycles per n
+// samples.
+func fftFrequency(i int, n int) float64 {
+	if i > n/2 {
+		return float64(i - n)
+	}
+	return float64(i)
+}
@@ -0,0 +1,199 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module grows branching filaments, like lightning bolts, cracks radiating
+from an impact or the veins of a leaf, and renders them into a NoiseMap:
+
+	bolt := noisey.NewFilamentGenerator(&fbm, rng, noisey.Vec2f{0.5, 1.0}, -math.Pi/2.0)
+	bolt.Glow = 0.02
+	nm, err := bolt.Generate(512, 512, noisey.Builder2DBounds{0.0, 0.0, 1.0, 1.0})
+
+	impact := noisey.NewFilamentGenerator(&fbm, rng, noisey.Vec2f{0.5, 0.5}, 0.0)
+	impact.Branches, impact.Spread = 7, 2.0*math.Pi
+
+Branches start at Start heading in Direction, spread evenly over Spread
+radians when there is more than one. Each branch walks StepLength at a time
+and is steered by the Source, which is sampled along its path, turning up to
+Wander radians each step. Bias pulls the heading back towards the direction
+the branch started in, so 0.0 wanders freely and 1.0 walks straight.
+
+At every step a branch forks with a chance of BranchChance into a child that
+heads off BranchAngle radians to one side. Children are WidthFalloff times
+as wide and walk LengthFalloff times as many Steps as their parent, and no
+more than MaxDepth generations are grown.
+
+The map is 1.0 within half the width of a branch and falls to 0.0 over Glow
+world units beyond that; everywhere else it's 0.0. The Rng makes the forking
+decisions, so the same seed grows the same filaments.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// filamentSegment is one step of a branch.
+type filamentSegment struct {
+	a, b  Vec2f
+	width float64
+}
+
+// FilamentGenerator contains the parameters for growing branching filaments.
+type FilamentGenerator struct {
+	Source        NoiseyGet2D  // the noise that steers the branches
+	Rng           RandomSource // the random numbers that decide where branches fork
+	Start         Vec2f        // the world coordinate the branches start at
+	Direction     float64      // the heading of the first branch in radians from +X towards +Y
+	Branches      int          // the number of branches that start at Start
+	Spread        float64      // the angle the starting branches are spread over
+	StepLength    float64      // the world length of each step
+	Steps         int          // the number of steps of the starting branches
+	Wander        float64      // the most the Source turns a branch each step in radians
+	Bias          float64      // how strongly branches return to their starting heading [0, 1]
+	BranchChance  float64      // the chance of a fork at each step [0, 1]
+	BranchAngle   float64      // the angle between a branch and its children in radians
+	Width         float64      // the world width of the starting branches
+	WidthFalloff  float64      // the width of children relative to their parent
+	LengthFalloff float64      // the steps of children relative to their parent
+	MaxDepth      int          // the most generations of children
+	Glow          float64      // the world distance the values fade over beyond the width
+}
+
+// NewFilamentGenerator creates a new generator of a single lightning like
+// branch with forks sized for a unit square.
+func NewFilamentGenerator(src NoiseyGet2D, rng RandomSource, start Vec2f, direction float64) (fg FilamentGenerator) {
+	fg.Source = src
+	fg.Rng = rng
+	fg.Start = start
+	fg.Direction = direction
+	fg.Branches = 1
+	fg.StepLength = 0.01
+	fg.Steps = 100
+	fg.Wander = 0.6
+	fg.Bias = 0.2
+	fg.BranchChance = 0.04
+	fg.BranchAngle = 0.6
+	fg.Width = 0.006
+	fg.WidthFalloff = 0.6
+	fg.LengthFalloff = 0.5
+	fg.MaxDepth = 3
+	return
+}
+
+// String returns a description of the generator and its source.
+func (fg *FilamentGenerator) String() string {
+	return fmt.Sprintf("FilamentGenerator{Start: %v, Direction: %v, Branches: %v, Spread: %v, StepLength: %v, Steps: %v, Wander: %v, Bias: %v, BranchChance: %v, BranchAngle: %v, Width: %v, WidthFalloff: %v, LengthFalloff: %v, MaxDepth: %v, Glow: %v, Source: %s}",
+		fg.Start, fg.Direction, fg.Branches, fg.Spread, fg.StepLength, fg.Steps, fg.Wander, fg.Bias, fg.BranchChance,
+		fg.BranchAngle, fg.Width, fg.WidthFalloff, fg.LengthFalloff, fg.MaxDepth, fg.Glow, describeModule(fg.Source))
+}
+
+// grow walks a branch and its children and returns all of their segments.
+func (fg *FilamentGenerator) grow() []filamentSegment {
+	type branch struct {
+		pos     Vec2f
+		heading float64
+		steps   int
+		width   float64
+		depth   int
+	}
+	var pending []branch
+	for i := 0; i < fg.Branches; i++ {
+		heading := fg.Direction
+		if fg.Branches > 1 {
+			heading += fg.Spread * (float64(i)/float64(fg.Branches) - 0.5)
+		}
+		pending = append(pending, branch{fg.Start, heading, fg.Steps, fg.Width, 0})
+	}
+
+	var segments []filamentSegment
+	for n := 0; len(pending) > 0; n++ {
+		b := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		// every branch samples its own part of the Source so that children
+		// don't follow their parents
+		offset := float64(n) * turbulenceOffset
+		target := b.heading
+		for s := 0; s < b.steps; s++ {
+			b.heading += fg.Wander * fg.Source.Get2D(b.pos.X+offset, b.pos.Y+offset)
+			b.heading += fg.Bias * math.Remainder(target-b.heading, 2.0*math.Pi)
+			next := Vec2f{b.pos.X + math.Cos(b.heading)*fg.StepLength, b.pos.Y + math.Sin(b.heading)*fg.StepLength}
+			segments = append(segments, filamentSegment{b.pos, next, b.width})
+			b.pos = next
+
+			if b.depth < fg.MaxDepth && fg.Rng.Float64() < fg.BranchChance {
+				side := fg.BranchAngle
+				if fg.Rng.Float64() < 0.5 {
+					side = -side
+				}
+				steps := int(float64(b.steps-s) * fg.LengthFalloff)
+				if steps > 0 {
+					pending = append(pending, branch{b.pos, b.heading + side, steps, b.width * fg.WidthFalloff, b.depth + 1})
+				}
+			}
+		}
+	}
+	return segments
+}
+
+// segmentDistance returns the distance from p to the segment from a to b.
+func segmentDistance(p Vec2f, a Vec2f, b Vec2f) float64 {
+	abx, aby := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l2 := abx*abx + aby*aby; l2 > 0.0 {
+		t = math.Max(0.0, math.Min(1.0, ((p.X-a.X)*abx+(p.Y-a.Y)*aby)/l2))
+	}
+	return math.Hypot(p.X-a.X-t*abx, p.Y-a.Y-t*aby)
+}
+
+// Generate grows the filaments and renders them into a map of width x
+// height values over bounds.
+func (fg *FilamentGenerator) Generate(width int, height int, bounds Builder2DBounds) (NoiseMap, error) {
+	if fg.Source == nil || fg.Rng == nil {
+		return NoiseMap{}, fmt.Errorf("Unable to grow filaments without a Source and a random number generator.\n")
+	}
+	if width <= 0 || height <= 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to render filaments into a map of size %dx%d.\n", width, height)
+	}
+	if fg.StepLength <= 0.0 || fg.Width <= 0.0 || fg.Glow < 0.0 {
+		return NoiseMap{}, fmt.Errorf("Unable to grow filaments with a StepLength of %v, a Width of %v and a Glow of %v.\n", fg.StepLength, fg.Width, fg.Glow)
+	}
+
+	nm := NewNoiseMap(width, height, bounds)
+	dx := (bounds.MaxX - bounds.MinX) / float64(width)
+	dy := (bounds.MaxY - bounds.MinY) / float64(height)
+	if dx <= 0.0 || dy <= 0.0 {
+		return NoiseMap{}, fmt.Errorf("Unable to render filaments over the Bounds %v.\n", bounds)
+	}
+
+	// each segment only touches the grid points within its reach
+	for _, seg := range fg.grow() {
+		half := seg.width * 0.5
+		reach := half + fg.Glow
+		x0 := maxInt(0, int(math.Floor((math.Min(seg.a.X, seg.b.X)-reach-bounds.MinX)/dx)))
+		x1 := minInt(width-1, int(math.Ceil((math.Max(seg.a.X, seg.b.X)+reach-bounds.MinX)/dx)))
+		y0 := maxInt(0, int(math.Floor((math.Min(seg.a.Y, seg.b.Y)-reach-bounds.MinY)/dy)))
+		y1 := minInt(height-1, int(math.Ceil((math.Max(seg.a.Y, seg.b.Y)+reach-bounds.MinY)/dy)))
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				p := Vec2f{bounds.MinX + float64(x)*dx, bounds.MinY + float64(y)*dy}
+				d := segmentDistance(p, seg.a, seg.b) - half
+				v := 0.0
+				if d <= 0.0 {
+					v = 1.0
+				} else if d < fg.Glow {
+					v = (1.0 - d/fg.Glow) * (1.0 - d/fg.Glow)
+				}
+				if i := y*width + x; v > nm.Values[i] {
+					nm.Values[i] = v
+				}
+			}
+		}
+	}
+	return nm, nil
+}
@@ -0,0 +1,82 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module generates flow maps for water shaders from a heightmap. Water
+flows down the slope of the terrain, faster where it's steeper, and the curl
+of a noise source adds eddies so that flat water still moves:
+
+	fm := noisey.NewFlowMap(&perlin)
+	flow, err := fm.Generate(&heightmap)
+	err = flow.WriteRGPNG(file)
+
+The vectors of the flow map are at most 1.0 long. WriteRGPNG() encodes them
+into the red and green channels of an image mapping [-1, 1] to [0, 255], so
+a vector of zero is (128, 128). Green is positive along the rows of the map,
+which is down the image; shaders that expect green to point up the image
+should flip it.
+
+*/
+
+import "fmt"
+
+// FlowMap contains the parameters for generating flow maps.
+type FlowMap struct {
+	Noise       NoiseyGet2D // the noise whose curl adds eddies; nil for none
+	Frequency   float64     // the frequency the noise is sampled at
+	NoiseAmount float64     // how strong the eddies are compared to the fastest downslope flow
+	Strength    float64     // what the slope is multiplied by to get the downslope speed
+}
+
+// NewFlowMap creates a new flow map generator with parameters for a
+// heightmap in [-1, 1] with a cell size of about 1.0.
+func NewFlowMap(noise NoiseyGet2D) (fm FlowMap) {
+	fm.Noise = noise
+	fm.Frequency = 0.05
+	fm.NoiseAmount = 0.25
+	fm.Strength = 20.0
+	return
+}
+
+// String returns a description of the generator and its noise.
+func (fm *FlowMap) String() string {
+	return fmt.Sprintf("FlowMap{Frequency: %v, NoiseAmount: %v, Strength: %v, Noise: %s}",
+		fm.Frequency, fm.NoiseAmount, fm.Strength, describeModule(fm.Noise))
+}
+
+// Generate creates the flow map for the heightmap.
+func (fm *FlowMap) Generate(heightmap *NoiseMap) (VectorMap, error) {
+	w, h := heightmap.Width, heightmap.Height
+	if w <= 0 || h <= 0 || len(heightmap.Values) != w*h {
+		return VectorMap{}, fmt.Errorf("Unable to generate a flow map for a heightmap of size %dx%d with %d values.\n", w, h, len(heightmap.Values))
+	}
+
+	b := heightmap.Bounds
+	dx, dy := (b.MaxX-b.MinX)/float64(w), (b.MaxY-b.MinY)/float64(h)
+	flow := NewVectorMap(w, h, b)
+	for y := 0; y < h; y++ {
+		wy := b.MinY + float64(y)*dy
+		for x := 0; x < w; x++ {
+			wx := b.MinX + float64(x)*dx
+
+			// downhill is against the gradient
+			gx, gy := heightmap.worldGradient(x, y)
+			v := Vec2f{-gx, -gy}.Scale(fm.Strength)
+			if l := v.Length(); l > 1.0 {
+				v = v.Scale(1.0 / l)
+			}
+			if fm.Noise != nil && fm.NoiseAmount != 0.0 {
+				v = v.Add(noiseCurl(fm.Noise, wx*fm.Frequency, wy*fm.Frequency).Scale(fm.NoiseAmount))
+			}
+			if l := v.Length(); l > 1.0 {
+				v = v.Scale(1.0 / l)
+			}
+			flow.U.Values[y*w+x] = v.X
+			flow.V.Values[y*w+x] = v.Y
+		}
+	}
+	return flow, nil
+}
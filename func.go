@@ -0,0 +1,132 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains function types that implement the NoiseyGet2D and
+NoiseyGet3D interfaces so small custom transforms can be used as modules
+without declaring a struct, plus middleware helpers for wrapping modules.
+
+	clamped := noisey.Wrap2D(&fbm, noisey.Clamp2D(0.0, 1.0))
+	squared := noisey.NoiseFunc2D(func(x, y float64) float64 {
+		v := clamped.Get2D(x, y)
+		return v * v
+	})
+
+*/
+
+import "math"
+
+// NoiseFunc2D is a function that can be used as a NoiseyGet2D.
+type NoiseFunc2D func(x float64, y float64) float64
+
+// Get2D calculates the noise value by calling the function.
+func (f NoiseFunc2D) Get2D(x float64, y float64) float64 {
+	return f(x, y)
+}
+
+// NoiseFunc3D is a function that can be used as a NoiseyGet3D.
+type NoiseFunc3D func(x float64, y float64, z float64) float64
+
+// Get3D calculates the noise value by calling the function.
+func (f NoiseFunc3D) Get3D(x float64, y float64, z float64) float64 {
+	return f(x, y, z)
+}
+
+// Middleware2D takes a module and returns a new module that wraps it.
+type Middleware2D func(s NoiseyGet2D) NoiseyGet2D
+
+// Middleware3D takes a module and returns a new module that wraps it.
+type Middleware3D func(s NoiseyGet3D) NoiseyGet3D
+
+// Wrap2D wraps s with each of the middleware in order, so the first
+// middleware is closest to s and the last one is called first.
+func Wrap2D(s NoiseyGet2D, middleware ...Middleware2D) NoiseyGet2D {
+	for _, m := range middleware {
+		s = m(s)
+	}
+	return s
+}
+
+// Wrap3D wraps s with each of the middleware in order, so the first
+// middleware is closest to s and the last one is called first.
+func Wrap3D(s NoiseyGet3D, middleware ...Middleware3D) NoiseyGet3D {
+	for _, m := range middleware {
+		s = m(s)
+	}
+	return s
+}
+
+// Clamp2D returns middleware that limits the values of a module to [min, max].
+func Clamp2D(min float64, max float64) Middleware2D {
+	return func(s NoiseyGet2D) NoiseyGet2D {
+		return &clamped2D{s, min, max}
+	}
+}
+
+// clamped2D is the module that Clamp2D wraps a module with.
+type clamped2D struct {
+	source   NoiseyGet2D
+	min, max float64
+}
+
+// Get2D returns the value of the source limited to [min, max].
+func (c *clamped2D) Get2D(x float64, y float64) float64 {
+	return math.Min(c.max, math.Max(c.min, c.source.Get2D(x, y)))
+}
+
+// Range2D returns the bounds of the source limited to [min, max].
+func (c *clamped2D) Range2D() (min float64, max float64) {
+	min, max = ValueRange2D(c.source)
+	return clampRange(min, max, c.min, c.max)
+}
+
+// Clamp3D returns middleware that limits the values of a module to [min, max].
+func Clamp3D(min float64, max float64) Middleware3D {
+	return func(s NoiseyGet3D) NoiseyGet3D {
+		return &clamped3D{s, min, max}
+	}
+}
+
+// clamped3D is the module that Clamp3D wraps a module with.
+type clamped3D struct {
+	source   NoiseyGet3D
+	min, max float64
+}
+
+// Get3D returns the value of the source limited to [min, max].
+func (c *clamped3D) Get3D(x float64, y float64, z float64) float64 {
+	return math.Min(c.max, math.Max(c.min, c.source.Get3D(x, y, z)))
+}
+
+// Range3D returns the bounds of the source limited to [min, max].
+func (c *clamped3D) Range3D() (min float64, max float64) {
+	min, max = ValueRange3D(c.source)
+	return clampRange(min, max, c.min, c.max)
+}
+
+// Observe2D returns middleware that calls observer with every coordinate and
+// the value the module returned for it, which is useful for logging.
+func Observe2D(observer func(x, y, v float64)) Middleware2D {
+	return func(s NoiseyGet2D) NoiseyGet2D {
+		return NoiseFunc2D(func(x, y float64) float64 {
+			v := s.Get2D(x, y)
+			observer(x, y, v)
+			return v
+		})
+	}
+}
+
+// Observe3D returns middleware that calls observer with every coordinate and
+// the value the module returned for it, which is useful for logging.
+func Observe3D(observer func(x, y, z, v float64)) Middleware3D {
+	return func(s NoiseyGet3D) NoiseyGet3D {
+		return NoiseFunc3D(func(x, y, z float64) float64 {
+			v := s.Get3D(x, y, z)
+			observer(x, y, z, v)
+			return v
+		})
+	}
+}
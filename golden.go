@@ -0,0 +1,240 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module records the values that the sources and generators of a NoiseJSON
+configuration return at a fixed set of coordinates, so that a build can later
+check that it still makes exactly the same world. Changes to the algorithms,
+to the compiler or to the platform can shift noise values silently; a golden
+file saved next to a world turns that into an error.
+
+	cfg.BuildSources(nil)
+	cfg.BuildGenerators()
+	points := noisey.GoldenPoints(64, noisey.Builder3DBounds{-100, -100, -100, 100, 100, 100})
+	golden, err := noisey.RecordGolden(cfg, points)
+	data, err := noisey.SaveGolden(golden)
+	...
+	err = noisey.VerifyGolden(data, nil, 1e-9)
+
+The golden file holds the configuration along with the values, so it can be
+verified on its own. The values of noisey's own sources and generators are
+kept in testdata/golden.json and checked by the tests.
+
+Values are compared with a tolerance since some platforms fuse multiplies and
+adds, which changes the last bits of a result; 1e-9 allows for that while
+still catching any real change.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// GoldenFile holds the values of the sources and generators of a configuration
+// at a set of coordinates.
+type GoldenFile struct {
+	// Config is the configuration that was sampled
+	Config *NoiseJSON
+
+	// Points are the coordinates every module was sampled at; the 2D values
+	// use only their X and Y
+	Points []Vec3f
+
+	// Sources are the 2D values of each source by name
+	Sources map[string][]float64
+
+	// Sources3D are the 3D values of each source that implements NoiseyGet3D
+	Sources3D map[string][]float64 `json:",omitempty"`
+
+	// Generators are the 2D values of each generator by name
+	Generators map[string][]float64
+}
+
+// GoldenPoints returns n coordinates spread over the bounds by a SplitMixSource,
+// so they are the same on every platform and Go version.
+func GoldenPoints(n int, bounds Builder3DBounds) []Vec3f {
+	rng := NewSplitMixSource(uint64(n))
+	points := make([]Vec3f, n)
+	for i := range points {
+		points[i].X = bounds.MinX + rng.Float64()*(bounds.MaxX-bounds.MinX)
+		points[i].Y = bounds.MinY + rng.Float64()*(bounds.MaxY-bounds.MinY)
+		points[i].Z = bounds.MinZ + rng.Float64()*(bounds.MaxZ-bounds.MinZ)
+	}
+	return points
+}
+
+// RecordGolden samples every source and generator of a configuration at the
+// points. BuildSources() and BuildGenerators() must have been called.
+func RecordGolden(cfg *NoiseJSON, points []Vec3f) (*GoldenFile, error) {
+	if len(cfg.builtSources) < len(cfg.Sources) || len(cfg.builtGenerators) < len(cfg.Generators) {
+		return nil, fmt.Errorf("Unable to record golden values of a configuration that wasn't built.\n")
+	}
+	g := new(GoldenFile)
+	g.Config = cfg
+	g.Points = make([]Vec3f, len(points))
+	copy(g.Points, points)
+	g.Sources = make(map[string][]float64)
+	g.Sources3D = make(map[string][]float64)
+	g.Generators = make(map[string][]float64)
+
+	for name, s := range cfg.builtSources {
+		g.Sources[name] = sampleGolden2D(s, points)
+		if s3, ok := s.(NoiseyGet3D); ok {
+			g.Sources3D[name] = sampleGolden3D(s3, points)
+		}
+	}
+	for name, gen := range cfg.builtGenerators {
+		g.Generators[name] = sampleGolden2D(gen, points)
+	}
+	return g, nil
+}
+
+func sampleGolden2D(m NoiseyGet2D, points []Vec3f) []float64 {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = m.Get2D(p.X, p.Y)
+	}
+	return values
+}
+
+func sampleGolden3D(m NoiseyGet3D, points []Vec3f) []float64 {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = m.Get3D(p.X, p.Y, p.Z)
+	}
+	return values
+}
+
+// goldenMismatch is a value that differs from the golden one.
+type goldenMismatch struct {
+	module string
+	point  Vec3f
+	value  float64
+	golden float64
+}
+
+// compareGolden adds the values of a module that differ from the golden ones
+// by more than the tolerance to mismatches.
+func compareGolden(mismatches []goldenMismatch, module string, points []Vec3f, values []float64, golden []float64, tolerance float64) []goldenMismatch {
+	for i := range golden {
+		v, gv := values[i], golden[i]
+		if v == gv || math.Abs(v-gv) <= tolerance || (math.IsNaN(v) && math.IsNaN(gv)) {
+			continue
+		}
+		mismatches = append(mismatches, goldenMismatch{module, points[i], v, gv})
+	}
+	return mismatches
+}
+
+// Verify samples the sources and generators of a built configuration at the
+// golden points and returns an error describing the values that differ from
+// the golden ones by more than the tolerance, or that are missing.
+func (g *GoldenFile) Verify(cfg *NoiseJSON, tolerance float64) error {
+	var mismatches []goldenMismatch
+	var missing []string
+
+	check := func(kind string, golden map[string][]float64, built map[string]NoiseyGet2D, dims int) error {
+		names := make([]string, 0, len(golden))
+		for name := range golden {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			values := golden[name]
+			if len(values) != len(g.Points) {
+				return fmt.Errorf("Unable to verify the %s \"%s\": %d golden values for %d points.\n", kind, name, len(values), len(g.Points))
+			}
+			m, ok := built[name]
+			if !ok {
+				missing = append(missing, kind+" \""+name+"\"")
+				continue
+			}
+			if dims == 3 {
+				m3, ok := m.(NoiseyGet3D)
+				if !ok {
+					missing = append(missing, "3D "+kind+" \""+name+"\"")
+					continue
+				}
+				mismatches = compareGolden(mismatches, name, g.Points, sampleGolden3D(m3, g.Points), values, tolerance)
+			} else {
+				mismatches = compareGolden(mismatches, name, g.Points, sampleGolden2D(m, g.Points), values, tolerance)
+			}
+		}
+		return nil
+	}
+	if err := check("source", g.Sources, cfg.builtSources, 2); err != nil {
+		return err
+	}
+	if err := check("source", g.Sources3D, cfg.builtSources, 3); err != nil {
+		return err
+	}
+	if err := check("generator", g.Generators, cfg.builtGenerators, 2); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("Unable to verify the golden values; the configuration has no %v.\n", missing)
+	}
+	if len(mismatches) > 0 {
+		m := mismatches[0]
+		return fmt.Errorf("Unable to reproduce %d golden values; the first is \"%s\" at (%v, %v, %v) which returned %v instead of %v.\n",
+			len(mismatches), m.module, m.point.X, m.point.Y, m.point.Z, m.value, m.golden)
+	}
+	return nil
+}
+
+// Build builds the sources and generators of the configuration of the golden
+// file with the seed builder, which is passed on to BuildSources() and may be
+// nil.
+func (g *GoldenFile) Build(seedBuilder RandomSeedBuilder) (*NoiseJSON, error) {
+	if g.Config == nil {
+		return nil, fmt.Errorf("Unable to build a golden file without a configuration.\n")
+	}
+	g.Config.initBuilt()
+	if err := g.Config.BuildSources(seedBuilder); err != nil {
+		return nil, err
+	}
+	if err := g.Config.BuildGenerators(); err != nil {
+		return nil, err
+	}
+	return g.Config, nil
+}
+
+// SaveGolden marshals the golden file into an indented JSON byte array. The
+// values are written with enough digits to be read back exactly.
+func SaveGolden(g *GoldenFile) ([]byte, error) {
+	bytes, err := json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode the golden file into JSON.\n%v\n", err)
+	}
+	return bytes, nil
+}
+
+// LoadGolden unmarshals a golden file saved by SaveGolden().
+func LoadGolden(bytes []byte) (*GoldenFile, error) {
+	g := new(GoldenFile)
+	if err := json.Unmarshal(bytes, g); err != nil {
+		return nil, fmt.Errorf("Unable to read json into the golden file structure.\n%v\n", err)
+	}
+	return g, nil
+}
+
+// VerifyGolden loads a golden file, builds its configuration with the seed
+// builder and verifies its values with the tolerance.
+func VerifyGolden(bytes []byte, seedBuilder RandomSeedBuilder, tolerance float64) error {
+	g, err := LoadGolden(bytes)
+	if err != nil {
+		return err
+	}
+	cfg, err := g.Build(seedBuilder)
+	if err != nil {
+		return err
+	}
+	return g.Verify(cfg, tolerance)
+}
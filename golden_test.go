@@ -0,0 +1,61 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden.json with the current values")
+
+// TestGolden makes sure the sources and generators still return the values
+// saved in testdata/golden.json. Run it with -update after a deliberate
+// change to the output.
+func TestGolden(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *updateGolden {
+		g, err := LoadGolden(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := g.Build(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g, err = RecordGolden(cfg, g.Points); err != nil {
+			t.Fatal(err)
+		}
+		if data, err = SaveGolden(g); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile("testdata/golden.json", append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if err := VerifyGolden(data, nil, 1e-9); err != nil {
+		t.Error(err)
+	}
+
+	// a changed value has to be caught
+	g, err := LoadGolden(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := g.Build(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Generators["fbm"][0] += 1e-6
+	if err := g.Verify(cfg, 1e-9); err == nil {
+		t.Errorf("Verify() didn't catch a changed value")
+	}
+}
@@ -0,0 +1,197 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the ColorGradient type which maps noise values to colors
+by interpolating between color keys, so that a built NoiseMap can be turned
+into an image:
+
+	gradient := noisey.NewColorGradient(
+		noisey.ColorKey{Position: -1.0, Color: color.NRGBA{0, 0, 128, 255}},
+		noisey.ColorKey{Position: 0.0, Color: color.NRGBA{240, 220, 160, 255}},
+		noisey.ColorKey{Position: 1.0, Color: color.NRGBA{255, 255, 255, 255}})
+	img := gradient.Image(&heightmap)
+
+Values below the first key get the color of the first key and values above
+the last key get the color of the last key. Between two keys the colors are
+blended as set by Interpolation:
+
+* GradientLinear blends the colors linearly, which is the default.
+* GradientSmoothstep eases in and out of each key with a smoothstep curve, so
+  there are no visible creases at the keys.
+* GradientConstant keeps the color of the lower key up to the next key, for
+  banded maps like contour shading or toon ramps.
+
+Gradients can be saved in the Gradients of a NoiseJSON configuration as a
+GradientJSON, with the keys' colors as their R, G, B and A channels:
+
+	cfg.Gradients["heights"] = gradient.ToJSON()
+	gradient, err := cfg.GetGradient("heights")
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// ColorKey is a color at a position of a ColorGradient.
+type ColorKey struct {
+	Position float64     // the noise value the color is used at
+	Color    color.NRGBA // the color at the position
+}
+
+// GradientInterpolation selects how a ColorGradient blends between its keys.
+type GradientInterpolation int
+
+const (
+	// GradientLinear blends the colors of the keys linearly.
+	GradientLinear GradientInterpolation = iota
+
+	// GradientSmoothstep blends the colors of the keys with a smoothstep curve.
+	GradientSmoothstep
+
+	// GradientConstant uses the color of the lower key up to the next key.
+	GradientConstant
+)
+
+// gradientInterpolationNames are the names of the interpolations used by GradientJSON.
+var gradientInterpolationNames = []string{"linear", "smoothstep", "constant"}
+
+// String returns the name of the interpolation.
+func (gi GradientInterpolation) String() string {
+	if gi >= GradientLinear && gi <= GradientConstant {
+		return gradientInterpolationNames[gi]
+	}
+	return fmt.Sprintf("GradientInterpolation(%d)", int(gi))
+}
+
+// ColorGradient maps noise values to colors.
+type ColorGradient struct {
+	Keys          []ColorKey            // the color keys sorted by position
+	Interpolation GradientInterpolation // how the colors are blended between the keys
+}
+
+// GradientJSON is the JSON configuration of a ColorGradient.
+type GradientJSON struct {
+	// Interpolation is "linear", "smoothstep" or "constant"; linear is used if it's empty
+	Interpolation string `json:",omitempty"`
+
+	// Keys are the color keys, which don't need to be in order
+	Keys []ColorKey
+}
+
+// NewColorGradient creates a new gradient from the keys, which don't need
+// to be in order.
+func NewColorGradient(keys ...ColorKey) (cg ColorGradient) {
+	cg.Keys = make([]ColorKey, len(keys))
+	copy(cg.Keys, keys)
+	sort.SliceStable(cg.Keys, func(i, j int) bool { return cg.Keys[i].Position < cg.Keys[j].Position })
+	return
+}
+
+// AddKey adds a color key to the gradient keeping the keys sorted.
+func (cg *ColorGradient) AddKey(position float64, c color.NRGBA) {
+	i := sort.Search(len(cg.Keys), func(i int) bool { return cg.Keys[i].Position > position })
+	cg.Keys = append(cg.Keys, ColorKey{})
+	copy(cg.Keys[i+1:], cg.Keys[i:])
+	cg.Keys[i] = ColorKey{position, c}
+}
+
+// String returns a description of the gradient.
+func (cg *ColorGradient) String() string {
+	return fmt.Sprintf("ColorGradient{Interpolation: %v, Keys: %v}", cg.Interpolation, cg.Keys)
+}
+
+// ToJSON returns the JSON configuration of the gradient.
+func (cg *ColorGradient) ToJSON() GradientJSON {
+	gj := GradientJSON{Keys: make([]ColorKey, len(cg.Keys))}
+	copy(gj.Keys, cg.Keys)
+	if cg.Interpolation != GradientLinear {
+		gj.Interpolation = cg.Interpolation.String()
+	}
+	return gj
+}
+
+// NewColorGradientFromJSON creates a new gradient from its JSON configuration.
+func NewColorGradientFromJSON(gj *GradientJSON) (cg ColorGradient, err error) {
+	cg = NewColorGradient(gj.Keys...)
+	if gj.Interpolation == "" {
+		return cg, nil
+	}
+	for i, name := range gradientInterpolationNames {
+		if name == gj.Interpolation {
+			cg.Interpolation = GradientInterpolation(i)
+			return cg, nil
+		}
+	}
+	return cg, fmt.Errorf("Unknown gradient interpolation (%s).\n", gj.Interpolation)
+}
+
+// GetGradient returns the gradient made from the GradientJSON in Gradients
+// with the name.
+func (cfg *NoiseJSON) GetGradient(name string) (ColorGradient, error) {
+	gj, ok := cfg.Gradients[name]
+	if !ok {
+		return ColorGradient{}, fmt.Errorf("Undefined gradient (%s).\n", name)
+	}
+	return NewColorGradientFromJSON(&gj)
+}
+
+// lerpColor linearly interpolates each channel of two colors.
+func lerpColor(a color.NRGBA, b color.NRGBA, t float64) color.NRGBA {
+	channel := func(a, b uint8) uint8 {
+		return uint8(math.Floor(lerp(float64(a), float64(b), t) + 0.5))
+	}
+	return color.NRGBA{channel(a.R, b.R), channel(a.G, b.G), channel(a.B, b.B), channel(a.A, b.A)}
+}
+
+// Color returns the color for the noise value v blended as set by
+// Interpolation. A gradient without keys returns transparent black.
+func (cg *ColorGradient) Color(v float64) color.NRGBA {
+	n := len(cg.Keys)
+	if n == 0 {
+		return color.NRGBA{}
+	}
+	if v <= cg.Keys[0].Position || math.IsNaN(v) {
+		return cg.Keys[0].Color
+	}
+	if v >= cg.Keys[n-1].Position {
+		return cg.Keys[n-1].Color
+	}
+	i := sort.Search(n, func(i int) bool { return cg.Keys[i].Position > v })
+	a, b := cg.Keys[i-1], cg.Keys[i]
+	t := (v - a.Position) / (b.Position - a.Position)
+	switch cg.Interpolation {
+	case GradientSmoothstep:
+		t = t * t * (3.0 - 2.0*t)
+	case GradientConstant:
+		return a.Color
+	}
+	return lerpColor(a.Color, b.Color, t)
+}
+
+// Image returns an image of the map with every value colored by the
+// gradient. Row y of the image is row y of the map.
+func (cg *ColorGradient) Image(nm *NoiseMap) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, nm.Width, nm.Height))
+	for y := 0; y < nm.Height; y++ {
+		for x := 0; x < nm.Width; x++ {
+			img.SetNRGBA(x, y, cg.Color(nm.Values[y*nm.Width+x]))
+		}
+	}
+	return img
+}
+
+// WritePNG writes Image() of the map to w as a PNG.
+func (cg *ColorGradient) WritePNG(w io.Writer, nm *NoiseMap) error {
+	return png.Encode(w, cg.Image(nm))
+}
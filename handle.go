@@ -0,0 +1,131 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains handles that hold a pipeline of noise modules which can
+be replaced atomically while other goroutines keep sampling from it, such as
+when a configuration file gets reloaded or parameters are tuned live.
+
+A pipeline that has been stored in a handle should not be modified in place
+anymore; build or DeepCopy a new one and Store() that instead.
+
+*/
+
+import "sync/atomic"
+
+// handle2DValue wraps the interface so that atomic.Value always sees the same type.
+type handle2DValue struct {
+	source NoiseyGet2D
+}
+
+// handle3DValue wraps the interface so that atomic.Value always sees the same type.
+type handle3DValue struct {
+	source NoiseyGet3D
+}
+
+// Handle2D holds a NoiseyGet2D that can be swapped safely while in use.
+// The zero value holds nothing and Get2D will return 0 until Store is called.
+// A Handle2D must not be copied after first use.
+type Handle2D struct {
+	v atomic.Value
+}
+
+// NewHandle2D creates a new handle holding the source s.
+func NewHandle2D(s NoiseyGet2D) *Handle2D {
+	h := new(Handle2D)
+	h.Store(s)
+	return h
+}
+
+// Load returns the source currently held by the handle or nil if there isn't one.
+func (h *Handle2D) Load() NoiseyGet2D {
+	v, ok := h.v.Load().(handle2DValue)
+	if !ok {
+		return nil
+	}
+	return v.source
+}
+
+// Store atomically replaces the source held by the handle.
+func (h *Handle2D) Store(s NoiseyGet2D) {
+	h.v.Store(handle2DValue{s})
+}
+
+// Get2D calculates the noise value using the source currently held by the handle.
+func (h *Handle2D) Get2D(x float64, y float64) float64 {
+	s := h.Load()
+	if s == nil {
+		return 0.0
+	}
+	return s.Get2D(x, y)
+}
+
+// Range2D returns the bounds of the values of the module the handle holds
+// now, or 0.0 to 0.0 if it holds none.
+func (h *Handle2D) Range2D() (min float64, max float64) {
+	s := h.Load()
+	if s == nil {
+		return 0.0, 0.0
+	}
+	return ValueRange2D(s)
+}
+
+// String returns a description of the source currently held by the handle.
+func (h *Handle2D) String() string {
+	return "Handle2D{Source: " + describeModule(h.Load()) + "}"
+}
+
+// Handle3D holds a NoiseyGet3D that can be swapped safely while in use.
+// The zero value holds nothing and Get3D will return 0 until Store is called.
+// A Handle3D must not be copied after first use.
+type Handle3D struct {
+	v atomic.Value
+}
+
+// NewHandle3D creates a new handle holding the source s.
+func NewHandle3D(s NoiseyGet3D) *Handle3D {
+	h := new(Handle3D)
+	h.Store(s)
+	return h
+}
+
+// Load returns the source currently held by the handle or nil if there isn't one.
+func (h *Handle3D) Load() NoiseyGet3D {
+	v, ok := h.v.Load().(handle3DValue)
+	if !ok {
+		return nil
+	}
+	return v.source
+}
+
+// Store atomically replaces the source held by the handle.
+func (h *Handle3D) Store(s NoiseyGet3D) {
+	h.v.Store(handle3DValue{s})
+}
+
+// Get3D calculates the noise value using the source currently held by the handle.
+func (h *Handle3D) Get3D(x float64, y float64, z float64) float64 {
+	s := h.Load()
+	if s == nil {
+		return 0.0
+	}
+	return s.Get3D(x, y, z)
+}
+
+// Range3D returns the bounds of the values of the module the handle holds
+// now, or 0.0 to 0.0 if it holds none.
+func (h *Handle3D) Range3D() (min float64, max float64) {
+	s := h.Load()
+	if s == nil {
+		return 0.0, 0.0
+	}
+	return ValueRange3D(s)
+}
+
+// String returns a description of the source currently held by the handle.
+func (h *Handle3D) String() string {
+	return "Handle3D{Source: " + describeModule(h.Load()) + "}"
+}
@@ -0,0 +1,441 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module reads heightmaps made by other tools into a NoiseMap so that
+terrain sculpted elsewhere can be eroded, analysed and exported like built
+noise, and writes maps back out for those tools:
+
+	file, _ := os.Open("terrain.r16")
+	nm, err := noisey.ReadRAW16(file, 1025, 1025, binary.LittleEndian, bounds)
+	err = noisey.WriteTerragen(out, &nm, 400.0, 30.0)
+
+These formats are understood:
+
+* RAW16 is a headerless grid of unsigned 16 bit heights in row major order,
+  as written by World Machine, Gaea and Unity (which calls it R16 and uses
+  little endian). The size and byte order aren't stored in the file, so they
+  have to be given.
+* PNG16 is a grayscale PNG. Any PNG is read, but 16 bit ones keep their full
+  precision; color images use their luminance.
+* Float TIFF is an uncompressed TIFF of 32 or 64 bit floating point samples
+  in strips or tiles, as exported by GDAL and most DEM tools. Only the first
+  sample of each pixel is read.
+* HGT is an SRTM elevation tile: a square grid of signed 16 bit big endian
+  heights in meters, 1201x1201 for 3 arc seconds or 3601x3601 for 1 arc
+  second, with the north edge as the first row and -32768 for voids.
+
+The unsigned 16 bit formats are mapped from [0, 65535] to [-1, 1] like the
+output of the noise sources, and the map can be scaled afterwards to the
+real heights. The floating point samples of a TIFF and the meters of an HGT
+tile are heights in world units already, so they are kept as they are and
+voids are read as NaN.
+
+Row 0 of the file becomes row 0 of the map at Bounds.MinY.
+
+WriteRAW16() and WritePNG16() do the opposite of the readers, clamping the
+values to [-1, 1] first. WriteTerragen() writes a Terragen .ter file and
+WriteHGT() an SRTM tile, both with the values multiplied by heightScale as
+the heights in meters. HGTTileName() names a tile by its south west corner
+the way SRTM tools expect:
+
+	nm := builder.GetNoiseMap() // a 1201x1201 map
+	file, _ := os.Create(noisey.HGTTileName(37, -122)) // N37W122.hgt
+	err := noisey.WriteHGT(file, &nm, 2000.0)
+
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// ReadRAW16 reads a width x height grid of unsigned 16 bit heights in the
+// byte order into a map covering bounds.
+func ReadRAW16(r io.Reader, width int, height int, order binary.ByteOrder, bounds Builder2DBounds) (NoiseMap, error) {
+	if width <= 0 || height <= 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a RAW16 heightmap of size %dx%d.\n", width, height)
+	}
+	data := make([]byte, width*height*2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return NoiseMap{}, fmt.Errorf("Unable to read a RAW16 heightmap of size %dx%d: %v\n", width, height, err)
+	}
+	nm := NewNoiseMap(width, height, bounds)
+	for i := range nm.Values {
+		nm.Values[i] = float64(order.Uint16(data[i*2:]))/65535.0*2.0 - 1.0
+	}
+	return nm, nil
+}
+
+// ReadPNG16 reads the luminance of a PNG into a map covering bounds.
+func ReadPNG16(r io.Reader, bounds Builder2DBounds) (NoiseMap, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return NoiseMap{}, fmt.Errorf("Unable to decode the PNG heightmap: %v\n", err)
+	}
+	src := NewImageSource(img, ImageLuminance)
+	src.Map.Bounds = bounds
+	return src.Map, nil
+}
+
+// TIFF tags used by ReadFloatTIFF.
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffPlanarConfig    = 284
+	tiffPredictor       = 317
+	tiffTileWidth       = 322
+	tiffTileLength      = 323
+	tiffTileOffsets     = 324
+	tiffTileByteCounts  = 325
+	tiffSampleFormat    = 339
+)
+
+// tiffFile is a TIFF held in memory with the fields of its first image.
+type tiffFile struct {
+	data   []byte
+	order  binary.ByteOrder
+	fields map[uint16][]uint32
+}
+
+// field returns the first value of the tag or def if the image doesn't
+// have the tag.
+func (t *tiffFile) field(tag uint16, def uint32) uint32 {
+	if v := t.fields[tag]; len(v) > 0 {
+		return v[0]
+	}
+	return def
+}
+
+// readIFD reads the SHORT and LONG fields of the image file directory at offset.
+func (t *tiffFile) readIFD(offset uint32) error {
+	if int(offset)+2 > len(t.data) {
+		return fmt.Errorf("Unable to read the TIFF directory at %d.\n", offset)
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	entries := int(offset) + 2
+	if entries+count*12 > len(t.data) {
+		return fmt.Errorf("Unable to read the %d TIFF directory entries at %d.\n", count, offset)
+	}
+	t.fields = make(map[uint16][]uint32)
+	for e := 0; e < count; e++ {
+		entry := t.data[entries+e*12:]
+		tag := t.order.Uint16(entry)
+		kind := t.order.Uint16(entry[2:])
+		n := int(t.order.Uint32(entry[4:]))
+		size := 0
+		switch kind {
+		case 3:
+			size = 2
+		case 4:
+			size = 4
+		default:
+			continue
+		}
+		values := entry[8:12]
+		if n*size > 4 {
+			at := int(t.order.Uint32(entry[8:]))
+			if at < 0 || at+n*size > len(t.data) {
+				return fmt.Errorf("Unable to read the values of TIFF tag %d.\n", tag)
+			}
+			values = t.data[at : at+n*size]
+		}
+		field := make([]uint32, n)
+		for i := range field {
+			if size == 2 {
+				field[i] = uint32(t.order.Uint16(values[i*2:]))
+			} else {
+				field[i] = t.order.Uint32(values[i*4:])
+			}
+		}
+		t.fields[tag] = field
+	}
+	return nil
+}
+
+// ReadFloatTIFF reads the first image of an uncompressed floating point TIFF
+// into a map covering bounds.
+func ReadFloatTIFF(r io.Reader, bounds Builder2DBounds) (NoiseMap, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return NoiseMap{}, fmt.Errorf("Unable to read the TIFF heightmap: %v\n", err)
+	}
+	t := tiffFile{data: data}
+	switch {
+	case len(data) >= 8 && string(data[:4]) == "II*\x00":
+		t.order = binary.LittleEndian
+	case len(data) >= 8 && string(data[:4]) == "MM\x00*":
+		t.order = binary.BigEndian
+	default:
+		return NoiseMap{}, fmt.Errorf("Unable to read the TIFF heightmap: not a TIFF file.\n")
+	}
+	if err := t.readIFD(t.order.Uint32(data[4:])); err != nil {
+		return NoiseMap{}, err
+	}
+
+	width := int(t.field(tiffImageWidth, 0))
+	height := int(t.field(tiffImageLength, 0))
+	bits := int(t.field(tiffBitsPerSample, 1))
+	samples := int(t.field(tiffSamplesPerPixel, 1))
+	if width <= 0 || height <= 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap of size %dx%d.\n", width, height)
+	}
+	if t.field(tiffSampleFormat, 1) != 3 || (bits != 32 && bits != 64) {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap of %d bit samples that aren't floating point.\n", bits)
+	}
+	if c := t.field(tiffCompression, 1); c != 1 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap with compression %d; only uncompressed files are supported.\n", c)
+	}
+	if p := t.field(tiffPredictor, 1); p != 1 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap with predictor %d.\n", p)
+	}
+	if samples > 1 && t.field(tiffPlanarConfig, 1) != 1 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap with planar samples.\n")
+	}
+
+	if samples < 1 {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap with %d samples per pixel.\n", samples)
+	}
+
+	// the samples have to fit in the file, which also keeps a broken header
+	// from allocating a huge map
+	bytesPerSample := bits / 8
+	pixel := bytesPerSample * samples
+	if width > len(data)/pixel || height > len(data)/(width*pixel) {
+		return NoiseMap{}, fmt.Errorf("Unable to read a TIFF heightmap of size %dx%d from %d bytes.\n", width, height, len(data))
+	}
+
+	// gather the samples of the first channel into row major order
+	pixels := make([]byte, width*height*bytesPerSample)
+	copyRow := func(chunk []byte, offset int, x int, y int, n int) error {
+		if offset < 0 || offset+n*pixel > len(chunk) {
+			return fmt.Errorf("Unable to read the TIFF heightmap: the image data is truncated.\n")
+		}
+		for i := 0; i < n; i++ {
+			copy(pixels[(y*width+x+i)*bytesPerSample:], chunk[offset+i*pixel:offset+i*pixel+bytesPerSample])
+		}
+		return nil
+	}
+	chunk := func(offsets []uint32, counts []uint32, i int) ([]byte, error) {
+		if i >= len(offsets) || i >= len(counts) || int(offsets[i])+int(counts[i]) > len(data) {
+			return nil, fmt.Errorf("Unable to read the TIFF heightmap: the image data is truncated.\n")
+		}
+		return data[offsets[i] : offsets[i]+counts[i]], nil
+	}
+
+	if tileWidth, tileHeight := int(t.field(tiffTileWidth, 0)), int(t.field(tiffTileLength, 0)); tileWidth > 0 && tileHeight > 0 {
+		across := (width + tileWidth - 1) / tileWidth
+		down := (height + tileHeight - 1) / tileHeight
+		for i := 0; i < across*down; i++ {
+			tile, err := chunk(t.fields[tiffTileOffsets], t.fields[tiffTileByteCounts], i)
+			if err != nil {
+				return NoiseMap{}, err
+			}
+			x0, y0 := (i%across)*tileWidth, (i/across)*tileHeight
+			n := minInt(tileWidth, width-x0)
+			for y := 0; y < tileHeight && y0+y < height; y++ {
+				if err := copyRow(tile, y*tileWidth*pixel, x0, y0+y, n); err != nil {
+					return NoiseMap{}, err
+				}
+			}
+		}
+	} else {
+		rowsPerStrip := int(t.field(tiffRowsPerStrip, uint32(height)))
+		if rowsPerStrip <= 0 {
+			rowsPerStrip = height
+		}
+		for y := 0; y < height; y++ {
+			strip, err := chunk(t.fields[tiffStripOffsets], t.fields[tiffStripByteCounts], y/rowsPerStrip)
+			if err != nil {
+				return NoiseMap{}, err
+			}
+			if err := copyRow(strip, (y%rowsPerStrip)*width*pixel, 0, y, width); err != nil {
+				return NoiseMap{}, err
+			}
+		}
+	}
+
+	nm := NewNoiseMap(width, height, bounds)
+	for i := range nm.Values {
+		if bits == 32 {
+			nm.Values[i] = float64(math.Float32frombits(t.order.Uint32(pixels[i*4:])))
+		} else {
+			nm.Values[i] = math.Float64frombits(t.order.Uint64(pixels[i*8:]))
+		}
+	}
+	return nm, nil
+}
+
+// heightmapWord maps a value in [-1, 1] to [0, 65535], clamping it first.
+func heightmapWord(v float64) uint16 {
+	return uint16(math.Floor(math.Max(0.0, math.Min(1.0, (v+1.0)*0.5))*65535.0 + 0.5))
+}
+
+// WriteRAW16 writes the values of the map to w as unsigned 16 bit heights
+// in the byte order without a header.
+func WriteRAW16(w io.Writer, nm *NoiseMap, order binary.ByteOrder) error {
+	data := make([]byte, len(nm.Values)*2)
+	for i, v := range nm.Values {
+		order.PutUint16(data[i*2:], heightmapWord(v))
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// WritePNG16 writes the values of the map to w as a 16 bit grayscale PNG.
+func WritePNG16(w io.Writer, nm *NoiseMap) error {
+	img := image.NewGray16(image.Rect(0, 0, nm.Width, nm.Height))
+	for y := 0; y < nm.Height; y++ {
+		for x := 0; x < nm.Width; x++ {
+			img.SetGray16(x, y, color.Gray16{heightmapWord(nm.Get(x, y))})
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// WriteTerragen writes the map to w as a Terragen terrain with the values
+// times heightScale as the heights in meters and spacing meters between
+// the grid points.
+func WriteTerragen(w io.Writer, nm *NoiseMap, heightScale float64, spacing float64) error {
+	if nm.Width <= 1 || nm.Height <= 1 || nm.Width > 32768 || nm.Height > 32768 {
+		return fmt.Errorf("Unable to write a Terragen terrain of size %dx%d.\n", nm.Width, nm.Height)
+	}
+	if spacing <= 0.0 {
+		return fmt.Errorf("Unable to write a Terragen terrain with a spacing of %v.\n", spacing)
+	}
+
+	// the heights are stored in units of the spacing as a base height plus
+	// 16 bit offsets scaled to fit the range of the map
+	low, high := nm.GetMinMax()
+	low, high = low*heightScale/spacing, high*heightScale/spacing
+	if low > high {
+		low, high = high, low
+	}
+	base := math.Floor((low+high)*0.5 + 0.5)
+	scale := math.Ceil(math.Max(high-base, base-low) * 65536.0 / 32767.0)
+	if scale < 1.0 {
+		scale = 1.0
+	}
+	if math.Abs(base) > 32767.0 || scale > 32767.0 {
+		return fmt.Errorf("Unable to write a Terragen terrain with heights from %v to %v meters.\n", low*spacing, high*spacing)
+	}
+
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	b.WriteString("TERRAGENTERRAIN ")
+	chunk16 := func(name string, v int) {
+		b.WriteString(name)
+		binary.Write(&b, le, int16(v))
+		binary.Write(&b, le, int16(0))
+	}
+	chunk16("SIZE", minInt(nm.Width, nm.Height)-1)
+	chunk16("XPTS", nm.Width)
+	chunk16("YPTS", nm.Height)
+	b.WriteString("SCAL")
+	for i := 0; i < 3; i++ {
+		binary.Write(&b, le, float32(spacing))
+	}
+	b.WriteString("ALTW")
+	binary.Write(&b, le, int16(scale))
+	binary.Write(&b, le, int16(base))
+	for _, v := range nm.Values {
+		e := math.Floor((v*heightScale/spacing-base)*65536.0/scale + 0.5)
+		binary.Write(&b, le, int16(math.Max(-32768.0, math.Min(32767.0, e))))
+	}
+	if len(nm.Values)%2 == 1 {
+		binary.Write(&b, le, int16(0))
+	}
+	b.WriteString("EOF ")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// the sizes of SRTM tiles, which overlap their neighbors by one row and
+// column
+const (
+	HGTSize3ArcSecond = 1201 // the size of a tile with 3 arc seconds between heights
+	HGTSize1ArcSecond = 3601 // the size of a tile with 1 arc second between heights
+)
+
+// hgtVoid marks a missing height in an HGT tile.
+const hgtVoid = -32768
+
+// HGTTileName returns the file name of the SRTM tile whose south west
+// corner is at the latitude and longitude, like "N37W122.hgt".
+func HGTTileName(lat int, lon int) string {
+	ns, ew := 'N', 'E'
+	if lat < 0 {
+		ns, lat = 'S', -lat
+	}
+	if lon < 0 {
+		ew, lon = 'W', -lon
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, lat, ew, lon)
+}
+
+// hgtSize returns true if size is the size of an SRTM tile.
+func hgtSize(size int) bool {
+	return size == HGTSize3ArcSecond || size == HGTSize1ArcSecond
+}
+
+// ReadHGT reads an SRTM tile into a map covering bounds, with the heights
+// in meters and voids as NaN. The size of the tile is taken from the
+// length of the data.
+func ReadHGT(r io.Reader, bounds Builder2DBounds) (NoiseMap, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return NoiseMap{}, err
+	}
+	size := int(math.Sqrt(float64(len(data)/2)) + 0.5)
+	if !hgtSize(size) || len(data) != size*size*2 {
+		return NoiseMap{}, fmt.Errorf("Unable to read an HGT tile of %d bytes; expected %d or %d.\n",
+			len(data), HGTSize3ArcSecond*HGTSize3ArcSecond*2, HGTSize1ArcSecond*HGTSize1ArcSecond*2)
+	}
+	nm := NewNoiseMap(size, size, bounds)
+	for i := range nm.Values {
+		h := int16(binary.BigEndian.Uint16(data[i*2:]))
+		if h == hgtVoid {
+			nm.Values[i] = math.NaN()
+		} else {
+			nm.Values[i] = float64(h)
+		}
+	}
+	return nm, nil
+}
+
+// WriteHGT writes the map to w as an SRTM tile with the values times
+// heightScale as the heights in meters. The map has to be one of the tile
+// sizes. The heights are rounded and clamped to [-32767, 32767] and NaN
+// values are written as voids.
+func WriteHGT(w io.Writer, nm *NoiseMap, heightScale float64) error {
+	if nm.Width != nm.Height || !hgtSize(nm.Width) || len(nm.Values) < nm.Width*nm.Height {
+		return fmt.Errorf("Unable to write an HGT tile of size %dx%d; expected %dx%d or %dx%d.\n",
+			nm.Width, nm.Height, HGTSize3ArcSecond, HGTSize3ArcSecond, HGTSize1ArcSecond, HGTSize1ArcSecond)
+	}
+	data := make([]byte, nm.Width*nm.Height*2)
+	for i, v := range nm.Values[:nm.Width*nm.Height] {
+		h := int16(hgtVoid)
+		if !math.IsNaN(v) {
+			h = int16(math.Max(-32767.0, math.Min(32767.0, math.Floor(v*heightScale+0.5))))
+		}
+		binary.BigEndian.PutUint16(data[i*2:], uint16(h))
+	}
+	_, err := w.Write(data)
+	return err
+}
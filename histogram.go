@@ -0,0 +1,283 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the Histogram type, which counts the values of a
+NoiseMap in evenly sized bins so that the share of the map below a value
+and the value below which a share of the map lies can be looked up quickly,
+without sorting the map for each query.
+
+The queries are what thresholds are usually tuned for by trial and error:
+
+	h := heightmap.Histogram(0)
+	sea := h.Percentile(0.6)    // 60% of the map is below the sea level
+	land := 1.0 - h.CDF(0.0)    // the share of the map above 0
+	peaks := h.Fraction(0.8, 1) // the share of the map in [0.8, 1)
+
+Remap() moves the values of a map by their share of the map instead, with a
+curve from percentiles to values. PercentileCurve() makes such a curve
+through keys, so the sea level can be set to 0 with 60% of the map below
+it while keeping the order of the heights:
+
+	h.Remap(&heightmap, noisey.PercentileCurve(
+		noisey.PercentileKey{0.0, -1.0}, noisey.PercentileKey{0.6, 0.0}, noisey.PercentileKey{1.0, 1.0}))
+
+Passing the Percentile method of the histogram of another map as the curve
+gives the map the distribution of values of the other one.
+
+The values are assumed to be spread evenly within each bin, so the queries
+are exact at the edges of the bins and off by at most the width of a bin in
+between. NewHistogram() spans the lowest to the highest value, while
+NewHistogramRange() uses fixed bounds, so that histograms of different maps
+have the same bins and can be compared with Distance(); the values outside
+of them are counted in Below and Above. NaN values are counted apart from
+all of the others.
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Histogram counts values in evenly sized bins between Min and Max.
+type Histogram struct {
+	Min    float64 // the lower edge of the first bin
+	Max    float64 // the upper edge of the last bin
+	Counts []int   // the number of values in each bin
+	Below  int     // the number of values under Min
+	Above  int     // the number of values over Max
+	NaN    int     // the number of NaN values, which aren't in Total
+	Total  int     // the number of values that aren't NaN
+
+	// cumulative are the number of values before each bin, starting with
+	// Below; it's nil until a query needs it
+	cumulative []int
+}
+
+// NewHistogram counts the values in a number of bins between their lowest
+// and highest value. 1024 bins are used if bins is 0 or less.
+func NewHistogram(values []float64, bins int) (h Histogram) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+	if min > max {
+		min, max = 0.0, 0.0
+	}
+	return NewHistogramRange(values, bins, min, max)
+}
+
+// NewHistogramRange counts the values in a number of bins between min and
+// max. 1024 bins are used if bins is 0 or less.
+func NewHistogramRange(values []float64, bins int, min float64, max float64) (h Histogram) {
+	if bins <= 0 {
+		bins = 1024
+	}
+	h.Min, h.Max = min, max
+	h.Counts = make([]int, bins)
+	for _, v := range values {
+		h.Add(v)
+	}
+	h.cumulate()
+	return
+}
+
+// Histogram counts the values of the map in a number of bins; see NewHistogram().
+func (nm *NoiseMap) Histogram(bins int) Histogram {
+	return NewHistogram(nm.Values, bins)
+}
+
+// String returns a description of the histogram.
+func (h *Histogram) String() string {
+	return fmt.Sprintf("Histogram{Min: %v, Max: %v, Bins: %d, Total: %d, Below: %d, Above: %d, NaN: %d}",
+		h.Min, h.Max, len(h.Counts), h.Total, h.Below, h.Above, h.NaN)
+}
+
+// Add counts a value. The next query updates the cumulative counts, so
+// queries mustn't run at the same time as Add.
+func (h *Histogram) Add(v float64) {
+	switch {
+	case math.IsNaN(v):
+		h.NaN++
+		return
+	case v < h.Min:
+		h.Below++
+	case v > h.Max:
+		h.Above++
+	case len(h.Counts) > 0:
+		h.Counts[h.bin(v)]++
+	default:
+		return
+	}
+	h.Total++
+	h.cumulative = nil
+}
+
+// BinWidth returns the width of the bins.
+func (h *Histogram) BinWidth() float64 {
+	if len(h.Counts) == 0 {
+		return 0.0
+	}
+	return (h.Max - h.Min) / float64(len(h.Counts))
+}
+
+// BinFraction returns the share of the values that are in bin i.
+func (h *Histogram) BinFraction(i int) float64 {
+	if h.Total == 0 || i < 0 || i >= len(h.Counts) {
+		return 0.0
+	}
+	return float64(h.Counts[i]) / float64(h.Total)
+}
+
+// bin returns the bin a value in [Min, Max] is counted in.
+func (h *Histogram) bin(v float64) int {
+	if h.Max <= h.Min {
+		return 0
+	}
+	i := int((v - h.Min) / (h.Max - h.Min) * float64(len(h.Counts)))
+	if i < 0 {
+		return 0
+	}
+	if i >= len(h.Counts) {
+		return len(h.Counts) - 1
+	}
+	return i
+}
+
+// cumulate makes sure the cumulative counts are up to date.
+func (h *Histogram) cumulate() {
+	if len(h.cumulative) == len(h.Counts)+1 {
+		return
+	}
+	h.cumulative = make([]int, len(h.Counts)+1)
+	h.cumulative[0] = h.Below
+	for i, c := range h.Counts {
+		h.cumulative[i+1] = h.cumulative[i] + c
+	}
+}
+
+// CDF returns the share of the values that are below v, in [0, 1]. The
+// values under Min count as below every v from Min on, and those over Max
+// as below no v up to Max.
+func (h *Histogram) CDF(v float64) float64 {
+	switch {
+	case h.Total == 0 || v < h.Min:
+		return 0.0
+	case v > h.Max:
+		return 1.0
+	}
+	h.cumulate()
+	if v == h.Min || len(h.Counts) == 0 {
+		return float64(h.Below) / float64(h.Total)
+	}
+	i := h.bin(v)
+	lower := h.Min + float64(i)*h.BinWidth()
+	t := 1.0
+	if h.BinWidth() > 0.0 {
+		t = (v - lower) / h.BinWidth()
+	}
+	return (float64(h.cumulative[i]) + t*float64(h.Counts[i])) / float64(h.Total)
+}
+
+// Fraction returns the share of the values that are in [lo, hi).
+func (h *Histogram) Fraction(lo float64, hi float64) float64 {
+	if hi <= lo {
+		return 0.0
+	}
+	return h.CDF(hi) - h.CDF(lo)
+}
+
+// Percentile returns the value that the share p [0, 1] of the values are
+// below, so 0.5 returns the median, 0 returns Min and 1 returns Max. Shares
+// that fall among the values under Min or over Max return Min or Max.
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.Total == 0 {
+		return 0.0
+	}
+	h.cumulate()
+	p = math.Max(0.0, math.Min(1.0, p))
+	target := p * float64(h.Total)
+	if target <= float64(h.Below) {
+		return h.Min
+	}
+
+	// the first bin that reaches the target, which isn't empty since the
+	// bins before it end short of the target
+	i := sort.Search(len(h.Counts), func(i int) bool { return float64(h.cumulative[i+1]) >= target })
+	if i >= len(h.Counts) {
+		return h.Max
+	}
+	t := (target - float64(h.cumulative[i])) / float64(h.Counts[i])
+	return h.Min + (float64(i)+t)*h.BinWidth()
+}
+
+// Distance returns the largest difference of the shares of the values of
+// two histograms with the same bins below the edges of the bins, which is
+// the Kolmogorov-Smirnov statistic of the binned values: 0 for the same
+// distribution and 1 for ones that don't overlap.
+func (h *Histogram) Distance(other *Histogram) (float64, error) {
+	if len(h.Counts) != len(other.Counts) || h.Min != other.Min || h.Max != other.Max {
+		return 0.0, fmt.Errorf("Unable to compare histograms with different bins.\n")
+	}
+	if h.Total == 0 || other.Total == 0 {
+		return 0.0, fmt.Errorf("Unable to compare an empty histogram.\n")
+	}
+	h.cumulate()
+	other.cumulate()
+	d := 0.0
+	for i := range h.cumulative {
+		a := float64(h.cumulative[i]) / float64(h.Total)
+		b := float64(other.cumulative[i]) / float64(other.Total)
+		d = math.Max(d, math.Abs(a-b))
+	}
+	return d, nil
+}
+
+// Remap replaces every value of the map with curve(p) where p is the share
+// of the values of the histogram below it. NaN values are left as they are.
+func (h *Histogram) Remap(nm *NoiseMap, curve func(p float64) float64) {
+	for i, v := range nm.Values {
+		if !math.IsNaN(v) {
+			nm.Values[i] = curve(h.CDF(v))
+		}
+	}
+}
+
+// PercentileKey is a value for a share of the values of a map in PercentileCurve().
+type PercentileKey struct {
+	Percentile float64 // the share in [0, 1] of the values
+	Value      float64 // the value it is mapped to
+}
+
+// PercentileCurve returns a curve for Histogram.Remap() that goes linearly
+// between the keys, which don't need to be in order. Shares before the first
+// key or after the last one get its value.
+func PercentileCurve(keys ...PercentileKey) func(p float64) float64 {
+	sorted := make([]PercentileKey, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Percentile < sorted[j].Percentile })
+
+	return func(p float64) float64 {
+		n := len(sorted)
+		if n == 0 {
+			return p
+		}
+		if p <= sorted[0].Percentile {
+			return sorted[0].Value
+		}
+		if p >= sorted[n-1].Percentile {
+			return sorted[n-1].Value
+		}
+		i := sort.Search(n, func(i int) bool { return sorted[i].Percentile > p })
+		a, b := sorted[i-1], sorted[i]
+		return lerp(a.Value, b.Value, (p-a.Percentile)/(b.Percentile-a.Percentile))
+	}
+}
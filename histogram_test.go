@@ -0,0 +1,123 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"math"
+	"testing"
+)
+
+// rampMap returns a 10x10 map with the values 0, 0.01, ... 0.99.
+func rampMap() NoiseMap {
+	nm := NewNoiseMap(10, 10, Builder2DBounds{0, 0, 1, 1})
+	for i := range nm.Values {
+		nm.Values[i] = float64(i) / 100.0
+	}
+	return nm
+}
+
+// TestHistogramQueries checks CDF(), Fraction() and Percentile() on a map
+// with a known distribution.
+func TestHistogramQueries(t *testing.T) {
+	nm := rampMap()
+	h := nm.Histogram(0)
+	width := h.BinWidth()
+
+	if h.Total != 100 || h.Min != 0.0 || h.Max != 0.99 {
+		t.Fatalf("got %v", h.String())
+	}
+	if v := h.CDF(-1.0); v != 0.0 {
+		t.Errorf("CDF() below Min returned %v", v)
+	}
+	if v := h.CDF(2.0); v != 1.0 {
+		t.Errorf("CDF() above Max returned %v", v)
+	}
+	if v := h.CDF(0.5); math.Abs(v-0.5) > 0.01 {
+		t.Errorf("CDF(0.5) returned %v; expected about 0.5", v)
+	}
+	if v := h.Fraction(0.2, 0.4); math.Abs(v-0.2) > 0.01 {
+		t.Errorf("Fraction(0.2, 0.4) returned %v; expected about 0.2", v)
+	}
+	if v := h.Percentile(0.0); v != 0.0 {
+		t.Errorf("Percentile(0) returned %v", v)
+	}
+	if v := h.Percentile(1.0); math.Abs(v-0.99) > width {
+		t.Errorf("Percentile(1) returned %v", v)
+	}
+
+	// 60% of the map below sea level
+	sea := h.Percentile(0.6)
+	if math.Abs(sea-0.6) > 0.01 {
+		t.Errorf("Percentile(0.6) returned %v; expected about 0.6", sea)
+	}
+	below := 0
+	for _, v := range nm.Values {
+		if v < sea {
+			below++
+		}
+	}
+	if below != 60 {
+		t.Errorf("%d values are below Percentile(0.6) = %v; expected 60", below, sea)
+	}
+
+	// the percentiles and the CDF undo each other
+	for _, p := range []float64{0.1, 0.25, 0.5, 0.75, 0.9} {
+		if v := h.CDF(h.Percentile(p)); math.Abs(v-p) > 1e-9 {
+			t.Errorf("CDF(Percentile(%v)) returned %v", p, v)
+		}
+	}
+}
+
+// TestHistogramRemap puts the sea level of a map at 0 with 60% of it below.
+func TestHistogramRemap(t *testing.T) {
+	nm := rampMap()
+	h := nm.Histogram(0)
+	h.Remap(&nm, PercentileCurve(PercentileKey{1.0, 1.0}, PercentileKey{0.0, -1.0}, PercentileKey{0.6, 0.0}))
+
+	below := 0
+	for i, v := range nm.Values {
+		if v < 0.0 {
+			below++
+		}
+		if i > 0 && v < nm.Values[i-1] {
+			t.Fatalf("Remap() changed the order of the values at %d: %v < %v", i, v, nm.Values[i-1])
+		}
+	}
+	if below != 60 {
+		t.Errorf("%d values are below the sea level; expected 60", below)
+	}
+	if nm.Values[0] != -1.0 || nm.Values[99] != 1.0 {
+		t.Errorf("Remap() mapped the extremes to %v and %v", nm.Values[0], nm.Values[99])
+	}
+}
+
+// TestHistogramOutliers makes sure values outside of the bounds and NaN
+// values are counted apart.
+func TestHistogramOutliers(t *testing.T) {
+	h := NewHistogramRange([]float64{-2.0, 0.25, 0.75, 3.0, math.NaN()}, 4, 0.0, 1.0)
+	if h.Below != 1 || h.Above != 1 || h.NaN != 1 || h.Total != 4 {
+		t.Fatalf("got %v", h.String())
+	}
+	if h.Counts[1] != 1 || h.Counts[3] != 1 {
+		t.Errorf("got the counts %v", h.Counts)
+	}
+	if v := h.CDF(0.0); v != 0.25 {
+		t.Errorf("CDF(Min) returned %v; expected the share below Min", v)
+	}
+	if v := h.CDF(1.0); v != 0.75 {
+		t.Errorf("CDF(Max) returned %v; expected all but the share above Max", v)
+	}
+
+	same := NewHistogramRange([]float64{-2.0, 0.25, 0.75, 3.0}, 4, 0.0, 1.0)
+	if d, err := h.Distance(&same); err != nil || d != 0.0 {
+		t.Errorf("Distance() to the same values returned %v, %v", d, err)
+	}
+	apart := NewHistogramRange([]float64{3.0, 4.0}, 4, 0.0, 1.0)
+	if d, err := h.Distance(&apart); err != nil || d != 0.75 {
+		t.Errorf("Distance() returned %v, %v; expected 0.75", d, err)
+	}
+	if _, err := h.Distance(&Histogram{Counts: make([]int, 2)}); err == nil {
+		t.Errorf("Distance() compared histograms with different bins")
+	}
+}
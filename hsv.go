@@ -0,0 +1,165 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module colors noise in HSV space, with the hue, saturation and value of
+every pixel each driven by its own generator, or held constant:
+
+	colorizer := noisey.NewHSVColorizer(&fbm)
+	colorizer.Value = noisey.HSVChannel{Source: &ridged, Min: 0.3, Max: 1.0}
+	img, err := colorizer.Image(256, 256, noisey.Builder2DBounds{0.0, 0.0, 1.0, 1.0})
+	frames, err := colorizer.CycleFrames(256, 256, bounds, 30)
+
+Each channel maps the [-1, 1] output of its Source linearly to [Min, Max],
+or uses Constant if it has no Source. Hue is measured in turns and wraps
+around, so a range of more than 1.0 goes around the color wheel more than
+once; saturation and value are clamped to [0, 1].
+
+HueShift turns every hue by that many turns. CycleFrames() makes the frames
+of a palette cycling animation that turns the hues once around the wheel,
+and since it only shifts the hues the channels are sampled just once.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// HSVChannel is one channel of an HSVColorizer.
+type HSVChannel struct {
+	Source   NoiseyGet2D // the optional generator driving the channel
+	Min      float64     // the channel's value where Source is -1.0
+	Max      float64     // the channel's value where Source is 1.0
+	Constant float64     // the channel's value if there is no Source
+}
+
+// get returns the value of the channel at the coordinate.
+func (ch *HSVChannel) get(x float64, y float64) float64 {
+	if ch.Source == nil {
+		return ch.Constant
+	}
+	return lerp(ch.Min, ch.Max, (ch.Source.Get2D(x, y)+1.0)*0.5)
+}
+
+// String returns a description of the channel and its source.
+func (ch *HSVChannel) String() string {
+	return fmt.Sprintf("HSVChannel{Min: %v, Max: %v, Constant: %v, Source: %s}", ch.Min, ch.Max, ch.Constant, describeModule(ch.Source))
+}
+
+// HSVColorizer colors noise by driving the hue, saturation and value of the
+// pixels separately.
+type HSVColorizer struct {
+	Hue        HSVChannel // the hue in turns
+	Saturation HSVChannel // the saturation [0, 1]
+	Value      HSVChannel // the value [0, 1]
+	HueShift   float64    // the turns added to every hue
+}
+
+// NewHSVColorizer creates a new colorizer with hue driving the hue once
+// around the color wheel at full saturation and value.
+func NewHSVColorizer(hue NoiseyGet2D) (hc HSVColorizer) {
+	hc.Hue = HSVChannel{Source: hue, Min: 0.0, Max: 1.0}
+	hc.Saturation = HSVChannel{Constant: 1.0}
+	hc.Value = HSVChannel{Constant: 1.0}
+	return
+}
+
+// String returns a description of the colorizer and its channels.
+func (hc *HSVColorizer) String() string {
+	return fmt.Sprintf("HSVColorizer{HueShift: %v, Hue: %s, Saturation: %s, Value: %s}",
+		hc.HueShift, hc.Hue.String(), hc.Saturation.String(), hc.Value.String())
+}
+
+// hsvToRGB converts a hue in turns and a saturation and value in [0, 1] to
+// an opaque color.
+func hsvToRGB(h float64, s float64, v float64) color.NRGBA {
+	h = (h - math.Floor(h)) * 6.0
+	s = math.Max(0.0, math.Min(1.0, s))
+	v = math.Max(0.0, math.Min(1.0, v))
+	sector := int(h) % 6
+	f := h - math.Floor(h)
+	p, q, t := v*(1.0-s), v*(1.0-s*f), v*(1.0-s*(1.0-f))
+	var r, g, b float64
+	switch sector {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	channel := func(c float64) uint8 { return uint8(math.Floor(c*255.0 + 0.5)) }
+	return color.NRGBA{channel(r), channel(g), channel(b), 255}
+}
+
+// Color returns the color at the coordinate.
+func (hc *HSVColorizer) Color(x float64, y float64) color.NRGBA {
+	return hsvToRGB(hc.Hue.get(x, y)+hc.HueShift, hc.Saturation.get(x, y), hc.Value.get(x, y))
+}
+
+// sample returns the hue, saturation and value of every pixel of an image
+// of width x height over bounds, stepping like Builder2D.
+func (hc *HSVColorizer) sample(width int, height int, bounds Builder2DBounds) (hsv []Vec3f, err error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("Unable to color an image of size %dx%d.\n", width, height)
+	}
+	hsv = make([]Vec3f, width*height)
+	dx := (bounds.MaxX - bounds.MinX) / float64(width)
+	dy := (bounds.MaxY - bounds.MinY) / float64(height)
+	for py := 0; py < height; py++ {
+		y := bounds.MinY + float64(py)*dy
+		for px := 0; px < width; px++ {
+			x := bounds.MinX + float64(px)*dx
+			hsv[py*width+px] = Vec3f{hc.Hue.get(x, y), hc.Saturation.get(x, y), hc.Value.get(x, y)}
+		}
+	}
+	return hsv, nil
+}
+
+// hsvImage returns the image of the sampled channels with the hues turned by shift.
+func hsvImage(hsv []Vec3f, width int, height int, shift float64) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, c := range hsv {
+		img.SetNRGBA(i%width, i/width, hsvToRGB(c.X+shift, c.Y, c.Z))
+	}
+	return img
+}
+
+// Image returns an image of width x height pixels over bounds. Row y of the
+// image is row y of a map built over the same bounds.
+func (hc *HSVColorizer) Image(width int, height int, bounds Builder2DBounds) (*image.NRGBA, error) {
+	hsv, err := hc.sample(width, height, bounds)
+	if err != nil {
+		return nil, err
+	}
+	return hsvImage(hsv, width, height, hc.HueShift), nil
+}
+
+// CycleFrames returns the frames of a palette cycling animation where the
+// hues turn once around the color wheel over the frames.
+func (hc *HSVColorizer) CycleFrames(width int, height int, bounds Builder2DBounds, frames int) ([]*image.NRGBA, error) {
+	if frames <= 0 {
+		return nil, fmt.Errorf("Unable to make a palette cycle of %d frames.\n", frames)
+	}
+	hsv, err := hc.sample(width, height, bounds)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]*image.NRGBA, frames)
+	for f := range images {
+		images[f] = hsvImage(hsv, width, height, hc.HueShift+float64(f)/float64(frames))
+	}
+	return images, nil
+}
@@ -0,0 +1,234 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module analyzes how water flows over a built heightmap. It finds the
+flow direction of every grid point, how many grid points drain through each
+one and which watershed each one belongs to.
+
+	flow, err := noisey.AnalyzeFlow(&heights, noisey.FlowDInf)
+	if err != nil {
+		panic(err)
+	}
+	// high values in flow.Accumulation are good places for rivers
+
+Two methods are supported for the flow directions. D8 sends all of the water
+to the steepest of the eight neighbors while D-infinity picks a direction
+from the steepest facet of the surface and splits the water between the two
+neighbors on either side of it, which makes smoother accumulation maps. The
+watersheds always follow the D8 directions.
+
+Reference material:
+* O'Callaghan and Mark's "The extraction of drainage networks from digital elevation data" (D8)
+* Tarboton's "A new method for the determination of flow directions and upslope areas in grid digital elevation models" (D-infinity)
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FlowMethod is the method used to determine how water flows between grid points.
+type FlowMethod int
+
+const (
+	// FlowD8 sends all water to the steepest downslope neighbor.
+	FlowD8 FlowMethod = iota
+
+	// FlowDInf splits water between the two neighbors of the steepest facet.
+	FlowDInf
+)
+
+// String returns the name of the flow method.
+func (m FlowMethod) String() string {
+	switch m {
+	case FlowD8:
+		return "FlowD8"
+	case FlowDInf:
+		return "FlowDInf"
+	}
+	return fmt.Sprintf("FlowMethod(%d)", int(m))
+}
+
+// D8Offsets are the offsets to the neighbors used by the D8 directions. They
+// start east and go clockwise when the Y axis points down, so direction d has
+// the angle d*Pi/4 from the X axis towards the Y axis.
+var D8Offsets = [8]Vec2i{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+// dInfFacets are the D-infinity facets as the D8 direction of their cardinal
+// neighbor, of their diagonal neighbor and the sign of the angle between them.
+var dInfFacets = [8]struct {
+	cardinal, diagonal int
+	sign               float64
+}{
+	{0, 1, 1}, {2, 1, -1}, {2, 3, 1}, {4, 3, -1},
+	{4, 5, 1}, {6, 5, -1}, {6, 7, 1}, {0, 7, -1},
+}
+
+// FlowAnalysis contains the results of AnalyzeFlow().
+type FlowAnalysis struct {
+	Width  int
+	Height int
+	Method FlowMethod
+
+	// Directions are the D8 directions of the steepest downslope neighbor of
+	// each grid point as indexes into D8Offsets, or -1 for pits, flats and
+	// edges that drain off of the map
+	Directions []int
+
+	// Angles are the D-infinity flow angles in radians of each grid point in
+	// the range [0, 2*Pi) or NaN where water doesn't flow; only set for FlowDInf
+	Angles []float64
+
+	// Accumulation is the number of grid points, including itself, that drain
+	// through each grid point
+	Accumulation NoiseMap
+
+	// Watersheds is the index into Outlets of the grid point that each grid point drains to
+	Watersheds []int
+
+	// Outlets are the grid points where water stops flowing
+	Outlets []Vec2i
+}
+
+// d8Direction returns the direction of the steepest downslope neighbor of (x, y) or -1.
+func d8Direction(hm *NoiseMap, x int, y int) int {
+	v := hm.Values[y*hm.Width+x]
+	best := -1
+	bestSlope := 0.0
+	for d, o := range D8Offsets {
+		nx, ny := x+o.X, y+o.Y
+		if nx < 0 || nx >= hm.Width || ny < 0 || ny >= hm.Height {
+			continue
+		}
+		dist := 1.0
+		if o.X != 0 && o.Y != 0 {
+			dist = math.Sqrt2
+		}
+		if slope := (v - hm.Values[ny*hm.Width+nx]) / dist; slope > bestSlope {
+			best = d
+			bestSlope = slope
+		}
+	}
+	return best
+}
+
+// dInfDirection returns the D-infinity flow angle at (x, y), the D8
+// directions of the two receiving neighbors and the fraction of water that
+// goes to the first one. ok is false if water doesn't flow.
+func dInfDirection(hm *NoiseMap, x int, y int) (angle float64, a int, b int, fraction float64, ok bool) {
+	e0 := hm.Values[y*hm.Width+x]
+	bestSlope := 0.0
+	for _, f := range dInfFacets {
+		c, d := D8Offsets[f.cardinal], D8Offsets[f.diagonal]
+		cx, cy, dx, dy := x+c.X, y+c.Y, x+d.X, y+d.Y
+		if cx < 0 || cx >= hm.Width || cy < 0 || cy >= hm.Height || dx < 0 || dx >= hm.Width || dy < 0 || dy >= hm.Height {
+			continue
+		}
+		e1 := hm.Values[cy*hm.Width+cx]
+		e2 := hm.Values[dy*hm.Width+dx]
+
+		s1 := e0 - e1
+		s2 := e1 - e2
+		var r, s float64
+		if s1 > 0.0 && s2 > 0.0 && s2 < s1 {
+			r = math.Atan2(s2, s1)
+			s = math.Sqrt(s1*s1 + s2*s2)
+		} else if s1 > 0.0 && s1 >= (e0-e2)/math.Sqrt2 {
+			r = 0.0
+			s = s1
+		} else {
+			r = math.Pi / 4.0
+			s = (e0 - e2) / math.Sqrt2
+		}
+
+		if s > bestSlope {
+			bestSlope = s
+			angle = float64(f.cardinal)*math.Pi/4.0 + f.sign*r
+			a, b = f.cardinal, f.diagonal
+			fraction = 1.0 - r/(math.Pi/4.0)
+			ok = true
+		}
+	}
+	if angle < 0.0 {
+		angle += 2.0 * math.Pi
+	}
+	return
+}
+
+// AnalyzeFlow calculates the flow directions, flow accumulation and
+// watersheds of heightmap using method.
+func AnalyzeFlow(heightmap *NoiseMap, method FlowMethod) (fa FlowAnalysis, err error) {
+	if heightmap.Width < 1 || heightmap.Height < 1 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return fa, fmt.Errorf("Unable to analyze a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+
+	w, h := heightmap.Width, heightmap.Height
+	n := w * h
+	fa.Width = w
+	fa.Height = h
+	fa.Method = method
+	fa.Directions = make([]int, n)
+	fa.Accumulation = NewNoiseMap(w, h, heightmap.Bounds)
+	fa.Watersheds = make([]int, n)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fa.Directions[y*w+x] = d8Direction(heightmap, x, y)
+		}
+	}
+
+	// water only flows downhill so visiting the grid points from highest to
+	// lowest passes all of the water on before the receivers are visited
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return heightmap.Values[order[i]] > heightmap.Values[order[j]]
+	})
+
+	acc := fa.Accumulation.Values
+	for i := range acc {
+		acc[i] = 1.0
+	}
+	if method == FlowDInf {
+		fa.Angles = make([]float64, n)
+	}
+	for _, i := range order {
+		x, y := i%w, i/w
+		if method == FlowDInf {
+			angle, a, b, fraction, ok := dInfDirection(heightmap, x, y)
+			if !ok {
+				fa.Angles[i] = math.NaN()
+				continue
+			}
+			fa.Angles[i] = angle
+			oa, ob := D8Offsets[a], D8Offsets[b]
+			acc[(y+oa.Y)*w+x+oa.X] += acc[i] * fraction
+			acc[(y+ob.Y)*w+x+ob.X] += acc[i] * (1.0 - fraction)
+		} else if d := fa.Directions[i]; d >= 0 {
+			o := D8Offsets[d]
+			acc[(y+o.Y)*w+x+o.X] += acc[i]
+		}
+	}
+
+	// label the watersheds from the lowest grid points up
+	for k := n - 1; k >= 0; k-- {
+		i := order[k]
+		d := fa.Directions[i]
+		if d < 0 {
+			fa.Watersheds[i] = len(fa.Outlets)
+			fa.Outlets = append(fa.Outlets, Vec2i{i % w, i / w})
+			continue
+		}
+		o := D8Offsets[d]
+		fa.Watersheds[i] = fa.Watersheds[(i/w+o.Y)*w+i%w+o.X]
+	}
+
+	return fa, nil
+}
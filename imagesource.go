@@ -0,0 +1,181 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module wraps an image so that hand painted masks, photographs and
+elevation scans can be used as a source for the other modules:
+
+	file, _ := os.Open("mask.png")
+	img, _, _ := image.Decode(file)
+	mask := noisey.NewImageSource(img, noisey.ImageLuminance)
+	mask.Map.Bounds = noisey.Builder2DBounds{0.0, 0.0, 4.0, 4.0}
+	mask.Wrap, mask.Map.Interpolation = noisey.WrapRepeat, noisey.MapBicubic
+	blended := noisey.NewSelect2D(&fbm, &ridged, &mask, 0.0, 0.5, 0.1)
+
+The selected channel of every pixel is read once, at 16 bits so that 16 bit
+grayscale elevation scans keep their precision, and is mapped from
+[0, 65535] to [-1, 1]. The values are kept in Map, a NoiseMap over the unit
+square, which sets where the image lies and how it's blended between pixels.
+
+Wrap sets what lies outside of the image: WrapClamp repeats the pixels of
+the edges, WrapRepeat tiles the image and WrapMirror tiles it reflecting
+every other tile so that images that don't tile have no seams. The
+interpolation blends across the wrap, so a tileable image stays seamless.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ImageChannel selects which channel of an image an ImageSource reads.
+type ImageChannel int
+
+const (
+	ImageLuminance ImageChannel = iota // the brightness of the color
+	ImageRed                           // the red channel
+	ImageGreen                         // the green channel
+	ImageBlue                          // the blue channel
+	ImageAlpha                         // the alpha channel
+)
+
+// String returns the name of the channel.
+func (ch ImageChannel) String() string {
+	switch ch {
+	case ImageLuminance:
+		return "ImageLuminance"
+	case ImageRed:
+		return "ImageRed"
+	case ImageGreen:
+		return "ImageGreen"
+	case ImageBlue:
+		return "ImageBlue"
+	case ImageAlpha:
+		return "ImageAlpha"
+	}
+	return fmt.Sprintf("ImageChannel(%d)", int(ch))
+}
+
+// ImageWrap selects what an ImageSource returns outside of its image.
+type ImageWrap int
+
+const (
+	WrapClamp  ImageWrap = iota // repeat the pixels of the edges
+	WrapRepeat                  // tile the image
+	WrapMirror                  // tile the image reflecting every other tile
+)
+
+// String returns the name of the wrap mode.
+func (w ImageWrap) String() string {
+	switch w {
+	case WrapClamp:
+		return "WrapClamp"
+	case WrapRepeat:
+		return "WrapRepeat"
+	case WrapMirror:
+		return "WrapMirror"
+	}
+	return fmt.Sprintf("ImageWrap(%d)", int(w))
+}
+
+// ImageSource is a module that returns a channel of an image.
+type ImageSource struct {
+	Map  NoiseMap  // the channel's values in [-1, 1] with one value per pixel
+	Wrap ImageWrap // what is returned outside of the image
+}
+
+// imageChannel returns the channel of the color in [0, 65535].
+func imageChannel(c color.Color, ch ImageChannel) uint32 {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	switch ch {
+	case ImageRed:
+		return uint32(n.R)
+	case ImageGreen:
+		return uint32(n.G)
+	case ImageBlue:
+		return uint32(n.B)
+	case ImageAlpha:
+		return uint32(n.A)
+	}
+	// the same weights as color.GrayModel
+	return (19595*uint32(n.R) + 38470*uint32(n.G) + 7471*uint32(n.B) + 1<<15) >> 16
+}
+
+// NewImageSource creates a new source from the channel of the image covering
+// the unit square, with bilinear blending and the edges clamped.
+func NewImageSource(img image.Image, ch ImageChannel) (src ImageSource) {
+	r := img.Bounds()
+	src.Map = NewNoiseMap(r.Dx(), r.Dy(), Builder2DBounds{0.0, 0.0, 1.0, 1.0})
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			v := imageChannel(img.At(r.Min.X+x, r.Min.Y+y), ch)
+			src.Map.Values[y*r.Dx()+x] = float64(v)/65535.0*2.0 - 1.0
+		}
+	}
+	return
+}
+
+// Clone2D returns a copy of the source that does not share its values.
+func (src *ImageSource) Clone2D() NoiseyGet2D {
+	c := *src
+	c.Map = src.Map.Clone()
+	return &c
+}
+
+// String returns a description of the source.
+func (src *ImageSource) String() string {
+	return fmt.Sprintf("ImageSource{Wrap: %v, Map: %s}", src.Wrap, src.Map.String())
+}
+
+// wrapIndex maps a grid index onto [0, n) as set by Wrap.
+func (src *ImageSource) wrapIndex(i int, n int) int {
+	switch src.Wrap {
+	case WrapRepeat:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+	case WrapMirror:
+		i %= 2 * n
+		if i < 0 {
+			i += 2 * n
+		}
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+	}
+	return i
+}
+
+// get returns the value of the pixel (x, y) with the coordinates wrapped.
+func (src *ImageSource) get(x int, y int) float64 {
+	return src.Map.Get(src.wrapIndex(x, src.Map.Width), src.wrapIndex(y, src.Map.Height))
+}
+
+// Get2D returns the value of the image at the coordinate.
+func (src *ImageSource) Get2D(x float64, y float64) float64 {
+	nm := &src.Map
+	if nm.Width <= 0 || nm.Height <= 0 {
+		return 0.0
+	}
+	gx, gy := nm.toGrid(x, y)
+	switch nm.Interpolation {
+	case MapNearest:
+		return src.get(int(math.Floor(gx+0.5)), int(math.Floor(gy+0.5)))
+	case MapBicubic:
+		return bicubicGrid(src.get, gx, gy)
+	default:
+		return bilinearGrid(src.get, gx, gy)
+	}
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are those of Map.
+func (src *ImageSource) Range2D() (min float64, max float64) {
+	return src.Map.Range2D()
+}
@@ -0,0 +1,176 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package ebitennoise turns noise into images for games written with
+github.com/hajimehoshi/ebiten/v2, so that a NoiseMap or an animated pipeline
+can be drawn without writing the conversion by hand.
+
+NewImage() makes an *ebiten.Image of a NoiseMap and UpdateImage() writes a
+NoiseMap into an image that already exists:
+
+	img := ebitennoise.NewImage(&nm, &gradient)
+	screen.DrawImage(img, nil)
+
+Animated samples a 3D pipeline with Z as time and keeps an image of it up
+to date. Calling Update() from the Update() of the game moves the time on
+and samples RowsPerUpdate rows of the image again, so the cost of a large
+animated image can be spread over several updates:
+
+	anim := ebitennoise.NewAnimated(&fbm3d, 256, 256)
+	anim.Speed = 0.25
+	anim.RowsPerUpdate = 32
+
+	// in the Update() of the game
+	anim.Update(1.0 / float64(ebiten.TPS()))
+
+	// in the Draw() of the game
+	screen.DrawImage(anim.Image(), nil)
+
+Values are colored with a ColorGradient, or as grayscale with [-1, 1] mapped
+to black and white if the gradient is nil or has no keys, like the
+exporters of noisey. It lives in its own package so that noisey itself does
+not depend on Ebiten.
+*/
+package ebitennoise
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tbogdala/noisey"
+)
+
+// valueColor returns the color of a value.
+func valueColor(v float64, cg *noisey.ColorGradient) color.NRGBA {
+	if cg == nil || len(cg.Keys) == 0 {
+		g := (v + 1.0) * 0.5 * 255.0
+		switch {
+		case g < 0.0 || g != g:
+			g = 0.0
+		case g > 255.0:
+			g = 255.0
+		}
+		return color.NRGBA{uint8(g + 0.5), uint8(g + 0.5), uint8(g + 0.5), 255}
+	}
+	return cg.Color(v)
+}
+
+// putPixel writes the color into pix as premultiplied RGBA, which is what
+// ebiten.Image.WritePixels() takes.
+func putPixel(pix []byte, c color.NRGBA) {
+	a := uint32(c.A)
+	pix[0] = uint8((uint32(c.R)*a + 127) / 255)
+	pix[1] = uint8((uint32(c.G)*a + 127) / 255)
+	pix[2] = uint8((uint32(c.B)*a + 127) / 255)
+	pix[3] = c.A
+}
+
+// Pixels returns the colored values of the map as premultiplied RGBA, four
+// bytes a pixel row by row from the top left. It reuses dst if it is large
+// enough.
+func Pixels(dst []byte, nm *noisey.NoiseMap, cg *noisey.ColorGradient) []byte {
+	size := 4 * nm.Width * nm.Height
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	for i, v := range nm.Values[:nm.Width*nm.Height] {
+		putPixel(dst[i*4:], valueColor(v, cg))
+	}
+	return dst
+}
+
+// NewImage creates a new image of the map.
+func NewImage(nm *noisey.NoiseMap, cg *noisey.ColorGradient) *ebiten.Image {
+	img := ebiten.NewImage(nm.Width, nm.Height)
+	img.WritePixels(Pixels(nil, nm, cg))
+	return img
+}
+
+// UpdateImage writes the map into an image of the same size.
+func UpdateImage(img *ebiten.Image, nm *noisey.NoiseMap, cg *noisey.ColorGradient) error {
+	b := img.Bounds()
+	if b.Dx() != nm.Width || b.Dy() != nm.Height {
+		return fmt.Errorf("Unable to write a %dx%d map into a %dx%d image.\n", nm.Width, nm.Height, b.Dx(), b.Dy())
+	}
+	img.WritePixels(Pixels(nil, nm, cg))
+	return nil
+}
+
+// Animated keeps an image of a 3D pipeline at a time that moves on.
+type Animated struct {
+	Source        noisey.NoiseyGet3D     // the pipeline with Z as time
+	Bounds        noisey.Builder2DBounds // the area of the pipeline in the image
+	Gradient      *noisey.ColorGradient  // the colors of the image; grayscale if nil
+	Time          float64                // the Z the next rows are sampled at
+	Speed         float64                // how far Time moves in a second
+	RowsPerUpdate int                    // the rows sampled again by Update(); every row if 0
+
+	width  int
+	height int
+	row    int // the row Update() samples next
+	pix    []byte
+	image  *ebiten.Image
+}
+
+// NewAnimated creates a new animated image of width x height pixels of the
+// pipeline over the unit square, moving one unit of time a second.
+func NewAnimated(src noisey.NoiseyGet3D, width int, height int) (a *Animated) {
+	a = new(Animated)
+	a.Source = src
+	a.Bounds = noisey.Builder2DBounds{MinX: 0.0, MinY: 0.0, MaxX: 1.0, MaxY: 1.0}
+	a.Speed = 1.0
+	a.width = width
+	a.height = height
+	a.pix = make([]byte, 4*width*height)
+	return a
+}
+
+// sampleRows samples the rows from y0 up to y1 at the current time and
+// writes them into the image.
+func (a *Animated) sampleRows(y0 int, y1 int) {
+	xDelta := (a.Bounds.MaxX - a.Bounds.MinX) / float64(a.width)
+	yDelta := (a.Bounds.MaxY - a.Bounds.MinY) / float64(a.height)
+	for y := y0; y < y1; y++ {
+		wy := a.Bounds.MinY + float64(y)*yDelta
+		for x := 0; x < a.width; x++ {
+			v := a.Source.Get3D(a.Bounds.MinX+float64(x)*xDelta, wy, a.Time)
+			putPixel(a.pix[(y*a.width+x)*4:], valueColor(v, a.Gradient))
+		}
+	}
+	rows := a.image.SubImage(image.Rect(0, y0, a.width, y1)).(*ebiten.Image)
+	rows.WritePixels(a.pix[y0*a.width*4 : y1*a.width*4])
+}
+
+// Image returns the image, sampling all of it the first time.
+func (a *Animated) Image() *ebiten.Image {
+	if a.image == nil {
+		a.image = ebiten.NewImage(a.width, a.height)
+		a.sampleRows(0, a.height)
+	}
+	return a.image
+}
+
+// Update moves the time on by dt seconds and samples the next
+// RowsPerUpdate rows of the image at the new time, going back to the top
+// when it reaches the bottom.
+func (a *Animated) Update(dt float64) {
+	a.Image()
+	a.Time += dt * a.Speed
+	rows := a.RowsPerUpdate
+	if rows <= 0 || rows > a.height {
+		rows = a.height
+	}
+	for rows > 0 {
+		end := a.row + rows
+		if end > a.height {
+			end = a.height
+		}
+		a.sampleRows(a.row, end)
+		rows -= end - a.row
+		a.row = end % a.height
+	}
+}
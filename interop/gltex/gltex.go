@@ -0,0 +1,222 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package gltex creates and updates OpenGL textures from noise with
+github.com/go-gl/gl/v3.3-core/gl, as the OpenGL examples of noisey do:
+
+	nm := builder.GetNoiseMap()
+	heights, err := gltex.NewFloat(&nm, gltex.DefaultParams)
+	colors, err := gltex.NewRGBA(gradient.Image(&nm), gltex.DefaultParams)
+
+	// later, with a map of the same size
+	err = gltex.UpdateFloat(heights, &nm)
+
+Float textures hold the values of a NoiseMap as they are in a single R32F
+channel, for shaders that color or displace by them. RGB and RGBA textures
+hold 8 bit colors, such as the image of a ColorGradient. Row 0 of a map or
+image is the first row of the texture, at a V of 0.
+
+Tightly packed rows of RGB or float data don't always line up with the
+default unpack alignment of 4 bytes, so every upload sets the alignment to
+1 and puts it back afterwards. The functions bind the texture to
+TEXTURE_2D of the active texture unit and need a current GL context.
+
+It lives in its own package so that noisey itself does not depend on
+go-gl.
+*/
+package gltex
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/tbogdala/noisey"
+)
+
+// Params are the filtering and wrapping of a texture.
+type Params struct {
+	MinFilter int32 // the TEXTURE_MIN_FILTER, like gl.LINEAR
+	MagFilter int32 // the TEXTURE_MAG_FILTER, like gl.LINEAR
+	WrapS     int32 // the TEXTURE_WRAP_S, like gl.REPEAT
+	WrapT     int32 // the TEXTURE_WRAP_T, like gl.REPEAT
+}
+
+// DefaultParams filter linearly and repeat, which suits tileable noise.
+var DefaultParams = Params{gl.LINEAR, gl.LINEAR, gl.REPEAT, gl.REPEAT}
+
+// create generates a texture, binds it and sets its parameters.
+func create(p Params) (tex uint32) {
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, p.MinFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, p.MagFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, p.WrapS)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, p.WrapT)
+	return tex
+}
+
+// unpacked runs an upload with the unpack alignment at 1 and the row
+// length at rowLength pixels, or the width of the upload if 0.
+func unpacked(rowLength int32, upload func()) {
+	var alignment, length int32
+	gl.GetIntegerv(gl.UNPACK_ALIGNMENT, &alignment)
+	gl.GetIntegerv(gl.UNPACK_ROW_LENGTH, &length)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, rowLength)
+	upload()
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, alignment)
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, length)
+}
+
+// floats returns the values of the map as float32.
+func floats(nm *noisey.NoiseMap) ([]float32, error) {
+	if nm.Width <= 0 || nm.Height <= 0 || len(nm.Values) < nm.Width*nm.Height {
+		return nil, fmt.Errorf("Unable to make a texture of a %dx%d map with %d values.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	data := make([]float32, nm.Width*nm.Height)
+	for i := range data {
+		data[i] = float32(nm.Values[i])
+	}
+	return data, nil
+}
+
+// textureSize returns the size of level 0 of the bound texture.
+func textureSize() (w int32, h int32) {
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &w)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &h)
+	return w, h
+}
+
+// checkSize returns an error if the bound texture isn't w x h.
+func checkSize(w int32, h int32) error {
+	tw, th := textureSize()
+	if tw != w || th != h {
+		return fmt.Errorf("Unable to update a %dx%d texture with %dx%d pixels.\n", tw, th, w, h)
+	}
+	return nil
+}
+
+// NewFloat creates a texture holding the values of the map in one R32F channel.
+func NewFloat(nm *noisey.NoiseMap, p Params) (uint32, error) {
+	data, err := floats(nm)
+	if err != nil {
+		return 0, err
+	}
+	tex := create(p)
+	unpacked(0, func() {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R32F, int32(nm.Width), int32(nm.Height), 0, gl.RED, gl.FLOAT, gl.Ptr(data))
+	})
+	return tex, nil
+}
+
+// UpdateFloat writes the values of a map into a float texture of its size.
+func UpdateFloat(tex uint32, nm *noisey.NoiseMap) error {
+	data, err := floats(nm)
+	if err != nil {
+		return err
+	}
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	if err := checkSize(int32(nm.Width), int32(nm.Height)); err != nil {
+		return err
+	}
+	unpacked(0, func() {
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(nm.Width), int32(nm.Height), gl.RED, gl.FLOAT, gl.Ptr(data))
+	})
+	return nil
+}
+
+// checkPixels returns an error if pix is too short for width x height
+// pixels of size bytes.
+func checkPixels(pix []byte, width int32, height int32, size int) error {
+	if width <= 0 || height <= 0 || len(pix) < int(width)*int(height)*size {
+		return fmt.Errorf("Unable to make a %dx%d texture of %d bytes.\n", width, height, len(pix))
+	}
+	return nil
+}
+
+// NewRGB creates a texture of tightly packed 8 bit RGB triplets, row by row.
+func NewRGB(rgb []byte, width int32, height int32, p Params) (uint32, error) {
+	if err := checkPixels(rgb, width, height, 3); err != nil {
+		return 0, err
+	}
+	tex := create(p)
+	unpacked(0, func() {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB8, width, height, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(rgb))
+	})
+	return tex, nil
+}
+
+// UpdateRGB writes tightly packed RGB triplets into an RGB texture of their size.
+func UpdateRGB(tex uint32, rgb []byte, width int32, height int32) error {
+	if err := checkPixels(rgb, width, height, 3); err != nil {
+		return err
+	}
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	if err := checkSize(width, height); err != nil {
+		return err
+	}
+	unpacked(0, func() {
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, width, height, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(rgb))
+	})
+	return nil
+}
+
+// imagePixels returns the first pixel of the image and its row length in
+// pixels, which lets sub-images be uploaded without copying them.
+func imagePixels(img *image.NRGBA) (unsafe.Pointer, int32, int32, int32, error) {
+	w, h := int32(img.Rect.Dx()), int32(img.Rect.Dy())
+	if w <= 0 || h <= 0 || img.Stride%4 != 0 {
+		return nil, 0, 0, 0, fmt.Errorf("Unable to make a texture of a %dx%d image.\n", w, h)
+	}
+	return gl.Ptr(img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):]), w, h, int32(img.Stride / 4), nil
+}
+
+// NewRGBA creates a texture of the image, such as the Image() of a
+// ColorGradient.
+func NewRGBA(img *image.NRGBA, p Params) (uint32, error) {
+	pix, w, h, rowLength, err := imagePixels(img)
+	if err != nil {
+		return 0, err
+	}
+	tex := create(p)
+	unpacked(rowLength, func() {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, pix)
+	})
+	return tex, nil
+}
+
+// UpdateRGBA writes the image into an RGBA texture of its size.
+func UpdateRGBA(tex uint32, img *image.NRGBA) error {
+	pix, w, h, rowLength, err := imagePixels(img)
+	if err != nil {
+		return err
+	}
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	if err := checkSize(w, h); err != nil {
+		return err
+	}
+	unpacked(rowLength, func() {
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, w, h, gl.RGBA, gl.UNSIGNED_BYTE, pix)
+	})
+	return nil
+}
+
+// GrayRGB returns the values of the map as gray RGB triplets for NewRGB(),
+// with [-1, 1] mapped to [0, 255].
+func GrayRGB(nm *noisey.NoiseMap) []byte {
+	rgb := make([]byte, nm.Width*nm.Height*3)
+	for i := 0; i < nm.Width*nm.Height; i++ {
+		g := (nm.Values[i]*0.5 + 0.5) * 255.0
+		switch {
+		case g < 0.0 || g != g:
+			g = 0.0
+		case g > 255.0:
+			g = 255.0
+		}
+		rgb[i*3], rgb[i*3+1], rgb[i*3+2] = byte(g), byte(g), byte(g)
+	}
+	return rgb
+}
@@ -0,0 +1,343 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package godot exports noise maps as heightmaps that Godot 4 and its terrain
+plugins can load as they are, together with small text resources that
+describe how to scale them:
+
+	nm := builder.GetNoiseMap()
+	opts := godot.Options{Format: godot.EXR, HeightScale: 120.0, Spacing: 2.0}
+	err := godot.Export("terrain/island.exr", &nm, opts)
+
+Export writes three files next to each other:
+
+  - island.exr: the heightmap, either an OpenEXR image with the heights in
+    world units in its R channel, or a raw little endian 16 bit .r16 file
+    with [-1, 1] mapped to [0, 65535]
+  - island.exr.import: the import settings of an EXR, keeping it lossless
+    and without mipmaps so the heights aren't blurred or quantized
+  - island.tres: a Resource whose metadata holds the file name, the size,
+    the spacing and the range of heights, for scripts and plugins that set
+    up the terrain
+
+Terrain3D and HTerrain import EXR and .r16 heightmaps and ask for the range
+of heights, which the sidecar records. For plain physics,
+WriteHeightMapShape() writes a HeightMapShape3D resource with the heights
+in it, which only needs the collision shape to be scaled by Spacing.
+
+The first row of the map is the -Z edge of the terrain and the first column
+the -X edge, matching the way Godot reads heightmaps.
+*/
+package godot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// Format is the file format of an exported heightmap.
+type Format int
+
+const (
+	EXR Format = iota // an OpenEXR image of 32 bit float heights in world units
+	R16               // raw little endian 16 bit heights with [-1, 1] mapped to [0, 65535]
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case EXR:
+		return "exr"
+	case R16:
+		return "r16"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Extension returns the file extension of the format, including the dot.
+func (f Format) Extension() string {
+	return "." + f.String()
+}
+
+// Options controls how a map is exported.
+type Options struct {
+	// Format is the file format of the heightmap
+	Format Format
+
+	// HeightScale is the height in world units of a value of 1
+	HeightScale float64
+
+	// Spacing is the distance in world units between two samples
+	Spacing float64
+}
+
+// DefaultOptions exports an EXR with the values as they are, one unit apart.
+var DefaultOptions = Options{Format: EXR, HeightScale: 1.0, Spacing: 1.0}
+
+// check returns an error if the map can't be exported.
+func check(nm *noisey.NoiseMap) error {
+	if nm.Width <= 0 || nm.Height <= 0 || len(nm.Values) < nm.Width*nm.Height {
+		return fmt.Errorf("Unable to export a %dx%d map with %d values to Godot.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	return nil
+}
+
+// heightRange returns the lowest and highest heights of the exported
+// heightmap in world units. For R16 it is the range the 16 bit values map
+// to, otherwise the range of the values in the map, ignoring NaN.
+func heightRange(nm *noisey.NoiseMap, opts Options) (min float64, max float64) {
+	if opts.Format == R16 {
+		return -opts.HeightScale, opts.HeightScale
+	}
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, v := range nm.Values[:nm.Width*nm.Height] {
+		if math.IsNaN(v) {
+			continue
+		}
+		min = math.Min(min, v*opts.HeightScale)
+		max = math.Max(max, v*opts.HeightScale)
+	}
+	if min > max {
+		return 0.0, 0.0
+	}
+	return min, max
+}
+
+// WriteEXR writes the map to w as an uncompressed scanline OpenEXR image
+// with the values times heightScale in a single 32 bit float R channel.
+func WriteEXR(w io.Writer, nm *noisey.NoiseMap, heightScale float64) error {
+	if err := check(nm); err != nil {
+		return err
+	}
+	le := binary.LittleEndian
+	var header bytes.Buffer
+	attribute := func(name string, kind string, value []byte) {
+		header.WriteString(name)
+		header.WriteByte(0)
+		header.WriteString(kind)
+		header.WriteByte(0)
+		binary.Write(&header, le, int32(len(value)))
+		header.Write(value)
+	}
+	box := func(width int, height int) []byte {
+		b := make([]byte, 16)
+		le.PutUint32(b[8:], uint32(int32(width-1)))
+		le.PutUint32(b[12:], uint32(int32(height-1)))
+		return b
+	}
+	float := func(f float32) []byte {
+		b := make([]byte, 4)
+		le.PutUint32(b, math.Float32bits(f))
+		return b
+	}
+
+	// the magic number and version 2 of a single part scanline image
+	header.Write([]byte{0x76, 0x2f, 0x31, 0x01, 0x02, 0x00, 0x00, 0x00})
+
+	// the attributes are in the order the OpenEXR library writes them; a
+	// channel is its name, FLOAT, linear, 3 reserved bytes and a sampling
+	// of 1 in x and y
+	channels := []byte{'R', 0, 2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 0}
+	attribute("channels", "chlist", channels)
+	attribute("compression", "compression", []byte{0})
+	attribute("dataWindow", "box2i", box(nm.Width, nm.Height))
+	attribute("displayWindow", "box2i", box(nm.Width, nm.Height))
+	attribute("lineOrder", "lineOrder", []byte{0})
+	attribute("pixelAspectRatio", "float", float(1.0))
+	attribute("screenWindowCenter", "v2f", append(float(0.0), float(0.0)...))
+	attribute("screenWindowWidth", "float", float(1.0))
+	header.WriteByte(0)
+
+	// every scanline is its y, its size and its samples, and the offset
+	// table before them points at the start of each
+	lineSize := 8 + nm.Width*4
+	start := header.Len() + nm.Height*8
+	for y := 0; y < nm.Height; y++ {
+		binary.Write(&header, le, uint64(start+y*lineSize))
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header.Bytes()); err != nil {
+		return err
+	}
+	line := make([]byte, lineSize)
+	for y := 0; y < nm.Height; y++ {
+		le.PutUint32(line[0:], uint32(int32(y)))
+		le.PutUint32(line[4:], uint32(nm.Width*4))
+		for x := 0; x < nm.Width; x++ {
+			v := nm.Values[y*nm.Width+x] * heightScale
+			le.PutUint32(line[8+x*4:], math.Float32bits(float32(v)))
+		}
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteHeightmap writes the map to w in the format of the options.
+func WriteHeightmap(w io.Writer, nm *noisey.NoiseMap, opts Options) error {
+	if err := check(nm); err != nil {
+		return err
+	}
+	switch opts.Format {
+	case EXR:
+		return WriteEXR(w, nm, opts.HeightScale)
+	case R16:
+		return noisey.WriteRAW16(w, nm, binary.LittleEndian)
+	}
+	return fmt.Errorf("Unable to export a heightmap as %v.\n", opts.Format)
+}
+
+// formatFloat returns v the way Godot writes floats in text resources.
+func formatFloat(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "0.0"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// WriteSidecar writes a Resource to w whose metadata describes the
+// heightmap file, which is named relative to the resource.
+func WriteSidecar(w io.Writer, file string, nm *noisey.NoiseMap, opts Options) error {
+	if err := check(nm); err != nil {
+		return err
+	}
+	min, max := heightRange(nm, opts)
+	_, err := fmt.Fprintf(w, `[gd_resource type="Resource" format=3]
+
+[resource]
+metadata/heightmap = %s
+metadata/format = "%v"
+metadata/width = %d
+metadata/depth = %d
+metadata/spacing = %s
+metadata/height_scale = %s
+metadata/min_height = %s
+metadata/max_height = %s
+`, strconv.Quote(file), opts.Format, nm.Width, nm.Height, formatFloat(opts.Spacing),
+		formatFloat(opts.HeightScale), formatFloat(min), formatFloat(max))
+	return err
+}
+
+// WriteImport writes the import settings of an EXR heightmap to w, which
+// Godot reads from the file named like the heightmap with .import added.
+// The path is the res:// path of the heightmap. Godot fills in the rest of
+// the file when it imports the image.
+func WriteImport(w io.Writer, path string) error {
+	_, err := fmt.Fprintf(w, `[remap]
+
+importer="texture"
+type="CompressedTexture2D"
+
+[deps]
+
+source_file=%s
+
+[params]
+
+compress/mode=0
+compress/high_quality=false
+compress/lossy_quality=0.7
+compress/hdr_compression=0
+compress/normal_map=0
+compress/channel_pack=0
+mipmaps/generate=false
+mipmaps/limit=-1
+process/fix_alpha_border=false
+process/premult_alpha=false
+process/normal_map_invert_y=false
+process/hdr_as_srgb=false
+process/hdr_clamp_exposure=false
+process/size_limit=0
+detect_3d/compress_to=0
+`, strconv.Quote(path))
+	return err
+}
+
+// WriteHeightMapShape writes the map to w as a HeightMapShape3D resource
+// with the values times heightScale as the heights. NaN values are written
+// as 0.
+func WriteHeightMapShape(w io.Writer, nm *noisey.NoiseMap, heightScale float64) error {
+	if err := check(nm); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "[gd_resource type=\"HeightMapShape3D\" format=3]\n\n[resource]\nmap_width = %d\nmap_depth = %d\nmap_data = PackedFloat32Array(", nm.Width, nm.Height)
+	for i, v := range nm.Values[:nm.Width*nm.Height] {
+		if i > 0 {
+			bw.WriteString(", ")
+		}
+		if math.IsNaN(v) {
+			v = 0.0
+		}
+		bw.WriteString(strconv.FormatFloat(float64(float32(v*heightScale)), 'g', -1, 32))
+	}
+	bw.WriteString(")\n")
+	return bw.Flush()
+}
+
+// writeFile creates the file at path and writes it with write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Export writes the map as a heightmap at path, with the extension of the
+// format added if path doesn't have it, and the sidecar resource as a .tres
+// next to it. An EXR also gets its .import file, which assumes a relative
+// path is the same under res:// and an absolute one is at the top of it.
+func Export(path string, nm *noisey.NoiseMap, opts Options) error {
+	if err := check(nm); err != nil {
+		return err
+	}
+	ext := opts.Format.Extension()
+	if !strings.EqualFold(filepath.Ext(path), ext) {
+		path += ext
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	name := filepath.Base(path)
+
+	err := writeFile(path, func(w io.Writer) error {
+		return WriteHeightmap(w, nm, opts)
+	})
+	if err != nil {
+		return err
+	}
+	if opts.Format == EXR {
+		res := "res://" + filepath.ToSlash(filepath.Clean(path))
+		if filepath.IsAbs(path) {
+			res = "res://" + name
+		}
+		err = writeFile(path+".import", func(w io.Writer) error {
+			return WriteImport(w, res)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return writeFile(base+".tres", func(w io.Writer) error {
+		return WriteSidecar(w, name, nm, opts)
+	})
+}
@@ -0,0 +1,124 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package interop contains adapters that make the noise generators of other Go
+packages usable as noisey.NoiseyGet2D and noisey.NoiseyGet3D sources so that
+they can be used inside Select, FBM and other noisey modules.
+
+The adapters only depend on the method sets of the wrapped generators, so this
+package does not import any of them and works with any type that has the same
+methods:
+
+  - github.com/ojrac/opensimplex-go: wrap the result of opensimplex.New() with NewOpenSimplexGo()
+  - github.com/aquilax/go-perlin: wrap the result of perlin.NewPerlin() with NewGoPerlin()
+  - github.com/KEINOS/go-noise: wrap the result of noise.New() with NewGoNoise()
+
+For example:
+
+	simplex := interop.NewOpenSimplexGo(opensimplex.New(1))
+	fbm := noisey.NewFBMGenerator2D(&simplex, 5, 0.5, 2.0, 1.0)
+*/
+package interop
+
+import (
+	"fmt"
+)
+
+// OpenSimplexNoise is the method set of opensimplex.Noise from github.com/ojrac/opensimplex-go.
+type OpenSimplexNoise interface {
+	Eval2(x, y float64) float64
+	Eval3(x, y, z float64) float64
+}
+
+// OpenSimplexGo adapts an OpenSimplexNoise to the noisey interfaces.
+type OpenSimplexGo struct {
+	// the generator that calculates the noise values
+	Noise OpenSimplexNoise
+}
+
+// NewOpenSimplexGo creates a new adapter for n.
+func NewOpenSimplexGo(n OpenSimplexNoise) (os OpenSimplexGo) {
+	os.Noise = n
+	return
+}
+
+// Get2D calculates the noise value at a given 2D coordinate.
+func (os *OpenSimplexGo) Get2D(x float64, y float64) float64 {
+	return os.Noise.Eval2(x, y)
+}
+
+// Get3D calculates the noise value at a given 3D coordinate.
+func (os *OpenSimplexGo) Get3D(x float64, y float64, z float64) float64 {
+	return os.Noise.Eval3(x, y, z)
+}
+
+// String returns a description of the adapter.
+func (os *OpenSimplexGo) String() string {
+	return fmt.Sprintf("OpenSimplexGo{Noise: %T}", os.Noise)
+}
+
+// PerlinNoise is the method set of *perlin.Perlin from github.com/aquilax/go-perlin.
+type PerlinNoise interface {
+	Noise2D(x, y float64) float64
+	Noise3D(x, y, z float64) float64
+}
+
+// GoPerlin adapts a PerlinNoise to the noisey interfaces.
+type GoPerlin struct {
+	// the generator that calculates the noise values
+	Noise PerlinNoise
+}
+
+// NewGoPerlin creates a new adapter for n.
+func NewGoPerlin(n PerlinNoise) (p GoPerlin) {
+	p.Noise = n
+	return
+}
+
+// Get2D calculates the noise value at a given 2D coordinate.
+func (p *GoPerlin) Get2D(x float64, y float64) float64 {
+	return p.Noise.Noise2D(x, y)
+}
+
+// Get3D calculates the noise value at a given 3D coordinate.
+func (p *GoPerlin) Get3D(x float64, y float64, z float64) float64 {
+	return p.Noise.Noise3D(x, y, z)
+}
+
+// String returns a description of the adapter.
+func (p *GoPerlin) String() string {
+	return fmt.Sprintf("GoPerlin{Noise: %T}", p.Noise)
+}
+
+// Evaluator64 is the 64 bit evaluation method of noise.Generator from github.com/KEINOS/go-noise.
+type Evaluator64 interface {
+	Eval64(dim ...float64) float64
+}
+
+// GoNoise adapts an Evaluator64 to the noisey interfaces.
+type GoNoise struct {
+	// the generator that calculates the noise values
+	Generator Evaluator64
+}
+
+// NewGoNoise creates a new adapter for g.
+func NewGoNoise(g Evaluator64) (n GoNoise) {
+	n.Generator = g
+	return
+}
+
+// Get2D calculates the noise value at a given 2D coordinate.
+func (n *GoNoise) Get2D(x float64, y float64) float64 {
+	return n.Generator.Eval64(x, y)
+}
+
+// Get3D calculates the noise value at a given 3D coordinate.
+func (n *GoNoise) Get3D(x float64, y float64, z float64) float64 {
+	return n.Generator.Eval64(x, y, z)
+}
+
+// String returns a description of the adapter.
+func (n *GoNoise) String() string {
+	return fmt.Sprintf("GoNoise{Generator: %T}", n.Generator)
+}
@@ -0,0 +1,97 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package mathgl contains conversion helpers between the vector types of noisey
+and the ones in github.com/go-gl/mathgl so that graphics code can sample noise
+without converting coordinates by hand.
+
+It lives in its own package so that noisey itself does not depend on mathgl.
+*/
+package mathgl
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/go-gl/mathgl/mgl64"
+	"github.com/tbogdala/noisey"
+)
+
+// FromMgl32Vec2 converts a mgl32.Vec2 to a noisey.Vec2f.
+func FromMgl32Vec2(v mgl32.Vec2) noisey.Vec2f {
+	return noisey.Vec2f{X: float64(v[0]), Y: float64(v[1])}
+}
+
+// FromMgl32Vec3 converts a mgl32.Vec3 to a noisey.Vec3f.
+func FromMgl32Vec3(v mgl32.Vec3) noisey.Vec3f {
+	return noisey.Vec3f{X: float64(v[0]), Y: float64(v[1]), Z: float64(v[2])}
+}
+
+// FromMgl32Vec4 converts a mgl32.Vec4 to a noisey.Vec4f.
+func FromMgl32Vec4(v mgl32.Vec4) noisey.Vec4f {
+	return noisey.Vec4f{X: float64(v[0]), Y: float64(v[1]), Z: float64(v[2]), W: float64(v[3])}
+}
+
+// ToMgl32Vec2 converts a noisey.Vec2f to a mgl32.Vec2.
+func ToMgl32Vec2(v noisey.Vec2f) mgl32.Vec2 {
+	return mgl32.Vec2{float32(v.X), float32(v.Y)}
+}
+
+// ToMgl32Vec3 converts a noisey.Vec3f to a mgl32.Vec3.
+func ToMgl32Vec3(v noisey.Vec3f) mgl32.Vec3 {
+	return mgl32.Vec3{float32(v.X), float32(v.Y), float32(v.Z)}
+}
+
+// ToMgl32Vec4 converts a noisey.Vec4f to a mgl32.Vec4.
+func ToMgl32Vec4(v noisey.Vec4f) mgl32.Vec4 {
+	return mgl32.Vec4{float32(v.X), float32(v.Y), float32(v.Z), float32(v.W)}
+}
+
+// FromMgl64Vec2 converts a mgl64.Vec2 to a noisey.Vec2f.
+func FromMgl64Vec2(v mgl64.Vec2) noisey.Vec2f {
+	return noisey.Vec2f{X: v[0], Y: v[1]}
+}
+
+// FromMgl64Vec3 converts a mgl64.Vec3 to a noisey.Vec3f.
+func FromMgl64Vec3(v mgl64.Vec3) noisey.Vec3f {
+	return noisey.Vec3f{X: v[0], Y: v[1], Z: v[2]}
+}
+
+// FromMgl64Vec4 converts a mgl64.Vec4 to a noisey.Vec4f.
+func FromMgl64Vec4(v mgl64.Vec4) noisey.Vec4f {
+	return noisey.Vec4f{X: v[0], Y: v[1], Z: v[2], W: v[3]}
+}
+
+// ToMgl64Vec2 converts a noisey.Vec2f to a mgl64.Vec2.
+func ToMgl64Vec2(v noisey.Vec2f) mgl64.Vec2 {
+	return mgl64.Vec2{v.X, v.Y}
+}
+
+// ToMgl64Vec3 converts a noisey.Vec3f to a mgl64.Vec3.
+func ToMgl64Vec3(v noisey.Vec3f) mgl64.Vec3 {
+	return mgl64.Vec3{v.X, v.Y, v.Z}
+}
+
+// ToMgl64Vec4 converts a noisey.Vec4f to a mgl64.Vec4.
+func ToMgl64Vec4(v noisey.Vec4f) mgl64.Vec4 {
+	return mgl64.Vec4{v.X, v.Y, v.Z, v.W}
+}
+
+// Get2D32 calculates the noise value of s at the coordinate v.
+func Get2D32(s noisey.NoiseyGet2D, v mgl32.Vec2) float32 {
+	return float32(s.Get2D(float64(v[0]), float64(v[1])))
+}
+
+// Get3D32 calculates the noise value of s at the coordinate v.
+func Get3D32(s noisey.NoiseyGet3D, v mgl32.Vec3) float32 {
+	return float32(s.Get3D(float64(v[0]), float64(v[1]), float64(v[2])))
+}
+
+// Get2D64 calculates the noise value of s at the coordinate v.
+func Get2D64(s noisey.NoiseyGet2D, v mgl64.Vec2) float64 {
+	return s.Get2D(v[0], v[1])
+}
+
+// Get3D64 calculates the noise value of s at the coordinate v.
+func Get3D64(s noisey.NoiseyGet3D, v mgl64.Vec3) float64 {
+	return s.Get3D(v[0], v[1], v[2])
+}
@@ -17,7 +17,7 @@ A sample JSON file would contain something like this:
   },
   "Sources": {
     "perlin": {
-      "SourceType": "perlin2d",
+      "SourceType": "perlin",
       "Quality": 2,
       "Seed": "Default"
     }
@@ -89,6 +89,40 @@ import (
 	"math/rand"
 )
 
+// The SourceType strings understood by BuildSources().
+const (
+	SourcePerlin      = "perlin"
+	SourceOpenSimplex = "opensimplex"
+	SourceWorley      = "worley"
+)
+
+// The GeneratorType strings understood by BuildGenerators().
+const (
+	GenFBM2D        = "fBm2d"
+	GenRidged2D     = "ridged2d"
+	GenSelect2D     = "select2d"
+	GenScale2D      = "scale2d"
+	GenFalloff2D    = "falloff2d"
+	GenBillow2D     = "billow2d"
+	GenTurbulence2D = "turbulence2d"
+	GenPattern2D    = "pattern2d"
+	GenCrackle2D    = "crackle2d"
+	GenCaustics2D   = "caustics2d"
+
+	GenKaleidoscope2D = "kaleidoscope2d"
+	GenPolar2D        = "polar2d"
+)
+
+// SourceTypes returns all of the SourceType strings understood by BuildSources().
+func SourceTypes() []string {
+	return []string{SourcePerlin, SourceOpenSimplex, SourceWorley}
+}
+
+// GeneratorTypes returns all of the GeneratorType strings understood by BuildGenerators().
+func GeneratorTypes() []string {
+	return []string{GenFBM2D, GenRidged2D, GenSelect2D, GenScale2D, GenFalloff2D, GenBillow2D, GenTurbulence2D, GenPattern2D, GenCrackle2D, GenCaustics2D, GenKaleidoscope2D, GenPolar2D}
+}
+
 // RandomSeedBuilder is a type used to construct RandomSource interfaces
 // from a seed listed in the configuration JSON
 type RandomSeedBuilder func(s int64) RandomSource
@@ -124,6 +158,33 @@ type GeneratorJSON struct {
 	Bias        float64 // Scale is generator specific ...
 	Min         float64 // Min is generator specific ...
 	Max         float64 // Min is generator specific ...
+	Offset      float64 // Offset is generator specific ...
+	Gain        float64 // Gain is generator specific ...
+	Exponent    float64 // Exponent is generator specific ...
+
+	Shape   FalloffShape // Shape is generator specific ...
+	CenterX float64      // CenterX is generator specific ...
+	CenterY float64      // CenterY is generator specific ...
+	Radius  float64      // Radius is generator specific ...
+
+	Power   float64      // Power is generator specific ...
+	Pattern PatternShape // Pattern is generator specific ...
+
+	CellSize   float64 // CellSize is generator specific ...
+	Width      float64 // Width is generator specific ...
+	Jaggedness float64 // Jaggedness is generator specific ...
+
+	Layers     int     // Layers is generator specific ...
+	Speed      float64 // Speed is generator specific ...
+	Distortion float64 // Distortion is generator specific ...
+	Time       float64 // Time is generator specific ...
+
+	Segments int          // Segments is generator specific ...
+	Symmetry SymmetryMode // Symmetry is generator specific ...
+	Rotation float64      // Rotation is generator specific ...
+
+	RadiusScale float64 // RadiusScale is generator specific ...
+	AngleScale  float64 // AngleScale is generator specific ...
 }
 
 // SourceJSON describes the source of the random information, like perlin2d.
@@ -135,6 +196,14 @@ type SourceJSON struct {
 	// Seed is a string that needs to be a name in the NoiseJSON.Seeds map that
 	// is to be used in this generator.
 	Seed string
+
+	// Quality is the NoiseQuality used to blend lattice points for the
+	// source types that support it (perlin). Zero is QualityDefault.
+	Quality NoiseQuality
+
+	// Normalized scales the values of the perlin and opensimplex sources to
+	// [-1, 1], so that Select thresholds carry over between them.
+	Normalized bool
 }
 
 // NoiseJSON is a structure that facilities the saving and loading of JSON
@@ -155,11 +224,27 @@ type NoiseJSON struct {
 	// noise should be built.
 	Generators []GeneratorJSON
 
+	// Ores is an ordered array of OreJSON objects that define how ores are
+	// distributed by BuildOres().
+	Ores []OreJSON `json:",omitempty"`
+
+	// Gradients uses a name string as a key that maps to a GradientJSON
+	// structure describing a ColorGradient for coloring the output.
+	Gradients map[string]GradientJSON `json:",omitempty"`
+
+	// Scenes uses a name string as a key that maps to a SceneJSON structure
+	// describing an image rendered by RenderScene() for visual regression checks.
+	Scenes map[string]SceneJSON `json:",omitempty"`
+
 	// builtSources are cached noise providers built after BuildSources()
 	builtSources map[string]NoiseyGet2D
 
 	// builtGenerators are cached noise generators built after BuildGenerators()
 	builtGenerators map[string]NoiseyGet2D
+
+	// checkNaN wraps the built modules to report to nanHandler; see CheckNaN()
+	checkNaN   bool
+	nanHandler NaNHandler
 }
 
 // NewNoiseJSON creates a new structure that can be used to save noise settings
@@ -175,18 +260,123 @@ func NewNoiseJSON() *NoiseJSON {
 	return nj
 }
 
+// initBuilt makes sure the maps of built objects exist so that BuildSources()
+// and BuildGenerators() work on a NoiseJSON that wasn't made by NewNoiseJSON().
+func (cfg *NoiseJSON) initBuilt() {
+	if cfg.builtSources == nil {
+		cfg.builtSources = make(map[string]NoiseyGet2D)
+	}
+	if cfg.builtGenerators == nil {
+		cfg.builtGenerators = make(map[string]NoiseyGet2D)
+	}
+}
+
 // LoadNoiseJSON unmarshals the JSON from the byte array and returns a NoiseJSON
 // object on success; error otherwise.
 func LoadNoiseJSON(bytes []byte) (*NoiseJSON, error) {
+	return LoadNoiseJSONWithLimits(bytes, LoadLimits{})
+}
+
+// LoadLimits bounds the configurations accepted by LoadNoiseJSONWithLimits(),
+// so that configurations from untrusted users can't make a process run out
+// of memory or spend minutes on every sample. Limits that are 0 aren't checked.
+type LoadLimits struct {
+	MaxBytes      int // the largest size of the JSON in bytes
+	MaxSources    int // the most sources
+	MaxGenerators int // the most generators
+	MaxInputs     int // the most sources and generators a generator may use
+	MaxOctaves    int // the largest Octaves or Layers of a generator
+	MaxDepth      int // the longest chain of generators using generators
+
+	// MaxSampleCost is the most module evaluations one sample of a generator
+	// may take, counting the generator, every octave of its sources and the
+	// cost of the generators it uses. A generator used by several others is
+	// evaluated for each of them, so this grows much faster than the number
+	// of generators.
+	MaxSampleCost int
+}
+
+// DefaultLoadLimits are limits for services that load configurations from
+// their users, which are generous for any hand made pipeline.
+var DefaultLoadLimits = LoadLimits{
+	MaxBytes:      1 << 20,
+	MaxSources:    256,
+	MaxGenerators: 1024,
+	MaxInputs:     64,
+	MaxOctaves:    maxOctaves,
+	MaxDepth:      64,
+	MaxSampleCost: 1 << 16,
+}
+
+// LoadNoiseJSONWithLimits unmarshals the JSON from the byte array like
+// LoadNoiseJSON() and returns an error if the configuration is beyond any of
+// the limits.
+func LoadNoiseJSONWithLimits(bytes []byte, limits LoadLimits) (*NoiseJSON, error) {
+	if limits.MaxBytes > 0 && len(bytes) > limits.MaxBytes {
+		return nil, fmt.Errorf("Unable to load a configuration of %d bytes; the limit is %d.\n", len(bytes), limits.MaxBytes)
+	}
 	var cfg *NoiseJSON = NewNoiseJSON()
 	err := json.Unmarshal(bytes, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to read json into the configuration structure.\n%v\n", err)
 	}
+	if err = limits.Check(cfg); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
 
+// Check returns an error if the configuration is beyond any of the limits
+// other than MaxBytes. Generators that use ones which aren't defined before
+// them are left for BuildGenerators() to reject.
+func (l *LoadLimits) Check(cfg *NoiseJSON) error {
+	if l.MaxSources > 0 && len(cfg.Sources) > l.MaxSources {
+		return fmt.Errorf("Unable to load a configuration with %d sources; the limit is %d.\n", len(cfg.Sources), l.MaxSources)
+	}
+	if l.MaxGenerators > 0 && len(cfg.Generators) > l.MaxGenerators {
+		return fmt.Errorf("Unable to load a configuration with %d generators; the limit is %d.\n", len(cfg.Generators), l.MaxGenerators)
+	}
+
+	// the depth and the cost of the generators defined so far; the cost is
+	// a float64 so that it can't overflow before it's compared
+	depths := make(map[string]int, len(cfg.Generators))
+	costs := make(map[string]float64, len(cfg.Generators))
+	for _, gen := range cfg.Generators {
+		if inputs := len(gen.Sources) + len(gen.Generators); l.MaxInputs > 0 && inputs > l.MaxInputs {
+			return fmt.Errorf("Generator \"%s\" uses %d inputs; the limit is %d.\n", gen.Name, inputs, l.MaxInputs)
+		}
+		octaves := gen.Octaves
+		if gen.Layers > octaves {
+			octaves = gen.Layers
+		}
+		if l.MaxOctaves > 0 && octaves > l.MaxOctaves {
+			return fmt.Errorf("Generator \"%s\" has %d octaves; the limit is %d.\n", gen.Name, octaves, l.MaxOctaves)
+		}
+		if octaves < 1 {
+			octaves = 1
+		}
+
+		depth := 1
+		cost := 1.0 + float64(octaves*len(gen.Sources))
+		for _, name := range gen.Generators {
+			if d, ok := depths[name]; ok && d+1 > depth {
+				depth = d + 1
+			}
+			cost += costs[name]
+		}
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return fmt.Errorf("Generator \"%s\" is %d generators deep; the limit is %d.\n", gen.Name, depth, l.MaxDepth)
+		}
+		if l.MaxSampleCost > 0 && cost > float64(l.MaxSampleCost) {
+			return fmt.Errorf("Generator \"%s\" takes %.0f module evaluations a sample; the limit is %d.\n", gen.Name, cost, l.MaxSampleCost)
+		}
+		depths[gen.Name] = depth
+		costs[gen.Name] = cost
+	}
+	return nil
+}
+
 // GetGenerator returns a cached generator NoiseyGet2D object. This function
 // Must be called after both BuildSources() and BuildGenerators().
 func (cfg *NoiseJSON) GetGenerator(name string) NoiseyGet2D {
@@ -217,6 +407,8 @@ func (cfg *NoiseJSON) SaveNoiseJSON() ([]byte, error) {
 // SourceJSON structures in NoiseJSON.Sources. This method should be
 // called before BuildGenerators().
 func (cfg *NoiseJSON) BuildSources(seedBuilder RandomSeedBuilder) error {
+	cfg.initBuilt()
+
 	// loop through all configured sources
 	for sourceName, source := range cfg.Sources {
 		// get the random source by taking the referenced seed and calling
@@ -231,23 +423,34 @@ func (cfg *NoiseJSON) BuildSources(seedBuilder RandomSeedBuilder) error {
 		var r RandomSource
 		if seedBuilder != nil {
 			r = seedBuilder(seed)
+			if r == nil {
+				return fmt.Errorf("Source \"%s\" creation failed: the seed builder returned a nil RandomSource.\n", sourceName)
+			}
 		} else {
 			r = rand.New(rand.NewSource(int64(seed)))
 		}
 
 		var s NoiseyGet2D
 		switch source.SourceType {
-		case "perlin":
-			p2d := NewPerlinGenerator(r)
+		case SourcePerlin:
+			p2d := NewPerlinGeneratorWithQuality(r, source.Quality)
+			p2d.Normalized = source.Normalized
 			s = NoiseyGet2D(&p2d)
-		case "opensimplex":
+		case SourceOpenSimplex:
 			os2d := NewOpenSimplexGenerator(r)
+			os2d.Normalized = source.Normalized
 			s = NoiseyGet2D(&os2d)
+		case SourceWorley:
+			wg := NewWorleyGenerator(r)
+			s = NoiseyGet2D(&wg)
 		default:
 			return fmt.Errorf("Undefined source type (%s) for source %s.\n", source.SourceType, sourceName)
 		}
 
 		// store the result
+		if cfg.checkNaN {
+			s = CheckNaN2D("source \""+sourceName+"\"", s, cfg.nanHandler)
+		}
 		cfg.builtSources[sourceName] = s
 	}
 
@@ -258,8 +461,20 @@ func (cfg *NoiseJSON) BuildSources(seedBuilder RandomSeedBuilder) error {
 // in the GeneratorJSON objects in NoiseJSON.Gnerators. This method should be
 // called after BuildSources().
 func (cfg *NoiseJSON) BuildGenerators() error {
+	cfg.initBuilt()
+
 	// loop through all configured generators
 	for _, gen := range cfg.Generators {
+		// make sure the generator has enough inputs before indexing them
+		if info, ok := LookupModule(gen.GeneratorType); ok && info.Kind == KindGenerator {
+			if len(gen.Sources) < info.Sources {
+				return fmt.Errorf("Generator \"%s\" creation failed: %s requires %d sources but %d were listed.\n", gen.Name, gen.GeneratorType, info.Sources, len(gen.Sources))
+			}
+			if len(gen.Generators) < info.Generators {
+				return fmt.Errorf("Generator \"%s\" creation failed: %s requires %d generators but %d were listed.\n", gen.Name, gen.GeneratorType, info.Generators, len(gen.Generators))
+			}
+		}
+
 		var sourceArray []NoiseyGet2D
 		var genArray []NoiseyGet2D
 
@@ -281,7 +496,7 @@ func (cfg *NoiseJSON) BuildGenerators() error {
 			for i, ss := range gen.Generators {
 				builtGen, ok := cfg.builtGenerators[ss]
 				if ok != true {
-					return fmt.Errorf("Generator \"%s\" creation failed: couldn't find built source \"%s\".\n", gen.Name, ss)
+					return fmt.Errorf("Generator \"%s\" creation failed: couldn't find built generator \"%s\".\n", gen.Name, ss)
 				}
 				genArray[i] = builtGen
 			}
@@ -289,20 +504,73 @@ func (cfg *NoiseJSON) BuildGenerators() error {
 
 		var g NoiseyGet2D
 		switch gen.GeneratorType {
-		case "fBm2d":
+		case GenFBM2D:
 			fbm := NewFBMGenerator2D(sourceArray[0], gen.Octaves, gen.Persistence, gen.Lacunarity, gen.Frequency)
 			g = NoiseyGet2D(&fbm)
-		case "select2d":
+		case GenRidged2D:
+			ridged := NewRidgedGenerator2D(sourceArray[0], gen.Octaves, gen.Lacunarity, gen.Frequency, gen.Offset, gen.Gain, gen.Exponent)
+			g = NoiseyGet2D(&ridged)
+		case GenSelect2D:
 			sel := NewSelect2D(genArray[0], genArray[1], genArray[2], gen.LowerBound, gen.UpperBound, gen.EdgeFalloff)
 			g = NoiseyGet2D(&sel)
-		case "scale2d":
+		case GenScale2D:
 			scale := NewScale2D(genArray[0], gen.Scale, gen.Bias, gen.Min, gen.Max)
 			g = NoiseyGet2D(&scale)
+		case GenFalloff2D:
+			falloff := NewFalloff2D(gen.Shape, gen.CenterX, gen.CenterY, gen.Radius, gen.Exponent)
+			g = NoiseyGet2D(&falloff)
+		case GenBillow2D:
+			billow := NewBillow2D(sourceArray[0], gen.Octaves, gen.Persistence, gen.Lacunarity, gen.Frequency)
+			g = NoiseyGet2D(&billow)
+		case GenTurbulence2D:
+			turb := NewTurbulence2D(genArray[0], genArray[1], genArray[2], gen.Frequency, gen.Power)
+			g = NoiseyGet2D(&turb)
+		case GenPattern2D:
+			pattern := NewPattern2D(gen.Pattern, gen.Frequency)
+			g = NoiseyGet2D(&pattern)
+		case GenCrackle2D:
+			cells, ok := unwrapNaNCheck(sourceArray[0]).(*WorleyGenerator)
+			if !ok {
+				return fmt.Errorf("Generator \"%s\" creation failed: %s requires a %s source.\n", gen.Name, gen.GeneratorType, SourceWorley)
+			}
+			crackle := NewCrackle2D(cells, gen.CellSize, gen.Width)
+			if len(genArray) > 0 {
+				crackle.Warp = genArray[0]
+				crackle.Jaggedness = gen.Jaggedness
+			}
+			g = NoiseyGet2D(&crackle)
+		case GenCaustics2D:
+			cells, ok := unwrapNaNCheck(sourceArray[0]).(*WorleyGenerator)
+			if !ok {
+				return fmt.Errorf("Generator \"%s\" creation failed: %s requires a %s source.\n", gen.Name, gen.GeneratorType, SourceWorley)
+			}
+			caustics := NewCaustics2D(cells, gen.CellSize, gen.Width)
+			caustics.Layers = gen.Layers
+			caustics.Speed = gen.Speed
+			caustics.Time = gen.Time
+			if len(genArray) > 0 {
+				caustics.Warp = genArray[0]
+				caustics.Distortion = gen.Distortion
+			}
+			g = NoiseyGet2D(&caustics)
+		case GenKaleidoscope2D:
+			k := NewKaleidoscope2D(genArray[0], gen.Segments, gen.Symmetry)
+			k.CenterX, k.CenterY = gen.CenterX, gen.CenterY
+			k.Rotation = gen.Rotation
+			g = NoiseyGet2D(&k)
+		case GenPolar2D:
+			polar := NewPolar2D(genArray[0])
+			polar.CenterX, polar.CenterY = gen.CenterX, gen.CenterY
+			polar.RadiusScale, polar.AngleScale = gen.RadiusScale, gen.AngleScale
+			g = NoiseyGet2D(&polar)
 		default:
 			return fmt.Errorf("Undefined generator type (%s) for generator %s.\n", gen.GeneratorType, gen.Name)
 		}
 
 		// store the result
+		if cfg.checkNaN {
+			g = CheckNaN2D("generator \""+gen.Name+"\"", g, cfg.nanHandler)
+		}
 		cfg.builtGenerators[gen.Name] = g
 	}
 
@@ -0,0 +1,126 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module folds the coordinates around a center into one wedge before
+sampling its source, which repeats whatever is in the wedge Segments times
+around the center for mandalas, snowflakes and rosettes:
+
+	mandala := noisey.NewKaleidoscope2D(&fbm, 8, noisey.SymmetryMirror)
+	mandala.CenterX, mandala.CenterY = 0.5, 0.5
+
+SymmetryRotate repeats the wedge by turning it, so the pattern has N-fold
+rotational symmetry. SymmetryMirror also reflects every other wedge, so each
+wedge is mirrored about its middle the way a kaleidoscope's mirrors show it,
+and the pattern has no seams between the wedges.
+
+Rotation turns the wedges around the center in radians. The distance of a
+point from the center is kept, so rings stay rings.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// SymmetryMode selects how a Kaleidoscope2D repeats its wedge.
+type SymmetryMode int
+
+const (
+	// SymmetryRotate repeats the wedge by turning it around the center.
+	SymmetryRotate SymmetryMode = iota
+
+	// SymmetryMirror repeats the wedge by reflecting it at every edge.
+	SymmetryMirror
+)
+
+// String returns the name of the mode.
+func (m SymmetryMode) String() string {
+	switch m {
+	case SymmetryRotate:
+		return "SymmetryRotate"
+	case SymmetryMirror:
+		return "SymmetryMirror"
+	}
+	return fmt.Sprintf("SymmetryMode(%d)", int(m))
+}
+
+// Kaleidoscope2D is a module that samples its source with N-fold symmetry.
+type Kaleidoscope2D struct {
+	Source   NoiseyGet2D  // the module that is sampled in the folded coordinates
+	Segments int          // the number of wedges around the center
+	Symmetry SymmetryMode // whether the wedges are turned or mirrored
+	CenterX  float64      // the X of the center of symmetry
+	CenterY  float64      // the Y of the center of symmetry
+	Rotation float64      // the angle the wedges are turned by in radians
+}
+
+// NewKaleidoscope2D creates a new symmetry module centered on the origin.
+func NewKaleidoscope2D(src NoiseyGet2D, segments int, symmetry SymmetryMode) (k Kaleidoscope2D) {
+	k.Source = src
+	k.Segments = segments
+	k.Symmetry = symmetry
+	return
+}
+
+// Clone2D returns a deep copy of the module and its source.
+func (k *Kaleidoscope2D) Clone2D() NoiseyGet2D {
+	c := *k
+	c.Source = DeepCopy2D(k.Source)
+	return &c
+}
+
+// SetSegments validates and changes the number of wedges.
+func (k *Kaleidoscope2D) SetSegments(segments int) error {
+	if err := validateParam(GenKaleidoscope2D, "Segments", float64(segments)); err != nil {
+		return err
+	}
+	k.Segments = segments
+	return nil
+}
+
+// SetSymmetry validates and changes how the wedges are repeated.
+func (k *Kaleidoscope2D) SetSymmetry(symmetry SymmetryMode) error {
+	if err := validateParam(GenKaleidoscope2D, "Symmetry", float64(symmetry)); err != nil {
+		return err
+	}
+	k.Symmetry = symmetry
+	return nil
+}
+
+// String returns a description of the module and its source.
+func (k *Kaleidoscope2D) String() string {
+	return fmt.Sprintf("Kaleidoscope2D{Segments: %v, Symmetry: %v, CenterX: %v, CenterY: %v, Rotation: %v, Source: %s}",
+		k.Segments, k.Symmetry, k.CenterX, k.CenterY, k.Rotation, describeModule(k.Source))
+}
+
+// Fold returns the coordinate in the first wedge that (x, y) is folded to.
+func (k *Kaleidoscope2D) Fold(x float64, y float64) (float64, float64) {
+	if k.Segments <= 1 && k.Symmetry == SymmetryRotate {
+		return x, y
+	}
+	dx, dy := x-k.CenterX, y-k.CenterY
+	r := math.Hypot(dx, dy)
+	wedge := 2.0 * math.Pi / float64(maxInt(1, k.Segments))
+	a := math.Atan2(dy, dx) - k.Rotation
+	a -= math.Floor(a/wedge) * wedge
+	if k.Symmetry == SymmetryMirror && a > wedge*0.5 {
+		a = wedge - a
+	}
+	a += k.Rotation
+	return k.CenterX + r*math.Cos(a), k.CenterY + r*math.Sin(a)
+}
+
+// Get2D calculates the value of the source at the folded coordinate.
+func (k *Kaleidoscope2D) Get2D(x float64, y float64) float64 {
+	return k.Source.Get2D(k.Fold(x, y))
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are those of Source.
+func (k *Kaleidoscope2D) Range2D() (min float64, max float64) {
+	return ValueRange2D(k.Source)
+}
@@ -0,0 +1,247 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module finds the closed depressions of a built heightmap, which are the
+places where water would pool into lakes, and can fill them in partially or
+completely so that water can always flow off of the map.
+
+	lakes := noisey.NewLakeFiller()
+	lakes.Fill = 1.0
+	lakes.Epsilon = 1e-6
+	result, err := lakes.FindLakes(&heights)
+
+	// the lakes in result.Filled now slope down towards where they spill over
+
+The depressions are found with the priority-flood algorithm which floods the
+map inwards from its edges.
+
+Reference material:
+* Barnes, Lehman and Mulla's "Priority-Flood: An Optimal Depression-Filling and Watershed-Labeling Algorithm for Digital Elevation Models"
+
+*/
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// Lake is a closed depression of a heightmap filled with water to the
+// level where it would spill over.
+type Lake struct {
+	Level  float64 // the height of the water surface
+	Bottom float64 // the height of the lowest grid point
+	Area   int     // the number of grid points covered by water
+	Volume float64 // the sum of the water depths of the grid points
+	MinX   int     // the bounding box of the lake in grid points
+	MinY   int
+	MaxX   int
+	MaxY   int
+}
+
+// LakeFiller contains the parameters for finding and filling lakes.
+type LakeFiller struct {
+	// Fill is the fraction of each lake's depth to fill in: 0.0 leaves the
+	// heightmap unchanged and 1.0 raises the lakes to their water levels
+	Fill float64
+
+	// Epsilon is added for each grid point a completely filled depression
+	// floods inwards so that filled lakes slope towards their outlets instead
+	// of being flat; 0.0 leaves them flat
+	Epsilon float64
+
+	// MinArea is the smallest number of grid points a lake must cover to be reported
+	MinArea int
+}
+
+// LakeResult contains the results of LakeFiller.FindLakes().
+type LakeResult struct {
+	// Filled is the heightmap with the lakes filled by LakeFiller.Fill
+	Filled NoiseMap
+
+	// Water is the depth of water at each grid point before filling
+	Water NoiseMap
+
+	// Lakes are the lakes found in the heightmap
+	Lakes []Lake
+
+	// LakeIndexes is the index into Lakes of the lake at each grid point or -1
+	LakeIndexes []int
+}
+
+// NewLakeFiller creates a new lake filler that only reports the lakes
+// without changing the heightmap.
+func NewLakeFiller() (lf LakeFiller) {
+	lf.Fill = 0.0
+	lf.Epsilon = 0.0
+	lf.MinArea = 1
+	return
+}
+
+// String returns a description of the parameters.
+func (lf *LakeFiller) String() string {
+	return fmt.Sprintf("LakeFiller{Fill: %v, Epsilon: %v, MinArea: %v}", lf.Fill, lf.Epsilon, lf.MinArea)
+}
+
+// floodCell is a grid point in the priority-flood queue.
+type floodCell struct {
+	index int
+	level float64
+}
+
+// floodQueue is a min-heap of grid points ordered by their level.
+type floodQueue []floodCell
+
+func (q floodQueue) Len() int            { return len(q) }
+func (q floodQueue) Less(i, j int) bool  { return q[i].level < q[j].level }
+func (q floodQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *floodQueue) Push(x interface{}) { *q = append(*q, x.(floodCell)) }
+func (q *floodQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// priorityFlood returns the height of each grid point after filling every
+// depression, adding epsilon for each step into a depression.
+func priorityFlood(hm *NoiseMap, epsilon float64) []float64 {
+	w, h := hm.Width, hm.Height
+	levels := make([]float64, len(hm.Values))
+	closed := make([]bool, len(hm.Values))
+	q := &floodQueue{}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				i := y*w + x
+				levels[i] = hm.Values[i]
+				closed[i] = true
+				heap.Push(q, floodCell{i, levels[i]})
+			}
+		}
+	}
+
+	for q.Len() > 0 {
+		c := heap.Pop(q).(floodCell)
+		cx, cy := c.index%w, c.index/w
+		for _, o := range D8Offsets {
+			nx, ny := cx+o.X, cy+o.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h {
+				continue
+			}
+			n := ny*w + nx
+			if closed[n] {
+				continue
+			}
+			closed[n] = true
+			levels[n] = math.Max(hm.Values[n], c.level)
+			if levels[n] == c.level && epsilon > 0.0 {
+				levels[n] += epsilon
+			}
+			heap.Push(q, floodCell{n, levels[n]})
+		}
+	}
+
+	return levels
+}
+
+// FindLakes finds the lakes of heightmap and returns them along with a copy
+// of the heightmap filled by Fill.
+func (lf *LakeFiller) FindLakes(heightmap *NoiseMap) (result LakeResult, err error) {
+	if heightmap.Width < 1 || heightmap.Height < 1 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return result, fmt.Errorf("Unable to find lakes in a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+	if lf.Fill < 0.0 || lf.Fill > 1.0 {
+		return result, fmt.Errorf("Fill must be in the range [0, 1]; got %v.\n", lf.Fill)
+	}
+
+	w, h := heightmap.Width, heightmap.Height
+	levels := priorityFlood(heightmap, 0.0)
+	result.Water = NewNoiseMap(w, h, heightmap.Bounds)
+	for i, l := range levels {
+		result.Water.Values[i] = l - heightmap.Values[i]
+	}
+
+	// group the flooded grid points into lakes
+	result.LakeIndexes = make([]int, len(levels))
+	for i := range result.LakeIndexes {
+		result.LakeIndexes[i] = -1
+	}
+	visited := make([]bool, len(levels))
+	var stack, members []int
+	for start, depth := range result.Water.Values {
+		if depth <= 0.0 || visited[start] {
+			continue
+		}
+
+		lake := Lake{Level: -math.MaxFloat64, Bottom: math.MaxFloat64, MinX: w, MinY: h, MaxX: -1, MaxY: -1}
+		members = members[:0]
+		stack = append(stack[:0], start)
+		visited[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			members = append(members, i)
+
+			x, y := i%w, i/w
+			lake.Level = math.Max(lake.Level, levels[i])
+			lake.Bottom = math.Min(lake.Bottom, heightmap.Values[i])
+			lake.Volume += result.Water.Values[i]
+			lake.MinX, lake.MaxX = minInt(lake.MinX, x), maxInt(lake.MaxX, x)
+			lake.MinY, lake.MaxY = minInt(lake.MinY, y), maxInt(lake.MaxY, y)
+
+			for _, o := range D8Offsets {
+				nx, ny := x+o.X, y+o.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h {
+					continue
+				}
+				n := ny*w + nx
+				if !visited[n] && result.Water.Values[n] > 0.0 {
+					visited[n] = true
+					stack = append(stack, n)
+				}
+			}
+		}
+
+		lake.Area = len(members)
+		if lake.Area < lf.MinArea {
+			continue
+		}
+		for _, i := range members {
+			result.LakeIndexes[i] = len(result.Lakes)
+		}
+		result.Lakes = append(result.Lakes, lake)
+	}
+
+	// fill in the lakes that were reported
+	if lf.Fill >= 1.0 && lf.Epsilon > 0.0 {
+		levels = priorityFlood(heightmap, lf.Epsilon)
+	}
+	result.Filled = heightmap.Clone()
+	for i, l := range result.LakeIndexes {
+		if l >= 0 {
+			result.Filled.Values[i] += (levels[i] - heightmap.Values[i]) * lf.Fill
+		}
+	}
+
+	return result, nil
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
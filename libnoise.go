@@ -0,0 +1,258 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module exports the generators of a NoiseJSON as libnoise style XML
+module definitions, for tools built around the libnoise C++ library:
+
+	issues, err := cfg.WriteLibnoiseXML(file)
+	for _, issue := range issues {
+		fmt.Printf("%s couldn't be exported: %s\n", issue.Generator, issue.Reason)
+	}
+
+Every generator becomes one or more <module> elements named after it, with
+<param> elements named after the libnoise setters without "Set" and
+<source> elements naming the modules at each source index:
+
+	<module name="basic" type="Perlin">
+	  <param name="Frequency" value="1"></param>
+	  ...
+	</module>
+
+Generators are only translated where libnoise has a module with the same
+semantics:
+
+* fBm2d, billow2d and ridged2d of a perlin source become Perlin, Billow and
+  RidgedMulti. Billow is followed by a ScaleBias removing the 0.5 libnoise
+  adds, and ridged2d needs the Offset 1, Gain 2 and Exponent 1 that
+  libnoise has built in.
+* select2d becomes Select with the control as source 2.
+* scale2d becomes ScaleBias and Clamp.
+* turbulence2d becomes Displace, with its distortion generators scaled and
+  translated by ScalePoint, TranslatePoint and ScaleBias modules.
+* pattern2d with PatternCylinders becomes Cylinders.
+
+Other generators, and those using them, are written as XML comments
+instead and returned as LibnoiseIssues. The helper modules are named after
+the generator with a suffix, like "warp.x".
+
+The modules are meant to be sampled on the XZ plane like the plane builder
+of libnoise's noiseutils does, so the Y of noisey is the Z of libnoise.
+The structure and the parameters match, but the sources don't make the
+same values: the gradients of libnoise are picked differently and a Seed
+is an int there. QualityDefault, which libnoise doesn't have, is written as
+QUALITY_STD.
+
+*/
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LibnoiseIssue describes a generator that WriteLibnoiseXML() couldn't
+// translate.
+type LibnoiseIssue struct {
+	Generator string // the name of the generator
+	Type      string // the GeneratorType of the generator
+	Reason    string // why it couldn't be translated
+}
+
+// libnoiseParam is a parameter of a libnoise module.
+type libnoiseParam struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// libnoiseSource is an input of a libnoise module.
+type libnoiseSource struct {
+	Index  int    `xml:"index,attr"`
+	Module string `xml:"module,attr"`
+}
+
+// libnoiseModule is a libnoise module definition.
+type libnoiseModule struct {
+	XMLName xml.Name         `xml:"module"`
+	Name    string           `xml:"name,attr"`
+	Type    string           `xml:"type,attr"`
+	Params  []libnoiseParam  `xml:"param"`
+	Sources []libnoiseSource `xml:"source"`
+}
+
+// param adds a parameter to the module.
+func (m *libnoiseModule) param(name string, value interface{}) *libnoiseModule {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		s = fmt.Sprint(v)
+	}
+	m.Params = append(m.Params, libnoiseParam{name, s})
+	return m
+}
+
+// source adds the inputs of the module in order.
+func (m *libnoiseModule) source(modules ...string) *libnoiseModule {
+	for _, name := range modules {
+		m.Sources = append(m.Sources, libnoiseSource{len(m.Sources), name})
+	}
+	return m
+}
+
+// libnoiseQuality returns the libnoise NoiseQuality of a quality.
+func libnoiseQuality(q NoiseQuality) string {
+	switch q {
+	case QualityFast:
+		return "QUALITY_FAST"
+	case QualityBest:
+		return "QUALITY_BEST"
+	}
+	return "QUALITY_STD"
+}
+
+// libnoiseTranslator translates the generators of a configuration.
+type libnoiseTranslator struct {
+	cfg     *NoiseJSON
+	done    map[string]bool // the generators that were translated
+	modules []*libnoiseModule
+}
+
+// module adds a new module to the translation.
+func (t *libnoiseTranslator) module(name string, kind string) *libnoiseModule {
+	m := &libnoiseModule{Name: name, Type: kind}
+	t.modules = append(t.modules, m)
+	return m
+}
+
+// perlin returns the seed and quality of the perlin source of a fractal.
+func (t *libnoiseTranslator) perlin(gen *GeneratorJSON) (int64, NoiseQuality, error) {
+	if len(gen.Sources) < 1 {
+		return 0, 0, fmt.Errorf("it has no source")
+	}
+	src, ok := t.cfg.Sources[gen.Sources[0]]
+	if !ok {
+		return 0, 0, fmt.Errorf("its source %q doesn't exist", gen.Sources[0])
+	}
+	if src.SourceType != SourcePerlin {
+		return 0, 0, fmt.Errorf("libnoise only has fractals of perlin noise, not %s", src.SourceType)
+	}
+	seed, ok := t.cfg.Seeds[src.Seed]
+	if !ok {
+		return 0, 0, fmt.Errorf("the seed %q of its source doesn't exist", src.Seed)
+	}
+	return seed, src.Quality, nil
+}
+
+// translate adds the modules of a generator, or returns why it can't.
+func (t *libnoiseTranslator) translate(gen *GeneratorJSON) error {
+	if info, ok := LookupModule(gen.GeneratorType); ok && info.Kind == KindGenerator {
+		if len(gen.Generators) < info.Generators {
+			return fmt.Errorf("it needs %d generators but lists %d", info.Generators, len(gen.Generators))
+		}
+	}
+	for _, name := range gen.Generators {
+		if !t.done[name] {
+			return fmt.Errorf("it uses %q which wasn't exported before it", name)
+		}
+	}
+
+	name := gen.Name
+	switch gen.GeneratorType {
+	case GenFBM2D, GenBillow2D, GenRidged2D:
+		seed, quality, err := t.perlin(gen)
+		if err != nil {
+			return err
+		}
+		if gen.GeneratorType == GenRidged2D && (gen.Offset != 1.0 || gen.Gain != 2.0 || gen.Exponent != 1.0) {
+			return fmt.Errorf("libnoise's RidgedMulti has an Offset of 1, a Gain of 2 and an Exponent of 1")
+		}
+		kind := map[string]string{GenFBM2D: "Perlin", GenBillow2D: "Billow", GenRidged2D: "RidgedMulti"}[gen.GeneratorType]
+		fractal := name
+		if gen.GeneratorType == GenBillow2D {
+			fractal = name + ".billow"
+		}
+		m := t.module(fractal, kind)
+		m.param("Frequency", gen.Frequency).param("Lacunarity", gen.Lacunarity)
+		m.param("OctaveCount", gen.Octaves)
+		if gen.GeneratorType != GenRidged2D {
+			m.param("Persistence", gen.Persistence)
+		}
+		m.param("Seed", seed).param("NoiseQuality", libnoiseQuality(quality))
+		if gen.GeneratorType == GenBillow2D {
+			t.module(name, "ScaleBias").param("Scale", 1.0).param("Bias", -0.5).source(fractal)
+		}
+	case GenSelect2D:
+		t.module(name, "Select").param("LowerBound", gen.LowerBound).param("UpperBound", gen.UpperBound).
+			param("EdgeFalloff", gen.EdgeFalloff).source(gen.Generators[0], gen.Generators[1], gen.Generators[2])
+	case GenScale2D:
+		t.module(name+".scale", "ScaleBias").param("Scale", gen.Scale).param("Bias", gen.Bias).source(gen.Generators[0])
+		t.module(name, "Clamp").param("LowerBound", gen.Min).param("UpperBound", gen.Max).source(name + ".scale")
+	case GenTurbulence2D:
+		// the distortions are sampled at the point times Frequency, the Y
+		// one translated as well, and scaled by Power
+		t.module(name+".xpoint", "ScalePoint").param("XScale", gen.Frequency).param("YScale", 1.0).
+			param("ZScale", gen.Frequency).source(gen.Generators[1])
+		t.module(name+".x", "ScaleBias").param("Scale", gen.Power).param("Bias", 0.0).source(name + ".xpoint")
+		t.module(name+".zoffset", "TranslatePoint").param("XTranslation", turbulenceOffset).param("YTranslation", 0.0).
+			param("ZTranslation", turbulenceOffset).source(gen.Generators[2])
+		t.module(name+".zpoint", "ScalePoint").param("XScale", gen.Frequency).param("YScale", 1.0).
+			param("ZScale", gen.Frequency).source(name + ".zoffset")
+		t.module(name+".z", "ScaleBias").param("Scale", gen.Power).param("Bias", 0.0).source(name + ".zpoint")
+		t.module(name+".y", "Const").param("ConstValue", 0.0)
+		t.module(name, "Displace").source(gen.Generators[0], name+".x", name+".y", name+".z")
+	case GenPattern2D:
+		if gen.Pattern != PatternCylinders {
+			return fmt.Errorf("libnoise has no module for %v", gen.Pattern)
+		}
+		t.module(name, "Cylinders").param("Frequency", gen.Frequency)
+	default:
+		return fmt.Errorf("libnoise has no module for %s", gen.GeneratorType)
+	}
+	return nil
+}
+
+// WriteLibnoiseXML writes the generators of the configuration to w as
+// libnoise XML module definitions in their order. The generators that
+// can't be translated are written as comments and returned as issues; an
+// error is only returned if writing fails.
+func (cfg *NoiseJSON) WriteLibnoiseXML(w io.Writer) ([]LibnoiseIssue, error) {
+	t := libnoiseTranslator{cfg: cfg, done: make(map[string]bool)}
+	var issues []LibnoiseIssue
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<noise>\n")
+	for i := range cfg.Generators {
+		gen := &cfg.Generators[i]
+		t.modules = t.modules[:0]
+		if err := t.translate(gen); err != nil {
+			issue := LibnoiseIssue{gen.Name, gen.GeneratorType, err.Error()}
+			issues = append(issues, issue)
+			comment := fmt.Sprintf(" %s %q can't be exported: %s ", issue.Type, issue.Generator, issue.Reason)
+			fmt.Fprintf(&b, "  <!--%s-->\n", strings.Replace(comment, "--", "- -", -1))
+			continue
+		}
+		t.done[gen.Name] = true
+		for _, m := range t.modules {
+			data, err := xml.MarshalIndent(m, "  ", "  ")
+			if err != nil {
+				return nil, err
+			}
+			b.Write(data)
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("</noise>\n")
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
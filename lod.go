@@ -0,0 +1,182 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module builds a level of detail pyramid from a heightmap for terrain
+renderers. Level 0 is the heightmap itself and every following level halves
+the size, so node (x, y) of level l covers a block of 2^l x 2^l values of the
+heightmap and is the parent of the four nodes (2x, 2y) to (2x+1, 2y+1) of the
+level below, like a quadtree.
+
+	pyramid, err := noisey.BuildLODPyramid(&heightmap, noisey.LODMean, 0)
+	for l := range pyramid.Levels {
+		level := &pyramid.Levels[l]
+		// draw level.Heights where level.Error is below the allowed error
+	}
+
+The filter decides how a block is reduced to one height. LODMean gives the
+best overall surface while LODMax keeps peaks and ridges from sinking, which
+matters for silhouettes against the sky.
+
+For each node a level keeps the lowest and highest heights of the block for
+bounding boxes and the error: the largest difference between the heightmap
+and the bilinear interpolation of the level anywhere in the block. The error
+of a node is never less than the error of its children so that a renderer
+walking the quadtree can stop refining at the first node that's accurate
+enough.
+
+The grid points of a level are at the centers of their blocks and the Bounds
+of each level's maps are set to match, so Heights.Get2D() takes the same world
+coordinates as the heightmap.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// LODFilter selects how a block of heights is reduced to one height.
+type LODFilter int
+
+const (
+	// LODMean uses the average height of the block.
+	LODMean LODFilter = iota
+
+	// LODMax uses the highest height of the block.
+	LODMax
+
+	// LODMin uses the lowest height of the block.
+	LODMin
+)
+
+// String returns the name of the filter.
+func (f LODFilter) String() string {
+	switch f {
+	case LODMean:
+		return "LODMean"
+	case LODMax:
+		return "LODMax"
+	case LODMin:
+		return "LODMin"
+	}
+	return fmt.Sprintf("LODFilter(%d)", int(f))
+}
+
+// LODLevel is one level of a LODPyramid. All of its maps have the same size.
+type LODLevel struct {
+	BlockSize  int      // the number of heightmap values along each side of a node: 2^level
+	Heights    NoiseMap // the filtered height of each node
+	MinHeights NoiseMap // the lowest heightmap value in each node
+	MaxHeights NoiseMap // the highest heightmap value in each node
+	Error      NoiseMap // the largest difference between the level and the heightmap in each node
+}
+
+// LODPyramid is a heightmap and its successively downsampled levels.
+type LODPyramid struct {
+	Filter LODFilter
+	Levels []LODLevel
+}
+
+// BuildLODPyramid builds a pyramid from the heightmap with the filter. If levels
+// is 0 or less levels are added until the last one is a single node.
+func BuildLODPyramid(heightmap *NoiseMap, filter LODFilter, levels int) (pyramid LODPyramid, err error) {
+	if heightmap.Width <= 0 || heightmap.Height <= 0 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return pyramid, fmt.Errorf("Unable to build a pyramid from a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+	if filter < LODMean || filter > LODMin {
+		return pyramid, fmt.Errorf("Unable to build a pyramid with the unknown filter %v.\n", filter)
+	}
+
+	pyramid.Filter = filter
+	w, h := heightmap.Width, heightmap.Height
+	dx := (heightmap.Bounds.MaxX - heightmap.Bounds.MinX) / float64(w)
+	dy := (heightmap.Bounds.MaxY - heightmap.Bounds.MinY) / float64(h)
+
+	for l := 0; levels <= 0 || l < levels; l++ {
+		s := 1 << uint(l)
+		lw, lh := (w+s-1)/s, (h+s-1)/s
+
+		// the grid points sit at the centers of the blocks
+		half := float64(s-1) * 0.5
+		bounds := Builder2DBounds{
+			heightmap.Bounds.MinX + half*dx, heightmap.Bounds.MinY + half*dy,
+			heightmap.Bounds.MinX + (half+float64(lw*s))*dx, heightmap.Bounds.MinY + (half+float64(lh*s))*dy,
+		}
+		level := LODLevel{BlockSize: s}
+		level.Heights = NewNoiseMap(lw, lh, bounds)
+		level.MinHeights = NewNoiseMap(lw, lh, bounds)
+		level.MaxHeights = NewNoiseMap(lw, lh, bounds)
+		level.Error = NewNoiseMap(lw, lh, bounds)
+
+		for y := 0; y < lh; y++ {
+			for x := 0; x < lw; x++ {
+				low, high, sum, count := math.Inf(1), math.Inf(-1), 0.0, 0
+				for sy := y * s; sy < minInt((y+1)*s, h); sy++ {
+					for sx := x * s; sx < minInt((x+1)*s, w); sx++ {
+						v := heightmap.Values[sy*w+sx]
+						low, high = math.Min(low, v), math.Max(high, v)
+						sum += v
+						count++
+					}
+				}
+				i := y*lw + x
+				level.MinHeights.Values[i] = low
+				level.MaxHeights.Values[i] = high
+				switch filter {
+				case LODMax:
+					level.Heights.Values[i] = high
+				case LODMin:
+					level.Heights.Values[i] = low
+				default:
+					level.Heights.Values[i] = sum / float64(count)
+				}
+			}
+		}
+
+		// the error is measured against the interpolated level
+		for y := 0; y < lh; y++ {
+			for x := 0; x < lw; x++ {
+				e := 0.0
+				for sy := y * s; sy < minInt((y+1)*s, h); sy++ {
+					gy := (float64(sy) - half) / float64(s)
+					for sx := x * s; sx < minInt((x+1)*s, w); sx++ {
+						gx := (float64(sx) - half) / float64(s)
+						e = math.Max(e, math.Abs(heightmap.Values[sy*w+sx]-level.Heights.getBilinear(gx, gy)))
+					}
+				}
+				if l > 0 {
+					below := &pyramid.Levels[l-1].Error
+					for cy := 2 * y; cy <= 2*y+1 && cy < below.Height; cy++ {
+						for cx := 2 * x; cx <= 2*x+1 && cx < below.Width; cx++ {
+							e = math.Max(e, below.Values[cy*below.Width+cx])
+						}
+					}
+				}
+				level.Error.Values[y*lw+x] = e
+			}
+		}
+
+		pyramid.Levels = append(pyramid.Levels, level)
+		if lw == 1 && lh == 1 {
+			break
+		}
+	}
+	return pyramid, nil
+}
+
+// SelectLevel returns the coarsest level whose error is no more than
+// maxError everywhere in the block of the heightmap at (x, y) that a node of
+// that level covers.
+func (p *LODPyramid) SelectLevel(x int, y int, maxError float64) int {
+	for l := len(p.Levels) - 1; l > 0; l-- {
+		level := &p.Levels[l]
+		if level.Error.Get(x/level.BlockSize, y/level.BlockSize) <= maxError {
+			return l
+		}
+	}
+	return 0
+}
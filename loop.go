@@ -0,0 +1,73 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes 2D noise that's animated over time and loops seamlessly.
+Sweeping a third axis of 3D noise as time never comes back to where it
+started, so instead time traces a circle through the third and fourth axes
+of 4D noise, which returns to the first frame after each Period:
+
+	perlin := noisey.NewPerlinSeeded(1)
+	loop := noisey.NewLoopingAnimation3D(&perlin, 2.0, 0.5)
+	anim := noisey.NewAnimationExporter(&loop, 256, 256, 50)
+	anim.EndTime = loop.Period
+	err := anim.WriteGIF(file)
+
+The value at (x, y) at time t is Source.Get4D(x, y, Radius*cos(a),
+Radius*sin(a)) with a = 2*Pi*t/Period. Radius sets how far through the noise
+the circle travels, so a larger Radius makes the animation change faster and
+more over each loop. Rendered by AnimationExporter from 0.0 to an EndTime of
+Period, the frames cover one loop exactly and the last frame leads straight
+back into the first.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// LoopingAnimation3D is a module that returns 2D noise animated along the
+// Z axis as time that repeats after Period.
+type LoopingAnimation3D struct {
+	Source NoiseyGet4D // the 4D noise the circle is traced through
+	Period float64     // the time of one loop
+	Radius float64     // the radius of the circle in the units of Source
+}
+
+// NewLoopingAnimation3D creates a new looping animation module.
+func NewLoopingAnimation3D(src NoiseyGet4D, period float64, radius float64) (loop LoopingAnimation3D) {
+	loop.Source = src
+	loop.Period = period
+	loop.Radius = radius
+	return
+}
+
+// String returns a description of the module and its source.
+func (loop *LoopingAnimation3D) String() string {
+	return fmt.Sprintf("LoopingAnimation3D{Period: %v, Radius: %v, Source: %s}", loop.Period, loop.Radius, describeModule(loop.Source))
+}
+
+// Get3D calculates the noise at (x, y) at the time z.
+func (loop *LoopingAnimation3D) Get3D(x float64, y float64, z float64) float64 {
+	a := 0.0
+	if loop.Period != 0.0 {
+		a = 2.0 * math.Pi * z / loop.Period
+	}
+	return loop.Source.Get4D(x, y, loop.Radius*math.Cos(a), loop.Radius*math.Sin(a))
+}
+
+// Range3D returns the bounds of the values of Get3D(), which are those of Source.
+func (loop *LoopingAnimation3D) Range3D() (min float64, max float64) {
+	return ValueRange4D(loop.Source)
+}
+
+// Frame returns the frame at time t as a 2D module.
+func (loop *LoopingAnimation3D) Frame(t float64) NoiseyGet2D {
+	return NoiseFunc2D(func(x float64, y float64) float64 {
+		return loop.Get3D(x, y, t)
+	})
+}
@@ -0,0 +1,258 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module converts 3D density fields into triangle meshes with marching
+cubes. Values greater than the iso level are treated as solid and the
+triangles face out of the solid, with normals calculated from the gradient
+of the field.
+
+	bounds := noisey.Builder3DBounds{0.0, 0.0, 0.0, 32.0, 32.0, 32.0}
+	mesh := noisey.MarchingCubes(&density, bounds, 64, 64, 64, 0.0)
+	mesh.WriteOBJ(file)
+
+Instead of the usual hand written tables, the triangles for each of the 256
+cube configurations are generated when the package is initialized by tracing
+the crossing points around the faces of the cube. Ambiguous faces always keep
+their solid corners apart, and because that choice only depends on the face
+the meshes are watertight.
+
+Reference material:
+* Lorensen and Cline's "Marching Cubes: A High Resolution 3D Surface Construction Algorithm"
+* Paul Bourke's "Polygonising a scalar field": http://paulbourke.net/geometry/polygonise/
+
+*/
+
+import "math"
+
+// mcEdges are the pairs of cube corners joined by each of the 12 cube edges. Corner
+// i is at the offset (i&1, (i>>1)&1, (i>>2)&1) from the minimum corner of the cube.
+var mcEdges [12][2]int
+
+// mcTriangles are the cube edges of the triangles for each of the cube configurations,
+// where bit i of the configuration is set if corner i is solid.
+var mcTriangles [256][][3]int
+
+func init() {
+	// the edges along the X, Y and Z axes in that order
+	e := 0
+	for axis := uint(0); axis < 3; axis++ {
+		for c := 0; c < 8; c++ {
+			if c&(1<<axis) == 0 {
+				mcEdges[e] = [2]int{c, c | 1<<axis}
+				e++
+			}
+		}
+	}
+	edgeOf := func(a, b int) int {
+		for i, edge := range mcEdges {
+			if (edge[0] == a && edge[1] == b) || (edge[0] == b && edge[1] == a) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// the corners of each face in counter-clockwise order seen from outside of the cube
+	var faces [6][4]int
+	f := 0
+	for axis := uint(0); axis < 3; axis++ {
+		b, c := (axis+1)%3, (axis+2)%3
+		for side := 0; side < 2; side++ {
+			var corners [4]int
+			for i, bc := range [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+				corners[i] = side<<axis | bc[0]<<b | bc[1]<<c
+			}
+			if side == 0 {
+				corners[1], corners[3] = corners[3], corners[1]
+			}
+			faces[f] = corners
+			f++
+		}
+	}
+
+	for config := 1; config < 255; config++ {
+		solid := func(c int) bool { return config&(1<<uint(c)) != 0 }
+
+		// for each run of solid corners around a face, link the crossed edge
+		// where the run ends to the one where it starts; on ambiguous faces
+		// every solid corner is a run of its own, which keeps them apart
+		next := make(map[int]int)
+		for _, face := range faces {
+			for i, k := range face {
+				prev := face[(i+3)%4]
+				if !solid(k) || solid(prev) {
+					continue
+				}
+				j := i
+				for solid(face[(j+1)%4]) {
+					j++
+				}
+				next[edgeOf(face[j%4], face[(j+1)%4])] = edgeOf(prev, k)
+			}
+		}
+
+		// chain the links into loops and fan triangulate them
+		used := make(map[int]bool)
+		for start := range mcEdges {
+			if _, ok := next[start]; !ok || used[start] {
+				continue
+			}
+			var loop []int
+			for e := start; !used[e]; e = next[e] {
+				used[e] = true
+				loop = append(loop, e)
+			}
+
+			// start the fan where the fewest of its diagonals lie on a face of the
+			// cube, since the neighbouring cube could use the same diagonal
+			best, bestCount := 0, len(loop)
+			for s := range loop {
+				count := 0
+				for i := 2; i+1 < len(loop); i++ {
+					if mcSameFace(loop[s], loop[(s+i)%len(loop)]) {
+						count++
+					}
+				}
+				if count < bestCount {
+					best, bestCount = s, count
+				}
+			}
+			for i := 1; i+1 < len(loop); i++ {
+				a, b, c := loop[best], loop[(best+i)%len(loop)], loop[(best+i+1)%len(loop)]
+				mcTriangles[config] = append(mcTriangles[config], [3]int{a, c, b})
+			}
+		}
+	}
+}
+
+// mcSameFace returns true if the two cube edges lie on the same face of the cube.
+func mcSameFace(a int, b int) bool {
+	and := mcEdges[a][0] & mcEdges[a][1] & mcEdges[b][0] & mcEdges[b][1]
+	or := mcEdges[a][0] | mcEdges[a][1] | mcEdges[b][0] | mcEdges[b][1]
+	// the corners share a face if one of the coordinates is the same for all of them
+	return and != 0 || or != 7
+}
+
+// mcVolume is a grid of samples that marching cubes runs over.
+type mcVolume struct {
+	nx, ny, nz int // the number of samples along each axis
+	values     []float64
+	origin     Vec3f
+	step       Vec3f
+}
+
+func (v *mcVolume) get(x, y, z int) float64 {
+	x = maxInt(0, minInt(v.nx-1, x))
+	y = maxInt(0, minInt(v.ny-1, y))
+	z = maxInt(0, minInt(v.nz-1, z))
+	return v.values[(z*v.ny+y)*v.nx+x]
+}
+
+// gradient returns the central difference gradient at a grid point.
+func (v *mcVolume) gradient(x, y, z int) Vec3f {
+	return Vec3f{
+		(v.get(x+1, y, z) - v.get(x-1, y, z)) / (2.0 * v.step.X),
+		(v.get(x, y+1, z) - v.get(x, y-1, z)) / (2.0 * v.step.Y),
+		(v.get(x, y, z+1) - v.get(x, y, z-1)) / (2.0 * v.step.Z),
+	}
+}
+
+// polygonise runs marching cubes over the volume.
+func (v *mcVolume) polygonise(level float64) (mesh Mesh) {
+	// vertices are shared between cubes by the grid edge they are on
+	vertices := make(map[int]int)
+	vertex := func(x, y, z int, edge int) int {
+		a, b := mcEdges[edge][0], mcEdges[edge][1]
+		ax, ay, az := x+a&1, y+(a>>1)&1, z+(a>>2)&1
+		bx, by, bz := x+b&1, y+(b>>1)&1, z+(b>>2)&1
+		axis := 0
+		if by != ay {
+			axis = 1
+		} else if bz != az {
+			axis = 2
+		}
+		key := ((az*v.ny+ay)*v.nx+ax)*3 + axis
+		if i, ok := vertices[key]; ok {
+			return i
+		}
+
+		va, vb := v.get(ax, ay, az), v.get(bx, by, bz)
+		t := 0.5
+		if vb != va {
+			t = (level - va) / (vb - va)
+		}
+		pa := Vec3f{float64(ax), float64(ay), float64(az)}
+		pb := Vec3f{float64(bx), float64(by), float64(bz)}
+		p := pa.Lerp(pb, t)
+		p = Vec3f{v.origin.X + p.X*v.step.X, v.origin.Y + p.Y*v.step.Y, v.origin.Z + p.Z*v.step.Z}
+
+		// normals point down the gradient, out of the solid
+		n := v.gradient(ax, ay, az).Lerp(v.gradient(bx, by, bz), t).Scale(-1.0)
+		if l := n.Length(); l > 0.0 {
+			n = n.Scale(1.0 / l)
+		}
+
+		i := len(mesh.Positions)
+		mesh.Positions = append(mesh.Positions, p)
+		mesh.Normals = append(mesh.Normals, n)
+		vertices[key] = i
+		return i
+	}
+
+	for z := 0; z+1 < v.nz; z++ {
+		for y := 0; y+1 < v.ny; y++ {
+			for x := 0; x+1 < v.nx; x++ {
+				config := 0
+				for c := 0; c < 8; c++ {
+					if v.get(x+c&1, y+(c>>1)&1, z+(c>>2)&1) > level {
+						config |= 1 << uint(c)
+					}
+				}
+				for _, tri := range mcTriangles[config] {
+					mesh.Indices = append(mesh.Indices, vertex(x, y, z, tri[0]), vertex(x, y, z, tri[1]), vertex(x, y, z, tri[2]))
+				}
+			}
+		}
+	}
+	return
+}
+
+// MarchingCubes samples src on a grid of nx x ny x nz cubes covering bounds,
+// including the maximum corner, and returns the mesh of the surface at level.
+func MarchingCubes(src NoiseyGet3D, bounds Builder3DBounds, nx int, ny int, nz int, level float64) Mesh {
+	if src == nil || nx < 1 || ny < 1 || nz < 1 {
+		return Mesh{}
+	}
+	v := mcVolume{nx: nx + 1, ny: ny + 1, nz: nz + 1}
+	v.origin = Vec3f{bounds.MinX, bounds.MinY, bounds.MinZ}
+	v.step = Vec3f{(bounds.MaxX - bounds.MinX) / float64(nx), (bounds.MaxY - bounds.MinY) / float64(ny), (bounds.MaxZ - bounds.MinZ) / float64(nz)}
+	v.values = make([]float64, v.nx*v.ny*v.nz)
+	for z := 0; z < v.nz; z++ {
+		for y := 0; y < v.ny; y++ {
+			for x := 0; x < v.nx; x++ {
+				v.values[(z*v.ny+y)*v.nx+x] = src.Get3D(v.origin.X+float64(x)*v.step.X, v.origin.Y+float64(y)*v.step.Y, v.origin.Z+float64(z)*v.step.Z)
+			}
+		}
+	}
+	return v.polygonise(level)
+}
+
+// MarchingCubesVolume returns the mesh of the surface at level of a volume
+// that has already been built. The vertices are placed at the same
+// coordinates the volume was sampled at.
+func MarchingCubesVolume(b *Builder3D, level float64) Mesh {
+	if b.Width < 2 || b.Height < 2 || b.Depth < 2 || len(b.Values) != b.Width*b.Height*b.Depth {
+		return Mesh{}
+	}
+	v := mcVolume{nx: b.Width, ny: b.Height, nz: b.Depth, values: b.Values}
+	v.origin = Vec3f{b.Bounds.MinX, b.Bounds.MinY, b.Bounds.MinZ}
+	v.step = Vec3f{(b.Bounds.MaxX - b.Bounds.MinX) / float64(b.Width), (b.Bounds.MaxY - b.Bounds.MinY) / float64(b.Height), (b.Bounds.MaxZ - b.Bounds.MinZ) / float64(b.Depth)}
+	if v.step.X == 0.0 || v.step.Y == 0.0 || v.step.Z == 0.0 || math.IsNaN(v.step.X+v.step.Y+v.step.Z) {
+		return Mesh{}
+	}
+	return v.polygonise(level)
+}
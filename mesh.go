@@ -0,0 +1,45 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Mesh is an indexed triangle mesh. Every three indexes make a triangle that
+// is wound counter-clockwise when seen from the side its normals face.
+type Mesh struct {
+	Positions []Vec3f // the position of each vertex
+	Normals   []Vec3f // the unit normal of each vertex
+	Indices   []int   // the vertex indexes of the triangles
+}
+
+// TriangleCount returns the number of triangles in the mesh.
+func (m *Mesh) TriangleCount() int {
+	return len(m.Indices) / 3
+}
+
+// WriteOBJ writes the mesh to w in the Wavefront OBJ text format.
+func (m *Mesh) WriteOBJ(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, p := range m.Positions {
+		fmt.Fprintf(bw, "v %g %g %g\n", p.X, p.Y, p.Z)
+	}
+	for _, n := range m.Normals {
+		fmt.Fprintf(bw, "vn %g %g %g\n", n.X, n.Y, n.Z)
+	}
+	hasNormals := len(m.Normals) == len(m.Positions)
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		// OBJ indexes start at 1
+		a, b, c := m.Indices[i]+1, m.Indices[i+1]+1, m.Indices[i+2]+1
+		if hasNormals {
+			fmt.Fprintf(bw, "f %d//%d %d//%d %d//%d\n", a, a, b, b, c, c)
+		} else {
+			fmt.Fprintf(bw, "f %d %d %d\n", a, b, c)
+		}
+	}
+	return bw.Flush()
+}
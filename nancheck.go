@@ -0,0 +1,271 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains a debugging wrapper that checks the values of a module
+for NaN and infinities, so that a value that poisons a whole map can be
+traced back to the module and the coordinates that made it, like a
+NoiseFunc2D dividing by a source that crosses zero or a Scale2D with an
+infinite Scale.
+
+NaNCheck2D and NaNCheck3D pass the values of the module they wrap through
+and call a handler with a NaNReport whenever one isn't finite. Modules of
+a pipeline built in Go can be wrapped with the ReportNaN2D() middleware:
+
+	ratio := noisey.Wrap2D(noisey.NoiseFunc2D(divide), noisey.ReportNaN2D("ratio", nil))
+
+Wrapping every module of a NoiseJSON configuration only needs a call to CheckNaN()
+before building it:
+
+	var log noisey.NaNLog
+	cfg.CheckNaN(log.Record)
+	cfg.BuildSources(nil)
+	cfg.BuildGenerators()
+	builder.Build()
+	if err := log.Err(); err != nil {
+		fmt.Print(err) // generator "ratio" returned NaN at (0.25, 0.75)
+	}
+
+A module samples its inputs before it returns, so the first report of a
+sample comes from the module that made the bad value and the ones after it
+from the modules it went through. Without a handler the wrapper panics with
+the report, which stops at the module in a debugger.
+
+The wrappers slow every module down and hide the modules they wrap from
+Compile2D() and SnapshotGenerator(), so they are meant for debugging only.
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// NaNReport describes a value that isn't finite.
+type NaNReport struct {
+	Module     string  // the name of the module that returned the value
+	Dims       int     // 2 for Get2D() and 3 for Get3D()
+	X, Y, Z    float64 // the coordinates the module was sampled at; Z is 0 for 2D
+	Value      float64 // NaN, +Inf or -Inf
+	Descriptor string  // the String() of the module
+}
+
+// String returns a description of the report.
+func (r NaNReport) String() string {
+	if r.Dims == 3 {
+		return fmt.Sprintf("%s returned %v at (%v, %v, %v)", r.Module, r.Value, r.X, r.Y, r.Z)
+	}
+	return fmt.Sprintf("%s returned %v at (%v, %v)", r.Module, r.Value, r.X, r.Y)
+}
+
+// NaNHandler is called with the reports of a NaN checking module.
+type NaNHandler func(r NaNReport)
+
+// NaNCheck2D is a module that checks the 2D values of Source.
+type NaNCheck2D struct {
+	Name    string      // the name of Source in the reports
+	Source  NoiseyGet2D // the module that is checked
+	Handler NaNHandler  // called with the values that aren't finite; panics if nil
+}
+
+// NewNaNCheck2D creates a new module checking the values of src under the
+// name. A nil handler panics on the first value that isn't finite.
+func NewNaNCheck2D(name string, src NoiseyGet2D, handler NaNHandler) (nc NaNCheck2D) {
+	nc.Name = name
+	nc.Source = src
+	nc.Handler = handler
+	return
+}
+
+// Clone2D returns a deep copy of the module and its source.
+func (nc *NaNCheck2D) Clone2D() NoiseyGet2D {
+	c := *nc
+	c.Source = DeepCopy2D(nc.Source)
+	return &c
+}
+
+// String returns a description of the module and its source.
+func (nc *NaNCheck2D) String() string {
+	return fmt.Sprintf("NaNCheck2D{Name: %s, Source: %s}", nc.Name, describeModule(nc.Source))
+}
+
+// report passes a value that isn't finite to the handler.
+func (nc *NaNCheck2D) report(m interface{}, dims int, x float64, y float64, z float64, v float64) {
+	r := NaNReport{nc.Name, dims, x, y, z, v, describeModule(m)}
+	if nc.Handler == nil {
+		panic(r.String())
+	}
+	nc.Handler(r)
+}
+
+// Get2D returns the value of Source at a given 2D coordinate.
+func (nc *NaNCheck2D) Get2D(x float64, y float64) float64 {
+	v := nc.Source.Get2D(x, y)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		nc.report(nc.Source, 2, x, y, 0.0, v)
+	}
+	return v
+}
+
+// Unwrap returns the module that is checked.
+func (nc *NaNCheck2D) Unwrap() NoiseyGet2D {
+	return nc.Source
+}
+
+// unwrapNaNCheck returns the module checked by a NaN checking module, or s
+// itself if it isn't one, for generators that need a source of a given type.
+func unwrapNaNCheck(s NoiseyGet2D) NoiseyGet2D {
+	for {
+		u, ok := s.(interface{ Unwrap() NoiseyGet2D })
+		if !ok || u.Unwrap() == nil {
+			return s
+		}
+		s = u.Unwrap()
+	}
+}
+
+// Range2D returns the bounds of the values of Source.
+func (nc *NaNCheck2D) Range2D() (min float64, max float64) {
+	return ValueRange2D(nc.Source)
+}
+
+// NaNCheck3D is a module that checks both the 2D and the 3D values of a
+// source that has them, like most of the sources. A source with only 3D
+// values reports every 2D sample as NaN.
+type NaNCheck3D struct {
+	NaNCheck2D
+	Source3D NoiseyGet3D // the 3D values of the module that is checked
+}
+
+// NewNaNCheck3D creates a new module checking the 2D and 3D values of src
+// under the name. A nil handler panics on the first value that isn't finite.
+func NewNaNCheck3D(name string, src NoiseyGet3D, handler NaNHandler) (nc NaNCheck3D) {
+	nc.Name = name
+	nc.Source3D = src
+	nc.Handler = handler
+	if s2, ok := src.(NoiseyGet2D); ok {
+		nc.Source = s2
+	}
+	return
+}
+
+// Clone2D returns a deep copy of the module and its source.
+func (nc *NaNCheck3D) Clone2D() NoiseyGet2D {
+	return nc.Clone3D().(NoiseyGet2D)
+}
+
+// Clone3D returns a deep copy of the module and its source.
+func (nc *NaNCheck3D) Clone3D() NoiseyGet3D {
+	c := *nc
+	c.Source3D = DeepCopy3D(nc.Source3D)
+	if s2, ok := c.Source3D.(NoiseyGet2D); ok {
+		c.Source = s2
+	}
+	return &c
+}
+
+// String returns a description of the module and its source.
+func (nc *NaNCheck3D) String() string {
+	return fmt.Sprintf("NaNCheck3D{Name: %s, Source: %s}", nc.Name, describeModule(nc.Source3D))
+}
+
+// Get2D returns the value of Source at a given 2D coordinate, or reports
+// and returns NaN if the source has no 2D values.
+func (nc *NaNCheck3D) Get2D(x float64, y float64) float64 {
+	if nc.Source == nil {
+		nc.report(nc.Source3D, 2, x, y, 0.0, math.NaN())
+		return math.NaN()
+	}
+	return nc.NaNCheck2D.Get2D(x, y)
+}
+
+// Get3D returns the value of Source3D at a given 3D coordinate.
+func (nc *NaNCheck3D) Get3D(x float64, y float64, z float64) float64 {
+	v := nc.Source3D.Get3D(x, y, z)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		nc.report(nc.Source3D, 3, x, y, z, v)
+	}
+	return v
+}
+
+// Range3D returns the bounds of the values of Source3D.
+func (nc *NaNCheck3D) Range3D() (min float64, max float64) {
+	return ValueRange3D(nc.Source3D)
+}
+
+// CheckNaN2D wraps a module in a NaNCheck3D if it has 3D values too, or in
+// a NaNCheck2D otherwise, so that it can replace the module anywhere.
+func CheckNaN2D(name string, src NoiseyGet2D, handler NaNHandler) NoiseyGet2D {
+	if s3, ok := src.(NoiseyGet3D); ok {
+		nc := NewNaNCheck3D(name, s3, handler)
+		return &nc
+	}
+	nc := NewNaNCheck2D(name, src, handler)
+	return &nc
+}
+
+// ReportNaN2D returns middleware that wraps a module with CheckNaN2D().
+func ReportNaN2D(name string, handler NaNHandler) Middleware2D {
+	return func(s NoiseyGet2D) NoiseyGet2D {
+		return CheckNaN2D(name, s, handler)
+	}
+}
+
+// CheckNaN makes BuildSources() and BuildGenerators() wrap every source and
+// generator they build with CheckNaN2D(), reporting to the handler. It has to
+// be called before they are. The modules are named like `source "perlin"`
+// and `generator "terrain"`.
+func (cfg *NoiseJSON) CheckNaN(handler NaNHandler) {
+	cfg.checkNaN = true
+	cfg.nanHandler = handler
+}
+
+// NaNLog collects the reports of NaN checking modules. Its Record method is
+// a NaNHandler and is safe to call from several goroutines at once.
+type NaNLog struct {
+	// Max is the most reports that are kept; 100 if 0
+	Max int
+
+	lock    sync.Mutex
+	reports []NaNReport
+	count   int
+}
+
+// Record adds a report to the log.
+func (l *NaNLog) Record(r NaNReport) {
+	max := l.Max
+	if max <= 0 {
+		max = 100
+	}
+	l.lock.Lock()
+	if len(l.reports) < max {
+		l.reports = append(l.reports, r)
+	}
+	l.count++
+	l.lock.Unlock()
+}
+
+// Reports returns the reports that were kept in the order they were made.
+func (l *NaNLog) Reports() []NaNReport {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	reports := make([]NaNReport, len(l.reports))
+	copy(reports, l.reports)
+	return reports
+}
+
+// Err returns an error describing the first report and the number of them,
+// or nil if there are none.
+func (l *NaNLog) Err() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.count == 0 {
+		return nil
+	}
+	r := l.reports[0]
+	return fmt.Errorf("%d values weren't finite; the first: %s.\n", l.count, r)
+}
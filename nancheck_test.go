@@ -0,0 +1,94 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+// TestCheckNaNBuild makes sure that configurations build with NaN checking
+// on, including the generators that need a source of a given type, and that
+// the checked pipelines return the same values.
+func TestCheckNaNBuild(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGolden(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log NaNLog
+	g.Config.initBuilt()
+	g.Config.CheckNaN(log.Record)
+	if err := g.Config.BuildSources(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Config.BuildGenerators(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Verify(g.Config, 1e-9); err != nil {
+		t.Error(err)
+	}
+	if err := log.Err(); err != nil {
+		t.Error(err)
+	}
+
+	data, err = ioutil.ReadFile("examples/noise.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadNoiseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.CheckNaN(log.Record)
+	if err := cfg.BuildSources(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.BuildGenerators(); err != nil {
+		t.Fatal(err)
+	}
+	for _, gen := range cfg.Generators {
+		builder := NewBuilder2D(cfg.GetGenerator(gen.Name), 16, 16)
+		if err := builder.Build(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCheckNaNReports makes sure that bad values are reported innermost first.
+func TestCheckNaNReports(t *testing.T) {
+	var log NaNLog
+	neg := Wrap2D(NoiseFunc2D(func(x, y float64) float64 { return -1.0 }), ReportNaN2D("neg", log.Record))
+	root := Wrap2D(NoiseFunc2D(func(x, y float64) float64 { return math.Sqrt(neg.Get2D(x, y)) }), ReportNaN2D("root", log.Record))
+	out := Wrap2D(root, ReportNaN2D("out", log.Record))
+	out.Get2D(1.0, 2.0)
+
+	reports := log.Reports()
+	if len(reports) != 2 || reports[0].Module != "root" || reports[1].Module != "out" {
+		t.Fatalf("got the reports %v, wanted root then out", reports)
+	}
+	if reports[0].X != 1.0 || reports[0].Y != 2.0 || !math.IsNaN(reports[0].Value) {
+		t.Errorf("got the report %v", reports[0])
+	}
+
+	// a source with only 3D values reports its 2D samples instead of crashing
+	var log3 NaNLog
+	only3D := NewNaNCheck3D("only3d", NoiseFunc3D(func(x, y, z float64) float64 { return x }), log3.Record)
+	if v := only3D.Get2D(0.5, 0.5); !math.IsNaN(v) {
+		t.Errorf("Get2D() of a 3D only source returned %v", v)
+	}
+	if v := only3D.Get3D(0.5, 0.5, 0.5); v != 0.5 {
+		t.Errorf("Get3D() returned %v", v)
+	}
+	if len(log3.Reports()) != 1 {
+		t.Errorf("got the reports %v, wanted one", log3.Reports())
+	}
+}
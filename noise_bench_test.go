@@ -70,6 +70,26 @@ func BenchmarkPerlin3D(b *testing.B) {
 	//fmt.Printf("\n\nPerlin resulting sum = %f\n", sum)
 }
 
+func BenchmarkPerlin4D(b *testing.B) {
+	var sum float64 = 0
+	const benchSize = 100
+	const totalBenchSize = benchSize * benchSize
+
+	// make a test generator seeded to 1
+	rngPerlin := rand.New(rand.NewSource(int64(1)))
+	perlin := NewPerlinGenerator(rngPerlin)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := 0; y < benchSize; y++ {
+			for x := 0; x < benchSize; x++ {
+				sum += perlin.Get4D(float64(x)*0.1, float64(y)*0.1, 0.5, 0.25)
+			}
+		}
+	}
+	//fmt.Printf("\n\nPerlin resulting sum = %f\n", sum)
+}
+
 func BenchmarkOpenSimplex2D(b *testing.B) {
 	var sum float64 = 0
 	const benchSize = 100
@@ -131,3 +151,47 @@ func BenchmarkOpenSimplex3D(b *testing.B) {
 	}
 	//	fmt.Printf("\n\nOpenSimplex resulting sum = %f\n", sum)
 }
+
+// benchPipeline2D returns selected fBm and ridged perlin noise like a
+// terrain pipeline would use.
+func benchPipeline2D() NoiseyGet2D {
+	perlin := NewPerlinGenerator(rand.New(rand.NewSource(int64(1))))
+	fbm := NewFBMGenerator2D(&perlin, 6, 0.5, 2.0, 0.01)
+	ridged := NewRidgedGenerator2D(&perlin, 4, 2.0, 0.02, 1.0, 2.0, 1.0)
+	control := NewFBMGenerator2D(&perlin, 3, 0.5, 2.0, 0.005)
+	selector := NewSelect2D(&fbm, &ridged, &control, 0.0, 1.0, 0.125)
+	return &selector
+}
+
+func BenchmarkPipeline2D(b *testing.B) {
+	var sum float64 = 0
+	const benchSize = 100
+	pipeline := benchPipeline2D()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := 0; y < benchSize; y++ {
+			for x := 0; x < benchSize; x++ {
+				sum += pipeline.Get2D(float64(x), float64(y))
+			}
+		}
+	}
+}
+
+func BenchmarkCompiledPipeline2D(b *testing.B) {
+	var sum float64 = 0
+	const benchSize = 100
+	program, err := Compile2D(benchPipeline2D())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := 0; y < benchSize; y++ {
+			for x := 0; x < benchSize; x++ {
+				sum += program.Get2D(float64(x), float64(y))
+			}
+		}
+	}
+}
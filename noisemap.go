@@ -0,0 +1,242 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the NoiseMap type which holds a grid of noise values
+that has already been built, such as the output of Builder2D, and can be
+resampled at any coordinate inside its Bounds.
+
+Value (x, y) of the grid sits at the coordinate
+(MinX + x*(MaxX-MinX)/Width, MinY + y*(MaxY-MinY)/Height) which matches
+the way Builder2D samples its Source.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// MapInterpolation selects how a NoiseMap blends values between grid points.
+type MapInterpolation int
+
+const (
+	MapBilinear MapInterpolation = iota // blend the 4 nearest values linearly
+	MapBicubic                          // blend the 16 nearest values with a Catmull-Rom spline
+	MapNearest                          // use the nearest value without blending
+)
+
+// NoiseMap is a grid of noise values covering a rectangle.
+type NoiseMap struct {
+	// the number of values in each row
+	Width int
+
+	// the number of rows
+	Height int
+
+	// the rectangle the values cover
+	Bounds Builder2DBounds
+
+	// the values in row major order
+	Values []float64
+
+	// how values are blended when sampled between grid points
+	Interpolation MapInterpolation
+}
+
+// NewNoiseMap creates a new noise map of the given size with all values set to zero.
+func NewNoiseMap(width int, height int, bounds Builder2DBounds) (nm NoiseMap) {
+	nm.Width = width
+	nm.Height = height
+	nm.Bounds = bounds
+	nm.Values = make([]float64, width*height)
+	return
+}
+
+// GetNoiseMap returns a NoiseMap for the Values created by Build(). The
+// Values slice is shared between the builder and the map.
+func (b *Builder2D) GetNoiseMap() (nm NoiseMap) {
+	nm.Width = b.Width
+	nm.Height = b.Height
+	nm.Bounds = b.Bounds
+	nm.Values = b.Values
+	return
+}
+
+// Clone returns a copy of the map that does not share Values with the original.
+func (nm *NoiseMap) Clone() NoiseMap {
+	c := *nm
+	c.Values = make([]float64, len(nm.Values))
+	copy(c.Values, nm.Values)
+	return c
+}
+
+// Clone2D returns a copy of the map as a NoiseyGet2D.
+func (nm *NoiseMap) Clone2D() NoiseyGet2D {
+	c := nm.Clone()
+	return &c
+}
+
+// String returns a description of the map.
+func (nm *NoiseMap) String() string {
+	return fmt.Sprintf("NoiseMap{Width: %d, Height: %d, Bounds: %v}", nm.Width, nm.Height, nm.Bounds)
+}
+
+// Get returns the value at the grid point (x, y). Coordinates outside of the
+// grid are clamped to the nearest edge. Zero is returned if the map has no
+// value for the grid point.
+func (nm *NoiseMap) Get(x int, y int) float64 {
+	if x < 0 {
+		x = 0
+	} else if x >= nm.Width {
+		x = nm.Width - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= nm.Height {
+		y = nm.Height - 1
+	}
+	i := y*nm.Width + x
+	if x < 0 || y < 0 || i >= len(nm.Values) {
+		return 0.0
+	}
+	return nm.Values[i]
+}
+
+// Set changes the value at the grid point (x, y). Coordinates outside of the
+// grid are ignored.
+func (nm *NoiseMap) Set(x int, y int, v float64) {
+	if x < 0 || x >= nm.Width || y < 0 || y >= nm.Height {
+		return
+	}
+	if i := y*nm.Width + x; i < len(nm.Values) {
+		nm.Values[i] = v
+	}
+}
+
+// worldGradient returns the central difference gradient at the grid point in
+// units of value per world unit, using one sided differences at the edges.
+func (nm *NoiseMap) worldGradient(x int, y int) (gx float64, gy float64) {
+	dx := (nm.Bounds.MaxX - nm.Bounds.MinX) / float64(nm.Width)
+	dy := (nm.Bounds.MaxY - nm.Bounds.MinY) / float64(nm.Height)
+	x0, x1 := maxInt(x-1, 0), minInt(x+1, nm.Width-1)
+	y0, y1 := maxInt(y-1, 0), minInt(y+1, nm.Height-1)
+	if x1 > x0 && dx != 0.0 {
+		gx = (nm.Get(x1, y) - nm.Get(x0, y)) / (float64(x1-x0) * dx)
+	}
+	if y1 > y0 && dy != 0.0 {
+		gy = (nm.Get(x, y1) - nm.Get(x, y0)) / (float64(y1-y0) * dy)
+	}
+	return
+}
+
+// GetMinMax returns the lowest and the highest Values.
+func (nm *NoiseMap) GetMinMax() (min float64, max float64) {
+	min = math.MaxFloat64
+	max = -math.MaxFloat64
+	for _, v := range nm.Values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return
+}
+
+// toGrid converts a coordinate inside Bounds into fractional grid coordinates.
+func (nm *NoiseMap) toGrid(x float64, y float64) (float64, float64) {
+	gx := (x - nm.Bounds.MinX) / (nm.Bounds.MaxX - nm.Bounds.MinX) * float64(nm.Width)
+	gy := (y - nm.Bounds.MinY) / (nm.Bounds.MaxY - nm.Bounds.MinY) * float64(nm.Height)
+	return gx, gy
+}
+
+// GetInterpolated returns the value of the map at the coordinate (x, y)
+// inside Bounds, blending the surrounding grid values as specified by
+// Interpolation. Coordinates outside of the map are clamped to the edges.
+func (nm *NoiseMap) GetInterpolated(x float64, y float64) float64 {
+	if nm.Width <= 0 || nm.Height <= 0 {
+		return 0.0
+	}
+
+	gx, gy := nm.toGrid(x, y)
+	switch nm.Interpolation {
+	case MapNearest:
+		return nm.Get(int(math.Floor(gx+0.5)), int(math.Floor(gy+0.5)))
+	case MapBicubic:
+		return nm.getBicubic(gx, gy)
+	default:
+		return nm.getBilinear(gx, gy)
+	}
+}
+
+// Get2D returns the value of the map at the coordinate (x, y) so that a
+// NoiseMap can be used as a source for other modules.
+func (nm *NoiseMap) Get2D(x float64, y float64) float64 {
+	return nm.GetInterpolated(x, y)
+}
+
+// Range2D returns the bounds of the values of Get2D(): those of Values,
+// widened by the overshoot of MapBicubic.
+func (nm *NoiseMap) Range2D() (min float64, max float64) {
+	if nm.Width <= 0 || nm.Height <= 0 {
+		return 0.0, 0.0
+	}
+	min, max = nm.GetMinMax()
+	if len(nm.Values) < nm.Width*nm.Height {
+		// the grid points without values are 0.0
+		min, max = unionRange(min, max, 0.0, 0.0)
+	}
+	if nm.Interpolation == MapBicubic {
+		min, max = bicubicRange(min, max)
+	}
+	return
+}
+
+func (nm *NoiseMap) getBilinear(gx float64, gy float64) float64 {
+	return bilinearGrid(nm.Get, gx, gy)
+}
+
+// bilinearGrid blends the 4 grid values around (gx, gy) returned by get.
+func bilinearGrid(get func(x int, y int) float64, gx float64, gy float64) float64 {
+	fx := math.Floor(gx)
+	fy := math.Floor(gy)
+	x0 := int(fx)
+	y0 := int(fy)
+	tx := gx - fx
+	ty := gy - fy
+
+	top := lerp(get(x0, y0), get(x0+1, y0), tx)
+	bottom := lerp(get(x0, y0+1), get(x0+1, y0+1), tx)
+	return lerp(top, bottom, ty)
+}
+
+// cubicInterp interpolates between b and c with a Catmull-Rom spline through a, b, c, d.
+func cubicInterp(a, b, c, d, t float64) float64 {
+	return b + 0.5*t*(c-a+t*(2.0*a-5.0*b+4.0*c-d+t*(3.0*(b-c)+d-a)))
+}
+
+func (nm *NoiseMap) getBicubic(gx float64, gy float64) float64 {
+	return bicubicGrid(nm.Get, gx, gy)
+}
+
+// bicubicGrid blends the 16 grid values around (gx, gy) returned by get.
+func bicubicGrid(get func(x int, y int) float64, gx float64, gy float64) float64 {
+	fx := math.Floor(gx)
+	fy := math.Floor(gy)
+	x0 := int(fx)
+	y0 := int(fy)
+	tx := gx - fx
+	ty := gy - fy
+
+	var rows [4]float64
+	for i := 0; i < 4; i++ {
+		y := y0 - 1 + i
+		rows[i] = cubicInterp(get(x0-1, y), get(x0, y), get(x0+1, y), get(x0+2, y), tx)
+	}
+	return cubicInterp(rows[0], rows[1], rows[2], rows[3], ty)
+}
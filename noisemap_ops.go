@@ -0,0 +1,129 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/* This module contains in-place operations for post-processing a NoiseMap. */
+
+import (
+	"fmt"
+	"math"
+)
+
+// checkSameSize returns an error if the two maps do not have the same
+// dimensions or if either doesn't have a value for every grid point.
+func (nm *NoiseMap) checkSameSize(other *NoiseMap) error {
+	if nm.Width != other.Width || nm.Height != other.Height {
+		return fmt.Errorf("NoiseMap sizes differ: %dx%d and %dx%d.\n", nm.Width, nm.Height, other.Width, other.Height)
+	}
+	if len(nm.Values) != nm.Width*nm.Height || len(other.Values) != other.Width*other.Height {
+		return fmt.Errorf("NoiseMap of size %dx%d has %d and %d values.\n", nm.Width, nm.Height, len(nm.Values), len(other.Values))
+	}
+	return nil
+}
+
+// Add adds the values of other to the values of the map. Both maps must
+// have the same Width and Height and a value for every grid point.
+func (nm *NoiseMap) Add(other *NoiseMap) error {
+	if err := nm.checkSameSize(other); err != nil {
+		return err
+	}
+	for i, v := range other.Values {
+		nm.Values[i] += v
+	}
+	return nil
+}
+
+// Multiply multiplies the values of the map by the values of other. Both
+// maps must have the same Width and Height and a value for every grid point.
+func (nm *NoiseMap) Multiply(other *NoiseMap) error {
+	if err := nm.checkSameSize(other); err != nil {
+		return err
+	}
+	for i, v := range other.Values {
+		nm.Values[i] *= v
+	}
+	return nil
+}
+
+// Normalize linearly rescales the values of the map so that the lowest value
+// becomes min and the highest becomes max. If all values are the same they
+// are all set to min.
+func (nm *NoiseMap) Normalize(min float64, max float64) {
+	low, high := nm.GetMinMax()
+	extent := high - low
+	for i, v := range nm.Values {
+		if extent == 0.0 {
+			nm.Values[i] = min
+		} else {
+			nm.Values[i] = min + (v-low)/extent*(max-min)
+		}
+	}
+}
+
+// Clamp limits the values of the map to the range [min, max].
+func (nm *NoiseMap) Clamp(min float64, max float64) {
+	for i, v := range nm.Values {
+		if v < min {
+			nm.Values[i] = min
+		} else if v > max {
+			nm.Values[i] = max
+		}
+	}
+}
+
+// Remap replaces every value v of the map with f(v).
+func (nm *NoiseMap) Remap(f func(v float64) float64) {
+	for i, v := range nm.Values {
+		nm.Values[i] = f(v)
+	}
+}
+
+// Percentile returns the value that the fraction p [0, 1] of the values of
+// the map are below, so 0.5 returns the median. It's read from a Histogram
+// of the map, so it's within 1/1024 of the range of the values.
+func (nm *NoiseMap) Percentile(p float64) float64 {
+	h := nm.Histogram(0)
+	return h.Percentile(p)
+}
+
+// Equalize spreads the values of the map evenly over [min, max] while
+// keeping their order, so that every part of the range is used by the same
+// number of values, by mapping each value through the CDF of a Histogram of
+// the map. Equal values stay equal.
+func (nm *NoiseMap) Equalize(min float64, max float64) {
+	if len(nm.Values) < 2 {
+		nm.Clamp(min, min)
+		return
+	}
+	h := nm.Histogram(0)
+	if h.Max == h.Min {
+		// equal values share the middle of the range
+		nm.Clamp((min+max)*0.5, (min+max)*0.5)
+		return
+	}
+	h.Remap(nm, func(p float64) float64 { return min + p*(max-min) })
+}
+
+// AutoLevels linearly rescales the values of the map so that the value
+// lowClip of the values are below becomes min and the value highClip of them
+// are below becomes max, and clamps the rest to [min, max]. Clips of 0.0 and
+// 1.0 work like Normalize(); clips like 0.01 and 0.99 keep a few extreme
+// values from squashing the rest.
+func (nm *NoiseMap) AutoLevels(min float64, max float64, lowClip float64, highClip float64) error {
+	if lowClip < 0.0 || highClip > 1.0 || lowClip >= highClip {
+		return fmt.Errorf("Unable to auto-level with the clipping percentiles %v and %v.\n", lowClip, highClip)
+	}
+	h := nm.Histogram(0)
+	low, high := h.Percentile(lowClip), h.Percentile(highClip)
+	extent := high - low
+	for i, v := range nm.Values {
+		if extent == 0.0 {
+			nm.Values[i] = min
+		} else {
+			t := math.Max(0.0, math.Min(1.0, (v-low)/extent))
+			nm.Values[i] = min + t*(max-min)
+		}
+	}
+	return nil
+}
@@ -3,19 +3,40 @@ Package noisey is a library that implements coherent noise algorithms.
 
 The selection is currently:
 
-	* 2D/3D Perlin noise (64bit)
+	* 1D/2D/3D/4D Perlin noise (64bit)
 	* 2D/3D OpenSimplex noise (64bit)
+	* 2D/3D Worley noise (64bit)
 
 The sources above can be combined with different generators and modifiers
 like the following:
 
-	* FBMGenerator2D - fractal Brownian Motion
+	* FBMGenerator2D - fractal Brownian Motion, also in 1D and 3D
+	* PinkNoise1D - 1/f noise, with NoiseMap.ShapeSpectrum() for 1/f^n maps
+	* BrownianNoise1D - a random walk of red noise clamped to a range
+	* RidgedGenerator2D - ridged multifractal noise
 	* Select2D - choose from source A or B depending on control source
 	* Scale2D - modify output by multiplying by a scale and adding a bias constant
+	* Slice2D - sample a 3D source on a plane so it can be used as a 2D source
+	* Extrude3D - extrude a 2D source along an axis so it can be used as a 3D source
+	* Falloff2D - a radial, square or custom mask for making islands
+	* Billow2D - billowy fractal noise that folds each octave into rounded lumps
+	* Turbulence2D - displace the coordinates of a source by two distortion sources
+	* Pattern2D - concentric cylinders or sine stripes for texture recipes
+	* Crackle2D - jagged cracks along Worley cell edges for dried mud, glaze and lava crust
+	* Caustics2D - animated underwater light patterns from layered Worley cell edges
+	* Kaleidoscope2D - fold the coordinates for N-fold rotational or mirror symmetry
+	* Polar2D - sample a source at the radius and angle around a center without a seam
+	* ImageSource - a channel of an image, like a painted mask or an elevation scan, as a source
+	* DensityTerrain3D - combine a surface heightmap with 3D density noise for overhanging voxel terrain
+	* Caves3D - spaghetti tunnels and cheese caverns for voxel terrain
+	* Clouds3D - volumetric cloud density for ray marched skies
+	* LoopingAnimation3D - 2D noise animated over time that loops seamlessly
 
 
 Once the noise generators have been set up, a Builder2D object can be created
-to map a region of noise into a float64 array.
+to map a region of noise into a float64 array. 3D density fields can be turned
+into triangle meshes with MarchingCubes and built maps can be colored with a
+ColorGradient.
 
 An interface called 'RandomSource' is also exported so that a client can implement
 a different random number generator and pass it to the noise generators.
@@ -25,6 +46,8 @@ Sample programs can be found in the 'examples' directory.
 */
 package noisey
 
+import "fmt"
+
 // RandomSource is a generic interface for a random number generator
 // allowing the user to use the built-in RNG or a custom one that implements
 // this interface.
@@ -33,6 +56,11 @@ type RandomSource interface {
 	Perm(int) []int
 }
 
+// NoiseyGet1D is an interface defining how the modules types get noise from a source.
+type NoiseyGet1D interface {
+	Get1D(float64) float64
+}
+
 // NoiseyGet2D is an interface defining how the modules types get noise from a source.
 type NoiseyGet2D interface {
 	Get2D(float64, float64) float64
@@ -43,6 +71,11 @@ type NoiseyGet3D interface {
 	Get3D(float64, float64, float64) float64
 }
 
+// NoiseyGet4D is an interface defining how the modules types get noise from a source.
+type NoiseyGet4D interface {
+	Get4D(float64, float64, float64, float64) float64
+}
+
 // Vec2f is a simple 2D vector of 64 bit floats
 type Vec2f struct {
 	X, Y float64
@@ -68,6 +101,52 @@ type Vec3i struct {
 	X, Y, Z int
 }
 
+// NoiseQuality controls how a source blends the values of the lattice
+// points surrounding a coordinate.
+type NoiseQuality int
+
+const (
+	// QualityDefault sums the radial falloff of each lattice point; this is
+	// the original behavior of the sources.
+	QualityDefault NoiseQuality = iota
+
+	// QualityFast interpolates linearly between lattice points.
+	QualityFast
+
+	// QualityStandard interpolates with a cubic S-curve.
+	QualityStandard
+
+	// QualityBest interpolates with a quintic S-curve which is also smooth
+	// in its second derivative.
+	QualityBest
+)
+
+// String returns the name of the quality setting.
+func (q NoiseQuality) String() string {
+	switch q {
+	case QualityDefault:
+		return "QualityDefault"
+	case QualityFast:
+		return "QualityFast"
+	case QualityStandard:
+		return "QualityStandard"
+	case QualityBest:
+		return "QualityBest"
+	}
+	return fmt.Sprintf("NoiseQuality(%d)", int(q))
+}
+
+// sCurve maps v in [0,1] onto the interpolation curve for the quality setting.
+func (q NoiseQuality) sCurve(v float64) float64 {
+	switch q {
+	case QualityStandard:
+		return calcCubicSCurve(v)
+	case QualityBest:
+		return calcQuinticSCurve(v)
+	}
+	return v
+}
+
 func calcCubicSCurve(v float64) float64 {
 	return v * v * (3 - 2*v)
 }
@@ -82,3 +161,16 @@ func calcQuinticSCurve(v float64) float64 {
 func lerp(a, b, v float64) float64 {
 	return a*(1-v) + b*v
 }
+
+// normalizeRange divides v by the largest magnitude that the source can
+// reach so that it falls in [-1, 1], clamping the rounding errors at the
+// extremes.
+func normalizeRange(v, peak float64) float64 {
+	v /= peak
+	if v > 1.0 {
+		return 1.0
+	} else if v < -1.0 {
+		return -1.0
+	}
+	return v
+}
@@ -0,0 +1,57 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestNormalizedExtremes makes sure that the normalized sources reach close
+// to both ends of [-1, 1] in every dimension and quality, with permutation
+// tables that the peaks weren't measured with. The extremes of a table vary
+// by several percent around the measured peaks, and those of each sign
+// more, so they are only expected within 0.15 of ±1.
+func TestNormalizedExtremes(t *testing.T) {
+	const samples = 200000
+	for seed := int64(17); seed <= 18; seed++ {
+		type source struct {
+			name string
+			get  func(r *rand.Rand) float64
+		}
+		var sources []source
+		for q := QualityDefault; q <= QualityBest; q++ {
+			pg := NewNormalizedPerlinGenerator(rand.New(rand.NewSource(seed)), q)
+			sources = append(sources,
+				source{fmt.Sprintf("Perlin %v 1D", q), func(r *rand.Rand) float64 { return pg.Get1D(r.Float64() * 256) }},
+				source{fmt.Sprintf("Perlin %v 2D", q), func(r *rand.Rand) float64 { return pg.Get2D(r.Float64()*256, r.Float64()*256) }},
+				source{fmt.Sprintf("Perlin %v 3D", q), func(r *rand.Rand) float64 {
+					return pg.Get3D(r.Float64()*256, r.Float64()*256, r.Float64()*256)
+				}},
+				source{fmt.Sprintf("Perlin %v 4D", q), func(r *rand.Rand) float64 {
+					return pg.Get4D(r.Float64()*256, r.Float64()*256, r.Float64()*256, r.Float64()*256)
+				}})
+		}
+		osg := NewNormalizedOpenSimplexGenerator(rand.New(rand.NewSource(seed)))
+		sources = append(sources,
+			source{"OpenSimplex 2D", func(r *rand.Rand) float64 { return osg.Get2D(r.Float64()*256, r.Float64()*256) }},
+			source{"OpenSimplex 3D", func(r *rand.Rand) float64 {
+				return osg.Get3D(r.Float64()*256, r.Float64()*256, r.Float64()*256)
+			}})
+
+		for _, s := range sources {
+			r := rand.New(rand.NewSource(seed))
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for i := 0; i < samples; i++ {
+				v := s.get(r)
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			if lo < -1.0 || hi > 1.0 || lo > -0.85 || hi < 0.85 {
+				t.Errorf("%s with seed %d spans [%v, %v]; expected close to [-1, 1]", s.name, seed, lo, hi)
+			}
+		}
+	}
+}
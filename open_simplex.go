@@ -21,6 +21,7 @@ The following references were used to implement this algorithm:
 
 import (
 	"math"
+	"math/rand"
 )
 
 const (
@@ -31,6 +32,20 @@ const (
 	squishConstant3D  = 1.0 / 3.0          // (sqrt(3+1)-1)/3;
 	normConstant2D    = 47.0
 	normConstant3D    = 103.0
+
+	// The largest magnitudes of the values after dividing by normConstant2D
+	// and normConstant3D. Every vertex draws its gradient from the table
+	// independently, so the bound over all permutation tables is the maximum
+	// over the lattice of sum((2 - |d|^2)^4 * max(g . d)) for the offsets d
+	// to the contributing vertices and the gradients g.
+	openSimplexPeak2D = 0.86592038891418233
+	openSimplexPeak3D = 0.98714654222970122
+
+	// Tables come within a percent of the 2D bound, but the 3D one is only
+	// reached at small spots, so Normalized divides the 3D values by the
+	// average of the largest magnitudes of a million samples of each of 16
+	// tables instead, clamping the tips that go past it.
+	openSimplexNormalPeak3D = 0.9451
 )
 
 var (
@@ -64,6 +79,7 @@ type OpenSimplexGenerator struct {
 	Rng             RandomSource // random number generator interface
 	Permutations    []int        // the random permutation table
 	PermGradIndex3D []int
+	Normalized      bool // scales Get2D and Get3D to [-1, 1]
 }
 
 // NewOpenSimplexGenerator creates a new state object for the open simplex noise generator
@@ -71,16 +87,89 @@ func NewOpenSimplexGenerator(rng RandomSource) (osg OpenSimplexGenerator) {
 	osg.Rng = rng
 	osg.Permutations = rng.Perm(permTableSize)
 
-	// construct the gradient index table
+	osg.calcPermGradIndex3D()
+	return
+}
+
+// calcPermGradIndex3D constructs the gradient index table from the permutations.
+func (osg *OpenSimplexGenerator) calcPermGradIndex3D() {
 	osg.PermGradIndex3D = make([]int, permTableSize)
 	gradLengthDiv3 := len(gradients3D) / 3
 	for i := range osg.PermGradIndex3D {
 		osg.PermGradIndex3D[i] = (osg.Permutations[i] % gradLengthDiv3) * 3
 	}
+}
 
+// NewNormalizedOpenSimplexGenerator creates a new state object for the open
+// simplex noise generator whose Get2D and Get3D values are scaled to [-1, 1],
+// like those of NewNormalizedPerlinGenerator.
+func NewNormalizedOpenSimplexGenerator(rng RandomSource) (osg OpenSimplexGenerator) {
+	osg = NewOpenSimplexGenerator(rng)
+	osg.Normalized = true
 	return
 }
 
+// NewOpenSimplexSeeded creates a new open simplex noise generator using Go's
+// built in random number generator seeded with seed. This is the same generator
+// that NoiseJSON.BuildSources() creates when no RandomSeedBuilder is supplied.
+func NewOpenSimplexSeeded(seed int64) OpenSimplexGenerator {
+	return NewOpenSimplexGenerator(rand.New(rand.NewSource(seed)))
+}
+
+// Clone returns a deep copy of the generator. The Rng is shared with the copy.
+func (osg *OpenSimplexGenerator) Clone() *OpenSimplexGenerator {
+	c := *osg
+	c.Permutations = copyInts(osg.Permutations)
+	c.PermGradIndex3D = copyInts(osg.PermGradIndex3D)
+	return &c
+}
+
+// Clone2D returns a deep copy of the generator as a NoiseyGet2D.
+func (osg *OpenSimplexGenerator) Clone2D() NoiseyGet2D {
+	return osg.Clone()
+}
+
+// Clone3D returns a deep copy of the generator as a NoiseyGet3D.
+func (osg *OpenSimplexGenerator) Clone3D() NoiseyGet3D {
+	return osg.Clone()
+}
+
+// SetPermutations validates and replaces the permutation table which must
+// contain each of the values 0 to 255 exactly once. The gradient index table
+// derived from it is recalculated.
+func (osg *OpenSimplexGenerator) SetPermutations(perm []int) error {
+	if err := validatePermutations(perm, permTableSize); err != nil {
+		return err
+	}
+	osg.Permutations = copyInts(perm)
+	osg.calcPermGradIndex3D()
+	return nil
+}
+
+// String returns a description of the generator.
+func (osg *OpenSimplexGenerator) String() string {
+	if osg.Normalized {
+		return "OpenSimplexGenerator{Normalized: true}"
+	}
+	return "OpenSimplexGenerator{}"
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (osg *OpenSimplexGenerator) Range2D() (min float64, max float64) {
+	if osg.Normalized {
+		return -1.0, 1.0
+	}
+	return -openSimplexPeak2D, openSimplexPeak2D
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (osg *OpenSimplexGenerator) Range3D() (min float64, max float64) {
+	if osg.Normalized {
+		return -1.0, 1.0
+	}
+	return -openSimplexPeak3D, openSimplexPeak3D
+}
+
 func (osg *OpenSimplexGenerator) extrapolate2(xsb int, ysb int, dx float64, dy float64) float64 {
 	index := osg.Permutations[(osg.Permutations[xsb&0xFF]+ysb)&0xFF] & 0x0E
 	return float64(gradients2D[index])*dx + float64(gradients2D[index+1])*dy
@@ -197,6 +286,9 @@ func (osg *OpenSimplexGenerator) Get2D(x float64, y float64) float64 {
 		value += attn_ext * attn_ext * osg.extrapolate2(xsv_ext, ysv_ext, dx_ext, dy_ext)
 	}
 
+	if osg.Normalized {
+		return normalizeRange(value/normConstant2D, openSimplexPeak2D)
+	}
 	return value / normConstant2D
 }
 
@@ -792,5 +884,8 @@ func (osg *OpenSimplexGenerator) Get3D(x float64, y float64, z float64) float64
 		value += attn_ext1 * attn_ext1 * osg.extrapolate3(xsv_ext1, ysv_ext1, zsv_ext1, dx_ext1, dy_ext1, dz_ext1)
 	}
 
+	if osg.Normalized {
+		return normalizeRange(value/normConstant3D, openSimplexNormalPeak3D)
+	}
 	return value / normConstant3D
 }
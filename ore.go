@@ -0,0 +1,232 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module distributes ores and other resources through voxel terrain. The Y
+axis points up. Each ore is shaped by a 3D noise source and is limited to a
+band of heights where it is most common at a peak height:
+
+* Blob ores are found where the noise is above a threshold, which makes
+  round clusters.
+
+* Vein ores are found where the noise is close to zero at two offset samples,
+  which makes long thin veins along the curves where the zero surfaces cross.
+
+Ores can be set up in code or listed in the Ores section of a NoiseJSON file
+where they reference the names of its sources:
+
+	"Ores": [
+	  {
+	    "Name": "coal",
+	    "Source": "perlin",
+	    "Shape": "blob",
+	    "Frequency": 0.15,
+	    "Threshold": 0.35,
+	    "MinY": 0, "PeakY": 48, "MaxY": 128,
+	    "Abundance": 1.0
+	  }
+	]
+
+After BuildSources() has been called, BuildOres() creates the distribution:
+
+	ores, err := noiseBank.BuildOres()
+	if i := ores.OreAt(x, y, z); i >= 0 {
+		// place ores.Ores[i].Name at (x, y, z)
+	}
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// The Shape strings understood by BuildOres().
+const (
+	OreBlobShape = "blob"
+	OreVeinShape = "vein"
+)
+
+// OreShape determines how the noise of an Ore is turned into deposits.
+type OreShape int
+
+const (
+	// OreBlob places ore where the noise is above the threshold.
+	OreBlob OreShape = iota
+
+	// OreVein places ore where two offset noise samples are both close to zero.
+	OreVein
+)
+
+// String returns the name of the ore shape.
+func (s OreShape) String() string {
+	switch s {
+	case OreBlob:
+		return "OreBlob"
+	case OreVein:
+		return "OreVein"
+	}
+	return fmt.Sprintf("OreShape(%d)", int(s))
+}
+
+// oreVeinOffset is how far apart the two samples of a vein are taken.
+const oreVeinOffset = 1000.5
+
+// Ore describes how one type of ore is distributed.
+type Ore struct {
+	Name      string      // the name of the ore
+	Source    NoiseyGet3D // the noise that shapes the deposits
+	Shape     OreShape    // how the noise is turned into deposits
+	Frequency float64     // the frequency the noise is sampled at
+	Threshold float64     // for blobs, the noise value deposits start at; for veins, how close to zero the samples must be
+	MinY      float64     // the lowest height the ore is found at
+	PeakY     float64     // the height the ore is most common at
+	MaxY      float64     // the highest height the ore is found at
+	Abundance float64     // how common the ore is at PeakY [0, 1]
+}
+
+// depthWeight returns how common the ore is at height y in [0, Abundance]
+// with a triangular distribution between MinY and MaxY peaking at PeakY.
+func (o *Ore) depthWeight(y float64) float64 {
+	if y < o.MinY || y > o.MaxY {
+		return 0.0
+	}
+	var w float64
+	switch {
+	case y == o.PeakY:
+		w = 1.0
+	case y < o.PeakY:
+		w = (y - o.MinY) / (o.PeakY - o.MinY)
+	default:
+		w = (o.MaxY - y) / (o.MaxY - o.PeakY)
+	}
+	return w * math.Max(0.0, math.Min(1.0, o.Abundance))
+}
+
+// Get3D returns a value greater than 0.0 where the ore is found.
+func (o *Ore) Get3D(x float64, y float64, z float64) float64 {
+	w := o.depthWeight(y)
+	if w <= 0.0 || o.Source == nil {
+		return -1.0
+	}
+	f := o.Frequency
+	n := o.Source.Get3D(x*f, y*f, z*f)
+	if o.Shape == OreVein {
+		m := o.Source.Get3D(x*f+oreVeinOffset, y*f, z*f+oreVeinOffset)
+		return o.Threshold*w - math.Max(math.Abs(n), math.Abs(m))
+	}
+	// less common depths raise the threshold towards 1.0
+	return n - (o.Threshold + (1.0-o.Threshold)*(1.0-w))
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (o *Ore) Range3D() (min float64, max float64) {
+	if o.Source == nil {
+		return -1.0, -1.0
+	}
+	lo, hi := ValueRange3D(o.Source)
+	if o.Shape == OreVein {
+		alo, ahi := absRange(lo, hi)
+		min, max = math.Min(0.0, o.Threshold)-ahi, math.Max(0.0, o.Threshold)-alo
+	} else {
+		// the threshold is between Threshold and 1.0 depending on the depth
+		min, max = lo-math.Max(o.Threshold, 1.0), hi-math.Min(o.Threshold, 1.0)
+	}
+	return unionRange(min, max, -1.0, -1.0)
+}
+
+// String returns a description of the ore and its source.
+func (o *Ore) String() string {
+	return fmt.Sprintf("Ore{Name: %s, Shape: %v, Frequency: %v, Threshold: %v, MinY: %v, PeakY: %v, MaxY: %v, Abundance: %v, Source: %s}",
+		o.Name, o.Shape, o.Frequency, o.Threshold, o.MinY, o.PeakY, o.MaxY, o.Abundance, describeModule(o.Source))
+}
+
+// OreDistribution is an ordered list of ores where earlier ores take
+// precedence over later ones when they overlap.
+type OreDistribution struct {
+	Ores []Ore
+}
+
+// OreAt returns the index of the first ore found at the coordinate or -1.
+func (od *OreDistribution) OreAt(x float64, y float64, z float64) int {
+	for i := range od.Ores {
+		if od.Ores[i].Get3D(x, y, z) > 0.0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// BakeChunk returns the ore index of each voxel in a chunk or -1 where there
+// is no ore. The chunk is laid out the same way as Caves3D.BakeChunk().
+func (od *OreDistribution) BakeChunk(originX float64, originY float64, originZ float64, sizeX int, sizeY int, sizeZ int, voxelSize float64) []int {
+	if sizeX <= 0 || sizeY <= 0 || sizeZ <= 0 {
+		return nil
+	}
+	indexes := make([]int, sizeX*sizeY*sizeZ)
+	for z := 0; z < sizeZ; z++ {
+		wz := originZ + float64(z)*voxelSize
+		for y := 0; y < sizeY; y++ {
+			wy := originY + float64(y)*voxelSize
+			for x := 0; x < sizeX; x++ {
+				wx := originX + float64(x)*voxelSize
+				indexes[(z*sizeY+y)*sizeX+x] = od.OreAt(wx, wy, wz)
+			}
+		}
+	}
+	return indexes
+}
+
+// OreJSON describes an ore in the Ores section of a NoiseJSON file.
+type OreJSON struct {
+	// Name is the name of the ore
+	Name string
+
+	// Source is a name in the NoiseJSON.Sources map of the noise that shapes the deposits
+	Source string
+
+	// Shape is either "blob" or "vein"; blob is used if it's empty
+	Shape string
+
+	Frequency float64 // Frequency is the frequency the noise is sampled at
+	Threshold float64 // Threshold is the noise value deposits start at for blobs or the thickness for veins
+	MinY      float64 // MinY is the lowest height the ore is found at
+	PeakY     float64 // PeakY is the height the ore is most common at
+	MaxY      float64 // MaxY is the highest height the ore is found at
+	Abundance float64 // Abundance is how common the ore is at PeakY [0, 1]
+}
+
+// BuildOres creates the ore distribution from the Ores section. This method
+// must be called after BuildSources().
+func (cfg *NoiseJSON) BuildOres() (od OreDistribution, err error) {
+	od.Ores = make([]Ore, 0, len(cfg.Ores))
+	for _, oj := range cfg.Ores {
+		built, ok := cfg.builtSources[oj.Source]
+		if !ok {
+			return od, fmt.Errorf("Ore \"%s\" creation failed: couldn't find built source \"%s\".\n", oj.Name, oj.Source)
+		}
+		src, ok := built.(NoiseyGet3D)
+		if !ok {
+			return od, fmt.Errorf("Ore \"%s\" creation failed: source \"%s\" is not a 3D source.\n", oj.Name, oj.Source)
+		}
+		if !(oj.MinY <= oj.PeakY && oj.PeakY <= oj.MaxY) {
+			return od, fmt.Errorf("Ore \"%s\" creation failed: MinY (%v), PeakY (%v) and MaxY (%v) must be in increasing order.\n", oj.Name, oj.MinY, oj.PeakY, oj.MaxY)
+		}
+
+		o := Ore{Name: oj.Name, Source: src, Frequency: oj.Frequency, Threshold: oj.Threshold,
+			MinY: oj.MinY, PeakY: oj.PeakY, MaxY: oj.MaxY, Abundance: oj.Abundance}
+		switch oj.Shape {
+		case OreBlobShape, "":
+			o.Shape = OreBlob
+		case OreVeinShape:
+			o.Shape = OreVein
+		default:
+			return od, fmt.Errorf("Undefined ore shape (%s) for ore %s.\n", oj.Shape, oj.Name)
+		}
+		od.Ores = append(od.Ores, o)
+	}
+	return od, nil
+}
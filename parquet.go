@@ -0,0 +1,397 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module writes built NoiseMaps as Apache Parquet files of one row per
+grid point, so the distributions of generated terrain can be analyzed in
+Spark, pandas, DuckDB or anything else that reads Parquet or Arrow:
+
+	heights := builder.GetNoiseMap()
+	moisture := moistureBuilder.GetNoiseMap()
+	channels := []noisey.ParquetChannel{{Name: "moisture", Map: &moisture}}
+	err := noisey.WriteParquet(file, &heights, channels, noisey.ParquetOptions{Gzip: true})
+
+Every row has the x and y world coordinates the point was sampled at, its
+value and the values of the extra channels at the same grid point, all as
+required DOUBLE columns:
+
+	x, y, value, moisture, ...
+
+The rows are in the order of the map, row by row, and are split into row
+groups of RowGroupSize rows so readers can split the file between workers.
+The columns are written PLAIN encoded in a single page per row group,
+either uncompressed or compressed with gzip, with their lowest and highest
+values as statistics when they have no NaN. The width, height and bounds of
+the map are stored in the key/value metadata of the file as noisey.width,
+noisey.height and noisey.bounds.
+
+*/
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ParquetChannel is an extra column of a Parquet file with the values of
+// another map of the same size.
+type ParquetChannel struct {
+	Name string    // the name of the column
+	Map  *NoiseMap // the values of the column, one for every grid point
+}
+
+// ParquetOptions controls how WriteParquet writes a file.
+type ParquetOptions struct {
+	// RowGroupSize is the number of rows of a row group; 1<<20 if it is 0
+	RowGroupSize int
+
+	// Gzip compresses the pages with gzip
+	Gzip bool
+}
+
+// the thrift compact protocol types used by the Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the thrift compact protocol.
+type thriftWriter struct {
+	b      bytes.Buffer
+	lastID []int16 // the last field id of each open struct
+}
+
+// varint writes an unsigned LEB128 varint.
+func (t *thriftWriter) varint(v uint64) {
+	for v >= 0x80 {
+		t.b.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	t.b.WriteByte(byte(v))
+}
+
+// zigzag writes a signed integer as a zigzag varint.
+func (t *thriftWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+// field writes the header of a field of the open struct.
+func (t *thriftWriter) field(id int16, kind byte) {
+	last := &t.lastID[len(t.lastID)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.b.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.b.WriteByte(kind)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+// begin opens a struct, either at the top or as the value of a field or
+// list that was written before.
+func (t *thriftWriter) begin() {
+	t.lastID = append(t.lastID, 0)
+}
+
+// end closes the open struct.
+func (t *thriftWriter) end() {
+	t.b.WriteByte(0)
+	t.lastID = t.lastID[:len(t.lastID)-1]
+}
+
+// list writes the header of a list of n elements of a kind.
+func (t *thriftWriter) list(n int, kind byte) {
+	if n < 15 {
+		t.b.WriteByte(byte(n)<<4 | kind)
+	} else {
+		t.b.WriteByte(0xf0 | kind)
+		t.varint(uint64(n))
+	}
+}
+
+// binary writes the length and bytes of a string or binary value.
+func (t *thriftWriter) binary(data []byte) {
+	t.varint(uint64(len(data)))
+	t.b.Write(data)
+}
+
+// i32 writes an i32 field.
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+// i64 writes an i64 field.
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+// str writes a string or binary field.
+func (t *thriftWriter) str(id int16, data []byte) {
+	t.field(id, thriftBinary)
+	t.binary(data)
+}
+
+// the Parquet enums that are written
+const (
+	parquetDouble       = 5 // Type DOUBLE
+	parquetRequired     = 0 // FieldRepetitionType REQUIRED
+	parquetPlain        = 0 // Encoding PLAIN
+	parquetRLE          = 3 // Encoding RLE
+	parquetUncompressed = 0 // CompressionCodec UNCOMPRESSED
+	parquetGzip         = 2 // CompressionCodec GZIP
+	parquetDataPage     = 0 // PageType DATA_PAGE
+)
+
+// parquetChunk is a written column chunk of a row group.
+type parquetChunk struct {
+	offset           int64 // where its page header starts
+	uncompressedSize int64 // the size of its page header and values
+	compressedSize   int64 // the size of its page header and page
+	min, max         float64
+	stats            bool // min and max are valid
+}
+
+// parquetColumn is a column of the file.
+type parquetColumn struct {
+	name  string
+	value func(i int) float64 // the value of row i
+}
+
+// WriteParquet writes the map and the extra channels to w as a Parquet file
+// with a row for every grid point. The channels have to have the size of
+// the map and names other than x, y and value.
+func WriteParquet(w io.Writer, nm *NoiseMap, channels []ParquetChannel, opts ParquetOptions) error {
+	rows := nm.Width * nm.Height
+	if nm.Width <= 0 || nm.Height <= 0 || len(nm.Values) < rows {
+		return fmt.Errorf("Unable to write a %dx%d map with %d values as Parquet.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	if opts.RowGroupSize < 0 {
+		return fmt.Errorf("Unable to write row groups of %d rows.\n", opts.RowGroupSize)
+	}
+	groupSize := opts.RowGroupSize
+	if groupSize == 0 {
+		groupSize = 1 << 20
+	}
+
+	// the coordinates are accumulated like Builder2D does, so they are the
+	// exact points the values were sampled at
+	xs := make([]float64, nm.Width)
+	ys := make([]float64, nm.Height)
+	xDelta := (nm.Bounds.MaxX - nm.Bounds.MinX) / float64(nm.Width)
+	yDelta := (nm.Bounds.MaxY - nm.Bounds.MinY) / float64(nm.Height)
+	for i, x := 0, nm.Bounds.MinX; i < nm.Width; i, x = i+1, x+xDelta {
+		xs[i] = x
+	}
+	for i, y := 0, nm.Bounds.MinY; i < nm.Height; i, y = i+1, y+yDelta {
+		ys[i] = y
+	}
+
+	columns := []parquetColumn{
+		{"x", func(i int) float64 { return xs[i%nm.Width] }},
+		{"y", func(i int) float64 { return ys[i/nm.Width] }},
+		{"value", func(i int) float64 { return nm.Values[i] }},
+	}
+	names := map[string]bool{"x": true, "y": true, "value": true}
+	for _, ch := range channels {
+		if ch.Name == "" || names[ch.Name] {
+			return fmt.Errorf("Unable to write a channel named %q; the names have to be unique and none of x, y or value.\n", ch.Name)
+		}
+		if ch.Map == nil || ch.Map.Width != nm.Width || ch.Map.Height != nm.Height || len(ch.Map.Values) < rows {
+			return fmt.Errorf("Unable to write the channel %q; its map has to be %dx%d like the values.\n", ch.Name, nm.Width, nm.Height)
+		}
+		names[ch.Name] = true
+		values := ch.Map.Values
+		columns = append(columns, parquetColumn{ch.Name, func(i int) float64 { return values[i] }})
+	}
+
+	// the magic number, then the pages of every column of every row group
+	var offset int64
+	write := func(data []byte) error {
+		n, err := w.Write(data)
+		offset += int64(n)
+		return err
+	}
+	if err := write([]byte("PAR1")); err != nil {
+		return err
+	}
+	var groups [][]parquetChunk
+	page := make([]byte, 0, 8*int(math.Min(float64(groupSize), float64(rows))))
+	for start := 0; start < rows; start += groupSize {
+		end := start + groupSize
+		if end > rows {
+			end = rows
+		}
+		var chunks []parquetChunk
+		for _, col := range columns {
+			chunk := parquetChunk{offset: offset, min: math.Inf(1), max: math.Inf(-1), stats: true}
+			page = page[:0]
+			for i := start; i < end; i++ {
+				v := col.value(i)
+				if math.IsNaN(v) {
+					chunk.stats = false
+				}
+				chunk.min = math.Min(chunk.min, v)
+				chunk.max = math.Max(chunk.max, v)
+				page = binary.LittleEndian.AppendUint64(page, math.Float64bits(v))
+			}
+			data := page
+			if opts.Gzip {
+				var b bytes.Buffer
+				zw := gzip.NewWriter(&b)
+				zw.Write(page)
+				if err := zw.Close(); err != nil {
+					return err
+				}
+				data = b.Bytes()
+			}
+
+			var t thriftWriter
+			t.begin()
+			t.i32(1, parquetDataPage)
+			t.i32(2, int32(len(page)))
+			t.i32(3, int32(len(data)))
+			t.field(5, thriftStruct)
+			t.begin()
+			t.i32(1, int32(end-start))
+			t.i32(2, parquetPlain)
+			t.i32(3, parquetRLE)
+			t.i32(4, parquetRLE)
+			t.end()
+			t.end()
+			header := t.b.Bytes()
+			chunk.uncompressedSize = int64(len(header) + len(page))
+			chunk.compressedSize = int64(len(header) + len(data))
+			if err := write(header); err != nil {
+				return err
+			}
+			if err := write(data); err != nil {
+				return err
+			}
+			chunks = append(chunks, chunk)
+		}
+		groups = append(groups, chunks)
+	}
+
+	// the FileMetaData describes the schema and where the pages are
+	codec := int32(parquetUncompressed)
+	if opts.Gzip {
+		codec = parquetGzip
+	}
+	double := func(v float64) []byte {
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v))
+	}
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1)
+	t.field(2, thriftList)
+	t.list(len(columns)+1, thriftStruct)
+	t.begin()
+	t.str(4, []byte("schema"))
+	t.i32(5, int32(len(columns)))
+	t.end()
+	for _, col := range columns {
+		t.begin()
+		t.i32(1, parquetDouble)
+		t.i32(3, parquetRequired)
+		t.str(4, []byte(col.name))
+		t.end()
+	}
+	t.i64(3, int64(rows))
+	t.field(4, thriftList)
+	t.list(len(groups), thriftStruct)
+	for g, chunks := range groups {
+		groupRows := groupSize
+		if g == len(groups)-1 {
+			groupRows = rows - g*groupSize
+		}
+		var total int64
+		t.begin()
+		t.field(1, thriftList)
+		t.list(len(chunks), thriftStruct)
+		for c, chunk := range chunks {
+			total += chunk.uncompressedSize
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.field(3, thriftStruct)
+			t.begin()
+			t.i32(1, parquetDouble)
+			t.field(2, thriftList)
+			t.list(2, thriftI32)
+			t.zigzag(parquetPlain)
+			t.zigzag(parquetRLE)
+			t.field(3, thriftList)
+			t.list(1, thriftBinary)
+			t.binary([]byte(columns[c].name))
+			t.i32(4, codec)
+			t.i64(5, int64(groupRows))
+			t.i64(6, chunk.uncompressedSize)
+			t.i64(7, chunk.compressedSize)
+			t.i64(9, chunk.offset)
+			if chunk.stats {
+				// a zero is written as -0 at the bottom and +0 at the top
+				if chunk.min == 0.0 {
+					chunk.min = math.Copysign(0.0, -1.0)
+				}
+				if chunk.max == 0.0 {
+					chunk.max = 0.0
+				}
+				t.field(12, thriftStruct)
+				t.begin()
+				t.i64(3, 0)
+				t.str(5, double(chunk.max))
+				t.str(6, double(chunk.min))
+				t.end()
+			}
+			t.end()
+			t.end()
+		}
+		t.i64(2, total)
+		t.i64(3, int64(groupRows))
+		t.end()
+	}
+	metadata := [][2]string{
+		{"noisey.width", strconv.Itoa(nm.Width)},
+		{"noisey.height", strconv.Itoa(nm.Height)},
+		{"noisey.bounds", fmt.Sprintf("%s,%s,%s,%s",
+			strconv.FormatFloat(nm.Bounds.MinX, 'g', -1, 64), strconv.FormatFloat(nm.Bounds.MinY, 'g', -1, 64),
+			strconv.FormatFloat(nm.Bounds.MaxX, 'g', -1, 64), strconv.FormatFloat(nm.Bounds.MaxY, 'g', -1, 64))},
+	}
+	t.field(5, thriftList)
+	t.list(len(metadata), thriftStruct)
+	for _, kv := range metadata {
+		t.begin()
+		t.str(1, []byte(kv[0]))
+		t.str(2, []byte(kv[1]))
+		t.end()
+	}
+	t.str(6, []byte("noisey"))
+
+	// the statistics of the columns are ordered by their values
+	t.field(7, thriftList)
+	t.list(len(columns), thriftStruct)
+	for range columns {
+		t.begin()
+		t.field(1, thriftStruct)
+		t.begin()
+		t.end()
+		t.end()
+	}
+	t.end()
+
+	footer := t.b.Bytes()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, "PAR1"...)
+	return write(footer)
+}
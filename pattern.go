@@ -0,0 +1,97 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes regular patterns in the range [-1, 1] that texture recipes
+distort with Turbulence2D: concentric cylinders around the origin for the
+rings of wood and sine stripes along the X axis for the veins of marble.
+
+	rings := noisey.NewPattern2D(noisey.PatternCylinders, 8.0)
+
+Reference material:
+* Libnoise's cylinders module: http://libnoise.sourceforge.net/docs/classnoise_1_1module_1_1Cylinders.html
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// PatternShape selects the pattern a Pattern2D makes.
+type PatternShape int
+
+const (
+	// PatternCylinders makes concentric rings around the origin that are 1.0
+	// on every ring and -1.0 halfway between them.
+	PatternCylinders PatternShape = iota
+
+	// PatternStripes makes sine stripes that run along the Y axis.
+	PatternStripes
+)
+
+// String returns the name of the shape.
+func (s PatternShape) String() string {
+	switch s {
+	case PatternCylinders:
+		return "PatternCylinders"
+	case PatternStripes:
+		return "PatternStripes"
+	}
+	return fmt.Sprintf("PatternShape(%d)", int(s))
+}
+
+// Pattern2D is a module that returns a regular pattern.
+type Pattern2D struct {
+	Shape     PatternShape // which pattern is made
+	Frequency float64      // the number of rings or stripes per unit length
+}
+
+// NewPattern2D creates a new pattern module.
+func NewPattern2D(shape PatternShape, frequency float64) (pattern Pattern2D) {
+	pattern.Shape = shape
+	pattern.Frequency = frequency
+	return
+}
+
+// Clone2D returns a copy of the module.
+func (pattern *Pattern2D) Clone2D() NoiseyGet2D {
+	c := *pattern
+	return &c
+}
+
+// SetFrequency validates and changes the frequency.
+func (pattern *Pattern2D) SetFrequency(frequency float64) error {
+	if err := validateParam(GenPattern2D, "Frequency", frequency); err != nil {
+		return err
+	}
+	pattern.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the module.
+func (pattern *Pattern2D) String() string {
+	return fmt.Sprintf("Pattern2D{Shape: %v, Frequency: %v}", pattern.Shape, pattern.Frequency)
+}
+
+// Get2D calculates the pattern value at the coordinate.
+func (pattern *Pattern2D) Get2D(x float64, y float64) float64 {
+	x *= pattern.Frequency
+	y *= pattern.Frequency
+	switch pattern.Shape {
+	case PatternStripes:
+		return math.Sin(x * 2.0 * math.Pi)
+	default:
+		d := math.Sqrt(x*x + y*y)
+		f := d - math.Floor(d)
+		return 1.0 - 4.0*math.Min(f, 1.0-f)
+	}
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (pattern *Pattern2D) Range2D() (min float64, max float64) {
+	return -1.0, 1.0
+}
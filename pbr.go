@@ -0,0 +1,320 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module bakes a set of physically based rendering maps from one height
+pipeline in a single call, so that every map of the material has the same
+resolution and the same tiling:
+
+	baker := noisey.NewMaterialBaker(&fbm, gradient, 512)
+	baker.Tileable, baker.TileBlend = true, 32
+	material, err := baker.Bake()
+	err = material.WritePNG(file, noisey.MaterialNormal)
+
+The Source is built once over Bounds and every map is derived from that
+height map:
+
+* Albedo colors the heights with the Gradient.
+* Normal is a tangent space normal map with X in red, Y in green and Z in
+  blue. Green points towards increasing map Y, which is down the image;
+  FlipY makes it point up the image for the other common convention.
+* Roughness [0, 1] starts at RoughnessBase, gets RoughnessCurvature lower on
+  convex edges and higher in cavities, and RoughnessNoise times the optional
+  RoughnessSource noise added.
+* Height is the height map normalized to [0, 1]; the original range is kept
+  in HeightMin and HeightMax.
+* AO [0, 1] is the ambient occlusion from the horizon angles of 8 directions
+  out to AORadius grid points, where 1.0 is unoccluded.
+
+HeightScale is the world height of one unit of the Source's values and sets
+how steep the slopes are for the normals and the occlusion.
+
+When Tileable is set the neighbours of the points on the edges wrap around,
+so the normals, curvature and occlusion tile as well as the heights do. The
+Source should be periodic over Bounds in that case, or TileBlend can be set
+to make the height and roughness maps tileable with MakeTileable() first.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// MaterialChannel selects one of the maps of a MaterialMaps.
+type MaterialChannel int
+
+const (
+	// MaterialAlbedo is the colored height map.
+	MaterialAlbedo MaterialChannel = iota
+
+	// MaterialNormal is the tangent space normal map.
+	MaterialNormal
+
+	// MaterialRoughness is the roughness map.
+	MaterialRoughness
+
+	// MaterialHeight is the normalized height map.
+	MaterialHeight
+
+	// MaterialAO is the ambient occlusion map.
+	MaterialAO
+)
+
+// String returns the name of the channel.
+func (ch MaterialChannel) String() string {
+	switch ch {
+	case MaterialAlbedo:
+		return "albedo"
+	case MaterialNormal:
+		return "normal"
+	case MaterialRoughness:
+		return "roughness"
+	case MaterialHeight:
+		return "height"
+	case MaterialAO:
+		return "ao"
+	}
+	return fmt.Sprintf("MaterialChannel(%d)", int(ch))
+}
+
+// MaterialMaps is the output of MaterialBaker.Bake(). All of the maps have
+// the same size and the scalar maps have the Bounds of the baker.
+type MaterialMaps struct {
+	Albedo    *image.NRGBA
+	Normal    *image.NRGBA
+	Roughness NoiseMap
+	Height    NoiseMap
+	AO        NoiseMap
+	HeightMin float64 // the lowest value of the height map before it was normalized
+	HeightMax float64 // the highest value of the height map before it was normalized
+}
+
+// MaterialBaker contains the parameters for baking a material.
+type MaterialBaker struct {
+	Source             NoiseyGet2D     // the height pipeline
+	Gradient           ColorGradient   // the colors of the heights for the albedo
+	Width              int             // the number of grid points along X
+	Height             int             // the number of grid points along Y
+	Bounds             Builder2DBounds // the area of the Source to bake
+	HeightScale        float64         // the world height of one unit of the Source's values
+	Tileable           bool            // wrap the neighbours around the edges
+	TileBlend          int             // if above 0 and Tileable, the blend width given to MakeTileable()
+	FlipY              bool            // make the green channel of the normals point up the image
+	RoughnessSource    NoiseyGet2D     // the optional noise in [-1, 1] added to the roughness
+	RoughnessBase      float64         // the roughness of flat areas
+	RoughnessCurvature float64         // how much the curvature changes the roughness
+	RoughnessNoise     float64         // how much RoughnessSource changes the roughness
+	AORadius           int             // the number of grid points searched for the horizon
+	AOStrength         float64         // the scale of the occlusion [0, 1]
+}
+
+// NewMaterialBaker creates a new baker for size x size maps over the unit
+// square with default roughness and occlusion settings.
+func NewMaterialBaker(src NoiseyGet2D, gradient ColorGradient, size int) (mb MaterialBaker) {
+	mb.Source = src
+	mb.Gradient = gradient
+	mb.Width = size
+	mb.Height = size
+	mb.Bounds = Builder2DBounds{0.0, 0.0, 1.0, 1.0}
+	mb.HeightScale = 0.1
+	mb.RoughnessBase = 0.6
+	mb.RoughnessCurvature = 0.3
+	mb.RoughnessNoise = 0.1
+	mb.AORadius = 8
+	mb.AOStrength = 1.0
+	return
+}
+
+// String returns a description of the baker and its sources.
+func (mb *MaterialBaker) String() string {
+	return fmt.Sprintf("MaterialBaker{Width: %v, Height: %v, Bounds: %v, HeightScale: %v, Tileable: %v, TileBlend: %v, FlipY: %v, RoughnessBase: %v, RoughnessCurvature: %v, RoughnessNoise: %v, AORadius: %v, AOStrength: %v, Source: %s, RoughnessSource: %s}",
+		mb.Width, mb.Height, mb.Bounds, mb.HeightScale, mb.Tileable, mb.TileBlend, mb.FlipY, mb.RoughnessBase, mb.RoughnessCurvature,
+		mb.RoughnessNoise, mb.AORadius, mb.AOStrength, describeModule(mb.Source), describeModule(mb.RoughnessSource))
+}
+
+// buildMap builds src over the Bounds of the baker and makes it tileable if TileBlend is set.
+func (mb *MaterialBaker) buildMap(src NoiseyGet2D) (NoiseMap, error) {
+	builder := NewBuilder2D(src, mb.Width, mb.Height)
+	builder.Bounds = mb.Bounds
+	if err := builder.Build(); err != nil {
+		return NoiseMap{}, err
+	}
+	nm := builder.GetNoiseMap()
+	if mb.Tileable && mb.TileBlend > 0 {
+		return MakeTileable(&nm, TileOffset, mb.TileBlend)
+	}
+	return nm, nil
+}
+
+// neighbour returns the index of the grid point offset by (dx, dy) from (x, y),
+// wrapping around the edges if the baker is tileable and clamping otherwise.
+func (mb *MaterialBaker) neighbour(x int, y int, dx int, dy int) int {
+	w, h := mb.Width, mb.Height
+	x, y = x+dx, y+dy
+	if mb.Tileable {
+		x, y = (x%w+w)%w, (y%h+h)%h
+	} else {
+		x, y = maxInt(0, minInt(w-1, x)), maxInt(0, minInt(h-1, y))
+	}
+	return y*w + x
+}
+
+// Bake builds the Source and returns all of the maps of the material.
+func (mb *MaterialBaker) Bake() (m MaterialMaps, err error) {
+	if mb.Source == nil {
+		return m, fmt.Errorf("Unable to bake a material without a Source.\n")
+	}
+	if mb.Width < 2 || mb.Height < 2 {
+		return m, fmt.Errorf("Unable to bake a material of size %dx%d.\n", mb.Width, mb.Height)
+	}
+	if len(mb.Gradient.Keys) == 0 {
+		return m, fmt.Errorf("Unable to bake a material with a Gradient that has no keys.\n")
+	}
+	if mb.AORadius < 0 {
+		return m, fmt.Errorf("Unable to bake a material with an AORadius of %d.\n", mb.AORadius)
+	}
+
+	w, h := mb.Width, mb.Height
+	dx := (mb.Bounds.MaxX - mb.Bounds.MinX) / float64(w)
+	dy := (mb.Bounds.MaxY - mb.Bounds.MinY) / float64(h)
+	if dx <= 0.0 || dy <= 0.0 {
+		return m, fmt.Errorf("Unable to bake a material over the Bounds %v.\n", mb.Bounds)
+	}
+
+	heights, err := mb.buildMap(mb.Source)
+	if err != nil {
+		return m, err
+	}
+	var noise NoiseMap
+	if mb.RoughnessSource != nil {
+		if noise, err = mb.buildMap(mb.RoughnessSource); err != nil {
+			return m, err
+		}
+	}
+
+	hv := heights.Values
+	world := func(i int) float64 { return hv[i] * mb.HeightScale }
+
+	m.Albedo = mb.Gradient.Image(&heights)
+	m.Normal = image.NewNRGBA(image.Rect(0, 0, w, h))
+	m.Roughness = NewNoiseMap(w, h, mb.Bounds)
+	m.AO = NewNoiseMap(w, h, mb.Bounds)
+
+	// the normals and the curvature come from central differences of the
+	// world heights and the curvature is the negative laplacian, scaled by
+	// its largest magnitude so that it's in [-1, 1] with convex areas positive
+	curvature := make([]float64, w*h)
+	maxCurvature := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			l, r := world(mb.neighbour(x, y, -1, 0)), world(mb.neighbour(x, y, 1, 0))
+			d, u := world(mb.neighbour(x, y, 0, -1)), world(mb.neighbour(x, y, 0, 1))
+			gx, gy := (r-l)/(2.0*dx), (u-d)/(2.0*dy)
+			n := Vec3f{-gx, -gy, 1.0}
+			n = n.Scale(1.0 / n.Length())
+			if mb.FlipY {
+				n.Y = -n.Y
+			}
+			m.Normal.SetNRGBA(x, y, color.NRGBA{encodeUnit(n.X), encodeUnit(n.Y), encodeUnit(n.Z), 255})
+
+			c := world(i)
+			curvature[i] = -((l+r-2.0*c)/(dx*dx) + (d+u-2.0*c)/(dy*dy))
+			maxCurvature = math.Max(maxCurvature, math.Abs(curvature[i]))
+		}
+	}
+	for i := range curvature {
+		if maxCurvature > 0.0 {
+			curvature[i] /= maxCurvature
+		}
+		r := mb.RoughnessBase - mb.RoughnessCurvature*curvature[i]
+		if len(noise.Values) == len(curvature) {
+			r += mb.RoughnessNoise * noise.Values[i]
+		}
+		m.Roughness.Values[i] = math.Max(0.0, math.Min(1.0, r))
+	}
+
+	// the occlusion is the average sine of the highest horizon angle in
+	// each of 8 directions
+	dirs := [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			c := world(i)
+			occlusion := 0.0
+			for _, dir := range dirs {
+				step := math.Hypot(float64(dir[0])*dx, float64(dir[1])*dy)
+				slope := 0.0
+				for k := 1; k <= mb.AORadius; k++ {
+					if !mb.Tileable && (x+dir[0]*k < 0 || x+dir[0]*k >= w || y+dir[1]*k < 0 || y+dir[1]*k >= h) {
+						break
+					}
+					s := (world(mb.neighbour(x, y, dir[0]*k, dir[1]*k)) - c) / (float64(k) * step)
+					slope = math.Max(slope, s)
+				}
+				occlusion += slope / math.Sqrt(1.0+slope*slope)
+			}
+			m.AO.Values[i] = math.Max(0.0, math.Min(1.0, 1.0-mb.AOStrength*occlusion/float64(len(dirs))))
+		}
+	}
+
+	m.HeightMin, m.HeightMax = heights.GetMinMax()
+	m.Height = heights.Clone()
+	if span := m.HeightMax - m.HeightMin; span > 0.0 {
+		for i, v := range m.Height.Values {
+			m.Height.Values[i] = (v - m.HeightMin) / span
+		}
+	} else {
+		for i := range m.Height.Values {
+			m.Height.Values[i] = 0.0
+		}
+	}
+	return m, nil
+}
+
+// unitImage returns a grayscale image of a map with the values in [0, 1]
+// mapped to [0, 255].
+func unitImage(nm *NoiseMap) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, nm.Width, nm.Height))
+	for y := 0; y < nm.Height; y++ {
+		for x := 0; x < nm.Width; x++ {
+			v := math.Max(0.0, math.Min(1.0, nm.Values[y*nm.Width+x]))
+			img.SetGray(x, y, color.Gray{uint8(math.Floor(v*255.0 + 0.5))})
+		}
+	}
+	return img
+}
+
+// Image returns the image of one of the maps, with the scalar maps as grayscale.
+func (m *MaterialMaps) Image(ch MaterialChannel) (image.Image, error) {
+	switch ch {
+	case MaterialAlbedo:
+		return m.Albedo, nil
+	case MaterialNormal:
+		return m.Normal, nil
+	case MaterialRoughness:
+		return unitImage(&m.Roughness), nil
+	case MaterialHeight:
+		return unitImage(&m.Height), nil
+	case MaterialAO:
+		return unitImage(&m.AO), nil
+	}
+	return nil, fmt.Errorf("Unable to make an image of the unknown material channel %v.\n", ch)
+}
+
+// WritePNG writes the image of one of the maps to w as a PNG.
+func (m *MaterialMaps) WritePNG(w io.Writer, ch MaterialChannel) error {
+	img, err := m.Image(ch)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
@@ -20,11 +20,64 @@ https://github.com/bjz/noise-rs/blob/master/src/perlin.rs
 */
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 )
 
 const (
 	tableSize = 256
+
+	// the number of gradients of the gradient table
+	gradientTableSize = 32
+
+	// The largest magnitudes of the unscaled 2D and 3D sums. Every corner of
+	// a lattice cell draws its gradient from the table independently, so the
+	// bound over all permutation tables is the maximum over the cell of
+	// sum((1 - |d|^2)^2 * (|dx| + |dy| + |dz|)) for the offsets d to the
+	// corners, with the radial falloff of QualityDefault. In 2D it is reached
+	// at the center of a cell; in 3D it lies at (0.5, 0.5, 0.10990) and the
+	// symmetric points of the cell.
+	perlinPeak2D = 1.0
+	perlinPeak3D = 1.056926108237104
+
+	// The same bounds in 1D, at the middle between two lattice points, and in
+	// 4D, which peaks on the faces of a cell where it is the 3D bound.
+	perlinPeak1D = 0.5625
+	perlinPeak4D = perlinPeak3D
+
+	// With interpolation the bound is the largest blend of the magnitudes of
+	// the best gradients for the corners. In 1D and 2D it is the sum over the
+	// axes of the maximum of u + s(u)*(1 - 2u) for the S-curve s, which is 0.5
+	// at the center of a cell for the linear, cubic and quintic curves alike.
+	// In 3D it is reached at the center too, when all eight corners draw one
+	// of the gradients with three nonzero components pointing at it; in 4D it
+	// lies off the center and is largest for the linear curve.
+	perlinInterpolatedPeak1D = 0.5
+	perlinInterpolatedPeak2D = 1.0
+	perlinInterpolatedPeak3D = 1.5
+	perlinInterpolatedPeak4D = 1.5653132397205645
+
+	// The bounds above are over all permutation tables. In 2D every table
+	// comes within a percent of them, but in 1D they need the largest
+	// gradients of opposite signs next to each other, and in 3D and 4D the
+	// gradients of a whole cell to line up, which a table seldom does, so
+	// normalized values that were divided by them would only reach about 0.9
+	// in 1D and 0.7 in 3D with interpolation. Normalized divides the values
+	// of those dimensions by these instead: the average of the largest
+	// magnitudes of a million samples of each of 16 tables. A table reaches
+	// within about a tenth of ±1 with them, and the tips of the rare peaks
+	// that go past are clamped.
+	perlinNormalPeak1D             = 0.5325
+	perlinInterpolatedNormalPeak1D = 0.4733
+	perlinNormalPeak3D             = 1.0017
+	perlinNormalPeak4D             = 0.9829
+	perlinFastNormalPeak3D         = 1.0644
+	perlinFastNormalPeak4D         = 1.0364
+	perlinStandardNormalPeak3D     = 1.0697
+	perlinStandardNormalPeak4D     = 1.0763
+	perlinBestNormalPeak3D         = 1.0910
+	perlinBestNormalPeak4D         = 1.1028
 )
 
 // PerlinGenerator stores the state information for generating perlin noise.
@@ -32,6 +85,8 @@ type PerlinGenerator struct {
 	Rng             RandomSource // random number generator interface
 	Permutations    []int        // the random permutation table
 	RandomGradients []Vec4f      // the random gradient table
+	Quality         NoiseQuality // how the values of the lattice points are blended
+	Normalized      bool         // scales the values to [-1, 1]
 }
 
 // NewPerlinGenerator creates a new state object for the #D perlin noise generator
@@ -39,7 +94,7 @@ func NewPerlinGenerator(rng RandomSource) (pg PerlinGenerator) {
 	pg.Rng = rng
 	pg.Permutations = rng.Perm(tableSize)
 
-	pg.RandomGradients = make([]Vec4f, 32)
+	pg.RandomGradients = make([]Vec4f, gradientTableSize)
 	pg.RandomGradients[1] = Vec4f{0.0, 1.0, 1.0, -1.0}    //  [ zero,  one,   one,  -one],
 	pg.RandomGradients[2] = Vec4f{0.0, 1.0, -1.0, 1.0}    // [ zero,  one,  -one,   one],
 	pg.RandomGradients[3] = Vec4f{0.0, 1.0, -1.0, -1.0}   // [ zero,  one,  -one,  -one],
@@ -75,6 +130,157 @@ func NewPerlinGenerator(rng RandomSource) (pg PerlinGenerator) {
 	return
 }
 
+// NewPerlinGeneratorWithQuality creates a new state object for the perlin
+// noise generator that blends lattice points as specified by quality.
+func NewPerlinGeneratorWithQuality(rng RandomSource, quality NoiseQuality) (pg PerlinGenerator) {
+	pg = NewPerlinGenerator(rng)
+	pg.Quality = quality
+	return
+}
+
+// NewNormalizedPerlinGenerator creates a new state object for the perlin
+// noise generator whose values are scaled to [-1, 1] for the dimension and
+// the quality, like those of NewNormalizedOpenSimplexGenerator, so that
+// thresholds mean the same for both sources.
+func NewNormalizedPerlinGenerator(rng RandomSource, quality NoiseQuality) (pg PerlinGenerator) {
+	pg = NewPerlinGeneratorWithQuality(rng, quality)
+	pg.Normalized = true
+	return
+}
+
+// NewPerlinSeeded creates a new perlin noise generator using Go's built in
+// random number generator seeded with seed. This is the same generator that
+// NoiseJSON.BuildSources() creates when no RandomSeedBuilder is supplied.
+func NewPerlinSeeded(seed int64) PerlinGenerator {
+	return NewPerlinGenerator(rand.New(rand.NewSource(seed)))
+}
+
+// Clone returns a deep copy of the generator. The Rng is shared with the copy.
+func (pg *PerlinGenerator) Clone() *PerlinGenerator {
+	c := *pg
+	c.Permutations = copyInts(pg.Permutations)
+	c.RandomGradients = make([]Vec4f, len(pg.RandomGradients))
+	copy(c.RandomGradients, pg.RandomGradients)
+	return &c
+}
+
+// Clone2D returns a deep copy of the generator as a NoiseyGet2D.
+func (pg *PerlinGenerator) Clone2D() NoiseyGet2D {
+	return pg.Clone()
+}
+
+// Clone3D returns a deep copy of the generator as a NoiseyGet3D.
+func (pg *PerlinGenerator) Clone3D() NoiseyGet3D {
+	return pg.Clone()
+}
+
+// SetQuality validates and changes how lattice points are blended.
+func (pg *PerlinGenerator) SetQuality(quality NoiseQuality) error {
+	if err := validateParam(SourcePerlin, "Quality", float64(quality)); err != nil {
+		return err
+	}
+	pg.Quality = quality
+	return nil
+}
+
+// SetPermutations validates and replaces the permutation table which must
+// contain each of the values 0 to 255 exactly once.
+func (pg *PerlinGenerator) SetPermutations(perm []int) error {
+	if err := validatePermutations(perm, tableSize); err != nil {
+		return err
+	}
+	pg.Permutations = copyInts(perm)
+	return nil
+}
+
+// SetGradients validates and replaces the gradient table which must contain
+// exactly 32 gradients.
+func (pg *PerlinGenerator) SetGradients(gradients []Vec4f) error {
+	if len(gradients) != gradientTableSize {
+		return fmt.Errorf("Gradient table must have %d entries; got %d.\n", gradientTableSize, len(gradients))
+	}
+	pg.RandomGradients = make([]Vec4f, gradientTableSize)
+	copy(pg.RandomGradients, gradients)
+	return nil
+}
+
+// String returns a description of the generator.
+func (pg *PerlinGenerator) String() string {
+	if pg.Normalized {
+		return fmt.Sprintf("PerlinGenerator{Quality: %v, Normalized: true}", pg.Quality)
+	}
+	return fmt.Sprintf("PerlinGenerator{Quality: %v}", pg.Quality)
+}
+
+// Range1D returns the bounds of the values of Get1D().
+func (pg *PerlinGenerator) Range1D() (min float64, max float64) {
+	if pg.Normalized {
+		return -1.0, 1.0
+	}
+	if pg.Quality == QualityDefault {
+		return -perlinPeak1D * 1.8, perlinPeak1D * 1.8
+	}
+	return -1.0, 1.0
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (pg *PerlinGenerator) Range2D() (min float64, max float64) {
+	return pg.rangeOf(perlinPeak2D, perlinInterpolatedPeak2D)
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (pg *PerlinGenerator) Range3D() (min float64, max float64) {
+	return pg.rangeOf(perlinPeak3D, perlinInterpolatedPeak3D)
+}
+
+// Range4D returns the bounds of the values of Get4D().
+func (pg *PerlinGenerator) Range4D() (min float64, max float64) {
+	if pg.Normalized {
+		return -1.0, 1.0
+	}
+	if pg.Quality == QualityDefault {
+		return -perlinPeak4D, perlinPeak4D
+	}
+	return -perlinInterpolatedPeak4D, perlinInterpolatedPeak4D
+}
+
+// rangeOf returns the bounds of the 2D or 3D values with the peaks of the dimension.
+func (pg *PerlinGenerator) rangeOf(peak float64, interpolatedPeak float64) (min float64, max float64) {
+	switch {
+	case pg.Normalized:
+		return -1.0, 1.0
+	case pg.Quality != QualityDefault:
+		return -interpolatedPeak, interpolatedPeak
+	}
+	return (-peak + 0.053179) * 1.056165, (peak + 0.053179) * 1.056165
+}
+
+// normalPeak3D returns what Normalized divides the 3D values by.
+func (pg *PerlinGenerator) normalPeak3D() float64 {
+	switch pg.Quality {
+	case QualityDefault:
+		return perlinNormalPeak3D
+	case QualityStandard:
+		return perlinStandardNormalPeak3D
+	case QualityBest:
+		return perlinBestNormalPeak3D
+	}
+	return perlinFastNormalPeak3D
+}
+
+// normalPeak4D returns what Normalized divides the 4D values by.
+func (pg *PerlinGenerator) normalPeak4D() float64 {
+	switch pg.Quality {
+	case QualityDefault:
+		return perlinNormalPeak4D
+	case QualityStandard:
+		return perlinStandardNormalPeak4D
+	case QualityBest:
+		return perlinBestNormalPeak4D
+	}
+	return perlinFastNormalPeak4D
+}
+
 func (pg *PerlinGenerator) getGradient2(whole Vec2i) Vec2f {
 	x := whole.X & 0xFF
 	xv := pg.Permutations[x]
@@ -82,7 +288,7 @@ func (pg *PerlinGenerator) getGradient2(whole Vec2i) Vec2f {
 	y := whole.Y & 0xFF
 	yv := pg.Permutations[xv^y]
 
-	i := yv % 32
+	i := yv % gradientTableSize
 	return Vec2f{pg.RandomGradients[i].X, pg.RandomGradients[i].Y}
 }
 
@@ -96,10 +302,22 @@ func (pg *PerlinGenerator) getGradient3(whole Vec3i) Vec3f {
 	z := whole.Z & 0xFF
 	zv := pg.Permutations[yv^z]
 
-	i := zv % 32
+	i := zv % gradientTableSize
 	return Vec3f{pg.RandomGradients[i].X, pg.RandomGradients[i].Y, pg.RandomGradients[i].Z}
 }
 
+func (pg *PerlinGenerator) getGradient4(x, y, z, w int) Vec4f {
+	xv := pg.Permutations[x&0xFF]
+	yv := pg.Permutations[xv^(y&0xFF)]
+	zv := pg.Permutations[yv^(z&0xFF)]
+	wv := pg.Permutations[zv^(w&0xFF)]
+	return pg.RandomGradients[wv%gradientTableSize]
+}
+
+func vec4fDot(a, b Vec4f) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z + a.W*b.W
+}
+
 func vec3fDot(a, b Vec3f) float64 {
 	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
 }
@@ -110,6 +328,13 @@ func vec2fDot(a, b Vec2f) float64 {
 
 // Get3D calculates the perlin noise at a given 3D coordinate
 func (pg *PerlinGenerator) Get3D(x, y, z float64) float64 {
+	if pg.Quality != QualityDefault {
+		if pg.Normalized {
+			return normalizeRange(pg.getInterpolated3D(x, y, z), pg.normalPeak3D())
+		}
+		return pg.getInterpolated3D(x, y, z)
+	}
+
 	gradient3 := func(whole Vec3i, frac Vec3f) float64 {
 		attn := 1.0 - vec3fDot(frac, frac)
 		if attn > 0.0 {
@@ -134,12 +359,119 @@ func (pg *PerlinGenerator) Get3D(x, y, z float64) float64 {
 	f011 := gradient3(Vec3i{whole0.X, whole1.Y, whole1.Z}, Vec3f{frac0.X, frac1.Y, frac1.Z})
 	f111 := gradient3(Vec3i{whole1.X, whole1.Y, whole1.Z}, Vec3f{frac1.X, frac1.Y, frac1.Z})
 
+	sum := f000 + f100 + f010 + f110 + f001 + f101 + f011 + f111
+	if pg.Normalized {
+		return normalizeRange(sum, pg.normalPeak3D())
+	}
+
 	// Arbitrary values to shift and scale noise to -1..1
-	return (f000 + f100 + f010 + f110 + f001 + f101 + f011 + f111 + 0.053179) * 1.056165
+	return (sum + 0.053179) * 1.056165
+}
+
+// Get4D calculates the perlin noise at a given 4D coordinate
+func (pg *PerlinGenerator) Get4D(x, y, z, w float64) float64 {
+	floored := Vec4f{math.Floor(x), math.Floor(y), math.Floor(z), math.Floor(w)}
+	frac0 := Vec4f{x - floored.X, y - floored.Y, z - floored.Z, w - floored.W}
+	wx, wy, wz, ww := int(floored.X), int(floored.Y), int(floored.Z), int(floored.W)
+
+	// the 16 corners of the lattice cell are numbered with one bit per axis
+	var f [16]float64
+	for c := range f {
+		frac := frac0
+		cx, cy, cz, cw := wx, wy, wz, ww
+		if c&1 != 0 {
+			cx, frac.X = cx+1, frac.X-1
+		}
+		if c&2 != 0 {
+			cy, frac.Y = cy+1, frac.Y-1
+		}
+		if c&4 != 0 {
+			cz, frac.Z = cz+1, frac.Z-1
+		}
+		if c&8 != 0 {
+			cw, frac.W = cw+1, frac.W-1
+		}
+		f[c] = vec4fDot(frac, pg.getGradient4(cx, cy, cz, cw))
+		if pg.Quality == QualityDefault {
+			attn := 1.0 - vec4fDot(frac, frac)
+			if attn > 0.0 {
+				f[c] *= attn * attn
+			} else {
+				f[c] = 0.0
+			}
+		}
+	}
+
+	if pg.Quality == QualityDefault {
+		sum := 0.0
+		for _, v := range f {
+			sum += v
+		}
+		if pg.Normalized {
+			return normalizeRange(sum, pg.normalPeak4D())
+		}
+		// the sum of the 16 corners already falls in about -1..1
+		return sum
+	}
+
+	// blend the corners along each axis in turn, halving the corners each time
+	n := 16
+	for _, t := range []float64{frac0.X, frac0.Y, frac0.Z, frac0.W} {
+		s := pg.Quality.sCurve(t)
+		n /= 2
+		for i := 0; i < n; i++ {
+			f[i] = lerp(f[2*i], f[2*i+1], s)
+		}
+	}
+	if pg.Normalized {
+		return normalizeRange(f[0], pg.normalPeak4D())
+	}
+	return f[0]
+}
+
+// getGradient1 returns the gradient of a lattice point on a line, which is
+// spread evenly over [-1, 1] by the permutation table.
+func (pg *PerlinGenerator) getGradient1(whole int) float64 {
+	return float64(pg.Permutations[whole&0xFF])/127.5 - 1.0
+}
+
+// Get1D calculates the perlin noise at a given 1D coordinate
+func (pg *PerlinGenerator) Get1D(x float64) float64 {
+	floored := math.Floor(x)
+	whole0 := int(floored)
+	frac0 := x - floored
+	frac1 := frac0 - 1.0
+	f0 := frac0 * pg.getGradient1(whole0)
+	f1 := frac1 * pg.getGradient1(whole0+1)
+
+	if pg.Quality == QualityDefault {
+		attn0 := 1.0 - frac0*frac0
+		attn1 := 1.0 - frac1*frac1
+		sum := attn0*attn0*f0 + attn1*attn1*f1
+		if pg.Normalized {
+			return normalizeRange(sum, perlinNormalPeak1D)
+		}
+		// the two corners peak at about 0.55, so scale it to -1..1
+		return sum * 1.8
+	}
+
+	// the blended corners peak at 0.5 halfway between them
+	v := lerp(f0, f1, pg.Quality.sCurve(frac0))
+	if pg.Normalized {
+		return normalizeRange(v, perlinInterpolatedNormalPeak1D)
+	}
+	return v * 2.0
 }
 
 // Get2D calculates the perlin noise at a given 2D coordinate
 func (pg *PerlinGenerator) Get2D(x, y float64) float64 {
+	if pg.Quality != QualityDefault {
+		if pg.Normalized {
+			return normalizeRange(pg.getInterpolated2D(x, y), perlinInterpolatedPeak2D)
+		}
+		return pg.getInterpolated2D(x, y)
+	}
+
 	gradient2 := func(whole Vec2i, frac Vec2f) float64 {
 		attn := 1.0 - vec2fDot(frac, frac)
 		if attn > 0.0 {
@@ -160,6 +492,58 @@ func (pg *PerlinGenerator) Get2D(x, y float64) float64 {
 	f01 := gradient2(Vec2i{whole0.X, whole1.Y}, Vec2f{frac0.X, frac1.Y})
 	f11 := gradient2(Vec2i{whole1.X, whole1.Y}, Vec2f{frac1.X, frac1.Y})
 
+	sum := f00 + f10 + f01 + f11
+	if pg.Normalized {
+		return normalizeRange(sum, perlinPeak2D)
+	}
+
 	// Arbitrary values to shift and scale noise to -1..1
-	return (f00 + f10 + f01 + f11 + 0.053179) * 1.056165
+	return (sum + 0.053179) * 1.056165
+}
+
+// getInterpolated3D calculates classic gradient noise by interpolating the
+// contribution of each corner of the lattice cell with the S-curve of Quality.
+func (pg *PerlinGenerator) getInterpolated3D(x, y, z float64) float64 {
+	floored := Vec3f{math.Floor(x), math.Floor(y), math.Floor(z)}
+	whole0 := Vec3i{int(floored.X), int(floored.Y), int(floored.Z)}
+	whole1 := Vec3i{whole0.X + 1, whole0.Y + 1, whole0.Z + 1}
+	frac0 := Vec3f{x - floored.X, y - floored.Y, z - floored.Z}
+	frac1 := Vec3f{frac0.X - 1, frac0.Y - 1, frac0.Z - 1}
+
+	f000 := vec3fDot(frac0, pg.getGradient3(whole0))
+	f100 := vec3fDot(Vec3f{frac1.X, frac0.Y, frac0.Z}, pg.getGradient3(Vec3i{whole1.X, whole0.Y, whole0.Z}))
+	f010 := vec3fDot(Vec3f{frac0.X, frac1.Y, frac0.Z}, pg.getGradient3(Vec3i{whole0.X, whole1.Y, whole0.Z}))
+	f110 := vec3fDot(Vec3f{frac1.X, frac1.Y, frac0.Z}, pg.getGradient3(Vec3i{whole1.X, whole1.Y, whole0.Z}))
+	f001 := vec3fDot(Vec3f{frac0.X, frac0.Y, frac1.Z}, pg.getGradient3(Vec3i{whole0.X, whole0.Y, whole1.Z}))
+	f101 := vec3fDot(Vec3f{frac1.X, frac0.Y, frac1.Z}, pg.getGradient3(Vec3i{whole1.X, whole0.Y, whole1.Z}))
+	f011 := vec3fDot(Vec3f{frac0.X, frac1.Y, frac1.Z}, pg.getGradient3(Vec3i{whole0.X, whole1.Y, whole1.Z}))
+	f111 := vec3fDot(frac1, pg.getGradient3(whole1))
+
+	sx := pg.Quality.sCurve(frac0.X)
+	sy := pg.Quality.sCurve(frac0.Y)
+	sz := pg.Quality.sCurve(frac0.Z)
+
+	y0 := lerp(lerp(f000, f100, sx), lerp(f010, f110, sx), sy)
+	y1 := lerp(lerp(f001, f101, sx), lerp(f011, f111, sx), sy)
+	return lerp(y0, y1, sz)
+}
+
+// getInterpolated2D calculates classic gradient noise by interpolating the
+// contribution of each corner of the lattice cell with the S-curve of Quality.
+func (pg *PerlinGenerator) getInterpolated2D(x, y float64) float64 {
+	floored := Vec2f{math.Floor(x), math.Floor(y)}
+	whole0 := Vec2i{int(floored.X), int(floored.Y)}
+	whole1 := Vec2i{whole0.X + 1, whole0.Y + 1}
+	frac0 := Vec2f{x - floored.X, y - floored.Y}
+	frac1 := Vec2f{frac0.X - 1, frac0.Y - 1}
+
+	f00 := vec2fDot(frac0, pg.getGradient2(whole0))
+	f10 := vec2fDot(Vec2f{frac1.X, frac0.Y}, pg.getGradient2(Vec2i{whole1.X, whole0.Y}))
+	f01 := vec2fDot(Vec2f{frac0.X, frac1.Y}, pg.getGradient2(Vec2i{whole0.X, whole1.Y}))
+	f11 := vec2fDot(frac1, pg.getGradient2(whole1))
+
+	sx := pg.Quality.sCurve(frac0.X)
+	sy := pg.Quality.sCurve(frac0.Y)
+
+	return lerp(lerp(f00, f10, sx), lerp(f01, f11, sx), sy)
 }
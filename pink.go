@@ -0,0 +1,192 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains pink noise, whose power falls off as 1/f with the
+frequency f so that every octave carries the same energy. It sounds and
+looks more natural than white noise and, unlike fBm which only sums a few
+octaves of smooth noise, it has that spectrum at every frequency down to
+single samples.
+
+PinkNoise1D is a 1D source using the Voss-McCartney algorithm: a white
+value per sample plus Rows random values that each hold for twice as long
+as the one before, with the updates staggered so that only one of them
+changes per sample. The values are picked by hashing their position, so
+any sample can be read without stepping through the ones before it:
+
+	pink := noisey.NewPinkNoise1D(rng, 16)
+	stream := audio.NewStream(&pink, 44100)
+	stream.Frequency = 44100.0 // one sample of pink noise a sample
+
+Integer coordinates are the samples and coordinates in between blend the
+two samples around them linearly.
+
+For maps, ShapeSpectrum() filters a NoiseMap in the frequency domain so
+that its power falls off as 1/f^exponent: 1 for pink noise, 2 for brown
+noise and 0 to keep it as it is. NewPinkNoiseMap() shapes white noise this
+way, which makes a map that also tiles seamlessly.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// PinkNoise1D stores the state for generating 1D pink noise.
+type PinkNoise1D struct {
+	Seed uint64 // the hash seed picking the random values
+	Rows int    // the number of held random values; each one adds an octave
+}
+
+// NewPinkNoise1D creates a new pink noise source with the number of rows,
+// which is the number of octaves below the sample rate that are pink. 16 is
+// a good default. The seed is taken from rng.
+func NewPinkNoise1D(rng RandomSource, rows int) (pn PinkNoise1D) {
+	hi := uint64(rng.Float64() * (1 << 32))
+	lo := uint64(rng.Float64() * (1 << 32))
+	pn.Seed = hi<<32 | lo
+	pn.Rows = rows
+	return
+}
+
+// String returns a description of the source.
+func (pn *PinkNoise1D) String() string {
+	return fmt.Sprintf("PinkNoise1D{Rows: %v}", pn.Rows)
+}
+
+// white returns the random value in [-1, 1) of a row at a block.
+func (pn *PinkNoise1D) white(row int, block int) float64 {
+	state := pn.Seed ^ uint64(row)*0xd1b54a32d192ed03
+	state += uint64(block) * 0xaef17502108ef2d9
+	return uint64ToFloat64(splitMix64(&state))*2.0 - 1.0
+}
+
+// sample returns the pink noise of a sample.
+func (pn *PinkNoise1D) sample(n int) float64 {
+	rows := pn.Rows
+	if rows < 0 {
+		rows = 0
+	} else if rows > 62 {
+		rows = 62
+	}
+	sum := pn.white(0, n)
+	for k := 0; k < rows; k++ {
+		// row k changes every 2^(k+1) samples, at the samples whose
+		// lowest set bit is bit k
+		sum += pn.white(k+1, (n+(1<<uint(k)))>>uint(k+1))
+	}
+	// the sum has a standard deviation of sqrt((rows+1)/3), which is
+	// scaled to 1/3 so that the values fall in about -1..1
+	return sum / math.Sqrt(3.0*float64(rows+1))
+}
+
+// Get1D calculates the pink noise at a given 1D coordinate
+func (pn *PinkNoise1D) Get1D(x float64) float64 {
+	floored := math.Floor(x)
+	n := int(floored)
+	t := x - floored
+	if t == 0.0 {
+		return pn.sample(n)
+	}
+	return lerp(pn.sample(n), pn.sample(n+1), t)
+}
+
+// Range1D returns the bounds of the values of Get1D(), where every row and
+// the white noise are at the same extreme.
+func (pn *PinkNoise1D) Range1D() (min float64, max float64) {
+	rows := pn.Rows
+	if rows < 0 {
+		rows = 0
+	} else if rows > 62 {
+		rows = 62
+	}
+	peak := math.Sqrt(float64(rows+1) / 3.0)
+	return -peak, peak
+}
+
+// ShapeSpectrum filters the map so that its power falls off as
+// 1/f^exponent with the frequency, keeping the mean and the standard
+// deviation of its values. The map is treated as if it repeats, so the
+// result tiles seamlessly. A map whose values are all the same is left as
+// it is.
+func (nm *NoiseMap) ShapeSpectrum(exponent float64) error {
+	if nm.Width <= 0 || nm.Height <= 0 || len(nm.Values) < nm.Width*nm.Height {
+		return fmt.Errorf("Unable to shape the spectrum of a %dx%d map with %d values.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	n := nm.Width * nm.Height
+	mean, deviation := meanDeviation(nm.Values[:n])
+	if deviation == 0.0 {
+		return nil
+	}
+
+	data := make([]complex128, n)
+	for i, v := range nm.Values[:n] {
+		data[i] = complex(v-mean, 0.0)
+	}
+	fft2D(data, nm.Width, nm.Height, false)
+	for y := 0; y < nm.Height; y++ {
+		fy := fftFrequency(y, nm.Height) / float64(nm.Height)
+		for x := 0; x < nm.Width; x++ {
+			fx := fftFrequency(x, nm.Width) / float64(nm.Width)
+			f := math.Hypot(fx, fy)
+			if f == 0.0 {
+				data[y*nm.Width+x] = 0
+				continue
+			}
+			// the amplitude is the square root of the power
+			data[y*nm.Width+x] *= complex(math.Pow(f, -exponent*0.5), 0.0)
+		}
+	}
+	fft2D(data, nm.Width, nm.Height, true)
+
+	shaped := make([]float64, n)
+	for i, c := range data {
+		shaped[i] = real(c)
+	}
+	_, shapedDeviation := meanDeviation(shaped)
+	scale := 0.0
+	if shapedDeviation > 0.0 {
+		scale = deviation / shapedDeviation
+	}
+	for i, v := range shaped {
+		nm.Values[i] = mean + v*scale
+	}
+	return nil
+}
+
+// meanDeviation returns the mean and the standard deviation of the values.
+func meanDeviation(values []float64) (mean float64, deviation float64) {
+	if len(values) == 0 {
+		return 0.0, 0.0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		deviation += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(deviation / float64(len(values)))
+}
+
+// NewPinkNoiseMap creates a width x height map covering bounds of white
+// noise from rng shaped to a power of 1/f^exponent and normalized to
+// [-1, 1]. An exponent of 1 makes pink noise and 2 brown noise.
+func NewPinkNoiseMap(width int, height int, bounds Builder2DBounds, rng RandomSource, exponent float64) (NoiseMap, error) {
+	if width <= 0 || height <= 0 {
+		return NoiseMap{}, fmt.Errorf("Unable to make a pink noise map of size %dx%d.\n", width, height)
+	}
+	nm := NewNoiseMap(width, height, bounds)
+	for i := range nm.Values {
+		nm.Values[i] = rng.Float64()*2.0 - 1.0
+	}
+	if err := nm.ShapeSpectrum(exponent); err != nil {
+		return NoiseMap{}, err
+	}
+	nm.Normalize(-1.0, 1.0)
+	return nm, nil
+}
@@ -0,0 +1,148 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module scatters points over a region with Poisson disk sampling, where
+no two points are closer together than a minimum radius. The radius changes
+across the region with a density source: where the density is 1.0 points are
+MinRadius apart and where it is -1.0 they are MaxRadius apart, so trees and
+rocks can thin out on slopes and mountaintops.
+
+	ps := noisey.NewPoissonDisk(rng, 2.0, 10.0, &treeDensity)
+	points, err := ps.Sample(noisey.Builder2DBounds{0.0, 0.0, 256.0, 256.0})
+
+Two points p and q are kept at least max(radius(p), radius(q)) apart. The
+points are generated with Bridson's algorithm which grows the set outward
+from a random first point by trying Attempts random positions around each
+point before giving up on it.
+
+Reference material:
+* Robert Bridson's "Fast Poisson Disk Sampling in Arbitrary Dimensions"
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// PoissonDisk contains the parameters for density driven Poisson disk sampling.
+type PoissonDisk struct {
+	Rng       RandomSource // random number generator interface
+	MinRadius float64      // the distance between points where the density is 1.0
+	MaxRadius float64      // the distance between points where the density is -1.0
+	Density   NoiseyGet2D  // the density in [-1, 1]; nil uses MinRadius everywhere
+	Attempts  int          // the number of positions tried around each point
+}
+
+// NewPoissonDisk creates a new Poisson disk sampler that tries 30 positions around each point.
+func NewPoissonDisk(rng RandomSource, minRadius float64, maxRadius float64, density NoiseyGet2D) (ps PoissonDisk) {
+	ps.Rng = rng
+	ps.MinRadius = minRadius
+	ps.MaxRadius = maxRadius
+	ps.Density = density
+	ps.Attempts = 30
+	return
+}
+
+// String returns a description of the sampler and its density source.
+func (ps *PoissonDisk) String() string {
+	return fmt.Sprintf("PoissonDisk{MinRadius: %v, MaxRadius: %v, Attempts: %v, Density: %s}",
+		ps.MinRadius, ps.MaxRadius, ps.Attempts, describeModule(ps.Density))
+}
+
+// Radius returns the minimum distance to other points at the coordinate.
+func (ps *PoissonDisk) Radius(x float64, y float64) float64 {
+	if ps.Density == nil {
+		return ps.MinRadius
+	}
+	t := (ps.Density.Get2D(x, y) + 1.0) * 0.5
+	t = math.Max(0.0, math.Min(1.0, t))
+	return lerp(ps.MaxRadius, ps.MinRadius, t)
+}
+
+// Sample returns the points scattered over the bounds.
+func (ps *PoissonDisk) Sample(bounds Builder2DBounds) ([]Vec2f, error) {
+	if ps.Rng == nil {
+		return nil, fmt.Errorf("Unable to sample points without a random number generator.\n")
+	}
+	if !(ps.MinRadius > 0.0) || ps.MaxRadius < ps.MinRadius {
+		return nil, fmt.Errorf("Unable to sample points with MinRadius %v and MaxRadius %v; they must be positive and in increasing order.\n", ps.MinRadius, ps.MaxRadius)
+	}
+	w, h := bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY
+	if !(w > 0.0) || !(h > 0.0) {
+		return nil, fmt.Errorf("Unable to sample points in empty bounds %v.\n", bounds)
+	}
+
+	// each grid cell is small enough to hold at most one point
+	cell := ps.MinRadius / math.Sqrt2
+	gw, gh := int(math.Ceil(w/cell)), int(math.Ceil(h/cell))
+	grid := make([]int, gw*gh)
+	for i := range grid {
+		grid[i] = -1
+	}
+	reach := int(math.Ceil(ps.MaxRadius / cell))
+
+	var points []Vec2f
+	var radii []float64
+	var active []int
+	add := func(p Vec2f, r float64) {
+		gx := minInt(int((p.X-bounds.MinX)/cell), gw-1)
+		gy := minInt(int((p.Y-bounds.MinY)/cell), gh-1)
+		grid[gy*gw+gx] = len(points)
+		active = append(active, len(points))
+		points = append(points, p)
+		radii = append(radii, r)
+	}
+	// fits returns true if no point is too close to p
+	fits := func(p Vec2f, r float64) bool {
+		gx := int((p.X - bounds.MinX) / cell)
+		gy := int((p.Y - bounds.MinY) / cell)
+		for y := maxInt(0, gy-reach); y <= minInt(gh-1, gy+reach); y++ {
+			for x := maxInt(0, gx-reach); x <= minInt(gw-1, gx+reach); x++ {
+				i := grid[y*gw+x]
+				if i < 0 {
+					continue
+				}
+				d := points[i].Sub(p)
+				limit := math.Max(r, radii[i])
+				if d.Dot(d) < limit*limit {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	first := Vec2f{bounds.MinX + ps.Rng.Float64()*w, bounds.MinY + ps.Rng.Float64()*h}
+	add(first, ps.Radius(first.X, first.Y))
+	for len(active) > 0 {
+		a := int(ps.Rng.Float64() * float64(len(active)))
+		p, r := points[active[a]], radii[active[a]]
+
+		placed := false
+		for attempt := 0; attempt < ps.Attempts; attempt++ {
+			// a random position in the annulus between r and 2r around p
+			angle := ps.Rng.Float64() * 2.0 * math.Pi
+			dist := r * (1.0 + ps.Rng.Float64())
+			q := Vec2f{p.X + math.Cos(angle)*dist, p.Y + math.Sin(angle)*dist}
+			if q.X < bounds.MinX || q.X >= bounds.MaxX || q.Y < bounds.MinY || q.Y >= bounds.MaxY {
+				continue
+			}
+			qr := ps.Radius(q.X, q.Y)
+			if fits(q, qr) {
+				add(q, qr)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			active[a] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,100 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module samples its source in polar coordinates around a center, which
+turns stripes into rings and spokes and noise into portals, tree rings and
+craters:
+
+	rings := noisey.NewPolar2D(&fbm)
+	rings.CenterX, rings.CenterY = 0.5, 0.5
+	rings.RadiusScale, rings.AngleScale = 8.0, 2.0
+
+The source's X is the distance from the center times RadiusScale and its Y
+is the angle around the center, measured as a fraction of a turn from +X
+towards +Y, times AngleScale. So one turn around the center covers
+AngleScale units of the source.
+
+A source doesn't usually repeat after AngleScale units, which would leave a
+seam along the +X axis where the angle wraps around. To hide it the module
+blends the source at the angle with the source one turn earlier, weighted by
+how far around the turn the angle is, so both sides of the wrap are sampled
+from the same place. The blend lowers the contrast a little half way around.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Polar2D is a module that samples its source at the radius and angle of
+// the coordinate around a center.
+type Polar2D struct {
+	Source      NoiseyGet2D // the module that is sampled at (radius, angle)
+	CenterX     float64     // the X of the center
+	CenterY     float64     // the Y of the center
+	RadiusScale float64     // the source units per world unit of radius
+	AngleScale  float64     // the source units per turn around the center
+}
+
+// NewPolar2D creates a new polar module centered on the origin where the
+// radius is unscaled and a turn covers one unit of the source.
+func NewPolar2D(src NoiseyGet2D) (polar Polar2D) {
+	polar.Source = src
+	polar.RadiusScale = 1.0
+	polar.AngleScale = 1.0
+	return
+}
+
+// Clone2D returns a deep copy of the module and its source.
+func (polar *Polar2D) Clone2D() NoiseyGet2D {
+	c := *polar
+	c.Source = DeepCopy2D(polar.Source)
+	return &c
+}
+
+// SetAngleScale validates and changes the source units per turn.
+func (polar *Polar2D) SetAngleScale(angleScale float64) error {
+	if err := validateParam(GenPolar2D, "AngleScale", angleScale); err != nil {
+		return err
+	}
+	polar.AngleScale = angleScale
+	return nil
+}
+
+// String returns a description of the module and its source.
+func (polar *Polar2D) String() string {
+	return fmt.Sprintf("Polar2D{CenterX: %v, CenterY: %v, RadiusScale: %v, AngleScale: %v, Source: %s}",
+		polar.CenterX, polar.CenterY, polar.RadiusScale, polar.AngleScale, describeModule(polar.Source))
+}
+
+// Polar returns the radius and the angle in turns [0, 1) of the coordinate
+// around the center.
+func (polar *Polar2D) Polar(x float64, y float64) (float64, float64) {
+	dx, dy := x-polar.CenterX, y-polar.CenterY
+	a := math.Atan2(dy, dx) / (2.0 * math.Pi)
+	if a < 0.0 {
+		a += 1.0
+	}
+	return math.Hypot(dx, dy), a
+}
+
+// Get2D calculates the value of the source at the radius and angle of the
+// coordinate.
+func (polar *Polar2D) Get2D(x float64, y float64) float64 {
+	r, a := polar.Polar(x, y)
+	r *= polar.RadiusScale
+	u := a * polar.AngleScale
+	v0 := polar.Source.Get2D(r, u)
+	v1 := polar.Source.Get2D(r, u-polar.AngleScale)
+	return lerp(v0, v1, a)
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are those of Source.
+func (polar *Polar2D) Range2D() (min float64, max float64) {
+	return ValueRange2D(polar.Source)
+}
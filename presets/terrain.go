@@ -0,0 +1,176 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package presets contains ready-made noisey pipelines that can be created by
+name, used as a starting point for new pipelines or exported to JSON.
+
+Terrain presets are defined as noisey.NoiseJSON configurations so they can be
+saved with SaveNoiseJSON(), edited and loaded back with LoadNoiseJSON(). The
+final generator of each terrain preset is named by TerrainOutput and covers
+roughly the range [-1, 1] where 0 can be used as sea level.
+
+	terrain, err := presets.NewTerrain("alpine-ridges", 1)
+	if err != nil {
+		panic(err)
+	}
+	builder := noisey.NewBuilder2D(terrain, 512, 512)
+	builder.Bounds = noisey.Builder2DBounds{0.0, 0.0, 4.0, 4.0}
+	builder.Build()
+
+Texture presets are made the same way and also come with a suggested color
+gradient; see NewTexture().
+*/
+package presets
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tbogdala/noisey"
+)
+
+// TerrainOutput is the name of the final generator in every terrain preset.
+const TerrainOutput = "terrain"
+
+// terrainPreset describes a terrain preset and how to make its configuration.
+type terrainPreset struct {
+	description string
+	config      func(cfg *noisey.NoiseJSON)
+}
+
+// newPresetConfig creates a configuration with the "Default" and "Detail"
+// seeds and an opensimplex and perlin source using each of them.
+func newPresetConfig(seed int64) *noisey.NoiseJSON {
+	cfg := noisey.NewNoiseJSON()
+	cfg.Seeds["Default"] = seed
+	cfg.Seeds["Detail"] = seed + 1
+	cfg.Sources["simplex"] = noisey.SourceJSON{SourceType: noisey.SourceOpenSimplex, Seed: "Default"}
+	cfg.Sources["perlin"] = noisey.SourceJSON{SourceType: noisey.SourcePerlin, Seed: "Detail", Quality: noisey.QualityBest}
+	return cfg
+}
+
+var terrainPresets = map[string]terrainPreset{
+	"rolling-hills": {
+		description: "gentle low frequency hills",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "hills", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 4, Persistence: 0.4, Lacunarity: 2.0, Frequency: 0.5},
+				{Name: TerrainOutput, GeneratorType: noisey.GenScale2D, Generators: []string{"hills"},
+					Scale: 0.5, Bias: 0.15, Min: -1.0, Max: 1.0},
+			}
+		},
+	},
+	"alpine-ridges": {
+		description: "sharp mountain ridges rising out of rolling foothills",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "ridges", GeneratorType: noisey.GenRidged2D, Sources: []string{"perlin"},
+					Octaves: 6, Lacunarity: 2.1, Frequency: 0.8, Offset: 1.0, Gain: 2.0, Exponent: 1.0},
+				{Name: "mountains", GeneratorType: noisey.GenScale2D, Generators: []string{"ridges"},
+					Scale: 0.6, Bias: 0.2, Min: -1.0, Max: 1.0},
+				{Name: "foothills", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 4, Persistence: 0.45, Lacunarity: 2.0, Frequency: 0.6},
+				{Name: "lowlands", GeneratorType: noisey.GenScale2D, Generators: []string{"foothills"},
+					Scale: 0.3, Bias: -0.1, Min: -1.0, Max: 1.0},
+				{Name: "mountaincontrol", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 2, Persistence: 0.5, Lacunarity: 2.0, Frequency: 0.25},
+				{Name: TerrainOutput, GeneratorType: noisey.GenSelect2D, Generators: []string{"lowlands", "mountains", "mountaincontrol"},
+					LowerBound: 0.0, UpperBound: 100.0, EdgeFalloff: 0.25},
+			}
+		},
+	},
+	"badlands": {
+		description: "flat topped mesas cut by sharp canyons",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "mesarough", GeneratorType: noisey.GenFBM2D, Sources: []string{"perlin"},
+					Octaves: 3, Persistence: 0.3, Lacunarity: 2.2, Frequency: 2.0},
+				{Name: "mesas", GeneratorType: noisey.GenScale2D, Generators: []string{"mesarough"},
+					Scale: 0.05, Bias: 0.5, Min: -1.0, Max: 1.0},
+				{Name: "canyonridges", GeneratorType: noisey.GenRidged2D, Sources: []string{"simplex"},
+					Octaves: 4, Lacunarity: 2.0, Frequency: 1.2, Offset: 1.0, Gain: 1.5, Exponent: 0.9},
+				{Name: "canyons", GeneratorType: noisey.GenScale2D, Generators: []string{"canyonridges"},
+					Scale: -0.4, Bias: -0.1, Min: -1.0, Max: 1.0},
+				{Name: "mesacontrol", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 3, Persistence: 0.5, Lacunarity: 2.0, Frequency: 0.7},
+				{Name: TerrainOutput, GeneratorType: noisey.GenSelect2D, Generators: []string{"canyons", "mesas", "mesacontrol"},
+					LowerBound: -0.1, UpperBound: 100.0, EdgeFalloff: 0.04},
+			}
+		},
+	},
+	"archipelago": {
+		description: "scattered islands in a mostly submerged landscape",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "islands", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 6, Persistence: 0.5, Lacunarity: 2.0, Frequency: 0.9},
+				{Name: TerrainOutput, GeneratorType: noisey.GenScale2D, Generators: []string{"islands"},
+					Scale: 1.2, Bias: -0.35, Min: -1.0, Max: 1.0},
+			}
+		},
+	},
+	"dunes": {
+		description: "rounded sand dunes with soft crests",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "crests", GeneratorType: noisey.GenRidged2D, Sources: []string{"simplex"},
+					Octaves: 2, Lacunarity: 2.3, Frequency: 1.8, Offset: 1.0, Gain: 1.0, Exponent: 1.2},
+				{Name: "crestheight", GeneratorType: noisey.GenScale2D, Generators: []string{"crests"},
+					Scale: 0.3, Bias: 0.2, Min: -1.0, Max: 1.0},
+				{Name: "ripples", GeneratorType: noisey.GenFBM2D, Sources: []string{"perlin"},
+					Octaves: 2, Persistence: 0.5, Lacunarity: 2.0, Frequency: 6.0},
+				{Name: "rippleheight", GeneratorType: noisey.GenScale2D, Generators: []string{"ripples"},
+					Scale: 0.05, Bias: 0.2, Min: -1.0, Max: 1.0},
+				{Name: "dunecontrol", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 2, Persistence: 0.5, Lacunarity: 2.0, Frequency: 0.4},
+				{Name: TerrainOutput, GeneratorType: noisey.GenSelect2D, Generators: []string{"rippleheight", "crestheight", "dunecontrol"},
+					LowerBound: -0.2, UpperBound: 100.0, EdgeFalloff: 0.3},
+			}
+		},
+	},
+}
+
+// TerrainNames returns the names of all of the terrain presets in sorted order.
+func TerrainNames() []string {
+	names := make([]string, 0, len(terrainPresets))
+	for name := range terrainPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TerrainDescription returns a short description of the terrain preset.
+func TerrainDescription(name string) string {
+	return terrainPresets[name].description
+}
+
+// TerrainConfig returns the configuration of the terrain preset using seed
+// for its random sources. The configuration has not been built yet.
+func TerrainConfig(name string, seed int64) (*noisey.NoiseJSON, error) {
+	preset, ok := terrainPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("Undefined terrain preset (%s).\n", name)
+	}
+	cfg := newPresetConfig(seed)
+	preset.config(cfg)
+	return cfg, nil
+}
+
+// NewTerrain builds the terrain preset using seed for its random sources and
+// returns its output generator.
+func NewTerrain(name string, seed int64) (noisey.NoiseyGet2D, error) {
+	cfg, err := TerrainConfig(name, seed)
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.BuildSources(nil); err != nil {
+		return nil, err
+	}
+	if err = cfg.BuildGenerators(); err != nil {
+		return nil, err
+	}
+	return cfg.GetGenerator(TerrainOutput), nil
+}
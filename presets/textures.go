@@ -0,0 +1,196 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package presets
+
+/*
+
+This file contains the texture presets: the classic libnoise texture recipes
+for wood, marble, granite and jade. Like the terrain presets they are
+noisey.NoiseJSON configurations, and each one comes with a suggested
+noisey.ColorGradient that colors its output:
+
+	texture, gradient, err := presets.NewTexture("marble", 1)
+	if err != nil {
+		panic(err)
+	}
+	builder := noisey.NewBuilder2D(texture, 512, 512)
+	builder.Bounds = noisey.Builder2DBounds{0.0, 0.0, 1.0, 1.0}
+	builder.Build()
+	nm := builder.GetNoiseMap()
+	img := gradient.Image(&nm)
+
+The textures are made to be sampled over about one unit and their outputs
+cover roughly the range [-1, 1] of their gradients.
+
+*/
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/tbogdala/noisey"
+)
+
+// TextureOutput is the name of the final generator in every texture preset.
+const TextureOutput = "texture"
+
+// texturePreset describes a texture preset, how to make its configuration
+// and its suggested colors.
+type texturePreset struct {
+	description string
+	config      func(cfg *noisey.NoiseJSON)
+	colors      []noisey.ColorKey
+}
+
+var texturePresets = map[string]texturePreset{
+	"wood": {
+		description: "growth rings from concentric cylinders distorted by turbulence, with a fine grain",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "rings", GeneratorType: noisey.GenPattern2D, Pattern: noisey.PatternCylinders, Frequency: 6.0},
+				{Name: "ringwarp", GeneratorType: noisey.GenFBM2D, Sources: []string{"perlin"},
+					Octaves: 4, Persistence: 0.5, Lacunarity: 2.0, Frequency: 1.0},
+				{Name: "warpedrings", GeneratorType: noisey.GenTurbulence2D, Generators: []string{"rings", "ringwarp", "ringwarp"},
+					Frequency: 2.0, Power: 0.06},
+				{Name: "grain", GeneratorType: noisey.GenFBM2D, Sources: []string{"perlin"},
+					Octaves: 3, Persistence: 0.5, Lacunarity: 2.0, Frequency: 1.0},
+				{Name: TextureOutput, GeneratorType: noisey.GenTurbulence2D, Generators: []string{"warpedrings", "grain", "grain"},
+					Frequency: 24.0, Power: 0.004},
+			}
+		},
+		colors: []noisey.ColorKey{
+			{Position: -1.0, Color: color.NRGBA{90, 50, 20, 255}},
+			{Position: -0.2, Color: color.NRGBA{150, 95, 45, 255}},
+			{Position: 0.6, Color: color.NRGBA{190, 135, 75, 255}},
+			{Position: 1.0, Color: color.NRGBA{110, 65, 30, 255}},
+		},
+	},
+	"marble": {
+		description: "veins from sine stripes perturbed by fBm",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "veins", GeneratorType: noisey.GenPattern2D, Pattern: noisey.PatternStripes, Frequency: 2.0},
+				{Name: "perturb", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 6, Persistence: 0.5, Lacunarity: 2.0, Frequency: 1.0},
+				{Name: TextureOutput, GeneratorType: noisey.GenTurbulence2D, Generators: []string{"veins", "perturb", "perturb"},
+					Frequency: 1.5, Power: 0.4},
+			}
+		},
+		colors: []noisey.ColorKey{
+			{Position: -1.0, Color: color.NRGBA{235, 235, 230, 255}},
+			{Position: 0.5, Color: color.NRGBA{215, 215, 210, 255}},
+			{Position: 0.85, Color: color.NRGBA{130, 130, 135, 255}},
+			{Position: 1.0, Color: color.NRGBA{60, 60, 70, 255}},
+		},
+	},
+	"granite": {
+		description: "billowy mineral grains with dark Worley speckles",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Sources["worley"] = noisey.SourceJSON{SourceType: noisey.SourceWorley, Seed: "Detail"}
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "grains", GeneratorType: noisey.GenBillow2D, Sources: []string{"perlin"},
+					Octaves: 6, Persistence: 0.6, Lacunarity: 2.2, Frequency: 8.0},
+				{Name: "stone", GeneratorType: noisey.GenScale2D, Generators: []string{"grains"},
+					Scale: 0.5, Bias: 0.5, Min: -1.0, Max: 1.0},
+				{Name: "darkstone", GeneratorType: noisey.GenScale2D, Generators: []string{"grains"},
+					Scale: 0.2, Bias: -0.8, Min: -1.0, Max: 1.0},
+				{Name: "cells", GeneratorType: noisey.GenFBM2D, Sources: []string{"worley"},
+					Octaves: 1, Persistence: 0.5, Lacunarity: 2.0, Frequency: 16.0},
+				{Name: TextureOutput, GeneratorType: noisey.GenSelect2D, Generators: []string{"stone", "darkstone", "cells"},
+					LowerBound: -2.0, UpperBound: -0.75, EdgeFalloff: 0.05},
+			}
+		},
+		colors: []noisey.ColorKey{
+			{Position: -1.0, Color: color.NRGBA{20, 20, 20, 255}},
+			{Position: -0.5, Color: color.NRGBA{70, 65, 65, 255}},
+			{Position: 0.0, Color: color.NRGBA{160, 150, 150, 255}},
+			{Position: 0.4, Color: color.NRGBA{200, 170, 165, 255}},
+			{Position: 1.0, Color: color.NRGBA{240, 230, 225, 255}},
+		},
+	},
+	"jade": {
+		description: "translucent green bands from stripes warped by ridged noise",
+		config: func(cfg *noisey.NoiseJSON) {
+			cfg.Generators = []noisey.GeneratorJSON{
+				{Name: "bands", GeneratorType: noisey.GenPattern2D, Pattern: noisey.PatternStripes, Frequency: 1.5},
+				{Name: "ridges", GeneratorType: noisey.GenRidged2D, Sources: []string{"simplex"},
+					Octaves: 4, Lacunarity: 2.0, Frequency: 1.2, Offset: 1.0, Gain: 2.0, Exponent: 1.0},
+				{Name: "swirl", GeneratorType: noisey.GenFBM2D, Sources: []string{"simplex"},
+					Octaves: 3, Persistence: 0.5, Lacunarity: 2.0, Frequency: 1.0},
+				{Name: "warpedbands", GeneratorType: noisey.GenTurbulence2D, Generators: []string{"bands", "ridges", "swirl"},
+					Frequency: 1.0, Power: 0.25},
+				{Name: "mottle", GeneratorType: noisey.GenFBM2D, Sources: []string{"perlin"},
+					Octaves: 4, Persistence: 0.5, Lacunarity: 2.0, Frequency: 1.0},
+				{Name: TextureOutput, GeneratorType: noisey.GenTurbulence2D, Generators: []string{"warpedbands", "mottle", "mottle"},
+					Frequency: 4.0, Power: 0.03},
+			}
+		},
+		colors: []noisey.ColorKey{
+			{Position: -1.0, Color: color.NRGBA{25, 80, 45, 255}},
+			{Position: 0.0, Color: color.NRGBA{45, 125, 70, 255}},
+			{Position: 0.6, Color: color.NRGBA{105, 170, 110, 255}},
+			{Position: 1.0, Color: color.NRGBA{175, 215, 170, 255}},
+		},
+	},
+}
+
+// TextureNames returns the names of all of the texture presets in sorted order.
+func TextureNames() []string {
+	names := make([]string, 0, len(texturePresets))
+	for name := range texturePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TextureDescription returns a short description of the texture preset.
+func TextureDescription(name string) string {
+	return texturePresets[name].description
+}
+
+// TextureConfig returns the configuration of the texture preset using seed
+// for its random sources, with its suggested gradient saved in Gradients as
+// TextureOutput. The configuration has not been built yet.
+func TextureConfig(name string, seed int64) (*noisey.NoiseJSON, error) {
+	preset, ok := texturePresets[name]
+	if !ok {
+		return nil, fmt.Errorf("Undefined texture preset (%s).\n", name)
+	}
+	cfg := newPresetConfig(seed)
+	preset.config(cfg)
+	gradient := noisey.NewColorGradient(preset.colors...)
+	cfg.Gradients = map[string]noisey.GradientJSON{TextureOutput: gradient.ToJSON()}
+	return cfg, nil
+}
+
+// TextureGradient returns the suggested color gradient of the texture preset.
+func TextureGradient(name string) (noisey.ColorGradient, error) {
+	preset, ok := texturePresets[name]
+	if !ok {
+		return noisey.ColorGradient{}, fmt.Errorf("Undefined texture preset (%s).\n", name)
+	}
+	return noisey.NewColorGradient(preset.colors...), nil
+}
+
+// NewTexture builds the texture preset using seed for its random sources and
+// returns its output generator and suggested color gradient.
+func NewTexture(name string, seed int64) (noisey.NoiseyGet2D, noisey.ColorGradient, error) {
+	cfg, err := TextureConfig(name, seed)
+	if err != nil {
+		return nil, noisey.ColorGradient{}, err
+	}
+	gradient, err := cfg.GetGradient(TextureOutput)
+	if err != nil {
+		return nil, gradient, err
+	}
+	if err = cfg.BuildSources(nil); err != nil {
+		return nil, gradient, err
+	}
+	if err = cfg.BuildGenerators(); err != nil {
+		return nil, gradient, err
+	}
+	return cfg.GetGenerator(TextureOutput), gradient, nil
+}
@@ -0,0 +1,145 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the interfaces and functions used to find the bounds of
+the values a module can return without sampling it, so that normalizers,
+image exporters and the thresholds of a Select can be set up from the
+pipeline alone.
+
+The bounds of the sources are derived from their algorithms and modifiers
+work theirs out from the bounds of the modules they use. The values are
+guaranteed to fall within the bounds, but as the bounds of a modifier are
+worked out term by term they are not always tight. A module that can't bound
+its values, such as a NoiseFunc2D, has the bounds -Inf and +Inf.
+
+*/
+
+import "math"
+
+// NoiseyRange1D is an interface for modules that know the bounds of their 1D values.
+type NoiseyRange1D interface {
+	Range1D() (min float64, max float64)
+}
+
+// NoiseyRange2D is an interface for modules that know the bounds of their 2D values.
+type NoiseyRange2D interface {
+	Range2D() (min float64, max float64)
+}
+
+// NoiseyRange3D is an interface for modules that know the bounds of their 3D values.
+type NoiseyRange3D interface {
+	Range3D() (min float64, max float64)
+}
+
+// NoiseyRange4D is an interface for modules that know the bounds of their 4D values.
+type NoiseyRange4D interface {
+	Range4D() (min float64, max float64)
+}
+
+// ValueRange1D returns the bounds of the values of the module s. If s does
+// not implement NoiseyRange1D the bounds are -Inf and +Inf.
+func ValueRange1D(s NoiseyGet1D) (min float64, max float64) {
+	if r, ok := s.(NoiseyRange1D); ok {
+		return r.Range1D()
+	}
+	return unboundedRange()
+}
+
+// ValueRange2D returns the bounds of the values of the module s. If s does
+// not implement NoiseyRange2D the bounds are -Inf and +Inf.
+func ValueRange2D(s NoiseyGet2D) (min float64, max float64) {
+	if r, ok := s.(NoiseyRange2D); ok {
+		return r.Range2D()
+	}
+	return unboundedRange()
+}
+
+// ValueRange3D returns the bounds of the values of the module s. If s does
+// not implement NoiseyRange3D the bounds are -Inf and +Inf.
+func ValueRange3D(s NoiseyGet3D) (min float64, max float64) {
+	if r, ok := s.(NoiseyRange3D); ok {
+		return r.Range3D()
+	}
+	return unboundedRange()
+}
+
+// ValueRange4D returns the bounds of the values of the module s. If s does
+// not implement NoiseyRange4D the bounds are -Inf and +Inf.
+func ValueRange4D(s NoiseyGet4D) (min float64, max float64) {
+	if r, ok := s.(NoiseyRange4D); ok {
+		return r.Range4D()
+	}
+	return unboundedRange()
+}
+
+func unboundedRange() (float64, float64) {
+	return math.Inf(-1), math.Inf(1)
+}
+
+// scaleRange returns the bounds of v*s for v in [min, max].
+func scaleRange(min float64, max float64, s float64) (float64, float64) {
+	return mulRange(min, max, s, s)
+}
+
+// addRange returns the bounds of a+b for a in [amin, amax] and b in [bmin, bmax].
+func addRange(amin float64, amax float64, bmin float64, bmax float64) (float64, float64) {
+	return amin + bmin, amax + bmax
+}
+
+// mulRange returns the bounds of a*b for a in [amin, amax] and b in
+// [bmin, bmax]. A zero bound times an infinite one counts as zero since the
+// values themselves are finite.
+func mulRange(amin float64, amax float64, bmin float64, bmax float64) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, a := range []float64{amin, amax} {
+		for _, b := range []float64{bmin, bmax} {
+			p := a * b
+			if math.IsNaN(p) {
+				p = 0.0
+			}
+			min = math.Min(min, p)
+			max = math.Max(max, p)
+		}
+	}
+	return min, max
+}
+
+// absRange returns the bounds of |v| for v in [min, max].
+func absRange(min float64, max float64) (float64, float64) {
+	switch {
+	case min >= 0.0:
+		return min, max
+	case max <= 0.0:
+		return -max, -min
+	}
+	return 0.0, math.Max(-min, max)
+}
+
+// squareRange returns the bounds of v*v for v in [min, max].
+func squareRange(min float64, max float64) (float64, float64) {
+	lo, hi := absRange(min, max)
+	return lo * lo, hi * hi
+}
+
+// unionRange returns the bounds that cover both [amin, amax] and [bmin, bmax].
+func unionRange(amin float64, amax float64, bmin float64, bmax float64) (float64, float64) {
+	return math.Min(amin, bmin), math.Max(amax, bmax)
+}
+
+// clampRange returns the bounds of v clamped to [lo, hi] for v in [min, max].
+func clampRange(min float64, max float64, lo float64, hi float64) (float64, float64) {
+	return math.Min(hi, math.Max(lo, min)), math.Min(hi, math.Max(lo, max))
+}
+
+// bicubicRange returns the bounds of the bicubic blend of grid values in
+// [min, max]. The negative weights of the Catmull-Rom spline add up to at
+// most 1/8 along each axis, so the blend overshoots by 1/8 of the span along
+// the first axis and 1/8 of the widened span along the second.
+func bicubicRange(min float64, max float64) (float64, float64) {
+	pad := (max - min) * (0.125 + 0.125*1.25)
+	return min - pad, max + pad
+}
@@ -0,0 +1,210 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains NoiseReader, an io.Reader that streams the values of
+a generator as bytes, so noise can be piped into tools that read streams or
+used as reproducible test data:
+
+	// the values of a 256x256 map, row by row, as little endian float64
+	r := noisey.NewRegionReader(&fbm, bounds, 256, 256, noisey.StreamFloat64)
+	io.Copy(file, r)
+
+	// a megabyte of bytes from a walk along the X axis
+	w := noisey.NewWalkReader(&fbm, noisey.Vec2f{0, 0}, noisey.Vec2f{0.01, 0}, noisey.StreamUint8)
+	data, err := ioutil.ReadAll(io.LimitReader(w, 1<<20))
+
+A region reader samples the same points in the same order as Builder2D, so
+its values are those of the map Builder2D would build, and it ends with
+io.EOF after the last one. A walk reader samples Start + i * Step for i =
+0, 1, 2, ... and never ends. The stream only depends on the generator and
+how the reader was made, not on the sizes of the reads.
+
+The values are encoded as:
+
+* StreamUint8 - one byte a value, [-1, 1] mapped to [0, 255]
+* StreamUint16 - two bytes a value, [-1, 1] mapped to [0, 65535] like WriteRAW16()
+* StreamFloat32 - the value as a float32
+* StreamFloat64 - the value as a float64
+
+The integer encodings clamp the values to [-1, 1] first. Multi byte
+encodings use Order, which is little endian unless it's changed.
+
+*/
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamEncoding is how a NoiseReader writes values as bytes.
+type StreamEncoding int
+
+const (
+	StreamUint8   StreamEncoding = iota // one byte a value, [-1, 1] mapped to [0, 255]
+	StreamUint16                        // two bytes a value, [-1, 1] mapped to [0, 65535]
+	StreamFloat32                       // four bytes a value as a float32
+	StreamFloat64                       // eight bytes a value as a float64
+)
+
+// String returns the name of the encoding.
+func (e StreamEncoding) String() string {
+	switch e {
+	case StreamUint8:
+		return "uint8"
+	case StreamUint16:
+		return "uint16"
+	case StreamFloat32:
+		return "float32"
+	case StreamFloat64:
+		return "float64"
+	}
+	return fmt.Sprintf("StreamEncoding(%d)", int(e))
+}
+
+// Size returns the number of bytes of a value in the encoding.
+func (e StreamEncoding) Size() int {
+	switch e {
+	case StreamUint8:
+		return 1
+	case StreamUint16:
+		return 2
+	case StreamFloat32:
+		return 4
+	}
+	return 8
+}
+
+// NoiseReader is an io.Reader of the values of a generator.
+type NoiseReader struct {
+	// Source is the generator that is sampled
+	Source NoiseyGet2D
+
+	// Encoding is how the values are written as bytes
+	Encoding StreamEncoding
+
+	// Order is the byte order of the multi byte encodings
+	Order binary.ByteOrder
+
+	// next returns the next point to sample and false when there are none
+	next func() (float64, float64, bool)
+
+	buf     [8]byte
+	pending []byte // the bytes of the last value that haven't been read
+}
+
+// NewRegionReader creates a new reader of the values of a width x height
+// map of src over bounds, row by row from bounds.MinY.
+func NewRegionReader(src NoiseyGet2D, bounds Builder2DBounds, width int, height int, enc StreamEncoding) (r *NoiseReader) {
+	r = new(NoiseReader)
+	r.Source = src
+	r.Encoding = enc
+	r.Order = binary.LittleEndian
+
+	// the points are stepped to the same way Builder2D does
+	xDelta := (bounds.MaxX - bounds.MinX) / float64(width)
+	yDelta := (bounds.MaxY - bounds.MinY) / float64(height)
+	xCur, yCur := bounds.MinX, bounds.MinY
+	x, y := 0, 0
+	r.next = func() (float64, float64, bool) {
+		if width <= 0 || y >= height {
+			return 0.0, 0.0, false
+		}
+		px, py := xCur, yCur
+		x++
+		xCur += xDelta
+		if x == width {
+			x, xCur = 0, bounds.MinX
+			y++
+			yCur += yDelta
+		}
+		return px, py, true
+	}
+	return r
+}
+
+// NewWalkReader creates a new reader of the values of src at start and
+// every step after it, without an end.
+func NewWalkReader(src NoiseyGet2D, start Vec2f, step Vec2f, enc StreamEncoding) (r *NoiseReader) {
+	r = new(NoiseReader)
+	r.Source = src
+	r.Encoding = enc
+	r.Order = binary.LittleEndian
+
+	var i float64
+	r.next = func() (float64, float64, bool) {
+		x, y := start.X+i*step.X, start.Y+i*step.Y
+		i++
+		return x, y, true
+	}
+	return r
+}
+
+// encode writes the value into the buffer and returns its bytes.
+func (r *NoiseReader) encode(v float64) []byte {
+	switch r.Encoding {
+	case StreamUint8:
+		r.buf[0] = uint8(math.Floor(math.Max(0.0, math.Min(1.0, (v+1.0)*0.5))*255.0 + 0.5))
+		return r.buf[:1]
+	case StreamUint16:
+		r.Order.PutUint16(r.buf[:], heightmapWord(v))
+		return r.buf[:2]
+	case StreamFloat32:
+		r.Order.PutUint32(r.buf[:], math.Float32bits(float32(v)))
+		return r.buf[:4]
+	}
+	r.Order.PutUint64(r.buf[:], math.Float64bits(v))
+	return r.buf[:8]
+}
+
+// Read fills p with the bytes of the next values. A value can be split
+// between reads. It returns io.EOF after the last value of a region.
+func (r *NoiseReader) Read(p []byte) (n int, err error) {
+	if r.Source == nil {
+		return 0, fmt.Errorf("Unable to read noise without a Source.\n")
+	}
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			x, y, ok := r.next()
+			if !ok {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				return n, nil
+			}
+			r.pending = r.encode(r.Source.Get2D(x, y))
+		}
+		c := copy(p[n:], r.pending)
+		r.pending = r.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// ReadValues fills values with the next values of the generator, without
+// encoding them, and returns how many it read. It returns io.EOF when a
+// region has no values left. Bytes of a value that a Read() split are
+// dropped.
+func (r *NoiseReader) ReadValues(values []float64) (n int, err error) {
+	if r.Source == nil {
+		return 0, fmt.Errorf("Unable to read noise without a Source.\n")
+	}
+	r.pending = nil
+	for n < len(values) {
+		x, y, ok := r.next()
+		if !ok {
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		}
+		values[n] = r.Source.Get2D(x, y)
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,351 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains a registry describing the module types that can be
+created from a JSON configuration, along with their parameters, so that tools
+such as editors can discover them at runtime.
+
+The parameter names match the field names of GeneratorJSON and SourceJSON.
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// maxOctaves is the largest number of octaves of the fractal generators, in
+// their ParamInfo and in DefaultLoadLimits.
+const maxOctaves = 30
+
+// ModuleKind identifies whether a module type is a source or a generator.
+type ModuleKind int
+
+const (
+	KindSource    ModuleKind = iota // a module listed in NoiseJSON.Sources
+	KindGenerator                   // a module listed in NoiseJSON.Generators
+)
+
+// String returns the name of the module kind.
+func (k ModuleKind) String() string {
+	switch k {
+	case KindSource:
+		return "source"
+	case KindGenerator:
+		return "generator"
+	}
+	return fmt.Sprintf("ModuleKind(%d)", int(k))
+}
+
+// ParamInfo describes one parameter of a module type.
+type ParamInfo struct {
+	// Name is the name of the field in GeneratorJSON or SourceJSON
+	Name string
+
+	// Type is the Go type of the parameter: "int" or "float64"
+	Type string
+
+	// Min is the lowest valid value for the parameter
+	Min float64
+
+	// Max is the highest valid value for the parameter
+	Max float64
+
+	// Default is the value a 'default' module would use
+	Default float64
+
+	// Description is a short human readable explanation of the parameter
+	Description string
+}
+
+// ModuleInfo describes a module type that can be created from JSON.
+type ModuleInfo struct {
+	// Type is the type string used in SourceJSON.SourceType or GeneratorJSON.GeneratorType
+	Type string
+
+	// Kind is whether the module is a source or a generator
+	Kind ModuleKind
+
+	// Description is a short human readable explanation of the module
+	Description string
+
+	// Sources is the number of names required in GeneratorJSON.Sources
+	Sources int
+
+	// Generators is the number of names required in GeneratorJSON.Generators
+	Generators int
+
+	// Params describes the numeric parameters of the module
+	Params []ParamInfo
+}
+
+// Param returns the description of the parameter name and true if the
+// module has such parameter; false otherwise.
+func (mi ModuleInfo) Param(name string) (ParamInfo, bool) {
+	for _, p := range mi.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ParamInfo{}, false
+}
+
+var (
+	registryLock  sync.RWMutex
+	registryOrder []string
+	registry      = make(map[string]ModuleInfo)
+)
+
+// RegisterModule adds a module type description to the registry. An error
+// is returned if the type is already registered.
+func RegisterModule(info ModuleInfo) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[info.Type]; ok {
+		return fmt.Errorf("Module type \"%s\" is already registered.\n", info.Type)
+	}
+	registry[info.Type] = info
+	registryOrder = append(registryOrder, info.Type)
+	return nil
+}
+
+// Modules returns the descriptions of all registered module types in the
+// order they were registered.
+func Modules() []ModuleInfo {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	infos := make([]ModuleInfo, len(registryOrder))
+	for i, t := range registryOrder {
+		infos[i] = registry[t]
+	}
+	return infos
+}
+
+// LookupModule returns the description of the module type and true if it
+// is registered; false otherwise.
+func LookupModule(moduleType string) (ModuleInfo, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	info, ok := registry[moduleType]
+	return info, ok
+}
+
+// fbmParams are the parameters of the fBm generators in every dimension;
+// only FBMGenerator2D is registered, as GenFBM2D.
+var fbmParams = []ParamInfo{
+	{"Octaves", "int", 1, maxOctaves, 1, "the number of octaves to calculate"},
+	{"Persistence", "float64", 0, math.Inf(1), 0.5, "how quickly the amplitudes diminish for each successive octave"},
+	{"Lacunarity", "float64", 0, math.Inf(1), 2.0, "how quickly the frequency increases for each successive octave"},
+	{"Frequency", "float64", 0, math.Inf(1), 1.0, "the number of cycles per unit length"},
+}
+
+func init() {
+	inf := math.Inf(1)
+	builtins := []ModuleInfo{
+		{
+			Type:        SourcePerlin,
+			Kind:        KindSource,
+			Description: "2D/3D/4D Perlin noise",
+			Params: []ParamInfo{
+				{"Quality", "int", 0, 3, 0, "how lattice points are blended: 0 default, 1 linear, 2 cubic, 3 quintic"},
+			},
+		},
+		{
+			Type:        SourceOpenSimplex,
+			Kind:        KindSource,
+			Description: "2D/3D OpenSimplex noise",
+		},
+		{
+			Type:        SourceWorley,
+			Kind:        KindSource,
+			Description: "2D/3D Worley (cellular) noise returning the distance to the closest feature point",
+		},
+		{
+			Type:        GenFBM2D,
+			Kind:        KindGenerator,
+			Description: "fractal Brownian motion over a source",
+			Sources:     1,
+			Params:      fbmParams,
+		},
+		{
+			Type:        GenRidged2D,
+			Kind:        KindGenerator,
+			Description: "ridged multifractal noise over a source",
+			Sources:     1,
+			Params: []ParamInfo{
+				{"Octaves", "int", 1, maxOctaves, 6, "the number of octaves to calculate"},
+				{"Lacunarity", "float64", 0, inf, 2.0, "how quickly the frequency increases for each successive octave"},
+				{"Frequency", "float64", 0, inf, 1.0, "the number of cycles per unit length"},
+				{"Offset", "float64", -inf, inf, 1.0, "the value each folded octave is subtracted from"},
+				{"Gain", "float64", 0, inf, 2.0, "how strongly each octave weights the next one"},
+				{"Exponent", "float64", -inf, inf, 1.0, "how quickly the amplitudes diminish for each successive octave"},
+			},
+		},
+		{
+			Type:        GenSelect2D,
+			Kind:        KindGenerator,
+			Description: "choose from generator A or B depending on a control generator",
+			Generators:  3,
+			Params: []ParamInfo{
+				{"LowerBound", "float64", -inf, inf, -1.0, "the lower bound of the control range that selects B"},
+				{"UpperBound", "float64", -inf, inf, 1.0, "the upper bound of the control range that selects B"},
+				{"EdgeFalloff", "float64", 0, inf, 0.0, "the width of the blended transition between A and B"},
+			},
+		},
+		{
+			Type:        GenScale2D,
+			Kind:        KindGenerator,
+			Description: "multiply a generator by a scale, add a bias and clamp the result",
+			Generators:  1,
+			Params: []ParamInfo{
+				{"Scale", "float64", -inf, inf, 1.0, "what to scale the noise value by"},
+				{"Bias", "float64", -inf, inf, 0.0, "the constant to add to the scaled noise value"},
+				{"Min", "float64", -inf, inf, -1.0, "the minimum value to return"},
+				{"Max", "float64", -inf, inf, 1.0, "the maximum value to return"},
+			},
+		},
+		{
+			Type:        GenFalloff2D,
+			Kind:        KindGenerator,
+			Description: "a mask that is 1.0 at a center point and falls off to 0.0 at a radius",
+			Params: []ParamInfo{
+				{"Shape", "int", 0, 3, 0, "how distance is measured: 0 radial, 1 square, 2 diamond, 3 custom with a Distance function set in code"},
+				{"CenterX", "float64", -inf, inf, 0.0, "the X coordinate of the center"},
+				{"CenterY", "float64", -inf, inf, 0.0, "the Y coordinate of the center"},
+				{"Radius", "float64", 0, inf, 1.0, "the distance from the center where the mask reaches 0.0"},
+				{"Exponent", "float64", 0, inf, 1.0, "the shape of the falloff; 1.0 is linear"},
+			},
+		},
+		{
+			Type:        GenBillow2D,
+			Kind:        KindGenerator,
+			Description: "billowy fractal noise that folds each octave of a source into rounded lumps",
+			Sources:     1,
+			Params: []ParamInfo{
+				{"Octaves", "int", 1, maxOctaves, 6, "the number of octaves to calculate"},
+				{"Persistence", "float64", 0, inf, 0.5, "how quickly the amplitudes diminish for each successive octave"},
+				{"Lacunarity", "float64", 0, inf, 2.0, "how quickly the frequency increases for each successive octave"},
+				{"Frequency", "float64", 0, inf, 1.0, "the number of cycles per unit length"},
+			},
+		},
+		{
+			Type:        GenTurbulence2D,
+			Kind:        KindGenerator,
+			Description: "displace the coordinates of a generator by an X and a Y distortion generator",
+			Generators:  3,
+			Params: []ParamInfo{
+				{"Frequency", "float64", 0, inf, 1.0, "the frequency the distortion generators are sampled at"},
+				{"Power", "float64", -inf, inf, 1.0, "what the distortion values are multiplied by"},
+			},
+		},
+		{
+			Type:        GenPattern2D,
+			Kind:        KindGenerator,
+			Description: "a regular pattern of concentric cylinders or sine stripes",
+			Params: []ParamInfo{
+				{"Pattern", "int", 0, 1, 0, "the pattern: 0 cylinders, 1 stripes"},
+				{"Frequency", "float64", 0, inf, 1.0, "the number of rings or stripes per unit length"},
+			},
+		},
+		{
+			Type:        GenCrackle2D,
+			Kind:        KindGenerator,
+			Description: "cracks along the edges of the cells of a worley source, made jagged by an optional warp generator",
+			Sources:     1,
+			Params: []ParamInfo{
+				{"CellSize", "float64", 0, inf, 1.0, "the world size of a cell"},
+				{"Width", "float64", 0, inf, 0.05, "the width of the cracks in cells"},
+				{"Jaggedness", "float64", -inf, inf, 0.0, "how far in cells the warp generator displaces the coordinates"},
+			},
+		},
+		{
+			Type:        GenCaustics2D,
+			Kind:        KindGenerator,
+			Description: "animated water caustics from layers of lines along the edges of the cells of a worley source, bent by an optional warp generator",
+			Sources:     1,
+			Params: []ParamInfo{
+				{"CellSize", "float64", 0, inf, 1.0, "the world size of a cell of the first layer"},
+				{"Width", "float64", 0, inf, 0.08, "the width of the lines in cells"},
+				{"Layers", "int", 1, 8, 2, "the number of line patterns laid over each other"},
+				{"Speed", "float64", -inf, inf, 1.0, "how many cells the pattern moves through per unit of time"},
+				{"Distortion", "float64", -inf, inf, 0.0, "how far in cells the warp generator displaces the coordinates"},
+				{"Time", "float64", -inf, inf, 0.0, "the time the pattern is sampled at"},
+			},
+		},
+		{
+			Type:        GenKaleidoscope2D,
+			Kind:        KindGenerator,
+			Description: "fold the coordinates into one wedge around a center so a generator repeats with N-fold rotational or mirror symmetry",
+			Generators:  1,
+			Params: []ParamInfo{
+				{"Segments", "int", 1, inf, 6, "the number of wedges around the center"},
+				{"Symmetry", "int", 0, 1, 1, "how the wedges repeat: 0 rotated, 1 mirrored"},
+				{"CenterX", "float64", -inf, inf, 0.0, "the X of the center of symmetry"},
+				{"CenterY", "float64", -inf, inf, 0.0, "the Y of the center of symmetry"},
+				{"Rotation", "float64", -inf, inf, 0.0, "the angle the wedges are turned by in radians"},
+			},
+		},
+		{
+			Type:        GenPolar2D,
+			Kind:        KindGenerator,
+			Description: "sample a generator at the radius and angle around a center for rings, spokes and portals, blending across the angle's wrap so there is no seam",
+			Generators:  1,
+			Params: []ParamInfo{
+				{"CenterX", "float64", -inf, inf, 0.0, "the X of the center"},
+				{"CenterY", "float64", -inf, inf, 0.0, "the Y of the center"},
+				{"RadiusScale", "float64", -inf, inf, 1.0, "the source units per world unit of radius"},
+				{"AngleScale", "float64", 0.0, inf, 1.0, "the source units per turn around the center"},
+			},
+		},
+	}
+
+	for _, info := range builtins {
+		RegisterModule(info)
+	}
+}
+
+// validateParam returns an error if value is not a finite number inside the
+// range registered for the parameter name of moduleType.
+func validateParam(moduleType string, name string, value float64) error {
+	info, _ := LookupModule(moduleType)
+	return validateParamIn(info.Params, name, value)
+}
+
+// validateParamIn returns an error if value is not a finite number inside the
+// range of the parameter name in params.
+func validateParamIn(params []ParamInfo, name string, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%s must be a finite number; got %v.\n", name, value)
+	}
+	p, ok := ModuleInfo{Params: params}.Param(name)
+	if !ok {
+		return nil
+	}
+	if value < p.Min || value > p.Max {
+		return fmt.Errorf("%s must be in the range [%v, %v]; got %v.\n", name, p.Min, p.Max, value)
+	}
+	return nil
+}
+
+// validatePermutations returns an error if perm is not a permutation of [0,n).
+func validatePermutations(perm []int, n int) error {
+	if len(perm) != n {
+		return fmt.Errorf("Permutation table must have %d entries; got %d.\n", n, len(perm))
+	}
+	seen := make([]bool, n)
+	for _, v := range perm {
+		if v < 0 || v >= n || seen[v] {
+			return fmt.Errorf("Permutation table must contain each of the values 0 to %d exactly once.\n", n-1)
+		}
+		seen[v] = true
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module performs ridged multifractal noise which folds each octave of a
+coherent noise generator around zero to create sharp ridges, and weights each
+octave by the previous one so that ridges stay sharp while valleys get smooth.
+
+Reference material:
+* Libnoise's ridged multifractal module: http://libnoise.sourceforge.net/docs/classnoise_1_1module_1_1RidgedMulti.html
+* Musgrave's original algorithm in "Texturing & Modeling: A Procedural Approach"
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// RidgedGenerator2D takes noise and makes ridged multifractal values.
+type RidgedGenerator2D struct {
+	NoiseMaker NoiseyGet2D // the interface RidgedGenerator2D uses gets noise values
+	Octaves    int         // the number of octaves to calculate on each Get()
+	Lacunarity float64     // a multiplier that determines how quickly the frequency increases for each successive octave
+	Frequency  float64     // the number of cycles per unit length
+	Offset     float64     // the value each folded octave is subtracted from; higher values make ridges wider
+	Gain       float64     // how strongly each octave weights the next one
+	Exponent   float64     // how quickly the amplitudes diminish for each successive octave
+}
+
+// NewRidgedGenerator2D creates a new ridged multifractal generator state. A 'default'
+// generator would have 6 octaves, 2.0 lacunarity, 1.0 frequency, 1.0 offset,
+// 2.0 gain and 1.0 exponent.
+func NewRidgedGenerator2D(noise NoiseyGet2D, octaves int, lacunarity float64, frequency float64, offset float64, gain float64, exponent float64) (ridged RidgedGenerator2D) {
+	ridged.NoiseMaker = noise
+	ridged.Octaves = octaves
+	ridged.Lacunarity = lacunarity
+	ridged.Frequency = frequency
+	ridged.Offset = offset
+	ridged.Gain = gain
+	ridged.Exponent = exponent
+	return
+}
+
+// Clone2D returns a deep copy of the generator and its NoiseMaker.
+func (ridged *RidgedGenerator2D) Clone2D() NoiseyGet2D {
+	c := *ridged
+	c.NoiseMaker = DeepCopy2D(ridged.NoiseMaker)
+	return &c
+}
+
+// SetOctaves validates and changes the number of octaves.
+func (ridged *RidgedGenerator2D) SetOctaves(octaves int) error {
+	if err := validateParam(GenRidged2D, "Octaves", float64(octaves)); err != nil {
+		return err
+	}
+	ridged.Octaves = octaves
+	return nil
+}
+
+// SetLacunarity validates and changes the lacunarity.
+func (ridged *RidgedGenerator2D) SetLacunarity(lacunarity float64) error {
+	if err := validateParam(GenRidged2D, "Lacunarity", lacunarity); err != nil {
+		return err
+	}
+	ridged.Lacunarity = lacunarity
+	return nil
+}
+
+// SetFrequency validates and changes the frequency.
+func (ridged *RidgedGenerator2D) SetFrequency(frequency float64) error {
+	if err := validateParam(GenRidged2D, "Frequency", frequency); err != nil {
+		return err
+	}
+	ridged.Frequency = frequency
+	return nil
+}
+
+// String returns a description of the generator and its NoiseMaker.
+func (ridged *RidgedGenerator2D) String() string {
+	return fmt.Sprintf("RidgedGenerator2D{Octaves: %v, Lacunarity: %v, Frequency: %v, Offset: %v, Gain: %v, Exponent: %v, NoiseMaker: %s}",
+		ridged.Octaves, ridged.Lacunarity, ridged.Frequency, ridged.Offset, ridged.Gain, ridged.Exponent, describeModule(ridged.NoiseMaker))
+}
+
+// Get2D calculates the noise value over the number of Octaves and other parameters
+// that scale the coordinates over each octave.
+func (ridged *RidgedGenerator2D) Get2D(x float64, y float64) (v float64) {
+	weight := 1.0
+	spectralWeight := 1.0
+	spectralStep := math.Pow(ridged.Lacunarity, -ridged.Exponent)
+
+	x *= ridged.Frequency
+	y *= ridged.Frequency
+
+	for o := 0; o < ridged.Octaves; o++ {
+		// fold the signal around zero and square it to sharpen the ridges
+		signal := ridged.Offset - math.Abs(ridged.NoiseMaker.Get2D(x, y))
+		signal *= signal
+
+		// weight successive octaves by the previous signal
+		signal *= weight
+		weight = signal * ridged.Gain
+		if weight > 1.0 {
+			weight = 1.0
+		} else if weight < 0.0 {
+			weight = 0.0
+		}
+
+		v += signal * spectralWeight
+
+		x *= ridged.Lacunarity
+		y *= ridged.Lacunarity
+		spectralWeight *= spectralStep
+	}
+
+	// shift the output so that it's roughly centered on zero
+	return v*1.25 - 1.0
+}
+
+// Range2D returns the bounds of the values of Get2D(). The weight of every
+// octave after the first is in [0, 1].
+func (ridged *RidgedGenerator2D) Range2D() (min float64, max float64) {
+	lo, hi := absRange(ValueRange2D(ridged.NoiseMaker))
+	lo, hi = squareRange(ridged.Offset-hi, ridged.Offset-lo)
+	spectralWeight := 1.0
+	spectralStep := math.Pow(ridged.Lacunarity, -ridged.Exponent)
+	for o := 0; o < ridged.Octaves; o++ {
+		omin, omax := scaleRange(lo, hi, spectralWeight)
+		min, max = addRange(min, max, omin, omax)
+		lo = 0.0
+		spectralWeight *= spectralStep
+	}
+	return min*1.25 - 1.0, max*1.25 - 1.0
+}
@@ -0,0 +1,185 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module traces rivers downslope from random spring points on a built
+heightmap and carves channels for them. The channels get wider as the rivers
+flow away from their springs, and the bed of each river never rises along its
+path so that it always looks like the water can flow.
+
+	rivers := noisey.NewRiverCarver(rand.New(rand.NewSource(1)))
+	rivers.Springs = 40
+	result, err := rivers.Carve(&heights)
+
+	// result.Heightmap has the channels carved into it and result.Mask is
+	// 1.0 at the center of a river falling off to 0.0 at its banks
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// RiverCarver contains the parameters for tracing and carving rivers.
+type RiverCarver struct {
+	Rng             RandomSource // the random number generator used to place springs
+	Springs         int          // the number of springs to try and start rivers from
+	MinSpringHeight float64      // springs are only placed on grid points higher than this
+	SeaLevel        float64      // rivers end when they flow below this height
+	MaxLength       int          // the most grid points a river can flow through
+	SpringWidth     float64      // the radius of the channel in grid points at the spring
+	MouthWidth      float64      // the radius of the channel in grid points at the end of the river
+	Depth           float64      // how deep the center of the channel is carved below the river bed
+	Falloff         float64      // the exponent of the falloff from the center of the channel to its banks
+}
+
+// RiverResult contains the maps produced by RiverCarver.Carve().
+type RiverResult struct {
+	// Heightmap is the heightmap with the river channels carved into it
+	Heightmap NoiseMap
+
+	// Mask is 1.0 at the center of a river channel and falls off to 0.0 at its banks
+	Mask NoiseMap
+
+	// Paths are the grid points each river flows through from its spring
+	Paths [][]Vec2i
+}
+
+// NewRiverCarver creates a new river carver with parameters that work well
+// for heightmaps with values in the range [-1, 1].
+func NewRiverCarver(rng RandomSource) (rc RiverCarver) {
+	rc.Rng = rng
+	rc.Springs = 20
+	rc.MinSpringHeight = 0.4
+	rc.SeaLevel = 0.0
+	rc.MaxLength = 1000
+	rc.SpringWidth = 1.0
+	rc.MouthWidth = 3.0
+	rc.Depth = 0.02
+	rc.Falloff = 1.5
+	return
+}
+
+// String returns a description of the river parameters.
+func (rc *RiverCarver) String() string {
+	return fmt.Sprintf("RiverCarver{Springs: %v, MinSpringHeight: %v, SeaLevel: %v, MaxLength: %v, SpringWidth: %v, MouthWidth: %v, Depth: %v, Falloff: %v}",
+		rc.Springs, rc.MinSpringHeight, rc.SeaLevel, rc.MaxLength, rc.SpringWidth, rc.MouthWidth, rc.Depth, rc.Falloff)
+}
+
+// tracePath follows the steepest descent from the spring until the river
+// reaches the sea, the edge of the map, another river or MaxLength. When it
+// gets stuck in a pit it climbs out through the lowest unvisited neighbor.
+func (rc *RiverCarver) tracePath(hm *NoiseMap, river []int, spring Vec2i) []Vec2i {
+	w, h := hm.Width, hm.Height
+	path := []Vec2i{spring}
+	visited := map[int]bool{spring.Y*w + spring.X: true}
+	cur := spring
+
+	for len(path) < rc.MaxLength {
+		if hm.Values[cur.Y*w+cur.X] < rc.SeaLevel {
+			break
+		}
+		if cur.X == 0 || cur.Y == 0 || cur.X == w-1 || cur.Y == h-1 {
+			break
+		}
+
+		best := -1
+		bestHeight := math.MaxFloat64
+		for _, nb := range thermalNeighbors {
+			n := (cur.Y+nb.dy)*w + cur.X + nb.dx
+			if visited[n] {
+				continue
+			}
+			if hm.Values[n] < bestHeight {
+				best = n
+				bestHeight = hm.Values[n]
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		visited[best] = true
+		cur = Vec2i{best % w, best / w}
+		path = append(path, cur)
+		if river[best] >= 0 {
+			// joined a river that was traced earlier
+			break
+		}
+	}
+
+	return path
+}
+
+// Carve traces rivers over a copy of heightmap and returns the carved copy,
+// the river mask and the paths of the rivers.
+func (rc *RiverCarver) Carve(heightmap *NoiseMap) (result RiverResult, err error) {
+	if rc.Rng == nil {
+		return result, fmt.Errorf("Unable to carve rivers without a random source.\n")
+	}
+	if heightmap.Width < 3 || heightmap.Height < 3 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return result, fmt.Errorf("Unable to carve rivers in a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+
+	result.Heightmap = heightmap.Clone()
+	result.Mask = NewNoiseMap(heightmap.Width, heightmap.Height, heightmap.Bounds)
+	hm := &result.Heightmap
+	w, h := hm.Width, hm.Height
+
+	// river holds the index of the river that flows through each grid point or -1
+	river := make([]int, len(hm.Values))
+	for i := range river {
+		river[i] = -1
+	}
+
+	for s := 0; s < rc.Springs; s++ {
+		spring := Vec2i{int(rc.Rng.Float64() * float64(w)), int(rc.Rng.Float64() * float64(h))}
+		i := spring.Y*w + spring.X
+		if heightmap.Values[i] <= rc.MinSpringHeight || river[i] >= 0 {
+			continue
+		}
+
+		path := rc.tracePath(heightmap, river, spring)
+		if len(path) < 2 {
+			continue
+		}
+		for _, p := range path {
+			if river[p.Y*w+p.X] < 0 {
+				river[p.Y*w+p.X] = len(result.Paths)
+			}
+		}
+		result.Paths = append(result.Paths, path)
+
+		// carve the channel, keeping the bed from ever rising downstream
+		bed := math.MaxFloat64
+		for step, p := range path {
+			bed = math.Min(bed, heightmap.Values[p.Y*w+p.X])
+			t := float64(step) / float64(len(path)-1)
+			radius := rc.SpringWidth + (rc.MouthWidth-rc.SpringWidth)*t
+			reach := int(math.Ceil(radius))
+
+			for dy := -reach; dy <= reach; dy++ {
+				for dx := -reach; dx <= reach; dx++ {
+					x, y := p.X+dx, p.Y+dy
+					if x < 0 || x >= w || y < 0 || y >= h {
+						continue
+					}
+					d := math.Sqrt(float64(dx*dx + dy*dy))
+					if d >= radius {
+						continue
+					}
+					f := math.Pow(1.0-d/radius, rc.Falloff)
+					c := y*w + x
+					hm.Values[c] = math.Min(hm.Values[c], lerp(hm.Values[c], bed-rc.Depth, f))
+					result.Mask.Values[c] = math.Max(result.Mask.Values[c], f)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,226 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains implementations of the RandomSource interface on top of
+random number generators other than Go's built in math/rand.
+
+Reference material:
+* PCG: http://www.pcg-random.org/
+* xoshiro256**: http://xoshiro.di.unimi.it/
+* SplitMix64: http://xoshiro.di.unimi.it/splitmix64.c
+
+Perm() on all of these generators is a Fisher-Yates shuffle that uses
+rejection sampling so that every permutation is equally likely.
+
+SplitMixSource is the generator to use when noise must never change. Its
+output is completely specified by this module and does not depend on the Go
+version or platform, so worlds generated from a seed will stay the same.
+
+*/
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// uint64ToFloat64 takes the high 53 bits of u and returns them as a float64 in [0,1).
+func uint64ToFloat64(u uint64) float64 {
+	return float64(u>>11) / (1 << 53)
+}
+
+// uint64n returns an unbiased random number in [0,n) using next() as the source of bits.
+func uint64n(n uint64, next func() uint64) uint64 {
+	// reject the values at the bottom of the range that would
+	// make some results more likely than others
+	threshold := -n % n
+	for {
+		r := next()
+		if r >= threshold {
+			return r % n
+		}
+	}
+}
+
+// permUint64 returns a random permutation of the integers [0,n) using next()
+// as the source of bits.
+func permUint64(n int, next func() uint64) []int {
+	m := make([]int, n)
+	for i := range m {
+		m[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := int(uint64n(uint64(i+1), next))
+		m[i], m[j] = m[j], m[i]
+	}
+	return m
+}
+
+// splitMix64 advances state and returns the next SplitMix64 output.
+func splitMix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// PCGSource is a RandomSource using the PCG32 (XSH RR) generator.
+type PCGSource struct {
+	state uint64
+	inc   uint64
+}
+
+// NewPCGSource creates a new PCG32 generator seeded with seed. Generators
+// with the same seed but different stream values produce different sequences.
+func NewPCGSource(seed uint64, stream uint64) *PCGSource {
+	pcg := new(PCGSource)
+	pcg.inc = (stream << 1) | 1
+	pcg.Uint32()
+	pcg.state += seed
+	pcg.Uint32()
+	return pcg
+}
+
+// Uint32 returns the next 32 random bits.
+func (pcg *PCGSource) Uint32() uint32 {
+	old := pcg.state
+	pcg.state = old*6364136223846793005 + pcg.inc
+	xorshifted := uint32(((old >> 18) ^ old) >> 27)
+	rot := uint32(old >> 59)
+	return (xorshifted >> rot) | (xorshifted << ((-rot) & 31))
+}
+
+// Uint64 returns the next 64 random bits.
+func (pcg *PCGSource) Uint64() uint64 {
+	return uint64(pcg.Uint32())<<32 | uint64(pcg.Uint32())
+}
+
+// Float64 returns a random number in [0,1).
+func (pcg *PCGSource) Float64() float64 {
+	return uint64ToFloat64(pcg.Uint64())
+}
+
+// Perm returns a random permutation of the integers [0,n).
+func (pcg *PCGSource) Perm(n int) []int {
+	return permUint64(n, pcg.Uint64)
+}
+
+// Xoshiro256Source is a RandomSource using the xoshiro256** generator.
+type Xoshiro256Source struct {
+	s [4]uint64
+}
+
+// NewXoshiro256Source creates a new xoshiro256** generator with its state
+// initialized from seed using SplitMix64 as recommended by the authors.
+func NewXoshiro256Source(seed uint64) *Xoshiro256Source {
+	xs := new(Xoshiro256Source)
+	for i := range xs.s {
+		xs.s[i] = splitMix64(&seed)
+	}
+	return xs
+}
+
+func rotl64(x uint64, k uint) uint64 {
+	return (x << k) | (x >> (64 - k))
+}
+
+// Uint64 returns the next 64 random bits.
+func (xs *Xoshiro256Source) Uint64() uint64 {
+	s := &xs.s
+	result := rotl64(s[1]*5, 7) * 9
+	t := s[1] << 17
+
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = rotl64(s[3], 45)
+
+	return result
+}
+
+// Float64 returns a random number in [0,1).
+func (xs *Xoshiro256Source) Float64() float64 {
+	return uint64ToFloat64(xs.Uint64())
+}
+
+// Perm returns a random permutation of the integers [0,n).
+func (xs *Xoshiro256Source) Perm(n int) []int {
+	return permUint64(n, xs.Uint64)
+}
+
+// CryptoSource is a RandomSource that reads from crypto/rand. It cannot be
+// seeded so the noise it produces will be different every time. Since the
+// RandomSource interface has no way to report errors, CryptoSource will panic
+// if the operating system fails to supply random bytes.
+type CryptoSource struct{}
+
+// NewCryptoSource creates a new RandomSource reading from crypto/rand.
+func NewCryptoSource() CryptoSource {
+	return CryptoSource{}
+}
+
+// Uint64 returns the next 64 random bits.
+func (cs CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("noisey: unable to read from crypto/rand: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Float64 returns a random number in [0,1).
+func (cs CryptoSource) Float64() float64 {
+	return uint64ToFloat64(cs.Uint64())
+}
+
+// Perm returns a random permutation of the integers [0,n).
+func (cs CryptoSource) Perm(n int) []int {
+	return permUint64(n, cs.Uint64)
+}
+
+// SplitMixSource is a deterministic RandomSource built on SplitMix64 whose
+// output is guaranteed to be stable across Go versions and platforms:
+//
+//	Uint64  - the next SplitMix64 output for the state
+//	Float64 - the high 53 bits of Uint64() divided by 2^53
+//	Perm    - start with [0,1,...,n-1] and for i from n-1 down to 1 swap
+//	          element i with element j, where j is Uint64() % (i+1) after
+//	          discarding outputs below (2^64 - (i+1)) % (i+1)
+//
+// These definitions will not change.
+type SplitMixSource struct {
+	state uint64
+}
+
+// NewSplitMixSource creates a new deterministic generator seeded with seed.
+func NewSplitMixSource(seed uint64) *SplitMixSource {
+	return &SplitMixSource{state: seed}
+}
+
+// SplitMixSeedBuilder is a RandomSeedBuilder for NoiseJSON.BuildSources() that
+// creates SplitMixSource generators so that noise built from a JSON file is
+// stable across Go versions and platforms.
+func SplitMixSeedBuilder(s int64) RandomSource {
+	return NewSplitMixSource(uint64(s))
+}
+
+// Uint64 returns the next 64 random bits.
+func (sm *SplitMixSource) Uint64() uint64 {
+	return splitMix64(&sm.state)
+}
+
+// Float64 returns a random number in [0,1).
+func (sm *SplitMixSource) Float64() float64 {
+	return uint64ToFloat64(sm.Uint64())
+}
+
+// Perm returns a random permutation of the integers [0,n).
+func (sm *SplitMixSource) Perm(n int) []int {
+	return permUint64(n, sm.Uint64)
+}
@@ -0,0 +1,26 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplitMixStable makes sure the output of SplitMixSource never changes.
+func TestSplitMixStable(t *testing.T) {
+	sm := NewSplitMixSource(1234567)
+	expected := []uint64{6457827717110365317, 3203168211198807973, 9817491932198370423, 4593380528125082431, 16408922859458223821}
+	for i, e := range expected {
+		if v := sm.Uint64(); v != e {
+			t.Errorf("Uint64() #%d returned %d; expected %d", i, v, e)
+		}
+	}
+
+	perm := NewSplitMixSource(1).Perm(10)
+	expectedPerm := []int{4, 2, 8, 1, 9, 3, 0, 6, 7, 5}
+	if !reflect.DeepEqual(perm, expectedPerm) {
+		t.Errorf("Perm(10) returned %v; expected %v", perm, expectedPerm)
+	}
+}
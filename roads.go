@@ -0,0 +1,207 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module lays roads or paths between pairs of waypoints over a heightmap.
+The course of each road starts as a straight line and is pushed sideways by
+a noise source so that it wanders, with the wander fading out towards the
+waypoints so roads always meet them. The result is a mask that is 1.0 on the
+road and falls off smoothly to 0.0 beside it:
+
+	roads := noisey.NewRoadGenerator(&perlin)
+	roads.Width = 4.0
+	result, err := roads.Build(&heightmap, [][2]noisey.Vec2f{{village1, village2}})
+
+If Flatten is set the heightmap is blended towards the height of the road
+under the mask. The road height is the height of the terrain along its course
+smoothed GradeSmoothing times so that it climbs and descends gently.
+
+All distances are in the world coordinates of the Bounds of the heightmap.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// RoadGenerator contains the parameters for laying roads.
+type RoadGenerator struct {
+	Noise          NoiseyGet2D // the noise that pushes the roads sideways; nil makes straight roads
+	Frequency      float64     // the frequency the noise is sampled at
+	Wander         float64     // how far in world units the noise can push a road sideways
+	Step           float64     // the distance between the points of a road
+	Width          float64     // the width of the part of the road where the mask is 1.0
+	Falloff        float64     // the distance beside the road over which the mask falls to 0.0
+	Flatten        bool        // whether the heightmap is flattened under the roads
+	GradeSmoothing int         // how many times the road height is smoothed along the road
+}
+
+// RoadResult is the output of RoadGenerator.Build().
+type RoadResult struct {
+	Heightmap NoiseMap  // a copy of the heightmap, flattened under the roads if Flatten is set
+	Mask      NoiseMap  // 1.0 on the roads falling off to 0.0 beside them
+	Paths     [][]Vec2f // the course of each road from its first waypoint to its second
+}
+
+// NewRoadGenerator creates a new road generator with parameters for a
+// heightmap with a cell size of about 1.0.
+func NewRoadGenerator(noise NoiseyGet2D) (rg RoadGenerator) {
+	rg.Noise = noise
+	rg.Frequency = 0.02
+	rg.Wander = 20.0
+	rg.Step = 1.0
+	rg.Width = 3.0
+	rg.Falloff = 3.0
+	rg.Flatten = true
+	rg.GradeSmoothing = 20
+	return
+}
+
+// String returns a description of the generator and its noise.
+func (rg *RoadGenerator) String() string {
+	return fmt.Sprintf("RoadGenerator{Frequency: %v, Wander: %v, Step: %v, Width: %v, Falloff: %v, Flatten: %v, GradeSmoothing: %v, Noise: %s}",
+		rg.Frequency, rg.Wander, rg.Step, rg.Width, rg.Falloff, rg.Flatten, rg.GradeSmoothing, describeModule(rg.Noise))
+}
+
+// Path returns the course of a road between two waypoints.
+func (rg *RoadGenerator) Path(from Vec2f, to Vec2f) []Vec2f {
+	dir := to.Sub(from)
+	length := dir.Length()
+	steps := 1
+	if rg.Step > 0.0 {
+		steps = maxInt(1, int(math.Ceil(length/rg.Step)))
+	}
+	var side Vec2f
+	if length > 0.0 {
+		side = Vec2f{-dir.Y / length, dir.X / length}
+	}
+
+	path := make([]Vec2f, steps+1)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		p := from.Lerp(to, t)
+		if rg.Noise != nil && rg.Wander != 0.0 {
+			// the wander fades out towards both waypoints
+			n := rg.Noise.Get2D(p.X*rg.Frequency, p.Y*rg.Frequency)
+			p = p.Add(side.Scale(n * rg.Wander * math.Sin(t*math.Pi)))
+		}
+		path[i] = p
+	}
+	return path
+}
+
+// closestOnPath returns the distance from p to the path and the index and
+// offset [0, 1] of the closest point along the segment it's on.
+func closestOnPath(path []Vec2f, p Vec2f) (dist float64, seg int, t float64) {
+	dist = math.Inf(1)
+	for i := 0; i+1 < len(path); i++ {
+		a, b := path[i], path[i+1]
+		ab := b.Sub(a)
+		u := 0.0
+		if l2 := ab.Dot(ab); l2 > 0.0 {
+			u = math.Max(0.0, math.Min(1.0, p.Sub(a).Dot(ab)/l2))
+		}
+		if d := p.Sub(a.Add(ab.Scale(u))).Length(); d < dist {
+			dist, seg, t = d, i, u
+		}
+	}
+	if len(path) == 1 {
+		dist = p.Sub(path[0]).Length()
+	}
+	return
+}
+
+// Build lays a road between each pair of waypoints over the heightmap.
+func (rg *RoadGenerator) Build(heightmap *NoiseMap, waypoints [][2]Vec2f) (result RoadResult, err error) {
+	if heightmap.Width <= 0 || heightmap.Height <= 0 || len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return result, fmt.Errorf("Unable to lay roads on a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+	if rg.Width < 0.0 || rg.Falloff < 0.0 {
+		return result, fmt.Errorf("Unable to lay roads with a Width of %v and a Falloff of %v; they must not be negative.\n", rg.Width, rg.Falloff)
+	}
+
+	result.Heightmap = heightmap.Clone()
+	result.Mask = NewNoiseMap(heightmap.Width, heightmap.Height, heightmap.Bounds)
+	w, h := heightmap.Width, heightmap.Height
+	b := heightmap.Bounds
+	dx, dy := (b.MaxX-b.MinX)/float64(w), (b.MaxY-b.MinY)/float64(h)
+	halfWidth := rg.Width * 0.5
+	reach := halfWidth + rg.Falloff
+
+	// the road heights are found before any road flattens the terrain
+	grades := make([][]float64, len(waypoints))
+	for r, pair := range waypoints {
+		path := rg.Path(pair[0], pair[1])
+		result.Paths = append(result.Paths, path)
+		if !rg.Flatten {
+			continue
+		}
+		grade := make([]float64, len(path))
+		for i, p := range path {
+			grade[i] = heightmap.Get2D(p.X, p.Y)
+		}
+		next := make([]float64, len(grade))
+		for s := 0; s < rg.GradeSmoothing; s++ {
+			next[0], next[len(grade)-1] = grade[0], grade[len(grade)-1]
+			for i := 1; i+1 < len(grade); i++ {
+				next[i] = (grade[i-1] + 2.0*grade[i] + grade[i+1]) * 0.25
+			}
+			grade, next = next, grade
+		}
+		grades[r] = grade
+	}
+
+	// the target height of the most covering road at each point
+	target := make([]float64, w*h)
+	for r, path := range result.Paths {
+		// only the grid points near the road need to be checked
+		minP, maxP := path[0], path[0]
+		for _, p := range path {
+			minP = Vec2f{math.Min(minP.X, p.X), math.Min(minP.Y, p.Y)}
+			maxP = Vec2f{math.Max(maxP.X, p.X), math.Max(maxP.Y, p.Y)}
+		}
+		x0 := maxInt(0, int(math.Floor((minP.X-reach-b.MinX)/dx)))
+		x1 := minInt(w-1, int(math.Ceil((maxP.X+reach-b.MinX)/dx)))
+		y0 := maxInt(0, int(math.Floor((minP.Y-reach-b.MinY)/dy)))
+		y1 := minInt(h-1, int(math.Ceil((maxP.Y+reach-b.MinY)/dy)))
+
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				p := Vec2f{b.MinX + float64(x)*dx, b.MinY + float64(y)*dy}
+				dist, seg, t := closestOnPath(path, p)
+				if dist > reach {
+					continue
+				}
+				m := 1.0
+				if dist > halfWidth {
+					f := 1.0 - (dist-halfWidth)/rg.Falloff
+					m = f * f * (3.0 - 2.0*f)
+				}
+				i := y*w + x
+				if m > result.Mask.Values[i] {
+					result.Mask.Values[i] = m
+					if rg.Flatten {
+						grade := grades[r]
+						target[i] = grade[seg]
+						if seg+1 < len(grade) {
+							target[i] = lerp(grade[seg], grade[seg+1], t)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if rg.Flatten {
+		for i, m := range result.Mask.Values {
+			if m > 0.0 {
+				result.Heightmap.Values[i] = lerp(result.Heightmap.Values[i], target[i], m)
+			}
+		}
+	}
+	return result, nil
+}
@@ -3,7 +3,10 @@ package noisey
 /* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
 See the LICENSE file for more details. */
 
-import "math"
+import (
+  "fmt"
+  "math"
+)
 
 // Scale2D is a module that uses gets the noise from Source, scales
 // it and then adds a bias.
@@ -34,6 +37,53 @@ func NewScale2D(src NoiseyGet2D, scale float64, bias float64, min float64, max f
   return
 }
 
+// Clone2D returns a deep copy of the module and its Source.
+func (scales *Scale2D) Clone2D() NoiseyGet2D {
+  c := *scales
+  c.Source = DeepCopy2D(scales.Source)
+  return &c
+}
+
+// SetScale validates and changes the scale.
+func (scales *Scale2D) SetScale(scale float64) error {
+  if err := validateParam(GenScale2D, "Scale", scale); err != nil {
+    return err
+  }
+  scales.Scale = scale
+  return nil
+}
+
+// SetBias validates and changes the bias.
+func (scales *Scale2D) SetBias(bias float64) error {
+  if err := validateParam(GenScale2D, "Bias", bias); err != nil {
+    return err
+  }
+  scales.Bias = bias
+  return nil
+}
+
+// SetRange validates and changes the minimum and maximum values to return.
+func (scales *Scale2D) SetRange(min float64, max float64) error {
+  if err := validateParam(GenScale2D, "Min", min); err != nil {
+    return err
+  }
+  if err := validateParam(GenScale2D, "Max", max); err != nil {
+    return err
+  }
+  if min > max {
+    return fmt.Errorf("Min (%v) must not be greater than Max (%v).\n", min, max)
+  }
+  scales.Min = min
+  scales.Max = max
+  return nil
+}
+
+// String returns a description of the module and its Source.
+func (scales *Scale2D) String() string {
+  return fmt.Sprintf("Scale2D{Scale: %v, Bias: %v, Min: %v, Max: %v, Source: %s}",
+    scales.Scale, scales.Bias, scales.Min, scales.Max, describeModule(scales.Source))
+}
+
 // Get2D calculates the noise value scaling it by Scale and adding Bias
 func (scales *Scale2D) Get2D(x float64, y float64) (v float64) {
   v = scales.Source.Get2D(x, y)
@@ -43,3 +93,10 @@ func (scales *Scale2D) Get2D(x float64, y float64) (v float64) {
   v = math.Min(scales.Max, v)
   return v
 }
+
+// Range2D returns the bounds of the values of Get2D().
+func (scales *Scale2D) Range2D() (min float64, max float64) {
+  min, max = ValueRange2D(scales.Source)
+  min, max = scaleRange(min, max, scales.Scale)
+  return clampRange(min+scales.Bias, max+scales.Bias, scales.Min, scales.Max)
+}
@@ -3,6 +3,8 @@ package noisey
 /* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
 See the LICENSE file for more details. */
 
+import "fmt"
+
 // Select2D is a module that uses SourcesA or SourceB depending
 // on the value coming from Control. If the value from control is between
 // LowerBound and UpperBound then it uses SourceB, but otherwise it will
@@ -44,6 +46,47 @@ func NewSelect2D(a, b, c NoiseyGet2D, lower float64, upper float64, edge float64
 	return
 }
 
+// Clone2D returns a deep copy of the selector and all of its sources.
+func (selector *Select2D) Clone2D() NoiseyGet2D {
+	c := *selector
+	c.SourceA = DeepCopy2D(selector.SourceA)
+	c.SourceB = DeepCopy2D(selector.SourceB)
+	c.Control = DeepCopy2D(selector.Control)
+	return &c
+}
+
+// SetBounds validates and changes the control range that selects SourceB.
+func (selector *Select2D) SetBounds(lower float64, upper float64) error {
+	if err := validateParam(GenSelect2D, "LowerBound", lower); err != nil {
+		return err
+	}
+	if err := validateParam(GenSelect2D, "UpperBound", upper); err != nil {
+		return err
+	}
+	if lower > upper {
+		return fmt.Errorf("LowerBound (%v) must not be greater than UpperBound (%v).\n", lower, upper)
+	}
+	selector.LowerBound = lower
+	selector.UpperBound = upper
+	return nil
+}
+
+// SetEdgeFalloff validates and changes the width of the transition between sources.
+func (selector *Select2D) SetEdgeFalloff(edge float64) error {
+	if err := validateParam(GenSelect2D, "EdgeFalloff", edge); err != nil {
+		return err
+	}
+	selector.EdgeFalloff = edge
+	return nil
+}
+
+// String returns a description of the selector and all of its sources.
+func (selector *Select2D) String() string {
+	return fmt.Sprintf("Select2D{LowerBound: %v, UpperBound: %v, EdgeFalloff: %v, SourceA: %s, SourceB: %s, Control: %s}",
+		selector.LowerBound, selector.UpperBound, selector.EdgeFalloff,
+		describeModule(selector.SourceA), describeModule(selector.SourceB), describeModule(selector.Control))
+}
+
 // Get2D calculates the noise value using SourceA or SourceB depending on Control.
 func (selector *Select2D) Get2D(x float64, y float64) (v float64) {
 	control := selector.Control.Get2D(x, y)
@@ -84,6 +127,14 @@ func (selector *Select2D) Get2D(x float64, y float64) (v float64) {
 	return selector.SourceA.Get2D(x, y)
 }
 
+// Range2D returns the bounds of the values of Get2D(), which cover those of
+// both sources since the blends stay between them.
+func (selector *Select2D) Range2D() (min float64, max float64) {
+	amin, amax := ValueRange2D(selector.SourceA)
+	bmin, bmax := ValueRange2D(selector.SourceB)
+	return unionRange(amin, amax, bmin, bmax)
+}
+
 // Select3D is a module that uses SourcesA or SourceB depending
 // on the value coming from Control. If the value from control is between
 // LowerBound and UpperBound then it uses SourceB, but otherwise it will
@@ -125,6 +176,47 @@ func NewSelect3D(a, b, c NoiseyGet3D, lower float64, upper float64, edge float64
 	return
 }
 
+// Clone3D returns a deep copy of the selector and all of its sources.
+func (selector *Select3D) Clone3D() NoiseyGet3D {
+	c := *selector
+	c.SourceA = DeepCopy3D(selector.SourceA)
+	c.SourceB = DeepCopy3D(selector.SourceB)
+	c.Control = DeepCopy3D(selector.Control)
+	return &c
+}
+
+// SetBounds validates and changes the control range that selects SourceB.
+func (selector *Select3D) SetBounds(lower float64, upper float64) error {
+	if err := validateParam(GenSelect2D, "LowerBound", lower); err != nil {
+		return err
+	}
+	if err := validateParam(GenSelect2D, "UpperBound", upper); err != nil {
+		return err
+	}
+	if lower > upper {
+		return fmt.Errorf("LowerBound (%v) must not be greater than UpperBound (%v).\n", lower, upper)
+	}
+	selector.LowerBound = lower
+	selector.UpperBound = upper
+	return nil
+}
+
+// SetEdgeFalloff validates and changes the width of the transition between sources.
+func (selector *Select3D) SetEdgeFalloff(edge float64) error {
+	if err := validateParam(GenSelect2D, "EdgeFalloff", edge); err != nil {
+		return err
+	}
+	selector.EdgeFalloff = edge
+	return nil
+}
+
+// String returns a description of the selector and all of its sources.
+func (selector *Select3D) String() string {
+	return fmt.Sprintf("Select3D{LowerBound: %v, UpperBound: %v, EdgeFalloff: %v, SourceA: %s, SourceB: %s, Control: %s}",
+		selector.LowerBound, selector.UpperBound, selector.EdgeFalloff,
+		describeModule(selector.SourceA), describeModule(selector.SourceB), describeModule(selector.Control))
+}
+
 // Get3D calculates the noise value using SourceA or SourceB depending on Control.
 func (selector *Select3D) Get3D(x, y, z float64) (v float64) {
 	control := selector.Control.Get3D(x, y, z)
@@ -164,3 +256,11 @@ func (selector *Select3D) Get3D(x, y, z float64) (v float64) {
 	}
 	return selector.SourceA.Get3D(x, y, z)
 }
+
+// Range3D returns the bounds of the values of Get3D(), which cover those of
+// both sources since the blends stay between them.
+func (selector *Select3D) Range3D() (min float64, max float64) {
+	amin, amax := ValueRange3D(selector.SourceA)
+	bmin, bmax := ValueRange3D(selector.SourceB)
+	return unionRange(amin, amax, bmin, bmax)
+}
@@ -0,0 +1,76 @@
+//go:build grpc
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package server
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/tbogdala/noisey"
+	"github.com/tbogdala/noisey/server/noiseypb"
+)
+
+// grpcServer serves a Service as the Noisey service of noisey.proto.
+type grpcServer struct {
+	noiseypb.UnimplementedNoiseyServer
+	svc *Service
+}
+
+// Register adds the Noisey service of the Service to a gRPC server.
+func Register(s *grpc.Server, svc *Service) {
+	noiseypb.RegisterNoiseyServer(s, &grpcServer{svc: svc})
+}
+
+// invalid returns an error of the service as a gRPC status.
+func invalid(err error) error {
+	return status.Error(codes.InvalidArgument, strings.TrimSpace(err.Error()))
+}
+
+func (g *grpcServer) Load(ctx context.Context, req *noiseypb.LoadRequest) (*noiseypb.LoadResponse, error) {
+	id, generators, err := g.svc.Load(req.GetConfig())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return &noiseypb.LoadResponse{Id: id, Generators: generators}, nil
+}
+
+func (g *grpcServer) Unload(ctx context.Context, req *noiseypb.UnloadRequest) (*noiseypb.UnloadResponse, error) {
+	g.svc.Unload(req.GetId())
+	return &noiseypb.UnloadResponse{}, nil
+}
+
+func (g *grpcServer) Sample(ctx context.Context, req *noiseypb.SampleRequest) (*noiseypb.SampleResponse, error) {
+	values, err := g.svc.Sample(req.GetId(), req.GetGenerator(), req.GetX(), req.GetY())
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return &noiseypb.SampleResponse{Values: values}, nil
+}
+
+func (g *grpcServer) Region(ctx context.Context, req *noiseypb.RegionRequest) (*noiseypb.RegionResponse, error) {
+	bounds := noisey.Builder2DBounds{
+		MinX: req.GetMinX(),
+		MinY: req.GetMinY(),
+		MaxX: req.GetMaxX(),
+		MaxY: req.GetMaxY(),
+	}
+	tile, err := g.svc.Region(req.GetId(), req.GetGenerator(), bounds, int(req.GetWidth()), int(req.GetHeight()), TileEncoding(req.GetEncoding()))
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return &noiseypb.RegionResponse{
+		Width:    int32(tile.Width),
+		Height:   int32(tile.Height),
+		Encoding: noiseypb.TileEncoding(tile.Encoding),
+		Min:      tile.Min,
+		Max:      tile.Max,
+		Data:     tile.Data,
+	}, nil
+}
@@ -0,0 +1,206 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package server
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the buckets of the
+// evaluation time histograms, from a small tile to a large region.
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0}
+
+// metricsKey is the pipeline and generator of a histogram.
+type metricsKey struct {
+	pipeline, generator string
+}
+
+// histogram counts observations in buckets.
+type histogram struct {
+	counts []uint64 // the observations in each bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Metrics counts the tiles that are rendered and the hits and misses of tile
+// caches, and keeps histograms of how long the generators of the pipelines
+// take to evaluate. It serves them in the Prometheus text format, so
+// Prometheus can scrape it without a client library:
+//
+//	noisey_tiles_rendered_total
+//	noisey_tile_cache_hits_total
+//	noisey_tile_cache_misses_total
+//	noisey_evaluation_seconds{pipeline="...",generator="..."}
+//
+// Publish() adds the same values to expvar. It's safe to use from several
+// goroutines at once.
+type Metrics struct {
+	// Buckets are the upper bounds in seconds of the histogram buckets; they
+	// shouldn't be changed once something was observed
+	Buckets []float64
+
+	tilesRendered uint64
+	cacheHits     uint64
+	cacheMisses   uint64
+
+	lock       sync.Mutex
+	histograms map[metricsKey]*histogram
+}
+
+// NewMetrics creates new metrics with the DefaultBuckets.
+func NewMetrics() (m *Metrics) {
+	m = new(Metrics)
+	m.Buckets = DefaultBuckets
+	m.histograms = make(map[metricsKey]*histogram)
+	return m
+}
+
+// TileRendered counts a rendered tile.
+func (m *Metrics) TileRendered() {
+	atomic.AddUint64(&m.tilesRendered, 1)
+}
+
+// CacheHit counts a tile that was found in a cache.
+func (m *Metrics) CacheHit() {
+	atomic.AddUint64(&m.cacheHits, 1)
+}
+
+// CacheMiss counts a tile that wasn't found in a cache.
+func (m *Metrics) CacheMiss() {
+	atomic.AddUint64(&m.cacheMisses, 1)
+}
+
+// Observe records that evaluating a generator of a pipeline took d.
+func (m *Metrics) Observe(pipeline string, generator string, d time.Duration) {
+	seconds := d.Seconds()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := metricsKey{pipeline, generator}
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.Buckets))}
+		m.histograms[key] = h
+	}
+	h.count++
+	h.sum += seconds
+	i := sort.SearchFloat64s(m.Buckets, seconds)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+}
+
+// HitRate returns the share of tiles that were found in a cache, or 0 if
+// none were looked up.
+func (m *Metrics) HitRate() float64 {
+	hits := atomic.LoadUint64(&m.cacheHits)
+	misses := atomic.LoadUint64(&m.cacheMisses)
+	if hits+misses == 0 {
+		return 0.0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// keys returns the keys of the histograms in order. It's called with the
+// lock held.
+func (m *Metrics) keys() []metricsKey {
+	keys := make([]metricsKey, 0, len(m.histograms))
+	for key := range m.histograms {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pipeline != keys[j].pipeline {
+			return keys[i].pipeline < keys[j].pipeline
+		}
+		return keys[i].generator < keys[j].generator
+	})
+	return keys
+}
+
+// formatMetric formats a value the way the Prometheus text format does.
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// quoteLabel escapes a label value of the Prometheus text format.
+func quoteLabel(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return strings.Replace(s, `"`, `\"`, -1)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	counter := func(name string, help string, v uint64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("noisey_tiles_rendered_total", "Tiles and regions that were rendered.", atomic.LoadUint64(&m.tilesRendered))
+	counter("noisey_tile_cache_hits_total", "Tiles that were found in a cache.", atomic.LoadUint64(&m.cacheHits))
+	counter("noisey_tile_cache_misses_total", "Tiles that weren't found in a cache.", atomic.LoadUint64(&m.cacheMisses))
+
+	b.WriteString("# HELP noisey_evaluation_seconds How long the generators of the pipelines took to evaluate.\n")
+	b.WriteString("# TYPE noisey_evaluation_seconds histogram\n")
+	m.lock.Lock()
+	for _, key := range m.keys() {
+		h := m.histograms[key]
+		labels := fmt.Sprintf(`pipeline="%s",generator="%s"`, quoteLabel(key.pipeline), quoteLabel(key.generator))
+		var cumulative uint64
+		for i, le := range m.Buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "noisey_evaluation_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatMetric(le), cumulative)
+		}
+		fmt.Fprintf(&b, "noisey_evaluation_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "noisey_evaluation_seconds_sum{%s} %s\n", labels, formatMetric(h.sum))
+		fmt.Fprintf(&b, "noisey_evaluation_seconds_count{%s} %d\n", labels, h.count)
+	}
+	m.lock.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// expvarHistogram is a histogram as it's published to expvar.
+type expvarHistogram struct {
+	Pipeline  string            `json:"pipeline"`
+	Generator string            `json:"generator"`
+	Count     uint64            `json:"count"`
+	Sum       float64           `json:"sum"`
+	Buckets   map[string]uint64 `json:"buckets"` // the cumulative counts by upper bound
+}
+
+// Publish adds the metrics to expvar under the name. Like expvar.Publish(),
+// it panics if the name is already used.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		var histograms []expvarHistogram
+		for _, key := range m.keys() {
+			h := m.histograms[key]
+			eh := expvarHistogram{Pipeline: key.pipeline, Generator: key.generator, Count: h.count, Sum: h.sum,
+				Buckets: make(map[string]uint64)}
+			var cumulative uint64
+			for i, le := range m.Buckets {
+				cumulative += h.counts[i]
+				eh.Buckets[formatMetric(le)] = cumulative
+			}
+			histograms = append(histograms, eh)
+		}
+		return map[string]interface{}{
+			"tiles_rendered":   atomic.LoadUint64(&m.tilesRendered),
+			"cache_hits":       atomic.LoadUint64(&m.cacheHits),
+			"cache_misses":     atomic.LoadUint64(&m.cacheMisses),
+			"cache_hit_rate":   m.HitRate(),
+			"evaluation_times": histograms,
+		}
+	}))
+}
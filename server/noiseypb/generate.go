@@ -0,0 +1,17 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package noiseypb holds the protobuf messages and the gRPC service of the
+noise server, generated from noisey.proto by protoc with the
+protoc-gen-go and protoc-gen-go-grpc plugins. The generated files are kept
+in the repository so that building with the grpc tag doesn't need protoc;
+after changes to noisey.proto they're generated again with:
+
+	go generate github.com/tbogdala/noisey/server/noiseypb
+
+Clients in other languages generate theirs from noisey.proto the same way.
+*/
+package noiseypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative noisey.proto
@@ -0,0 +1,680 @@
+// Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// The noise service of github.com/tbogdala/noisey/server, which evaluates
+// NoiseJSON pipelines for clients in other languages.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: noisey.proto
+
+package noiseypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The encodings of RegionResponse.data.
+type TileEncoding int32
+
+const (
+	// little endian float32 values in rows from the top, compressed with zlib
+	TileEncoding_TILE_FLOAT32_ZLIB TileEncoding = 0
+	// a 16 bit grayscale PNG of the values in [-1, 1]
+	TileEncoding_TILE_PNG16 TileEncoding = 1
+)
+
+// Enum value maps for TileEncoding.
+var (
+	TileEncoding_name = map[int32]string{
+		0: "TILE_FLOAT32_ZLIB",
+		1: "TILE_PNG16",
+	}
+	TileEncoding_value = map[string]int32{
+		"TILE_FLOAT32_ZLIB": 0,
+		"TILE_PNG16":        1,
+	}
+)
+
+func (x TileEncoding) Enum() *TileEncoding {
+	p := new(TileEncoding)
+	*p = x
+	return p
+}
+
+func (x TileEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TileEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_noisey_proto_enumTypes[0].Descriptor()
+}
+
+func (TileEncoding) Type() protoreflect.EnumType {
+	return &file_noisey_proto_enumTypes[0]
+}
+
+func (x TileEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TileEncoding.Descriptor instead.
+func (TileEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{0}
+}
+
+type LoadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the NoiseJSON configuration
+	Config        []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadRequest) Reset() {
+	*x = LoadRequest{}
+	mi := &file_noisey_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadRequest) ProtoMessage() {}
+
+func (x *LoadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadRequest.ProtoReflect.Descriptor instead.
+func (*LoadRequest) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LoadRequest) GetConfig() []byte {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type LoadResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the id of the pipeline, the SHA-256 of the configuration in hex
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the names of the generators of the pipeline in order
+	Generators    []string `protobuf:"bytes,2,rep,name=generators,proto3" json:"generators,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoadResponse) Reset() {
+	*x = LoadResponse{}
+	mi := &file_noisey_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadResponse) ProtoMessage() {}
+
+func (x *LoadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadResponse.ProtoReflect.Descriptor instead.
+func (*LoadResponse) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LoadResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LoadResponse) GetGenerators() []string {
+	if x != nil {
+		return x.Generators
+	}
+	return nil
+}
+
+type UnloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnloadRequest) Reset() {
+	*x = UnloadRequest{}
+	mi := &file_noisey_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadRequest) ProtoMessage() {}
+
+func (x *UnloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadRequest.ProtoReflect.Descriptor instead.
+func (*UnloadRequest) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UnloadRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type UnloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnloadResponse) Reset() {
+	*x = UnloadResponse{}
+	mi := &file_noisey_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadResponse) ProtoMessage() {}
+
+func (x *UnloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadResponse.ProtoReflect.Descriptor instead.
+func (*UnloadResponse) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{3}
+}
+
+type SampleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the generator to sample; the last one of the pipeline if empty
+	Generator     string    `protobuf:"bytes,2,opt,name=generator,proto3" json:"generator,omitempty"`
+	X             []float64 `protobuf:"fixed64,3,rep,packed,name=x,proto3" json:"x,omitempty"`
+	Y             []float64 `protobuf:"fixed64,4,rep,packed,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SampleRequest) Reset() {
+	*x = SampleRequest{}
+	mi := &file_noisey_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SampleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SampleRequest) ProtoMessage() {}
+
+func (x *SampleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SampleRequest.ProtoReflect.Descriptor instead.
+func (*SampleRequest) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SampleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SampleRequest) GetGenerator() string {
+	if x != nil {
+		return x.Generator
+	}
+	return ""
+}
+
+func (x *SampleRequest) GetX() []float64 {
+	if x != nil {
+		return x.X
+	}
+	return nil
+}
+
+func (x *SampleRequest) GetY() []float64 {
+	if x != nil {
+		return x.Y
+	}
+	return nil
+}
+
+type SampleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float64              `protobuf:"fixed64,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SampleResponse) Reset() {
+	*x = SampleResponse{}
+	mi := &file_noisey_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SampleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SampleResponse) ProtoMessage() {}
+
+func (x *SampleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SampleResponse.ProtoReflect.Descriptor instead.
+func (*SampleResponse) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SampleResponse) GetValues() []float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type RegionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the generator to bake; the last one of the pipeline if empty
+	Generator     string       `protobuf:"bytes,2,opt,name=generator,proto3" json:"generator,omitempty"`
+	MinX          float64      `protobuf:"fixed64,3,opt,name=min_x,json=minX,proto3" json:"min_x,omitempty"`
+	MinY          float64      `protobuf:"fixed64,4,opt,name=min_y,json=minY,proto3" json:"min_y,omitempty"`
+	MaxX          float64      `protobuf:"fixed64,5,opt,name=max_x,json=maxX,proto3" json:"max_x,omitempty"`
+	MaxY          float64      `protobuf:"fixed64,6,opt,name=max_y,json=maxY,proto3" json:"max_y,omitempty"`
+	Width         int32        `protobuf:"varint,7,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32        `protobuf:"varint,8,opt,name=height,proto3" json:"height,omitempty"`
+	Encoding      TileEncoding `protobuf:"varint,9,opt,name=encoding,proto3,enum=noisey.TileEncoding" json:"encoding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegionRequest) Reset() {
+	*x = RegionRequest{}
+	mi := &file_noisey_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegionRequest) ProtoMessage() {}
+
+func (x *RegionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegionRequest.ProtoReflect.Descriptor instead.
+func (*RegionRequest) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RegionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RegionRequest) GetGenerator() string {
+	if x != nil {
+		return x.Generator
+	}
+	return ""
+}
+
+func (x *RegionRequest) GetMinX() float64 {
+	if x != nil {
+		return x.MinX
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetMinY() float64 {
+	if x != nil {
+		return x.MinY
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetMaxX() float64 {
+	if x != nil {
+		return x.MaxX
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetMaxY() float64 {
+	if x != nil {
+		return x.MaxY
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RegionRequest) GetEncoding() TileEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return TileEncoding_TILE_FLOAT32_ZLIB
+}
+
+type RegionResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Width    int32                  `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height   int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Encoding TileEncoding           `protobuf:"varint,3,opt,name=encoding,proto3,enum=noisey.TileEncoding" json:"encoding,omitempty"`
+	// the lowest and highest values of the region
+	Min           float64 `protobuf:"fixed64,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64 `protobuf:"fixed64,5,opt,name=max,proto3" json:"max,omitempty"`
+	Data          []byte  `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegionResponse) Reset() {
+	*x = RegionResponse{}
+	mi := &file_noisey_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegionResponse) ProtoMessage() {}
+
+func (x *RegionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_noisey_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegionResponse.ProtoReflect.Descriptor instead.
+func (*RegionResponse) Descriptor() ([]byte, []int) {
+	return file_noisey_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RegionResponse) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *RegionResponse) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RegionResponse) GetEncoding() TileEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return TileEncoding_TILE_FLOAT32_ZLIB
+}
+
+func (x *RegionResponse) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *RegionResponse) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *RegionResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_noisey_proto protoreflect.FileDescriptor
+
+const file_noisey_proto_rawDesc = "" +
+	"\n" +
+	"\fnoisey.proto\x12\x06noisey\"%\n" +
+	"\vLoadRequest\x12\x16\n" +
+	"\x06config\x18\x01 \x01(\fR\x06config\">\n" +
+	"\fLoadResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1e\n" +
+	"\n" +
+	"generators\x18\x02 \x03(\tR\n" +
+	"generators\"\x1f\n" +
+	"\rUnloadRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x10\n" +
+	"\x0eUnloadResponse\"Y\n" +
+	"\rSampleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tgenerator\x18\x02 \x01(\tR\tgenerator\x12\f\n" +
+	"\x01x\x18\x03 \x03(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x04 \x03(\x01R\x01y\"(\n" +
+	"\x0eSampleResponse\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x01R\x06values\"\xf1\x01\n" +
+	"\rRegionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tgenerator\x18\x02 \x01(\tR\tgenerator\x12\x13\n" +
+	"\x05min_x\x18\x03 \x01(\x01R\x04minX\x12\x13\n" +
+	"\x05min_y\x18\x04 \x01(\x01R\x04minY\x12\x13\n" +
+	"\x05max_x\x18\x05 \x01(\x01R\x04maxX\x12\x13\n" +
+	"\x05max_y\x18\x06 \x01(\x01R\x04maxY\x12\x14\n" +
+	"\x05width\x18\a \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\b \x01(\x05R\x06height\x120\n" +
+	"\bencoding\x18\t \x01(\x0e2\x14.noisey.TileEncodingR\bencoding\"\xa8\x01\n" +
+	"\x0eRegionResponse\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\x120\n" +
+	"\bencoding\x18\x03 \x01(\x0e2\x14.noisey.TileEncodingR\bencoding\x12\x10\n" +
+	"\x03min\x18\x04 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x05 \x01(\x01R\x03max\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data*5\n" +
+	"\fTileEncoding\x12\x15\n" +
+	"\x11TILE_FLOAT32_ZLIB\x10\x00\x12\x0e\n" +
+	"\n" +
+	"TILE_PNG16\x10\x012\xe6\x01\n" +
+	"\x06Noisey\x121\n" +
+	"\x04Load\x12\x13.noisey.LoadRequest\x1a\x14.noisey.LoadResponse\x127\n" +
+	"\x06Unload\x12\x15.noisey.UnloadRequest\x1a\x16.noisey.UnloadResponse\x127\n" +
+	"\x06Sample\x12\x15.noisey.SampleRequest\x1a\x16.noisey.SampleResponse\x127\n" +
+	"\x06Region\x12\x15.noisey.RegionRequest\x1a\x16.noisey.RegionResponseB,Z*github.com/tbogdala/noisey/server/noiseypbb\x06proto3"
+
+var (
+	file_noisey_proto_rawDescOnce sync.Once
+	file_noisey_proto_rawDescData []byte
+)
+
+func file_noisey_proto_rawDescGZIP() []byte {
+	file_noisey_proto_rawDescOnce.Do(func() {
+		file_noisey_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_noisey_proto_rawDesc), len(file_noisey_proto_rawDesc)))
+	})
+	return file_noisey_proto_rawDescData
+}
+
+var file_noisey_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_noisey_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_noisey_proto_goTypes = []any{
+	(TileEncoding)(0),      // 0: noisey.TileEncoding
+	(*LoadRequest)(nil),    // 1: noisey.LoadRequest
+	(*LoadResponse)(nil),   // 2: noisey.LoadResponse
+	(*UnloadRequest)(nil),  // 3: noisey.UnloadRequest
+	(*UnloadResponse)(nil), // 4: noisey.UnloadResponse
+	(*SampleRequest)(nil),  // 5: noisey.SampleRequest
+	(*SampleResponse)(nil), // 6: noisey.SampleResponse
+	(*RegionRequest)(nil),  // 7: noisey.RegionRequest
+	(*RegionResponse)(nil), // 8: noisey.RegionResponse
+}
+var file_noisey_proto_depIdxs = []int32{
+	0, // 0: noisey.RegionRequest.encoding:type_name -> noisey.TileEncoding
+	0, // 1: noisey.RegionResponse.encoding:type_name -> noisey.TileEncoding
+	1, // 2: noisey.Noisey.Load:input_type -> noisey.LoadRequest
+	3, // 3: noisey.Noisey.Unload:input_type -> noisey.UnloadRequest
+	5, // 4: noisey.Noisey.Sample:input_type -> noisey.SampleRequest
+	7, // 5: noisey.Noisey.Region:input_type -> noisey.RegionRequest
+	2, // 6: noisey.Noisey.Load:output_type -> noisey.LoadResponse
+	4, // 7: noisey.Noisey.Unload:output_type -> noisey.UnloadResponse
+	6, // 8: noisey.Noisey.Sample:output_type -> noisey.SampleResponse
+	8, // 9: noisey.Noisey.Region:output_type -> noisey.RegionResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_noisey_proto_init() }
+func file_noisey_proto_init() {
+	if File_noisey_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_noisey_proto_rawDesc), len(file_noisey_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_noisey_proto_goTypes,
+		DependencyIndexes: file_noisey_proto_depIdxs,
+		EnumInfos:         file_noisey_proto_enumTypes,
+		MessageInfos:      file_noisey_proto_msgTypes,
+	}.Build()
+	File_noisey_proto = out.File
+	file_noisey_proto_goTypes = nil
+	file_noisey_proto_depIdxs = nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+// See the LICENSE file for more details.
+
+// The noise service of github.com/tbogdala/noisey/server, which evaluates
+// NoiseJSON pipelines for clients in other languages.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: noisey.proto
+
+package noiseypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Noisey_Load_FullMethodName   = "/noisey.Noisey/Load"
+	Noisey_Unload_FullMethodName = "/noisey.Noisey/Unload"
+	Noisey_Sample_FullMethodName = "/noisey.Noisey/Sample"
+	Noisey_Region_FullMethodName = "/noisey.Noisey/Region"
+)
+
+// NoiseyClient is the client API for Noisey service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NoiseyClient interface {
+	// Load builds a pipeline from a NoiseJSON configuration.
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error)
+	// Unload forgets a pipeline.
+	Unload(ctx context.Context, in *UnloadRequest, opts ...grpc.CallOption) (*UnloadResponse, error)
+	// Sample evaluates a generator of a pipeline at a list of points.
+	Sample(ctx context.Context, in *SampleRequest, opts ...grpc.CallOption) (*SampleResponse, error)
+	// Region bakes a generator of a pipeline over a rectangle into a tile.
+	Region(ctx context.Context, in *RegionRequest, opts ...grpc.CallOption) (*RegionResponse, error)
+}
+
+type noiseyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNoiseyClient(cc grpc.ClientConnInterface) NoiseyClient {
+	return &noiseyClient{cc}
+}
+
+func (c *noiseyClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoadResponse)
+	err := c.cc.Invoke(ctx, Noisey_Load_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *noiseyClient) Unload(ctx context.Context, in *UnloadRequest, opts ...grpc.CallOption) (*UnloadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnloadResponse)
+	err := c.cc.Invoke(ctx, Noisey_Unload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *noiseyClient) Sample(ctx context.Context, in *SampleRequest, opts ...grpc.CallOption) (*SampleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SampleResponse)
+	err := c.cc.Invoke(ctx, Noisey_Sample_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *noiseyClient) Region(ctx context.Context, in *RegionRequest, opts ...grpc.CallOption) (*RegionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegionResponse)
+	err := c.cc.Invoke(ctx, Noisey_Region_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NoiseyServer is the server API for Noisey service.
+// All implementations must embed UnimplementedNoiseyServer
+// for forward compatibility.
+type NoiseyServer interface {
+	// Load builds a pipeline from a NoiseJSON configuration.
+	Load(context.Context, *LoadRequest) (*LoadResponse, error)
+	// Unload forgets a pipeline.
+	Unload(context.Context, *UnloadRequest) (*UnloadResponse, error)
+	// Sample evaluates a generator of a pipeline at a list of points.
+	Sample(context.Context, *SampleRequest) (*SampleResponse, error)
+	// Region bakes a generator of a pipeline over a rectangle into a tile.
+	Region(context.Context, *RegionRequest) (*RegionResponse, error)
+	mustEmbedUnimplementedNoiseyServer()
+}
+
+// UnimplementedNoiseyServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNoiseyServer struct{}
+
+func (UnimplementedNoiseyServer) Load(context.Context, *LoadRequest) (*LoadResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedNoiseyServer) Unload(context.Context, *UnloadRequest) (*UnloadResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Unload not implemented")
+}
+func (UnimplementedNoiseyServer) Sample(context.Context, *SampleRequest) (*SampleResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Sample not implemented")
+}
+func (UnimplementedNoiseyServer) Region(context.Context, *RegionRequest) (*RegionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Region not implemented")
+}
+func (UnimplementedNoiseyServer) mustEmbedUnimplementedNoiseyServer() {}
+func (UnimplementedNoiseyServer) testEmbeddedByValue()                {}
+
+// UnsafeNoiseyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NoiseyServer will
+// result in compilation errors.
+type UnsafeNoiseyServer interface {
+	mustEmbedUnimplementedNoiseyServer()
+}
+
+func RegisterNoiseyServer(s grpc.ServiceRegistrar, srv NoiseyServer) {
+	// If the following call panics, it indicates UnimplementedNoiseyServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Noisey_ServiceDesc, srv)
+}
+
+func _Noisey_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoiseyServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Noisey_Load_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoiseyServer).Load(ctx, req.(*LoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Noisey_Unload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoiseyServer).Unload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Noisey_Unload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoiseyServer).Unload(ctx, req.(*UnloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Noisey_Sample_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SampleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoiseyServer).Sample(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Noisey_Sample_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoiseyServer).Sample(ctx, req.(*SampleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Noisey_Region_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoiseyServer).Region(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Noisey_Region_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoiseyServer).Region(ctx, req.(*RegionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Noisey_ServiceDesc is the grpc.ServiceDesc for Noisey service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Noisey_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "noisey.Noisey",
+	HandlerType: (*NoiseyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Load",
+			Handler:    _Noisey_Load_Handler,
+		},
+		{
+			MethodName: "Unload",
+			Handler:    _Noisey_Unload_Handler,
+		},
+		{
+			MethodName: "Sample",
+			Handler:    _Noisey_Sample_Handler,
+		},
+		{
+			MethodName: "Region",
+			Handler:    _Noisey_Region_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "noisey.proto",
+}
@@ -0,0 +1,216 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3TileCache is a TileCache of the objects in a bucket of Amazon S3 or of
+// a service with the same API, like Google Cloud Storage with HMAC keys,
+// MinIO or Cloudflare R2. The requests are signed with AWS Signature
+// Version 4 and use path style URLs, so no SDK is needed.
+type S3TileCache struct {
+	// Endpoint is the URL of the service, like
+	// "https://s3.us-east-1.amazonaws.com"
+	Endpoint string
+
+	// Region is the region the requests are signed for
+	Region string
+
+	// Bucket is the bucket the tiles are stored in
+	Bucket string
+
+	// Prefix is put in front of the keys, like "tiles/"
+	Prefix string
+
+	// AccessKey and SecretKey are the credentials that sign the requests
+	AccessKey string
+	SecretKey string
+
+	// SessionToken is sent with temporary credentials if it isn't empty
+	SessionToken string
+
+	// Client makes the requests; http.DefaultClient if it is nil
+	Client *http.Client
+}
+
+// NewS3TileCache creates a new cache of the tiles in an S3 bucket of the
+// region.
+func NewS3TileCache(region string, bucket string, accessKey string, secretKey string) (sc *S3TileCache) {
+	sc = new(S3TileCache)
+	sc.Endpoint = "https://s3." + region + ".amazonaws.com"
+	sc.Region = region
+	sc.Bucket = bucket
+	sc.AccessKey = accessKey
+	sc.SecretKey = secretKey
+	return sc
+}
+
+// NewGCSTileCache creates a new cache of the tiles in a Google Cloud
+// Storage bucket, using its XML API with the HMAC keys of a service
+// account.
+func NewGCSTileCache(bucket string, accessKey string, secretKey string) (sc *S3TileCache) {
+	sc = new(S3TileCache)
+	sc.Endpoint = "https://storage.googleapis.com"
+	sc.Region = "auto"
+	sc.Bucket = bucket
+	sc.AccessKey = accessKey
+	sc.SecretKey = secretKey
+	return sc
+}
+
+// emptySHA256 is the SHA-256 of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sign adds the x-amz-date, x-amz-content-sha256 and Authorization headers
+// of AWS Signature Version 4 to a request with a payload of the hash.
+func (sc *S3TileCache) sign(req *http.Request, payloadHash string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if sc.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", sc.SessionToken)
+	}
+
+	// every header is signed, with the host that net/http will send
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + sc.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+sc.SecretKey), date)
+	key = hmacSHA256(key, sc.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sc.AccessKey, scope, signedHeaders, signature))
+}
+
+// request makes a signed request for the object of a key.
+func (sc *S3TileCache) request(method string, key string, body []byte) (*http.Response, error) {
+	u, err := url.Parse(strings.TrimSuffix(sc.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	// the object path is escaped like S3 expects, keeping the slashes
+	u.Path += "/" + sc.Bucket + "/" + sc.Prefix + key
+	u.RawPath = ""
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		segments = append(segments, strings.Replace(url.PathEscape(segment), "+", "%2B", -1))
+	}
+	u.RawPath = strings.Join(segments, "/")
+
+	var r io.Reader
+	payloadHash := emptySHA256
+	if body != nil {
+		r = bytes.NewReader(body)
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	req, err := http.NewRequest(method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	sc.sign(req, payloadHash, time.Now())
+
+	client := sc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// responseError returns the status and the start of the body of a failed
+// request as an error.
+func responseError(method string, key string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("Unable to %s the tile %q: %s %s\n", method, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// Get returns the tile stored under the key.
+func (sc *S3TileCache) Get(key string) ([]byte, bool, error) {
+	resp, err := sc.request("GET", key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		return data, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	}
+	return nil, false, responseError("GET", key, resp)
+}
+
+// Put stores a tile under the key.
+func (sc *S3TileCache) Put(key string, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	resp, err := sc.request("PUT", key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError("PUT", key, resp)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// String returns a description of the cache.
+func (sc *S3TileCache) String() string {
+	return fmt.Sprintf("S3TileCache{Endpoint: %q, Bucket: %q, Prefix: %q}", sc.Endpoint, sc.Bucket, sc.Prefix)
+}
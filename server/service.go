@@ -0,0 +1,259 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package server evaluates noise pipelines for other processes, so that
+services that aren't written in Go, like a Unity client or Python tooling,
+can sample the same noise as a Go backend.
+
+A Service holds the pipelines that have been loaded from NoiseJSON
+configurations. It samples their generators at lists of points and bakes
+rectangular regions into compressed tiles:
+
+	svc := server.NewService()
+	id, _, err := svc.Load(configJSON)
+	values, err := svc.Sample(id, "terrain", xs, ys)
+	tile, err := svc.Region(id, "terrain", bounds, 256, 256, server.TileFloat32Zlib)
+
+A Service with Metrics records the regions it bakes and how long its
+generators take, which can be served to Prometheus or published to expvar:
+
+	svc.Metrics = server.NewMetrics()
+	http.Handle("/metrics", svc.Metrics)
+
+ChunkStream streams the chunks of a noisey.ChunkManager to browsers over
+WebSocket as they subscribe to regions of the world.
+
+The Service doesn't depend on any transport. The gRPC server for it is in
+grpc.go and only built with the grpc tag, since it needs the
+google.golang.org/grpc packages and the code in noiseypb generated from
+noiseypb/noisey.proto:
+
+	go build -tags grpc github.com/tbogdala/noisey/server
+*/
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tbogdala/noisey"
+)
+
+// TileEncoding is how the values of a baked region are encoded.
+type TileEncoding int
+
+const (
+	// TileFloat32Zlib is the values as little endian float32 in rows from
+	// the top, compressed with zlib.
+	TileFloat32Zlib TileEncoding = iota
+
+	// TilePNG16 is a 16 bit grayscale PNG of the values in [-1, 1].
+	TilePNG16
+)
+
+// String returns the name of the encoding.
+func (e TileEncoding) String() string {
+	switch e {
+	case TileFloat32Zlib:
+		return "TileFloat32Zlib"
+	case TilePNG16:
+		return "TilePNG16"
+	default:
+		return fmt.Sprintf("TileEncoding(%d)", int(e))
+	}
+}
+
+// Tile is a baked region of a generator.
+type Tile struct {
+	Width    int
+	Height   int
+	Encoding TileEncoding
+	Min      float64 // the lowest value of the region
+	Max      float64 // the highest value of the region
+	Data     []byte  // the values in the encoding
+}
+
+// Service holds loaded pipelines and evaluates them. It's safe to use from
+// several goroutines at once.
+type Service struct {
+	// MaxPoints is the most points that Sample() and Region() evaluate in
+	// one call, which keeps a single request from using up the memory.
+	MaxPoints int
+
+	// Limits bounds the configurations that Load() accepts. NewService()
+	// sets it to noisey.DefaultLoadLimits.
+	Limits noisey.LoadLimits
+
+	// SeedBuilder makes the random sources of the pipelines from their
+	// seeds. NewService() sets it to use math/rand.
+	SeedBuilder noisey.RandomSeedBuilder
+
+	// Metrics records the regions and how long the generators take to
+	// evaluate if it isn't nil.
+	Metrics *Metrics
+
+	lock      sync.RWMutex
+	pipelines map[string]*noisey.NoiseJSON
+}
+
+// NewService creates a new service without any pipelines that evaluates at
+// most 16M points a call and loads configurations within DefaultLoadLimits.
+func NewService() (s *Service) {
+	s = new(Service)
+	s.MaxPoints = 4096 * 4096
+	s.Limits = noisey.DefaultLoadLimits
+	s.SeedBuilder = func(seed int64) noisey.RandomSource {
+		return rand.New(rand.NewSource(seed))
+	}
+	s.pipelines = make(map[string]*noisey.NoiseJSON)
+	return s
+}
+
+// Load builds the sources and generators of a NoiseJSON configuration and
+// returns the id of the pipeline and the names of its generators. The id is
+// the SHA-256 of the configuration, so loading the same one twice gives the
+// same pipeline.
+func (s *Service) Load(config []byte) (id string, generators []string, err error) {
+	sum := sha256.Sum256(config)
+	id = hex.EncodeToString(sum[:])
+
+	s.lock.RLock()
+	cfg, ok := s.pipelines[id]
+	s.lock.RUnlock()
+	if !ok {
+		cfg, err = noisey.LoadNoiseJSONWithLimits(config, s.Limits)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = cfg.BuildSources(s.SeedBuilder); err != nil {
+			return "", nil, err
+		}
+		if err = cfg.BuildGenerators(); err != nil {
+			return "", nil, err
+		}
+		s.lock.Lock()
+		s.pipelines[id] = cfg
+		s.lock.Unlock()
+	}
+
+	for _, gj := range cfg.Generators {
+		generators = append(generators, gj.Name)
+	}
+	return id, generators, nil
+}
+
+// Unload forgets a pipeline.
+func (s *Service) Unload(id string) {
+	s.lock.Lock()
+	delete(s.pipelines, id)
+	s.lock.Unlock()
+}
+
+// Pipelines returns the ids of the loaded pipelines in order.
+func (s *Service) Pipelines() (ids []string) {
+	s.lock.RLock()
+	for id := range s.pipelines {
+		ids = append(ids, id)
+	}
+	s.lock.RUnlock()
+	sort.Strings(ids)
+	return ids
+}
+
+// generator returns the named generator of a pipeline, which is the last
+// one of the configuration if the name is empty, and its name.
+func (s *Service) generator(id string, name string) (noisey.NoiseyGet2D, string, error) {
+	s.lock.RLock()
+	cfg, ok := s.pipelines[id]
+	s.lock.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("Unable to find a loaded pipeline with the id %q.\n", id)
+	}
+	if name == "" && len(cfg.Generators) > 0 {
+		name = cfg.Generators[len(cfg.Generators)-1].Name
+	}
+	gen := cfg.GetGenerator(name)
+	if gen == nil {
+		return nil, "", fmt.Errorf("Unable to find the generator %q in the pipeline.\n", name)
+	}
+	return gen, name, nil
+}
+
+// observe records the time since start in the metrics of the service.
+func (s *Service) observe(id string, generator string, start time.Time) {
+	if s.Metrics != nil {
+		s.Metrics.Observe(id, generator, time.Since(start))
+	}
+}
+
+// Sample returns the values of a generator of a pipeline at the points.
+func (s *Service) Sample(id string, generator string, xs []float64, ys []float64) ([]float64, error) {
+	if len(xs) != len(ys) {
+		return nil, fmt.Errorf("Unable to sample %d x and %d y coordinates; they have to be pairs.\n", len(xs), len(ys))
+	}
+	if len(xs) > s.MaxPoints {
+		return nil, fmt.Errorf("Unable to sample %d points; at most %d can be sampled at once.\n", len(xs), s.MaxPoints)
+	}
+	gen, generator, err := s.generator(id, generator)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	values := make([]float64, len(xs))
+	for i := range xs {
+		values[i] = gen.Get2D(xs[i], ys[i])
+	}
+	s.observe(id, generator, start)
+	return values, nil
+}
+
+// Region bakes a generator of a pipeline over the bounds into a tile of
+// width by height values.
+func (s *Service) Region(id string, generator string, bounds noisey.Builder2DBounds, width int, height int, encoding TileEncoding) (*Tile, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("Unable to bake a region of %dx%d values.\n", width, height)
+	}
+	if width*height > s.MaxPoints || width*height/width != height {
+		return nil, fmt.Errorf("Unable to bake a region of %dx%d values; at most %d can be baked at once.\n", width, height, s.MaxPoints)
+	}
+	gen, generator, err := s.generator(id, generator)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	builder := noisey.NewBuilder2D(gen, width, height)
+	builder.Bounds = bounds
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	s.observe(id, generator, start)
+	if s.Metrics != nil {
+		s.Metrics.TileRendered()
+	}
+	nm := builder.GetNoiseMap()
+	tile := &Tile{Width: width, Height: height, Encoding: encoding}
+	tile.Min, tile.Max = nm.GetMinMax()
+
+	var b bytes.Buffer
+	switch encoding {
+	case TileFloat32Zlib:
+		err = encodeFloat32Zlib(&b, nm.Values)
+	case TilePNG16:
+		err = noisey.WritePNG16(&b, &nm)
+	default:
+		return nil, fmt.Errorf("Unable to encode a tile as %v.\n", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tile.Data = b.Bytes()
+	return tile, nil
+}
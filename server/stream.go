@@ -0,0 +1,230 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package server
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// ChunkStream is an http.Handler that streams the chunks of a ChunkManager
+// over WebSocket as clients subscribe to regions of them, so map clients
+// don't have to poll for tiles.
+//
+// A client subscribes by sending a text message with the inclusive
+// rectangle of chunk coordinates it wants, which replaces the one it had:
+//
+//	{"subscribe": [minX, minY, maxX, maxY]}
+//
+// The server answers with a binary message for every chunk of the region,
+// from the middle out, that it hasn't sent since the chunk was last in a
+// region of the client. A message is the chunk's X and Y as little endian
+// int32, its width and height as little endian uint32 and then its values
+// as little endian float32 in rows, compressed with zlib. When a new
+// subscription comes in while sending, the chunks of the old region that
+// are left aren't sent.
+type ChunkStream struct {
+	// Chunks builds the chunks that are streamed.
+	Chunks *noisey.ChunkManager
+
+	// MaxChunks is the most chunks a subscription can have.
+	MaxChunks int
+}
+
+// NewChunkStream creates a new stream of the chunks of cm that allows
+// subscriptions of up to 1024 chunks.
+func NewChunkStream(cm *noisey.ChunkManager) (cs *ChunkStream) {
+	cs = new(ChunkStream)
+	cs.Chunks = cm
+	cs.MaxChunks = 1024
+	return cs
+}
+
+// chunkRegion is an inclusive rectangle of chunk coordinates.
+type chunkRegion struct {
+	min, max noisey.ChunkCoord
+}
+
+// contains returns true if the chunk is in the region.
+func (r chunkRegion) contains(c noisey.ChunkCoord) bool {
+	return c.X >= r.min.X && c.X <= r.max.X && c.Y >= r.min.Y && c.Y <= r.max.Y
+}
+
+// coords returns the chunks of the region from the middle out.
+func (r chunkRegion) coords() []noisey.ChunkCoord {
+	var coords []noisey.ChunkCoord
+	for y := r.min.Y; y <= r.max.Y; y++ {
+		for x := r.min.X; x <= r.max.X; x++ {
+			coords = append(coords, noisey.ChunkCoord{X: x, Y: y})
+		}
+	}
+	cx, cy := float64(r.min.X+r.max.X)*0.5, float64(r.min.Y+r.max.Y)*0.5
+	sort.SliceStable(coords, func(i, j int) bool {
+		di := math.Hypot(float64(coords[i].X)-cx, float64(coords[i].Y)-cy)
+		dj := math.Hypot(float64(coords[j].X)-cx, float64(coords[j].Y)-cy)
+		return di < dj
+	})
+	return coords
+}
+
+// parseSubscription parses a subscribe message of a client.
+func (cs *ChunkStream) parseSubscription(message []byte) (chunkRegion, error) {
+	var msg struct {
+		Subscribe []int `json:"subscribe"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || len(msg.Subscribe) != 4 {
+		return chunkRegion{}, fmt.Errorf("Unable to read the subscription %q; expected {\"subscribe\": [minX, minY, maxX, maxY]}.\n", message)
+	}
+	r := chunkRegion{
+		noisey.ChunkCoord{X: msg.Subscribe[0], Y: msg.Subscribe[1]},
+		noisey.ChunkCoord{X: msg.Subscribe[2], Y: msg.Subscribe[3]},
+	}
+	w, h := int64(r.max.X)-int64(r.min.X)+1, int64(r.max.Y)-int64(r.min.Y)+1
+	if w <= 0 || h <= 0 || w*h > int64(cs.MaxChunks) {
+		return chunkRegion{}, fmt.Errorf("Unable to subscribe to %dx%d chunks; at most %d chunks can be subscribed to.\n", w, h, cs.MaxChunks)
+	}
+	return r, nil
+}
+
+// encodeFloat32Zlib writes the values as little endian float32 compressed with zlib.
+func encodeFloat32Zlib(w io.Writer, values []float64) error {
+	raw := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(float32(v)))
+	}
+	zw := zlib.NewWriter(w)
+	if _, err := zw.Write(raw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// chunkMessage encodes the binary message of a chunk.
+func chunkMessage(c noisey.ChunkCoord, nm *noisey.NoiseMap) ([]byte, error) {
+	var b bytes.Buffer
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:], uint32(int32(c.X)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(int32(c.Y)))
+	binary.LittleEndian.PutUint32(header[8:], uint32(nm.Width))
+	binary.LittleEndian.PutUint32(header[12:], uint32(nm.Height))
+	b.Write(header)
+	if err := encodeFloat32Zlib(&b, nm.Values); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams the chunks the
+// client subscribes to until it disconnects.
+func (cs *ChunkStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r, 4096)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// the subscriptions are read on their own goroutine so that a new one
+	// can cut the sending of an old one short
+	regions := make(chan chunkRegion, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			op, message, err := conn.readMessage()
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("chunk stream %s: %s", r.RemoteAddr, err)
+				}
+				return
+			}
+			if op != wsText {
+				continue
+			}
+			region, err := cs.parseSubscription(message)
+			if err != nil {
+				conn.writeFrame(wsText, []byte(fmt.Sprintf("{\"error\": %q}", strings.TrimSpace(err.Error()))))
+				continue
+			}
+			// only the latest subscription matters
+			select {
+			case <-regions:
+			default:
+			}
+			regions <- region
+		}
+	}()
+
+	sent := make(map[noisey.ChunkCoord]bool)
+	for {
+		var region chunkRegion
+		select {
+		case region = <-regions:
+		case <-done:
+			return
+		}
+		for c := range sent {
+			if !region.contains(c) {
+				delete(sent, c)
+			}
+		}
+
+		var todo []noisey.ChunkCoord
+		for _, c := range region.coords() {
+			if !sent[c] {
+				todo = append(todo, c)
+			}
+		}
+		// the chunks are built a batch of workers at a time and the batches
+		// are sent unless a newer subscription came in
+		batch := cs.Chunks.Workers
+		if batch < 1 {
+			batch = 1
+		}
+	sending:
+		for start := 0; start < len(todo); start += batch {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if len(regions) > 0 {
+				break sending
+			}
+			coords := todo[start:minInt(start+batch, len(todo))]
+			maps, err := cs.Chunks.Chunks(coords)
+			if err != nil {
+				log.Printf("chunk stream %s: %s", r.RemoteAddr, err)
+				return
+			}
+			for i, c := range coords {
+				message, err := chunkMessage(c, maps[i])
+				if err == nil {
+					err = conn.writeFrame(wsBinary, message)
+				}
+				if err != nil {
+					return
+				}
+				sent[c] = true
+			}
+		}
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
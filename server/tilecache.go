@@ -0,0 +1,127 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TileCache stores rendered tiles by key so that they outlive a server and
+// can be shared by all the servers of a deployment. The keys are paths like
+// those that TileCacheKey() returns. Implementations have to be safe to use
+// from several goroutines at once.
+type TileCache interface {
+	// Get returns the tile stored under the key and true, or false if there
+	// is none.
+	Get(key string) ([]byte, bool, error)
+
+	// Put stores a tile under the key, replacing any that was there.
+	Put(key string, data []byte) error
+}
+
+// TileCacheVersion returns the version of the cache keys for the tiles of a
+// configuration: the start of the SHA-256 of the configuration and of the
+// settings that change how its tiles look. Tiles of a changed configuration
+// get new keys, so they are rendered again while those of the old one stay
+// valid for the servers that still use it.
+func TileCacheVersion(config []byte, settings ...string) string {
+	h := sha256.New()
+	h.Write(config)
+	for _, s := range settings {
+		h.Write([]byte{0})
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// TileCacheKey returns the key of a tile of a version of a configuration,
+// like "a1b2c3d4e5f60718/3/-1/2.png".
+func TileCacheKey(version string, z int, x int, y int, ext string) string {
+	return fmt.Sprintf("%s/%d/%d/%d%s", version, z, x, y, ext)
+}
+
+// DirTileCache is a TileCache of files in a directory, such as a volume
+// that several servers mount.
+type DirTileCache struct {
+	// Dir is the directory the tiles are stored in by their keys
+	Dir string
+}
+
+// NewDirTileCache creates a new cache of the tiles in the directory.
+func NewDirTileCache(dir string) (dc *DirTileCache) {
+	dc = new(DirTileCache)
+	dc.Dir = dir
+	return dc
+}
+
+// path returns the file of a key, refusing keys that leave the directory.
+func (dc *DirTileCache) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("Unable to use %q as the key of a tile.\n", key)
+	}
+	return filepath.Join(dc.Dir, filepath.FromSlash(clean)), nil
+}
+
+// Get returns the tile stored under the key.
+func (dc *DirTileCache) Get(key string) ([]byte, bool, error) {
+	path, err := dc.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Put stores a tile under the key. It's written to a temporary file first
+// and renamed, so other servers never read half of it.
+func (dc *DirTileCache) Put(key string, data []byte) error {
+	path, err := dc.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tile-")
+	if err != nil {
+		return err
+	}
+	// temporary files are only readable by their owner
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// String returns a description of the cache.
+func (dc *DirTileCache) String() string {
+	return fmt.Sprintf("DirTileCache{Dir: %q}", dc.Dir)
+}
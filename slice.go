@@ -0,0 +1,136 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains adapters that let a 3D source be used where a 2D source
+is expected and the other way around.
+
+A common use of Slice2D is animation: take a 3D source and sweep the Offset
+along AxisZ over time to get a smoothly changing 2D image.
+
+*/
+
+import "fmt"
+
+// Axis identifies one of the three coordinate axes.
+type Axis int
+
+const (
+	AxisX Axis = iota // the x axis
+	AxisY             // the y axis
+	AxisZ             // the z axis
+)
+
+// String returns the name of the axis.
+func (a Axis) String() string {
+	switch a {
+	case AxisX:
+		return "AxisX"
+	case AxisY:
+		return "AxisY"
+	case AxisZ:
+		return "AxisZ"
+	}
+	return fmt.Sprintf("Axis(%d)", int(a))
+}
+
+// Slice2D is a module that samples a NoiseyGet3D source on a plane
+// perpendicular to Axis, making it usable as a NoiseyGet2D. The two remaining
+// axes are mapped to the x and y coordinates in order.
+type Slice2D struct {
+	// the 3D noise that gets sampled
+	Source NoiseyGet3D
+
+	// the axis that is held fixed
+	Axis Axis
+
+	// the coordinate on Axis where the plane sits
+	Offset float64
+}
+
+// NewSlice2D creates a new 2D slice module of a 3D source.
+func NewSlice2D(src NoiseyGet3D, axis Axis, offset float64) (slice Slice2D) {
+	slice.Source = src
+	slice.Axis = axis
+	slice.Offset = offset
+	return
+}
+
+// Clone2D returns a deep copy of the module and its Source.
+func (slice *Slice2D) Clone2D() NoiseyGet2D {
+	c := *slice
+	c.Source = DeepCopy3D(slice.Source)
+	return &c
+}
+
+// String returns a description of the module and its Source.
+func (slice *Slice2D) String() string {
+	return fmt.Sprintf("Slice2D{Axis: %v, Offset: %v, Source: %s}", slice.Axis, slice.Offset, describeModule(slice.Source))
+}
+
+// Get2D calculates the noise value of Source on the plane.
+func (slice *Slice2D) Get2D(x float64, y float64) float64 {
+	switch slice.Axis {
+	case AxisX:
+		return slice.Source.Get3D(slice.Offset, x, y)
+	case AxisY:
+		return slice.Source.Get3D(x, slice.Offset, y)
+	default:
+		return slice.Source.Get3D(x, y, slice.Offset)
+	}
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are those of Source.
+func (slice *Slice2D) Range2D() (min float64, max float64) {
+	return ValueRange3D(slice.Source)
+}
+
+// Extrude3D is a module that makes a NoiseyGet2D source usable as a NoiseyGet3D
+// by ignoring the coordinate on Axis, which extrudes the 2D noise along it.
+// The two remaining axes are passed to the source as x and y in order.
+type Extrude3D struct {
+	// the 2D noise that gets sampled
+	Source NoiseyGet2D
+
+	// the axis the noise is extruded along
+	Axis Axis
+}
+
+// NewExtrude3D creates a new 3D extrusion module of a 2D source.
+func NewExtrude3D(src NoiseyGet2D, axis Axis) (extrude Extrude3D) {
+	extrude.Source = src
+	extrude.Axis = axis
+	return
+}
+
+// Clone3D returns a deep copy of the module and its Source.
+func (extrude *Extrude3D) Clone3D() NoiseyGet3D {
+	c := *extrude
+	c.Source = DeepCopy2D(extrude.Source)
+	return &c
+}
+
+// String returns a description of the module and its Source.
+func (extrude *Extrude3D) String() string {
+	return fmt.Sprintf("Extrude3D{Axis: %v, Source: %s}", extrude.Axis, describeModule(extrude.Source))
+}
+
+// Get3D calculates the noise value of Source ignoring the coordinate on Axis.
+func (extrude *Extrude3D) Get3D(x float64, y float64, z float64) float64 {
+	switch extrude.Axis {
+	case AxisX:
+		return extrude.Source.Get2D(y, z)
+	case AxisY:
+		return extrude.Source.Get2D(x, z)
+	default:
+		return extrude.Source.Get2D(x, y)
+	}
+}
+
+// Range3D returns the bounds of the values of Get3D(), which are those of Source.
+func (extrude *Extrude3D) Range3D() (min float64, max float64) {
+	return ValueRange2D(extrude.Source)
+}
@@ -0,0 +1,112 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module measures how steep a heightmap is and which way it faces, for
+texture splatting and placement rules like grass on gentle slopes and rock
+on cliffs:
+
+	slope, err := noisey.SlopeMap(&heightmap, 200.0, noisey.SlopeDegrees)
+	aspect, err := noisey.AspectMap(&heightmap)
+
+The heights are multiplied by heightScale to get world units before the
+slope is measured against the spacing of the grid in Bounds, so a map in
+[-1, 1] of terrain 400 units high has a heightScale of 200. The gradient is
+measured with central differences, one sided at the edges.
+
+SlopeDegrees gives the angle from the horizontal in [0, 90], SlopeNormalized
+the same angle mapped to [0, 1] and SlopeRatio the rise over run, which is
+0.0 on flat ground and 1.0 at 45 degrees.
+
+The aspect is the compass direction the ground faces downhill in degrees
+[0, 360), with the top of the map (towards Bounds.MinY) as north at 0 and
+the right (towards Bounds.MaxX) as east at 90. Flat points have an aspect
+of -1.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// SlopeUnits selects the units of a SlopeMap.
+type SlopeUnits int
+
+const (
+	SlopeDegrees    SlopeUnits = iota // the angle from the horizontal in [0, 90]
+	SlopeNormalized                   // the angle from the horizontal mapped to [0, 1]
+	SlopeRatio                        // the rise over run
+)
+
+// String returns the name of the units.
+func (u SlopeUnits) String() string {
+	switch u {
+	case SlopeDegrees:
+		return "SlopeDegrees"
+	case SlopeNormalized:
+		return "SlopeNormalized"
+	case SlopeRatio:
+		return "SlopeRatio"
+	}
+	return fmt.Sprintf("SlopeUnits(%d)", int(u))
+}
+
+// checkHeightmap returns an error if the heightmap can't be analysed.
+func checkHeightmap(heightmap *NoiseMap, what string) error {
+	w, h := heightmap.Width, heightmap.Height
+	if w <= 0 || h <= 0 || len(heightmap.Values) != w*h {
+		return fmt.Errorf("Unable to make a %s map for a heightmap of size %dx%d with %d values.\n", what, w, h, len(heightmap.Values))
+	}
+	return nil
+}
+
+// SlopeMap returns a map of how steep the heightmap is at each grid point.
+func SlopeMap(heightmap *NoiseMap, heightScale float64, units SlopeUnits) (NoiseMap, error) {
+	if err := checkHeightmap(heightmap, "slope"); err != nil {
+		return NoiseMap{}, err
+	}
+	slope := NewNoiseMap(heightmap.Width, heightmap.Height, heightmap.Bounds)
+	for y := 0; y < heightmap.Height; y++ {
+		for x := 0; x < heightmap.Width; x++ {
+			gx, gy := heightmap.worldGradient(x, y)
+			ratio := math.Hypot(gx, gy) * math.Abs(heightScale)
+			v := ratio
+			switch units {
+			case SlopeDegrees:
+				v = math.Atan(ratio) * 180.0 / math.Pi
+			case SlopeNormalized:
+				v = math.Atan(ratio) / (math.Pi * 0.5)
+			}
+			slope.Values[y*heightmap.Width+x] = v
+		}
+	}
+	return slope, nil
+}
+
+// AspectMap returns a map of the compass direction the heightmap faces
+// downhill at each grid point.
+func AspectMap(heightmap *NoiseMap) (NoiseMap, error) {
+	if err := checkHeightmap(heightmap, "aspect"); err != nil {
+		return NoiseMap{}, err
+	}
+	aspect := NewNoiseMap(heightmap.Width, heightmap.Height, heightmap.Bounds)
+	for y := 0; y < heightmap.Height; y++ {
+		for x := 0; x < heightmap.Width; x++ {
+			gx, gy := heightmap.worldGradient(x, y)
+			v := -1.0
+			if gx != 0.0 || gy != 0.0 {
+				// downhill is against the gradient and north is towards -Y
+				v = math.Atan2(-gx, gy) * 180.0 / math.Pi
+				if v < 0.0 {
+					v += 360.0
+				}
+			}
+			aspect.Values[y*heightmap.Width+x] = v
+		}
+	}
+	return aspect, nil
+}
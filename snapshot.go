@@ -0,0 +1,625 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module saves the fully resolved state of a pipeline of modules, including
+the permutation tables of the sources, so that it can be restored later and
+produce exactly the same noise without needing the RandomSource that was used
+to create it.
+
+	snap, err := noisey.Snapshot2D(pipeline)
+	if err != nil {
+		panic(err)
+	}
+	bytes, err := noisey.SaveSnapshot(snap)
+
+	// ... later, possibly after a restart ...
+
+	snap, err = noisey.LoadSnapshot(bytes)
+	pipeline, err = noisey.Restore2D(snap)
+
+Restored sources have a nil Rng since it is only needed during construction.
+Modules used more than once in a pipeline are restored as separate copies
+that produce the same values.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SnapshotVersion is the version of the snapshot format written by SaveSnapshot().
+const SnapshotVersion = 1
+
+// ModuleSnapshot is the saved state of a module and the modules it uses.
+type ModuleSnapshot struct {
+	// Type is the Go type name of the module, like "PerlinGenerator"
+	Type string
+
+	// Params are the numeric parameters of the module keyed by field name
+	Params map[string]float64 `json:",omitempty"`
+
+	// Permutations is the permutation table of a source
+	Permutations []int `json:",omitempty"`
+
+	// Gradients is the gradient table of a source
+	Gradients []Vec4f `json:",omitempty"`
+
+	// Values are the values of a NoiseMap
+	Values []float64 `json:",omitempty"`
+
+	// Inputs are the snapshots of the modules used by this module keyed by field name
+	Inputs map[string]*ModuleSnapshot `json:",omitempty"`
+}
+
+// snapshotFile is the structure written by SaveSnapshot().
+type snapshotFile struct {
+	Version int
+	Root    *ModuleSnapshot
+}
+
+// SaveSnapshot marshals the snapshot into a JSON byte array.
+func SaveSnapshot(s *ModuleSnapshot) ([]byte, error) {
+	bytes, err := json.Marshal(snapshotFile{SnapshotVersion, s})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode the snapshot into JSON.\n%v\n", err)
+	}
+	return bytes, nil
+}
+
+// LoadSnapshot unmarshals a snapshot created by SaveSnapshot().
+func LoadSnapshot(bytes []byte) (*ModuleSnapshot, error) {
+	var f snapshotFile
+	if err := json.Unmarshal(bytes, &f); err != nil {
+		return nil, fmt.Errorf("Unable to read json into the snapshot structure.\n%v\n", err)
+	}
+	if f.Version != SnapshotVersion {
+		return nil, fmt.Errorf("Unsupported snapshot version %d.\n", f.Version)
+	}
+	if f.Root == nil {
+		return nil, fmt.Errorf("The snapshot does not contain a module.\n")
+	}
+	return f.Root, nil
+}
+
+func newModuleSnapshot(m interface{}) *ModuleSnapshot {
+	s := new(ModuleSnapshot)
+	s.Type = strings.TrimPrefix(fmt.Sprintf("%T", m), "*noisey.")
+	s.Params = make(map[string]float64)
+	return s
+}
+
+func (s *ModuleSnapshot) addInput2D(name string, m NoiseyGet2D) error {
+	in, err := Snapshot2D(m)
+	if err != nil {
+		return err
+	}
+	if s.Inputs == nil {
+		s.Inputs = make(map[string]*ModuleSnapshot)
+	}
+	s.Inputs[name] = in
+	return nil
+}
+
+func (s *ModuleSnapshot) addInput3D(name string, m NoiseyGet3D) error {
+	in, err := Snapshot3D(m)
+	if err != nil {
+		return err
+	}
+	if s.Inputs == nil {
+		s.Inputs = make(map[string]*ModuleSnapshot)
+	}
+	s.Inputs[name] = in
+	return nil
+}
+
+// snapshotSource saves the modules that implement both NoiseyGet2D and NoiseyGet3D.
+func snapshotSource(m interface{}) (*ModuleSnapshot, bool) {
+	s := newModuleSnapshot(m)
+	switch src := m.(type) {
+	case *PerlinGenerator:
+		s.Params["Quality"] = float64(src.Quality)
+		if src.Normalized {
+			s.Params["Normalized"] = 1.0
+		}
+		s.Permutations = copyInts(src.Permutations)
+		s.Gradients = make([]Vec4f, len(src.RandomGradients))
+		copy(s.Gradients, src.RandomGradients)
+	case *OpenSimplexGenerator:
+		if src.Normalized {
+			s.Params["Normalized"] = 1.0
+		}
+		s.Permutations = copyInts(src.Permutations)
+	case *WorleyGenerator:
+		s.Params["Return"] = float64(src.Return)
+		s.Params["Jitter"] = src.Jitter
+		s.Permutations = copyInts(src.Permutations)
+	default:
+		return nil, false
+	}
+	return s, true
+}
+
+// saveCaustics saves a Caustics2D, which can be used as either a 2D or a 3D module.
+func (s *ModuleSnapshot) saveCaustics(mod *Caustics2D) (err error) {
+	if mod.Cells == nil {
+		return fmt.Errorf("Unable to snapshot a Caustics2D without Cells.\n")
+	}
+	s.Params["CellSize"] = mod.CellSize
+	s.Params["Width"] = mod.Width
+	s.Params["Layers"] = float64(mod.Layers)
+	s.Params["Speed"] = mod.Speed
+	s.Params["Distortion"] = mod.Distortion
+	s.Params["Time"] = mod.Time
+	if err = s.addInput2D("Cells", mod.Cells); err == nil && mod.Warp != nil {
+		err = s.addInput2D("Warp", mod.Warp)
+	}
+	return err
+}
+
+// restoreCaustics restores a Caustics2D saved by saveCaustics().
+func (s *ModuleSnapshot) restoreCaustics() (*Caustics2D, error) {
+	p := s.Params
+	caustics := NewCaustics2D(nil, p["CellSize"], p["Width"])
+	caustics.Layers = int(p["Layers"])
+	caustics.Speed = p["Speed"]
+	caustics.Distortion = p["Distortion"]
+	caustics.Time = p["Time"]
+	cells, err := s.input2D("Cells")
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if caustics.Cells, ok = cells.(*WorleyGenerator); !ok {
+		return nil, fmt.Errorf("Snapshot of Caustics2D has Cells of type %T; expected a WorleyGenerator.\n", cells)
+	}
+	if _, ok = s.Inputs["Warp"]; ok {
+		caustics.Warp, err = s.input2D("Warp")
+	}
+	return &caustics, err
+}
+
+// Snapshot2D saves the state of the module m and all of the modules it uses.
+// An error is returned if a module type cannot be saved.
+func Snapshot2D(m NoiseyGet2D) (*ModuleSnapshot, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Unable to snapshot a nil module.\n")
+	}
+	if s, ok := snapshotSource(m); ok {
+		return s, nil
+	}
+
+	var err error
+	s := newModuleSnapshot(m)
+	switch mod := m.(type) {
+	case *FBMGenerator2D:
+		s.Params["Octaves"] = float64(mod.Octaves)
+		s.Params["Persistence"] = mod.Persistence
+		s.Params["Lacunarity"] = mod.Lacunarity
+		s.Params["Frequency"] = mod.Frequency
+		err = s.addInput2D("NoiseMaker", mod.NoiseMaker)
+	case *RidgedGenerator2D:
+		s.Params["Octaves"] = float64(mod.Octaves)
+		s.Params["Lacunarity"] = mod.Lacunarity
+		s.Params["Frequency"] = mod.Frequency
+		s.Params["Offset"] = mod.Offset
+		s.Params["Gain"] = mod.Gain
+		s.Params["Exponent"] = mod.Exponent
+		err = s.addInput2D("NoiseMaker", mod.NoiseMaker)
+	case *Select2D:
+		s.Params["LowerBound"] = mod.LowerBound
+		s.Params["UpperBound"] = mod.UpperBound
+		s.Params["EdgeFalloff"] = mod.EdgeFalloff
+		if err = s.addInput2D("SourceA", mod.SourceA); err == nil {
+			if err = s.addInput2D("SourceB", mod.SourceB); err == nil {
+				err = s.addInput2D("Control", mod.Control)
+			}
+		}
+	case *Scale2D:
+		s.Params["Scale"] = mod.Scale
+		s.Params["Bias"] = mod.Bias
+		s.Params["Min"] = mod.Min
+		s.Params["Max"] = mod.Max
+		err = s.addInput2D("Source", mod.Source)
+	case *Slice2D:
+		s.Params["Axis"] = float64(mod.Axis)
+		s.Params["Offset"] = mod.Offset
+		err = s.addInput3D("Source", mod.Source)
+	case *Falloff2D:
+		if mod.Shape == FalloffCustom {
+			return nil, fmt.Errorf("Unable to snapshot a Falloff2D with a custom Distance function.\n")
+		}
+		s.Params["Shape"] = float64(mod.Shape)
+		s.Params["CenterX"] = mod.CenterX
+		s.Params["CenterY"] = mod.CenterY
+		s.Params["Radius"] = mod.Radius
+		s.Params["Exponent"] = mod.Exponent
+	case *Billow2D:
+		s.Params["Octaves"] = float64(mod.Octaves)
+		s.Params["Persistence"] = mod.Persistence
+		s.Params["Lacunarity"] = mod.Lacunarity
+		s.Params["Frequency"] = mod.Frequency
+		err = s.addInput2D("NoiseMaker", mod.NoiseMaker)
+	case *Turbulence2D:
+		s.Params["Frequency"] = mod.Frequency
+		s.Params["Power"] = mod.Power
+		if err = s.addInput2D("Source", mod.Source); err == nil {
+			if err = s.addInput2D("XDistort", mod.XDistort); err == nil {
+				err = s.addInput2D("YDistort", mod.YDistort)
+			}
+		}
+	case *Kaleidoscope2D:
+		s.Params["Segments"] = float64(mod.Segments)
+		s.Params["Symmetry"] = float64(mod.Symmetry)
+		s.Params["CenterX"] = mod.CenterX
+		s.Params["CenterY"] = mod.CenterY
+		s.Params["Rotation"] = mod.Rotation
+		err = s.addInput2D("Source", mod.Source)
+	case *Polar2D:
+		s.Params["CenterX"] = mod.CenterX
+		s.Params["CenterY"] = mod.CenterY
+		s.Params["RadiusScale"] = mod.RadiusScale
+		s.Params["AngleScale"] = mod.AngleScale
+		err = s.addInput2D("Source", mod.Source)
+	case *Pattern2D:
+		s.Params["Shape"] = float64(mod.Shape)
+		s.Params["Frequency"] = mod.Frequency
+	case *Crackle2D:
+		if mod.Cells == nil {
+			return nil, fmt.Errorf("Unable to snapshot a Crackle2D without Cells.\n")
+		}
+		s.Params["CellSize"] = mod.CellSize
+		s.Params["Width"] = mod.Width
+		s.Params["Jaggedness"] = mod.Jaggedness
+		if err = s.addInput2D("Cells", mod.Cells); err == nil && mod.Warp != nil {
+			err = s.addInput2D("Warp", mod.Warp)
+		}
+	case *Caustics2D:
+		err = s.saveCaustics(mod)
+	case *NoiseMap:
+		s.Params["Width"] = float64(mod.Width)
+		s.Params["Height"] = float64(mod.Height)
+		s.Params["MinX"] = mod.Bounds.MinX
+		s.Params["MinY"] = mod.Bounds.MinY
+		s.Params["MaxX"] = mod.Bounds.MaxX
+		s.Params["MaxY"] = mod.Bounds.MaxY
+		s.Params["Interpolation"] = float64(mod.Interpolation)
+		s.Values = make([]float64, len(mod.Values))
+		copy(s.Values, mod.Values)
+	case *ImageSource:
+		s.Params["Wrap"] = float64(mod.Wrap)
+		err = s.addInput2D("Map", &mod.Map)
+	case *Handle2D:
+		return Snapshot2D(mod.Load())
+	default:
+		return nil, fmt.Errorf("Unable to snapshot module type %T.\n", m)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Snapshot3D saves the state of the module m and all of the modules it uses.
+// An error is returned if a module type cannot be saved.
+func Snapshot3D(m NoiseyGet3D) (*ModuleSnapshot, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Unable to snapshot a nil module.\n")
+	}
+	if s, ok := snapshotSource(m); ok {
+		return s, nil
+	}
+
+	var err error
+	s := newModuleSnapshot(m)
+	switch mod := m.(type) {
+	case *FBMGenerator3D:
+		s.Params["Octaves"] = float64(mod.Octaves)
+		s.Params["Persistence"] = mod.Persistence
+		s.Params["Lacunarity"] = mod.Lacunarity
+		s.Params["Frequency"] = mod.Frequency
+		err = s.addInput3D("NoiseMaker", mod.NoiseMaker)
+	case *Select3D:
+		s.Params["LowerBound"] = mod.LowerBound
+		s.Params["UpperBound"] = mod.UpperBound
+		s.Params["EdgeFalloff"] = mod.EdgeFalloff
+		if err = s.addInput3D("SourceA", mod.SourceA); err == nil {
+			if err = s.addInput3D("SourceB", mod.SourceB); err == nil {
+				err = s.addInput3D("Control", mod.Control)
+			}
+		}
+	case *Extrude3D:
+		s.Params["Axis"] = float64(mod.Axis)
+		err = s.addInput2D("Source", mod.Source)
+	case *DensityTerrain3D:
+		s.Params["BaseHeight"] = mod.BaseHeight
+		s.Params["HeightScale"] = mod.HeightScale
+		s.Params["Squash"] = mod.Squash
+		s.Params["Amplitude"] = mod.Amplitude
+		if err = s.addInput2D("Surface", mod.Surface); err == nil {
+			err = s.addInput3D("Density", mod.Density)
+		}
+	case *Caustics2D:
+		err = s.saveCaustics(mod)
+	case *Clouds3D:
+		s.Params["MinY"] = mod.MinY
+		s.Params["MaxY"] = mod.MaxY
+		s.Params["CloudType"] = mod.CloudType
+		s.Params["DetailStrength"] = mod.DetailStrength
+		s.Params["Density"] = mod.Density
+		if err = s.addInput2D("Coverage", mod.Coverage); err == nil {
+			err = s.addInput3D("Shape", mod.Shape)
+		}
+		if err == nil && mod.Type != nil {
+			err = s.addInput2D("Type", mod.Type)
+		}
+		if err == nil && mod.Detail != nil {
+			err = s.addInput3D("Detail", mod.Detail)
+		}
+	case *Handle3D:
+		return Snapshot3D(mod.Load())
+	default:
+		return nil, fmt.Errorf("Unable to snapshot module type %T.\n", m)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// restoreSource restores the modules that implement both NoiseyGet2D and NoiseyGet3D.
+func restoreSource(s *ModuleSnapshot) (interface{}, bool, error) {
+	switch s.Type {
+	case "PerlinGenerator":
+		pg := new(PerlinGenerator)
+		pg.Quality = NoiseQuality(s.Params["Quality"])
+		pg.Normalized = s.Params["Normalized"] != 0.0
+		if err := pg.SetPermutations(s.Permutations); err != nil {
+			return nil, true, err
+		}
+		if err := pg.SetGradients(s.Gradients); err != nil {
+			return nil, true, err
+		}
+		return pg, true, nil
+	case "OpenSimplexGenerator":
+		osg := new(OpenSimplexGenerator)
+		osg.Normalized = s.Params["Normalized"] != 0.0
+		if err := osg.SetPermutations(s.Permutations); err != nil {
+			return nil, true, err
+		}
+		return osg, true, nil
+	case "WorleyGenerator":
+		wg := new(WorleyGenerator)
+		wg.Return = WorleyReturn(s.Params["Return"])
+		wg.Jitter = s.Params["Jitter"]
+		if err := wg.SetPermutations(s.Permutations); err != nil {
+			return nil, true, err
+		}
+		return wg, true, nil
+	}
+	return nil, false, nil
+}
+
+func (s *ModuleSnapshot) input2D(name string) (NoiseyGet2D, error) {
+	in, ok := s.Inputs[name]
+	if !ok {
+		return nil, fmt.Errorf("Snapshot of %s is missing the input \"%s\".\n", s.Type, name)
+	}
+	return Restore2D(in)
+}
+
+func (s *ModuleSnapshot) input3D(name string) (NoiseyGet3D, error) {
+	in, ok := s.Inputs[name]
+	if !ok {
+		return nil, fmt.Errorf("Snapshot of %s is missing the input \"%s\".\n", s.Type, name)
+	}
+	return Restore3D(in)
+}
+
+// Restore2D recreates a module and all of the modules it uses from a snapshot.
+func Restore2D(s *ModuleSnapshot) (NoiseyGet2D, error) {
+	if s == nil {
+		return nil, fmt.Errorf("Unable to restore a nil snapshot.\n")
+	}
+	if src, ok, err := restoreSource(s); ok {
+		if err != nil {
+			return nil, err
+		}
+		return src.(NoiseyGet2D), nil
+	}
+
+	var err error
+	p := s.Params
+	switch s.Type {
+	case "FBMGenerator2D":
+		fbm := NewFBMGenerator2D(nil, int(p["Octaves"]), p["Persistence"], p["Lacunarity"], p["Frequency"])
+		fbm.NoiseMaker, err = s.input2D("NoiseMaker")
+		return &fbm, err
+	case "RidgedGenerator2D":
+		ridged := NewRidgedGenerator2D(nil, int(p["Octaves"]), p["Lacunarity"], p["Frequency"], p["Offset"], p["Gain"], p["Exponent"])
+		ridged.NoiseMaker, err = s.input2D("NoiseMaker")
+		return &ridged, err
+	case "Select2D":
+		sel := NewSelect2D(nil, nil, nil, p["LowerBound"], p["UpperBound"], p["EdgeFalloff"])
+		if sel.SourceA, err = s.input2D("SourceA"); err != nil {
+			return nil, err
+		}
+		if sel.SourceB, err = s.input2D("SourceB"); err != nil {
+			return nil, err
+		}
+		sel.Control, err = s.input2D("Control")
+		return &sel, err
+	case "Scale2D":
+		scale := NewScale2D(nil, p["Scale"], p["Bias"], p["Min"], p["Max"])
+		scale.Source, err = s.input2D("Source")
+		return &scale, err
+	case "Slice2D":
+		slice := NewSlice2D(nil, Axis(p["Axis"]), p["Offset"])
+		slice.Source, err = s.input3D("Source")
+		return &slice, err
+	case "Falloff2D":
+		falloff := NewFalloff2D(FalloffShape(p["Shape"]), p["CenterX"], p["CenterY"], p["Radius"], p["Exponent"])
+		return &falloff, nil
+	case "Billow2D":
+		billow := NewBillow2D(nil, int(p["Octaves"]), p["Persistence"], p["Lacunarity"], p["Frequency"])
+		billow.NoiseMaker, err = s.input2D("NoiseMaker")
+		return &billow, err
+	case "Turbulence2D":
+		turb := NewTurbulence2D(nil, nil, nil, p["Frequency"], p["Power"])
+		if turb.Source, err = s.input2D("Source"); err != nil {
+			return nil, err
+		}
+		if turb.XDistort, err = s.input2D("XDistort"); err != nil {
+			return nil, err
+		}
+		turb.YDistort, err = s.input2D("YDistort")
+		return &turb, err
+	case "Kaleidoscope2D":
+		k := NewKaleidoscope2D(nil, int(p["Segments"]), SymmetryMode(p["Symmetry"]))
+		k.CenterX, k.CenterY = p["CenterX"], p["CenterY"]
+		k.Rotation = p["Rotation"]
+		k.Source, err = s.input2D("Source")
+		return &k, err
+	case "Polar2D":
+		polar := NewPolar2D(nil)
+		polar.CenterX, polar.CenterY = p["CenterX"], p["CenterY"]
+		polar.RadiusScale, polar.AngleScale = p["RadiusScale"], p["AngleScale"]
+		polar.Source, err = s.input2D("Source")
+		return &polar, err
+	case "Pattern2D":
+		pattern := NewPattern2D(PatternShape(p["Shape"]), p["Frequency"])
+		return &pattern, nil
+	case "Crackle2D":
+		crackle := NewCrackle2D(nil, p["CellSize"], p["Width"])
+		crackle.Jaggedness = p["Jaggedness"]
+		var cells NoiseyGet2D
+		if cells, err = s.input2D("Cells"); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if crackle.Cells, ok = cells.(*WorleyGenerator); !ok {
+			return nil, fmt.Errorf("Snapshot of Crackle2D has Cells of type %T; expected a WorleyGenerator.\n", cells)
+		}
+		if _, ok = s.Inputs["Warp"]; ok {
+			crackle.Warp, err = s.input2D("Warp")
+		}
+		return &crackle, err
+	case "Caustics2D":
+		caustics, err := s.restoreCaustics()
+		if err != nil {
+			return nil, err
+		}
+		return caustics, nil
+	case "NoiseMap":
+		// check the size against the values before allocating the map, in
+		// floating point so that huge sizes can't overflow
+		w, h := p["Width"], p["Height"]
+		if !(w >= 1.0 && h >= 1.0) || w != math.Trunc(w) || h != math.Trunc(h) ||
+			w > float64(len(s.Values)) || h > float64(len(s.Values))/w || int(w)*int(h) != len(s.Values) {
+			return nil, fmt.Errorf("Snapshot of NoiseMap of size %vx%v has %d values.\n", w, h, len(s.Values))
+		}
+		nm := NewNoiseMap(int(w), int(h), Builder2DBounds{p["MinX"], p["MinY"], p["MaxX"], p["MaxY"]})
+		copy(nm.Values, s.Values)
+		nm.Interpolation = MapInterpolation(p["Interpolation"])
+		return &nm, nil
+	case "ImageSource":
+		m, err := s.input2D("Map")
+		if err != nil {
+			return nil, err
+		}
+		nm, ok := m.(*NoiseMap)
+		if !ok {
+			return nil, fmt.Errorf("Snapshot of ImageSource has a Map of type %T; expected a NoiseMap.\n", m)
+		}
+		return &ImageSource{Map: *nm, Wrap: ImageWrap(p["Wrap"])}, nil
+	}
+	return nil, fmt.Errorf("Unable to restore module type %s as a 2D module.\n", s.Type)
+}
+
+// Restore3D recreates a module and all of the modules it uses from a snapshot.
+func Restore3D(s *ModuleSnapshot) (NoiseyGet3D, error) {
+	if s == nil {
+		return nil, fmt.Errorf("Unable to restore a nil snapshot.\n")
+	}
+	if src, ok, err := restoreSource(s); ok {
+		if err != nil {
+			return nil, err
+		}
+		return src.(NoiseyGet3D), nil
+	}
+
+	var err error
+	p := s.Params
+	switch s.Type {
+	case "FBMGenerator3D":
+		fbm := NewFBMGenerator3D(nil, int(p["Octaves"]), p["Persistence"], p["Lacunarity"], p["Frequency"])
+		fbm.NoiseMaker, err = s.input3D("NoiseMaker")
+		return &fbm, err
+	case "Select3D":
+		sel := NewSelect3D(nil, nil, nil, p["LowerBound"], p["UpperBound"], p["EdgeFalloff"])
+		if sel.SourceA, err = s.input3D("SourceA"); err != nil {
+			return nil, err
+		}
+		if sel.SourceB, err = s.input3D("SourceB"); err != nil {
+			return nil, err
+		}
+		sel.Control, err = s.input3D("Control")
+		return &sel, err
+	case "Extrude3D":
+		extrude := NewExtrude3D(nil, Axis(p["Axis"]))
+		extrude.Source, err = s.input2D("Source")
+		return &extrude, err
+	case "DensityTerrain3D":
+		dt := NewDensityTerrain3D(nil, nil, p["BaseHeight"], p["HeightScale"], p["Squash"], p["Amplitude"])
+		if dt.Surface, err = s.input2D("Surface"); err != nil {
+			return nil, err
+		}
+		dt.Density, err = s.input3D("Density")
+		return &dt, err
+	case "Caustics2D":
+		caustics, err := s.restoreCaustics()
+		if err != nil {
+			return nil, err
+		}
+		return caustics, nil
+	case "Clouds3D":
+		c := NewClouds3D(nil, nil, p["MinY"], p["MaxY"])
+		c.CloudType = p["CloudType"]
+		c.DetailStrength = p["DetailStrength"]
+		c.Density = p["Density"]
+		if c.Coverage, err = s.input2D("Coverage"); err != nil {
+			return nil, err
+		}
+		if c.Shape, err = s.input3D("Shape"); err != nil {
+			return nil, err
+		}
+		if _, ok := s.Inputs["Type"]; ok {
+			if c.Type, err = s.input2D("Type"); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := s.Inputs["Detail"]; ok {
+			c.Detail, err = s.input3D("Detail")
+		}
+		return &c, err
+	}
+	return nil, fmt.Errorf("Unable to restore module type %s as a 3D module.\n", s.Type)
+}
+
+// SnapshotGenerator saves the state of the built generator name. This
+// function must be called after both BuildSources() and BuildGenerators().
+func (cfg *NoiseJSON) SnapshotGenerator(name string) (*ModuleSnapshot, error) {
+	g := cfg.GetGenerator(name)
+	if g == nil {
+		return nil, fmt.Errorf("Generator \"%s\" has not been built.\n", name)
+	}
+	return Snapshot2D(g)
+}
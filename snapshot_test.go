@@ -0,0 +1,59 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+import (
+	"testing"
+)
+
+// TestRestoreBadSnapshots makes sure that broken snapshots are rejected with
+// an error instead of panicking or allocating huge maps.
+func TestRestoreBadSnapshots(t *testing.T) {
+	nm := NewNoiseMap(4, 4, Builder2DBounds{MinX: 0, MinY: 0, MaxX: 1, MaxY: 1})
+	good, err := Snapshot2D(&nm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore2D(good); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := []struct {
+		name          string
+		width, height float64
+	}{
+		{"negative width", -1, 4},
+		{"zero height", 4, 0},
+		{"oversized", 1e6, 1e6},
+		{"overflowing", 1e300, 1e300},
+		{"fractional", 2.5, 6.4},
+		{"too few values", 4, 5},
+	}
+	for _, size := range sizes {
+		s := *good
+		s.Params = map[string]float64{}
+		for k, v := range good.Params {
+			s.Params[k] = v
+		}
+		s.Params["Width"], s.Params["Height"] = size.width, size.height
+		if _, err := Restore2D(&s); err == nil {
+			t.Errorf("%s: restored a NoiseMap of size %vx%v", size.name, size.width, size.height)
+		}
+
+		image := &ModuleSnapshot{Type: "ImageSource", Inputs: map[string]*ModuleSnapshot{"Map": &s}}
+		if _, err := Restore2D(image); err == nil {
+			t.Errorf("%s: restored an ImageSource of size %vx%v", size.name, size.width, size.height)
+		}
+	}
+
+	perlin := NewPerlinSeeded(1)
+	s, err := Snapshot2D(&perlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Gradients = s.Gradients[:5]
+	if _, err := Restore2D(s); err == nil {
+		t.Errorf("restored a PerlinGenerator with 5 gradients")
+	}
+}
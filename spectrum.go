@@ -0,0 +1,264 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the radially averaged power spectrum of a NoiseMap,
+which shows how the variance of the values is spread over the frequencies.
+It shows at a glance whether a source is band-limited, like Perlin noise
+whose power peaks around half a cycle per unit, or falls off as 1/f^n like fBm
+and pink noise:
+
+	spectrum, err := nm.PowerSpectrum(true)
+	slope, err := spectrum.Slope(0.01, 0.25) // about -1 for pink noise
+	err = noisey.WriteSpectrumPNG(file, spectrum, 512, 256)
+
+The power of each frequency of the 2D transform of the map is averaged over
+rings of the same distance from the origin, one sample apart. The power is
+scaled so that the powers of all the frequencies of the transform add up to
+the variance of the values; the rings end at the Nyquist frequency, so the
+frequencies in the corners of the transform aren't in any of them.
+
+The transform treats the map as if it repeats, so a map that doesn't tile
+has a seam at its edges that spreads power over every frequency. A Hann
+window fades the edges out to avoid that, at the cost of blurring the
+spectrum slightly.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// PowerSpectrum is the radially averaged power spectrum of a map.
+type PowerSpectrum struct {
+	// Frequencies are the mean frequency of each ring in cycles per sample,
+	// from 1 sample to the Nyquist frequency of 0.5
+	Frequencies []float64
+
+	// Power is the mean power of the frequencies in each ring
+	Power []float64
+
+	// Counts are the number of frequencies in each ring
+	Counts []int
+
+	// SamplesPerUnit is the number of samples of the map along X per unit of
+	// its bounds, which turns the Frequencies into cycles per unit
+	SamplesPerUnit float64
+}
+
+// PowerSpectrum returns the radially averaged power spectrum of the map with
+// its mean removed. If window is true the map is faded out towards its edges
+// with a Hann window first, which should be used for maps that don't tile.
+func (nm *NoiseMap) PowerSpectrum(window bool) (*PowerSpectrum, error) {
+	if nm.Width < 2 || nm.Height < 2 || len(nm.Values) < nm.Width*nm.Height {
+		return nil, fmt.Errorf("Unable to compute the power spectrum of a %dx%d map with %d values.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	n := nm.Width * nm.Height
+	mean, _ := meanDeviation(nm.Values[:n])
+
+	// the window takes away power, which is given back by dividing by the
+	// mean square of its weights
+	data := make([]complex128, n)
+	weights := 0.0
+	for y := 0; y < nm.Height; y++ {
+		wy := 1.0
+		if window {
+			wy = hannWeight(y, nm.Height)
+		}
+		for x := 0; x < nm.Width; x++ {
+			w := wy
+			if window {
+				w *= hannWeight(x, nm.Width)
+			}
+			weights += w * w
+			data[y*nm.Width+x] = complex((nm.Values[y*nm.Width+x]-mean)*w, 0.0)
+		}
+	}
+	fft2D(data, nm.Width, nm.Height, false)
+
+	// the rings are one sample of the shorter side apart
+	size := nm.Width
+	if nm.Height < size {
+		size = nm.Height
+	}
+	rings := size / 2
+	ps := new(PowerSpectrum)
+	ps.Frequencies = make([]float64, rings)
+	ps.Power = make([]float64, rings)
+	ps.Counts = make([]int, rings)
+	if width := nm.Bounds.MaxX - nm.Bounds.MinX; width > 0.0 {
+		ps.SamplesPerUnit = float64(nm.Width) / width
+	}
+
+	scale := 0.0
+	if weights > 0.0 {
+		scale = 1.0 / (float64(n) * weights)
+	}
+	for y := 0; y < nm.Height; y++ {
+		fy := fftFrequency(y, nm.Height) / float64(nm.Height)
+		for x := 0; x < nm.Width; x++ {
+			fx := fftFrequency(x, nm.Width) / float64(nm.Width)
+			f := math.Hypot(fx, fy)
+			ring := int(math.Floor(f*float64(size)+0.5)) - 1
+			if ring < 0 || ring >= rings {
+				continue
+			}
+			c := data[y*nm.Width+x]
+			ps.Frequencies[ring] += f
+			ps.Power[ring] += (real(c)*real(c) + imag(c)*imag(c)) * scale
+			ps.Counts[ring]++
+		}
+	}
+	for i, c := range ps.Counts {
+		if c > 0 {
+			ps.Frequencies[i] /= float64(c)
+			ps.Power[i] /= float64(c)
+		}
+	}
+	return ps, nil
+}
+
+// hannWeight returns the weight of the Hann window at sample i of n.
+func hannWeight(i int, n int) float64 {
+	return 0.5 - 0.5*math.Cos(2.0*math.Pi*(float64(i)+0.5)/float64(n))
+}
+
+// Slope fits a line to the logarithm of the power against the logarithm of
+// the frequency of the rings between the frequencies min and max in cycles
+// per sample, and returns its slope: about 0 for white noise, -1 for pink
+// noise and -2 for brown noise.
+func (ps *PowerSpectrum) Slope(min float64, max float64) (float64, error) {
+	var sx, sy, sxx, sxy float64
+	count := 0
+	for i, f := range ps.Frequencies {
+		if ps.Counts[i] == 0 || f < min || f > max || ps.Power[i] <= 0.0 {
+			continue
+		}
+		lx, ly := math.Log(f), math.Log(ps.Power[i])
+		sx += lx
+		sy += ly
+		sxx += lx * lx
+		sxy += lx * ly
+		count++
+	}
+	if count < 2 {
+		return 0.0, fmt.Errorf("Unable to fit the slope of the spectrum to %d frequencies between %v and %v.\n", count, min, max)
+	}
+	n := float64(count)
+	return (n*sxy - sx*sy) / (n*sxx - sx*sx), nil
+}
+
+// Peak returns the frequency in cycles per sample of the ring with the most
+// power, or 0 if there is no power.
+func (ps *PowerSpectrum) Peak() float64 {
+	peak, power := 0.0, 0.0
+	for i, p := range ps.Power {
+		if p > power {
+			peak, power = ps.Frequencies[i], p
+		}
+	}
+	return peak
+}
+
+// Image plots the power against the frequency of the rings on logarithmic
+// axes in a width x height grayscale image, with lines at every power of
+// ten. The frequencies run from the first ring on the left to 0.5 cycles
+// per sample on the right.
+func (ps *PowerSpectrum) Image(width int, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	// the bounds of the axes, in logarithms of ten
+	fmin, fmax := math.Inf(1), math.Log10(0.5)
+	pmin, pmax := math.Inf(1), math.Inf(-1)
+	for i, p := range ps.Power {
+		if ps.Counts[i] == 0 || p <= 0.0 {
+			continue
+		}
+		fmin = math.Min(fmin, math.Log10(ps.Frequencies[i]))
+		pmin = math.Min(pmin, math.Log10(p))
+		pmax = math.Max(pmax, math.Log10(p))
+	}
+	if math.IsInf(fmin, 0) || width < 2 || height < 2 {
+		return img
+	}
+	if fmax <= fmin {
+		fmax = fmin + 1.0
+	}
+	if pmax <= pmin {
+		pmin, pmax = pmin-0.5, pmax+0.5
+	}
+	toX := func(f float64) int {
+		return int(math.Floor((f - fmin) / (fmax - fmin) * float64(width-1)))
+	}
+	toY := func(p float64) int {
+		return int(math.Floor((pmax - p) / (pmax - pmin) * float64(height-1)))
+	}
+
+	grid := color.Gray{208}
+	for d := math.Ceil(fmin); d <= fmax; d++ {
+		x := toX(d)
+		for y := 0; y < height; y++ {
+			img.SetGray(x, y, grid)
+		}
+	}
+	for d := math.Ceil(pmin); d <= pmax; d++ {
+		y := toY(d)
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, grid)
+		}
+	}
+
+	first := true
+	var lastX, lastY int
+	for i, p := range ps.Power {
+		if ps.Counts[i] == 0 || p <= 0.0 {
+			continue
+		}
+		x, y := toX(math.Log10(ps.Frequencies[i])), toY(math.Log10(p))
+		if first {
+			lastX, lastY, first = x, y, false
+		}
+		drawGrayLine(img, lastX, lastY, x, y, color.Gray{0})
+		lastX, lastY = x, y
+	}
+	return img
+}
+
+// drawGrayLine draws a line between two pixels of the image.
+func drawGrayLine(img *image.Gray, x0 int, y0 int, x1 int, y1 int, c color.Gray) {
+	steps := x1 - x0
+	if steps < 0 {
+		steps = -steps
+	}
+	if dy := y1 - y0; dy > steps {
+		steps = dy
+	} else if -dy > steps {
+		steps = -dy
+	}
+	if steps == 0 {
+		img.SetGray(x0, y0, c)
+		return
+	}
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / float64(steps)
+		x := x0 + int(math.Floor(float64(x1-x0)*t+0.5))
+		y := y0 + int(math.Floor(float64(y1-y0)*t+0.5))
+		img.SetGray(x, y, c)
+	}
+}
+
+// WriteSpectrumPNG writes the Image() of the spectrum to w as a PNG.
+func WriteSpectrumPNG(w io.Writer, ps *PowerSpectrum, width int, height int) error {
+	return png.Encode(w, ps.Image(width, height))
+}
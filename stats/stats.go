@@ -0,0 +1,330 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+Package stats measures the distribution of the values of a generator so that
+tests can check that changes to a pipeline don't shift the terrain it makes:
+
+	report, err := stats.Sample(terrain, noisey.Builder2DBounds{0, 0, 16, 16}, stats.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.CheckMean(0.0, 0.05); err != nil {
+		t.Error(err)
+	}
+	if err := report.CheckIsotropy(0.2); err != nil {
+		t.Error(err)
+	}
+
+Sample() builds a grid of values over a region and reports their mean,
+variance, extremes and histogram. The isotropy check compares the variogram
+of the generator, half the mean squared difference of the values of two
+points, in several directions at the same distance; noise that looks the same
+in every direction has about the same variogram in each of them.
+
+A Report can be saved as JSON and later used as the baseline of Compare(),
+which fails if the distribution moved by more than a Tolerance. The
+coordinates are picked by a noisey.SplitMixSource, so a generator always
+gets the same report.
+*/
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tbogdala/noisey"
+)
+
+// Options are the settings of Sample(). The zero value uses the defaults.
+type Options struct {
+	// Width and Height are the size of the grid of values; 256x256 if 0
+	Width, Height int
+
+	// Bins is the number of bins of the histogram; 32 if 0
+	Bins int
+
+	// HistogramMin and HistogramMax are the range of the histogram. If they
+	// are equal the bounds of noisey.ValueRange2D() are used, or the lowest
+	// and highest values if those are infinite.
+	HistogramMin, HistogramMax float64
+
+	// Lag is the distance between the points of the variograms; 1/32 of the
+	// width of the region if 0
+	Lag float64
+
+	// Directions is the number of directions of the variograms, spread
+	// evenly over half a turn; 4 if 0
+	Directions int
+
+	// Pairs is the number of pairs of points of each variogram; 4096 if 0
+	Pairs int
+}
+
+// histogramString draws a histogram as rows of bars.
+func histogramString(h *noisey.Histogram) string {
+	var b strings.Builder
+	largest := 0
+	for _, c := range h.Counts {
+		if c > largest {
+			largest = c
+		}
+	}
+	for i, c := range h.Counts {
+		bar := 0
+		if largest > 0 {
+			bar = c * 40 / largest
+		}
+		fmt.Fprintf(&b, "%9.4f %s %.4f\n", h.Min+float64(i)*h.BinWidth(), strings.Repeat("#", bar), h.BinFraction(i))
+	}
+	return b.String()
+}
+
+// Report is the distribution of the values of a generator over a region.
+type Report struct {
+	Bounds   noisey.Builder2DBounds // the region that was sampled
+	Count    int                    // the number of values that aren't NaN
+	NaN      int                    // the number of NaN values, which are left out of the rest
+	Mean     float64                // the mean of the values
+	Variance float64                // the variance of the values
+	StdDev   float64                // the standard deviation of the values
+	Min      float64                // the lowest value
+	Max      float64                // the highest value
+
+	Histogram *noisey.Histogram // the histogram of the values
+
+	// Lag is the distance between the points of the variograms
+	Lag float64
+
+	// Variograms are half the mean squared differences of the values of
+	// points Lag apart, in directions spread evenly over half a turn
+	// starting along X
+	Variograms []float64
+
+	// Anisotropy is the difference of the largest and the smallest of the
+	// Variograms relative to their mean: 0 for noise that looks the same
+	// in every direction
+	Anisotropy float64
+}
+
+// Sample builds the generator over the bounds and reports the distribution
+// of its values.
+func Sample(gen noisey.NoiseyGet2D, bounds noisey.Builder2DBounds, opts Options) (*Report, error) {
+	if gen == nil {
+		return nil, fmt.Errorf("Unable to sample a nil generator.\n")
+	}
+	if bounds.MaxX <= bounds.MinX || bounds.MaxY <= bounds.MinY {
+		return nil, fmt.Errorf("Unable to sample the empty region %v.\n", bounds)
+	}
+	if opts.Width <= 0 {
+		opts.Width = 256
+	}
+	if opts.Height <= 0 {
+		opts.Height = 256
+	}
+	if opts.Bins <= 0 {
+		opts.Bins = 32
+	}
+	if opts.Lag <= 0.0 {
+		opts.Lag = (bounds.MaxX - bounds.MinX) / 32.0
+	}
+	if opts.Directions <= 0 {
+		opts.Directions = 4
+	}
+	if opts.Pairs <= 0 {
+		opts.Pairs = 4096
+	}
+
+	builder := noisey.NewBuilder2D(gen, opts.Width, opts.Height)
+	builder.Bounds = bounds
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+
+	lo, hi := opts.HistogramMin, opts.HistogramMax
+	if lo == hi {
+		lo, hi = noisey.ValueRange2D(gen)
+	}
+	r := Analyze(builder.Values, opts.Bins, lo, hi)
+	r.Bounds = bounds
+	r.Lag = opts.Lag
+	r.Variograms = Variograms(gen, bounds, opts.Lag, opts.Directions, opts.Pairs)
+	r.Anisotropy = anisotropy(r.Variograms)
+	return r, nil
+}
+
+// Analyze reports the distribution of values, like those of a NoiseMap,
+// with a histogram of a number of bins between min and max. The lowest and
+// highest values are used for bounds that are infinite or equal. NaN values
+// are counted but left out of the rest of the report.
+func Analyze(values []float64, bins int, min float64, max float64) (r *Report) {
+	r = new(Report)
+	r.Min, r.Max = math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, v := range values {
+		if math.IsNaN(v) {
+			r.NaN++
+			continue
+		}
+		r.Count++
+		sum += v
+		r.Min = math.Min(r.Min, v)
+		r.Max = math.Max(r.Max, v)
+	}
+	if r.Count > 0 {
+		r.Mean = sum / float64(r.Count)
+		squares := 0.0
+		for _, v := range values {
+			if !math.IsNaN(v) {
+				squares += (v - r.Mean) * (v - r.Mean)
+			}
+		}
+		r.Variance = squares / float64(r.Count)
+		r.StdDev = math.Sqrt(r.Variance)
+	} else {
+		r.Min, r.Max = 0.0, 0.0
+	}
+
+	if math.IsInf(min, 0) || math.IsInf(max, 0) || min >= max {
+		min, max = r.Min, r.Max
+	}
+	if bins <= 0 {
+		bins = 32
+	}
+	h := noisey.NewHistogramRange(values, bins, min, max)
+	r.Histogram = &h
+	return r
+}
+
+// Variograms returns half the mean squared difference of the values of the
+// generator at pairs of points lag apart, with the first point of each pair
+// in the bounds, for a number of directions spread evenly over half a turn.
+// The same first points are used in every direction and the pairs with a NaN
+// value are left out.
+func Variograms(gen noisey.NoiseyGet2D, bounds noisey.Builder2DBounds, lag float64, directions int, pairs int) []float64 {
+	rng := noisey.NewSplitMixSource(uint64(pairs))
+	points := make([]noisey.Vec2f, pairs)
+	values := make([]float64, pairs)
+	for i := range points {
+		points[i].X = bounds.MinX + rng.Float64()*(bounds.MaxX-bounds.MinX)
+		points[i].Y = bounds.MinY + rng.Float64()*(bounds.MaxY-bounds.MinY)
+		values[i] = gen.Get2D(points[i].X, points[i].Y)
+	}
+
+	variograms := make([]float64, directions)
+	for d := range variograms {
+		sin, cos := math.Sincos(math.Pi * float64(d) / float64(directions))
+		dx, dy := cos*lag, sin*lag
+		sum, count := 0.0, 0
+		for i, p := range points {
+			diff := gen.Get2D(p.X+dx, p.Y+dy) - values[i]
+			if !math.IsNaN(diff) {
+				sum += diff * diff
+				count++
+			}
+		}
+		if count > 0 {
+			variograms[d] = sum / (2.0 * float64(count))
+		}
+	}
+	return variograms
+}
+
+// anisotropy returns the spread of the variograms relative to their mean.
+func anisotropy(variograms []float64) float64 {
+	if len(variograms) == 0 {
+		return 0.0
+	}
+	lo, hi, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, v := range variograms {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+		sum += v
+	}
+	mean := sum / float64(len(variograms))
+	if mean == 0.0 {
+		return 0.0
+	}
+	return (hi - lo) / mean
+}
+
+// CheckMean returns an error if the mean is further than tolerance from want.
+func (r *Report) CheckMean(want float64, tolerance float64) error {
+	if math.Abs(r.Mean-want) > tolerance {
+		return fmt.Errorf("The mean is %v, more than %v away from %v.\n", r.Mean, tolerance, want)
+	}
+	return nil
+}
+
+// CheckStdDev returns an error if the standard deviation is further than
+// tolerance from want.
+func (r *Report) CheckStdDev(want float64, tolerance float64) error {
+	if math.Abs(r.StdDev-want) > tolerance {
+		return fmt.Errorf("The standard deviation is %v, more than %v away from %v.\n", r.StdDev, tolerance, want)
+	}
+	return nil
+}
+
+// CheckRange returns an error if any value is outside of [min, max].
+func (r *Report) CheckRange(min float64, max float64) error {
+	if r.Count > 0 && (r.Min < min || r.Max > max) {
+		return fmt.Errorf("The values span [%v, %v], which is outside of [%v, %v].\n", r.Min, r.Max, min, max)
+	}
+	return nil
+}
+
+// CheckIsotropy returns an error if the Anisotropy is larger than max.
+func (r *Report) CheckIsotropy(max float64) error {
+	if r.Anisotropy > max {
+		return fmt.Errorf("The anisotropy is %v, more than %v; the variograms are %v.\n", r.Anisotropy, max, r.Variograms)
+	}
+	return nil
+}
+
+// Tolerance is how far a Report may be from a baseline in Compare(). The
+// fields that are 0 aren't checked.
+type Tolerance struct {
+	Mean       float64 // the largest difference of the means
+	StdDev     float64 // the largest difference of the standard deviations relative to the baseline
+	Histogram  float64 // the largest Distance() of the histograms
+	Anisotropy float64 // the largest increase of the anisotropy
+}
+
+// Compare returns an error describing how the report moved away from a
+// baseline, such as one saved before a refactor, by more than the tolerance.
+func (r *Report) Compare(baseline *Report, tol Tolerance) error {
+	var problems []string
+	if tol.Mean > 0.0 && math.Abs(r.Mean-baseline.Mean) > tol.Mean {
+		problems = append(problems, fmt.Sprintf("the mean moved from %v to %v", baseline.Mean, r.Mean))
+	}
+	if tol.StdDev > 0.0 && math.Abs(r.StdDev-baseline.StdDev) > tol.StdDev*baseline.StdDev {
+		problems = append(problems, fmt.Sprintf("the standard deviation changed from %v to %v", baseline.StdDev, r.StdDev))
+	}
+	if tol.Histogram > 0.0 && r.Histogram != nil && baseline.Histogram != nil {
+		d, err := r.Histogram.Distance(baseline.Histogram)
+		if err != nil {
+			return err
+		}
+		if d > tol.Histogram {
+			problems = append(problems, fmt.Sprintf("the histograms are %v apart", d))
+		}
+	}
+	if tol.Anisotropy > 0.0 && r.Anisotropy-baseline.Anisotropy > tol.Anisotropy {
+		problems = append(problems, fmt.Sprintf("the anisotropy grew from %v to %v", baseline.Anisotropy, r.Anisotropy))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("The distribution changed from the baseline: %s.\n", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// String returns a summary of the report with its histogram.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Count: %d\nNaN: %d\nMean: %.6f\nStdDev: %.6f\nMin: %.6f\nMax: %.6f\n", r.Count, r.NaN, r.Mean, r.StdDev, r.Min, r.Max)
+	fmt.Fprintf(&b, "Variograms (lag %v): %.6f\nAnisotropy: %.4f\n", r.Lag, r.Variograms, r.Anisotropy)
+	if r.Histogram != nil {
+		b.WriteString(histogramString(r.Histogram))
+	}
+	return b.String()
+}
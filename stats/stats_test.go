@@ -0,0 +1,138 @@
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tbogdala/noisey"
+)
+
+// uniform returns n values spread evenly over [min, max).
+func uniform(n int, min float64, max float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = min + (max-min)*float64(i)/float64(n)
+	}
+	return values
+}
+
+// TestAnalyzeUniform checks the report of a uniform distribution against
+// its known mean and standard deviation.
+func TestAnalyzeUniform(t *testing.T) {
+	r := Analyze(uniform(10000, -1.0, 1.0), 20, -1.0, 1.0)
+	if r.Count != 10000 || r.NaN != 0 {
+		t.Fatalf("counted %d values and %d NaN", r.Count, r.NaN)
+	}
+	if err := r.CheckMean(0.0, 1e-3); err != nil {
+		t.Error(err)
+	}
+	if err := r.CheckStdDev(2.0/math.Sqrt(12.0), 1e-3); err != nil {
+		t.Error(err)
+	}
+	if err := r.CheckRange(-1.0, 1.0); err != nil {
+		t.Error(err)
+	}
+	for i := range r.Histogram.Counts {
+		if f := r.Histogram.BinFraction(i); math.Abs(f-0.05) > 2e-4 {
+			t.Errorf("bin %d has %v of the values; expected 0.05", i, f)
+		}
+	}
+
+	if err := r.CheckMean(0.5, 0.1); err == nil {
+		t.Errorf("CheckMean() accepted a mean of %v", r.Mean)
+	}
+	if err := r.CheckStdDev(1.0, 0.1); err == nil {
+		t.Errorf("CheckStdDev() accepted a standard deviation of %v", r.StdDev)
+	}
+	if err := r.CheckRange(-0.5, 0.5); err == nil {
+		t.Errorf("CheckRange() accepted [%v, %v]", r.Min, r.Max)
+	}
+}
+
+// TestAnalyzeNaN makes sure NaN values are counted but don't poison the
+// rest of the report.
+func TestAnalyzeNaN(t *testing.T) {
+	r := Analyze([]float64{0.0, 1.0, math.NaN()}, 8, 0.0, 1.0)
+	if r.Count != 2 || r.NaN != 1 || r.Histogram.NaN != 1 {
+		t.Fatalf("counted %d values and %d NaN", r.Count, r.NaN)
+	}
+	if r.Mean != 0.5 || r.StdDev != 0.5 || r.Min != 0.0 || r.Max != 1.0 {
+		t.Errorf("got the report:\n%v", r)
+	}
+}
+
+// TestDistance checks the distance of histograms of known distributions.
+func TestDistance(t *testing.T) {
+	a := Analyze(uniform(1000, 0.0, 1.0), 10, 0.0, 2.0)
+	same := Analyze(uniform(500, 0.0, 1.0), 10, 0.0, 2.0)
+	half := Analyze(uniform(1000, 0.5, 1.5), 10, 0.0, 2.0)
+	apart := Analyze(uniform(1000, 1.0, 2.0), 10, 0.0, 2.0)
+
+	for _, c := range []struct {
+		name  string
+		other *Report
+		want  float64
+	}{{"same", same, 0.0}, {"half", half, 0.5}, {"apart", apart, 1.0}} {
+		d, err := a.Histogram.Distance(c.other.Histogram)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(d-c.want) > 1e-9 {
+			t.Errorf("the distance to %s is %v; expected %v", c.name, d, c.want)
+		}
+	}
+
+	other := Analyze(uniform(1000, 0.0, 1.0), 20, 0.0, 2.0)
+	if _, err := a.Histogram.Distance(other.Histogram); err == nil {
+		t.Errorf("Distance() compared histograms with different bins")
+	}
+}
+
+// TestCheckIsotropy compares noise that looks the same in every direction
+// with a ramp along X.
+func TestCheckIsotropy(t *testing.T) {
+	bounds := noisey.Builder2DBounds{MinX: 0, MinY: 0, MaxX: 16, MaxY: 16}
+	osg := noisey.NewOpenSimplexSeeded(1)
+	r, err := Sample(&osg, bounds, Options{Width: 64, Height: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckIsotropy(0.2); err != nil {
+		t.Error(err)
+	}
+
+	ramp := noisey.NoiseFunc2D(func(x, y float64) float64 { return x })
+	r, err = Sample(ramp, bounds, Options{Width: 64, Height: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckIsotropy(0.2); err == nil {
+		t.Errorf("CheckIsotropy() accepted a ramp with the variograms %v", r.Variograms)
+	}
+}
+
+// TestCompare checks that a report passes against itself and fails against
+// a shifted baseline.
+func TestCompare(t *testing.T) {
+	values := uniform(1000, 0.0, 1.0)
+	r := Analyze(values, 10, 0.0, 2.0)
+	tol := Tolerance{Mean: 0.01, StdDev: 0.01, Histogram: 0.01, Anisotropy: 0.01}
+	if err := r.Compare(Analyze(values, 10, 0.0, 2.0), tol); err != nil {
+		t.Error(err)
+	}
+
+	shifted := Analyze(uniform(1000, 0.5, 1.5), 10, 0.0, 2.0)
+	if err := r.Compare(shifted, tol); err == nil {
+		t.Errorf("Compare() accepted a baseline with the mean %v", shifted.Mean)
+	}
+	if err := r.Compare(shifted, Tolerance{StdDev: 0.01}); err != nil {
+		t.Errorf("Compare() checked more than the standard deviation: %v", err)
+	}
+	wider := Analyze(uniform(1000, 0.0, 2.0), 10, 0.0, 2.0)
+	if err := r.Compare(wider, Tolerance{StdDev: 0.01}); err == nil {
+		t.Errorf("Compare() accepted a baseline with the standard deviation %v", wider.StdDev)
+	}
+}
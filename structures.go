@@ -0,0 +1,186 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module places structures like villages, dungeons or rocks across an
+infinite world. The world is divided into square cells of Spacing world units
+and each cell has at most one candidate position, jittered away from the
+center of the cell by a hash of the seed and the cell coordinates. Candidates
+are accepted if:
+
+* a random draw for the cell is less than Probability,
+
+* the Mask is greater than Threshold at the position if there is a Mask,
+
+* no other candidate that passes the first two tests and has a higher
+  priority is closer than MinDistance. A candidate can be dropped because of
+  a stronger one that is itself dropped, which leaves a few gaps but means
+  that deciding a cell only needs the cells around it.
+
+Everything only depends on the seed, the cell coordinates and the fixed
+priority of each cell, so the same structures are found no matter which
+region is queried or in which order:
+
+	villages := noisey.NewStructurePlacer(1234, 256.0)
+	villages.Mask = &flatness
+	villages.MinDistance = 300.0
+	near, err := villages.Query(chunks.ChunkBounds(coord))
+
+Use a different seed for each kind of structure.
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Structure is a placed structure.
+type Structure struct {
+	Position Vec2f   // the world position of the structure
+	Cell     Vec2i   // the grid cell the structure belongs to
+	Priority float64 // the priority [0, 1) used to resolve MinDistance conflicts
+	Hash     uint64  // a random value for the structure that can be used to pick a variant or rotation
+}
+
+// StructurePlacer deterministically places structures on a jittered grid.
+type StructurePlacer struct {
+	Seed        uint64      // the seed the cells are hashed with
+	Spacing     float64     // the size of a grid cell in world units
+	Jitter      float64     // how far the position can move from the center of its cell [0, 1]
+	Probability float64     // the chance [0, 1] of a cell having a candidate
+	Mask        NoiseyGet2D // the optional acceptance mask sampled at the position
+	Threshold   float64     // candidates are accepted where the Mask is greater than this
+	MinDistance float64     // the smallest distance allowed between two structures
+}
+
+// NewStructurePlacer creates a new structure placer with a candidate in every
+// cell jittered anywhere in the cell and no mask or minimum distance.
+func NewStructurePlacer(seed uint64, spacing float64) (sp StructurePlacer) {
+	sp.Seed = seed
+	sp.Spacing = spacing
+	sp.Jitter = 1.0
+	sp.Probability = 1.0
+	return
+}
+
+// String returns a description of the placer and its mask.
+func (sp *StructurePlacer) String() string {
+	return fmt.Sprintf("StructurePlacer{Seed: %v, Spacing: %v, Jitter: %v, Probability: %v, Threshold: %v, MinDistance: %v, Mask: %s}",
+		sp.Seed, sp.Spacing, sp.Jitter, sp.Probability, sp.Threshold, sp.MinDistance, describeModule(sp.Mask))
+}
+
+// candidate returns the candidate of a cell and whether it passes the
+// probability and mask tests.
+func (sp *StructurePlacer) candidate(cx int, cy int) (s Structure, ok bool) {
+	state := sp.Seed ^ uint64(int64(cx))*0x9e3779b97f4a7c15 ^ uint64(int64(cy))*0xc2b2ae3d27d4eb4f
+	jx := uint64ToFloat64(splitMix64(&state))
+	jy := uint64ToFloat64(splitMix64(&state))
+	chance := uint64ToFloat64(splitMix64(&state))
+	s.Priority = uint64ToFloat64(splitMix64(&state))
+	s.Hash = splitMix64(&state)
+
+	s.Cell = Vec2i{cx, cy}
+	s.Position.X = (float64(cx) + 0.5 + (jx-0.5)*sp.Jitter) * sp.Spacing
+	s.Position.Y = (float64(cy) + 0.5 + (jy-0.5)*sp.Jitter) * sp.Spacing
+	if chance >= sp.Probability {
+		return s, false
+	}
+	if sp.Mask != nil && !(sp.Mask.Get2D(s.Position.X, s.Position.Y) > sp.Threshold) {
+		return s, false
+	}
+	return s, true
+}
+
+// beats returns true if structure a wins a MinDistance conflict with b.
+func (a *Structure) beats(b *Structure) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.Cell.Y != b.Cell.Y {
+		return a.Cell.Y < b.Cell.Y
+	}
+	return a.Cell.X < b.Cell.X
+}
+
+// accepted returns the structure of a cell if it has one.
+func (sp *StructurePlacer) accepted(cx int, cy int) (Structure, bool) {
+	s, ok := sp.candidate(cx, cy)
+	if !ok || sp.MinDistance <= 0.0 {
+		return s, ok
+	}
+
+	// the stronger candidates are not checked for conflicts of their own
+	r := int(math.Ceil(sp.MinDistance / sp.Spacing))
+	minDist2 := sp.MinDistance * sp.MinDistance
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			if x == cx && y == cy {
+				continue
+			}
+			o, ok := sp.candidate(x, y)
+			if !ok || !o.beats(&s) {
+				continue
+			}
+			d := o.Position.Sub(s.Position)
+			if d.Dot(d) < minDist2 {
+				return s, false
+			}
+		}
+	}
+	return s, true
+}
+
+// Query returns the structures with positions inside of the bounds, including
+// the minimum edges but not the maximum ones, ordered by cell from top to
+// bottom and left to right.
+func (sp *StructurePlacer) Query(bounds Builder2DBounds) ([]Structure, error) {
+	if !(sp.Spacing > 0.0) {
+		return nil, fmt.Errorf("Unable to place structures with a Spacing of %v.\n", sp.Spacing)
+	}
+	if sp.Jitter < 0.0 || sp.Jitter > 1.0 {
+		return nil, fmt.Errorf("Unable to place structures with a Jitter of %v; it must be in [0, 1].\n", sp.Jitter)
+	}
+
+	minX := int(math.Floor(bounds.MinX / sp.Spacing))
+	minY := int(math.Floor(bounds.MinY / sp.Spacing))
+	maxX := int(math.Floor(bounds.MaxX / sp.Spacing))
+	maxY := int(math.Floor(bounds.MaxY / sp.Spacing))
+	var found []Structure
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			s, ok := sp.accepted(cx, cy)
+			if !ok {
+				continue
+			}
+			p := s.Position
+			if p.X >= bounds.MinX && p.X < bounds.MaxX && p.Y >= bounds.MinY && p.Y < bounds.MaxY {
+				found = append(found, s)
+			}
+		}
+	}
+	return found, nil
+}
+
+// Near returns the structures within radius of the world coordinate, closest first.
+func (sp *StructurePlacer) Near(x float64, y float64, radius float64) ([]Structure, error) {
+	found, err := sp.Query(Builder2DBounds{x - radius, y - radius, x + radius, y + radius})
+	if err != nil {
+		return nil, err
+	}
+	c := Vec2f{x, y}
+	near := found[:0]
+	for _, s := range found {
+		if s.Position.Sub(c).Length() <= radius {
+			near = append(near, s)
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		return near[i].Position.Sub(c).Length() < near[j].Position.Sub(c).Length()
+	})
+	return near, nil
+}
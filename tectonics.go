@@ -0,0 +1,277 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes continent scale base heightmaps with a simplified model of
+plate tectonics on a flat grid. Random plates are placed on the grid, each
+one either oceanic or continental and drifting with its own velocity. Where
+plates collide the crust is pushed up into mountain chains or pulled down
+into subduction trenches, and where they pull apart rifts and ridges form.
+
+The result is meant to be a base that FBM detail is layered onto:
+
+	tectonics := noisey.NewTectonics(rand.New(rand.NewSource(1)))
+	result, err := tectonics.Generate(256, 256, noisey.Builder2DBounds{0.0, 0.0, 4.0, 4.0})
+	if err != nil {
+		panic(err)
+	}
+	detail := noisey.NewBuilder2D(&fbm, 256, 256)
+	detail.Bounds = result.Heightmap.Bounds
+	detail.Build()
+	detailMap := detail.GetNoiseMap()
+	result.Heightmap.Add(&detailMap)
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Plate is a tectonic plate simulated by Tectonics.
+type Plate struct {
+	Center   Vec2f // the center of the plate in grid points
+	Velocity Vec2f // how far the plate moves in grid points on each step
+	Oceanic  bool  // true if the plate is oceanic crust; continental otherwise
+}
+
+// Tectonics contains the parameters for the plate tectonic simulation.
+type Tectonics struct {
+	Rng             RandomSource // the random number generator used to create the plates
+	Plates          int          // the number of plates
+	OceanicRatio    float64      // the fraction of plates that are oceanic [0, 1]
+	Steps           int          // the number of steps the plates drift for
+	DriftSpeed      float64      // the largest distance in grid points a plate moves on each step
+	ContinentHeight float64      // the base height of continental plates
+	OceanHeight     float64      // the base height of oceanic plates
+	UpliftHeight    float64      // the height added to continental crust where plates collide
+	TrenchDepth     float64      // the depth subtracted from oceanic crust where it subducts
+	BoundaryWidth   float64      // how far in grid points the effects of a boundary spread
+
+	// Warp, if set, displaces the grid coordinates before they are assigned to
+	// plates so the plate boundaries aren't straight lines
+	Warp NoiseyGet2D
+
+	// WarpAmount is how far in grid points Warp can displace a coordinate
+	WarpAmount float64
+}
+
+// TectonicsResult contains the results of Tectonics.Generate().
+type TectonicsResult struct {
+	// Heightmap is the base heightmap averaged over the drift steps
+	Heightmap NoiseMap
+
+	// Plates are the plates at the end of the simulation
+	Plates []Plate
+
+	// PlateIndexes is the index of the plate at each grid point at the end of the simulation
+	PlateIndexes []int
+}
+
+// NewTectonics creates a new simulation with parameters that make heights
+// roughly in the range [-1, 1] where 0.0 is sea level.
+func NewTectonics(rng RandomSource) (t Tectonics) {
+	t.Rng = rng
+	t.Plates = 12
+	t.OceanicRatio = 0.6
+	t.Steps = 5
+	t.DriftSpeed = 2.0
+	t.ContinentHeight = 0.1
+	t.OceanHeight = -0.5
+	t.UpliftHeight = 0.8
+	t.TrenchDepth = 0.4
+	t.BoundaryWidth = 8.0
+	t.WarpAmount = 16.0
+	return
+}
+
+// String returns a description of the simulation parameters.
+func (t *Tectonics) String() string {
+	return fmt.Sprintf("Tectonics{Plates: %v, OceanicRatio: %v, Steps: %v, DriftSpeed: %v, ContinentHeight: %v, OceanHeight: %v, UpliftHeight: %v, TrenchDepth: %v, BoundaryWidth: %v, WarpAmount: %v, Warp: %s}",
+		t.Plates, t.OceanicRatio, t.Steps, t.DriftSpeed, t.ContinentHeight, t.OceanHeight, t.UpliftHeight, t.TrenchDepth, t.BoundaryWidth, t.WarpAmount, describeModule(t.Warp))
+}
+
+// assignPlates sets the index of the closest plate for each grid point.
+func (t *Tectonics) assignPlates(plates []Plate, warp []Vec2f, width int, height int, indexes []int) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			p := Vec2f{float64(x), float64(y)}.Add(warp[i])
+			best := 0
+			bestDist := math.MaxFloat64
+			for pi := range plates {
+				d := p.Sub(plates[pi].Center)
+				if dd := d.Dot(d); dd < bestDist {
+					best = pi
+					bestDist = dd
+				}
+			}
+			indexes[i] = best
+		}
+	}
+}
+
+// boundaryFeature returns the height change at a grid point of plate a that
+// borders plate b in the direction n.
+func (t *Tectonics) boundaryFeature(plates []Plate, a int, b int, n Vec2f) float64 {
+	pa, pb := &plates[a], &plates[b]
+	c := pa.Velocity.Sub(pb.Velocity).Dot(n)
+	if t.DriftSpeed > 0.0 {
+		c /= t.DriftSpeed
+	}
+	c = math.Max(-1.0, math.Min(1.0, c))
+
+	if c > 0.0 {
+		// converging
+		switch {
+		case !pa.Oceanic:
+			return t.UpliftHeight * c
+		case !pb.Oceanic:
+			return -t.TrenchDepth * c
+		case a < b:
+			return -t.TrenchDepth * c
+		default:
+			// a volcanic island arc on the overriding oceanic plate
+			return t.UpliftHeight * 0.5 * c
+		}
+	}
+
+	// diverging, which makes ridges in oceans and rift valleys on continents
+	if pa.Oceanic {
+		return -t.UpliftHeight * 0.25 * c
+	}
+	return t.TrenchDepth * 0.5 * c
+}
+
+// Generate runs the simulation on a width x height grid and returns the
+// heightmap covering bounds.
+func (t *Tectonics) Generate(width int, height int, bounds Builder2DBounds) (result TectonicsResult, err error) {
+	if t.Rng == nil {
+		return result, fmt.Errorf("Unable to simulate tectonics without a random source.\n")
+	}
+	if width < 1 || height < 1 {
+		return result, fmt.Errorf("Unable to simulate tectonics on a grid of size %dx%d.\n", width, height)
+	}
+	if t.Plates < 1 {
+		return result, fmt.Errorf("Unable to simulate tectonics with %d plates.\n", t.Plates)
+	}
+
+	plates := make([]Plate, t.Plates)
+	for i := range plates {
+		angle := t.Rng.Float64() * 2.0 * math.Pi
+		speed := t.Rng.Float64() * t.DriftSpeed
+		plates[i].Center = Vec2f{t.Rng.Float64() * float64(width), t.Rng.Float64() * float64(height)}
+		plates[i].Velocity = Vec2f{math.Cos(angle) * speed, math.Sin(angle) * speed}
+		plates[i].Oceanic = t.Rng.Float64() < t.OceanicRatio
+	}
+
+	// the warp is fixed during the simulation so the boundaries keep their shape as they drift
+	n := width * height
+	warp := make([]Vec2f, n)
+	if t.Warp != nil {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				u := bounds.MinX + (bounds.MaxX-bounds.MinX)*float64(x)/float64(width)
+				v := bounds.MinY + (bounds.MaxY-bounds.MinY)*float64(y)/float64(height)
+				warp[y*width+x] = Vec2f{t.Warp.Get2D(u, v), t.Warp.Get2D(u+17.31, v+41.77)}.Scale(t.WarpAmount)
+			}
+		}
+	}
+
+	result.Heightmap = NewNoiseMap(width, height, bounds)
+	indexes := make([]int, n)
+	feature := make([]float64, n)
+	dist := make([]float64, n)
+	steps := t.Steps
+	if steps < 1 {
+		steps = 1
+	}
+
+	for step := 0; step < steps; step++ {
+		t.assignPlates(plates, warp, width, height, indexes)
+
+		// find the features of the boundary grid points
+		for i := range dist {
+			dist[i] = math.MaxFloat64
+			feature[i] = 0.0
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				i := y*width + x
+				sum, count := 0.0, 0
+				for _, nb := range [4]Vec2i{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+					nx, ny := x+nb.X, y+nb.Y
+					if nx < 0 || nx >= width || ny < 0 || ny >= height {
+						continue
+					}
+					if b := indexes[ny*width+nx]; b != indexes[i] {
+						sum += t.boundaryFeature(plates, indexes[i], b, Vec2f{float64(nb.X), float64(nb.Y)})
+						count++
+					}
+				}
+				if count > 0 {
+					feature[i] = sum / float64(count)
+					dist[i] = 0.0
+				}
+			}
+		}
+
+		// spread the boundary features to the rest of the grid points with a
+		// two pass chamfer transform, carrying along the nearest feature
+		chamferPass := func(x, y, dx, dy int, cost float64) {
+			nx, ny := x+dx, y+dy
+			if nx < 0 || nx >= width || ny < 0 || ny >= height {
+				return
+			}
+			i, j := y*width+x, ny*width+nx
+			if indexes[i] != indexes[j] || dist[j] == math.MaxFloat64 {
+				return
+			}
+			if d := dist[j] + cost; d < dist[i] {
+				dist[i] = d
+				feature[i] = feature[j]
+			}
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				chamferPass(x, y, -1, 0, 1.0)
+				chamferPass(x, y, 0, -1, 1.0)
+				chamferPass(x, y, -1, -1, math.Sqrt2)
+				chamferPass(x, y, 1, -1, math.Sqrt2)
+			}
+		}
+		for y := height - 1; y >= 0; y-- {
+			for x := width - 1; x >= 0; x-- {
+				chamferPass(x, y, 1, 0, 1.0)
+				chamferPass(x, y, 0, 1, 1.0)
+				chamferPass(x, y, 1, 1, math.Sqrt2)
+				chamferPass(x, y, -1, 1, math.Sqrt2)
+			}
+		}
+
+		// accumulate the heights of this step
+		for i := range indexes {
+			base := t.ContinentHeight
+			if plates[indexes[i]].Oceanic {
+				base = t.OceanHeight
+			}
+			h := base
+			if dist[i] != math.MaxFloat64 && t.BoundaryWidth > 0.0 {
+				d := dist[i] / t.BoundaryWidth
+				h += feature[i] * math.Exp(-d*d)
+			}
+			result.Heightmap.Values[i] += h / float64(steps)
+		}
+
+		for i := range plates {
+			plates[i].Center = plates[i].Center.Add(plates[i].Velocity)
+		}
+	}
+
+	result.Plates = plates
+	result.PlateIndexes = indexes
+	return result, nil
+}
@@ -0,0 +1,218 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module prints a NoiseMap to a terminal with ANSI colors, for a quick
+look at a map on a headless server or in the log of a CI job without
+writing an image and opening it somewhere else:
+
+	nm := builder.GetNoiseMap()
+	err := noisey.WriteTerminal(os.Stdout, &nm, noisey.TerminalOptions{Columns: 80})
+
+Every character is an upper half block, "▀", with the foreground colored by
+one pixel and the background by the pixel below it, so a character shows
+two pixels that are about square in a usual terminal font. The map is
+scaled to Columns pixels across, averaging the values of the map under each
+pixel, and to as many rows as keep its aspect ratio.
+
+With Terminal256 the colors are the closest of the color cube and gray ramp
+of the 256 colors xterm and nearly every other terminal has; with
+TerminalTrueColor they are written as they are, which most modern terminals
+show and which they tell with COLORTERM=truecolor.
+
+*/
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// TerminalColors selects the ANSI colors WriteTerminal uses.
+type TerminalColors int
+
+const (
+	// Terminal256 uses the closest of the 256 colors of xterm.
+	Terminal256 TerminalColors = iota
+
+	// TerminalTrueColor uses 24 bit colors.
+	TerminalTrueColor
+)
+
+// terminalColorsNames are the names of the TerminalColors.
+var terminalColorsNames = []string{"256", "truecolor"}
+
+// String returns the name of the colors.
+func (tc TerminalColors) String() string {
+	if tc >= 0 && int(tc) < len(terminalColorsNames) {
+		return terminalColorsNames[tc]
+	}
+	return fmt.Sprintf("TerminalColors(%d)", int(tc))
+}
+
+// TerminalOptions controls how WriteTerminal prints a map.
+type TerminalOptions struct {
+	// Columns is the width of the preview in characters; the width of the
+	// map if it is 0
+	Columns int
+
+	// Colors selects the ANSI colors that are written
+	Colors TerminalColors
+
+	// Gradient colors the values; if it is nil they go from black at -1.0
+	// to white at 1.0
+	Gradient *ColorGradient
+}
+
+// terminalCube are the levels of each channel of the 6x6x6 color cube of
+// the 256 colors.
+var terminalCube = [6]int{0, 95, 135, 175, 215, 255}
+
+// terminalCubeIndex returns the level of the color cube closest to v.
+func terminalCubeIndex(v uint8) int {
+	best := 0
+	for i, level := range terminalCube {
+		if absInt(int(v)-level) < absInt(int(v)-terminalCube[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// absInt returns the absolute value of i.
+func absInt(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// terminal256 returns the one of the 256 colors closest to c, either from
+// the color cube at 16 to 231 or from the gray ramp at 232 to 255.
+func terminal256(c color.NRGBA) int {
+	distance := func(r, g, b int) int {
+		dr, dg, db := int(c.R)-r, int(c.G)-g, int(c.B)-b
+		return dr*dr + dg*dg + db*db
+	}
+	ri, gi, bi := terminalCubeIndex(c.R), terminalCubeIndex(c.G), terminalCubeIndex(c.B)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDistance := distance(terminalCube[ri], terminalCube[gi], terminalCube[bi])
+
+	// the gray ramp goes from 8 to 238 in steps of 10
+	gray := (int(c.R) + int(c.G) + int(c.B)) / 3
+	step := (gray - 8 + 5) / 10
+	if step < 0 {
+		step = 0
+	} else if step > 23 {
+		step = 23
+	}
+	level := 8 + 10*step
+	if distance(level, level, level) < cubeDistance {
+		return 232 + step
+	}
+	return cube
+}
+
+// terminalColor returns the ANSI parameters that set the foreground, or
+// the background if background is true, to c.
+func terminalColor(c color.NRGBA, colors TerminalColors, background bool) string {
+	code := 38
+	if background {
+		code = 48
+	}
+	if colors == TerminalTrueColor {
+		return fmt.Sprintf("%d;2;%d;%d;%d", code, c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("%d;5;%d", code, terminal256(c))
+}
+
+// terminalSample returns the mean of the values of the map in the columns
+// [x0, x1) and rows [y0, y1), ignoring NaN.
+func terminalSample(nm *NoiseMap, x0, x1, y0, y1 int) float64 {
+	sum, count := 0.0, 0
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			v := nm.Values[y*nm.Width+x]
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// WriteTerminal writes the map to w as rows of half block characters with
+// ANSI colors, each row ending by resetting the colors.
+func WriteTerminal(w io.Writer, nm *NoiseMap, opts TerminalOptions) error {
+	if nm.Width <= 0 || nm.Height <= 0 || len(nm.Values) < nm.Width*nm.Height {
+		return fmt.Errorf("Unable to print a %dx%d map with %d values.\n", nm.Width, nm.Height, len(nm.Values))
+	}
+	if opts.Columns < 0 {
+		return fmt.Errorf("Unable to print a map %d columns wide.\n", opts.Columns)
+	}
+	gradient := opts.Gradient
+	if gradient == nil {
+		cg := NewColorGradient(
+			ColorKey{Position: -1.0, Color: color.NRGBA{0, 0, 0, 255}},
+			ColorKey{Position: 1.0, Color: color.NRGBA{255, 255, 255, 255}})
+		gradient = &cg
+	}
+
+	width := opts.Columns
+	if width == 0 {
+		width = nm.Width
+	}
+	height := int(math.Floor(float64(width)*float64(nm.Height)/float64(nm.Width) + 0.5))
+	if height < 1 {
+		height = 1
+	}
+
+	// pixel returns the color of the pixel at px, py of the preview
+	span := func(p, n, size int) (int, int) {
+		start := p * size / n
+		end := (p + 1) * size / n
+		if end <= start {
+			end = start + 1
+		}
+		return start, end
+	}
+	pixel := func(px, py int) color.NRGBA {
+		x0, x1 := span(px, width, nm.Width)
+		y0, y1 := span(py, height, nm.Height)
+		return gradient.Color(terminalSample(nm, x0, x1, y0, y1))
+	}
+
+	bw := bufio.NewWriter(w)
+	for py := 0; py < height; py += 2 {
+		var lastTop, lastBottom string
+		for px := 0; px < width; px++ {
+			top := terminalColor(pixel(px, py), opts.Colors, false)
+			bottom := "49"
+			if py+1 < height {
+				bottom = terminalColor(pixel(px, py+1), opts.Colors, true)
+			}
+			// only the colors that change are written
+			switch {
+			case top != lastTop && bottom != lastBottom:
+				fmt.Fprintf(bw, "\x1b[%s;%sm", top, bottom)
+			case top != lastTop:
+				fmt.Fprintf(bw, "\x1b[%sm", top)
+			case bottom != lastBottom:
+				fmt.Fprintf(bw, "\x1b[%sm", bottom)
+			}
+			lastTop, lastBottom = top, bottom
+			bw.WriteString("▀")
+		}
+		bw.WriteString("\x1b[0m\n")
+	}
+	return bw.Flush()
+}
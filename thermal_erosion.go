@@ -0,0 +1,128 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module performs thermal erosion, also known as talus relaxation, on a
+built heightmap. Wherever the slope between neighboring grid points is steeper
+than the talus angle, material slides down to the lower points until the
+slope settles. This smooths out unrealistic spikes and cliffs.
+
+	thermal := noisey.NewThermalErosion(50, noisey.TalusFromAngle(30.0, 0.01), 0.5)
+	smoothed, err := thermal.Erode(&heights)
+
+Reference material:
+* Olsen's "Realtime Procedural Terrain Generation": http://web.mit.edu/cesium/Public/terrain.pdf
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// ThermalErosion contains the parameters for thermal erosion.
+type ThermalErosion struct {
+	Iterations int     // the number of times material is moved over the whole map
+	Talus      float64 // the largest height difference between neighbors one grid step apart that is stable
+	Rate       float64 // the fraction of the unstable material that is moved on each iteration (0, 1]
+}
+
+// NewThermalErosion creates a new thermal erosion state.
+func NewThermalErosion(iterations int, talus float64, rate float64) (te ThermalErosion) {
+	te.Iterations = iterations
+	te.Talus = talus
+	te.Rate = rate
+	return
+}
+
+// TalusFromAngle converts a talus angle in degrees to the height difference
+// used by ThermalErosion. cellSize is the distance between neighboring grid
+// points measured in the same units as the heights.
+func TalusFromAngle(degrees float64, cellSize float64) float64 {
+	return math.Tan(degrees*math.Pi/180.0) * cellSize
+}
+
+// String returns a description of the erosion parameters.
+func (te *ThermalErosion) String() string {
+	return fmt.Sprintf("ThermalErosion{Iterations: %v, Talus: %v, Rate: %v}", te.Iterations, te.Talus, te.Rate)
+}
+
+// thermalNeighbors are the offsets of the eight neighbors of a grid point and their distance.
+var thermalNeighbors = [8]struct {
+	dx, dy int
+	dist   float64
+}{
+	{-1, -1, math.Sqrt2}, {0, -1, 1.0}, {1, -1, math.Sqrt2},
+	{-1, 0, 1.0}, {1, 0, 1.0},
+	{-1, 1, math.Sqrt2}, {0, 1, 1.0}, {1, 1, math.Sqrt2},
+}
+
+// Erode relaxes a copy of heightmap and returns it. The total amount of
+// material in the map is preserved.
+func (te *ThermalErosion) Erode(heightmap *NoiseMap) (NoiseMap, error) {
+	if len(heightmap.Values) != heightmap.Width*heightmap.Height {
+		return NoiseMap{}, fmt.Errorf("Unable to erode a heightmap of size %dx%d with %d values.\n", heightmap.Width, heightmap.Height, len(heightmap.Values))
+	}
+	if te.Talus < 0.0 || te.Rate <= 0.0 || te.Rate > 1.0 {
+		return NoiseMap{}, fmt.Errorf("Invalid thermal erosion parameters: Talus %v must not be negative and Rate %v must be in (0, 1].\n", te.Talus, te.Rate)
+	}
+
+	hm := heightmap.Clone()
+	w, h := hm.Width, hm.Height
+	delta := make([]float64, len(hm.Values))
+	var excess [8]float64
+
+	for iter := 0; iter < te.Iterations; iter++ {
+		for i := range delta {
+			delta[i] = 0.0
+		}
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				i := y*w + x
+				v := hm.Values[i]
+
+				// find how far above the stable slope each lower neighbor is
+				total := 0.0
+				largest := 0.0
+				for n, nb := range thermalNeighbors {
+					excess[n] = 0.0
+					nx, ny := x+nb.dx, y+nb.dy
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					d := v - hm.Values[ny*w+nx] - te.Talus*nb.dist
+					if d > 0.0 {
+						excess[n] = d
+						total += d
+						if d > largest {
+							largest = d
+						}
+					}
+				}
+				if total == 0.0 {
+					continue
+				}
+
+				// move half of the largest excess so the slope is never overshot,
+				// shared between the neighbors by how unstable they are
+				moved := te.Rate * largest * 0.5
+				delta[i] -= moved
+				for n, nb := range thermalNeighbors {
+					if excess[n] > 0.0 {
+						delta[(y+nb.dy)*w+x+nb.dx] += moved * excess[n] / total
+					}
+				}
+			}
+		}
+
+		for i, d := range delta {
+			hm.Values[i] += d
+		}
+	}
+
+	return hm, nil
+}
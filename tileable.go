@@ -0,0 +1,117 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module makes a built NoiseMap tileable by blending its edges, for the
+pipelines that can't be made periodic at the source:
+
+	tiled, err := noisey.MakeTileable(&texture, noisey.TileOffset, 32)
+
+Each axis is blended in its own pass over BlendWidth grid points from each
+edge, with a smooth weight that reaches its strongest at the edge:
+
+TileOffset blends the map with a copy of itself that's shifted by half of its
+size and wrapped around. The edges of the shifted copy are the middle of the
+map so they already match when tiled, and its own seam across the middle is
+hidden because the shifted copy isn't used there. This keeps the features of
+the map at the edges intact and is the best choice for most maps.
+
+TileMirror blends the map with its mirror image, so at the edges each value is
+the average of itself and the value on the opposite edge. It changes less of
+the map, but features near the edges are reflected and ghosted.
+
+The values in the middle of the map, further than BlendWidth from every edge,
+are left as they were. The Bounds are unchanged.
+
+*/
+
+import "fmt"
+
+// TileMode selects how MakeTileable() blends the edges of a map.
+type TileMode int
+
+const (
+	// TileOffset blends the edges with a half size shifted copy of the map.
+	TileOffset TileMode = iota
+
+	// TileMirror blends the edges with the mirror image of the map.
+	TileMirror
+)
+
+// String returns the name of the mode.
+func (m TileMode) String() string {
+	switch m {
+	case TileOffset:
+		return "TileOffset"
+	case TileMirror:
+		return "TileMirror"
+	}
+	return fmt.Sprintf("TileMode(%d)", int(m))
+}
+
+// tileWeight returns the weight [0, 1] of the blended copy for a point that
+// is d away from the nearest edge, falling smoothly from 1.0 at the edge to
+// 0.0 at blendWidth.
+func tileWeight(d float64, blendWidth float64) float64 {
+	if d >= blendWidth {
+		return 0.0
+	}
+	t := 1.0 - d/blendWidth
+	return t * t * (3.0 - 2.0*t)
+}
+
+// MakeTileable returns a copy of the map with its edges blended over
+// blendWidth grid points so that it wraps around seamlessly. The blendWidth
+// must be at least 1 and no more than half of the Width and the Height.
+func MakeTileable(nm *NoiseMap, mode TileMode, blendWidth int) (NoiseMap, error) {
+	w, h := nm.Width, nm.Height
+	if w <= 0 || h <= 0 || len(nm.Values) != w*h {
+		return NoiseMap{}, fmt.Errorf("Unable to make a map of size %dx%d with %d values tileable.\n", w, h, len(nm.Values))
+	}
+	if mode < TileOffset || mode > TileMirror {
+		return NoiseMap{}, fmt.Errorf("Unable to make a map tileable with the unknown mode %v.\n", mode)
+	}
+	if blendWidth < 1 || blendWidth > w/2 || blendWidth > h/2 {
+		return NoiseMap{}, fmt.Errorf("Unable to make a map of size %dx%d tileable with a blend width of %d; it must be in [1, %d].\n", w, h, blendWidth, minInt(w, h)/2)
+	}
+
+	// the X axis is blended first and the Y axis is blended from the result,
+	// which keeps the wrap along X because the weights only depend on Y
+	src := nm.Values
+	out := nm.Clone()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			wt := tileWeight(float64(minInt(x, w-1-x)), float64(blendWidth))
+			if wt == 0.0 {
+				continue
+			}
+			other := src[y*w+(x+w/2)%w]
+			if mode == TileMirror {
+				other = src[y*w+w-1-x]
+				wt *= 0.5
+			}
+			out.Values[y*w+x] = lerp(src[y*w+x], other, wt)
+		}
+	}
+
+	src = make([]float64, len(out.Values))
+	copy(src, out.Values)
+	for y := 0; y < h; y++ {
+		wt := tileWeight(float64(minInt(y, h-1-y)), float64(blendWidth))
+		if wt == 0.0 {
+			continue
+		}
+		oy := (y + h/2) % h
+		if mode == TileMirror {
+			oy = h - 1 - y
+			wt *= 0.5
+		}
+		for x := 0; x < w; x++ {
+			out.Values[y*w+x] = lerp(src[y*w+x], src[oy*w+x], wt)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module displaces the coordinates of a source by two distortion
+generators before sampling it, which breaks up regular patterns like the
+rings of wood or the veins of marble:
+
+	fbm := noisey.NewFBMGenerator2D(&perlin, 4, 0.5, 2.0, 1.0)
+	turb := noisey.NewTurbulence2D(&rings, &fbm, &fbm, 2.0, 0.1)
+
+The distortion generators are sampled at the coordinates multiplied by
+Frequency and their values are multiplied by Power, so Power is the largest
+displacement in world units for generators in [-1, 1]. YDistort is sampled at
+a fixed offset so the same generator can be used for both axes without moving
+every point along the diagonal.
+
+Reference material:
+* Libnoise's turbulence module: http://libnoise.sourceforge.net/docs/classnoise_1_1module_1_1Turbulence.html
+
+*/
+
+import "fmt"
+
+// turbulenceOffset is added to the coordinates YDistort is sampled at.
+const turbulenceOffset = 12.4143
+
+// Turbulence2D is a module that samples Source at coordinates displaced by
+// XDistort and YDistort.
+type Turbulence2D struct {
+	Source    NoiseyGet2D // the noise that is displaced
+	XDistort  NoiseyGet2D // the noise that displaces the X coordinate
+	YDistort  NoiseyGet2D // the noise that displaces the Y coordinate
+	Frequency float64     // the frequency the distortion generators are sampled at
+	Power     float64     // what the distortion values are multiplied by
+}
+
+// NewTurbulence2D creates a new turbulence module.
+func NewTurbulence2D(src NoiseyGet2D, xDistort NoiseyGet2D, yDistort NoiseyGet2D, frequency float64, power float64) (turb Turbulence2D) {
+	turb.Source = src
+	turb.XDistort = xDistort
+	turb.YDistort = yDistort
+	turb.Frequency = frequency
+	turb.Power = power
+	return
+}
+
+// Clone2D returns a deep copy of the module and its generators.
+func (turb *Turbulence2D) Clone2D() NoiseyGet2D {
+	c := *turb
+	c.Source = DeepCopy2D(turb.Source)
+	c.XDistort = DeepCopy2D(turb.XDistort)
+	c.YDistort = DeepCopy2D(turb.YDistort)
+	return &c
+}
+
+// SetFrequency validates and changes the frequency.
+func (turb *Turbulence2D) SetFrequency(frequency float64) error {
+	if err := validateParam(GenTurbulence2D, "Frequency", frequency); err != nil {
+		return err
+	}
+	turb.Frequency = frequency
+	return nil
+}
+
+// SetPower validates and changes the power.
+func (turb *Turbulence2D) SetPower(power float64) error {
+	if err := validateParam(GenTurbulence2D, "Power", power); err != nil {
+		return err
+	}
+	turb.Power = power
+	return nil
+}
+
+// String returns a description of the module and its generators.
+func (turb *Turbulence2D) String() string {
+	return fmt.Sprintf("Turbulence2D{Frequency: %v, Power: %v, Source: %s, XDistort: %s, YDistort: %s}",
+		turb.Frequency, turb.Power, describeModule(turb.Source), describeModule(turb.XDistort), describeModule(turb.YDistort))
+}
+
+// Get2D samples Source at the displaced coordinate.
+func (turb *Turbulence2D) Get2D(x float64, y float64) float64 {
+	fx, fy := x*turb.Frequency, y*turb.Frequency
+	dx := turb.XDistort.Get2D(fx, fy) * turb.Power
+	dy := turb.YDistort.Get2D(fx+turbulenceOffset, fy+turbulenceOffset) * turb.Power
+	return turb.Source.Get2D(x+dx, y+dy)
+}
+
+// Range2D returns the bounds of the values of Get2D(), which are those of Source.
+func (turb *Turbulence2D) Range2D() (min float64, max float64) {
+	return ValueRange2D(turb.Source)
+}
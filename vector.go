@@ -0,0 +1,108 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/* This module contains helpers for working with the vector types of the package. */
+
+import "math"
+
+// GetV2 calculates the noise value of s at the coordinate v.
+func GetV2(s NoiseyGet2D, v Vec2f) float64 {
+	return s.Get2D(v.X, v.Y)
+}
+
+// GetV3 calculates the noise value of s at the coordinate v.
+func GetV3(s NoiseyGet3D, v Vec3f) float64 {
+	return s.Get3D(v.X, v.Y, v.Z)
+}
+
+// Add returns the sum of v and o.
+func (v Vec2f) Add(o Vec2f) Vec2f {
+	return Vec2f{v.X + o.X, v.Y + o.Y}
+}
+
+// Sub returns the difference of v and o.
+func (v Vec2f) Sub(o Vec2f) Vec2f {
+	return Vec2f{v.X - o.X, v.Y - o.Y}
+}
+
+// Scale returns v with each component multiplied by s.
+func (v Vec2f) Scale(s float64) Vec2f {
+	return Vec2f{v.X * s, v.Y * s}
+}
+
+// Dot returns the dot product of v and o.
+func (v Vec2f) Dot(o Vec2f) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Lerp returns the linear interpolation between v and o where t is 0.0 at v and 1.0 at o.
+func (v Vec2f) Lerp(o Vec2f, t float64) Vec2f {
+	return Vec2f{lerp(v.X, o.X, t), lerp(v.Y, o.Y, t)}
+}
+
+// Length returns the euclidean length of v.
+func (v Vec2f) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Add returns the sum of v and o.
+func (v Vec3f) Add(o Vec3f) Vec3f {
+	return Vec3f{v.X + o.X, v.Y + o.Y, v.Z + o.Z}
+}
+
+// Sub returns the difference of v and o.
+func (v Vec3f) Sub(o Vec3f) Vec3f {
+	return Vec3f{v.X - o.X, v.Y - o.Y, v.Z - o.Z}
+}
+
+// Scale returns v with each component multiplied by s.
+func (v Vec3f) Scale(s float64) Vec3f {
+	return Vec3f{v.X * s, v.Y * s, v.Z * s}
+}
+
+// Dot returns the dot product of v and o.
+func (v Vec3f) Dot(o Vec3f) float64 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z
+}
+
+// Lerp returns the linear interpolation between v and o where t is 0.0 at v and 1.0 at o.
+func (v Vec3f) Lerp(o Vec3f, t float64) Vec3f {
+	return Vec3f{lerp(v.X, o.X, t), lerp(v.Y, o.Y, t), lerp(v.Z, o.Z, t)}
+}
+
+// Length returns the euclidean length of v.
+func (v Vec3f) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Add returns the sum of v and o.
+func (v Vec4f) Add(o Vec4f) Vec4f {
+	return Vec4f{v.X + o.X, v.Y + o.Y, v.Z + o.Z, v.W + o.W}
+}
+
+// Sub returns the difference of v and o.
+func (v Vec4f) Sub(o Vec4f) Vec4f {
+	return Vec4f{v.X - o.X, v.Y - o.Y, v.Z - o.Z, v.W - o.W}
+}
+
+// Scale returns v with each component multiplied by s.
+func (v Vec4f) Scale(s float64) Vec4f {
+	return Vec4f{v.X * s, v.Y * s, v.Z * s, v.W * s}
+}
+
+// Dot returns the dot product of v and o.
+func (v Vec4f) Dot(o Vec4f) float64 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z + v.W*o.W
+}
+
+// Lerp returns the linear interpolation between v and o where t is 0.0 at v and 1.0 at o.
+func (v Vec4f) Lerp(o Vec4f, t float64) Vec4f {
+	return Vec4f{lerp(v.X, o.X, t), lerp(v.Y, o.Y, t), lerp(v.Z, o.Z, t), lerp(v.W, o.W, t)}
+}
+
+// Length returns the euclidean length of v.
+func (v Vec4f) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
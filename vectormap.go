@@ -0,0 +1,106 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module contains the VectorMap type which holds a grid of 2D vectors,
+such as wind or flow directions, as two NoiseMap channels. Both channels
+have the same size and Bounds so each channel can be used on its own
+wherever a NoiseMap can.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// VectorMap is a grid of 2D vectors stored as two channels.
+type VectorMap struct {
+	U NoiseMap // the X component of each vector
+	V NoiseMap // the Y component of each vector
+}
+
+// NewVectorMap creates a new vector map of the given size with all vectors set to zero.
+func NewVectorMap(width int, height int, bounds Builder2DBounds) (vm VectorMap) {
+	vm.U = NewNoiseMap(width, height, bounds)
+	vm.V = NewNoiseMap(width, height, bounds)
+	return
+}
+
+// Clone returns a deep copy of the map.
+func (vm *VectorMap) Clone() VectorMap {
+	return VectorMap{vm.U.Clone(), vm.V.Clone()}
+}
+
+// String returns a description of the map.
+func (vm *VectorMap) String() string {
+	return fmt.Sprintf("VectorMap{Width: %d, Height: %d, Bounds: %v}", vm.U.Width, vm.U.Height, vm.U.Bounds)
+}
+
+// Get returns the vector at the grid point (x, y) the same way NoiseMap.Get() does.
+func (vm *VectorMap) Get(x int, y int) Vec2f {
+	return Vec2f{vm.U.Get(x, y), vm.V.Get(x, y)}
+}
+
+// Set changes the vector at the grid point (x, y).
+func (vm *VectorMap) Set(x int, y int, v Vec2f) {
+	vm.U.Set(x, y, v.X)
+	vm.V.Set(x, y, v.Y)
+}
+
+// Sample returns the interpolated vector at the world coordinate (x, y).
+func (vm *VectorMap) Sample(x float64, y float64) Vec2f {
+	return Vec2f{vm.U.GetInterpolated(x, y), vm.V.GetInterpolated(x, y)}
+}
+
+// Magnitude returns a map of the length of each vector.
+func (vm *VectorMap) Magnitude() NoiseMap {
+	nm := NewNoiseMap(vm.U.Width, vm.U.Height, vm.U.Bounds)
+	for i := range nm.Values {
+		nm.Values[i] = math.Hypot(vm.U.Values[i], vm.V.Values[i])
+	}
+	return nm
+}
+
+// Direction returns a map of the angle of each vector in radians (-Pi, Pi]
+// measured from the +X axis towards the +Y axis.
+func (vm *VectorMap) Direction() NoiseMap {
+	nm := NewNoiseMap(vm.U.Width, vm.U.Height, vm.U.Bounds)
+	for i := range nm.Values {
+		nm.Values[i] = math.Atan2(vm.V.Values[i], vm.U.Values[i])
+	}
+	return nm
+}
+
+// encodeUnit maps a value in [-1, 1] to a byte in [0, 255].
+func encodeUnit(v float64) uint8 {
+	v = math.Max(-1.0, math.Min(1.0, v))
+	return uint8(math.Floor((v+1.0)*127.5 + 0.5))
+}
+
+// RGImage returns an image with the X and Y components of the vectors in
+// [-1, 1] mapped to [0, 255] in the red and green channels. Blue is 0 and the
+// image is opaque.
+func (vm *VectorMap) RGImage() *image.NRGBA {
+	w, h := vm.U.Width, vm.U.Height
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := vm.Get(x, y)
+			img.SetNRGBA(x, y, color.NRGBA{encodeUnit(v.X), encodeUnit(v.Y), 0, 255})
+		}
+	}
+	return img
+}
+
+// WriteRGPNG writes RGImage() to w as a PNG.
+func (vm *VectorMap) WriteRGPNG(w io.Writer) error {
+	return png.Encode(w, vm.RGImage())
+}
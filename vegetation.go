@@ -0,0 +1,200 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module decides where plants grow on a heightmap. Each species has
+ranges of elevation, slope and moisture it grows in and, optionally, a list of
+the biomes it grows in. Vegetation combines them with a mask into a density
+map in [0, 1] for every species and can scatter instances of a species with
+a random rotation and scale where its density is high:
+
+	veg := noisey.NewVegetation(rng, &heightmap, []noisey.Species{pine, grass})
+	veg.Moisture = &moisture
+	densities, err := veg.DensityMaps()
+	trees, err := veg.Instances(0, &densities[0])
+
+Slopes are measured as rise over run in world units, using the Bounds of the
+heightmap for the run, so 1.0 is a 45 degree slope. Near the edges of each
+range the density fades out over Softness of the width of the range.
+
+Instances are scattered with PoissonDisk. Dense areas use the MinSpacing of
+the species and sparse areas the MaxSpacing, and each point is then kept with
+a chance equal to the density so that nothing grows where the density is 0.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// Species describes where a type of plant grows and how it's scattered.
+type Species struct {
+	Name string
+
+	MinElevation float64 // the lowest height of the heightmap the species grows at
+	MaxElevation float64 // the highest height of the heightmap the species grows at
+	MinSlope     float64 // the flattest slope the species grows on
+	MaxSlope     float64 // the steepest slope the species grows on
+	MinMoisture  float64 // the driest moisture the species grows in
+	MaxMoisture  float64 // the wettest moisture the species grows in
+	Biomes       []int   // the biome indexes the species grows in; empty for all of them
+	Softness     float64 // the part [0, 1] of each range that the density fades out over
+	Density      float64 // the density where the conditions are ideal [0, 1]
+
+	MinSpacing float64 // the distance between instances where the density is 1.0
+	MaxSpacing float64 // the distance between instances where the density is close to 0.0
+	MinScale   float64 // the smallest random scale of an instance
+	MaxScale   float64 // the largest random scale of an instance
+}
+
+// PlantInstance is a plant placed by Vegetation.Instances().
+type PlantInstance struct {
+	Species  int     // the index of the species
+	Position Vec2f   // the world position
+	Rotation float64 // the rotation around the up axis in radians [0, 2*Pi)
+	Scale    float64 // the scale in [MinScale, MaxScale]
+}
+
+// Vegetation combines terrain maps into plant density maps.
+type Vegetation struct {
+	Rng       RandomSource // random number generator interface used for instances
+	Elevation *NoiseMap    // the heightmap the plants grow on
+	Moisture  NoiseyGet2D  // the optional moisture sampled at world coordinates; 0.0 is used without one
+	Mask      NoiseyGet2D  // the optional mask in [0, 1] the densities are multiplied by
+	Biomes    NoiseyGet2D  // the optional biome index at world coordinates, such as a BiomeClassifier
+	Species   []Species
+}
+
+// NewVegetation creates a new vegetation generator for the heightmap.
+func NewVegetation(rng RandomSource, elevation *NoiseMap, species []Species) (veg Vegetation) {
+	veg.Rng = rng
+	veg.Elevation = elevation
+	veg.Species = species
+	return
+}
+
+// String returns a description of the generator and its sources.
+func (veg *Vegetation) String() string {
+	names := make([]string, len(veg.Species))
+	for i := range veg.Species {
+		names[i] = veg.Species[i].Name
+	}
+	return fmt.Sprintf("Vegetation{Species: %v, Moisture: %s, Mask: %s, Biomes: %s}",
+		names, describeModule(veg.Moisture), describeModule(veg.Mask), describeModule(veg.Biomes))
+}
+
+// vegetationBand returns 1.0 inside of [min, max] fading to 0.0 at the edges
+// over softness of the width of the range.
+func vegetationBand(v float64, min float64, max float64, softness float64) float64 {
+	if v < min || v > max {
+		return 0.0
+	}
+	fade := (max - min) * softness * 0.5
+	if fade <= 0.0 {
+		return 1.0
+	}
+	t := math.Min(1.0, math.Min(v-min, max-v)/fade)
+	return t * t * (3.0 - 2.0*t)
+}
+
+// density returns the density of a species for the terrain values at a point.
+func (s *Species) density(elevation float64, slope float64, moisture float64, biome int) float64 {
+	if len(s.Biomes) > 0 {
+		found := false
+		for _, b := range s.Biomes {
+			if b == biome {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0.0
+		}
+	}
+	d := s.Density
+	d *= vegetationBand(elevation, s.MinElevation, s.MaxElevation, s.Softness)
+	d *= vegetationBand(slope, s.MinSlope, s.MaxSlope, s.Softness)
+	d *= vegetationBand(moisture, s.MinMoisture, s.MaxMoisture, s.Softness)
+	return math.Max(0.0, math.Min(1.0, d))
+}
+
+// DensityMaps returns a density map in [0, 1] for each species on the same
+// grid as the Elevation map.
+func (veg *Vegetation) DensityMaps() ([]NoiseMap, error) {
+	hm := veg.Elevation
+	if hm == nil || hm.Width <= 0 || hm.Height <= 0 || len(hm.Values) != hm.Width*hm.Height {
+		return nil, fmt.Errorf("Unable to place vegetation without a valid Elevation map.\n")
+	}
+
+	maps := make([]NoiseMap, len(veg.Species))
+	for i := range maps {
+		maps[i] = NewNoiseMap(hm.Width, hm.Height, hm.Bounds)
+	}
+	dx := (hm.Bounds.MaxX - hm.Bounds.MinX) / float64(hm.Width)
+	dy := (hm.Bounds.MaxY - hm.Bounds.MinY) / float64(hm.Height)
+	for y := 0; y < hm.Height; y++ {
+		wy := hm.Bounds.MinY + float64(y)*dy
+		for x := 0; x < hm.Width; x++ {
+			wx := hm.Bounds.MinX + float64(x)*dx
+
+			elevation := hm.Values[y*hm.Width+x]
+			gx, gy := hm.worldGradient(x, y)
+			slope := math.Sqrt(gx*gx + gy*gy)
+			moisture := 0.0
+			if veg.Moisture != nil {
+				moisture = veg.Moisture.Get2D(wx, wy)
+			}
+			mask := 1.0
+			if veg.Mask != nil {
+				mask = math.Max(0.0, math.Min(1.0, veg.Mask.Get2D(wx, wy)))
+			}
+			biome := -1
+			if veg.Biomes != nil {
+				biome = int(veg.Biomes.Get2D(wx, wy))
+			}
+
+			for i := range veg.Species {
+				maps[i].Values[y*hm.Width+x] = mask * veg.Species[i].density(elevation, slope, moisture, biome)
+			}
+		}
+	}
+	return maps, nil
+}
+
+// Instances scatters instances of a species over its density map, which is
+// usually one of the maps returned by DensityMaps().
+func (veg *Vegetation) Instances(species int, density *NoiseMap) ([]PlantInstance, error) {
+	if species < 0 || species >= len(veg.Species) {
+		return nil, fmt.Errorf("Unable to place instances of species %d; there are %d species.\n", species, len(veg.Species))
+	}
+	if veg.Rng == nil {
+		return nil, fmt.Errorf("Unable to place instances without a random number generator.\n")
+	}
+	s := &veg.Species[species]
+
+	// PoissonDisk expects densities in [-1, 1]
+	signed := NewScale2D(density, 2.0, -1.0, -1.0, 1.0)
+	ps := NewPoissonDisk(veg.Rng, s.MinSpacing, s.MaxSpacing, &signed)
+	points, err := ps.Sample(density.Bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]PlantInstance, 0, len(points))
+	for _, p := range points {
+		if veg.Rng.Float64() >= density.Get2D(p.X, p.Y) {
+			continue
+		}
+		instances = append(instances, PlantInstance{
+			Species:  species,
+			Position: p,
+			Rotation: veg.Rng.Float64() * 2.0 * math.Pi,
+			Scale:    lerp(s.MinScale, s.MaxScale, veg.Rng.Float64()),
+		})
+	}
+	return instances, nil
+}
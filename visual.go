@@ -0,0 +1,205 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module renders the scenes of a NoiseJSON configuration to images and
+compares them to reference images, so that changes which alter how a
+pipeline looks are caught even when they don't break anything. A scene is a
+generator built over a rectangle and colored by a gradient:
+
+	"Scenes": {
+		"island": {"Generator": "terrain", "Width": 256, "Height": 256,
+			"Bounds": {"MinX": 0, "MinY": 0, "MaxX": 4, "MaxY": 4}, "Gradient": "heights"}
+	}
+
+	img, err := cfg.RenderScene("island")
+	diff, err := noisey.CompareImages(reference, img, noisey.DefaultVisualTolerance)
+	if diff.Failed {
+		png.Encode(file, diff.Image)
+	}
+
+The images are compared pixel by pixel with the CIE76 color difference, the
+distance of the colors in the CIELAB color space, which follows how different
+people see two colors better than the difference of their RGB channels: a
+difference of about 2.3 is just noticeable. Pixels that are further apart
+than the PixelDelta of the tolerance count as different, and the comparison
+fails if more than the MaxFraction of the pixels are.
+
+The diff image shows the reference dimmed to gray with the pixels that
+differ in red, brighter the more they differ. The regress command of the
+noisey tool checks every scene of a configuration against a directory of
+reference images and writes the diff images of the ones that fail.
+
+*/
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// SceneJSON is the JSON configuration of an image of a generator.
+type SceneJSON struct {
+	// Generator is the name of the generator to render
+	Generator string
+
+	// Width and Height are the size of the image in pixels
+	Width, Height int
+
+	// Bounds is the rectangle of the generator that is rendered
+	Bounds Builder2DBounds
+
+	// Gradient is the name of the gradient of the configuration that colors
+	// the image; the values go from black at -1 to white at 1 if it's empty
+	Gradient string `json:",omitempty"`
+
+	// Normalize stretches the values to fill [-1, 1] before they are colored
+	Normalize bool `json:",omitempty"`
+}
+
+// RenderScene renders the scene with the name. BuildSources() and
+// BuildGenerators() must have been called.
+func (cfg *NoiseJSON) RenderScene(name string) (*image.NRGBA, error) {
+	scene, ok := cfg.Scenes[name]
+	if !ok {
+		return nil, fmt.Errorf("Undefined scene (%s).\n", name)
+	}
+	if scene.Width <= 0 || scene.Height <= 0 {
+		return nil, fmt.Errorf("Unable to render the scene (%s) of size %dx%d.\n", name, scene.Width, scene.Height)
+	}
+	gen := cfg.GetGenerator(scene.Generator)
+	if gen == nil {
+		return nil, fmt.Errorf("Undefined generator (%s) in the scene (%s).\n", scene.Generator, name)
+	}
+
+	var cg ColorGradient
+	if scene.Gradient != "" {
+		var err error
+		if cg, err = cfg.GetGradient(scene.Gradient); err != nil {
+			return nil, err
+		}
+	} else {
+		cg.AddKey(-1.0, color.NRGBA{0, 0, 0, 255})
+		cg.AddKey(1.0, color.NRGBA{255, 255, 255, 255})
+	}
+
+	builder := NewBuilder2D(gen, scene.Width, scene.Height)
+	builder.Bounds = scene.Bounds
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	nm := builder.GetNoiseMap()
+	if scene.Normalize {
+		nm.Normalize(-1.0, 1.0)
+	}
+	return cg.Image(&nm), nil
+}
+
+// VisualTolerance is how much an image may differ from its reference in
+// CompareImages().
+type VisualTolerance struct {
+	// PixelDelta is the largest CIE76 difference of the colors of a pixel
+	// that still counts as the same
+	PixelDelta float64
+
+	// MaxFraction is the largest share of the pixels that may differ
+	MaxFraction float64
+}
+
+// DefaultVisualTolerance allows differences too small to see, such as the
+// rounding of a color channel, and no pixels that visibly differ.
+var DefaultVisualTolerance = VisualTolerance{PixelDelta: 2.3, MaxFraction: 0.0}
+
+// ImageDiff is the result of comparing an image to its reference.
+type ImageDiff struct {
+	Different int     // the number of pixels that differ by more than the PixelDelta
+	Fraction  float64 // the share of the pixels that differ
+	MeanDelta float64 // the mean color difference of the pixels
+	MaxDelta  float64 // the largest color difference of a pixel
+	Failed    bool    // true if the Fraction is more than the MaxFraction
+
+	// Image shows the differing pixels in red over the reference in gray
+	Image *image.NRGBA
+}
+
+// String returns a description of the difference.
+func (d *ImageDiff) String() string {
+	return fmt.Sprintf("ImageDiff{Different: %d (%.4f%%), MeanDelta: %.4f, MaxDelta: %.4f, Failed: %v}",
+		d.Different, d.Fraction*100.0, d.MeanDelta, d.MaxDelta, d.Failed)
+}
+
+// CompareImages compares an image to its reference with the tolerance. The
+// images have to be the same size.
+func CompareImages(reference image.Image, img image.Image, tol VisualTolerance) (*ImageDiff, error) {
+	rb, ib := reference.Bounds(), img.Bounds()
+	if rb.Dx() != ib.Dx() || rb.Dy() != ib.Dy() {
+		return nil, fmt.Errorf("Unable to compare a %dx%d image to a %dx%d reference.\n", ib.Dx(), ib.Dy(), rb.Dx(), rb.Dy())
+	}
+
+	d := new(ImageDiff)
+	d.Image = image.NewNRGBA(image.Rect(0, 0, rb.Dx(), rb.Dy()))
+	sum := 0.0
+	for y := 0; y < rb.Dy(); y++ {
+		for x := 0; x < rb.Dx(); x++ {
+			rc := reference.At(rb.Min.X+x, rb.Min.Y+y)
+			delta := colorDelta(rc, img.At(ib.Min.X+x, ib.Min.Y+y))
+			sum += delta
+			d.MaxDelta = math.Max(d.MaxDelta, delta)
+
+			if delta > tol.PixelDelta {
+				d.Different++
+				// brighter reds for larger differences, full at 4x the tolerance
+				t := math.Min(1.0, delta/(4.0*math.Max(tol.PixelDelta, 1.0)))
+				d.Image.SetNRGBA(x, y, color.NRGBA{uint8(128.0 + 127.0*t), 0, 0, 255})
+				continue
+			}
+			gray := color.GrayModel.Convert(rc).(color.Gray)
+			d.Image.SetNRGBA(x, y, color.NRGBA{gray.Y / 3, gray.Y / 3, gray.Y / 3, 255})
+		}
+	}
+	if n := rb.Dx() * rb.Dy(); n > 0 {
+		d.Fraction = float64(d.Different) / float64(n)
+		d.MeanDelta = sum / float64(n)
+	}
+	d.Failed = d.Fraction > tol.MaxFraction
+	return d, nil
+}
+
+// colorDelta returns the CIE76 difference of two colors over black, or 100
+// times the difference of their alpha if that is larger.
+func colorDelta(a color.Color, b color.Color) float64 {
+	al, aa, ab, alpha1 := colorLab(a)
+	bl, ba, bb, alpha2 := colorLab(b)
+	delta := math.Sqrt((al-bl)*(al-bl) + (aa-ba)*(aa-ba) + (ab-bb)*(ab-bb))
+	return math.Max(delta, 100.0*math.Abs(alpha1-alpha2))
+}
+
+// colorLab returns the CIELAB coordinates of the sRGB color over black under
+// the D65 white point, and its alpha in [0, 1].
+func colorLab(c color.Color) (l float64, a float64, b float64, alpha float64) {
+	r16, g16, b16, a16 := c.RGBA()
+	linear := func(v uint32) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	r, g, bl := linear(r16), linear(g16), linear(b16)
+
+	x := (0.4124*r + 0.3576*g + 0.1805*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.0193*r + 0.1192*g + 0.9505*bl) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16.0) / 116.0
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116.0*fy - 16.0, 500.0 * (fx - fy), 200.0 * (fy - fz), float64(a16) / 0xffff
+}
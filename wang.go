@@ -0,0 +1,243 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module bakes a set of Wang tiles from a generator. Every edge of a tile
+has a color and any two tiles can be placed side by side when the colors of
+the edges they share are the same, so a small set can be assembled into large
+maps that don't repeat in any regular way:
+
+	wang := noisey.NewWangTileGenerator(&fbm, 4.0, 128)
+	tiles, err := wang.Generate()
+	layout, err := tiles.Layout(rng, 16, 16)
+	terrain, err := tiles.Assemble(layout, 16, 16)
+
+The set is complete: there is one tile for every combination of edge colors,
+so HorizontalColors^2 * VerticalColors^2 tiles are made. The north and south
+edges use the horizontal colors and the east and west edges use the vertical
+colors. North is the edge at the highest Y, like the rows of a NoiseMap.
+
+Each tile samples the generator in regions of world space far apart from
+each other, so tiles differ. Within BlendWidth of an edge the tile fades to a
+region shared by every edge of the same color, sampled so that it continues
+across the edge into the neighbouring tile, and where two edges meet it fades
+to a region shared by every corner. The values on both sides of a matching edge
+therefore come from one continuous part of the generator.
+
+The blends are divided by the length of their weights, which keeps the
+contrast of zero centered noise from dropping where regions overlap.
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// WangTile is one baked tile of a WangTileSet.
+type WangTile struct {
+	North int      // the color of the edge at the highest Y
+	East  int      // the color of the edge at the highest X
+	South int      // the color of the edge at the lowest Y
+	West  int      // the color of the edge at the lowest X
+	Map   NoiseMap // the values of the tile with Bounds from 0.0 to TileSize
+}
+
+// WangTileSet is the output of WangTileGenerator.Generate().
+type WangTileSet struct {
+	HorizontalColors int     // the number of colors of the north and south edges
+	VerticalColors   int     // the number of colors of the east and west edges
+	TileSize         float64 // the world size of each tile
+	Resolution       int     // the number of grid points along each side of a tile
+	Tiles            []WangTile
+}
+
+// WangTileGenerator contains the parameters for baking Wang tiles.
+type WangTileGenerator struct {
+	Source           NoiseyGet2D // the generator the tiles are sampled from
+	TileSize         float64     // the world size of each tile
+	Resolution       int         // the number of grid points along each side of a tile
+	HorizontalColors int         // the number of colors of the north and south edges
+	VerticalColors   int         // the number of colors of the east and west edges
+	BlendWidth       float64     // the world distance from the edges over which tiles fade into the shared regions
+	Spacing          float64     // the world distance between the sampled regions
+}
+
+// NewWangTileGenerator creates a new generator for a set of tiles with two
+// colors for each pair of edges and a BlendWidth of a quarter of the tile.
+func NewWangTileGenerator(src NoiseyGet2D, tileSize float64, resolution int) (wg WangTileGenerator) {
+	wg.Source = src
+	wg.TileSize = tileSize
+	wg.Resolution = resolution
+	wg.HorizontalColors = 2
+	wg.VerticalColors = 2
+	wg.BlendWidth = tileSize * 0.25
+	wg.Spacing = tileSize * 4.0
+	return
+}
+
+// String returns a description of the generator and its source.
+func (wg *WangTileGenerator) String() string {
+	return fmt.Sprintf("WangTileGenerator{TileSize: %v, Resolution: %v, HorizontalColors: %v, VerticalColors: %v, BlendWidth: %v, Spacing: %v, Source: %s}",
+		wg.TileSize, wg.Resolution, wg.HorizontalColors, wg.VerticalColors, wg.BlendWidth, wg.Spacing, describeModule(wg.Source))
+}
+
+// Generate bakes the complete tile set.
+func (wg *WangTileGenerator) Generate() (set WangTileSet, err error) {
+	if wg.Source == nil {
+		return set, fmt.Errorf("Unable to generate Wang tiles without a Source.\n")
+	}
+	if wg.Resolution < 2 || wg.TileSize <= 0.0 {
+		return set, fmt.Errorf("Unable to generate Wang tiles with a Resolution of %d and a TileSize of %v.\n", wg.Resolution, wg.TileSize)
+	}
+	if wg.HorizontalColors < 1 || wg.VerticalColors < 1 {
+		return set, fmt.Errorf("Unable to generate Wang tiles with %d horizontal and %d vertical colors.\n", wg.HorizontalColors, wg.VerticalColors)
+	}
+	if wg.BlendWidth <= 0.0 || wg.BlendWidth > wg.TileSize*0.25 {
+		return set, fmt.Errorf("Unable to generate Wang tiles with a BlendWidth of %v; it must be in (0, %v].\n", wg.BlendWidth, wg.TileSize*0.25)
+	}
+
+	set.HorizontalColors = wg.HorizontalColors
+	set.VerticalColors = wg.VerticalColors
+	set.TileSize = wg.TileSize
+	set.Resolution = wg.Resolution
+
+	// every region gets its own spot along a row of world space: the corner
+	// region first, then the edge colors and then the tile interiors
+	t, b := wg.TileSize, wg.BlendWidth
+	region := func(i int) Vec2f { return Vec2f{float64(i) * wg.Spacing, -wg.Spacing} }
+	corner := region(0)
+	horizontal := func(c int) Vec2f { return region(1 + c) }
+	vertical := func(c int) Vec2f { return region(1 + wg.HorizontalColors + c) }
+	firstTile := 1 + wg.HorizontalColors + wg.VerticalColors
+
+	res := wg.Resolution
+	cell := t / float64(res)
+	bounds := Builder2DBounds{0.0, 0.0, t, t}
+	for n := 0; n < wg.HorizontalColors; n++ {
+		for e := 0; e < wg.VerticalColors; e++ {
+			for s := 0; s < wg.HorizontalColors; s++ {
+				for w := 0; w < wg.VerticalColors; w++ {
+					tile := WangTile{North: n, East: e, South: s, West: w, Map: NewNoiseMap(res, res, bounds)}
+					interior := region(firstTile + len(set.Tiles))
+
+					for y := 0; y < res; y++ {
+						ly := float64(y) * cell
+						for x := 0; x < res; x++ {
+							lx := float64(x) * cell
+
+							// the weights of the edges and the corner are a bilinear
+							// partition near the corners so that each edge only sees
+							// its own color and the shared corner region
+							fn, fe := tileWeight(t-ly, b), tileWeight(t-lx, b)
+							fs, fw := tileWeight(ly, b), tileWeight(lx, b)
+							wn, ws := fn*(1.0-fe)*(1.0-fw), fs*(1.0-fe)*(1.0-fw)
+							we, ww := fe*(1.0-fn)*(1.0-fs), fw*(1.0-fn)*(1.0-fs)
+							wc := (fn + fs) * (fe + fw)
+							wi := (1.0 - fn) * (1.0 - fe) * (1.0 - fs) * (1.0 - fw)
+
+							// the corner region is shared by the four tiles around every corner
+							cx, cy := lx, ly
+							if cx > t*0.5 {
+								cx -= t
+							}
+							if cy > t*0.5 {
+								cy -= t
+							}
+
+							v, sum := 0.0, 0.0
+							add := func(wt float64, origin Vec2f, x float64, y float64) {
+								if wt > 0.0 {
+									v += wt * wg.Source.Get2D(origin.X+x, origin.Y+y)
+									sum += wt * wt
+								}
+							}
+							add(wi, interior, lx, ly)
+							add(wn, horizontal(n), lx, ly-t)
+							add(ws, horizontal(s), lx, ly)
+							add(we, vertical(e), lx-t, ly)
+							add(ww, vertical(w), lx, ly)
+							add(wc, corner, cx, cy)
+							v /= math.Sqrt(sum)
+							tile.Map.Values[y*res+x] = v
+						}
+					}
+					set.Tiles = append(set.Tiles, tile)
+				}
+			}
+		}
+	}
+	return set, nil
+}
+
+// Find returns the index of the tile with the edge colors or -1 if the set
+// doesn't have one.
+func (set *WangTileSet) Find(north int, east int, south int, west int) int {
+	for i := range set.Tiles {
+		tile := &set.Tiles[i]
+		if tile.North == north && tile.East == east && tile.South == south && tile.West == west {
+			return i
+		}
+	}
+	return -1
+}
+
+// Layout returns the tile indexes of a random grid of cols x rows matching
+// tiles, row by row starting at the lowest Y. The edges around the outside
+// of the grid get random colors.
+func (set *WangTileSet) Layout(rng RandomSource, cols int, rows int) ([]int, error) {
+	if cols <= 0 || rows <= 0 {
+		return nil, fmt.Errorf("Unable to lay out a grid of %dx%d Wang tiles.\n", cols, rows)
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("Unable to lay out Wang tiles without a random number generator.\n")
+	}
+	pick := func(n int) int { return minInt(n-1, int(rng.Float64()*float64(n))) }
+
+	layout := make([]int, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			south := pick(set.HorizontalColors)
+			if y > 0 {
+				south = set.Tiles[layout[(y-1)*cols+x]].North
+			}
+			west := pick(set.VerticalColors)
+			if x > 0 {
+				west = set.Tiles[layout[y*cols+x-1]].East
+			}
+			i := set.Find(pick(set.HorizontalColors), pick(set.VerticalColors), south, west)
+			if i < 0 {
+				return nil, fmt.Errorf("Unable to lay out Wang tiles; the set has no tile with a south color of %d and a west color of %d.\n", south, west)
+			}
+			layout[y*cols+x] = i
+		}
+	}
+	return layout, nil
+}
+
+// Assemble places the tiles of a layout made by Layout() into one map of
+// cols*Resolution x rows*Resolution values with Bounds from 0.0 to the
+// world size of the grid.
+func (set *WangTileSet) Assemble(layout []int, cols int, rows int) (NoiseMap, error) {
+	if cols <= 0 || rows <= 0 || len(layout) != cols*rows {
+		return NoiseMap{}, fmt.Errorf("Unable to assemble a grid of %dx%d Wang tiles from a layout of %d tiles.\n", cols, rows, len(layout))
+	}
+	res := set.Resolution
+	nm := NewNoiseMap(cols*res, rows*res, Builder2DBounds{0.0, 0.0, float64(cols) * set.TileSize, float64(rows) * set.TileSize})
+	for ty := 0; ty < rows; ty++ {
+		for tx := 0; tx < cols; tx++ {
+			i := layout[ty*cols+tx]
+			if i < 0 || i >= len(set.Tiles) {
+				return NoiseMap{}, fmt.Errorf("Unable to assemble Wang tiles; the layout uses tile %d of %d.\n", i, len(set.Tiles))
+			}
+			tile := &set.Tiles[i].Map
+			for y := 0; y < res; y++ {
+				copy(nm.Values[(ty*res+y)*nm.Width+tx*res:], tile.Values[y*res:(y+1)*res])
+			}
+		}
+	}
+	return nm, nil
+}
@@ -0,0 +1,115 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This module generates 2D wind vector fields over a heightmap. The wind is the
+prevailing wind plus swirls made from the curl of a large scale noise source,
+which has no sources or sinks so the air flows around instead of piling up.
+The terrain then bends the wind:
+
+* Slopes turn the wind along them instead of up or down them by removing
+  Deflection of the part of the wind that crosses the slope; steep slopes
+  deflect more than gentle ones.
+
+* The wind gets faster with elevation by Speedup per unit of elevation above
+  sea level at 0.0, so peaks and ridges are windy.
+
+The result is a VectorMap on the grid of the heightmap whose Magnitude() and
+Direction() can be taken as separate maps:
+
+	wind := noisey.NewWindField(&perlin)
+	wind.Prevailing = noisey.Vec2f{1.0, 0.25}
+	field, err := wind.Generate(&heightmap)
+	speed := field.Magnitude()
+
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// curlDelta is the step used to differentiate noise for its curl.
+const curlDelta = 0.01
+
+// WindField contains the parameters for generating wind.
+type WindField struct {
+	Noise      NoiseyGet2D // the noise whose curl makes the swirls; nil for none
+	Frequency  float64     // the frequency the noise is sampled at
+	Prevailing Vec2f       // the prevailing wind in world units
+	Turbulence float64     // how strong the swirls are compared to a prevailing wind of 1.0
+	Deflection float64     // how much of the wind across a slope is turned along it [0, 1]
+	Speedup    float64     // how much faster the wind gets per unit of elevation
+}
+
+// NewWindField creates a new wind generator with a west wind and parameters
+// for a heightmap in [-1, 1] with a cell size of about 1.0.
+func NewWindField(noise NoiseyGet2D) (wf WindField) {
+	wf.Noise = noise
+	wf.Frequency = 0.01
+	wf.Prevailing = Vec2f{1.0, 0.0}
+	wf.Turbulence = 0.5
+	wf.Deflection = 0.8
+	wf.Speedup = 1.0
+	return
+}
+
+// String returns a description of the generator and its noise.
+func (wf *WindField) String() string {
+	return fmt.Sprintf("WindField{Frequency: %v, Prevailing: %v, Turbulence: %v, Deflection: %v, Speedup: %v, Noise: %s}",
+		wf.Frequency, wf.Prevailing, wf.Turbulence, wf.Deflection, wf.Speedup, describeModule(wf.Noise))
+}
+
+// noiseCurl returns the curl of the noise at the coordinate, which is the
+// gradient turned by 90 degrees.
+func noiseCurl(noise NoiseyGet2D, x float64, y float64) Vec2f {
+	d := curlDelta
+	dx := (noise.Get2D(x+d, y) - noise.Get2D(x-d, y)) / (2.0 * d)
+	dy := (noise.Get2D(x, y+d) - noise.Get2D(x, y-d)) / (2.0 * d)
+	return Vec2f{dy, -dx}
+}
+
+// Generate creates the wind field for the heightmap.
+func (wf *WindField) Generate(heightmap *NoiseMap) (VectorMap, error) {
+	w, h := heightmap.Width, heightmap.Height
+	if w <= 0 || h <= 0 || len(heightmap.Values) != w*h {
+		return VectorMap{}, fmt.Errorf("Unable to generate wind for a heightmap of size %dx%d with %d values.\n", w, h, len(heightmap.Values))
+	}
+	if wf.Deflection < 0.0 || wf.Deflection > 1.0 {
+		return VectorMap{}, fmt.Errorf("Unable to generate wind with a Deflection of %v; it must be in [0, 1].\n", wf.Deflection)
+	}
+
+	b := heightmap.Bounds
+	dx, dy := (b.MaxX-b.MinX)/float64(w), (b.MaxY-b.MinY)/float64(h)
+	field := NewVectorMap(w, h, b)
+	for y := 0; y < h; y++ {
+		wy := b.MinY + float64(y)*dy
+		for x := 0; x < w; x++ {
+			wx := b.MinX + float64(x)*dx
+			v := wf.Prevailing
+			if wf.Noise != nil && wf.Turbulence != 0.0 {
+				v = v.Add(noiseCurl(wf.Noise, wx*wf.Frequency, wy*wf.Frequency).Scale(wf.Turbulence))
+			}
+			speed := v.Length()
+
+			// turn the wind along the slope, keeping its speed
+			gx, gy := heightmap.worldGradient(x, y)
+			if g := math.Hypot(gx, gy); g > 0.0 && speed > 0.0 {
+				n := Vec2f{gx / g, gy / g}
+				steepness := g / (1.0 + g)
+				v = v.Sub(n.Scale(v.Dot(n) * wf.Deflection * steepness))
+				if l := v.Length(); l > 0.0 {
+					v = v.Scale(speed / l)
+				}
+			}
+
+			v = v.Scale(1.0 + wf.Speedup*math.Max(0.0, heightmap.Values[y*w+x]))
+			field.U.Values[y*w+x] = v.X
+			field.V.Values[y*w+x] = v.Y
+		}
+	}
+	return field, nil
+}
@@ -0,0 +1,216 @@
+package noisey
+
+/* Copyright 2015, Timothy Bogdala <tdb@animal-machine.com>
+See the LICENSE file for more details. */
+
+/*
+
+This is an implementation of Worley noise, also known as cellular noise. Space
+is divided into unit cells that each contain one randomly placed feature
+point and the noise value is based on the distance to the closest feature
+points, which makes cell or bubble like patterns.
+
+Reference material:
+* Steven Worley's "A Cellular Texture Basis Function"
+
+*/
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// WorleyReturn selects which distance WorleyGenerator returns.
+type WorleyReturn int
+
+const (
+	// WorleyF1 returns the distance to the closest feature point.
+	WorleyF1 WorleyReturn = iota
+
+	// WorleyF2 returns the distance to the second closest feature point.
+	WorleyF2
+
+	// WorleyF2MinusF1 returns the difference of the two, which is 0.0 on the edges between cells.
+	WorleyF2MinusF1
+)
+
+// String returns the name of the distance.
+func (r WorleyReturn) String() string {
+	switch r {
+	case WorleyF1:
+		return "WorleyF1"
+	case WorleyF2:
+		return "WorleyF2"
+	case WorleyF2MinusF1:
+		return "WorleyF2MinusF1"
+	}
+	return fmt.Sprintf("WorleyReturn(%d)", int(r))
+}
+
+// worleyTableSize is the size of the permutation table used to place the feature points.
+const worleyTableSize = 256
+
+// WorleyGenerator stores the state information for generating Worley noise.
+type WorleyGenerator struct {
+	Rng          RandomSource // random number generator interface
+	Permutations []int        // the random permutation table
+	Return       WorleyReturn // which distance is returned
+	Jitter       float64      // how far feature points can move from the center of their cells [0, 1]
+}
+
+// NewWorleyGenerator creates a new state object for the Worley noise
+// generator that returns F1 with a Jitter of 1.0.
+func NewWorleyGenerator(rng RandomSource) (wg WorleyGenerator) {
+	wg.Rng = rng
+	wg.Permutations = rng.Perm(worleyTableSize)
+	wg.Return = WorleyF1
+	wg.Jitter = 1.0
+	return
+}
+
+// NewWorleySeeded creates a new Worley noise generator using Go's built in
+// random number generator seeded with seed.
+func NewWorleySeeded(seed int64) WorleyGenerator {
+	return NewWorleyGenerator(rand.New(rand.NewSource(seed)))
+}
+
+// Clone returns a deep copy of the generator. The Rng is shared with the copy.
+func (wg *WorleyGenerator) Clone() *WorleyGenerator {
+	c := *wg
+	c.Permutations = copyInts(wg.Permutations)
+	return &c
+}
+
+// Clone2D returns a deep copy of the generator as a NoiseyGet2D.
+func (wg *WorleyGenerator) Clone2D() NoiseyGet2D {
+	return wg.Clone()
+}
+
+// Clone3D returns a deep copy of the generator as a NoiseyGet3D.
+func (wg *WorleyGenerator) Clone3D() NoiseyGet3D {
+	return wg.Clone()
+}
+
+// SetPermutations validates and replaces the permutation table.
+func (wg *WorleyGenerator) SetPermutations(perm []int) error {
+	if err := validatePermutations(perm, worleyTableSize); err != nil {
+		return err
+	}
+	wg.Permutations = copyInts(perm)
+	return nil
+}
+
+// String returns a description of the generator.
+func (wg *WorleyGenerator) String() string {
+	return fmt.Sprintf("WorleyGenerator{Return: %v, Jitter: %v}", wg.Return, wg.Jitter)
+}
+
+// hash returns a pseudo random value in [0, worleyTableSize) for the integer coordinates.
+func (wg *WorleyGenerator) hash(x int, y int, z int, salt int) int {
+	p := wg.Permutations
+	m := worleyTableSize - 1
+	return p[(p[(p[(x+salt)&m]+y)&m]+z)&m]
+}
+
+// feature returns the offset of the feature point of a cell on one axis.
+func (wg *WorleyGenerator) feature(x int, y int, z int, salt int) float64 {
+	r := (float64(wg.hash(x, y, z, salt)) + 0.5) / worleyTableSize
+	return 0.5 + (r-0.5)*wg.Jitter
+}
+
+// result converts the two closest squared distances into the returned value.
+func (wg *WorleyGenerator) result(f1 float64, f2 float64) float64 {
+	var v float64
+	switch wg.Return {
+	case WorleyF2:
+		v = math.Sqrt(f2)
+	case WorleyF2MinusF1:
+		v = math.Sqrt(f2) - math.Sqrt(f1)
+	default:
+		v = math.Sqrt(f1)
+	}
+	// the distances are mostly in [0, 1] so stretch them to [-1, 1] like the other sources
+	return v*2.0 - 1.0
+}
+
+// distances2D returns the squared distances to the closest and second closest
+// feature points of a 2D coordinate.
+func (wg *WorleyGenerator) distances2D(x float64, y float64) (f1 float64, f2 float64) {
+	cx := int(math.Floor(x))
+	cy := int(math.Floor(y))
+	f1, f2 = math.MaxFloat64, math.MaxFloat64
+	for j := cy - 1; j <= cy+1; j++ {
+		for i := cx - 1; i <= cx+1; i++ {
+			dx := float64(i) + wg.feature(i, j, 0, 0) - x
+			dy := float64(j) + wg.feature(i, j, 0, 101) - y
+			d := dx*dx + dy*dy
+			if d < f1 {
+				f1, f2 = d, f1
+			} else if d < f2 {
+				f2 = d
+			}
+		}
+	}
+	return
+}
+
+// Get2D calculates the noise value at a given 2D coordinate. The values are
+// mostly in the range [-1, 1].
+func (wg *WorleyGenerator) Get2D(x float64, y float64) float64 {
+	return wg.result(wg.distances2D(x, y))
+}
+
+// distances3D returns the squared distances to the closest and second closest
+// feature points of a 3D coordinate.
+func (wg *WorleyGenerator) distances3D(x float64, y float64, z float64) (f1 float64, f2 float64) {
+	cx := int(math.Floor(x))
+	cy := int(math.Floor(y))
+	cz := int(math.Floor(z))
+	f1, f2 = math.MaxFloat64, math.MaxFloat64
+	for k := cz - 1; k <= cz+1; k++ {
+		for j := cy - 1; j <= cy+1; j++ {
+			for i := cx - 1; i <= cx+1; i++ {
+				dx := float64(i) + wg.feature(i, j, k, 0) - x
+				dy := float64(j) + wg.feature(i, j, k, 101) - y
+				dz := float64(k) + wg.feature(i, j, k, 211) - z
+				d := dx*dx + dy*dy + dz*dz
+				if d < f1 {
+					f1, f2 = d, f1
+				} else if d < f2 {
+					f2 = d
+				}
+			}
+		}
+	}
+	return
+}
+
+// Get3D calculates the noise value at a given 3D coordinate. The values are
+// mostly in the range [-1, 1].
+func (wg *WorleyGenerator) Get3D(x float64, y float64, z float64) float64 {
+	return wg.result(wg.distances3D(x, y, z))
+}
+
+// Range2D returns the bounds of the values of Get2D().
+func (wg *WorleyGenerator) Range2D() (min float64, max float64) {
+	return wg.rangeOf(2.0)
+}
+
+// Range3D returns the bounds of the values of Get3D().
+func (wg *WorleyGenerator) Range3D() (min float64, max float64) {
+	return wg.rangeOf(3.0)
+}
+
+// rangeOf returns the bounds of the values in a number of dimensions. The
+// feature point of the cell of a coordinate is at most 0.5 + Jitter/2 away
+// along each axis, and the one of the neighbor on the near side along an axis
+// is at most 0.5 further along it; those bound F1 and F2.
+func (wg *WorleyGenerator) rangeOf(dims float64) (min float64, max float64) {
+	near := 0.5 + math.Abs(wg.Jitter)*0.5
+	d := math.Sqrt(dims) * near
+	if wg.Return == WorleyF2 || wg.Return == WorleyF2MinusF1 {
+		d = math.Sqrt((near+0.5)*(near+0.5) + (dims-1.0)*near*near)
+	}
+	return -1.0, d*2.0 - 1.0
+}